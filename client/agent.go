@@ -45,6 +45,8 @@ const (
 	AgentResponseTypeAnswer     AgentResponseType = "answer"
 	AgentResponseTypeReflection AgentResponseType = "reflection"
 	AgentResponseTypeError      AgentResponseType = "error"
+	// Agent stopped at max iterations; the answer that follows may be incomplete
+	AgentResponseTypeBudgetExceeded AgentResponseType = "budget_exceeded"
 )
 
 // AgentStreamResponse agent streaming response
@@ -26,40 +26,45 @@ type Agent struct {
 
 // AgentConfig represents the configuration for an agent
 type AgentConfig struct {
-	AgentMode                string   `json:"agent_mode"` // "quick-answer" or "smart-reasoning"
-	SystemPrompt             string   `json:"system_prompt,omitempty"`
-	ContextTemplate          string   `json:"context_template,omitempty"`
-	ModelID                  string   `json:"model_id,omitempty"`
-	RerankModelID            string   `json:"rerank_model_id,omitempty"`
-	Temperature              float64  `json:"temperature,omitempty"`
-	MaxCompletionTokens      int      `json:"max_completion_tokens,omitempty"`
-	MaxIterations            int      `json:"max_iterations,omitempty"`
-	AllowedTools             []string `json:"allowed_tools,omitempty"`
-	ReflectionEnabled        bool     `json:"reflection_enabled,omitempty"`
-	MCPSelectionMode         string   `json:"mcp_selection_mode,omitempty"` // "all", "selected", "none"
-	MCPServices              []string `json:"mcp_services,omitempty"`
-	KBSelectionMode          string   `json:"kb_selection_mode,omitempty"` // "all", "selected", "none"
-	KnowledgeBases           []string `json:"knowledge_bases,omitempty"`
-	SupportedFileTypes       []string `json:"supported_file_types,omitempty"`
-	FAQPriorityEnabled       bool     `json:"faq_priority_enabled,omitempty"`
-	FAQDirectAnswerThreshold float64  `json:"faq_direct_answer_threshold,omitempty"`
-	FAQScoreBoost            float64  `json:"faq_score_boost,omitempty"`
-	WebSearchEnabled         bool     `json:"web_search_enabled,omitempty"`
-	WebSearchMaxResults      int      `json:"web_search_max_results,omitempty"`
-	MultiTurnEnabled         bool     `json:"multi_turn_enabled,omitempty"`
-	HistoryTurns             int      `json:"history_turns,omitempty"`
-	EmbeddingTopK            int      `json:"embedding_top_k,omitempty"`
-	KeywordThreshold         float64  `json:"keyword_threshold,omitempty"`
-	VectorThreshold          float64  `json:"vector_threshold,omitempty"`
-	RerankTopK               int      `json:"rerank_top_k,omitempty"`
-	RerankThreshold          float64  `json:"rerank_threshold,omitempty"`
-	EnableQueryExpansion     bool     `json:"enable_query_expansion,omitempty"`
-	EnableRewrite            bool     `json:"enable_rewrite,omitempty"`
-	RewritePromptSystem      string   `json:"rewrite_prompt_system,omitempty"`
-	RewritePromptUser        string   `json:"rewrite_prompt_user,omitempty"`
-	FallbackStrategy         string   `json:"fallback_strategy,omitempty"` // "fixed" or "model"
-	FallbackResponse         string   `json:"fallback_response,omitempty"`
-	FallbackPrompt           string   `json:"fallback_prompt,omitempty"`
+	AgentMode                  string   `json:"agent_mode"` // "quick-answer" or "smart-reasoning"
+	SystemPrompt               string   `json:"system_prompt,omitempty"`
+	SystemPromptRef            string   `json:"system_prompt_ref,omitempty"` // Prompt template ID, takes precedence over SystemPrompt
+	ContextTemplate            string   `json:"context_template,omitempty"`
+	ModelID                    string   `json:"model_id,omitempty"`
+	RerankModelID              string   `json:"rerank_model_id,omitempty"`
+	Temperature                float64  `json:"temperature,omitempty"`
+	MaxCompletionTokens        int      `json:"max_completion_tokens,omitempty"`
+	MaxIterations              int      `json:"max_iterations,omitempty"`
+	AllowedTools               []string `json:"allowed_tools,omitempty"`
+	ReflectionEnabled          bool     `json:"reflection_enabled,omitempty"`
+	MCPSelectionMode           string   `json:"mcp_selection_mode,omitempty"` // "all", "selected", "none"
+	MCPServices                []string `json:"mcp_services,omitempty"`
+	KBSelectionMode            string   `json:"kb_selection_mode,omitempty"` // "all", "selected", "none"
+	KnowledgeBases             []string `json:"knowledge_bases,omitempty"`
+	SupportedFileTypes         []string `json:"supported_file_types,omitempty"`
+	FAQPriorityEnabled         bool     `json:"faq_priority_enabled,omitempty"`
+	FAQDirectAnswerThreshold   float64  `json:"faq_direct_answer_threshold,omitempty"`
+	FAQScoreBoost              float64  `json:"faq_score_boost,omitempty"`
+	FAQExclusiveAboveThreshold bool     `json:"faq_exclusive_above_threshold,omitempty"`
+	WebSearchEnabled           bool     `json:"web_search_enabled,omitempty"`
+	WebSearchMaxResults        int      `json:"web_search_max_results,omitempty"`
+	MultiTurnEnabled           bool     `json:"multi_turn_enabled,omitempty"`
+	HistoryTurns               int      `json:"history_turns,omitempty"`
+	EmbeddingTopK              int      `json:"embedding_top_k,omitempty"`
+	KeywordThreshold           float64  `json:"keyword_threshold,omitempty"`
+	VectorThreshold            float64  `json:"vector_threshold,omitempty"`
+	RerankTopK                 int      `json:"rerank_top_k,omitempty"`
+	RerankThreshold            float64  `json:"rerank_threshold,omitempty"`
+	ChunkContextWindow         int      `json:"chunk_context_window,omitempty"`
+	EnableQueryExpansion       bool     `json:"enable_query_expansion,omitempty"`
+	EnableRewrite              bool     `json:"enable_rewrite,omitempty"`
+	RewritePromptSystem        string   `json:"rewrite_prompt_system,omitempty"`
+	RewritePromptUser          string   `json:"rewrite_prompt_user,omitempty"`
+	FallbackStrategy           string   `json:"fallback_strategy,omitempty"` // "fixed" or "model"
+	FallbackResponse           string   `json:"fallback_response,omitempty"`
+	FallbackPrompt             string   `json:"fallback_prompt,omitempty"`
+	NoMatchPrefix              string   `json:"no_match_prefix,omitempty"`   // Overrides the global no-match prefix
+	CitationRequired           bool     `json:"citation_required,omitempty"` // Uncited answers get the fallback response
 }
 
 // CreateAgentRequest represents the request to create an agent
@@ -96,6 +101,85 @@ type AgentPlaceholdersResponse struct {
 	Data    map[string]json.RawMessage `json:"data"`
 }
 
+// EvaluateAgentRequest represents the request to evaluate an agent against a set of questions
+type EvaluateAgentRequest struct {
+	Questions []string `json:"questions"`
+}
+
+// CloneAgentRequest represents the request for cloning an agent
+type CloneAgentRequest struct {
+	Name string `json:"name,omitempty"`
+}
+
+// AgentCloneResult represents a cloned agent and warnings about references it cannot access
+type AgentCloneResult struct {
+	Agent    Agent    `json:"agent"`
+	Warnings []string `json:"warnings"`
+}
+
+// AgentCloneResponse represents the API response for cloning an agent
+type AgentCloneResponse struct {
+	Success bool             `json:"success"`
+	Data    AgentCloneResult `json:"data"`
+}
+
+// AgentResolvedKB represents one knowledge base in an agent's resolved scope
+type AgentResolvedKB struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	TenantID uint64 `json:"tenant_id"`
+	Missing  bool   `json:"missing,omitempty"`
+}
+
+// AgentResolvedKBs represents the knowledge bases an agent searches when the request mentions none
+type AgentResolvedKBs struct {
+	KBSelectionMode             string            `json:"kb_selection_mode"`
+	RetrieveKBOnlyWhenMentioned bool              `json:"retrieve_kb_only_when_mentioned"`
+	KnowledgeBases              []AgentResolvedKB `json:"knowledge_bases"`
+}
+
+// AgentEvaluationResult represents the outcome of one evaluation question
+type AgentEvaluationResult struct {
+	Question   string          `json:"question"`
+	Answer     string          `json:"answer"`
+	References []*SearchResult `json:"references"`
+	Error      string          `json:"error,omitempty"`
+	DurationMs int64           `json:"duration_ms"`
+}
+
+// CompareAgentsRequest represents the request to compare two agents on the same query
+type CompareAgentsRequest struct {
+	AgentIDA string `json:"agent_id_a"`
+	AgentIDB string `json:"agent_id_b"`
+	Query    string `json:"query"`
+}
+
+// AgentComparisonRun represents one side of an agent comparison
+type AgentComparisonRun struct {
+	AgentID   string `json:"agent_id"`
+	AgentName string `json:"agent_name"`
+	AgentEvaluationResult
+}
+
+// AgentComparisonResult represents the side-by-side outcome of an agent comparison
+type AgentComparisonResult struct {
+	Query  string              `json:"query"`
+	AgentA *AgentComparisonRun `json:"agent_a"`
+	AgentB *AgentComparisonRun `json:"agent_b"`
+}
+
+// AgentComparisonResponse represents the API response for an agent comparison
+type AgentComparisonResponse struct {
+	Success bool                   `json:"success"`
+	Data    *AgentComparisonResult `json:"data"`
+}
+
+// AgentEvaluationResponse represents the API response for an agent evaluation
+type AgentEvaluationResponse struct {
+	Success bool                     `json:"success"`
+	Data    []*AgentEvaluationResult `json:"data"`
+}
+
 // CreateAgent creates a new custom agent
 func (c *Client) CreateAgent(ctx context.Context, request *CreateAgentRequest) (*Agent, error) {
 	resp, err := c.doRequest(ctx, http.MethodPost, "/api/v1/agents", request, nil)
@@ -190,6 +274,88 @@ func (c *Client) CopyAgent(ctx context.Context, agentID string) (*Agent, error)
 	return &response.Data, nil
 }
 
+// PreviewResolvedKBs returns the knowledge bases the agent resolves to from its selection mode
+func (c *Client) PreviewResolvedKBs(ctx context.Context, agentID string) (*AgentResolvedKBs, error) {
+	path := fmt.Sprintf("/api/v1/agents/%s/resolved-kbs", agentID)
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool             `json:"success"`
+		Data    AgentResolvedKBs `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+
+	return &response.Data, nil
+}
+
+// CloneAgent creates a new agent from an owned or shared agent; an empty name uses the source name
+func (c *Client) CloneAgent(ctx context.Context, agentID string, name string) (*AgentCloneResult, error) {
+	path := fmt.Sprintf("/api/v1/agents/%s/clone", agentID)
+	resp, err := c.doRequest(ctx, http.MethodPost, path, &CloneAgentRequest{Name: name}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response AgentCloneResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+
+	return &response.Data, nil
+}
+
+// ResetAgentConfig resets an agent's configuration to defaults, keeping its name, mode and knowledge bases
+func (c *Client) ResetAgentConfig(ctx context.Context, agentID string) (*Agent, error) {
+	path := fmt.Sprintf("/api/v1/agents/%s/reset-config", agentID)
+	resp, err := c.doRequest(ctx, http.MethodPost, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response AgentResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+
+	return &response.Data, nil
+}
+
+// EvaluateAgent runs each question through the agent and returns the answers and references
+func (c *Client) EvaluateAgent(ctx context.Context, agentID string, questions []string) ([]*AgentEvaluationResult, error) {
+	path := fmt.Sprintf("/api/v1/agents/%s/evaluate", agentID)
+	resp, err := c.doRequest(ctx, http.MethodPost, path, &EvaluateAgentRequest{Questions: questions}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response AgentEvaluationResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+
+	return response.Data, nil
+}
+
+// CompareAgents runs the same query through two agents and returns both results side by side
+func (c *Client) CompareAgents(ctx context.Context, request *CompareAgentsRequest) (*AgentComparisonResult, error) {
+	resp, err := c.doRequest(ctx, http.MethodPost, "/api/v1/agents/compare", request, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response AgentComparisonResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+
+	return response.Data, nil
+}
+
 // GetAgentPlaceholders retrieves all available prompt placeholder definitions
 func (c *Client) GetAgentPlaceholders(ctx context.Context) (map[string]json.RawMessage, error) {
 	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/agents/placeholders", nil, nil)
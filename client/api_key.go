@@ -0,0 +1,113 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// APIKey represents an API key limited to a set of knowledge bases.
+type APIKey struct {
+	ID               string     `json:"id"`
+	TenantID         uint64     `json:"tenant_id"`
+	Name             string     `json:"name"`
+	KeyPrefix        string     `json:"key_prefix"`
+	KnowledgeBaseIDs []string   `json:"knowledge_base_ids"`
+	Permission       string     `json:"permission"`
+	UploadRateLimit  int        `json:"upload_rate_limit"`
+	DailyUploadQuota int        `json:"daily_upload_quota"`
+	CreatedBy        string     `json:"created_by"`
+	LastUsedAt       *time.Time `json:"last_used_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+	// Usage is returned by ListAPIKeys
+	Usage *APIKeyUsage `json:"usage,omitempty"`
+}
+
+// APIKeyUsage is the upload usage of an API key against its effective limits (0 = unlimited).
+type APIKeyUsage struct {
+	UploadsThisMinute int64     `json:"uploads_this_minute"`
+	UploadRateLimit   int       `json:"upload_rate_limit"`
+	UploadsToday      int64     `json:"uploads_today"`
+	DailyUploadQuota  int       `json:"daily_upload_quota"`
+	QuotaResetsAt     time.Time `json:"quota_resets_at"`
+}
+
+// CreatedAPIKey is returned when an API key is created.
+// Key is the plaintext key; it is only returned once.
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// CreateAPIKeyPayload is used to create an API key.
+// Permission is "viewer" (default) or "editor".
+// UploadRateLimit (per minute) and DailyUploadQuota default to the server configuration when 0.
+type CreateAPIKeyPayload struct {
+	Name             string   `json:"name"`
+	KnowledgeBaseIDs []string `json:"knowledge_base_ids"`
+	Permission       string   `json:"permission,omitempty"`
+	UploadRateLimit  int      `json:"upload_rate_limit,omitempty"`
+	DailyUploadQuota int      `json:"daily_upload_quota,omitempty"`
+}
+
+// CreateAPIKeyResponse wraps the create API key response.
+type CreateAPIKeyResponse struct {
+	Success bool           `json:"success"`
+	Data    *CreatedAPIKey `json:"data"`
+	Message string         `json:"message,omitempty"`
+	Code    string         `json:"code,omitempty"`
+}
+
+// APIKeysResponse wraps the list API keys response.
+type APIKeysResponse struct {
+	Success bool      `json:"success"`
+	Data    []*APIKey `json:"data"`
+	Message string    `json:"message,omitempty"`
+	Code    string    `json:"code,omitempty"`
+}
+
+// CreateAPIKey creates an API key that can only access the given knowledge bases.
+func (c *Client) CreateAPIKey(ctx context.Context, payload *CreateAPIKeyPayload) (*CreatedAPIKey, error) {
+	resp, err := c.doRequest(ctx, http.MethodPost, "/api/v1/api-keys", payload, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response CreateAPIKeyResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// ListAPIKeys returns the API keys of the current tenant and their upload usage, without their plaintext keys.
+func (c *Client) ListAPIKeys(ctx context.Context) ([]*APIKey, error) {
+	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/api-keys", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response APIKeysResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// DeleteAPIKey revokes an API key.
+func (c *Client) DeleteAPIKey(ctx context.Context, id string) error {
+	path := fmt.Sprintf("/api/v1/api-keys/%s", id)
+	resp, err := c.doRequest(ctx, http.MethodDelete, path, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		Success bool   `json:"success"`
+		Message string `json:"message,omitempty"`
+	}
+
+	return parseResponse(resp, &response)
+}
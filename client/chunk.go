@@ -102,6 +102,41 @@ func (c *Client) ListKnowledgeChunks(ctx context.Context,
 	return response.Data, response.Total, nil
 }
 
+// ListRetrievableChunks lists every indexed chunk of a knowledge document
+// Unlike ListKnowledgeChunks it also returns image, table and FAQ chunks, which helps
+// inspect how a document was chunked and embedded
+// Parameters:
+//   - ctx: Context
+//   - knowledgeID: Knowledge ID
+//   - page: Page number, starts from 1
+//   - pageSize: Number of items per page
+//
+// Returns:
+//   - []Chunk: List of chunks
+//   - int64: Total count
+//   - error: Error information
+func (c *Client) ListRetrievableChunks(ctx context.Context,
+	knowledgeID string, page int, pageSize int,
+) ([]Chunk, int64, error) {
+	path := fmt.Sprintf("/api/v1/knowledge/%s/chunks", knowledgeID)
+
+	queryParams := url.Values{}
+	queryParams.Add("page", strconv.Itoa(page))
+	queryParams.Add("page_size", strconv.Itoa(pageSize))
+
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, queryParams)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var response ChunkListResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, 0, err
+	}
+
+	return response.Data, response.Total, nil
+}
+
 // UpdateChunk updates a chunk's information
 // Updates information for a specific chunk under a knowledge document
 // Parameters:
@@ -130,6 +165,35 @@ func (c *Client) UpdateChunk(ctx context.Context,
 	return &response.Data, nil
 }
 
+// UpdateChunkText corrects a chunk's text and re-embeds it
+// FAQ chunks cannot be edited this way; update their FAQ entry instead
+// Parameters:
+//   - ctx: Context
+//   - knowledgeID: Knowledge ID
+//   - chunkID: Chunk ID
+//   - content: New chunk text
+//
+// Returns:
+//   - *Chunk: Updated chunk
+//   - error: Error information
+func (c *Client) UpdateChunkText(ctx context.Context,
+	knowledgeID string, chunkID string, content string,
+) (*Chunk, error) {
+	path := fmt.Sprintf("/api/v1/knowledge/%s/chunks/%s", knowledgeID, chunkID)
+	request := map[string]string{"content": content}
+	resp, err := c.doRequest(ctx, http.MethodPut, path, request, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response ChunkResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+
+	return &response.Data, nil
+}
+
 // DeleteChunk deletes a specific chunk
 // Deletes a specific chunk under a knowledge document
 // Parameters:
@@ -154,6 +218,30 @@ func (c *Client) DeleteChunk(ctx context.Context, knowledgeID string, chunkID st
 	return parseResponse(resp, &response)
 }
 
+// DeleteKnowledgeChunk deletes a junk chunk from a knowledge document along with its embeddings
+// The document stays listed even if its last chunk is deleted
+// Parameters:
+//   - ctx: Context
+//   - knowledgeID: Knowledge ID
+//   - chunkID: Chunk ID
+//
+// Returns:
+//   - error: Error information
+func (c *Client) DeleteKnowledgeChunk(ctx context.Context, knowledgeID string, chunkID string) error {
+	path := fmt.Sprintf("/api/v1/knowledge/%s/chunks/%s", knowledgeID, chunkID)
+	resp, err := c.doRequest(ctx, http.MethodDelete, path, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		Success bool   `json:"success"`
+		Message string `json:"message,omitempty"`
+	}
+
+	return parseResponse(resp, &response)
+}
+
 // GetChunkByIDOnly retrieves a chunk by its ID without requiring knowledge ID
 func (c *Client) GetChunkByIDOnly(ctx context.Context, chunkID string) (*Chunk, error) {
 	path := fmt.Sprintf("/api/v1/chunks/get-by-id/%s", chunkID)
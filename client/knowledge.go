@@ -39,11 +39,14 @@ type Knowledge struct {
 	FileHash         string          `json:"file_hash"`
 	FilePath         string          `json:"file_path"`
 	StorageSize      int64           `json:"storage_size"`
-	Metadata         json.RawMessage `json:"metadata"` // Extensible metadata for storing machine information, paths, etc.
+	Metadata         json.RawMessage `json:"metadata"`        // Extensible metadata for storing machine information, paths, etc.
+	RetrievalBoost   float64         `json:"retrieval_boost"` // Score multiplier for this document's chunks in retrieval (1.0 = no change)
 	CreatedAt        time.Time       `json:"created_at"`
 	UpdatedAt        time.Time       `json:"updated_at"`
 	ProcessedAt      *time.Time      `json:"processed_at"`
 	ErrorMessage     string          `json:"error_message"`
+	// KnowledgeBaseName is set by ListAllKnowledge
+	KnowledgeBaseName string `json:"knowledge_base_name,omitempty"`
 }
 
 // KnowledgeResponse represents the API response containing a single knowledge entry
@@ -81,6 +84,45 @@ var ErrDuplicateFile = errors.New("file already exists")
 // ErrDuplicateURL is returned when attempting to create a knowledge entry with a URL that already exists
 var ErrDuplicateURL = errors.New("URL already exists")
 
+// FileUploadCheckRequest describes a file to check before uploading it
+type FileUploadCheckRequest struct {
+	FileName string `json:"file_name"`
+	FileSize int64  `json:"file_size,omitempty"`
+	MimeType string `json:"mime_type,omitempty"`
+	// Optional first bytes of the file, used to detect its content type (at most 64KB)
+	Head []byte `json:"head,omitempty"`
+}
+
+// FileUploadCheckResult reports whether a file would be accepted by CreateKnowledgeFromFile
+type FileUploadCheckResult struct {
+	Supported        bool     `json:"supported"`
+	FileType         string   `json:"file_type"`
+	DetectedMimeType string   `json:"detected_mime_type,omitempty"`
+	MaxFileSize      int64    `json:"max_file_size"`
+	ExceedsMaxSize   bool     `json:"exceeds_max_size"`
+	Errors           []string `json:"errors"`
+}
+
+// ValidateFileUpload checks whether a file can be uploaded to a knowledge base without uploading it
+func (c *Client) ValidateFileUpload(ctx context.Context,
+	knowledgeBaseID string, req *FileUploadCheckRequest,
+) (*FileUploadCheckResult, error) {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/knowledge/file/validate", knowledgeBaseID)
+	resp, err := c.doRequest(ctx, http.MethodPost, path, req, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Success bool                   `json:"success"`
+		Data    *FileUploadCheckResult `json:"data"`
+	}
+	if err := parseResponse(resp, &result); err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
+
 // CreateKnowledgeFromFile creates a knowledge entry from a local file path
 // Parameters:
 //   - knowledgeBaseID: The ID of the knowledge base
@@ -303,6 +345,72 @@ func (c *Client) ListKnowledge(ctx context.Context,
 	return response.Data, response.Total, nil
 }
 
+// KnowledgeListFilter narrows ListAllKnowledge; empty fields do not filter.
+// FileType is a file type such as "pdf", or "manual" / "url" for manual and web page knowledge.
+// Keyword matches the file name or title.
+type KnowledgeListFilter struct {
+	KnowledgeBaseID string
+	TagID           string
+	FileType        string
+	Keyword         string
+}
+
+// ListAllKnowledge lists knowledge across all document knowledge bases of the tenant with pagination,
+// newest first. Each entry carries the name of its knowledge base.
+func (c *Client) ListAllKnowledge(ctx context.Context,
+	page int,
+	pageSize int,
+	filter *KnowledgeListFilter,
+) ([]Knowledge, int64, error) {
+	queryParams := url.Values{}
+	queryParams.Add("page", strconv.Itoa(page))
+	queryParams.Add("page_size", strconv.Itoa(pageSize))
+	if filter != nil {
+		for key, value := range map[string]string{
+			"knowledge_base_id": filter.KnowledgeBaseID,
+			"tag_id":            filter.TagID,
+			"file_type":         filter.FileType,
+			"keyword":           filter.Keyword,
+		} {
+			if value != "" {
+				queryParams.Add(key, value)
+			}
+		}
+	}
+
+	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/knowledge", nil, queryParams)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var response KnowledgeListResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, 0, err
+	}
+
+	return response.Data, response.Total, nil
+}
+
+// GetKnowledgeByFilename returns the knowledge in a knowledge base uploaded with the given original
+// file name, most recent first. The list is empty if no document has that name.
+func (c *Client) GetKnowledgeByFilename(ctx context.Context, knowledgeBaseID string, fileName string) ([]Knowledge, error) {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/knowledge/by-filename", knowledgeBaseID)
+	queryParams := url.Values{}
+	queryParams.Add("name", fileName)
+
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, queryParams)
+	if err != nil {
+		return nil, err
+	}
+
+	var response KnowledgeListResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+
+	return response.Data, nil
+}
+
 // DeleteKnowledge deletes a knowledge entry by its ID
 func (c *Client) DeleteKnowledge(ctx context.Context, knowledgeID string) error {
 	path := fmt.Sprintf("/api/v1/knowledge/%s", knowledgeID)
@@ -405,6 +513,28 @@ func (c *Client) ReparseKnowledge(ctx context.Context, knowledgeID string) (*Kno
 	return &response.Data, nil
 }
 
+// RetryKnowledgeProcessing re-runs the processing of a knowledge entry whose parsing or embedding failed
+// The stored original file is reused, so there is no need to delete and upload it again.
+// The server rejects the request if the knowledge is not in the "failed" state.
+func (c *Client) RetryKnowledgeProcessing(ctx context.Context, knowledgeID string) (*Knowledge, error) {
+	if knowledgeID == "" {
+		return nil, fmt.Errorf("knowledge ID cannot be empty")
+	}
+
+	path := fmt.Sprintf("/api/v1/knowledge/%s/retry", knowledgeID)
+	resp, err := c.doRequest(ctx, http.MethodPost, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response KnowledgeResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+
+	return &response.Data, nil
+}
+
 // UpdateChunk updates a chunk's information
 // Updates information for a specific chunk under a knowledge document
 // Parameters:
@@ -606,3 +736,52 @@ func (c *Client) BatchUpdateKnowledgeTags(ctx context.Context, updates map[strin
 
 	return parseResponse(resp, &batchResponse)
 }
+
+// MentionCandidate is a knowledge base or document that can be @mentioned in a QA request
+type MentionCandidate struct {
+	MentionedItem
+	KnowledgeBaseID string `json:"knowledge_base_id,omitempty"` // parent knowledge base (documents only)
+	Shared          bool   `json:"shared"`
+}
+
+// MentionResolveResult holds the knowledge bases and documents matching a mention
+type MentionResolveResult struct {
+	KnowledgeBases []*MentionCandidate `json:"knowledge_bases"`
+	Documents      []*MentionCandidate `json:"documents"`
+}
+
+// ResolveMentions resolves an @name mention to the knowledge bases and documents the user can access.
+// mentionType is "kb", "doc" or empty for both; agentID optionally scopes the lookup to a shared agent.
+func (c *Client) ResolveMentions(
+	ctx context.Context,
+	query string,
+	mentionType string,
+	agentID string,
+	limit int,
+) (*MentionResolveResult, error) {
+	queryParams := url.Values{}
+	queryParams.Add("q", query)
+	if mentionType != "" {
+		queryParams.Add("type", mentionType)
+	}
+	if agentID != "" {
+		queryParams.Add("agent_id", agentID)
+	}
+	if limit > 0 {
+		queryParams.Add("limit", strconv.Itoa(limit))
+	}
+
+	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/mentions/resolve", nil, queryParams)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool                  `json:"success"`
+		Data    *MentionResolveResult `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
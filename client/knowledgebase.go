@@ -29,6 +29,9 @@ type KnowledgeBase struct {
 	StorageProviderConfig *StorageProviderConfig `json:"storage_provider_config"`
 	StorageConfig         StorageConfig          `json:"storage_config"`
 	ExtractConfig         *ExtractConfig         `json:"extract_config"`
+	DuplicateScope        string                 `json:"duplicate_scope"` // "kb" (default) or "tenant"
+	FallbackResponse      string                 `json:"fallback_response"`
+	ShareLabel            string                 `json:"share_label"` // Matched by organization dynamic share rules
 	CreatedAt             time.Time              `json:"created_at"`
 	UpdatedAt             time.Time              `json:"updated_at"`
 	// Computed fields (not stored in database)
@@ -43,6 +46,13 @@ type KnowledgeBaseConfig struct {
 	ChunkingConfig        ChunkingConfig        `json:"chunking_config"`
 	ImageProcessingConfig ImageProcessingConfig `json:"image_processing_config"`
 	FAQConfig             *FAQConfig            `json:"faq_config"`
+	DuplicateScope        string                `json:"duplicate_scope,omitempty"` // Empty keeps the current scope
+	// FallbackResponse is answered when nothing relevant is found; nil keeps the current value, empty clears it
+	FallbackResponse *string `json:"fallback_response,omitempty"`
+	// EmbeddingModelID switches the embedding model; empty keeps the current model
+	EmbeddingModelID string `json:"embedding_model_id,omitempty"`
+	// Reindex re-processes all knowledge with the new embedding model, required when its dimension differs
+	Reindex bool `json:"reindex,omitempty"`
 }
 
 // ChunkingConfig represents document chunking configuration
@@ -50,6 +60,10 @@ type ChunkingConfig struct {
 	ChunkSize    int      `json:"chunk_size"`    // Chunk size
 	ChunkOverlap int      `json:"chunk_overlap"` // Overlap size
 	Separators   []string `json:"separators"`    // Separators
+	// Whether to run OCR on scanned pages and images; nil keeps it on
+	OCREnabled *bool `json:"ocr_enabled,omitempty"`
+	// Languages of the document text (e.g. "ja", "en"), primary language first
+	OCRLanguages []string `json:"ocr_languages,omitempty"`
 }
 
 // FAQConfig represents faq-specific configuration
@@ -157,6 +171,26 @@ type SearchResult struct {
 	// MatchedContent is the actual content that was matched in vector search
 	// For FAQ: this is the matched question text (standard or similar question)
 	MatchedContent string `json:"matched_content,omitempty"`
+	// KnowledgeBaseID is the ID of the knowledge base this result belongs to
+	KnowledgeBaseID string `json:"knowledge_base_id,omitempty"`
+	// Score breakdown (informational; ranking uses Score)
+	VectorScore      *float64 `json:"vector_score,omitempty"`
+	KeywordScore     *float64 `json:"keyword_score,omitempty"`
+	RerankScore      *float64 `json:"rerank_score,omitempty"`
+	ThresholdApplied *float64 `json:"threshold_applied,omitempty"`
+	// Document holds document-level fields for citations, only set when include_metadata is requested
+	Document *SearchResultDocument `json:"document,omitempty"`
+}
+
+// SearchResultDocument holds the core fields and selected metadata of a search result's knowledge
+type SearchResultDocument struct {
+	Title     string            `json:"title"`
+	FileName  string            `json:"file_name,omitempty"`
+	FileType  string            `json:"file_type,omitempty"`
+	Source    string            `json:"source,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
 }
 
 // HybridSearchResponse hybrid search response
@@ -184,7 +218,7 @@ type KBCloneProgress struct {
 	TaskID    string `json:"task_id"`
 	SourceID  string `json:"source_id"`
 	TargetID  string `json:"target_id"`
-	Status    string `json:"status"`    // pending, processing, completed, failed
+	Status    string `json:"status"`    // pending, processing, completed, completed_with_errors, failed
 	Progress  int    `json:"progress"`  // 0-100
 	Total     int    `json:"total"`     // Total operations count
 	Processed int    `json:"processed"` // Processed operations count
@@ -192,6 +226,28 @@ type KBCloneProgress struct {
 	Error     string `json:"error,omitempty"`
 	CreatedAt int64  `json:"created_at"`
 	UpdatedAt int64  `json:"updated_at"`
+	// FailedItems lists the source knowledge that could not be cloned
+	FailedItems []KBCloneFailedItem `json:"failed_items,omitempty"`
+}
+
+// KBKeywordIndexProgress represents the progress of a knowledge base keyword index rebuild
+type KBKeywordIndexProgress struct {
+	KnowledgeBaseID string `json:"knowledge_base_id"`
+	Status          string `json:"status"`    // pending, processing, completed, failed
+	Progress        int    `json:"progress"`  // 0-100
+	Total           int    `json:"total"`     // Total knowledge count
+	Processed       int    `json:"processed"` // Processed knowledge count
+	Message         string `json:"message"`
+	Error           string `json:"error,omitempty"`
+	CreatedAt       int64  `json:"created_at"`
+	UpdatedAt       int64  `json:"updated_at"`
+}
+
+// KBCloneFailedItem is a source knowledge that could not be cloned
+type KBCloneFailedItem struct {
+	KnowledgeID string `json:"knowledge_id"`
+	Title       string `json:"title"`
+	Error       string `json:"error"`
 }
 
 // CreateKnowledgeBase creates a knowledge base
@@ -225,6 +281,40 @@ func (c *Client) GetKnowledgeBase(ctx context.Context, knowledgeBaseID string) (
 	return &response.Data, nil
 }
 
+// KnowledgeBaseStats holds aggregate statistics of a knowledge base
+type KnowledgeBaseStats struct {
+	KnowledgeBaseID     string           `json:"knowledge_base_id"`
+	KnowledgeCount      int64            `json:"knowledge_count"`
+	TotalFileSize       int64            `json:"total_file_size"`
+	FileTypes           map[string]int64 `json:"file_types"`
+	ParseStatuses       map[string]int64 `json:"parse_statuses"`
+	ChunkCount          int64            `json:"chunk_count"`
+	AvgChunkSize        float64          `json:"avg_chunk_size"`
+	IndexableChunkCount int64            `json:"indexable_chunk_count"`
+	IndexedChunkCount   int64            `json:"indexed_chunk_count"`
+	EmbeddingCoverage   float64          `json:"embedding_coverage"`
+	LastUpdatedAt       time.Time        `json:"last_updated_at"`
+}
+
+// GetKnowledgeBaseStats gets aggregate statistics of a knowledge base
+func (c *Client) GetKnowledgeBaseStats(ctx context.Context, knowledgeBaseID string) (*KnowledgeBaseStats, error) {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/stats", knowledgeBaseID)
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool                `json:"success"`
+		Data    *KnowledgeBaseStats `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+
+	return response.Data, nil
+}
+
 // ListKnowledgeBases lists knowledge bases
 func (c *Client) ListKnowledgeBases(ctx context.Context) ([]KnowledgeBase, error) {
 	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/knowledge-bases", nil, nil)
@@ -290,6 +380,8 @@ type SearchParams struct {
 	MatchCount           int     `json:"match_count"`
 	DisableKeywordsMatch bool    `json:"disable_keywords_match"`
 	DisableVectorMatch   bool    `json:"disable_vector_match"`
+	// Knowledge metadata keys returned in each result's document ("*" for all)
+	IncludeMetadata []string `json:"include_metadata,omitempty"`
 }
 
 // HybridSearch performs hybrid search
@@ -327,7 +419,23 @@ func (c *Client) TogglePinKnowledgeBase(ctx context.Context, knowledgeBaseID str
 	return &response.Data, nil
 }
 
-// MoveTarget represents a knowledge base that can receive moved knowledge
+// SetShareLabel sets the label matched by organization dynamic share rules on a knowledge base of
+// the caller's tenant; an empty label clears it
+func (c *Client) SetShareLabel(ctx context.Context, knowledgeBaseID string, label string) (*KnowledgeBase, error) {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/share-label", knowledgeBaseID)
+	resp, err := c.doRequest(ctx, http.MethodPut, path, map[string]string{"share_label": label}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response KnowledgeBaseResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+
+	return &response.Data, nil
+}
+
 type MoveTarget struct {
 	ID          string `json:"id"`
 	Name        string `json:"name"`
@@ -372,6 +480,28 @@ func (c *Client) CopyKnowledgeBase(ctx context.Context, request *CopyKnowledgeBa
 	return &response.Data, nil
 }
 
+// RetryKBCloneFailedItems starts a new clone task that copies only the failed items of a
+// task completed with errors
+func (c *Client) RetryKBCloneFailedItems(ctx context.Context, taskID string) (*CopyKnowledgeBaseResponse, error) {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/copy/%s/retry", taskID)
+
+	resp, err := c.doRequest(ctx, http.MethodPost, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool                      `json:"success"`
+		Data    CopyKnowledgeBaseResponse `json:"data"`
+	}
+
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+
+	return &response.Data, nil
+}
+
 // GetKBCloneProgress gets the progress of a knowledge base clone task
 func (c *Client) GetKBCloneProgress(ctx context.Context, taskID string) (*KBCloneProgress, error) {
 	path := fmt.Sprintf("/api/v1/knowledge-bases/copy/progress/%s", taskID)
@@ -392,3 +522,46 @@ func (c *Client) GetKBCloneProgress(ctx context.Context, taskID string) (*KBClon
 
 	return &response.Data, nil
 }
+
+// RebuildKeywordIndex starts an async rebuild of the keyword index of a knowledge base,
+// without re-embedding its chunks. Only the owner of the knowledge base may call it
+func (c *Client) RebuildKeywordIndex(ctx context.Context, knowledgeBaseID string) (*KBKeywordIndexProgress, error) {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/rebuild-index", knowledgeBaseID)
+
+	resp, err := c.doRequest(ctx, http.MethodPost, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool                   `json:"success"`
+		Data    KBKeywordIndexProgress `json:"data"`
+	}
+
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+
+	return &response.Data, nil
+}
+
+// GetKeywordIndexProgress gets the progress of the latest keyword index rebuild of a knowledge base
+func (c *Client) GetKeywordIndexProgress(ctx context.Context, knowledgeBaseID string) (*KBKeywordIndexProgress, error) {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/rebuild-index", knowledgeBaseID)
+
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool                   `json:"success"`
+		Data    KBKeywordIndexProgress `json:"data"`
+	}
+
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+
+	return &response.Data, nil
+}
@@ -187,3 +187,56 @@ func (c *Client) DeleteMessage(ctx context.Context, sessionID string, messageID
 
 	return parseResponse(resp, &response)
 }
+
+// GetMessageReferences gets the knowledge references persisted with an assistant message
+func (c *Client) GetMessageReferences(ctx context.Context, sessionID string, messageID string) ([]*SearchResult, error) {
+	path := fmt.Sprintf("/api/v1/sessions/%s/messages/%s/references", sessionID, messageID)
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool            `json:"success"`
+		Data    []*SearchResult `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// SessionMessageMatch is a message of a session matching a keyword search
+type SessionMessageMatch struct {
+	ID        string    `json:"id"`
+	RequestID string    `json:"request_id"`
+	Role      string    `json:"role"`
+	Snippet   string    `json:"snippet"` // Text around the first occurrence of the keyword
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SearchSessionMessages searches the messages of a session by keyword (case-insensitive), oldest first.
+// limit <= 0 uses the server default.
+func (c *Client) SearchSessionMessages(
+	ctx context.Context, sessionID string, keyword string, limit int,
+) ([]SessionMessageMatch, error) {
+	path := fmt.Sprintf("/api/v1/sessions/%s/messages/search", sessionID)
+	queryParams := url.Values{}
+	queryParams.Add("q", keyword)
+	if limit > 0 {
+		queryParams.Add("limit", strconv.Itoa(limit))
+	}
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, queryParams)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool                  `json:"success"`
+		Data    []SessionMessageMatch `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
@@ -123,6 +123,46 @@ func (c *Client) ListModels(ctx context.Context) ([]Model, error) {
 	return response.Data, nil
 }
 
+// ModelCatalogEntry summarizes a model for model selectors
+type ModelCatalogEntry struct {
+	ID              string      `json:"id"`
+	Name            string      `json:"name"`
+	Type            ModelType   `json:"type"`
+	Source          ModelSource `json:"source"`
+	Deployment      string      `json:"deployment"` // "local" or "remote"
+	Status          string      `json:"status"`
+	IsDefault       bool        `json:"is_default"`
+	IsBuiltin       bool        `json:"is_builtin"`
+	Available       bool        `json:"available"`
+	TitleGeneration bool        `json:"title_generation"`
+}
+
+// ModelCatalog groups the tenant's models by purpose
+type ModelCatalog struct {
+	Chat      []ModelCatalogEntry `json:"chat"`
+	Embedding []ModelCatalogEntry `json:"embedding"`
+	Rerank    []ModelCatalogEntry `json:"rerank"`
+	VLM       []ModelCatalogEntry `json:"vlm"`
+}
+
+// ListModelsGrouped lists all models grouped into chat, embedding, rerank and VLM buckets
+func (c *Client) ListModelsGrouped(ctx context.Context) (*ModelCatalog, error) {
+	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/models/grouped", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool         `json:"success"`
+		Data    ModelCatalog `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+
+	return &response.Data, nil
+}
+
 // UpdateModel updates a model
 func (c *Client) UpdateModel(ctx context.Context, modelID string, request *UpdateModelRequest) (*Model, error) {
 	path := fmt.Sprintf("/api/v1/models/%s", modelID)
@@ -191,3 +231,48 @@ func (c *Client) ListModelProviders(ctx context.Context, modelType string) ([]Mo
 
 	return response.Data, nil
 }
+
+// ModelWarmupResult is the outcome of warming up a model
+type ModelWarmupResult struct {
+	ModelID   string    `json:"model_id"`
+	Name      string    `json:"name"`
+	Type      ModelType `json:"type"`
+	Success   bool      `json:"success"`
+	LatencyMs int64     `json:"latency_ms"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// WarmupModel sends a minimal request to a model so it is loaded before real traffic
+func (c *Client) WarmupModel(ctx context.Context, modelID string) (*ModelWarmupResult, error) {
+	path := fmt.Sprintf("/api/v1/models/%s/warmup", modelID)
+	resp, err := c.doRequest(ctx, http.MethodPost, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool               `json:"success"`
+		Data    *ModelWarmupResult `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// WarmupModels warms up all models of the tenant
+func (c *Client) WarmupModels(ctx context.Context) ([]*ModelWarmupResult, error) {
+	resp, err := c.doRequest(ctx, http.MethodPost, "/api/v1/models/warmup", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool                 `json:"success"`
+		Data    []*ModelWarmupResult `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
@@ -1,10 +1,16 @@
 package client
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/url"
+	"path/filepath"
+	"strconv"
 	"time"
 )
 
@@ -21,6 +27,9 @@ type Organization struct {
 	RequireApproval        bool       `json:"require_approval"`
 	Searchable             bool       `json:"searchable"`
 	MemberLimit            int        `json:"member_limit"`
+	DefaultSharePermission string     `json:"default_share_permission"`
+	JoinRequestTTLDays     int        `json:"join_request_ttl_days"`
+	StorageQuota           int64      `json:"storage_quota"`
 	CreatedAt              time.Time  `json:"created_at"`
 	UpdatedAt              time.Time  `json:"updated_at"`
 }
@@ -38,6 +47,9 @@ type OrganizationResponse struct {
 	RequireApproval         bool       `json:"require_approval"`
 	Searchable              bool       `json:"searchable"`
 	MemberLimit             int        `json:"member_limit"`
+	DefaultSharePermission  string     `json:"default_share_permission"`
+	JoinRequestTTLDays      int        `json:"join_request_ttl_days"`
+	StorageQuota            int64      `json:"storage_quota"`
 	MemberCount             int        `json:"member_count"`
 	ShareCount              int        `json:"share_count"`
 	AgentShareCount         int        `json:"agent_share_count"`
@@ -56,6 +68,8 @@ type CreateOrganizationRequest struct {
 	Avatar                 string `json:"avatar,omitempty"`
 	InviteCodeValidityDays *int   `json:"invite_code_validity_days,omitempty"`
 	MemberLimit            *int   `json:"member_limit,omitempty"`
+	DefaultSharePermission string `json:"default_share_permission,omitempty"`
+	JoinRequestTTLDays     *int   `json:"join_request_ttl_days,omitempty"`
 }
 
 // UpdateOrganizationRequest represents a request to update an organization
@@ -67,18 +81,22 @@ type UpdateOrganizationRequest struct {
 	Searchable             *bool   `json:"searchable,omitempty"`
 	InviteCodeValidityDays *int    `json:"invite_code_validity_days,omitempty"`
 	MemberLimit            *int    `json:"member_limit,omitempty"`
+	DefaultSharePermission *string `json:"default_share_permission,omitempty"`
+	JoinRequestTTLDays     *int    `json:"join_request_ttl_days,omitempty"`
+	StorageQuota           *int64  `json:"storage_quota,omitempty"`
 }
 
 // OrganizationMemberResponse represents a member in API responses
 type OrganizationMemberResponse struct {
-	ID       string    `json:"id"`
-	UserID   string    `json:"user_id"`
-	Username string    `json:"username"`
-	Email    string    `json:"email"`
-	Avatar   string    `json:"avatar"`
-	Role     string    `json:"role"`
-	TenantID uint64    `json:"tenant_id"`
-	JoinedAt time.Time `json:"joined_at"`
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	Avatar    string    `json:"avatar"`
+	Role      string    `json:"role"`
+	Suspended bool      `json:"suspended"`
+	TenantID  uint64    `json:"tenant_id"`
+	JoinedAt  time.Time `json:"joined_at"`
 }
 
 // KnowledgeBaseShareResponse represents a KB share record in API responses
@@ -95,6 +113,25 @@ type KnowledgeBaseShareResponse struct {
 	MyRoleInOrg       string    `json:"my_role_in_org"`
 	MyPermission      string    `json:"my_permission"`
 	CreatedAt         time.Time `json:"created_at"`
+	DynamicShareID    string    `json:"dynamic_share_id,omitempty"`
+	KnowledgeIDs      []string  `json:"knowledge_ids,omitempty"`
+}
+
+// KBShareCriteria selects the knowledge bases covered by a dynamic share rule
+type KBShareCriteria struct {
+	Label string `json:"label"` // Matched against the share label of the knowledge bases
+}
+
+// KnowledgeBaseDynamicShare is a rule sharing every knowledge base matching its criteria to an organization
+type KnowledgeBaseDynamicShare struct {
+	ID              string          `json:"id"`
+	OrganizationID  string          `json:"organization_id"`
+	CreatedByUserID string          `json:"created_by_user_id"`
+	SourceTenantID  uint64          `json:"source_tenant_id"`
+	Criteria        KBShareCriteria `json:"criteria"`
+	Permission      string          `json:"permission"`
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
 }
 
 // AgentShareResponse represents an agent share record in API responses
@@ -126,6 +163,16 @@ type JoinRequestResponse struct {
 	ReviewedAt    *time.Time `json:"reviewed_at,omitempty"`
 }
 
+// UserNotification is a notification of the current user. For the "org_request_reviewed" type,
+// Data holds the organization, request type, review status, assigned role and review message.
+type UserNotification struct {
+	ID        string          `json:"id"`
+	UserID    string          `json:"user_id"`
+	Type      string          `json:"type"`
+	Data      json.RawMessage `json:"data"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
 // SharedKnowledgeBaseInfo represents a shared knowledge base
 type SharedKnowledgeBaseInfo struct {
 	ShareID        string    `json:"share_id"`
@@ -157,6 +204,9 @@ type UserInfo struct {
 	CanAccessAllTenants bool      `json:"can_access_all_tenants"`
 	CreatedAt           time.Time `json:"created_at"`
 	UpdatedAt           time.Time `json:"updated_at"`
+	// Only set on the current user's profile; MaxOwnedOrganizations is 0 when unlimited
+	OwnedOrganizationCount *int64 `json:"owned_organization_count,omitempty"`
+	MaxOwnedOrganizations  *int   `json:"max_owned_organizations,omitempty"`
 }
 
 // --- Organization CRUD ---
@@ -211,6 +261,32 @@ func (c *Client) GetOrganization(ctx context.Context, orgID string) (*Organizati
 	return result.Data, nil
 }
 
+// OrgStorageUsage is the storage used by the knowledge bases shared to an organization
+type OrgStorageUsage struct {
+	OrganizationID     string    `json:"organization_id"`
+	UsedBytes          int64     `json:"used_bytes"`
+	QuotaBytes         int64     `json:"quota_bytes"` // 0 = unlimited
+	Exceeded           bool      `json:"exceeded"`
+	KnowledgeBaseCount int       `json:"knowledge_base_count"`
+	ComputedAt         time.Time `json:"computed_at"`
+}
+
+// GetOrganizationStorage gets the storage used by the knowledge bases shared to an organization
+func (c *Client) GetOrganizationStorage(ctx context.Context, orgID string) (*OrgStorageUsage, error) {
+	resp, err := c.doRequest(ctx, http.MethodGet, fmt.Sprintf("/api/v1/organizations/%s/storage", orgID), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Success bool             `json:"success"`
+		Data    *OrgStorageUsage `json:"data"`
+	}
+	if err := parseResponse(resp, &result); err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
+
 // UpdateOrganization updates an organization
 func (c *Client) UpdateOrganization(ctx context.Context, orgID string, req *UpdateOrganizationRequest) (*OrganizationResponse, error) {
 	resp, err := c.doRequest(ctx, http.MethodPut, fmt.Sprintf("/api/v1/organizations/%s", orgID), req, nil)
@@ -227,6 +303,51 @@ func (c *Client) UpdateOrganization(ctx context.Context, orgID string, req *Upda
 	return result.Data, nil
 }
 
+// UploadOrganizationAvatar uploads an avatar image for an organization (admin only).
+// Accepts PNG, JPEG, GIF or WebP images of at most 2 MB.
+func (c *Client) UploadOrganizationAvatar(ctx context.Context,
+	orgID string, fileName string, image io.Reader,
+) (*OrganizationResponse, error) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", filepath.Base(fileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create form file: %w", err)
+	}
+	if _, err := io.Copy(part, image); err != nil {
+		return nil, fmt.Errorf("failed to copy image content: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close writer: %w", err)
+	}
+
+	path := fmt.Sprintf("/api/v1/organizations/%s/avatar", orgID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	if c.token != "" {
+		req.Header.Set("X-API-Key", c.token)
+	}
+	if requestID := ctx.Value("RequestID"); requestID != nil {
+		req.Header.Set("X-Request-ID", requestID.(string))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	var result struct {
+		Success bool                  `json:"success"`
+		Data    *OrganizationResponse `json:"data"`
+	}
+	if err := parseResponse(resp, &result); err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
+
 // DeleteOrganization deletes an organization
 func (c *Client) DeleteOrganization(ctx context.Context, orgID string) error {
 	resp, err := c.doRequest(ctx, http.MethodDelete, fmt.Sprintf("/api/v1/organizations/%s", orgID), nil, nil)
@@ -262,6 +383,33 @@ func (c *Client) SubmitJoinRequest(ctx context.Context, inviteCode, message, rol
 	return parseResponse(resp, nil)
 }
 
+// CancelRequest withdraws the current user's pending join or upgrade request
+func (c *Client) CancelRequest(ctx context.Context, requestID string) error {
+	resp, err := c.doRequest(ctx, http.MethodDelete, fmt.Sprintf("/api/v1/organizations/requests/%s", requestID), nil, nil)
+	if err != nil {
+		return err
+	}
+	return parseResponse(resp, nil)
+}
+
+// AcceptInvite joins the organization of an invite sent to the current user's email before they signed up
+func (c *Client) AcceptInvite(ctx context.Context, inviteID string) error {
+	resp, err := c.doRequest(ctx, http.MethodPost, fmt.Sprintf("/api/v1/organizations/invites/%s/accept", inviteID), nil, nil)
+	if err != nil {
+		return err
+	}
+	return parseResponse(resp, nil)
+}
+
+// DeclineInvite deletes an invite sent to the current user's email before they signed up
+func (c *Client) DeclineInvite(ctx context.Context, inviteID string) error {
+	resp, err := c.doRequest(ctx, http.MethodPost, fmt.Sprintf("/api/v1/organizations/invites/%s/decline", inviteID), nil, nil)
+	if err != nil {
+		return err
+	}
+	return parseResponse(resp, nil)
+}
+
 // SearchOrganizations searches for discoverable organizations
 func (c *Client) SearchOrganizations(ctx context.Context, keyword string, page, pageSize int) ([]OrganizationResponse, error) {
 	q := url.Values{}
@@ -290,6 +438,46 @@ func (c *Client) SearchOrganizations(ctx context.Context, keyword string, page,
 	return result.Data.Organizations, nil
 }
 
+// SearchableOrganization is a discoverable organization with membership hints for the current user
+type SearchableOrganization struct {
+	ID                string `json:"id"`
+	Name              string `json:"name"`
+	Description       string `json:"description"`
+	Avatar            string `json:"avatar,omitempty"`
+	MemberCount       int    `json:"member_count"`
+	MemberLimit       int    `json:"member_limit"`
+	ShareCount        int    `json:"share_count"`
+	AgentShareCount   int    `json:"agent_share_count"`
+	IsAlreadyMember   bool   `json:"is_already_member"`
+	RequireApproval   bool   `json:"require_approval"`
+	IsFull            bool   `json:"is_full"`
+	HasPendingRequest bool   `json:"has_pending_request"`
+}
+
+// DiscoverOrganizations searches searchable organizations by name, description or ID, with hints on
+// whether the current user can join them
+func (c *Client) DiscoverOrganizations(ctx context.Context, query string, limit int) ([]SearchableOrganization, error) {
+	queryParams := url.Values{}
+	if query != "" {
+		queryParams.Add("q", query)
+	}
+	if limit > 0 {
+		queryParams.Add("limit", strconv.Itoa(limit))
+	}
+	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/organizations/search", nil, queryParams)
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Success bool                     `json:"success"`
+		Data    []SearchableOrganization `json:"data"`
+	}
+	if err := parseResponse(resp, &result); err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
+
 // JoinByOrganizationID joins a searchable organization by its ID
 func (c *Client) JoinByOrganizationID(ctx context.Context, orgID, message, role string) error {
 	req := map[string]string{
@@ -320,6 +508,67 @@ func (c *Client) PreviewOrganizationByInviteCode(ctx context.Context, code strin
 	return result.Data, nil
 }
 
+// OrganizationPreview is the preview of an organization shown before joining
+type OrganizationPreview struct {
+	ID              string                    `json:"id"`
+	Name            string                    `json:"name"`
+	Description     string                    `json:"description"`
+	Avatar          string                    `json:"avatar,omitempty"`
+	MemberCount     int                       `json:"member_count"`
+	ShareCount      int                       `json:"share_count"`
+	AgentShareCount int                       `json:"agent_share_count"`
+	IsAlreadyMember bool                      `json:"is_already_member"`
+	RequireApproval bool                      `json:"require_approval"`
+	CreatedAt       time.Time                 `json:"created_at"`
+	KnowledgeBases  []OrgPreviewKnowledgeBase `json:"knowledge_bases,omitempty"`
+	Agents          []OrgPreviewAgent         `json:"agents,omitempty"`
+}
+
+// OrgPreviewKnowledgeBase is a shared knowledge base listed in a detailed preview
+type OrgPreviewKnowledgeBase struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// OrgPreviewAgent is a shared agent listed in a detailed preview
+type OrgPreviewAgent struct {
+	Name   string `json:"name"`
+	Avatar string `json:"avatar,omitempty"`
+}
+
+// PreviewOrganizationDetail previews an organization by invite code, listing the names and types
+// of its shared knowledge bases and agents
+func (c *Client) PreviewOrganizationDetail(ctx context.Context, code string) (*OrganizationPreview, error) {
+	return c.getOrganizationPreview(ctx, fmt.Sprintf("/api/v1/organizations/preview/%s", code), true)
+}
+
+// PreviewSearchableOrganization previews a searchable organization by ID; detail also lists its
+// shared knowledge bases and agents
+func (c *Client) PreviewSearchableOrganization(ctx context.Context,
+	orgID string, detail bool,
+) (*OrganizationPreview, error) {
+	return c.getOrganizationPreview(ctx, fmt.Sprintf("/api/v1/organizations/%s/preview", orgID), detail)
+}
+
+func (c *Client) getOrganizationPreview(ctx context.Context, path string, detail bool) (*OrganizationPreview, error) {
+	queryParams := url.Values{}
+	if detail {
+		queryParams.Add("detail", "true")
+	}
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, queryParams)
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Success bool                 `json:"success"`
+		Data    *OrganizationPreview `json:"data"`
+	}
+	if err := parseResponse(resp, &result); err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
+
 // LeaveOrganization leaves an organization
 func (c *Client) LeaveOrganization(ctx context.Context, orgID string) error {
 	resp, err := c.doRequest(ctx, http.MethodPost, fmt.Sprintf("/api/v1/organizations/%s/leave", orgID), nil, nil)
@@ -393,6 +642,34 @@ func (c *Client) InviteMember(ctx context.Context, orgID, userID, role string) e
 	return parseResponse(resp, nil)
 }
 
+// BulkInviteResult is the outcome of inviting one email
+type BulkInviteResult struct {
+	Email  string `json:"email"`
+	Status string `json:"status"` // added, pending, role_not_allowed, already_member, suspended, limit_reached, invalid_email or failed
+	UserID string `json:"user_id,omitempty"`
+}
+
+// BulkInviteMembers invites users to an organization by email (admin only). Emails without an
+// account get a pending invite that the user who signs up with the email can accept.
+func (c *Client) BulkInviteMembers(ctx context.Context, orgID string, emails []string, role string) ([]BulkInviteResult, error) {
+	req := map[string]interface{}{
+		"emails": emails,
+		"role":   role,
+	}
+	resp, err := c.doRequest(ctx, http.MethodPost, fmt.Sprintf("/api/v1/organizations/%s/invite-bulk", orgID), req, nil)
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Success bool               `json:"success"`
+		Data    []BulkInviteResult `json:"data"`
+	}
+	if err := parseResponse(resp, &result); err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
+
 // ListMembers lists members of an organization
 func (c *Client) ListOrgMembers(ctx context.Context, orgID string) ([]OrganizationMemberResponse, error) {
 	resp, err := c.doRequest(ctx, http.MethodGet, fmt.Sprintf("/api/v1/organizations/%s/members", orgID), nil, nil)
@@ -411,6 +688,31 @@ func (c *Client) ListOrgMembers(ctx context.Context, orgID string) ([]Organizati
 	return result.Data.Members, nil
 }
 
+// SearchOrgMembers searches members of an organization by username or email
+func (c *Client) SearchOrgMembers(ctx context.Context,
+	orgID, query string, limit int,
+) ([]OrganizationMemberResponse, error) {
+	q := url.Values{}
+	q.Set("q", query)
+	if limit > 0 {
+		q.Set("limit", fmt.Sprintf("%d", limit))
+	}
+	resp, err := c.doRequest(ctx, http.MethodGet, fmt.Sprintf("/api/v1/organizations/%s/members/search", orgID), nil, q)
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Success bool `json:"success"`
+		Data    struct {
+			Members []OrganizationMemberResponse `json:"members"`
+		} `json:"data"`
+	}
+	if err := parseResponse(resp, &result); err != nil {
+		return nil, err
+	}
+	return result.Data.Members, nil
+}
+
 // UpdateMemberRole updates a member's role in an organization
 func (c *Client) UpdateMemberRole(ctx context.Context, orgID, userID, role string) error {
 	req := map[string]string{"role": role}
@@ -430,11 +732,57 @@ func (c *Client) RemoveMember(ctx context.Context, orgID, userID string) error {
 	return parseResponse(resp, nil)
 }
 
+// SuspendMember revokes a member's access without removing them from the organization
+func (c *Client) SuspendMember(ctx context.Context, orgID, userID string) error {
+	resp, err := c.doRequest(ctx, http.MethodPost, fmt.Sprintf("/api/v1/organizations/%s/members/%s/suspend", orgID, userID), nil, nil)
+	if err != nil {
+		return err
+	}
+	return parseResponse(resp, nil)
+}
+
+// UnsuspendMember restores the access of a suspended member
+func (c *Client) UnsuspendMember(ctx context.Context, orgID, userID string) error {
+	resp, err := c.doRequest(ctx, http.MethodPost, fmt.Sprintf("/api/v1/organizations/%s/members/%s/unsuspend", orgID, userID), nil, nil)
+	if err != nil {
+		return err
+	}
+	return parseResponse(resp, nil)
+}
+
 // --- Join request management ---
 
 // ListJoinRequests lists pending join requests (admin only)
 func (c *Client) ListJoinRequests(ctx context.Context, orgID string) ([]JoinRequestResponse, error) {
-	resp, err := c.doRequest(ctx, http.MethodGet, fmt.Sprintf("/api/v1/organizations/%s/join-requests", orgID), nil, nil)
+	return c.ListJoinRequestsByStatus(ctx, orgID, "")
+}
+
+// ListJoinRequestsByStatus lists join requests with the given status (admin only): pending, approved,
+// rejected, cancelled, expired or all. An empty status lists pending requests.
+func (c *Client) ListJoinRequestsByStatus(ctx context.Context, orgID string, status string) ([]JoinRequestResponse, error) {
+	queryParams := url.Values{}
+	if status != "" {
+		queryParams.Add("status", status)
+	}
+	resp, err := c.doRequest(ctx, http.MethodGet, fmt.Sprintf("/api/v1/organizations/%s/join-requests", orgID), nil, queryParams)
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Success bool `json:"success"`
+		Data    struct {
+			Requests []JoinRequestResponse `json:"requests"`
+		} `json:"data"`
+	}
+	if err := parseResponse(resp, &result); err != nil {
+		return nil, err
+	}
+	return result.Data.Requests, nil
+}
+
+// ListUpgradeRequests lists pending role upgrade requests (admin only); review them with ReviewJoinRequest
+func (c *Client) ListUpgradeRequests(ctx context.Context, orgID string) ([]JoinRequestResponse, error) {
+	resp, err := c.doRequest(ctx, http.MethodGet, fmt.Sprintf("/api/v1/organizations/%s/upgrade-requests", orgID), nil, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -466,7 +814,8 @@ func (c *Client) ReviewJoinRequest(ctx context.Context, orgID, requestID string,
 
 // --- Knowledge base sharing ---
 
-// ShareKnowledgeBase shares a knowledge base with an organization
+// ShareKnowledgeBase shares a knowledge base with an organization; an empty permission uses the
+// organization's default share permission
 func (c *Client) ShareKnowledgeBase(ctx context.Context, kbID, orgID, permission string) (*KnowledgeBaseShareResponse, error) {
 	req := map[string]string{
 		"organization_id": orgID,
@@ -486,6 +835,52 @@ func (c *Client) ShareKnowledgeBase(ctx context.Context, kbID, orgID, permission
 	return result.Data, nil
 }
 
+// ShareKnowledgeDocuments shares only the given documents of a knowledge base with an organization (read-only)
+func (c *Client) ShareKnowledgeDocuments(ctx context.Context, kbID, orgID string, knowledgeIDs []string) (*KnowledgeBaseShareResponse, error) {
+	req := map[string]interface{}{
+		"organization_id": orgID,
+		"permission":      "viewer",
+		"knowledge_ids":   knowledgeIDs,
+	}
+	resp, err := c.doRequest(ctx, http.MethodPost, fmt.Sprintf("/api/v1/knowledge-bases/%s/shares", kbID), req, nil)
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Success bool                        `json:"success"`
+		Data    *KnowledgeBaseShareResponse `json:"data"`
+	}
+	if err := parseResponse(resp, &result); err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
+
+// CopyKBToUserResponse is returned when a copy of a knowledge base to another user has started
+type CopyKBToUserResponse struct {
+	TaskID   string `json:"task_id"`
+	SourceID string `json:"source_id"`
+	TargetID string `json:"target_id"`
+}
+
+// CopyKBToUser gives another user an independent copy of a knowledge base.
+// Track the clone with GetKBCloneProgress.
+func (c *Client) CopyKBToUser(ctx context.Context, kbID, targetUserID string) (*CopyKBToUserResponse, error) {
+	req := map[string]string{"target_user_id": targetUserID}
+	resp, err := c.doRequest(ctx, http.MethodPost, fmt.Sprintf("/api/v1/knowledge-bases/%s/copy-to-user", kbID), req, nil)
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Success bool                  `json:"success"`
+		Data    *CopyKBToUserResponse `json:"data"`
+	}
+	if err := parseResponse(resp, &result); err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
+
 // ListKBShares lists shares of a knowledge base
 func (c *Client) ListKBShares(ctx context.Context, kbID string) ([]KnowledgeBaseShareResponse, error) {
 	resp, err := c.doRequest(ctx, http.MethodGet, fmt.Sprintf("/api/v1/knowledge-bases/%s/shares", kbID), nil, nil)
@@ -504,6 +899,39 @@ func (c *Client) ListKBShares(ctx context.Context, kbID string) ([]KnowledgeBase
 	return result.Data.Shares, nil
 }
 
+// ListKBSharesPage lists one page of a knowledge base's shares along with the total number of shares
+func (c *Client) ListKBSharesPage(ctx context.Context,
+	kbID string, page, pageSize int,
+) ([]KnowledgeBaseShareResponse, int64, error) {
+	return c.listSharesPage(ctx, fmt.Sprintf("/api/v1/knowledge-bases/%s/shares", kbID), page, pageSize)
+}
+
+// listSharesPage fetches one page of a share listing endpoint
+func (c *Client) listSharesPage(ctx context.Context,
+	path string, page, pageSize int,
+) ([]KnowledgeBaseShareResponse, int64, error) {
+	q := url.Values{}
+	q.Set("page", fmt.Sprintf("%d", max(page, 1)))
+	if pageSize > 0 {
+		q.Set("page_size", fmt.Sprintf("%d", pageSize))
+	}
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, q)
+	if err != nil {
+		return nil, 0, err
+	}
+	var result struct {
+		Success bool `json:"success"`
+		Data    struct {
+			Shares []KnowledgeBaseShareResponse `json:"shares"`
+			Total  int64                        `json:"total"`
+		} `json:"data"`
+	}
+	if err := parseResponse(resp, &result); err != nil {
+		return nil, 0, err
+	}
+	return result.Data.Shares, result.Data.Total, nil
+}
+
 // UpdateSharePermission updates a KB share's permission
 func (c *Client) UpdateSharePermission(ctx context.Context, kbID, shareID, permission string) error {
 	req := map[string]string{"permission": permission}
@@ -523,6 +951,52 @@ func (c *Client) RemoveKBShare(ctx context.Context, kbID, shareID string) error
 	return parseResponse(resp, nil)
 }
 
+// CreateDynamicShare shares every knowledge base of the caller's tenant with the share label with an
+// organization (organization admin only)
+func (c *Client) CreateDynamicShare(ctx context.Context, orgID, label, permission string) (*KnowledgeBaseDynamicShare, error) {
+	req := map[string]interface{}{
+		"criteria":   KBShareCriteria{Label: label},
+		"permission": permission,
+	}
+	resp, err := c.doRequest(ctx, http.MethodPost, fmt.Sprintf("/api/v1/organizations/%s/dynamic-shares", orgID), req, nil)
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Success bool                       `json:"success"`
+		Data    *KnowledgeBaseDynamicShare `json:"data"`
+	}
+	if err := parseResponse(resp, &result); err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
+
+// ListDynamicShares lists the dynamic share rules of an organization
+func (c *Client) ListDynamicShares(ctx context.Context, orgID string) ([]KnowledgeBaseDynamicShare, error) {
+	resp, err := c.doRequest(ctx, http.MethodGet, fmt.Sprintf("/api/v1/organizations/%s/dynamic-shares", orgID), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Success bool                        `json:"success"`
+		Data    []KnowledgeBaseDynamicShare `json:"data"`
+	}
+	if err := parseResponse(resp, &result); err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
+
+// RemoveDynamicShare removes a dynamic share rule of an organization
+func (c *Client) RemoveDynamicShare(ctx context.Context, orgID, ruleID string) error {
+	resp, err := c.doRequest(ctx, http.MethodDelete, fmt.Sprintf("/api/v1/organizations/%s/dynamic-shares/%s", orgID, ruleID), nil, nil)
+	if err != nil {
+		return err
+	}
+	return parseResponse(resp, nil)
+}
+
 // --- Agent sharing ---
 
 // ShareAgent shares an agent with an organization
@@ -592,6 +1066,14 @@ func (c *Client) ListOrgShares(ctx context.Context, orgID string) ([]KnowledgeBa
 	return result.Data.Shares, nil
 }
 
+// ListOrgSharesPage lists one page of the knowledge bases shared to an organization along with
+// the total number of shares
+func (c *Client) ListOrgSharesPage(ctx context.Context,
+	orgID string, page, pageSize int,
+) ([]KnowledgeBaseShareResponse, int64, error) {
+	return c.listSharesPage(ctx, fmt.Sprintf("/api/v1/organizations/%s/shares", orgID), page, pageSize)
+}
+
 // ListOrgAgentShares lists agents shared to an organization
 func (c *Client) ListOrgAgentShares(ctx context.Context, orgID string) ([]AgentShareResponse, error) {
 	resp, err := c.doRequest(ctx, http.MethodGet, fmt.Sprintf("/api/v1/organizations/%s/agent-shares", orgID), nil, nil)
@@ -641,3 +1123,31 @@ func (c *Client) ListSharedAgents(ctx context.Context) ([]SharedAgentInfo, error
 	}
 	return result.Data, nil
 }
+
+// --- Notifications ---
+
+// ListNotifications lists a page of the current user's notifications, newest first, with their total count
+func (c *Client) ListNotifications(ctx context.Context, page, pageSize int) ([]UserNotification, int64, error) {
+	q := url.Values{}
+	if page > 0 {
+		q.Set("page", fmt.Sprintf("%d", page))
+	}
+	if pageSize > 0 {
+		q.Set("page_size", fmt.Sprintf("%d", pageSize))
+	}
+	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/me/notifications", nil, q)
+	if err != nil {
+		return nil, 0, err
+	}
+	var result struct {
+		Success bool `json:"success"`
+		Data    struct {
+			Total int64              `json:"total"`
+			Data  []UserNotification `json:"data"`
+		} `json:"data"`
+	}
+	if err := parseResponse(resp, &result); err != nil {
+		return nil, 0, err
+	}
+	return result.Data.Data, result.Data.Total, nil
+}
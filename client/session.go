@@ -6,14 +6,19 @@ package client
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // SummaryConfig defines summary configuration
@@ -37,18 +42,22 @@ type SummaryConfig struct {
 // Sessions are now knowledge-base-independent and serve as conversation containers.
 // All configuration comes from custom agent at query time.
 type CreateSessionRequest struct {
-	Title       string `json:"title"`       // Session title (optional)
-	Description string `json:"description"` // Session description (optional)
+	Title       string `json:"title"`              // Session title (optional)
+	Description string `json:"description"`        // Session description (optional)
+	AgentID     string `json:"agent_id,omitempty"` // Agent the session is started with (optional)
 }
 
 // Session session information
 type Session struct {
-	ID          string `json:"id"`
-	TenantID    uint64 `json:"tenant_id"`
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	CreatedAt   string `json:"created_at"`
-	UpdatedAt   string `json:"updated_at"`
+	ID                     string   `json:"id"`
+	TenantID               uint64   `json:"tenant_id"`
+	Title                  string   `json:"title"`
+	Description            string   `json:"description"`
+	AgentID                string   `json:"agent_id,omitempty"`
+	AutoTitleDisabled      bool     `json:"auto_title_disabled"`
+	PinnedKnowledgeBaseIDs []string `json:"pinned_knowledge_base_ids,omitempty"`
+	CreatedAt              string   `json:"created_at"`
+	UpdatedAt              string   `json:"updated_at"`
 }
 
 // SessionResponse session response
@@ -115,6 +124,25 @@ func (c *Client) GetSessionsByTenant(ctx context.Context, page int, pageSize int
 	return response.Data, response.Total, nil
 }
 
+// GetSessionsByAgent gets the sessions started with an agent
+func (c *Client) GetSessionsByAgent(ctx context.Context, agentID string, page int, pageSize int) ([]Session, int, error) {
+	queryParams := url.Values{}
+	queryParams.Add("agent_id", agentID)
+	queryParams.Add("page", strconv.Itoa(page))
+	queryParams.Add("page_size", strconv.Itoa(pageSize))
+	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/sessions", nil, queryParams)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var response SessionListResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, 0, err
+	}
+
+	return response.Data, response.Total, nil
+}
+
 // UpdateSession updates a session
 func (c *Client) UpdateSession(ctx context.Context, sessionID string, request *CreateSessionRequest) (*Session, error) {
 	path := fmt.Sprintf("/api/v1/sessions/%s", sessionID)
@@ -131,6 +159,158 @@ func (c *Client) UpdateSession(ctx context.Context, sessionID string, request *C
 	return &response.Data, nil
 }
 
+// SetSessionAutoTitleDisabled turns automatic title generation of a session off or back on
+func (c *Client) SetSessionAutoTitleDisabled(ctx context.Context, sessionID string, disabled bool) (*Session, error) {
+	path := fmt.Sprintf("/api/v1/sessions/%s", sessionID)
+	request := map[string]bool{"auto_title_disabled": disabled}
+	resp, err := c.doRequest(ctx, http.MethodPut, path, request, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response SessionResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+
+	return &response.Data, nil
+}
+
+// contextSummaryResponse is the response of the context summary endpoints
+type contextSummaryResponse struct {
+	Success bool `json:"success"`
+	Data    struct {
+		Summary string `json:"summary"`
+	} `json:"data"`
+}
+
+// GetContextSummary gets the compressed conversation summary the agent carries forward
+func (c *Client) GetContextSummary(ctx context.Context, sessionID string) (string, error) {
+	path := fmt.Sprintf("/api/v1/sessions/%s/context-summary", sessionID)
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return "", err
+	}
+
+	var response contextSummaryResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return "", err
+	}
+	return response.Data.Summary, nil
+}
+
+// UpdateContextSummary replaces the compressed conversation summary, an empty summary removes it
+func (c *Client) UpdateContextSummary(ctx context.Context, sessionID string, summary string) error {
+	path := fmt.Sprintf("/api/v1/sessions/%s/context-summary", sessionID)
+	resp, err := c.doRequest(ctx, http.MethodPut, path, map[string]string{"summary": summary}, nil)
+	if err != nil {
+		return err
+	}
+
+	var response contextSummaryResponse
+	return parseResponse(resp, &response)
+}
+
+// PinKnowledgeBases replaces the knowledge bases pinned to a session, an empty list unpins all
+func (c *Client) PinKnowledgeBases(ctx context.Context, sessionID string, knowledgeBaseIDs []string) (*Session, error) {
+	path := fmt.Sprintf("/api/v1/sessions/%s/pinned-kbs", sessionID)
+	request := struct {
+		KnowledgeBaseIDs []string `json:"knowledge_base_ids"`
+	}{KnowledgeBaseIDs: knowledgeBaseIDs}
+	resp, err := c.doRequest(ctx, http.MethodPut, path, request, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response SessionResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// PruneSessionHistory trims the oldest messages of a session according to the tenant's
+// history retention config and returns the number of deleted messages
+func (c *Client) PruneSessionHistory(ctx context.Context, sessionID string) (int, error) {
+	path := fmt.Sprintf("/api/v1/sessions/%s/prune", sessionID)
+	resp, err := c.doRequest(ctx, http.MethodPost, path, nil, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var response struct {
+		Success bool `json:"success"`
+		Data    struct {
+			Pruned int `json:"pruned"`
+		} `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return 0, err
+	}
+	return response.Data.Pruned, nil
+}
+
+// AttachFileToSession uploads a local file that is only searchable within the given session.
+// The file is removed when the session is deleted. ErrDuplicateFile is returned together with
+// the existing attachment when the same file was already attached.
+func (c *Client) AttachFileToSession(
+	ctx context.Context, sessionID string, filePath string, enableMultimodel *bool,
+) (*Knowledge, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", filepath.Base(filePath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create form file: %w", err)
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return nil, fmt.Errorf("failed to copy file content: %w", err)
+	}
+	if enableMultimodel != nil {
+		if err := writer.WriteField("enable_multimodel", strconv.FormatBool(*enableMultimodel)); err != nil {
+			return nil, fmt.Errorf("failed to write enable_multimodel field: %w", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close writer: %w", err)
+	}
+
+	path := fmt.Sprintf("/api/v1/sessions/%s/attachments", sessionID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	if c.token != "" {
+		req.Header.Set("X-API-Key", c.token)
+	}
+	if requestID := ctx.Value("RequestID"); requestID != nil {
+		req.Header.Set("X-Request-ID", requestID.(string))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var response KnowledgeResponse
+	if resp.StatusCode == http.StatusConflict {
+		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+			return nil, fmt.Errorf("failed to parse response: %w", err)
+		}
+		return &response.Data, ErrDuplicateFile
+	} else if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
 // DeleteSession deletes a session
 func (c *Client) DeleteSession(ctx context.Context, sessionID string) error {
 	path := fmt.Sprintf("/api/v1/sessions/%s", sessionID)
@@ -198,6 +378,23 @@ func (c *Client) GenerateTitle(ctx context.Context, sessionID string, request *G
 	return response.Data, nil
 }
 
+// RegenerateTitle replaces the session title with a freshly generated one, even if it was
+// already titled. modelID is optional
+func (c *Client) RegenerateTitle(ctx context.Context, sessionID string, modelID string) (string, error) {
+	path := fmt.Sprintf("/api/v1/sessions/%s/regenerate-title", sessionID)
+	resp, err := c.doRequest(ctx, http.MethodPost, path, map[string]string{"model_id": modelID}, nil)
+	if err != nil {
+		return "", err
+	}
+
+	var response GenerateTitleResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return "", err
+	}
+
+	return response.Data, nil
+}
+
 // KnowledgeQARequest knowledge Q&A request
 type KnowledgeQARequest struct {
 	Query            string   `json:"query"`              // Query text for knowledge base search
@@ -208,6 +405,20 @@ type KnowledgeQARequest struct {
 	WebSearchEnabled bool     `json:"web_search_enabled"` // Whether web search is enabled for this request
 	SummaryModelID   string   `json:"summary_model_id"`   // Optional summary model ID (overrides session default)
 	DisableTitle     bool     `json:"disable_title"`      // Whether to disable auto title generation
+	// Answer length: "brief", "normal" or "detailed"; brief answers are also capped in tokens
+	Verbosity string `json:"verbosity,omitempty"`
+	// Only retrieve documents whose metadata matches all key/value pairs
+	MetadataFilters map[string]string `json:"metadata_filters,omitempty"`
+	// Only retrieve documents created within [CreatedAfter, CreatedBefore)
+	CreatedAfter  *time.Time `json:"created_after,omitempty"`
+	CreatedBefore *time.Time `json:"created_before,omitempty"`
+	// Sampling overrides for this request only: temperature in (0, 2], top_p in (0, 1]
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"top_p,omitempty"`
+	// Tools disabled for this request only, e.g. "web_search" for a knowledge-base-only answer
+	DisabledTools []string `json:"disabled_tools,omitempty"`
+	// Knowledge metadata keys returned with each reference's document ("*" for all)
+	IncludeMetadata []string `json:"include_metadata,omitempty"`
 }
 
 // LLMToolCall represents a function/tool call from the LLM
@@ -236,6 +447,8 @@ const (
 	ResponseTypeSessionTitle ResponseType = "session_title"
 	ResponseTypeAgentQuery   ResponseType = "agent_query"
 	ResponseTypeComplete     ResponseType = "complete"
+	// Agent stopped at max iterations; the answer that follows may be incomplete
+	ResponseTypeBudgetExceeded ResponseType = "budget_exceeded"
 )
 
 // StreamResponse streaming response
@@ -289,6 +502,12 @@ func (c *Client) KnowledgeQAStream(
 
 		// Empty line indicates the end of an event
 		if line == "" {
+			// Heartbeats only keep the connection alive and carry no content
+			if eventType == "heartbeat" {
+				dataBuffer = ""
+				eventType = ""
+				continue
+			}
 			if dataBuffer != "" {
 				fmt.Printf("Processing data: %s, event type: %s\n", dataBuffer, eventType)
 				var streamResponse StreamResponse
@@ -428,6 +647,11 @@ type SearchKnowledgeRequest struct {
 	KnowledgeBaseID  string   `json:"knowledge_base_id,omitempty"`  // Single knowledge base ID (for backward compatibility)
 	KnowledgeBaseIDs []string `json:"knowledge_base_ids,omitempty"` // Knowledge base IDs (multi-KB support)
 	KnowledgeIDs     []string `json:"knowledge_ids,omitempty"`      // Specific knowledge (file) IDs
+	// Only retrieve documents whose metadata matches all key/value pairs
+	MetadataFilters map[string]string `json:"metadata_filters,omitempty"`
+	// Only retrieve documents created within [CreatedAfter, CreatedBefore)
+	CreatedAfter  *time.Time `json:"created_after,omitempty"`
+	CreatedBefore *time.Time `json:"created_before,omitempty"`
 }
 
 // SearchKnowledgeResponse search results response
@@ -30,6 +30,7 @@ type TagWithStats struct {
 }
 
 // CreateTagPayload is used to create a new tag.
+// Color must be a #RGB or #RRGGBB hex code.
 type CreateTagPayload struct {
 	Name      string `json:"name"`
 	Color     string `json:"color,omitempty"`
@@ -185,3 +186,28 @@ func (c *Client) DeleteTagBySeqID(ctx context.Context,
 ) error {
 	return c.DeleteTag(ctx, knowledgeBaseID, strconv.FormatInt(tagSeqID, 10), force, contentOnly, excludeIDs)
 }
+
+// MergeTagsPayload represents the request body for merging two tags.
+// Both tag IDs can be either UUID or seq_id (as string).
+type MergeTagsPayload struct {
+	SourceTagID string `json:"source_tag_id"`
+	TargetTagID string `json:"target_tag_id"`
+}
+
+// MergeTags moves all knowledge and FAQ entries from the source tag to the target tag,
+// then deletes the source tag. Returns the target tag.
+func (c *Client) MergeTags(ctx context.Context,
+	knowledgeBaseID string, payload *MergeTagsPayload,
+) (*Tag, error) {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/tags/merge", knowledgeBaseID)
+	resp, err := c.doRequest(ctx, http.MethodPost, path, payload, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response TagResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
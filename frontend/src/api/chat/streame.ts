@@ -136,6 +136,8 @@ export function useStream() {
         },
 
         onmessage: (ev) => {
+          // 心跳事件仅用于保活连接，不含内容
+          if (ev.event === 'heartbeat') return;
           buffer.push(JSON.parse(ev.data)); // 数据存入缓冲
           // 执行自定义处理
           if (chunkHandler) {
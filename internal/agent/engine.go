@@ -163,6 +163,11 @@ func (e *AgentEngine) Execute(
 			e.systemPromptTemplate,
 		)
 	}
+	// In JSON mode the final answer is produced through the final_answer tool, which native
+	// response_format cannot constrain, so the format is always stated in the system prompt
+	if e.isJSONMode() {
+		systemPrompt += "\n\n" + chat.BuildJSONModeInstruction(e.config.ResponseSchema)
+	}
 	logger.Debugf(ctx, "[Agent] SystemPrompt Length: %d characters", len(systemPrompt))
 	logger.Debugf(ctx, "[Agent] SystemPrompt (stream)\n----\n%s\n----", systemPrompt)
 
@@ -313,15 +318,7 @@ func (e *AgentEngine) executeLoop(
 			state.RoundSteps = append(state.RoundSteps, step)
 
 			// Emit final answer done marker
-			e.eventBus.Emit(ctx, event.Event{
-				ID:        generateEventID("answer-done"),
-				Type:      event.EventAgentFinalAnswer,
-				SessionID: sessionID,
-				Data: event.AgentFinalAnswerData{
-					Content: "",
-					Done:    true,
-				},
-			})
+			e.emitAnswerDone(ctx, sessionID)
 			logger.Infof(
 				ctx,
 				"[Agent][Round-%d] Duration: %dms",
@@ -349,15 +346,7 @@ func (e *AgentEngine) executeLoop(
 						hasFinalAnswer = true
 
 						// Emit answer done marker (content was already streamed via processToolCallsDelta)
-						e.eventBus.Emit(ctx, event.Event{
-							ID:        generateEventID("answer-done"),
-							Type:      event.EventAgentFinalAnswer,
-							SessionID: sessionID,
-							Data: event.AgentFinalAnswerData{
-								Content: "",
-								Done:    true,
-							},
-						})
+						e.emitAnswerDone(ctx, sessionID)
 
 						common.PipelineInfo(ctx, "Agent", "final_answer_tool", map[string]interface{}{
 							"iteration":  state.CurrentRound,
@@ -575,6 +564,16 @@ func (e *AgentEngine) executeLoop(
 			"iterations": state.CurrentRound,
 			"max":        e.config.MaxIterations,
 		})
+		state.BudgetExceeded = true
+		e.eventBus.Emit(ctx, event.Event{
+			ID:        generateEventID("budget-exceeded"),
+			Type:      event.EventAgentBudgetExceeded,
+			SessionID: sessionID,
+			Data: event.AgentBudgetExceededData{
+				Iterations:    state.CurrentRound,
+				MaxIterations: e.config.MaxIterations,
+			},
+		})
 
 		// Stream final answer generation through EventBus
 		if err := e.streamFinalAnswerToEventBus(ctx, query, state, sessionID); err != nil {
@@ -587,6 +586,11 @@ func (e *AgentEngine) executeLoop(
 		state.IsComplete = true
 	}
 
+	if e.isJSONMode() {
+		state.FinalAnswer = e.ensureJSONAnswer(ctx, state.FinalAnswer)
+		e.emitBufferedAnswer(ctx, state.FinalAnswer, sessionID)
+	}
+
 	// Emit completion event
 	// Convert knowledge refs to interface{} slice for event data
 	knowledgeRefsInterface := make([]interface{}, 0, len(state.KnowledgeRefs))
@@ -605,6 +609,7 @@ func (e *AgentEngine) executeLoop(
 			TotalSteps:      len(state.RoundSteps),
 			TotalDurationMs: time.Since(startTime).Milliseconds(),
 			MessageID:       messageID, // Include message ID for proper message update
+			BudgetExceeded:  state.BudgetExceeded,
 		},
 	})
 
@@ -816,9 +821,12 @@ func (e *AgentEngine) streamThinkingToEventBus(
 		iteration+1, e.config.Temperature, len(tools), e.config.Thinking)
 
 	opts := &chat.ChatOptions{
-		Temperature: e.config.Temperature,
-		Tools:       tools,
-		Thinking:    e.config.Thinking,
+		Temperature:         e.config.Temperature,
+		TopP:                e.config.TopP,
+		MaxCompletionTokens: e.config.MaxCompletionTokens,
+		Stop:                e.config.StopSequences,
+		Tools:               tools,
+		Thinking:            e.config.Thinking,
 	}
 	logger.Debug(context.Background(), "[Agent] streamLLM opts tool_choice=auto temperature=", e.config.Temperature)
 
@@ -858,6 +866,10 @@ func (e *AgentEngine) streamThinkingToEventBus(
 			// Handle final_answer tool's streaming answer content
 			if chunk.ResponseType == types.ResponseTypeAnswer {
 				if source, _ := chunk.Data["source"].(string); source == "final_answer_tool" {
+					// In JSON mode the answer is buffered until it has been validated
+					if e.isJSONMode() {
+						return
+					}
 					e.eventBus.Emit(ctx, event.Event{
 						ID:        answerID,
 						Type:      event.EventAgentFinalAnswer,
@@ -952,6 +964,18 @@ func (e *AgentEngine) streamFinalAnswerToEventBus(
 		e.systemPromptTemplate,
 	)
 
+	opts := &chat.ChatOptions{
+		Temperature:         e.config.Temperature,
+		TopP:                e.config.TopP,
+		MaxCompletionTokens: e.config.MaxCompletionTokens,
+		Stop:                e.config.StopSequences,
+		Thinking:            e.config.Thinking,
+	}
+	if e.isJSONMode() {
+		chat.ApplyJSONMode(e.chatModel, opts)
+		systemPrompt += "\n\n" + chat.BuildJSONModeInstruction(e.config.ResponseSchema)
+	}
+
 	messages := []chat.Message{
 		{Role: "system", Content: systemPrompt},
 		{Role: "user", Content: query},
@@ -1000,9 +1024,10 @@ Now generate the final answer:`, query)
 	fullAnswer, _, err := e.streamLLMToEventBus(
 		ctx,
 		messages,
-		&chat.ChatOptions{Temperature: e.config.Temperature, Thinking: e.config.Thinking},
+		opts,
 		func(chunk *types.StreamResponse, fullContent string) {
-			if chunk.Content != "" {
+			// In JSON mode the answer is buffered until it has been validated
+			if chunk.Content != "" && !e.isJSONMode() {
 				logger.Debugf(ctx, "[Agent][FinalAnswer] Emitting answer chunk: %d chars", len(chunk.Content))
 				e.eventBus.Emit(ctx, event.Event{
 					ID:        answerID, // Same ID for all chunks in this stream
@@ -1034,6 +1059,72 @@ Now generate the final answer:`, query)
 	return nil
 }
 
+// emitAnswerDone emits the done marker of a streamed final answer. In JSON mode nothing has been
+// streamed yet; emitBufferedAnswer emits the answer and its marker once the answer is validated.
+func (e *AgentEngine) emitAnswerDone(ctx context.Context, sessionID string) {
+	if e.isJSONMode() {
+		return
+	}
+	e.eventBus.Emit(ctx, event.Event{
+		ID:        generateEventID("answer-done"),
+		Type:      event.EventAgentFinalAnswer,
+		SessionID: sessionID,
+		Data: event.AgentFinalAnswerData{
+			Content: "",
+			Done:    true,
+		},
+	})
+}
+
+// emitBufferedAnswer emits a JSON-mode final answer as a single chunk followed by the done marker,
+// so clients only ever see the validated or repaired answer
+func (e *AgentEngine) emitBufferedAnswer(ctx context.Context, answer string, sessionID string) {
+	e.eventBus.Emit(ctx, event.Event{
+		ID:        generateEventID("answer"),
+		Type:      event.EventAgentFinalAnswer,
+		SessionID: sessionID,
+		Data:      event.AgentFinalAnswerData{Content: answer},
+	})
+	e.eventBus.Emit(ctx, event.Event{
+		ID:        generateEventID("answer-done"),
+		Type:      event.EventAgentFinalAnswer,
+		SessionID: sessionID,
+		Data: event.AgentFinalAnswerData{
+			Content: "",
+			Done:    true,
+		},
+	})
+}
+
+// isJSONMode returns true if the agent must produce a JSON final answer
+func (e *AgentEngine) isJSONMode() bool {
+	return e.config.ResponseFormat == types.ResponseFormatJSONObject
+}
+
+// ensureJSONAnswer validates that the final answer is valid JSON and runs a single
+// repair pass if it is not. The original answer is kept when the repair fails.
+func (e *AgentEngine) ensureJSONAnswer(ctx context.Context, answer string) string {
+	if normalized, ok := chat.NormalizeJSONAnswer(answer); ok {
+		return normalized
+	}
+
+	logger.Warnf(ctx, "[Agent] Final answer is not valid JSON (%d chars), attempting repair", len(answer))
+	opts := &chat.ChatOptions{MaxCompletionTokens: e.config.MaxCompletionTokens}
+	chat.ApplyJSONMode(e.chatModel, opts)
+	repaired, err := chat.RepairJSONAnswer(ctx, e.chatModel, answer, e.config.ResponseSchema, opts)
+	if err != nil {
+		logger.Warnf(ctx, "[Agent] JSON repair failed, keeping original answer: %v", err)
+		common.PipelineWarn(ctx, "Agent", "json_repair_failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return answer
+	}
+	common.PipelineInfo(ctx, "Agent", "json_repaired", map[string]interface{}{
+		"answer_len": len(repaired),
+	})
+	return repaired
+}
+
 // countTotalToolCalls counts total tool calls across all steps
 func countTotalToolCalls(steps []types.AgentStep) int {
 	total := 0
@@ -1052,6 +1143,15 @@ func (e *AgentEngine) buildMessagesWithLLMContext(
 		{Role: "system", Content: systemPrompt},
 	}
 
+	// Few-shot examples are pinned right after the system prompt; they are never written to the
+	// context manager, so compression of the stored history can't drop or summarize them
+	for _, example := range e.config.FewShotExamples {
+		messages = append(messages,
+			chat.Message{Role: "user", Content: example.User},
+			chat.Message{Role: "assistant", Content: example.Assistant},
+		)
+	}
+
 	if len(llmContext) > 0 {
 		for _, msg := range llmContext {
 			if msg.Role == "system" {
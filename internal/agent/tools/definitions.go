@@ -51,6 +51,20 @@ func AvailableToolDefinitions() []AvailableTool {
 	}
 }
 
+// CanDisableTool reports whether a chat request may disable the tool for that request.
+// final_answer is always registered; disabling web_search also removes web_fetch.
+func CanDisableTool(name string) bool {
+	if name == ToolWebSearch {
+		return true
+	}
+	for _, tool := range AvailableToolDefinitions() {
+		if tool.Name == name {
+			return name != ToolFinalAnswer
+		}
+	}
+	return false
+}
+
 // DefaultAllowedTools returns the default allowed tools list.
 func DefaultAllowedTools() []string {
 	return []string{
@@ -127,11 +127,12 @@ func (t *GetDocumentInfoTool) Execute(ctx context.Context, args json.RawMessage)
 				return
 			}
 
-			// Verify the knowledge's KB is in searchTargets (permission check)
-			if !t.searchTargets.ContainsKB(knowledge.KnowledgeBaseID) {
+			// Verify the knowledge is covered by searchTargets (permission check); partial shares
+			// only cover the documents they list
+			if !t.searchTargets.ContainsKnowledge(knowledge.KnowledgeBaseID, knowledge.ID) {
 				mu.Lock()
 				results[id] = &docInfo{
-					err: fmt.Errorf("knowledge base %s is not accessible", knowledge.KnowledgeBaseID),
+					err: fmt.Errorf("knowledge %s is not accessible", id),
 				}
 				mu.Unlock()
 				return
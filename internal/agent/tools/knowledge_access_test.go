@@ -0,0 +1,105 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// mapKnowledgeService serves knowledge by ID
+type mapKnowledgeService struct {
+	interfaces.KnowledgeService
+	knowledge map[string]*types.Knowledge
+}
+
+func (s *mapKnowledgeService) GetKnowledgeByIDOnly(ctx context.Context, id string) (*types.Knowledge, error) {
+	if k, ok := s.knowledge[id]; ok {
+		return k, nil
+	}
+	return nil, errors.New("knowledge not found")
+}
+
+// singleChunkService returns one chunk for every knowledge
+type singleChunkService struct {
+	interfaces.ChunkService
+}
+
+func (s *singleChunkService) GetRepository() interfaces.ChunkRepository {
+	return &singleChunkRepo{}
+}
+
+type singleChunkRepo struct {
+	interfaces.ChunkRepository
+}
+
+func (r *singleChunkRepo) ListPagedChunksByKnowledgeID(ctx context.Context,
+	tenantID uint64, knowledgeID string, page *types.Pagination, chunkType []types.ChunkType,
+	tagID, keyword, searchField, sortOrder, knowledgeType string,
+) ([]*types.Chunk, int64, error) {
+	return []*types.Chunk{{ID: "chunk-" + knowledgeID, KnowledgeID: knowledgeID, Content: "content"}}, 1, nil
+}
+
+// partialShareTargets shares k1 of kb-shared only, next to the whole kb-own
+func partialShareTargets() (types.SearchTargets, *mapKnowledgeService) {
+	targets := types.SearchTargets{
+		{Type: types.SearchTargetTypeKnowledgeBase, KnowledgeBaseID: "kb-own", TenantID: 1},
+		{Type: types.SearchTargetTypeKnowledge, KnowledgeBaseID: "kb-shared", TenantID: 2, KnowledgeIDs: []string{"k1"}},
+	}
+	knowledge := &mapKnowledgeService{knowledge: map[string]*types.Knowledge{
+		"k1":  {ID: "k1", KnowledgeBaseID: "kb-shared", TenantID: 2, Title: "shared"},
+		"k2":  {ID: "k2", KnowledgeBaseID: "kb-shared", TenantID: 2, Title: "not shared"},
+		"own": {ID: "own", KnowledgeBaseID: "kb-own", TenantID: 1, Title: "own"},
+		"k9":  {ID: "k9", KnowledgeBaseID: "kb-other", TenantID: 3, Title: "other"},
+	}}
+	return targets, knowledge
+}
+
+func TestListKnowledgeChunksPartialShare(t *testing.T) {
+	targets, knowledge := partialShareTargets()
+	tool := NewListKnowledgeChunksTool(knowledge, &singleChunkService{}, targets)
+	tests := []struct {
+		knowledgeID string
+		want        bool
+	}{
+		{"k1", true},
+		{"own", true},
+		{"k2", false},
+		{"k9", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.knowledgeID, func(t *testing.T) {
+			args, _ := json.Marshal(ListKnowledgeChunksInput{KnowledgeID: tt.knowledgeID})
+			result, err := tool.Execute(context.Background(), args)
+			if result.Success != tt.want || (err == nil) != tt.want {
+				t.Errorf("Execute(%s) success = %v, error = %v, want success %v", tt.knowledgeID, result.Success, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetDocumentInfoPartialShare(t *testing.T) {
+	targets, knowledge := partialShareTargets()
+	tool := NewGetDocumentInfoTool(knowledge, &singleChunkService{}, targets)
+
+	args, _ := json.Marshal(GetDocumentInfoInput{KnowledgeIDs: []string{"k1", "k2", "own", "k9"}})
+	result, err := tool.Execute(context.Background(), args)
+	if err != nil || !result.Success {
+		t.Fatalf("Execute() success = %v, error = %v", result.Success, err)
+	}
+	var got []string
+	for _, doc := range result.Data["documents"].([]map[string]interface{}) {
+		got = append(got, doc["knowledge_id"].(string))
+	}
+	if len(got) != 2 || got[0] != "k1" || got[1] != "own" {
+		t.Errorf("documents = %v, want [k1 own]", got)
+	}
+
+	args, _ = json.Marshal(GetDocumentInfoInput{KnowledgeIDs: []string{"k2"}})
+	if result, err := tool.Execute(context.Background(), args); err == nil || result.Success {
+		t.Errorf("Execute([k2]) success = %v, error = %v, want failure", result.Success, err)
+	}
+}
@@ -114,12 +114,13 @@ func (t *ListKnowledgeChunksTool) Execute(ctx context.Context, args json.RawMess
 		}, err
 	}
 
-	// Verify the knowledge's KB is in searchTargets (permission check)
-	if !t.searchTargets.ContainsKB(knowledge.KnowledgeBaseID) {
+	// Verify the knowledge is covered by searchTargets (permission check); partial shares only
+	// cover the documents they list
+	if !t.searchTargets.ContainsKnowledge(knowledge.KnowledgeBaseID, knowledge.ID) {
 		return &types.ToolResult{
 			Success: false,
-			Error:   fmt.Sprintf("Knowledge base %s is not accessible", knowledge.KnowledgeBaseID),
-		}, fmt.Errorf("knowledge base not in search targets")
+			Error:   fmt.Sprintf("Knowledge %s is not accessible", knowledgeID),
+		}, fmt.Errorf("knowledge not in search targets")
 	}
 
 	// Use the knowledge's actual tenant_id for chunk query (supports cross-tenant shared KB)
@@ -173,7 +173,7 @@ func (r *agentShareRepository) ListSharedAgentsForUser(ctx context.Context, user
 		Joins("JOIN custom_agents ON custom_agents.id = agent_shares.agent_id AND custom_agents.tenant_id = agent_shares.source_tenant_id AND custom_agents.deleted_at IS NULL").
 		Preload("Agent").
 		Preload("Organization").
-		Joins("JOIN organization_members ON organization_members.organization_id = agent_shares.organization_id").
+		Joins("JOIN organization_members ON organization_members.organization_id = agent_shares.organization_id AND organization_members.suspended = false").
 		Joins("JOIN organizations ON organizations.id = agent_shares.organization_id AND organizations.deleted_at IS NULL").
 		Where("organization_members.user_id = ?", userID).
 		Where("agent_shares.deleted_at IS NULL").
@@ -189,7 +189,7 @@ func (r *agentShareRepository) ListSharedAgentsForUser(ctx context.Context, user
 func (r *agentShareRepository) GetShareByAgentIDForUser(ctx context.Context, userID, agentID string, excludeTenantID uint64) (*types.AgentShare, error) {
 	var share types.AgentShare
 	err := r.db.WithContext(ctx).
-		Joins("JOIN organization_members ON organization_members.organization_id = agent_shares.organization_id").
+		Joins("JOIN organization_members ON organization_members.organization_id = agent_shares.organization_id AND organization_members.suspended = false").
 		Where("agent_shares.agent_id = ?", agentID).
 		Where("organization_members.user_id = ?", userID).
 		Where("agent_shares.source_tenant_id != ?", excludeTenantID).
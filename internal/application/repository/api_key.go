@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+)
+
+// ErrAPIKeyNotFound is returned when an API key is not found
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+// apiKeyRepository implements the APIKeyRepository interface
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyRepository creates a new API key repository
+func NewAPIKeyRepository(db *gorm.DB) interfaces.APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+// Create creates a new API key
+func (r *apiKeyRepository) Create(ctx context.Context, key *types.APIKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+// GetByID gets an API key by id and tenant
+func (r *apiKeyRepository) GetByID(ctx context.Context, tenantID uint64, id string) (*types.APIKey, error) {
+	var key types.APIKey
+	if err := r.db.WithContext(ctx).Where("id = ? AND tenant_id = ?", id, tenantID).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAPIKeyNotFound
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+// GetByHash gets an API key by the hash of its key
+func (r *apiKeyRepository) GetByHash(ctx context.Context, keyHash string) (*types.APIKey, error) {
+	var key types.APIKey
+	if err := r.db.WithContext(ctx).Where("key_hash = ?", keyHash).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAPIKeyNotFound
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+// List lists all API keys of a tenant
+func (r *apiKeyRepository) List(ctx context.Context, tenantID uint64) ([]*types.APIKey, error) {
+	var keys []*types.APIKey
+	if err := r.db.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("created_at DESC").
+		Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// UpdateLastUsedAt records when an API key was last used
+func (r *apiKeyRepository) UpdateLastUsedAt(ctx context.Context, id string, usedAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&types.APIKey{}).
+		Where("id = ?", id).
+		UpdateColumn("last_used_at", usedAt).Error
+}
+
+// Delete deletes an API key
+func (r *apiKeyRepository) Delete(ctx context.Context, tenantID uint64, id string) error {
+	return r.db.WithContext(ctx).
+		Where("id = ? AND tenant_id = ?", id, tenantID).
+		Delete(&types.APIKey{}).Error
+}
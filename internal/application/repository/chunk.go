@@ -427,6 +427,28 @@ func (r *chunkRepository) MoveChunksByKnowledgeID(ctx context.Context, tenantID
 		Update("knowledge_base_id", targetKBID).Error
 }
 
+// ReassignTagID moves all chunks of a knowledge base from one tag to another (empty means uncategorized).
+// Returns the IDs of the moved chunks for syncing with retriever engines.
+func (r *chunkRepository) ReassignTagID(ctx context.Context,
+	tenantID uint64, kbID string, fromTagID string, toTagID string,
+) ([]string, error) {
+	var ids []string
+	if err := r.db.WithContext(ctx).Model(&types.Chunk{}).
+		Where("tenant_id = ? AND knowledge_base_id = ? AND tag_id = ?", tenantID, kbID, fromTagID).
+		Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	if err := r.db.WithContext(ctx).Model(&types.Chunk{}).
+		Where("tenant_id = ? AND knowledge_base_id = ? AND tag_id = ?", tenantID, kbID, fromTagID).
+		Update("tag_id", toTagID).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
 // DeleteChunksByTagID deletes all chunks with the specified tag ID
 // Returns the IDs of deleted chunks for index cleanup
 func (r *chunkRepository) DeleteChunksByTagID(ctx context.Context, tenantID uint64, kbID string, tagID string, excludeIDs []string) ([]string, error) {
@@ -487,6 +509,60 @@ func (r *chunkRepository) CountChunksByKnowledgeBaseID(
 	return count, err
 }
 
+// CountChunksByKnowledgeBaseIDs counts the chunks of several knowledge bases in one query.
+// Knowledge base IDs are globally unique, so no tenant filter is needed.
+func (r *chunkRepository) CountChunksByKnowledgeBaseIDs(
+	ctx context.Context,
+	kbIDs []string,
+) (map[string]int64, error) {
+	counts := make(map[string]int64, len(kbIDs))
+	if len(kbIDs) == 0 {
+		return counts, nil
+	}
+	var rows []struct {
+		KnowledgeBaseID string
+		Count           int64
+	}
+	err := r.db.WithContext(ctx).Model(&types.Chunk{}).
+		Select("knowledge_base_id, COUNT(*) AS count").
+		Where("knowledge_base_id IN ?", kbIDs).
+		Group("knowledge_base_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		counts[row.KnowledgeBaseID] = row.Count
+	}
+	return counts, nil
+}
+
+// AggregateChunksByKnowledgeBaseID returns the average chunk size and embedding coverage counts of a knowledge base.
+// Parent chunks are context only and never indexed, so they are excluded from the coverage counts.
+func (r *chunkRepository) AggregateChunksByKnowledgeBaseID(
+	ctx context.Context,
+	tenantID uint64,
+	kbID string,
+	knowledgeIDs []string,
+) (*types.ChunkAggregates, error) {
+	var agg types.ChunkAggregates
+	query := r.db.WithContext(ctx).Model(&types.Chunk{}).
+		Where("tenant_id = ? AND knowledge_base_id = ?", tenantID, kbID)
+	if knowledgeIDs != nil {
+		query = query.Where("knowledge_id IN ?", knowledgeIDs)
+	}
+	err := query.
+		Select("COUNT(*) AS count, COALESCE(AVG(LENGTH(content)), 0) AS avg_size, "+
+			"COALESCE(SUM(CASE WHEN chunk_type <> ? THEN 1 ELSE 0 END), 0) AS indexable_count, "+
+			"COALESCE(SUM(CASE WHEN chunk_type <> ? AND status = ? THEN 1 ELSE 0 END), 0) AS indexed_count",
+			types.ChunkTypeParentText, types.ChunkTypeParentText, types.ChunkStatusIndexed).
+		Scan(&agg).Error
+	if err != nil {
+		return nil, err
+	}
+	return &agg, nil
+}
+
 // DeleteUnindexedChunks by knowledge id and chunk index range
 func (r *chunkRepository) DeleteUnindexedChunks(
 	ctx context.Context,
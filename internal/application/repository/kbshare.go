@@ -155,7 +155,7 @@ func (r *kbShareRepository) ListSharedKBsForUser(ctx context.Context, userID str
 		Joins("JOIN knowledge_bases ON knowledge_bases.id = kb_shares.knowledge_base_id AND knowledge_bases.deleted_at IS NULL").
 		Preload("KnowledgeBase").
 		Preload("Organization").
-		Joins("JOIN organization_members ON organization_members.organization_id = kb_shares.organization_id").
+		Joins("JOIN organization_members ON organization_members.organization_id = kb_shares.organization_id AND organization_members.suspended = false").
 		Joins("JOIN organizations ON organizations.id = kb_shares.organization_id AND organizations.deleted_at IS NULL").
 		Where("organization_members.user_id = ?", userID).
 		Where("kb_shares.deleted_at IS NULL").
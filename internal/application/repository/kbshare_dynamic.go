@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"gorm.io/gorm"
+)
+
+var ErrKBDynamicShareNotFound = errors.New("dynamic knowledge base share not found")
+
+// kbHasShareLabelCondition matches knowledge bases whose share label equals the bound value (case-insensitive)
+const kbHasShareLabelCondition = "knowledge_bases.share_label <> '' AND LOWER(knowledge_bases.share_label) = LOWER(?)"
+
+// CreateDynamic creates a new dynamic share rule
+func (r *kbShareRepository) CreateDynamic(ctx context.Context, rule *types.KnowledgeBaseDynamicShare) error {
+	return r.db.WithContext(ctx).Create(rule).Error
+}
+
+// GetDynamicByID gets a dynamic share rule by ID
+func (r *kbShareRepository) GetDynamicByID(ctx context.Context, id string) (*types.KnowledgeBaseDynamicShare, error) {
+	var rule types.KnowledgeBaseDynamicShare
+	err := r.db.WithContext(ctx).
+		Where("id = ?", id).
+		First(&rule).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrKBDynamicShareNotFound
+		}
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// DeleteDynamic soft deletes a dynamic share rule
+func (r *kbShareRepository) DeleteDynamic(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&types.KnowledgeBaseDynamicShare{}).Error
+}
+
+// DeleteDynamicByOrganizationID soft deletes all dynamic share rules of an organization
+func (r *kbShareRepository) DeleteDynamicByOrganizationID(ctx context.Context, orgID string) error {
+	return r.db.WithContext(ctx).Where("organization_id = ?", orgID).Delete(&types.KnowledgeBaseDynamicShare{}).Error
+}
+
+// ListDynamicByOrganization lists the dynamic share rules of an organization
+func (r *kbShareRepository) ListDynamicByOrganization(ctx context.Context, orgID string) ([]*types.KnowledgeBaseDynamicShare, error) {
+	var rules []*types.KnowledgeBaseDynamicShare
+	err := r.db.WithContext(ctx).
+		Preload("Organization").
+		Where("organization_id = ?", orgID).
+		Order("created_at DESC").
+		Find(&rules).Error
+
+	if err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// ListDynamicForUser lists the dynamic share rules of all organizations the user belongs to.
+// Excludes rules of soft-deleted organizations.
+func (r *kbShareRepository) ListDynamicForUser(ctx context.Context, userID string) ([]*types.KnowledgeBaseDynamicShare, error) {
+	var rules []*types.KnowledgeBaseDynamicShare
+	err := r.db.WithContext(ctx).
+		Preload("Organization").
+		Joins("JOIN organization_members ON organization_members.organization_id = kb_dynamic_shares.organization_id AND organization_members.suspended = false").
+		Joins("JOIN organizations ON organizations.id = kb_dynamic_shares.organization_id AND organizations.deleted_at IS NULL").
+		Where("organization_members.user_id = ?", userID).
+		Where("kb_dynamic_shares.deleted_at IS NULL").
+		Order("kb_dynamic_shares.created_at DESC").
+		Find(&rules).Error
+
+	if err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// ListDynamicMatchingKnowledgeBase lists the dynamic share rules whose criteria match the knowledge base
+func (r *kbShareRepository) ListDynamicMatchingKnowledgeBase(ctx context.Context, kbID string) ([]*types.KnowledgeBaseDynamicShare, error) {
+	var rules []*types.KnowledgeBaseDynamicShare
+	err := r.db.WithContext(ctx).
+		Joins("JOIN knowledge_bases ON knowledge_bases.id = ? AND knowledge_bases.tenant_id = kb_dynamic_shares.source_tenant_id "+
+			"AND knowledge_bases.deleted_at IS NULL AND knowledge_bases.is_temporary = ?", kbID, false).
+		Where("knowledge_bases.share_label <> '' AND " +
+			"LOWER(knowledge_bases.share_label) = LOWER(kb_dynamic_shares.criteria_label)").
+		Where("kb_dynamic_shares.deleted_at IS NULL").
+		Find(&rules).Error
+
+	if err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// ListKnowledgeBasesMatchingDynamic lists the knowledge bases currently matched by a dynamic share rule.
+// Temporary and soft-deleted knowledge bases are never matched.
+func (r *kbShareRepository) ListKnowledgeBasesMatchingDynamic(
+	ctx context.Context, rule *types.KnowledgeBaseDynamicShare,
+) ([]*types.KnowledgeBase, error) {
+	var kbs []*types.KnowledgeBase
+	err := r.db.WithContext(ctx).
+		Where("knowledge_bases.tenant_id = ? AND knowledge_bases.is_temporary = ?", rule.SourceTenantID, false).
+		Where(kbHasShareLabelCondition, rule.Criteria.Label).
+		Order("knowledge_bases.created_at DESC").
+		Find(&kbs).Error
+
+	if err != nil {
+		return nil, err
+	}
+	return kbs, nil
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestDynamicSharesMatchShareLabel(t *testing.T) {
+	ctx := context.Background()
+	rule := &types.KnowledgeBaseDynamicShare{
+		ID: "rule-1", OrganizationID: "org-1", CreatedByUserID: "owner", SourceTenantID: 1,
+		Criteria: types.KBShareCriteria{Label: "Public"}, Permission: types.OrgRoleViewer,
+	}
+	db := newKnowledgeTestDB(t,
+		&types.KnowledgeBase{ID: "labeled", TenantID: 1, ShareLabel: "public"},
+		&types.KnowledgeBase{ID: "tagged", TenantID: 1},
+		&types.KnowledgeBase{ID: "other-label", TenantID: 1, ShareLabel: "internal"},
+		&types.KnowledgeBase{ID: "other-tenant", TenantID: 2, ShareLabel: "public"},
+	)
+	if err := db.AutoMigrate(&types.KnowledgeTag{}, &types.KnowledgeBaseDynamicShare{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	// A document tag named like the rule, which editors of shared knowledge bases can create
+	for _, record := range []any{
+		&types.KnowledgeTag{ID: "tag-1", TenantID: 1, KnowledgeBaseID: "tagged", Name: "public"},
+		rule,
+	} {
+		if err := db.Create(record).Error; err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+	repo := NewKBShareRepository(db)
+
+	kbs, err := repo.ListKnowledgeBasesMatchingDynamic(ctx, rule)
+	if err != nil {
+		t.Fatalf("ListKnowledgeBasesMatchingDynamic() error = %v", err)
+	}
+	var ids []string
+	for _, kb := range kbs {
+		ids = append(ids, kb.ID)
+	}
+	if !slices.Equal(ids, []string{"labeled"}) {
+		t.Errorf("matched knowledge bases = %v, want only the labeled one", ids)
+	}
+
+	for kbID, want := range map[string]int{"labeled": 1, "tagged": 0, "other-tenant": 0} {
+		rules, err := repo.ListDynamicMatchingKnowledgeBase(ctx, kbID)
+		if err != nil {
+			t.Fatalf("ListDynamicMatchingKnowledgeBase(%s) error = %v", kbID, err)
+		}
+		if len(rules) != want {
+			t.Errorf("rules matching %s = %d, want %d", kbID, len(rules), want)
+		}
+	}
+}
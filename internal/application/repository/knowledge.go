@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"strings"
 
@@ -71,6 +72,19 @@ func (r *knowledgeRepository) ListKnowledgeByKnowledgeBaseID(
 	return knowledges, nil
 }
 
+// ListKnowledgeByFileName lists the knowledge in a knowledge base with the given file name, most recent first
+func (r *knowledgeRepository) ListKnowledgeByFileName(
+	ctx context.Context, tenantID uint64, kbID string, fileName string,
+) ([]*types.Knowledge, error) {
+	var knowledges []*types.Knowledge
+	if err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND knowledge_base_id = ? AND file_name = ?", tenantID, kbID, fileName).
+		Order("created_at DESC").Find(&knowledges).Error; err != nil {
+		return nil, err
+	}
+	return knowledges, nil
+}
+
 // ListPagedKnowledgeByKnowledgeBaseID lists all knowledge in a knowledge base with pagination
 func (r *knowledgeRepository) ListPagedKnowledgeByKnowledgeBaseID(
 	ctx context.Context,
@@ -80,12 +94,16 @@ func (r *knowledgeRepository) ListPagedKnowledgeByKnowledgeBaseID(
 	tagID string,
 	keyword string,
 	fileType string,
+	knowledgeIDs []string,
 ) ([]*types.Knowledge, int64, error) {
 	var knowledges []*types.Knowledge
 	var total int64
 
 	query := r.db.WithContext(ctx).Model(&types.Knowledge{}).
 		Where("tenant_id = ? AND knowledge_base_id = ?", tenantID, kbID)
+	if knowledgeIDs != nil {
+		query = query.Where("id IN ?", knowledgeIDs)
+	}
 	if tagID != "" {
 		query = query.Where("tag_id = ?", tagID)
 	}
@@ -110,6 +128,9 @@ func (r *knowledgeRepository) ListPagedKnowledgeByKnowledgeBaseID(
 	// Then query paginated data
 	dataQuery := r.db.WithContext(ctx).
 		Where("tenant_id = ? AND knowledge_base_id = ?", tenantID, kbID)
+	if knowledgeIDs != nil {
+		dataQuery = dataQuery.Where("id IN ?", knowledgeIDs)
+	}
 	if tagID != "" {
 		dataQuery = dataQuery.Where("tag_id = ?", tagID)
 	}
@@ -137,6 +158,77 @@ func (r *knowledgeRepository) ListPagedKnowledgeByKnowledgeBaseID(
 	return knowledges, total, nil
 }
 
+// ListPagedKnowledgeByTenant lists knowledge across the document knowledge bases of a tenant.
+// Counting and paging run on one joined query, so the cost does not grow with the number of knowledge bases.
+func (r *knowledgeRepository) ListPagedKnowledgeByTenant(
+	ctx context.Context,
+	tenantID uint64,
+	page *types.Pagination,
+	filter *types.KnowledgeListFilter,
+) ([]*types.Knowledge, int64, error) {
+	type KnowledgeWithKBName struct {
+		types.Knowledge
+		KnowledgeBaseName string `gorm:"column:knowledge_base_name"`
+	}
+
+	filtered := func() *gorm.DB {
+		query := r.db.WithContext(ctx).
+			Table("knowledges").
+			Joins("JOIN knowledge_bases ON knowledge_bases.id = knowledges.knowledge_base_id "+
+				"AND knowledge_bases.tenant_id = knowledges.tenant_id AND knowledge_bases.deleted_at IS NULL").
+			Where("knowledges.tenant_id = ?", tenantID).
+			Where("knowledge_bases.type = ?", types.KnowledgeBaseTypeDocument).
+			Where("knowledges.deleted_at IS NULL")
+		if filter == nil {
+			return query
+		}
+		if filter.KnowledgeBaseID != "" {
+			query = query.Where("knowledges.knowledge_base_id = ?", filter.KnowledgeBaseID)
+		}
+		if filter.TagID != "" {
+			query = query.Where("knowledges.tag_id = ?", filter.TagID)
+		}
+		if filter.Keyword != "" {
+			like := "%" + filter.Keyword + "%"
+			query = query.Where("(knowledges.file_name LIKE ? OR knowledges.title LIKE ?)", like, like)
+		}
+		switch filter.FileType {
+		case "":
+		case "manual", "url":
+			query = query.Where("knowledges.type = ?", filter.FileType)
+		default:
+			query = query.Where("knowledges.file_type = ?", filter.FileType)
+		}
+		return query
+	}
+
+	var total int64
+	if err := filtered().Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if total == 0 {
+		return []*types.Knowledge{}, 0, nil
+	}
+
+	var results []KnowledgeWithKBName
+	if err := filtered().
+		Select("knowledges.*, knowledge_bases.name as knowledge_base_name").
+		Order("knowledges.created_at DESC").
+		Offset(page.Offset()).
+		Limit(page.Limit()).
+		Scan(&results).Error; err != nil {
+		return nil, 0, err
+	}
+
+	knowledges := make([]*types.Knowledge, len(results))
+	for i, r := range results {
+		k := r.Knowledge
+		k.KnowledgeBaseName = r.KnowledgeBaseName
+		knowledges[i] = &k
+	}
+	return knowledges, total, nil
+}
+
 // UpdateKnowledge updates knowledge
 func (r *knowledgeRepository) UpdateKnowledge(ctx context.Context, knowledge *types.Knowledge) error {
 	err := r.db.WithContext(ctx).Omit(omitFieldsOnUpdate...).Save(knowledge).Error
@@ -182,7 +274,16 @@ func (r *knowledgeRepository) CheckKnowledgeExists(
 	params *types.KnowledgeCheckParams,
 ) (bool, *types.Knowledge, error) {
 	query := r.db.WithContext(ctx).Model(&types.Knowledge{}).
-		Where("tenant_id = ? AND knowledge_base_id = ? AND parse_status <> ?", tenantID, kbID, "failed")
+		Where("tenant_id = ? AND parse_status <> ?", tenantID, "failed")
+	if params.Scope == types.DuplicateScopeTenant {
+		// Any regular knowledge base of the tenant; temporary (session) knowledge bases are ignored
+		query = query.Where(
+			"knowledge_base_id IN (SELECT id FROM knowledge_bases WHERE tenant_id = ? AND is_temporary = ? AND deleted_at IS NULL)",
+			tenantID, false,
+		)
+	} else {
+		query = query.Where("knowledge_base_id = ?", kbID)
+	}
 
 	switch params.Type {
 	case "file":
@@ -273,6 +374,16 @@ func (r *knowledgeRepository) UpdateKnowledgeColumn(
 	return err
 }
 
+// ReassignTagID moves all knowledge of a knowledge base from one tag to another (empty means untagged)
+func (r *knowledgeRepository) ReassignTagID(ctx context.Context,
+	tenantID uint64, kbID string, fromTagID string, toTagID string,
+) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&types.Knowledge{}).
+		Where("tenant_id = ? AND knowledge_base_id = ? AND tag_id = ?", tenantID, kbID, fromTagID).
+		Update("tag_id", toTagID)
+	return result.RowsAffected, result.Error
+}
+
 // CountKnowledgeByKnowledgeBaseID counts the number of knowledge items in a knowledge base
 func (r *knowledgeRepository) CountKnowledgeByKnowledgeBaseID(
 	ctx context.Context,
@@ -286,6 +397,120 @@ func (r *knowledgeRepository) CountKnowledgeByKnowledgeBaseID(
 	return count, err
 }
 
+// CountKnowledgeByKnowledgeBaseIDs counts the knowledge items of several knowledge bases in one query.
+// Knowledge base IDs are globally unique, so no tenant filter is needed.
+func (r *knowledgeRepository) CountKnowledgeByKnowledgeBaseIDs(
+	ctx context.Context,
+	kbIDs []string,
+) (map[string]int64, error) {
+	counts := make(map[string]int64, len(kbIDs))
+	if len(kbIDs) == 0 {
+		return counts, nil
+	}
+	var rows []struct {
+		KnowledgeBaseID string
+		Count           int64
+	}
+	err := r.db.WithContext(ctx).Model(&types.Knowledge{}).
+		Select("knowledge_base_id, COUNT(*) AS count").
+		Where("knowledge_base_id IN ?", kbIDs).
+		Group("knowledge_base_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		counts[row.KnowledgeBaseID] = row.Count
+	}
+	return counts, nil
+}
+
+// SumKnowledgeSize sums the file and index sizes of the knowledge in the knowledge bases plus the
+// listed knowledge items, in one query.
+func (r *knowledgeRepository) SumKnowledgeSize(ctx context.Context,
+	kbIDs []string, knowledgeIDs []string,
+) (int64, error) {
+	if len(kbIDs) == 0 && len(knowledgeIDs) == 0 {
+		return 0, nil
+	}
+	query := r.db.WithContext(ctx).Model(&types.Knowledge{})
+	switch {
+	case len(kbIDs) == 0:
+		query = query.Where("id IN ?", knowledgeIDs)
+	case len(knowledgeIDs) == 0:
+		query = query.Where("knowledge_base_id IN ?", kbIDs)
+	default:
+		query = query.Where("knowledge_base_id IN ? OR id IN ?", kbIDs, knowledgeIDs)
+	}
+	var total int64
+	if err := query.Select("COALESCE(SUM(file_size + storage_size), 0)").Scan(&total).Error; err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// AggregateKnowledgeByKnowledgeBaseID returns the file type and parse status breakdown, total file size
+// and latest update of the knowledge items in a knowledge base
+func (r *knowledgeRepository) AggregateKnowledgeByKnowledgeBaseID(
+	ctx context.Context,
+	tenantID uint64,
+	kbID string,
+	knowledgeIDs []string,
+) (*types.KnowledgeAggregates, error) {
+	type groupCount struct {
+		Key   string `gorm:"column:group_key"`
+		Count int64  `gorm:"column:count"`
+		Size  int64  `gorm:"column:size"`
+	}
+	base := func() *gorm.DB {
+		query := r.db.WithContext(ctx).Model(&types.Knowledge{}).
+			Where("tenant_id = ? AND knowledge_base_id = ?", tenantID, kbID)
+		if knowledgeIDs != nil {
+			query = query.Where("id IN ?", knowledgeIDs)
+		}
+		return query
+	}
+
+	agg := &types.KnowledgeAggregates{
+		FileTypes:     make(map[string]int64),
+		ParseStatuses: make(map[string]int64),
+	}
+
+	// Non-file knowledge (URL, manual, FAQ) has no file type and is grouped by its knowledge type
+	var byType []groupCount
+	if err := base().
+		Select("LOWER(COALESCE(NULLIF(file_type, ''), type)) AS group_key, COUNT(*) AS count, COALESCE(SUM(file_size), 0) AS size").
+		Group("LOWER(COALESCE(NULLIF(file_type, ''), type))").
+		Scan(&byType).Error; err != nil {
+		return nil, err
+	}
+	for _, g := range byType {
+		agg.FileTypes[g.Key] = g.Count
+		agg.Count += g.Count
+		agg.TotalFileSize += g.Size
+	}
+
+	var byStatus []groupCount
+	if err := base().
+		Select("parse_status AS group_key, COUNT(*) AS count").
+		Group("parse_status").
+		Scan(&byStatus).Error; err != nil {
+		return nil, err
+	}
+	for _, g := range byStatus {
+		agg.ParseStatuses[g.Key] = g.Count
+	}
+
+	var latest []types.Knowledge
+	if err := base().Select("updated_at").Order("updated_at DESC").Limit(1).Find(&latest).Error; err != nil {
+		return nil, err
+	}
+	if len(latest) > 0 {
+		agg.LastUpdatedAt = &latest[0].UpdatedAt
+	}
+	return agg, nil
+}
+
 // CountKnowledgeByStatus counts the number of knowledge items with the specified parse status
 func (r *knowledgeRepository) CountKnowledgeByStatus(
 	ctx context.Context,
@@ -431,13 +656,29 @@ func (r *knowledgeRepository) SearchKnowledgeInScopes(
 		KnowledgeBaseName string `gorm:"column:knowledge_base_name"`
 	}
 
-	placeholders := make([]string, len(scopes))
+	placeholders := make([]string, 0, len(scopes))
 	args := make([]interface{}, 0, len(scopes)*2)
-	for i, s := range scopes {
-		placeholders[i] = "(?,?)"
+	var partialConditions []string
+	var partialArgs []interface{}
+	for _, s := range scopes {
+		// Scopes of partially shared KBs only cover the shared documents
+		if s.KnowledgeIDs != nil {
+			partialConditions = append(partialConditions,
+				"(knowledges.tenant_id = ? AND knowledges.knowledge_base_id = ? AND knowledges.id IN ?)")
+			partialArgs = append(partialArgs, s.TenantID, s.KBID, s.KnowledgeIDs)
+			continue
+		}
+		placeholders = append(placeholders, "(?,?)")
 		args = append(args, s.TenantID, s.KBID)
 	}
-	scopeCondition := "(knowledges.tenant_id, knowledges.knowledge_base_id) IN (" + strings.Join(placeholders, ",") + ")"
+	var conditions []string
+	if len(placeholders) > 0 {
+		conditions = append(conditions,
+			"(knowledges.tenant_id, knowledges.knowledge_base_id) IN ("+strings.Join(placeholders, ",")+")")
+	}
+	conditions = append(conditions, partialConditions...)
+	args = append(args, partialArgs...)
+	scopeCondition := "(" + strings.Join(conditions, " OR ") + ")"
 
 	query := r.db.WithContext(ctx).
 		Table("knowledges").
@@ -531,3 +772,72 @@ func (r *knowledgeRepository) ListIDsByTagID(
 		Pluck("id", &ids).Error
 	return ids, err
 }
+
+// ListIDsByRetrievalFilters returns all knowledge IDs in a knowledge base matching the metadata and creation date filters
+func (r *knowledgeRepository) ListIDsByRetrievalFilters(
+	ctx context.Context,
+	tenantID uint64,
+	kbID string,
+	filters *types.RetrievalFilters,
+) ([]string, error) {
+	db := r.db.WithContext(ctx).Model(&types.Knowledge{}).
+		Where("tenant_id = ? AND knowledge_base_id = ?", tenantID, kbID)
+	if len(filters.MetadataFilters) > 0 {
+		if r.db.Dialector.Name() == "postgres" {
+			// Containment lets PostgreSQL use the GIN index on metadata
+			filterJSON, err := json.Marshal(filters.MetadataFilters)
+			if err != nil {
+				return nil, err
+			}
+			db = db.Where("metadata @> ?::jsonb", string(filterJSON))
+		} else {
+			for key, value := range filters.MetadataFilters {
+				db = db.Where("json_extract(metadata, ?) = ?", metadataJSONPath(key), value)
+			}
+		}
+	}
+	if filters.CreatedAfter != nil {
+		db = db.Where("created_at >= ?", *filters.CreatedAfter)
+	}
+	if filters.CreatedBefore != nil {
+		db = db.Where("created_at < ?", *filters.CreatedBefore)
+	}
+	var ids []string
+	err := db.Pluck("id", &ids).Error
+	return ids, err
+}
+
+// HasMetadataKeys reports whether any knowledge in a knowledge base has metadata for at least one of the keys
+func (r *knowledgeRepository) HasMetadataKeys(
+	ctx context.Context,
+	tenantID uint64,
+	kbID string,
+	keys []string,
+) (bool, error) {
+	if len(keys) == 0 {
+		return false, nil
+	}
+	isPostgres := r.db.Dialector.Name() == "postgres"
+	conditions := make([]string, 0, len(keys))
+	args := make([]interface{}, 0, len(keys))
+	for _, key := range keys {
+		if isPostgres {
+			conditions = append(conditions, "metadata ->> ? IS NOT NULL")
+			args = append(args, key)
+		} else {
+			conditions = append(conditions, "json_extract(metadata, ?) IS NOT NULL")
+			args = append(args, metadataJSONPath(key))
+		}
+	}
+	var count int64
+	err := r.db.WithContext(ctx).Model(&types.Knowledge{}).
+		Where("tenant_id = ? AND knowledge_base_id = ?", tenantID, kbID).
+		Where("("+strings.Join(conditions, " OR ")+")", args...).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// metadataJSONPath builds a JSON path addressing a top-level metadata key literally
+func metadataJSONPath(key string) string {
+	return `$."` + key + `"`
+}
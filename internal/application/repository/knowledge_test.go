@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"gorm.io/gorm"
+)
+
+// newKnowledgeTestDB opens an in-memory database with the knowledge tables seeded with records
+func newKnowledgeTestDB(t *testing.T, records ...any) *gorm.DB {
+	t.Helper()
+	db := newTestDB(t)
+	if err := db.AutoMigrate(&types.KnowledgeBase{}, &types.Knowledge{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	for _, record := range records {
+		if err := db.Create(record).Error; err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+	return db
+}
+
+func knowledgeIDs(knowledges []*types.Knowledge) []string {
+	ids := make([]string, 0, len(knowledges))
+	for _, k := range knowledges {
+		ids = append(ids, k.ID)
+	}
+	return ids
+}
+
+func TestListKnowledgeByFileName(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	repo := NewKnowledgeRepository(newKnowledgeTestDB(t,
+		&types.Knowledge{ID: "old", TenantID: 1, KnowledgeBaseID: "kb-1", FileName: "report.pdf", CreatedAt: now.Add(-time.Hour)},
+		&types.Knowledge{ID: "new", TenantID: 1, KnowledgeBaseID: "kb-1", FileName: "report.pdf", CreatedAt: now},
+		&types.Knowledge{ID: "other-name", TenantID: 1, KnowledgeBaseID: "kb-1", FileName: "Report.pdf", CreatedAt: now},
+		&types.Knowledge{ID: "other-kb", TenantID: 1, KnowledgeBaseID: "kb-2", FileName: "report.pdf", CreatedAt: now},
+		&types.Knowledge{ID: "other-tenant", TenantID: 2, KnowledgeBaseID: "kb-1", FileName: "report.pdf", CreatedAt: now},
+	))
+
+	knowledges, err := repo.ListKnowledgeByFileName(ctx, 1, "kb-1", "report.pdf")
+	if err != nil {
+		t.Fatalf("ListKnowledgeByFileName() error = %v", err)
+	}
+	if got, want := knowledgeIDs(knowledges), []string{"new", "old"}; !slices.Equal(got, want) {
+		t.Errorf("ListKnowledgeByFileName() = %v, want %v", got, want)
+	}
+
+	knowledges, err = repo.ListKnowledgeByFileName(ctx, 1, "kb-1", "missing.pdf")
+	if err != nil || len(knowledges) != 0 {
+		t.Errorf("ListKnowledgeByFileName(missing) = %v, %v, want none", knowledgeIDs(knowledges), err)
+	}
+}
+
+func TestKnowledgeReassignTagID(t *testing.T) {
+	ctx := context.Background()
+	db := newKnowledgeTestDB(t,
+		&types.Knowledge{ID: "k-1", TenantID: 1, KnowledgeBaseID: "kb-1", TagID: "a"},
+		&types.Knowledge{ID: "k-2", TenantID: 1, KnowledgeBaseID: "kb-1", TagID: "b"},
+		&types.Knowledge{ID: "k-3", TenantID: 1, KnowledgeBaseID: "kb-2", TagID: "a"},
+		&types.Knowledge{ID: "k-4", TenantID: 2, KnowledgeBaseID: "kb-1", TagID: "a"},
+	)
+	repo := NewKnowledgeRepository(db)
+
+	moved, err := repo.ReassignTagID(ctx, 1, "kb-1", "a", "")
+	if err != nil {
+		t.Fatalf("ReassignTagID() error = %v", err)
+	}
+	if moved != 1 {
+		t.Errorf("ReassignTagID() = %d, want 1", moved)
+	}
+	want := map[string]string{"k-1": "", "k-2": "b", "k-3": "a", "k-4": "a"}
+	for id, tagID := range want {
+		var knowledge types.Knowledge
+		if err := db.First(&knowledge, "id = ?", id).Error; err != nil {
+			t.Fatalf("get %s: %v", id, err)
+		}
+		if knowledge.TagID != tagID {
+			t.Errorf("%s tag = %q, want %q", id, knowledge.TagID, tagID)
+		}
+	}
+}
+
+func TestListPagedKnowledgeByTenant(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	deleted := gorm.DeletedAt{Time: now, Valid: true}
+	knowledge := func(id, kbID string, age time.Duration, k types.Knowledge) *types.Knowledge {
+		k.ID, k.TenantID, k.KnowledgeBaseID, k.CreatedAt = id, 1, kbID, now.Add(-age)
+		return &k
+	}
+	repo := NewKnowledgeRepository(newKnowledgeTestDB(t,
+		&types.KnowledgeBase{ID: "docs", TenantID: 1, Name: "Docs", Type: types.KnowledgeBaseTypeDocument},
+		&types.KnowledgeBase{ID: "wiki", TenantID: 1, Name: "Wiki", Type: types.KnowledgeBaseTypeDocument},
+		&types.KnowledgeBase{ID: "faq", TenantID: 1, Name: "FAQ", Type: types.KnowledgeBaseTypeFAQ},
+		&types.KnowledgeBase{ID: "gone", TenantID: 1, Name: "Gone", Type: types.KnowledgeBaseTypeDocument, DeletedAt: deleted},
+		&types.KnowledgeBase{ID: "other", TenantID: 2, Name: "Other", Type: types.KnowledgeBaseTypeDocument},
+		knowledge("pdf", "docs", 1*time.Minute, types.Knowledge{Type: "file", FileType: "pdf", FileName: "Annual report.pdf", TagID: "finance"}),
+		knowledge("docx", "docs", 2*time.Minute, types.Knowledge{Type: "file", FileType: "docx", FileName: "plan.docx"}),
+		knowledge("url", "wiki", 3*time.Minute, types.Knowledge{Type: "url", Title: "Quarterly report", TagID: "finance"}),
+		knowledge("manual", "wiki", 4*time.Minute, types.Knowledge{Type: "manual", Title: "Notes"}),
+		knowledge("faq-entry", "faq", 0, types.Knowledge{Type: "manual", Title: "report"}),
+		knowledge("in-deleted-kb", "gone", 0, types.Knowledge{Type: "file", FileType: "pdf", FileName: "report.pdf"}),
+		knowledge("deleted", "docs", 0, types.Knowledge{Type: "file", FileType: "pdf", FileName: "report.pdf", DeletedAt: deleted}),
+		&types.Knowledge{ID: "other-tenant", TenantID: 2, KnowledgeBaseID: "other", Type: "file", FileType: "pdf", CreatedAt: now},
+	))
+
+	tests := []struct {
+		name      string
+		filter    *types.KnowledgeListFilter
+		page      types.Pagination
+		wantIDs   []string
+		wantTotal int64
+	}{
+		{"all", nil, types.Pagination{Page: 1, PageSize: 10}, []string{"pdf", "docx", "url", "manual"}, 4},
+		{"second page", nil, types.Pagination{Page: 2, PageSize: 3}, []string{"manual"}, 4},
+		{"knowledge base", &types.KnowledgeListFilter{KnowledgeBaseID: "wiki"}, types.Pagination{Page: 1, PageSize: 10}, []string{"url", "manual"}, 2},
+		{"tag", &types.KnowledgeListFilter{TagID: "finance"}, types.Pagination{Page: 1, PageSize: 10}, []string{"pdf", "url"}, 2},
+		{"file type", &types.KnowledgeListFilter{FileType: "pdf"}, types.Pagination{Page: 1, PageSize: 10}, []string{"pdf"}, 1},
+		{"manual", &types.KnowledgeListFilter{FileType: "manual"}, types.Pagination{Page: 1, PageSize: 10}, []string{"manual"}, 1},
+		{"url", &types.KnowledgeListFilter{FileType: "url"}, types.Pagination{Page: 1, PageSize: 10}, []string{"url"}, 1},
+		{"keyword in file name or title", &types.KnowledgeListFilter{Keyword: "report"}, types.Pagination{Page: 1, PageSize: 10}, []string{"pdf", "url"}, 2},
+		{"combined", &types.KnowledgeListFilter{KnowledgeBaseID: "docs", TagID: "finance", Keyword: "report"}, types.Pagination{Page: 1, PageSize: 10}, []string{"pdf"}, 1},
+		{"no match", &types.KnowledgeListFilter{KnowledgeBaseID: "faq"}, types.Pagination{Page: 1, PageSize: 10}, []string{}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			knowledges, total, err := repo.ListPagedKnowledgeByTenant(ctx, 1, &tt.page, tt.filter)
+			if err != nil {
+				t.Fatalf("ListPagedKnowledgeByTenant() error = %v", err)
+			}
+			if got := knowledgeIDs(knowledges); total != tt.wantTotal || !slices.Equal(got, tt.wantIDs) {
+				t.Errorf("ListPagedKnowledgeByTenant() = %v (total %d), want %v (total %d)",
+					got, total, tt.wantIDs, tt.wantTotal)
+			}
+		})
+	}
+
+	knowledges, _, err := repo.ListPagedKnowledgeByTenant(ctx, 1, &types.Pagination{Page: 1, PageSize: 1}, nil)
+	if err != nil {
+		t.Fatalf("ListPagedKnowledgeByTenant() error = %v", err)
+	}
+	if knowledges[0].KnowledgeBaseName != "Docs" {
+		t.Errorf("knowledge base name = %q, want %q", knowledges[0].KnowledgeBaseName, "Docs")
+	}
+}
@@ -112,6 +112,24 @@ func (r *knowledgeBaseRepository) TogglePinKnowledgeBase(ctx context.Context, id
 	return &kb, nil
 }
 
+// SetShareLabel sets the share label of a knowledge base of the tenant
+func (r *knowledgeBaseRepository) SetShareLabel(ctx context.Context,
+	id string, tenantID uint64, label string,
+) (*types.KnowledgeBase, error) {
+	var kb types.KnowledgeBase
+	if err := r.db.WithContext(ctx).Where("id = ? AND tenant_id = ?", id, tenantID).First(&kb).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrKnowledgeBaseNotFound
+		}
+		return nil, err
+	}
+	if err := r.db.WithContext(ctx).Model(&kb).Update("share_label", label).Error; err != nil {
+		return nil, err
+	}
+	kb.ShareLabel = label
+	return &kb, nil
+}
+
 // UpdateKnowledgeBase updates a knowledge base
 func (r *knowledgeBaseRepository) UpdateKnowledgeBase(ctx context.Context, kb *types.KnowledgeBase) error {
 	return r.db.WithContext(ctx).Save(kb).Error
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"slices"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -132,6 +133,29 @@ func (r *messageRepository) GetFirstMessageOfUser(ctx context.Context, sessionID
 	return &message, nil
 }
 
+// ListMessageMetaBySession lists all messages of a session without their content, oldest first
+func (r *messageRepository) ListMessageMetaBySession(ctx context.Context, sessionID string) ([]*types.Message, error) {
+	var messages []*types.Message
+	if err := r.db.WithContext(ctx).
+		Select("id", "session_id", "request_id", "role", "is_completed", "knowledge_id", "created_at").
+		Where("session_id = ?", sessionID).
+		Order("created_at ASC").
+		Find(&messages).Error; err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// DeleteMessagesByIDs deletes the given messages of a session
+func (r *messageRepository) DeleteMessagesByIDs(ctx context.Context, sessionID string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Where(
+		"session_id = ? AND id IN ?", sessionID, ids,
+	).Delete(&types.Message{}).Error
+}
+
 // GetMessageByRequestID retrieves a message by request ID
 func (r *messageRepository) GetMessageByRequestID(
 	ctx context.Context, sessionID string, requestID string,
@@ -152,6 +176,27 @@ func (r *messageRepository) GetMessageByRequestID(
 	return &message, nil
 }
 
+// likeEscaper escapes the LIKE wildcards and the escape character itself
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// SearchMessages lists the messages of a session whose content contains the keyword, ignoring case.
+// The keyword is matched literally: LIKE wildcards in it are escaped.
+func (r *messageRepository) SearchMessages(
+	ctx context.Context, sessionID string, keyword string, limit int,
+) ([]*types.Message, error) {
+	var messages []*types.Message
+	err := r.db.WithContext(ctx).
+		Where("session_id = ?", sessionID).
+		Where(`LOWER(content) LIKE ? ESCAPE '\'`, "%"+likeEscaper.Replace(strings.ToLower(keyword))+"%").
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&messages).Error
+	if err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
 // SearchMessagesByKeyword searches messages by keyword (ILIKE) across sessions for a tenant
 func (r *messageRepository) SearchMessagesByKeyword(
 	ctx context.Context, tenantID uint64, keyword string, sessionIDs []string, limit int,
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestSearchMessagesMatchesKeywordLiterally(t *testing.T) {
+	db := newTestDB(t)
+	// The message model carries Postgres-only column defaults, so only the searched columns are created
+	if err := db.Exec("CREATE TABLE messages (id TEXT PRIMARY KEY, session_id TEXT, content TEXT, " +
+		"created_at DATETIME, deleted_at DATETIME)").Error; err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+	for _, m := range []*types.Message{
+		{ID: "percent", SessionID: "s1", Content: "100% sure"},
+		{ID: "digits", SessionID: "s1", Content: "1000 sure"},
+		{ID: "underscore", SessionID: "s1", Content: "snake_case"},
+		{ID: "letter", SessionID: "s1", Content: "snakexcase"},
+		{ID: "backslash", SessionID: "s1", Content: `C:\temp`},
+		{ID: "upper", SessionID: "s1", Content: "Docker Compose"},
+		{ID: "other-session", SessionID: "s2", Content: "100% sure"},
+	} {
+		if err := db.Exec("INSERT INTO messages (id, session_id, content) VALUES (?, ?, ?)",
+			m.ID, m.SessionID, m.Content).Error; err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+	repo := NewMessageRepository(db)
+
+	tests := []struct {
+		keyword string
+		want    []string
+	}{
+		{"0%", []string{"percent"}},
+		{"%", []string{"percent"}},
+		{"e_c", []string{"underscore"}},
+		{"_", []string{"underscore"}},
+		{`:\t`, []string{"backslash"}},
+		{"docker", []string{"upper"}},
+		{"sure", []string{"percent", "digits"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.keyword, func(t *testing.T) {
+			messages, err := repo.SearchMessages(context.Background(), "s1", tt.keyword, 10)
+			if err != nil {
+				t.Fatalf("SearchMessages() error = %v", err)
+			}
+			var ids []string
+			for _, m := range messages {
+				ids = append(ids, m.ID)
+			}
+			slices.Sort(ids)
+			want := slices.Sorted(slices.Values(tt.want))
+			if !slices.Equal(ids, want) {
+				t.Errorf("SearchMessages(%q) = %v, want %v", tt.keyword, ids, want)
+			}
+		})
+	}
+}
@@ -3,11 +3,13 @@ package repository
 import (
 	"context"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var (
@@ -16,6 +18,7 @@ var (
 	ErrOrgMemberAlreadyExists = errors.New("member already exists in organization")
 	ErrInviteCodeNotFound     = errors.New("invite code not found")
 	ErrInviteCodeExpired      = errors.New("invite code has expired")
+	ErrInviteCodeTaken        = errors.New("invite code already in use")
 )
 
 // organizationRepository implements OrganizationRepository interface
@@ -28,9 +31,24 @@ func NewOrganizationRepository(db *gorm.DB) interfaces.OrganizationRepository {
 	return &organizationRepository{db: db}
 }
 
-// Create creates a new organization
+// Create creates a new organization; returns ErrInviteCodeTaken if its invite code is already in use
 func (r *organizationRepository) Create(ctx context.Context, org *types.Organization) error {
-	return r.db.WithContext(ctx).Create(org).Error
+	err := r.db.WithContext(ctx).Create(org).Error
+	if isInviteCodeConflict(err) {
+		return ErrInviteCodeTaken
+	}
+	return err
+}
+
+// isInviteCodeConflict reports whether err is a violation of the unique invite code index,
+// as reported by PostgreSQL or SQLite
+func isInviteCodeConflict(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "invite_code") &&
+		(strings.Contains(msg, "duplicate key") || strings.Contains(msg, "UNIQUE constraint failed"))
 }
 
 // GetByID gets an organization by ID
@@ -66,7 +84,7 @@ func (r *organizationRepository) ListByUserID(ctx context.Context, userID string
 
 	// Get organizations where user is a member
 	err := r.db.WithContext(ctx).
-		Joins("JOIN organization_members ON organization_members.organization_id = organizations.id").
+		Joins("JOIN organization_members ON organization_members.organization_id = organizations.id AND organization_members.suspended = false").
 		Where("organization_members.user_id = ?", userID).
 		Order("organizations.created_at DESC").
 		Find(&orgs).Error
@@ -77,6 +95,16 @@ func (r *organizationRepository) ListByUserID(ctx context.Context, userID string
 	return orgs, nil
 }
 
+// CountByOwnerID counts the organizations owned by a user
+func (r *organizationRepository) CountByOwnerID(ctx context.Context, ownerID string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&types.Organization{}).
+		Where("owner_id = ?", ownerID).
+		Count(&count).Error
+	return count, err
+}
+
 // ListSearchable lists organizations that are searchable (open for discovery), optionally filtered by name/description/ID
 func (r *organizationRepository) ListSearchable(ctx context.Context, query string, limit int) ([]*types.Organization, error) {
 	if limit <= 0 {
@@ -99,7 +127,7 @@ func (r *organizationRepository) ListSearchable(ctx context.Context, query strin
 // Update updates an organization (Select ensures zero values like invite_code_validity_days=0 are persisted)
 func (r *organizationRepository) Update(ctx context.Context, org *types.Organization) error {
 	return r.db.WithContext(ctx).Model(&types.Organization{}).Where("id = ?", org.ID).
-		Select("name", "description", "avatar", "require_approval", "searchable", "invite_code_validity_days", "member_limit", "updated_at").
+		Select("name", "description", "avatar", "avatar_path", "require_approval", "searchable", "invite_code_validity_days", "member_limit", "default_share_permission", "join_request_ttl_days", "storage_quota", "updated_at").
 		Updates(org).Error
 }
 
@@ -154,6 +182,24 @@ func (r *organizationRepository) UpdateMemberRole(ctx context.Context, orgID str
 	return nil
 }
 
+// SetMemberSuspended suspends or reinstates a member of an organization
+func (r *organizationRepository) SetMemberSuspended(ctx context.Context,
+	orgID string, userID string, suspended bool,
+) error {
+	result := r.db.WithContext(ctx).
+		Model(&types.OrganizationMember{}).
+		Where("organization_id = ? AND user_id = ?", orgID, userID).
+		Update("suspended", suspended)
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrOrgMemberNotFound
+	}
+	return nil
+}
+
 // ListMembers lists all members of an organization
 func (r *organizationRepository) ListMembers(ctx context.Context, orgID string) ([]*types.OrganizationMember, error) {
 	var members []*types.OrganizationMember
@@ -169,8 +215,50 @@ func (r *organizationRepository) ListMembers(ctx context.Context, orgID string)
 	return members, nil
 }
 
-// GetMember gets a specific member of an organization
+// SearchMembers finds members of an organization whose username or email contains query,
+// case-insensitively, ordered by username
+func (r *organizationRepository) SearchMembers(ctx context.Context,
+	orgID string, query string, limit int,
+) ([]*types.OrganizationMember, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	pattern := "%" + strings.ToLower(query) + "%"
+	var members []*types.OrganizationMember
+	err := r.db.WithContext(ctx).
+		Preload("User").
+		Joins("JOIN users ON users.id = organization_members.user_id AND users.deleted_at IS NULL").
+		Where("organization_members.organization_id = ?", orgID).
+		Where("LOWER(users.username) LIKE ? OR LOWER(users.email) LIKE ?", pattern, pattern).
+		Order("users.username ASC").
+		Limit(limit).
+		Find(&members).Error
+	if err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// GetMember gets a specific active member of an organization; suspended members are not returned
 func (r *organizationRepository) GetMember(ctx context.Context, orgID string, userID string) (*types.OrganizationMember, error) {
+	var member types.OrganizationMember
+	err := r.db.WithContext(ctx).
+		Where("organization_id = ? AND user_id = ? AND suspended = ?", orgID, userID, false).
+		First(&member).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrgMemberNotFound
+		}
+		return nil, err
+	}
+	return &member, nil
+}
+
+// GetMemberIncludingSuspended gets a specific member of an organization whether suspended or not
+func (r *organizationRepository) GetMemberIncludingSuspended(ctx context.Context,
+	orgID string, userID string,
+) (*types.OrganizationMember, error) {
 	var member types.OrganizationMember
 	err := r.db.WithContext(ctx).
 		Where("organization_id = ? AND user_id = ?", orgID, userID).
@@ -192,7 +280,7 @@ func (r *organizationRepository) ListMembersByUserForOrgs(ctx context.Context, u
 	}
 	var members []*types.OrganizationMember
 	err := r.db.WithContext(ctx).
-		Where("user_id = ? AND organization_id IN ?", userID, orgIDs).
+		Where("user_id = ? AND organization_id IN ? AND suspended = ?", userID, orgIDs, false).
 		Find(&members).Error
 	if err != nil {
 		return nil, err
@@ -206,6 +294,33 @@ func (r *organizationRepository) ListMembersByUserForOrgs(ctx context.Context, u
 	return out, nil
 }
 
+// CountMembersByOrganizations returns member counts per organization (batch)
+func (r *organizationRepository) CountMembersByOrganizations(ctx context.Context,
+	orgIDs []string,
+) (map[string]int64, error) {
+	out := make(map[string]int64, len(orgIDs))
+	if len(orgIDs) == 0 {
+		return out, nil
+	}
+	var rows []struct {
+		OrganizationID string
+		Count          int64
+	}
+	err := r.db.WithContext(ctx).
+		Model(&types.OrganizationMember{}).
+		Select("organization_id, COUNT(*) AS count").
+		Where("organization_id IN ?", orgIDs).
+		Group("organization_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		out[row.OrganizationID] = row.Count
+	}
+	return out, nil
+}
+
 // CountMembers counts the number of members in an organization
 func (r *organizationRepository) CountMembers(ctx context.Context, orgID string) (int64, error) {
 	var count int64
@@ -216,20 +331,39 @@ func (r *organizationRepository) CountMembers(ctx context.Context, orgID string)
 	return count, err
 }
 
-// UpdateInviteCode updates the invite code and optional expiry for an organization (expiresAt nil = never expire)
+// UpdateInviteCode updates the invite code and optional expiry for an organization (expiresAt nil = never expire).
+// Returns ErrInviteCodeTaken if the code is already used by another organization.
 func (r *organizationRepository) UpdateInviteCode(ctx context.Context, orgID string, inviteCode string, expiresAt *time.Time) error {
 	updates := map[string]interface{}{"invite_code": inviteCode, "invite_code_expires_at": expiresAt}
-	return r.db.WithContext(ctx).
+	err := r.db.WithContext(ctx).
 		Model(&types.Organization{}).
 		Where("id = ?", orgID).
 		Updates(updates).Error
+	if isInviteCodeConflict(err) {
+		return ErrInviteCodeTaken
+	}
+	return err
+}
+
+// InviteCodeExists checks whether an organization already uses the invite code
+func (r *organizationRepository) InviteCodeExists(ctx context.Context, inviteCode string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&types.Organization{}).
+		Where("invite_code = ?", inviteCode).
+		Count(&count).Error
+	return count > 0, err
 }
 
 // ----------------
 // Join Requests
 // ----------------
 
-var ErrJoinRequestNotFound = errors.New("join request not found")
+var (
+	ErrJoinRequestNotFound   = errors.New("join request not found")
+	ErrJoinRequestNotPending = errors.New("join request is not pending")
+	ErrPendingInviteNotFound = errors.New("pending invite not found")
+)
 
 // CreateJoinRequest creates a new join request
 func (r *organizationRepository) CreateJoinRequest(ctx context.Context, request *types.OrganizationJoinRequest) error {
@@ -300,6 +434,45 @@ func (r *organizationRepository) ListJoinRequests(ctx context.Context, orgID str
 	return requests, nil
 }
 
+// ListPendingJoinRequestsByUser lists the pending join requests a user submitted to any of the
+// given organizations (batch)
+func (r *organizationRepository) ListPendingJoinRequestsByUser(ctx context.Context,
+	userID string, orgIDs []string,
+) ([]*types.OrganizationJoinRequest, error) {
+	if len(orgIDs) == 0 {
+		return nil, nil
+	}
+	var requests []*types.OrganizationJoinRequest
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND organization_id IN ? AND request_type = ? AND status = ?",
+			userID, orgIDs, types.JoinRequestTypeJoin, types.JoinRequestStatusPending).
+		Find(&requests).Error
+	if err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+// ListJoinRequestsByType lists join requests of one type for an organization, optionally filtered by status
+func (r *organizationRepository) ListJoinRequestsByType(ctx context.Context,
+	orgID string, requestType types.JoinRequestType, status types.JoinRequestStatus,
+) ([]*types.OrganizationJoinRequest, error) {
+	var requests []*types.OrganizationJoinRequest
+	query := r.db.WithContext(ctx).
+		Preload("User").
+		Where("organization_id = ? AND request_type = ?", orgID, requestType)
+
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	err := query.Order("created_at DESC").Find(&requests).Error
+	if err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
 // CountJoinRequests counts join requests for an organization by status
 func (r *organizationRepository) CountJoinRequests(ctx context.Context, orgID string, status types.JoinRequestStatus) (int64, error) {
 	var count int64
@@ -311,6 +484,43 @@ func (r *organizationRepository) CountJoinRequests(ctx context.Context, orgID st
 	return count, err
 }
 
+// CountPendingJoinRequestsSince counts the pending join requests of an organization created after
+// createdAfter; a nil createdAfter counts all of them
+func (r *organizationRepository) CountPendingJoinRequestsSince(ctx context.Context,
+	orgID string, createdAfter *time.Time,
+) (int64, error) {
+	var count int64
+	query := r.db.WithContext(ctx).Model(&types.OrganizationJoinRequest{}).
+		Where("organization_id = ? AND status = ?", orgID, types.JoinRequestStatusPending)
+	if createdAfter != nil {
+		query = query.Where("created_at > ?", *createdAfter)
+	}
+	err := query.Count(&count).Error
+	return count, err
+}
+
+// ListOrganizationIDsWithPendingJoinRequests lists the organizations that have pending join requests
+func (r *organizationRepository) ListOrganizationIDsWithPendingJoinRequests(ctx context.Context) ([]string, error) {
+	var orgIDs []string
+	err := r.db.WithContext(ctx).Model(&types.OrganizationJoinRequest{}).
+		Where("status = ?", types.JoinRequestStatusPending).
+		Distinct().Pluck("organization_id", &orgIDs).Error
+	return orgIDs, err
+}
+
+// ExpireJoinRequests marks the pending join requests of an organization created at or before
+// createdBefore as expired and returns how many were expired
+func (r *organizationRepository) ExpireJoinRequests(ctx context.Context,
+	orgID string, createdBefore time.Time,
+) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Model(&types.OrganizationJoinRequest{}).
+		Where("organization_id = ? AND status = ? AND created_at <= ?",
+			orgID, types.JoinRequestStatusPending, createdBefore).
+		Update("status", types.JoinRequestStatusExpired)
+	return result.RowsAffected, result.Error
+}
+
 // UpdateJoinRequestStatus updates the status of a join request
 func (r *organizationRepository) UpdateJoinRequestStatus(ctx context.Context, id string, status types.JoinRequestStatus, reviewedBy string, reviewMessage string) error {
 	return r.db.WithContext(ctx).
@@ -323,3 +533,70 @@ func (r *organizationRepository) UpdateJoinRequestStatus(ctx context.Context, id
 			"review_message": reviewMessage,
 		}).Error
 }
+
+// CancelJoinRequest marks a pending join request as cancelled. The status check is part of the
+// update so a request reviewed concurrently is never cancelled.
+func (r *organizationRepository) CancelJoinRequest(ctx context.Context, id string) error {
+	result := r.db.WithContext(ctx).
+		Model(&types.OrganizationJoinRequest{}).
+		Where("id = ? AND status = ?", id, types.JoinRequestStatusPending).
+		Update("status", types.JoinRequestStatusCancelled)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrJoinRequestNotPending
+	}
+	return nil
+}
+
+// UpsertPendingInvite records an invite for an email; inviting the same email again updates the
+// role and renews the expiry
+func (r *organizationRepository) UpsertPendingInvite(ctx context.Context, invite *types.OrganizationPendingInvite) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "organization_id"}, {Name: "email"}},
+			DoUpdates: clause.AssignmentColumns([]string{"role", "invited_by", "expires_at", "updated_at"}),
+		}).
+		Create(invite).Error
+}
+
+// GetPendingInvite gets a pending invite by ID, including expired ones
+func (r *organizationRepository) GetPendingInvite(ctx context.Context, id string) (*types.OrganizationPendingInvite, error) {
+	var invite types.OrganizationPendingInvite
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&invite).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPendingInviteNotFound
+		}
+		return nil, err
+	}
+	return &invite, nil
+}
+
+// ListPendingInvitesByEmail lists the unexpired pending invites of an email across organizations
+func (r *organizationRepository) ListPendingInvitesByEmail(ctx context.Context,
+	email string,
+) ([]*types.OrganizationPendingInvite, error) {
+	var invites []*types.OrganizationPendingInvite
+	err := r.db.WithContext(ctx).
+		Where("email = ? AND expires_at > ?", email, time.Now()).
+		Order("created_at ASC").
+		Find(&invites).Error
+	if err != nil {
+		return nil, err
+	}
+	return invites, nil
+}
+
+// DeletePendingInvite deletes a pending invite
+func (r *organizationRepository) DeletePendingInvite(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&types.OrganizationPendingInvite{}).Error
+}
+
+// DeleteExpiredPendingInvites deletes the pending invites that expired before the given time
+func (r *organizationRepository) DeleteExpiredPendingInvites(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("expires_at <= ?", before).
+		Delete(&types.OrganizationPendingInvite{})
+	return result.RowsAffected, result.Error
+}
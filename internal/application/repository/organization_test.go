@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// newTestDB opens an in-memory database with the organization and notification tables
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger:                                   gormlogger.Discard,
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	// A single connection keeps every query on the same in-memory database
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("get sql db: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&types.Organization{}, &types.OrganizationMember{},
+		&types.CustomAgent{}, &types.AgentShare{}, &types.UserNotification{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+// seedSuspendedMember creates an organization with an active member and a suspended one
+func seedSuspendedMember(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	records := []any{
+		&types.Organization{ID: "org-1", Name: "team", OwnerID: "owner", InviteCode: "code"},
+		&types.OrganizationMember{ID: "m-1", OrganizationID: "org-1", UserID: "active", TenantID: 1, Role: types.OrgRoleEditor},
+		&types.OrganizationMember{ID: "m-2", OrganizationID: "org-1", UserID: "suspended", TenantID: 2, Role: types.OrgRoleEditor, Suspended: true},
+	}
+	for _, record := range records {
+		if err := db.Create(record).Error; err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+}
+
+func TestGetMemberExcludesSuspended(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	seedSuspendedMember(t, db)
+	r := NewOrganizationRepository(db)
+
+	if _, err := r.GetMember(ctx, "org-1", "active"); err != nil {
+		t.Fatalf("GetMember(active) error = %v", err)
+	}
+	if _, err := r.GetMember(ctx, "org-1", "suspended"); !errors.Is(err, ErrOrgMemberNotFound) {
+		t.Fatalf("GetMember(suspended) error = %v, want %v", err, ErrOrgMemberNotFound)
+	}
+	member, err := r.GetMemberIncludingSuspended(ctx, "org-1", "suspended")
+	if err != nil || !member.Suspended || member.Role != types.OrgRoleEditor {
+		t.Fatalf("GetMemberIncludingSuspended() = %+v, %v, want the suspended editor", member, err)
+	}
+
+	orgs, err := r.ListByUserID(ctx, "suspended")
+	if err != nil || len(orgs) != 0 {
+		t.Fatalf("ListByUserID(suspended) = %d orgs, %v, want none", len(orgs), err)
+	}
+
+	if err := r.SetMemberSuspended(ctx, "org-1", "suspended", false); err != nil {
+		t.Fatalf("SetMemberSuspended() error = %v", err)
+	}
+	if _, err := r.GetMember(ctx, "org-1", "suspended"); err != nil {
+		t.Fatalf("GetMember() after unsuspending error = %v", err)
+	}
+}
+
+func TestListSharedAgentsForUserExcludesSuspended(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	seedSuspendedMember(t, db)
+	records := []any{
+		&types.CustomAgent{ID: "agent-1", Name: "helper", TenantID: 3},
+		&types.AgentShare{ID: "s-1", AgentID: "agent-1", OrganizationID: "org-1", SharedByUserID: "owner", SourceTenantID: 3, Permission: types.OrgRoleViewer},
+	}
+	for _, record := range records {
+		if err := db.Create(record).Error; err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+	r := NewAgentShareRepository(db)
+
+	shares, err := r.ListSharedAgentsForUser(ctx, "active")
+	if err != nil || len(shares) != 1 {
+		t.Fatalf("ListSharedAgentsForUser(active) = %d shares, %v, want 1", len(shares), err)
+	}
+	shares, err = r.ListSharedAgentsForUser(ctx, "suspended")
+	if err != nil || len(shares) != 0 {
+		t.Fatalf("ListSharedAgentsForUser(suspended) = %d shares, %v, want none", len(shares), err)
+	}
+	if _, err := r.GetShareByAgentIDForUser(ctx, "suspended", "agent-1", 2); err == nil {
+		t.Fatal("GetShareByAgentIDForUser(suspended) found a share")
+	}
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+)
+
+// ErrPromptTemplateNotFound is returned when a prompt template is not found
+var ErrPromptTemplateNotFound = errors.New("prompt template not found")
+
+// promptTemplateRepository implements the PromptTemplateRepository interface
+type promptTemplateRepository struct {
+	db *gorm.DB
+}
+
+// NewPromptTemplateRepository creates a new prompt template repository
+func NewPromptTemplateRepository(db *gorm.DB) interfaces.PromptTemplateRepository {
+	return &promptTemplateRepository{db: db}
+}
+
+// Create creates a new prompt template
+func (r *promptTemplateRepository) Create(ctx context.Context, template *types.PromptTemplate) error {
+	return r.db.WithContext(ctx).Create(template).Error
+}
+
+// GetByID gets a prompt template by id and tenant
+func (r *promptTemplateRepository) GetByID(
+	ctx context.Context, tenantID uint64, id string,
+) (*types.PromptTemplate, error) {
+	var template types.PromptTemplate
+	if err := r.db.WithContext(ctx).Where("id = ? AND tenant_id = ?", id, tenantID).First(&template).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPromptTemplateNotFound
+		}
+		return nil, err
+	}
+	return &template, nil
+}
+
+// List lists all prompt templates for a tenant
+func (r *promptTemplateRepository) List(ctx context.Context, tenantID uint64) ([]*types.PromptTemplate, error) {
+	var templates []*types.PromptTemplate
+	if err := r.db.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("created_at DESC").
+		Find(&templates).Error; err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// Update updates a prompt template
+func (r *promptTemplateRepository) Update(ctx context.Context, template *types.PromptTemplate) error {
+	return r.db.WithContext(ctx).Save(template).Error
+}
+
+// Delete deletes a prompt template (soft delete)
+func (r *promptTemplateRepository) Delete(ctx context.Context, tenantID uint64, id string) error {
+	return r.db.WithContext(ctx).
+		Where("id = ? AND tenant_id = ?", id, tenantID).
+		Delete(&types.PromptTemplate{}).Error
+}
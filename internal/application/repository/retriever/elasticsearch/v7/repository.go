@@ -1314,3 +1314,40 @@ func (e *elasticsearchRepository) BatchUpdateChunkTagID(
 	log.Infof("[ElasticsearchV7] Successfully batch updated chunk tag ID")
 	return nil
 }
+
+// RebuildKeywordIndex re-indexes the given knowledge's documents in place with update_by_query,
+// so their content is analyzed again with the current mapping; embeddings are not touched
+func (e *elasticsearchRepository) RebuildKeywordIndex(ctx context.Context, knowledgeIDList []string) error {
+	log := logger.GetLogger(ctx)
+	if len(knowledgeIDList) == 0 {
+		return nil
+	}
+
+	query := map[string]interface{}{
+		"query": map[string]interface{}{
+			"terms": map[string]interface{}{
+				"knowledge_id.keyword": knowledgeIDList,
+			},
+		},
+	}
+	queryJSON, _ := json.Marshal(query)
+	refresh := true
+	res, err := esapi.UpdateByQueryRequest{
+		Index:     []string{e.index},
+		Body:      strings.NewReader(string(queryJSON)),
+		Conflicts: "proceed",
+		Refresh:   &refresh,
+	}.Do(ctx, e.client)
+	if err != nil {
+		log.Errorf("[ElasticsearchV7] Failed to rebuild keyword index: %v", err)
+		return err
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		log.Errorf("[ElasticsearchV7] Failed to rebuild keyword index: %s", res.String())
+		return fmt.Errorf("elasticsearch update_by_query failed with status: %d", res.StatusCode)
+	}
+
+	log.Infof("[ElasticsearchV7] Rebuilt keyword index for %d knowledge", len(knowledgeIDList))
+	return nil
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/elastic/go-elasticsearch/v8"
 	"github.com/elastic/go-elasticsearch/v8/typedapi/core/search"
 	"github.com/elastic/go-elasticsearch/v8/typedapi/types"
+	"github.com/elastic/go-elasticsearch/v8/typedapi/types/enums/conflicts"
 	"github.com/elastic/go-elasticsearch/v8/typedapi/types/enums/scriptlanguage"
 	"github.com/google/uuid"
 )
@@ -742,3 +743,28 @@ func (e *elasticsearchRepository) BatchUpdateChunkTagID(
 	log.Infof("[Elasticsearch] Successfully batch updated chunk tag ID")
 	return nil
 }
+
+// RebuildKeywordIndex re-indexes the given knowledge's documents in place with update_by_query,
+// so their content is analyzed again with the current mapping; embeddings are not touched
+func (e *elasticsearchRepository) RebuildKeywordIndex(ctx context.Context, knowledgeIDList []string) error {
+	log := logger.GetLogger(ctx)
+	if len(knowledgeIDList) == 0 {
+		return nil
+	}
+
+	query := types.NewQuery()
+	query.Terms = &types.TermsQuery{
+		TermsQuery: map[string]types.TermsQueryField{
+			"knowledge_id.keyword": knowledgeIDList,
+		},
+	}
+	_, err := e.client.UpdateByQuery(e.index).Query(query).
+		Conflicts(conflicts.Proceed).Refresh(true).Do(ctx)
+	if err != nil {
+		log.Errorf("[Elasticsearch] Failed to rebuild keyword index: %v", err)
+		return err
+	}
+
+	log.Infof("[Elasticsearch] Rebuilt keyword index for %d knowledge", len(knowledgeIDList))
+	return nil
+}
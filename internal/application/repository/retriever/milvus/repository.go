@@ -555,6 +555,14 @@ func (m *milvusRepository) BatchUpdateChunkTagID(ctx context.Context, chunkTagMa
 	return nil
 }
 
+// RebuildKeywordIndex is a no-op: Milvus derives the BM25 sparse vectors from the content
+// when rows are written and keeps them in sync itself
+func (m *milvusRepository) RebuildKeywordIndex(ctx context.Context, knowledgeIDList []string) error {
+	logger.GetLogger(ctx).Infof("[Milvus] Keyword index is maintained by Milvus, nothing to rebuild for %d knowledge",
+		len(knowledgeIDList))
+	return nil
+}
+
 func (m *milvusRepository) getBaseFilterForQuery(params types.RetrieveParams) (string, map[string]any, error) {
 	filters := make([]*universalFilterCondition, 0)
 	if len(params.KnowledgeBaseIDs) > 0 {
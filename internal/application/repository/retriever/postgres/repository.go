@@ -617,3 +617,21 @@ func (g *pgRepository) BatchUpdateChunkTagID(ctx context.Context, chunkTagMap ma
 	logger.GetLogger(ctx).Infof("[Postgres] Successfully batch updated chunk tag ID")
 	return nil
 }
+
+// RebuildKeywordIndex rewrites the content of the given knowledge's rows in place so
+// ParadeDB re-tokenizes them into the BM25 index; embeddings are not touched
+func (g *pgRepository) RebuildKeywordIndex(ctx context.Context, knowledgeIDList []string) error {
+	if len(knowledgeIDList) == 0 {
+		return nil
+	}
+	result := g.db.WithContext(ctx).Model(&pgVector{}).
+		Where("knowledge_id IN ?", knowledgeIDList).
+		Update("content", gorm.Expr("content"))
+	if result.Error != nil {
+		logger.GetLogger(ctx).Errorf("[Postgres] Failed to rebuild keyword index: %v", result.Error)
+		return result.Error
+	}
+	logger.GetLogger(ctx).Infof("[Postgres] Rebuilt keyword index for %d knowledge, rows affected: %d",
+		len(knowledgeIDList), result.RowsAffected)
+	return nil
+}
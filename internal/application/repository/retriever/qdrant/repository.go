@@ -479,6 +479,14 @@ func (q *qdrantRepository) BatchUpdateChunkTagID(ctx context.Context, chunkTagMa
 	return nil
 }
 
+// RebuildKeywordIndex is a no-op: the full-text payload index on content is maintained by
+// Qdrant on every write
+func (q *qdrantRepository) RebuildKeywordIndex(ctx context.Context, knowledgeIDList []string) error {
+	logger.GetLogger(ctx).Infof("[Qdrant] Keyword index is maintained by Qdrant, nothing to rebuild for %d knowledge",
+		len(knowledgeIDList))
+	return nil
+}
+
 func (q *qdrantRepository) getBaseFilter(params types.RetrieveParams) *qdrant.Filter {
 	must := make([]*qdrant.Condition, 0)
 	mustNot := make([]*qdrant.Condition, 0)
@@ -230,6 +230,64 @@ func (r *sqliteRepository) BatchUpdateChunkTagID(ctx context.Context, chunkTagMa
 	return nil
 }
 
+// RebuildKeywordIndex re-syncs the FTS5 index with the rows of the given knowledge. The FTS5
+// table is an external-content index shared by every tenant, so it is not rebuilt as a whole.
+// The indexed columns of a row never change after insert and the tokenizer is fixed by the table
+// definition, so an indexed row already matches its content; only the rows missing from the
+// index are added.
+func (r *sqliteRepository) RebuildKeywordIndex(ctx context.Context, knowledgeIDList []string) error {
+	if len(knowledgeIDList) == 0 {
+		return nil
+	}
+	var rows []sqliteEmbedding
+	if err := r.db.WithContext(ctx).Where("knowledge_id IN ?", knowledgeIDList).Find(&rows).Error; err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	indexed, err := r.indexedFTS5RowIDs(ctx, knowledgeIDList)
+	if err != nil {
+		logger.GetLogger(ctx).Errorf("[SQLite] Failed to read FTS5 index: %v", err)
+		return err
+	}
+
+	added := 0
+	for i := range rows {
+		if indexed[rows[i].ID] {
+			continue
+		}
+		if err := r.insertFTS5(ctx, &rows[i]); err != nil {
+			logger.GetLogger(ctx).Errorf("[SQLite] Failed to re-index row %d: %v", rows[i].ID, err)
+			return err
+		}
+		added++
+	}
+	logger.GetLogger(ctx).Infof("[SQLite] Re-synced keyword index for %d knowledge, rows: %d, added: %d",
+		len(knowledgeIDList), len(rows), added)
+	return nil
+}
+
+// indexedFTS5RowIDs returns the rowids the FTS5 index holds for the given knowledge
+func (r *sqliteRepository) indexedFTS5RowIDs(ctx context.Context, knowledgeIDList []string) (map[uint]bool, error) {
+	terms := make([]string, 0, len(knowledgeIDList))
+	for _, id := range knowledgeIDList {
+		terms = append(terms, `knowledge_id:"`+strings.ReplaceAll(id, `"`, `""`)+`"`)
+	}
+	var rowIDs []uint
+	err := r.db.WithContext(ctx).
+		Raw(`SELECT rowid FROM lite_embeddings_fts WHERE lite_embeddings_fts MATCH ?`, strings.Join(terms, " OR ")).
+		Scan(&rowIDs).Error
+	if err != nil {
+		return nil, err
+	}
+	indexed := make(map[uint]bool, len(rowIDs))
+	for _, id := range rowIDs {
+		indexed[id] = true
+	}
+	return indexed, nil
+}
+
 // --- Retrieve ---
 
 func (r *sqliteRepository) Retrieve(ctx context.Context, params types.RetrieveParams) ([]*types.RetrieveResult, error) {
@@ -501,12 +559,16 @@ func (r *sqliteRepository) copyVec(_ context.Context, srcID, dstID uint, dim int
 	), dstID, srcID)
 }
 
-func (r *sqliteRepository) syncFTS5Insert(_ context.Context, row *sqliteEmbedding) {
+func (r *sqliteRepository) syncFTS5Insert(ctx context.Context, row *sqliteEmbedding) {
 	if row.ID == 0 {
 		return
 	}
+	_ = r.insertFTS5(ctx, row)
+}
+
+func (r *sqliteRepository) insertFTS5(ctx context.Context, row *sqliteEmbedding) error {
 	sql := `INSERT INTO lite_embeddings_fts(rowid, content, source_id, chunk_id, knowledge_id, knowledge_base_id) VALUES(?, ?, ?, ?, ?, ?)`
-	r.db.Exec(sql, row.ID, row.Content, row.SourceID, row.ChunkID, row.KnowledgeID, row.KnowledgeBaseID)
+	return r.db.WithContext(ctx).Exec(sql, row.ID, row.Content, row.SourceID, row.ChunkID, row.KnowledgeID, row.KnowledgeBaseID).Error
 }
 
 type whereClause struct {
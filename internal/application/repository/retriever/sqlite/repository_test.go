@@ -0,0 +1,96 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+func newTestRepository(t *testing.T) *sqliteRepository {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: gormlogger.Discard})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	// A single connection keeps every query on the same in-memory database
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("get sql db: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	r := NewSQLiteRetrieveEngineRepository(db).(*sqliteRepository)
+	if !r.db.Migrator().HasTable("lite_embeddings_fts") {
+		t.Skip("sqlite built without FTS5, run with -tags sqlite_fts5")
+	}
+	return r
+}
+
+// keywordMatches returns the chunk IDs the keyword retriever finds for a query
+func keywordMatches(t *testing.T, r *sqliteRepository, query string) []string {
+	t.Helper()
+	results, err := r.Retrieve(context.Background(), types.RetrieveParams{
+		Query: query, TopK: 10, RetrieverType: types.KeywordsRetrieverType,
+	})
+	if err != nil {
+		t.Fatalf("Retrieve() error = %v", err)
+	}
+	var chunkIDs []string
+	for _, result := range results {
+		for _, item := range result.Results {
+			chunkIDs = append(chunkIDs, item.ChunkID)
+		}
+	}
+	return chunkIDs
+}
+
+func TestRebuildKeywordIndexAddsMissingRows(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepository(t)
+	infos := []*types.IndexInfo{
+		{SourceID: "s-1", ChunkID: "c-1", KnowledgeID: "k-1", KnowledgeBaseID: "kb-1", Content: "apple banana", IsEnabled: true},
+		{SourceID: "s-2", ChunkID: "c-2", KnowledgeID: "k-1", KnowledgeBaseID: "kb-1", Content: "apple cherry", IsEnabled: true},
+		{SourceID: "s-3", ChunkID: "c-3", KnowledgeID: "k-2", KnowledgeBaseID: "kb-2", Content: "apple durian", IsEnabled: true},
+	}
+	if err := r.BatchSave(ctx, infos, nil); err != nil {
+		t.Fatalf("BatchSave() error = %v", err)
+	}
+
+	// Lose the index entry of c-2 as if its FTS5 insert had failed
+	var lost sqliteEmbedding
+	if err := r.db.Where("chunk_id = ?", "c-2").First(&lost).Error; err != nil {
+		t.Fatalf("load row: %v", err)
+	}
+	if err := r.db.Exec(`INSERT INTO lite_embeddings_fts(lite_embeddings_fts, rowid, content, source_id, chunk_id, knowledge_id, knowledge_base_id) VALUES('delete', ?, ?, ?, ?, ?, ?)`,
+		lost.ID, lost.Content, lost.SourceID, lost.ChunkID, lost.KnowledgeID, lost.KnowledgeBaseID).Error; err != nil {
+		t.Fatalf("drop index entry: %v", err)
+	}
+	if got := keywordMatches(t, r, "cherry"); len(got) != 0 {
+		t.Fatalf("cherry matched %v before the rebuild", got)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := r.RebuildKeywordIndex(ctx, []string{"k-1"}); err != nil {
+			t.Fatalf("RebuildKeywordIndex() error = %v", err)
+		}
+	}
+
+	if got := keywordMatches(t, r, "cherry"); len(got) != 1 || got[0] != "c-2" {
+		t.Errorf("cherry matched %v, want [c-2]", got)
+	}
+	// Indexed rows are not added twice, and other knowledge is left alone
+	if got := keywordMatches(t, r, "apple"); len(got) != 3 {
+		t.Errorf("apple matched %v, want 3 chunks", got)
+	}
+	var entries int64
+	if err := r.db.Raw(`SELECT COUNT(*) FROM lite_embeddings_fts WHERE lite_embeddings_fts MATCH 'apple'`).
+		Scan(&entries).Error; err != nil {
+		t.Fatalf("count index entries: %v", err)
+	}
+	if entries != 3 {
+		t.Errorf("index holds %d entries for apple, want 3", entries)
+	}
+}
@@ -463,6 +463,14 @@ func (w *weaviateRepository) BatchUpdateChunkTagID(ctx context.Context, chunkTag
 
 }
 
+// RebuildKeywordIndex is a no-op: the BM25 inverted index on content is maintained by
+// Weaviate on every write
+func (w *weaviateRepository) RebuildKeywordIndex(ctx context.Context, knowledgeIDList []string) error {
+	logger.GetLogger(ctx).Infof("[Weaviate] Keyword index is maintained by Weaviate, nothing to rebuild for %d knowledge",
+		len(knowledgeIDList))
+	return nil
+}
+
 func (w *weaviateRepository) getBaseFilter(params types.RetrieveParams) *filters.WhereBuilder {
 	var operands []*filters.WhereBuilder
 	operands = append(operands, filters.Where().
@@ -80,10 +80,73 @@ func (r *sessionRepository) GetPagedByTenantID(
 	return sessions, total, nil
 }
 
+// GetPagedByTenantAndAgent retrieves the sessions of a tenant started with an agent, with pagination
+func (r *sessionRepository) GetPagedByTenantAndAgent(
+	ctx context.Context, tenantID uint64, agentID string, page *types.Pagination,
+) ([]*types.Session, int64, error) {
+	query := r.db.WithContext(ctx).Model(&types.Session{}).
+		Where("tenant_id = ? AND agent_id = ?", tenantID, agentID)
+	if page.ExternalUserId != "" {
+		query = query.Where("external_user_id = ?", page.ExternalUserId)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var sessions []*types.Session
+	err := query.Order("created_at DESC").
+		Offset(page.Offset()).
+		Limit(page.Limit()).
+		Find(&sessions).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return sessions, total, nil
+}
+
 // Update updates a session
 func (r *sessionRepository) Update(ctx context.Context, session *types.Session) error {
 	session.UpdatedAt = time.Now()
-	return r.db.WithContext(ctx).Where("tenant_id = ?", session.TenantID).Save(session).Error
+	return r.db.WithContext(ctx).Where("tenant_id = ?", session.TenantID).
+		Omit("pinned_knowledge_base_ids").Save(session).Error
+}
+
+// UpdatePinnedKnowledgeBases replaces the knowledge bases pinned to a session
+func (r *sessionRepository) UpdatePinnedKnowledgeBases(
+	ctx context.Context, tenantID uint64, id string, kbIDs []string,
+) error {
+	result := r.db.WithContext(ctx).Model(&types.Session{}).
+		Where("tenant_id = ? AND id = ?", tenantID, id).
+		Updates(map[string]interface{}{
+			"pinned_knowledge_base_ids": types.StringArray(kbIDs),
+			"updated_at":                time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// UpdateTitleIfEmpty sets the title only while the session has none, so a generated title
+// never overwrites one set by the user in the meantime
+func (r *sessionRepository) UpdateTitleIfEmpty(
+	ctx context.Context, tenantID uint64, id string, title string,
+) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&types.Session{}).
+		Where("tenant_id = ? AND id = ? AND (title = '' OR title IS NULL)", tenantID, id).
+		Updates(map[string]interface{}{
+			"title":      title,
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
 }
 
 // Delete deletes a session
@@ -42,6 +42,18 @@ func (r *userRepository) GetUserByID(ctx context.Context, id string) (*types.Use
 	return &user, nil
 }
 
+// GetUsersByIDs gets the users with the given IDs
+func (r *userRepository) GetUsersByIDs(ctx context.Context, ids []string) ([]*types.User, error) {
+	var users []*types.User
+	if len(ids) == 0 {
+		return users, nil
+	}
+	if err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
 // GetUserByEmail gets a user by email
 func (r *userRepository) GetUserByEmail(ctx context.Context, email string) (*types.User, error) {
 	var user types.User
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+)
+
+// userNotificationRepository implements the user notification repository interface
+type userNotificationRepository struct {
+	db *gorm.DB
+}
+
+// NewUserNotificationRepository creates a new user notification repository
+func NewUserNotificationRepository(db *gorm.DB) interfaces.UserNotificationRepository {
+	return &userNotificationRepository{db: db}
+}
+
+// Create stores a notification
+func (r *userNotificationRepository) Create(ctx context.Context, notification *types.UserNotification) error {
+	return r.db.WithContext(ctx).Create(notification).Error
+}
+
+// ListByUserID lists a page of the notifications of a user, newest first, with their total count
+func (r *userNotificationRepository) ListByUserID(ctx context.Context,
+	userID string, page *types.Pagination,
+) ([]*types.UserNotification, int64, error) {
+	query := r.db.WithContext(ctx).Model(&types.UserNotification{}).Where("user_id = ?", userID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var notifications []*types.UserNotification
+	err := query.Order("created_at DESC").
+		Offset(page.Offset()).
+		Limit(page.Limit()).
+		Find(&notifications).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return notifications, total, nil
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestListNotificationsByUserID(t *testing.T) {
+	ctx := context.Background()
+	repo := NewUserNotificationRepository(newTestDB(t))
+
+	start := time.Now()
+	for i, n := range []*types.UserNotification{
+		{ID: "n-1", UserID: "user", CreatedAt: start},
+		{ID: "n-2", UserID: "user", CreatedAt: start.Add(2 * time.Minute)},
+		{ID: "n-3", UserID: "user", CreatedAt: start.Add(time.Minute)},
+		{ID: "n-4", UserID: "other", CreatedAt: start.Add(3 * time.Minute)},
+	} {
+		n.Type = types.UserNotificationOrgRequestReviewed
+		n.Data = types.JSON(`{}`)
+		if err := repo.Create(ctx, n); err != nil {
+			t.Fatalf("Create(%d) error = %v", i, err)
+		}
+	}
+
+	tests := []struct {
+		page    types.Pagination
+		wantIDs []string
+	}{
+		{types.Pagination{Page: 1, PageSize: 2}, []string{"n-2", "n-3"}},
+		{types.Pagination{Page: 2, PageSize: 2}, []string{"n-1"}},
+	}
+	for _, tt := range tests {
+		notifications, total, err := repo.ListByUserID(ctx, "user", &tt.page)
+		if err != nil {
+			t.Fatalf("ListByUserID(page %d) error = %v", tt.page.Page, err)
+		}
+		if total != 3 {
+			t.Errorf("ListByUserID(page %d) total = %d, want 3", tt.page.Page, total)
+		}
+		var ids []string
+		for _, n := range notifications {
+			ids = append(ids, n.ID)
+		}
+		if len(ids) != len(tt.wantIDs) {
+			t.Fatalf("ListByUserID(page %d) = %v, want %v", tt.page.Page, ids, tt.wantIDs)
+		}
+		for i := range ids {
+			if ids[i] != tt.wantIDs[i] {
+				t.Fatalf("ListByUserID(page %d) = %v, want %v", tt.page.Page, ids, tt.wantIDs)
+			}
+		}
+	}
+}
@@ -503,7 +503,7 @@ func (s *agentService) getKnowledgeBaseInfos(ctx context.Context, kbIDs []string
 			pageResult, err := s.knowledgeService.ListPagedKnowledgeByKnowledgeBaseID(ctx, kbID, &types.Pagination{
 				Page:     1,
 				PageSize: 10,
-			}, "", "", "")
+			}, "", "", "", nil)
 
 			if err == nil && pageResult != nil {
 				docCount = int(pageResult.Total)
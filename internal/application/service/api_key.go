@@ -0,0 +1,340 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Scoped API key related errors
+var (
+	ErrAPIKeyNotFound              = errors.New("api key not found")
+	ErrAPIKeyNameRequired          = errors.New("api key name is required")
+	ErrAPIKeyScopeRequired         = errors.New("api key must be scoped to at least one knowledge base")
+	ErrAPIKeyTooManyKnowledgeBases = errors.New("api key is scoped to too many knowledge bases")
+	ErrAPIKeyInvalidPermission     = errors.New("api key permission must be viewer or editor")
+	ErrAPIKeyKnowledgeBaseNotOwned = errors.New("api key can only be scoped to knowledge bases of the tenant")
+	ErrAPIKeyInvalidUploadLimits   = errors.New("api key upload limits cannot be negative")
+	ErrAPIKeyUploadRateLimited     = errors.New("api key upload rate limit exceeded")
+	ErrAPIKeyUploadQuotaExceeded   = errors.New("api key daily upload quota exceeded")
+)
+
+const (
+	// maxAPIKeyKnowledgeBases bounds the scope of a single key
+	maxAPIKeyKnowledgeBases = 100
+	// apiKeySecretBytes is the number of random bytes in a key
+	apiKeySecretBytes = 32
+	// apiKeyDisplayPrefixLen is how many characters of a key are kept to tell keys apart
+	apiKeyDisplayPrefixLen = 12
+	// apiKeyLastUsedInterval throttles last_used_at writes for keys used on every request
+	apiKeyLastUsedInterval = time.Minute
+	// apiKeyUsageKeyPrefix prefixes the Redis counters of key uploads
+	apiKeyUsageKeyPrefix = "api_key_upload_usage:"
+)
+
+// apiKeyService implements the APIKeyService interface
+type apiKeyService struct {
+	repo        interfaces.APIKeyRepository
+	kbService   interfaces.KnowledgeBaseService
+	config      *config.Config
+	redisClient *redis.Client
+}
+
+// NewAPIKeyService creates a new scoped API key service; without Redis, upload limits are not enforced
+func NewAPIKeyService(
+	repo interfaces.APIKeyRepository,
+	kbService interfaces.KnowledgeBaseService,
+	config *config.Config,
+	redisClient *redis.Client,
+) interfaces.APIKeyService {
+	return &apiKeyService{repo: repo, kbService: kbService, config: config, redisClient: redisClient}
+}
+
+// CreateAPIKey creates a scoped API key and returns its plaintext once
+func (s *apiKeyService) CreateAPIKey(ctx context.Context, key *types.APIKey) (*types.CreatedAPIKey, error) {
+	tenantID, ok := types.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, ErrInvalidTenantID
+	}
+	key.Name = strings.TrimSpace(key.Name)
+	if key.Name == "" {
+		return nil, ErrAPIKeyNameRequired
+	}
+	if key.Permission == "" {
+		key.Permission = types.OrgRoleViewer
+	}
+	if !types.IsValidAPIKeyPermission(key.Permission) {
+		return nil, ErrAPIKeyInvalidPermission
+	}
+	if key.UploadRateLimit < 0 || key.DailyUploadQuota < 0 {
+		return nil, ErrAPIKeyInvalidUploadLimits
+	}
+	kbIDs, err := s.validateScope(ctx, tenantID, key.KnowledgeBaseIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	rawKey, err := generateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+	key.ID = uuid.New().String()
+	key.TenantID = tenantID
+	key.KnowledgeBaseIDs = kbIDs
+	key.KeyHash = hashAPIKey(rawKey)
+	key.KeyPrefix = rawKey[:apiKeyDisplayPrefixLen]
+	if userID, ok := types.UserIDFromContext(ctx); ok {
+		key.CreatedBy = userID
+	}
+	key.CreatedAt = time.Now()
+	key.UpdatedAt = key.CreatedAt
+
+	if err := s.repo.Create(ctx, key); err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"tenant_id": tenantID,
+		})
+		return nil, err
+	}
+	logger.Infof(ctx, "API key created, ID: %s, tenant ID: %d, knowledge bases: %d, permission: %s",
+		key.ID, tenantID, len(kbIDs), key.Permission)
+	return &types.CreatedAPIKey{APIKey: key, Key: rawKey}, nil
+}
+
+// validateScope deduplicates the knowledge base IDs of a key and checks that the tenant owns them
+func (s *apiKeyService) validateScope(ctx context.Context, tenantID uint64, ids []string) ([]string, error) {
+	kbIDs := make([]string, 0, len(ids))
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		kbIDs = append(kbIDs, id)
+	}
+	if len(kbIDs) == 0 {
+		return nil, ErrAPIKeyScopeRequired
+	}
+	if len(kbIDs) > maxAPIKeyKnowledgeBases {
+		return nil, ErrAPIKeyTooManyKnowledgeBases
+	}
+
+	kbs, err := s.kbService.GetKnowledgeBasesByIDsOnly(ctx, kbIDs)
+	if err != nil {
+		return nil, err
+	}
+	owned := make(map[string]bool, len(kbs))
+	for _, kb := range kbs {
+		if kb != nil && kb.TenantID == tenantID {
+			owned[kb.ID] = true
+		}
+	}
+	for _, id := range kbIDs {
+		if !owned[id] {
+			return nil, ErrAPIKeyKnowledgeBaseNotOwned
+		}
+	}
+	return kbIDs, nil
+}
+
+// ListAPIKeys lists the scoped API keys of the current tenant with their upload usage
+func (s *apiKeyService) ListAPIKeys(ctx context.Context) ([]*types.APIKey, error) {
+	tenantID, ok := types.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, ErrInvalidTenantID
+	}
+	keys, err := s.repo.List(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.fillUsage(ctx, keys); err != nil {
+		// Usage is informative; the keys are still listed
+		logger.Warnf(ctx, "Failed to read API key upload usage: %v", err)
+	}
+	return keys, nil
+}
+
+// DeleteAPIKey revokes a scoped API key; requests using it are rejected immediately
+func (s *apiKeyService) DeleteAPIKey(ctx context.Context, id string) error {
+	tenantID, ok := types.TenantIDFromContext(ctx)
+	if !ok {
+		return ErrInvalidTenantID
+	}
+	if _, err := s.repo.GetByID(ctx, tenantID, id); err != nil {
+		if errors.Is(err, repository.ErrAPIKeyNotFound) {
+			return ErrAPIKeyNotFound
+		}
+		return err
+	}
+	if err := s.repo.Delete(ctx, tenantID, id); err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"api_key_id": id,
+		})
+		return err
+	}
+	logger.Infof(ctx, "API key deleted, ID: %s", id)
+	return nil
+}
+
+// Authenticate looks a raw key up by its hash and records its use
+func (s *apiKeyService) Authenticate(ctx context.Context, rawKey string) (*types.APIKey, error) {
+	if !types.IsScopedAPIKey(rawKey) {
+		return nil, ErrAPIKeyNotFound
+	}
+	key, err := s.repo.GetByHash(ctx, hashAPIKey(rawKey))
+	if err != nil {
+		if errors.Is(err, repository.ErrAPIKeyNotFound) {
+			return nil, ErrAPIKeyNotFound
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyLastUsedInterval {
+		if err := s.repo.UpdateLastUsedAt(ctx, key.ID, now); err != nil {
+			logger.Warnf(ctx, "Failed to record use of API key %s: %v", key.ID, err)
+		} else {
+			key.LastUsedAt = &now
+		}
+	}
+	return key, nil
+}
+
+// uploadLimits returns the uploads per minute and per day allowed to a key, 0 meaning unlimited
+func (s *apiKeyService) uploadLimits(key *types.APIKey) (perMinute, daily int) {
+	if s.config != nil && s.config.KnowledgeBase != nil && s.config.KnowledgeBase.APIKeyUploadLimits != nil {
+		defaults := s.config.KnowledgeBase.APIKeyUploadLimits
+		perMinute, daily = max(defaults.PerMinute, 0), max(defaults.Daily, 0)
+	}
+	if key.UploadRateLimit > 0 {
+		perMinute = key.UploadRateLimit
+	}
+	if key.DailyUploadQuota > 0 {
+		daily = key.DailyUploadQuota
+	}
+	return perMinute, daily
+}
+
+// apiKeyUsageKeys returns the Redis counters of a key for the minute and the day containing now
+func apiKeyUsageKeys(keyID string, now time.Time) (minuteKey, dayKey string) {
+	return fmt.Sprintf("%s%s:minute:%d", apiKeyUsageKeyPrefix, keyID, now.Unix()/60),
+		fmt.Sprintf("%s%s:day:%s", apiKeyUsageKeyPrefix, keyID, now.Format("20060102"))
+}
+
+// nextMidnight returns when the daily quota of the day containing now starts over
+func nextMidnight(now time.Time) time.Time {
+	year, month, day := now.Date()
+	return time.Date(year, month, day+1, 0, 0, 0, 0, now.Location())
+}
+
+// ConsumeUpload counts an upload against the rate limit and daily quota of a key.
+// Rejected uploads are not counted, so a key can upload again once the window moves on.
+func (s *apiKeyService) ConsumeUpload(ctx context.Context, key *types.APIKey) error {
+	perMinute, daily := s.uploadLimits(key)
+	if s.redisClient == nil || (perMinute == 0 && daily == 0) {
+		return nil
+	}
+
+	now := time.Now()
+	minuteKey, dayKey := apiKeyUsageKeys(key.ID, now)
+	pipe := s.redisClient.TxPipeline()
+	minuteCount := pipe.Incr(ctx, minuteKey)
+	pipe.Expire(ctx, minuteKey, 2*time.Minute)
+	dayCount := pipe.Incr(ctx, dayKey)
+	pipe.ExpireAt(ctx, dayKey, nextMidnight(now).Add(time.Hour))
+	if _, err := pipe.Exec(ctx); err != nil {
+		// Do not block uploads when the counters are unavailable
+		logger.Warnf(ctx, "Failed to count upload of API key %s: %v", key.ID, err)
+		return nil
+	}
+
+	var limitErr error
+	switch {
+	case perMinute > 0 && minuteCount.Val() > int64(perMinute):
+		limitErr = ErrAPIKeyUploadRateLimited
+	case daily > 0 && dayCount.Val() > int64(daily):
+		limitErr = ErrAPIKeyUploadQuotaExceeded
+	default:
+		return nil
+	}
+	pipe = s.redisClient.TxPipeline()
+	pipe.Decr(ctx, minuteKey)
+	pipe.Decr(ctx, dayKey)
+	if _, err := pipe.Exec(ctx); err != nil {
+		logger.Warnf(ctx, "Failed to uncount rejected upload of API key %s: %v", key.ID, err)
+	}
+	logger.Warnf(ctx, "Upload of API key %s rejected: %v", key.ID, limitErr)
+	return limitErr
+}
+
+// fillUsage sets the Usage of the keys, reading all counters in one round trip.
+// Counts stay 0 when Redis is not configured.
+func (s *apiKeyService) fillUsage(ctx context.Context, keys []*types.APIKey) error {
+	now := time.Now()
+	for _, key := range keys {
+		perMinute, daily := s.uploadLimits(key)
+		key.Usage = &types.APIKeyUsage{
+			UploadRateLimit:  perMinute,
+			DailyUploadQuota: daily,
+			QuotaResetsAt:    nextMidnight(now),
+		}
+	}
+	if s.redisClient == nil || len(keys) == 0 {
+		return nil
+	}
+
+	redisKeys := make([]string, 0, len(keys)*2)
+	for _, key := range keys {
+		minuteKey, dayKey := apiKeyUsageKeys(key.ID, now)
+		redisKeys = append(redisKeys, minuteKey, dayKey)
+	}
+	values, err := s.redisClient.MGet(ctx, redisKeys...).Result()
+	if err != nil {
+		return err
+	}
+	for i, key := range keys {
+		key.Usage.UploadsThisMinute = parseUsageCount(values[2*i])
+		key.Usage.UploadsToday = parseUsageCount(values[2*i+1])
+	}
+	return nil
+}
+
+// parseUsageCount converts an MGET value to a count; missing counters are 0
+func parseUsageCount(value interface{}) int64 {
+	s, ok := value.(string)
+	if !ok {
+		return 0
+	}
+	n, _ := strconv.ParseInt(s, 10, 64)
+	return n
+}
+
+// generateAPIKey returns a new random scoped API key
+func generateAPIKey() (string, error) {
+	secret := make([]byte, apiKeySecretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return types.ScopedAPIKeyPrefix + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// hashAPIKey returns the hex encoded SHA-256 hash under which a key is stored
+func hashAPIKey(rawKey string) string {
+	sum := sha256.Sum256([]byte(rawKey))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestAPIKeyUploadLimits(t *testing.T) {
+	s := &apiKeyService{config: &config.Config{KnowledgeBase: &config.KnowledgeBaseConfig{
+		APIKeyUploadLimits: &config.APIKeyUploadLimitsConfig{PerMinute: 30, Daily: 1000},
+	}}}
+	tests := []struct {
+		name                     string
+		key                      *types.APIKey
+		wantPerMinute, wantDaily int
+	}{
+		{"configured defaults", &types.APIKey{}, 30, 1000},
+		{"key overrides", &types.APIKey{UploadRateLimit: 5, DailyUploadQuota: 50}, 5, 50},
+		{"partial override", &types.APIKey{DailyUploadQuota: 20}, 30, 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			perMinute, daily := s.uploadLimits(tt.key)
+			if perMinute != tt.wantPerMinute || daily != tt.wantDaily {
+				t.Errorf("uploadLimits() = (%d, %d), want (%d, %d)", perMinute, daily, tt.wantPerMinute, tt.wantDaily)
+			}
+		})
+	}
+
+	if perMinute, daily := (&apiKeyService{}).uploadLimits(&types.APIKey{}); perMinute != 0 || daily != 0 {
+		t.Errorf("uploadLimits() without config = (%d, %d), want unlimited", perMinute, daily)
+	}
+}
+
+func TestAPIKeyUsageWithoutRedis(t *testing.T) {
+	s := &apiKeyService{config: &config.Config{KnowledgeBase: &config.KnowledgeBaseConfig{
+		APIKeyUploadLimits: &config.APIKeyUploadLimitsConfig{PerMinute: 1, Daily: 1},
+	}}}
+	key := &types.APIKey{ID: "key-1"}
+	for i := 0; i < 3; i++ {
+		if err := s.ConsumeUpload(context.Background(), key); err != nil {
+			t.Fatalf("ConsumeUpload() without Redis error = %v, want uploads not limited", err)
+		}
+	}
+
+	if err := s.fillUsage(context.Background(), []*types.APIKey{key}); err != nil {
+		t.Fatalf("fillUsage() error = %v", err)
+	}
+	if key.Usage == nil || key.Usage.UploadRateLimit != 1 || key.Usage.DailyUploadQuota != 1 || key.Usage.UploadsToday != 0 {
+		t.Errorf("usage = %+v", key.Usage)
+	}
+	if !key.Usage.QuotaResetsAt.After(time.Now()) {
+		t.Errorf("quota resets at %v, want a future time", key.Usage.QuotaResetsAt)
+	}
+}
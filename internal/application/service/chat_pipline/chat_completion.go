@@ -51,6 +51,7 @@ func (p *PluginChatCompletion) OnEvent(
 		"message_count": len(chatManage.History) + 2,
 	})
 	chatMessages := prepareMessagesWithHistory(chatManage)
+	applyResponseFormat(chatModel, opt, chatMessages, chatManage)
 
 	// Call the chat model to generate response
 	pipelineInfo(ctx, "Completion", "model_call", map[string]interface{}{
@@ -71,6 +72,10 @@ func (p *PluginChatCompletion) OnEvent(
 		"completion_tokens": chatResponse.Usage.CompletionTokens,
 		"prompt_tokens":     chatResponse.Usage.PromptTokens,
 	})
+	if chatManage.SummaryConfig.ResponseFormat == types.ResponseFormatJSONObject {
+		chatResponse.Content = ensureJSONAnswer(ctx, chatModel, opt, chatManage, chatResponse.Content)
+	}
+	chatResponse.Content = enforceCitations(ctx, chatManage, chatResponse.Content)
 	chatManage.ChatResponse = chatResponse
 	return next()
 }
@@ -56,6 +56,10 @@ func (p *PluginChatCompletionStream) OnEvent(ctx context.Context,
 	// Prepare base messages without history
 
 	chatMessages := prepareMessagesWithHistory(chatManage)
+	applyResponseFormat(chatModel, opt, chatMessages, chatManage)
+	jsonMode := chatManage.SummaryConfig.ResponseFormat == types.ResponseFormatJSONObject
+	// Answers are buffered when they have to be checked before emission
+	bufferAnswer := jsonMode || chatManage.CitationRequired
 	pipelineInfo(ctx, "Stream", "messages_ready", map[string]interface{}{
 		"message_count": len(chatMessages),
 		"system_prompt": chatMessages[0].Content,
@@ -106,6 +110,9 @@ func (p *PluginChatCompletionStream) OnEvent(ctx context.Context,
 		var finalContent string
 		var thinkingStarted bool
 		var thinkingEnded bool
+		// In JSON mode the answer is validated (and repaired), in citation-required mode it must cite a source
+		var bufferedAnswer string
+		var bufferedEmitted bool
 
 		for response := range responseChan {
 			// Handle error responses from the stream
@@ -173,6 +180,14 @@ func (p *PluginChatCompletionStream) OnEvent(ctx context.Context,
 						logger.Errorf(ctx, "Failed to emit think close tag: %v", err)
 					}
 				}
+				if bufferAnswer {
+					bufferedAnswer += response.Content
+					if !response.Done {
+						continue
+					}
+					response.Content = finalizeBufferedAnswer(ctx, chatModel, opt, chatManage, bufferedAnswer)
+					bufferedEmitted = true
+				}
 				finalContent += response.Content
 				if err := eventBus.Emit(ctx, types.Event{
 					ID:        answerID,
@@ -188,6 +203,22 @@ func (p *PluginChatCompletionStream) OnEvent(ctx context.Context,
 			}
 		}
 
+		// Stream closed without a Done chunk: flush the buffered answer
+		if bufferAnswer && !bufferedEmitted && bufferedAnswer != "" {
+			content := finalizeBufferedAnswer(ctx, chatModel, opt, chatManage, bufferedAnswer)
+			if err := eventBus.Emit(ctx, types.Event{
+				ID:        answerID,
+				Type:      types.EventType(event.EventAgentFinalAnswer),
+				SessionID: chatManage.SessionID,
+				Data: event.AgentFinalAnswerData{
+					Content: content,
+					Done:    true,
+				},
+			}); err != nil {
+				logger.Errorf(ctx, "Failed to emit buffered answer event: %v", err)
+			}
+		}
+
 		pipelineInfo(ctx, "Stream", "channel_close", map[string]interface{}{
 			"session_id": chatManage.SessionID,
 		})
@@ -0,0 +1,46 @@
+package chatpipline
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// citationInstruction is appended to the system prompt in citation-required mode
+const citationInstruction = "Cite the retrieved information supporting each statement by its label in square brackets, " +
+	"for example [1], [FAQ-1] or [DOC-2]. Only state what the retrieved information supports; " +
+	"if it does not answer the question, say so briefly."
+
+var (
+	// citationPattern matches the labels of the answer context passages: [1], [FAQ-1] and [DOC-1]
+	citationPattern = regexp.MustCompile(`\[(?:FAQ-|DOC-)?(\d+)\]`)
+	// thinkBlockPattern matches reasoning embedded in the answer, which does not count as citing
+	thinkBlockPattern = regexp.MustCompile(`(?s)<think>.*?</think>`)
+)
+
+// hasCitation reports whether the answer cites at least one of resultCount context passages
+func hasCitation(answer string, resultCount int) bool {
+	answer = thinkBlockPattern.ReplaceAllString(answer, "")
+	for _, match := range citationPattern.FindAllStringSubmatch(answer, -1) {
+		n, err := strconv.Atoi(match[1])
+		if err == nil && n >= 1 && n <= resultCount {
+			return true
+		}
+	}
+	return false
+}
+
+// enforceCitations returns the fallback response in place of an answer citing none of the merged results
+func enforceCitations(ctx context.Context, chatManage *types.ChatManage, answer string) string {
+	if !chatManage.CitationRequired || hasCitation(answer, len(chatManage.MergeResult)) {
+		return answer
+	}
+	pipelineWarn(ctx, "Citation", "uncited_answer", map[string]interface{}{
+		"session_id":   chatManage.SessionID,
+		"answer_len":   len(answer),
+		"merged_count": len(chatManage.MergeResult),
+	})
+	return chatManage.FallbackResponse
+}
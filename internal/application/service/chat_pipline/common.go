@@ -52,15 +52,76 @@ func prepareChatModel(ctx context.Context, modelService interfaces.ModelService,
 	return chatModel, opt, nil
 }
 
+// applyResponseFormat configures JSON output for the chat call when JSON mode is enabled.
+// The system prompt always carries the JSON instruction; models with native JSON mode also get it
+// through options.
+func applyResponseFormat(chatModel chat.Chat, opt *chat.ChatOptions,
+	chatMessages []chat.Message, chatManage *types.ChatManage,
+) {
+	if chatManage.SummaryConfig.ResponseFormat != types.ResponseFormatJSONObject {
+		return
+	}
+	chat.ApplyJSONMode(chatModel, opt)
+	if len(chatMessages) > 0 {
+		chatMessages[0].Content += "\n\n" + chat.BuildJSONModeInstruction(chatManage.SummaryConfig.ResponseSchema)
+	}
+}
+
+// ensureJSONAnswer validates a JSON-mode answer and performs a single bounded repair pass on failure.
+// The original answer is returned unchanged when it cannot be repaired.
+func ensureJSONAnswer(ctx context.Context, chatModel chat.Chat, opt *chat.ChatOptions,
+	chatManage *types.ChatManage, answer string,
+) string {
+	if normalized, ok := chat.NormalizeJSONAnswer(answer); ok {
+		return normalized
+	}
+	logger.Warnf(ctx, "Answer is not valid JSON (%d chars), attempting repair", len(answer))
+	repaired, err := chat.RepairJSONAnswer(ctx, chatModel, answer, chatManage.SummaryConfig.ResponseSchema, opt)
+	if err != nil {
+		pipelineWarn(ctx, "JSONMode", "repair_failed", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+			"error":      err.Error(),
+		})
+		return answer
+	}
+	pipelineInfo(ctx, "JSONMode", "repaired", map[string]interface{}{
+		"session_id": chatManage.SessionID,
+		"answer_len": len(repaired),
+	})
+	return repaired
+}
+
+// finalizeBufferedAnswer applies the JSON mode and citation checks to a complete streamed answer
+func finalizeBufferedAnswer(ctx context.Context, chatModel chat.Chat, opt *chat.ChatOptions,
+	chatManage *types.ChatManage, answer string,
+) string {
+	if chatManage.SummaryConfig.ResponseFormat == types.ResponseFormatJSONObject {
+		answer = ensureJSONAnswer(ctx, chatModel, opt, chatManage, answer)
+	}
+	return enforceCitations(ctx, chatManage, answer)
+}
+
 // prepareMessagesWithHistory prepare complete messages including history
 func prepareMessagesWithHistory(chatManage *types.ChatManage) []chat.Message {
 	// Replace placeholders in system prompt
 	systemPrompt := renderSystemPromptPlaceholders(chatManage.SummaryConfig.Prompt)
+	if instruction := chatManage.SummaryConfig.Verbosity.Instruction(); instruction != "" {
+		systemPrompt += "\n\n" + instruction
+	}
+	if chatManage.CitationRequired {
+		systemPrompt += "\n\n" + citationInstruction
+	}
 	
 	chatMessages := []chat.Message{
 		{Role: "system", Content: systemPrompt},
 	}
 
+	// Add few-shot examples between the system prompt and the history
+	for _, example := range chatManage.SummaryConfig.FewShotExamples {
+		chatMessages = append(chatMessages, chat.Message{Role: "user", Content: example.User})
+		chatMessages = append(chatMessages, chat.Message{Role: "assistant", Content: example.Assistant})
+	}
+
 	// Add conversation history (already limited by maxRounds in load_history/rewrite plugins)
 	for _, history := range chatManage.History {
 		chatMessages = append(chatMessages, chat.Message{Role: "user", Content: history.Query})
@@ -0,0 +1,95 @@
+package chatpipline
+
+import (
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestPrepareMessagesWithHistoryVerbosity(t *testing.T) {
+	tests := []struct {
+		name      string
+		verbosity types.AnswerVerbosity
+		expect    string
+	}{
+		{name: "unset keeps prompt", verbosity: "", expect: "You are a helpful assistant."},
+		{name: "normal keeps prompt", verbosity: types.AnswerVerbosityNormal, expect: "You are a helpful assistant."},
+		{
+			name:      "brief appends instruction",
+			verbosity: types.AnswerVerbosityBrief,
+			expect:    "You are a helpful assistant.\n\n" + types.AnswerVerbosityBrief.Instruction(),
+		},
+		{
+			name:      "detailed appends instruction",
+			verbosity: types.AnswerVerbosityDetailed,
+			expect:    "You are a helpful assistant.\n\n" + types.AnswerVerbosityDetailed.Instruction(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chatManage := &types.ChatManage{UserContent: "question"}
+			chatManage.SummaryConfig.Prompt = "You are a helpful assistant."
+			chatManage.SummaryConfig.Verbosity = tt.verbosity
+
+			messages := prepareMessagesWithHistory(chatManage)
+			if len(messages) != 2 {
+				t.Fatalf("expected 2 messages, got %d", len(messages))
+			}
+			if messages[0].Content != tt.expect {
+				t.Errorf("system prompt = %q, want %q", messages[0].Content, tt.expect)
+			}
+		})
+	}
+}
+
+func TestApplyVerbosityCapsBriefAnswers(t *testing.T) {
+	tests := []struct {
+		name      string
+		verbosity types.AnswerVerbosity
+		maxTokens int
+		expect    int
+	}{
+		{name: "brief caps unlimited", verbosity: types.AnswerVerbosityBrief, maxTokens: 0, expect: types.BriefAnswerMaxCompletionTokens},
+		{name: "brief caps higher limit", verbosity: types.AnswerVerbosityBrief, maxTokens: 4096, expect: types.BriefAnswerMaxCompletionTokens},
+		{name: "brief keeps lower limit", verbosity: types.AnswerVerbosityBrief, maxTokens: 200, expect: 200},
+		{name: "detailed keeps limit", verbosity: types.AnswerVerbosityDetailed, maxTokens: 4096, expect: 4096},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := types.SummaryConfig{MaxCompletionTokens: tt.maxTokens}
+			cfg.ApplyVerbosity(tt.verbosity)
+			if cfg.MaxCompletionTokens != tt.expect {
+				t.Errorf("MaxCompletionTokens = %d, want %d", cfg.MaxCompletionTokens, tt.expect)
+			}
+			if cfg.Verbosity != tt.verbosity {
+				t.Errorf("Verbosity = %q, want %q", cfg.Verbosity, tt.verbosity)
+			}
+		})
+	}
+}
+
+func TestHasCitation(t *testing.T) {
+	tests := []struct {
+		name        string
+		answer      string
+		resultCount int
+		expect      bool
+	}{
+		{name: "numbered citation", answer: "The comet's tail points away from the sun [1].", resultCount: 2, expect: true},
+		{name: "faq and doc labels", answer: "See [FAQ-1] and [DOC-2].", resultCount: 3, expect: true},
+		{name: "no citation", answer: "The comet's tail points away from the sun.", resultCount: 2, expect: false},
+		{name: "citation out of range", answer: "As stated in [5].", resultCount: 2, expect: false},
+		{name: "no results", answer: "As stated in [1].", resultCount: 0, expect: false},
+		{name: "citation only in thinking", answer: "<think>source [1] says so</think>The tail is long.", resultCount: 1, expect: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hasCitation(tt.answer, tt.resultCount); got != tt.expect {
+				t.Errorf("hasCitation(%q, %d) = %v, want %v", tt.answer, tt.resultCount, got, tt.expect)
+			}
+		})
+	}
+}
@@ -49,6 +49,10 @@ func (p *PluginMerge) OnEvent(ctx context.Context,
 			"reason": "empty_rerank_result",
 		})
 		searchResult = chatManage.SearchResult
+		// Rerank did not run, so document boosts have not been applied yet
+		for _, sr := range searchResult {
+			applyRetrievalBoost(ctx, "Merge", chatManage, sr)
+		}
 		// Sort by score descending so dedup keeps highest-scored entries
 		sort.Slice(searchResult, func(i, j int) bool {
 			return searchResult[i].Score > searchResult[j].Score
@@ -58,9 +62,11 @@ func (p *PluginMerge) OnEvent(ctx context.Context,
 	// Deduplicate after rerank so higher-scored duplicates are preferred
 	beforeDedup := len(searchResult)
 	searchResult = removeDuplicateResults(searchResult)
+	searchResult = removeNearDuplicateResults(ctx, searchResult, chatManage.DedupThreshold)
 	pipelineInfo(ctx, "Merge", "dedup_summary", map[string]interface{}{
-		"before": beforeDedup,
-		"after":  len(searchResult),
+		"before":    beforeDedup,
+		"after":     len(searchResult),
+		"threshold": chatManage.DedupThreshold,
 	})
 
 	// Inject relevant results from chat history with similarity filtering.
@@ -182,12 +188,74 @@ func (p *PluginMerge) OnEvent(ctx context.Context,
 	})
 
 	mergedChunks = p.populateFAQAnswers(ctx, chatManage, mergedChunks)
-	mergedChunks = p.expandShortContextWithNeighbors(ctx, chatManage, mergedChunks)
+	if chatManage.ChunkContextWindow > 0 {
+		mergedChunks = p.expandWithContextWindow(ctx, chatManage, mergedChunks, chatManage.ChunkContextWindow)
+	} else {
+		mergedChunks = p.expandShortContextWithNeighbors(ctx, chatManage, mergedChunks)
+	}
 
 	chatManage.MergeResult = mergedChunks
 	return next()
 }
 
+// minNearDuplicateTokens is the smallest token set considered for near-duplicate removal;
+// very short chunks share tokens too easily to compare reliably
+const minNearDuplicateTokens = 8
+
+// removeNearDuplicateResults drops chunks whose token similarity to a higher-scored chunk
+// reaches threshold, e.g. the same passage from a re-uploaded document. The relative order
+// of the kept chunks is preserved. A threshold <= 0 disables the step.
+func removeNearDuplicateResults(
+	ctx context.Context,
+	results []*types.SearchResult,
+	threshold float64,
+) []*types.SearchResult {
+	if threshold <= 0 || len(results) < 2 {
+		return results
+	}
+
+	order := make([]int, len(results))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return results[order[i]].Score > results[order[j]].Score
+	})
+
+	tokens := make([]map[string]struct{}, len(results))
+	dropped := make([]bool, len(results))
+	kept := make([]int, 0, len(results))
+	for _, idx := range order {
+		tokens[idx] = searchutil.TokenizeSimple(results[idx].Content)
+		if len(tokens[idx]) < minNearDuplicateTokens {
+			continue
+		}
+		for _, k := range kept {
+			sim := searchutil.Jaccard(tokens[idx], tokens[k])
+			if sim >= threshold {
+				dropped[idx] = true
+				pipelineInfo(ctx, "Merge", "near_dup_drop", map[string]interface{}{
+					"chunk_id":   results[idx].ID,
+					"kept_id":    results[k].ID,
+					"similarity": sim,
+				})
+				break
+			}
+		}
+		if !dropped[idx] {
+			kept = append(kept, idx)
+		}
+	}
+
+	unique := make([]*types.SearchResult, 0, len(results))
+	for i, r := range results {
+		if !dropped[i] {
+			unique = append(unique, r)
+		}
+	}
+	return unique
+}
+
 // resolveParentChunks replaces child chunk content with parent chunk content
 // for results that have ParentChunkID set. This provides fuller context
 // for small child chunks used in parent-child chunking strategy.
@@ -0,0 +1,115 @@
+package chatpipline
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// expandWithContextWindow adds up to window adjacent chunks of the same document before and after
+// each merged text result, following the PreChunkID/NextChunkID links. Chunks already merged into
+// a result are skipped without counting towards the window.
+func (p *PluginMerge) expandWithContextWindow(
+	ctx context.Context,
+	chatManage *types.ChatManage,
+	results []*types.SearchResult,
+	window int,
+) []*types.SearchResult {
+	if len(results) == 0 || p.chunkRepo == nil || window <= 0 {
+		return results
+	}
+
+	tenantID, _ := types.TenantIDFromContext(ctx)
+	if tenantID == 0 && chatManage != nil {
+		tenantID = chatManage.TenantID
+	}
+	if tenantID == 0 {
+		pipelineWarn(ctx, "Merge", "context_window_skip", map[string]interface{}{
+			"reason": "missing_tenant",
+		})
+		return results
+	}
+
+	targets := make([]*types.SearchResult, 0, len(results))
+	baseIDs := make([]string, 0, len(results))
+	for _, r := range results {
+		if r == nil || r.ID == "" || r.Content == "" || r.ChunkType != string(types.ChunkTypeText) {
+			continue
+		}
+		targets = append(targets, r)
+		baseIDs = append(baseIDs, r.ID)
+	}
+	if len(targets) == 0 {
+		return results
+	}
+
+	chunkMap := make(map[string]*types.Chunk, len(baseIDs)*(2*window+1))
+	p.fetchChunksIfMissing(ctx, tenantID, chunkMap, baseIDs...)
+
+	for _, res := range targets {
+		baseChunk := chunkMap[res.ID]
+		if baseChunk == nil || baseChunk.ChunkType != types.ChunkTypeText {
+			continue
+		}
+		inResult := func(id string) bool {
+			return id == res.ID || containsID(res.SubChunkID, id)
+		}
+
+		prevContent := ""
+		prevIDs := []string{}
+		for cursor := baseChunk.PreChunkID; cursor != "" && len(prevIDs) < window; {
+			p.fetchChunksIfMissing(ctx, tenantID, chunkMap, cursor)
+			chunk := chunkMap[cursor]
+			if chunk == nil || chunk.KnowledgeID != baseChunk.KnowledgeID {
+				break
+			}
+			if !inResult(chunk.ID) {
+				prevContent = concatNoOverlap(chunk.Content, prevContent)
+				prevIDs = append([]string{chunk.ID}, prevIDs...)
+			}
+			cursor = chunk.PreChunkID
+		}
+
+		nextContent := ""
+		nextIDs := []string{}
+		for cursor := baseChunk.NextChunkID; cursor != "" && len(nextIDs) < window; {
+			p.fetchChunksIfMissing(ctx, tenantID, chunkMap, cursor)
+			chunk := chunkMap[cursor]
+			if chunk == nil || chunk.KnowledgeID != baseChunk.KnowledgeID {
+				break
+			}
+			if !inResult(chunk.ID) {
+				nextContent = concatNoOverlap(nextContent, chunk.Content)
+				nextIDs = append(nextIDs, chunk.ID)
+			}
+			cursor = chunk.NextChunkID
+		}
+
+		if len(prevIDs) == 0 && len(nextIDs) == 0 {
+			continue
+		}
+
+		beforeLen := runeLen(res.Content)
+		res.Content = concatNoOverlap(concatNoOverlap(prevContent, res.Content), nextContent)
+		for _, id := range append(prevIDs, nextIDs...) {
+			if !containsID(res.SubChunkID, id) {
+				res.SubChunkID = append(res.SubChunkID, id)
+			}
+		}
+		if prevContent != "" {
+			res.StartAt = max(0, res.StartAt-runeLen(prevContent))
+		}
+		res.EndAt = res.StartAt + runeLen(res.Content)
+
+		pipelineInfo(ctx, "Merge", "context_window_expand", map[string]interface{}{
+			"chunk_id":   res.ID,
+			"window":     window,
+			"prev_ids":   prevIDs,
+			"next_ids":   nextIDs,
+			"before_len": beforeLen,
+			"after_len":  runeLen(res.Content),
+		})
+	}
+
+	return results
+}
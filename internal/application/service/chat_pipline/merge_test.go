@@ -0,0 +1,113 @@
+package chatpipline
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+func TestRemoveNearDuplicateResults(t *testing.T) {
+	const passage = "the quarterly report shows revenue growth across all regions with strong demand"
+	tests := []struct {
+		name      string
+		results   []*types.SearchResult
+		threshold float64
+		want      []string
+	}{
+		{
+			name: "keeps higher scored copy of a near duplicate",
+			results: []*types.SearchResult{
+				{ID: "old", Score: 0.6, Content: passage},
+				{ID: "new", Score: 0.8, Content: passage + " overall"},
+				{ID: "other", Score: 0.7, Content: "installation requires docker compose and at least eight gigabytes of memory"},
+			},
+			threshold: types.DefaultDedupThreshold,
+			want:      []string{"new", "other"},
+		},
+		{
+			name: "disabled threshold keeps everything",
+			results: []*types.SearchResult{
+				{ID: "a", Score: 0.6, Content: passage},
+				{ID: "b", Score: 0.8, Content: passage + " overall"},
+			},
+			threshold: 0,
+			want:      []string{"a", "b"},
+		},
+		{
+			name: "short chunks are never collapsed",
+			results: []*types.SearchResult{
+				{ID: "a", Score: 0.9, Content: "revenue growth"},
+				{ID: "b", Score: 0.8, Content: "growth revenue"},
+			},
+			threshold: types.DefaultDedupThreshold,
+			want:      []string{"a", "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := removeNearDuplicateResults(context.Background(), tt.results, tt.threshold)
+			ids := make([]string, 0, len(got))
+			for _, r := range got {
+				ids = append(ids, r.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("removeNearDuplicateResults() = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+// neighborChunkRepo serves ListChunksByID from a fixed chunk set
+type neighborChunkRepo struct {
+	interfaces.ChunkRepository
+	chunks map[string]*types.Chunk
+}
+
+func (r *neighborChunkRepo) ListChunksByID(_ context.Context, _ uint64, ids []string) ([]*types.Chunk, error) {
+	var out []*types.Chunk
+	for _, id := range ids {
+		if c, ok := r.chunks[id]; ok {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func TestExpandWithContextWindow(t *testing.T) {
+	chunks := map[string]*types.Chunk{}
+	ids := []string{"c1", "c2", "c3", "c4", "c5"}
+	for i, id := range ids {
+		c := &types.Chunk{ID: id, KnowledgeID: "k1", ChunkType: types.ChunkTypeText, Content: id + " "}
+		if i > 0 {
+			c.PreChunkID = ids[i-1]
+		}
+		if i < len(ids)-1 {
+			c.NextChunkID = ids[i+1]
+		}
+		chunks[id] = c
+	}
+	chunks["c1"].PreChunkID = "other"
+	chunks["other"] = &types.Chunk{ID: "other", KnowledgeID: "k2", ChunkType: types.ChunkTypeText, Content: "other "}
+
+	p := &PluginMerge{chunkRepo: &neighborChunkRepo{chunks: chunks}}
+	ctx := context.WithValue(context.Background(), types.TenantIDContextKey, uint64(1))
+
+	res := &types.SearchResult{ID: "c2", ChunkType: string(types.ChunkTypeText), Content: "c2 c3 ", SubChunkID: []string{"c3"}}
+	p.expandWithContextWindow(ctx, &types.ChatManage{}, []*types.SearchResult{res}, 1)
+	if want := "c1 c2 c3 c4 "; res.Content != want {
+		t.Errorf("content = %q, want %q", res.Content, want)
+	}
+	if want := []string{"c3", "c1", "c4"}; !reflect.DeepEqual(res.SubChunkID, want) {
+		t.Errorf("sub chunk IDs = %v, want %v", res.SubChunkID, want)
+	}
+
+	res = &types.SearchResult{ID: "c1", ChunkType: string(types.ChunkTypeText), Content: "c1 "}
+	p.expandWithContextWindow(ctx, &types.ChatManage{}, []*types.SearchResult{res}, 2)
+	if want := "c1 c2 c3 "; res.Content != want {
+		t.Errorf("content = %q, want %q (neighbors of other documents must be skipped)", res.Content, want)
+	}
+}
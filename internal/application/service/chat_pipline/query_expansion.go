@@ -12,7 +12,9 @@ import (
 
 // runQueryExpansion performs query expansion when initial recall is low.
 // It generates query variants and runs concurrent retrieval across search targets.
-func (p *PluginSearch) runQueryExpansion(ctx context.Context, chatManage *types.ChatManage) []*types.SearchResult {
+func (p *PluginSearch) runQueryExpansion(
+	ctx context.Context, chatManage *types.ChatManage, filterScopes map[string][]string,
+) []*types.SearchResult {
 	pipelineInfo(ctx, "Search", "recall_low", map[string]interface{}{
 		"current":   len(chatManage.SearchResult),
 		"threshold": chatManage.EmbeddingTopK,
@@ -52,6 +54,10 @@ func (p *PluginSearch) runQueryExpansion(ctx context.Context, chatManage *types.
 				defer wgExp.Done()
 				sem <- struct{}{}
 				defer func() { <-sem }()
+				knowledgeIDs, restricted := scopedKnowledgeIDs(t, filterScopes)
+				if restricted && len(knowledgeIDs) == 0 {
+					return
+				}
 				paramsExp := types.SearchParams{
 					QueryText:             q,
 					VectorThreshold:       chatManage.VectorThreshold,
@@ -61,9 +67,9 @@ func (p *PluginSearch) runQueryExpansion(ctx context.Context, chatManage *types.
 					DisableKeywordsMatch:  false,
 					SkipContextEnrichment: true, // Pipeline handles context assembly in merge stage
 				}
-				// Apply knowledge ID filter if this is a partial KB search
-				if t.Type == types.SearchTargetTypeKnowledge {
-					paramsExp.KnowledgeIDs = t.KnowledgeIDs
+				// Apply knowledge ID filter if this is a partial KB search or narrowed by retrieval filters
+				if restricted {
+					paramsExp.KnowledgeIDs = knowledgeIDs
 				}
 				res, err := p.knowledgeBaseService.HybridSearch(ctx, t.KnowledgeBaseID, paramsExp)
 				if err != nil {
@@ -145,6 +145,7 @@ func (p *PluginRerank) OnEvent(ctx context.Context,
 		base := sr.Score
 		sr.Metadata["base_score"] = fmt.Sprintf("%.4f", base)
 		modelScore := rr.RelevanceScore
+		sr.RerankScore = &modelScore
 		sr.Score = compositeScore(sr, modelScore, base)
 
 		// Apply FAQ score boost if enabled
@@ -161,6 +162,7 @@ func (p *PluginRerank) OnEvent(ctx context.Context,
 				"boost_factor":   chatManage.FAQScoreBoost,
 			})
 		}
+		applyRetrievalBoost(ctx, "Rerank", chatManage, sr)
 
 		pipelineInfo(ctx, "Rerank", "composite_calc", map[string]interface{}{
 			"chunk_id":    sr.ID,
@@ -179,6 +181,7 @@ func (p *PluginRerank) OnEvent(ctx context.Context,
 		// Assign high model score for direct load items
 		modelScore := 1.0
 		sr.Score = compositeScore(sr, modelScore, base)
+		applyRetrievalBoost(ctx, "Rerank", chatManage, sr)
 		pipelineInfo(ctx, "Rerank", "composite_calc_direct", map[string]interface{}{
 			"chunk_id":    sr.ID,
 			"base_score":  fmt.Sprintf("%.4f", base),
@@ -323,6 +326,38 @@ func compositeScore(sr *types.SearchResult, modelScore, baseScore float64) float
 	return composite
 }
 
+// applyRetrievalBoost multiplies a result's score by its parent document's retrieval boost.
+// FAQ chunks already boosted by FAQScoreBoost only receive the part of the document boost that
+// exceeds it, so the larger of the two multipliers wins instead of their product compounding.
+// Unlike the FAQ boost, the result is not capped at 1.0 so boosted documents keep their relative order.
+func applyRetrievalBoost(ctx context.Context, stage string, chatManage *types.ChatManage, sr *types.SearchResult) {
+	if sr.RetrievalBoost <= 0 || sr.RetrievalBoost == types.DefaultRetrievalBoost {
+		return
+	}
+	sr.Metadata = ensureMetadata(sr.Metadata)
+	if sr.Metadata["retrieval_boosted"] == "true" {
+		return
+	}
+	factor := sr.RetrievalBoost
+	if sr.Metadata["faq_boosted"] == "true" && chatManage.FAQScoreBoost > 1.0 {
+		factor = sr.RetrievalBoost / chatManage.FAQScoreBoost
+		if factor <= 1.0 {
+			return
+		}
+	}
+	originalScore := sr.Score
+	sr.Score *= factor
+	sr.Metadata["retrieval_boosted"] = "true"
+	sr.Metadata["retrieval_original_score"] = fmt.Sprintf("%.4f", originalScore)
+	pipelineInfo(ctx, stage, "retrieval_boost", map[string]interface{}{
+		"chunk_id":       sr.ID,
+		"knowledge_id":   sr.KnowledgeID,
+		"original_score": fmt.Sprintf("%.4f", originalScore),
+		"boosted_score":  fmt.Sprintf("%.4f", sr.Score),
+		"boost_factor":   factor,
+	})
+}
+
 // applyMMR applies the MMR algorithm to the search results with pre-computed token sets
 func applyMMR(
 	ctx context.Context,
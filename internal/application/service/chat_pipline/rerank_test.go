@@ -0,0 +1,49 @@
+package chatpipline
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestApplyRetrievalBoost(t *testing.T) {
+	tests := []struct {
+		name     string
+		boost    float64
+		faqBoost float64
+		metadata map[string]string
+		score    float64
+		expect   float64
+	}{
+		{name: "unset boost leaves score", boost: 0, score: 0.5, expect: 0.5},
+		{name: "default boost leaves score", boost: 1.0, score: 0.5, expect: 0.5},
+		{name: "boost multiplies score", boost: 1.5, score: 0.5, expect: 0.75},
+		{name: "demotion lowers score", boost: 0.5, score: 0.8, expect: 0.4},
+		{name: "boost is not capped", boost: 2.0, score: 0.9, expect: 1.8},
+		{
+			name: "smaller boost than faq boost is skipped", boost: 1.1, faqBoost: 1.2,
+			metadata: map[string]string{"faq_boosted": "true"}, score: 0.6, expect: 0.6,
+		},
+		{
+			name: "larger boost than faq boost applies only the excess", boost: 1.5, faqBoost: 1.2,
+			metadata: map[string]string{"faq_boosted": "true"}, score: 0.6, expect: 0.75,
+		},
+		{
+			name: "already boosted result is left alone", boost: 2.0,
+			metadata: map[string]string{"retrieval_boosted": "true"}, score: 0.6, expect: 0.6,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sr := &types.SearchResult{Score: tt.score, RetrievalBoost: tt.boost, Metadata: tt.metadata}
+			chatManage := &types.ChatManage{FAQScoreBoost: tt.faqBoost}
+			applyRetrievalBoost(context.Background(), "Test", chatManage, sr)
+			if math.Abs(sr.Score-tt.expect) > 1e-9 {
+				t.Errorf("score = %v, want %v", sr.Score, tt.expect)
+			}
+		})
+	}
+}
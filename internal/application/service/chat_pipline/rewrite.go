@@ -12,6 +12,7 @@ import (
 
 	"github.com/Tencent/WeKnora/internal/config"
 	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/searchutil"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 )
@@ -147,6 +148,22 @@ func (p *PluginRewrite) OnEvent(ctx context.Context,
 	if chatManage.RewritePromptSystem != "" {
 		systemPrompt = chatManage.RewritePromptSystem
 	}
+	// Prefer the prompts written for the query's language, falling back to the defaults above
+	chatManage.QueryLanguage = searchutil.DetectLanguage(chatManage.Query)
+	if prompt, ok := chatManage.RewritePromptsByLanguage.ForLanguage(chatManage.QueryLanguage); ok {
+		if prompt.System != "" {
+			systemPrompt = prompt.System
+		}
+		if prompt.User != "" {
+			userPrompt = prompt.User
+		}
+		pipelineInfo(ctx, "Rewrite", "language_prompt", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+			"language":   chatManage.QueryLanguage,
+		})
+	}
+	chatManage.RewritePromptSystem = systemPrompt
+	chatManage.RewritePromptUser = userPrompt
 
 	// Format conversation history for template
 	conversationText := formatConversationHistory(historyList)
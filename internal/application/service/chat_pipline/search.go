@@ -82,15 +82,20 @@ func (p *PluginSearch) OnEvent(ctx context.Context,
 		"vector_threshold":  chatManage.VectorThreshold,
 		"keyword_threshold": chatManage.KeywordThreshold,
 	})
+	// Resolve retrieval filters once so the main search and query expansion share the same scope
+	filterScopes := p.resolveFilterScopes(ctx, chatManage)
+
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 	allResults := make([]*types.SearchResult, 0)
+	var faqExclusive bool
 
 	wg.Add(2)
 	// Goroutine 1: Knowledge base search using SearchTargets
 	go func() {
 		defer wg.Done()
-		kbResults := p.searchByTargets(ctx, chatManage)
+		kbResults, exclusive := p.searchByTargets(ctx, chatManage, filterScopes)
+		faqExclusive = exclusive
 		if len(kbResults) > 0 {
 			mu.Lock()
 			allResults = append(allResults, kbResults...)
@@ -123,9 +128,11 @@ func (p *PluginSearch) OnEvent(ctx context.Context,
 		})
 	}
 
-	// If recall is low, attempt query expansion with keyword-focused search
-	if chatManage.EnableQueryExpansion && len(chatManage.SearchResult) < max(1, chatManage.EmbeddingTopK) {
-		expResults := p.runQueryExpansion(ctx, chatManage)
+	// If recall is low, attempt query expansion with keyword-focused search.
+	// A high-confidence FAQ answer already settled the search, so expansion is skipped.
+	if chatManage.EnableQueryExpansion && !faqExclusive &&
+		len(chatManage.SearchResult) < max(1, chatManage.EmbeddingTopK) {
+		expResults := p.runQueryExpansion(ctx, chatManage, filterScopes)
 		if len(expResults) > 0 {
 			chatManage.SearchResult = append(chatManage.SearchResult, expResults...)
 		}
@@ -205,32 +212,109 @@ func buildContentSignature(content string) string {
 }
 
 // searchByTargets performs KB searches using pre-computed SearchTargets
-// This is the main search method that uses the unified search targets
+// This is the main search method that uses the unified search targets.
+// When FAQExclusiveAboveThreshold is set, FAQ knowledge bases are searched first and document
+// knowledge bases are skipped if an FAQ hit reaches FAQDirectAnswerThreshold; the returned flag
+// reports that the FAQ answer was used exclusively.
 func (p *PluginSearch) searchByTargets(
 	ctx context.Context,
 	chatManage *types.ChatManage,
-) []*types.SearchResult {
+	filterScopes map[string][]string,
+) ([]*types.SearchResult, bool) {
 	if len(chatManage.SearchTargets) == 0 {
-		return nil
+		return nil, false
 	}
 
+	if chatManage.FAQPriorityEnabled && chatManage.FAQExclusiveAboveThreshold {
+		faqTargets, docTargets := p.splitFAQTargets(ctx, chatManage.SearchTargets)
+		if len(faqTargets) > 0 && len(docTargets) > 0 {
+			faqResults := p.searchTargets(ctx, chatManage, faqTargets, filterScopes)
+			if top := topScore(faqResults); top >= chatManage.FAQDirectAnswerThreshold {
+				pipelineInfo(ctx, "Search", "faq_exclusive", map[string]interface{}{
+					"faq_hits":        len(faqResults),
+					"top_score":       fmt.Sprintf("%.4f", top),
+					"threshold":       chatManage.FAQDirectAnswerThreshold,
+					"skipped_targets": len(docTargets),
+				})
+				return faqResults, true
+			}
+			return append(faqResults, p.searchTargets(ctx, chatManage, docTargets, filterScopes)...), false
+		}
+	}
+	return p.searchTargets(ctx, chatManage, chatManage.SearchTargets, filterScopes), false
+}
+
+// splitFAQTargets separates targets in FAQ knowledge bases from the rest. If the knowledge bases
+// cannot be loaded, every target is treated as a document target.
+func (p *PluginSearch) splitFAQTargets(
+	ctx context.Context,
+	targets types.SearchTargets,
+) (faqTargets, docTargets types.SearchTargets) {
+	kbIDs := make([]string, 0, len(targets))
+	for _, t := range targets {
+		kbIDs = append(kbIDs, t.KnowledgeBaseID)
+	}
+	kbs, err := p.knowledgeBaseService.GetKnowledgeBasesByIDsOnly(ctx, kbIDs)
+	if err != nil {
+		pipelineWarn(ctx, "Search", "faq_split_error", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return nil, targets
+	}
+	faqKBs := make(map[string]bool, len(kbs))
+	for _, kb := range kbs {
+		if kb != nil && kb.Type == types.KnowledgeBaseTypeFAQ {
+			faqKBs[kb.ID] = true
+		}
+	}
+	for _, t := range targets {
+		if faqKBs[t.KnowledgeBaseID] {
+			faqTargets = append(faqTargets, t)
+		} else {
+			docTargets = append(docTargets, t)
+		}
+	}
+	return faqTargets, docTargets
+}
+
+// topScore returns the highest score among the results, or -1 when there are none
+func topScore(results []*types.SearchResult) float64 {
+	top := -1.0
+	for _, r := range results {
+		if r.Score > top {
+			top = r.Score
+		}
+	}
+	return top
+}
+
+// searchTargets searches the given targets concurrently
+func (p *PluginSearch) searchTargets(
+	ctx context.Context,
+	chatManage *types.ChatManage,
+	targets types.SearchTargets,
+	filterScopes map[string][]string,
+) []*types.SearchResult {
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 	var results []*types.SearchResult
 
 	// Search each target concurrently
-	for _, target := range chatManage.SearchTargets {
+	for _, target := range targets {
 		wg.Add(1)
 		go func(t *types.SearchTarget) {
 			defer wg.Done()
 
 			// List of knowledge IDs to perform vector search on
-			// Default to all IDs in the target
-			searchKnowledgeIDs := t.KnowledgeIDs
+			// Default to all IDs in the target, narrowed by any metadata or date filters
+			searchKnowledgeIDs, restricted := scopedKnowledgeIDs(t, filterScopes)
+			if restricted && len(searchKnowledgeIDs) == 0 {
+				return
+			}
 
 			// Try direct loading for specific knowledge targets
 			if t.Type == types.SearchTargetTypeKnowledge {
-				directResults, skippedIDs := p.tryDirectChunkLoading(ctx, chatManage.TenantID, t.KnowledgeIDs)
+				directResults, skippedIDs := p.tryDirectChunkLoading(ctx, chatManage.TenantID, searchKnowledgeIDs)
 
 				if len(directResults) > 0 {
 					for _, r := range directResults {
@@ -247,7 +331,7 @@ func (p *PluginSearch) searchByTargets(
 				}
 
 				// If all files were loaded directly, we don't need to search anything
-				if len(skippedIDs) == 0 && len(t.KnowledgeIDs) > 0 {
+				if len(skippedIDs) == 0 && len(searchKnowledgeIDs) > 0 {
 					return
 				}
 
@@ -268,8 +352,8 @@ func (p *PluginSearch) searchByTargets(
 				MatchCount:            chatManage.EmbeddingTopK,
 				SkipContextEnrichment: true, // Pipeline handles context assembly in merge stage
 			}
-			// Apply knowledge ID filter if this is a partial KB search
-			if t.Type == types.SearchTargetTypeKnowledge {
+			// Apply knowledge ID filter if this is a partial KB search or metadata-filtered
+			if restricted {
 				params.KnowledgeIDs = searchKnowledgeIDs
 			}
 			res, err := p.knowledgeBaseService.HybridSearch(ctx, t.KnowledgeBaseID, params)
@@ -304,6 +388,79 @@ func (p *PluginSearch) searchByTargets(
 	return results
 }
 
+// resolveFilterScopes resolves the request's metadata and creation date filters into the matching
+// knowledge IDs per knowledge base. Knowledge bases where the filters do not apply (or where the
+// lookup fails) are left out of the result so they are searched unfiltered.
+func (p *PluginSearch) resolveFilterScopes(ctx context.Context, chatManage *types.ChatManage) map[string][]string {
+	if chatManage.RetrievalFilters.IsEmpty() {
+		return nil
+	}
+	scopes := make(map[string][]string)
+	for _, t := range chatManage.SearchTargets {
+		if _, done := scopes[t.KnowledgeBaseID]; done {
+			continue
+		}
+		tenantID := t.TenantID
+		if tenantID == 0 {
+			tenantID = chatManage.TenantID
+		}
+		ids, applied, err := p.knowledgeService.ResolveRetrievalScope(
+			ctx, tenantID, t.KnowledgeBaseID, &chatManage.RetrievalFilters,
+		)
+		if err != nil {
+			pipelineWarn(ctx, "Search", "retrieval_filter_error", map[string]interface{}{
+				"kb_id": t.KnowledgeBaseID,
+				"error": err.Error(),
+			})
+			continue
+		}
+		if !applied {
+			pipelineWarn(ctx, "Search", "retrieval_filter_skip", map[string]interface{}{
+				"kb_id":  t.KnowledgeBaseID,
+				"reason": "no_documents_with_filter_keys",
+			})
+			continue
+		}
+		pipelineInfo(ctx, "Search", "retrieval_filter", map[string]interface{}{
+			"kb_id":         t.KnowledgeBaseID,
+			"matched_count": len(ids),
+		})
+		scopes[t.KnowledgeBaseID] = ids
+	}
+	return scopes
+}
+
+// scopedKnowledgeIDs returns the knowledge IDs a target's search is restricted to.
+// restricted is false when the whole knowledge base should be searched.
+func scopedKnowledgeIDs(t *types.SearchTarget, filterScopes map[string][]string) (ids []string, restricted bool) {
+	matched, filtered := filterScopes[t.KnowledgeBaseID]
+	switch {
+	case t.Type == types.SearchTargetTypeKnowledge && filtered:
+		return intersectIDs(t.KnowledgeIDs, matched), true
+	case t.Type == types.SearchTargetTypeKnowledge:
+		return t.KnowledgeIDs, true
+	case filtered:
+		return matched, true
+	default:
+		return nil, false
+	}
+}
+
+// intersectIDs returns the IDs present in both a and b, preserving the order of a
+func intersectIDs(a, b []string) []string {
+	set := make(map[string]struct{}, len(b))
+	for _, id := range b {
+		set[id] = struct{}{}
+	}
+	result := make([]string, 0, len(a))
+	for _, id := range a {
+		if _, ok := set[id]; ok {
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
 // tryDirectChunkLoading attempts to load chunks for given knowledge IDs directly
 // Returns loaded results and a list of knowledge IDs that were skipped (e.g. due to size limits)
 func (p *PluginSearch) tryDirectChunkLoading(ctx context.Context, tenantID uint64, knowledgeIDs []string) ([]*types.SearchResult, []string) {
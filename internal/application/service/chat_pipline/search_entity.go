@@ -223,5 +223,6 @@ func chunk2SearchResult(chunk *types.Chunk, knowledge *types.Knowledge) *types.S
 		KnowledgeSource:   knowledge.Source,
 		ChunkMetadata:     chunk.Metadata,
 		KnowledgeBaseID:   knowledge.KnowledgeBaseID,
+		RetrievalBoost:    knowledge.EffectiveRetrievalBoost(),
 	}
 }
@@ -0,0 +1,138 @@
+package chatpipline
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+func TestScopedKnowledgeIDs(t *testing.T) {
+	scopes := map[string][]string{
+		"kb-filtered": {"k1", "k3"},
+		"kb-empty":    {},
+	}
+	tests := []struct {
+		name           string
+		target         *types.SearchTarget
+		wantIDs        []string
+		wantRestricted bool
+	}{
+		{
+			name:           "unfiltered knowledge base searches everything",
+			target:         &types.SearchTarget{Type: types.SearchTargetTypeKnowledgeBase, KnowledgeBaseID: "kb-other"},
+			wantIDs:        nil,
+			wantRestricted: false,
+		},
+		{
+			name:           "filtered knowledge base is narrowed to matches",
+			target:         &types.SearchTarget{Type: types.SearchTargetTypeKnowledgeBase, KnowledgeBaseID: "kb-filtered"},
+			wantIDs:        []string{"k1", "k3"},
+			wantRestricted: true,
+		},
+		{
+			name:           "filtered knowledge base without matches searches nothing",
+			target:         &types.SearchTarget{Type: types.SearchTargetTypeKnowledgeBase, KnowledgeBaseID: "kb-empty"},
+			wantIDs:        []string{},
+			wantRestricted: true,
+		},
+		{
+			name: "unfiltered knowledge target keeps its files",
+			target: &types.SearchTarget{
+				Type: types.SearchTargetTypeKnowledge, KnowledgeBaseID: "kb-other", KnowledgeIDs: []string{"k1", "k2"},
+			},
+			wantIDs:        []string{"k1", "k2"},
+			wantRestricted: true,
+		},
+		{
+			name: "filtered knowledge target keeps only matching files",
+			target: &types.SearchTarget{
+				Type: types.SearchTargetTypeKnowledge, KnowledgeBaseID: "kb-filtered", KnowledgeIDs: []string{"k1", "k2"},
+			},
+			wantIDs:        []string{"k1"},
+			wantRestricted: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids, restricted := scopedKnowledgeIDs(tt.target, scopes)
+			if restricted != tt.wantRestricted || !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Errorf("scopedKnowledgeIDs() = (%v, %v), want (%v, %v)", ids, restricted, tt.wantIDs, tt.wantRestricted)
+			}
+		})
+	}
+}
+
+// scoredKBService returns one hit per knowledge base with a fixed score and records the searched IDs
+type scoredKBService struct {
+	interfaces.KnowledgeBaseService
+	kbs      map[string]*types.KnowledgeBase
+	scores   map[string]float64
+	mu       sync.Mutex
+	searched []string
+}
+
+func (s *scoredKBService) GetKnowledgeBasesByIDsOnly(ctx context.Context, ids []string) ([]*types.KnowledgeBase, error) {
+	var out []*types.KnowledgeBase
+	for _, id := range ids {
+		out = append(out, s.kbs[id])
+	}
+	return out, nil
+}
+
+func (s *scoredKBService) HybridSearch(ctx context.Context,
+	id string, params types.SearchParams,
+) ([]*types.SearchResult, error) {
+	s.mu.Lock()
+	s.searched = append(s.searched, id)
+	s.mu.Unlock()
+	return []*types.SearchResult{{ID: "chunk-" + id, Score: s.scores[id]}}, nil
+}
+
+func TestSearchByTargetsFAQExclusive(t *testing.T) {
+	tests := []struct {
+		name          string
+		exclusive     bool
+		faqScore      float64
+		wantSearched  int
+		wantExclusive bool
+	}{
+		{"blends by default", false, 0.95, 2, false},
+		{"skips documents above threshold", true, 0.95, 1, true},
+		{"searches documents below threshold", true, 0.5, 2, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kbService := &scoredKBService{
+				kbs: map[string]*types.KnowledgeBase{
+					"kb-faq": {ID: "kb-faq", Type: types.KnowledgeBaseTypeFAQ},
+					"kb-doc": {ID: "kb-doc", Type: types.KnowledgeBaseTypeDocument},
+				},
+				scores: map[string]float64{"kb-faq": tt.faqScore, "kb-doc": 0.8},
+			}
+			p := &PluginSearch{knowledgeBaseService: kbService}
+			chatManage := &types.ChatManage{
+				SearchTargets: types.SearchTargets{
+					{Type: types.SearchTargetTypeKnowledgeBase, KnowledgeBaseID: "kb-faq"},
+					{Type: types.SearchTargetTypeKnowledgeBase, KnowledgeBaseID: "kb-doc"},
+				},
+				FAQPriorityEnabled:         true,
+				FAQDirectAnswerThreshold:   0.9,
+				FAQExclusiveAboveThreshold: tt.exclusive,
+			}
+
+			results, exclusive := p.searchByTargets(context.Background(), chatManage, nil)
+			if exclusive != tt.wantExclusive {
+				t.Errorf("exclusive = %v, want %v", exclusive, tt.wantExclusive)
+			}
+			if len(kbService.searched) != tt.wantSearched || len(results) != tt.wantSearched {
+				t.Errorf("searched %v with %d results, want %d knowledge bases",
+					kbService.searched, len(results), tt.wantSearched)
+			}
+		})
+	}
+}
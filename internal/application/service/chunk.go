@@ -6,9 +6,11 @@ package service
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/Tencent/WeKnora/internal/application/service/retriever"
 	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/embedding"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 )
@@ -180,6 +182,33 @@ func (s *chunkService) ListPagedChunksByKnowledgeID(ctx context.Context,
 	return types.NewPageResult(total, page, chunks), nil
 }
 
+// retrievableChunkTypes are the chunk types that are indexed for retrieval
+var retrievableChunkTypes = []types.ChunkType{
+	types.ChunkTypeText,
+	types.ChunkTypeImageOCR,
+	types.ChunkTypeImageCaption,
+	types.ChunkTypeTableSummary,
+	types.ChunkTypeTableColumn,
+	types.ChunkTypeFAQ,
+}
+
+// ListChunksByKnowledge lists the retrievable chunks of a knowledge item with pagination
+// Unlike ListPagedChunksByKnowledgeID it includes image, table and FAQ chunks, so users can
+// see exactly what was indexed for a document
+// Parameters:
+//   - ctx: Context with authentication and request information
+//   - knowledgeID: ID of the knowledge document
+//   - page: Pagination parameters including page number and page size
+//
+// Returns:
+//   - *types.PageResult: Paginated result containing chunks ordered by index
+//   - error: Any error encountered during retrieval
+func (s *chunkService) ListChunksByKnowledge(ctx context.Context,
+	knowledgeID string, page *types.Pagination,
+) (*types.PageResult, error) {
+	return s.ListPagedChunksByKnowledgeID(ctx, knowledgeID, page, retrievableChunkTypes)
+}
+
 // updateChunk updates a chunk
 // This method updates an existing chunk in the repository
 // Parameters:
@@ -207,6 +236,111 @@ func (s *chunkService) UpdateChunk(ctx context.Context, chunk *types.Chunk) erro
 	return nil
 }
 
+// UpdateChunkText replaces a chunk's text and re-embeds just that chunk
+// The vector is replaced by source ID so vectors of generated questions for the chunk are kept
+// Parameters:
+//   - ctx: Context with authentication and request information
+//   - knowledgeID: ID of the knowledge the chunk must belong to
+//   - chunkID: ID of the chunk to edit
+//   - text: New chunk content
+//
+// Returns:
+//   - *types.Chunk: The updated chunk
+//   - error: ErrChunkNotFound, ErrEmptyChunkText, ErrFAQChunkTextEdit or any error during update
+func (s *chunkService) UpdateChunkText(ctx context.Context,
+	knowledgeID string, chunkID string, text string,
+) (*types.Chunk, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, ErrEmptyChunkText
+	}
+	tenantID := types.MustTenantIDFromContext(ctx)
+
+	chunk, err := s.chunkRepository.GetChunkByID(ctx, tenantID, chunkID)
+	if err != nil || chunk.KnowledgeID != knowledgeID {
+		return nil, ErrChunkNotFound
+	}
+
+	kb, err := s.kbRepository.GetKnowledgeBaseByID(ctx, chunk.KnowledgeBaseID)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"knowledge_base_id": chunk.KnowledgeBaseID,
+		})
+		return nil, fmt.Errorf("failed to get knowledge base: %w", err)
+	}
+	// FAQ chunk content is derived from the entry's question/answer metadata
+	if chunk.ChunkType == types.ChunkTypeFAQ || kb.Type == types.KnowledgeBaseTypeFAQ {
+		return nil, ErrFAQChunkTextEdit
+	}
+
+	chunk.Content = text
+	if err := s.chunkRepository.UpdateChunk(ctx, chunk); err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"chunk_id":     chunk.ID,
+			"knowledge_id": chunk.KnowledgeID,
+		})
+		return nil, err
+	}
+
+	// Parent chunks only provide context for their children and are not embedded
+	if chunk.ChunkType == types.ChunkTypeParentText {
+		logger.Infof(ctx, "Parent chunk text updated without re-embedding, chunk ID: %s", chunk.ID)
+		return chunk, nil
+	}
+
+	embeddingModel, retrieveEngine, err := s.vectorStoreFor(ctx, kb)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := retrieveEngine.DeleteBySourceIDList(
+		ctx, []string{chunk.ID}, embeddingModel.GetDimensions(), kb.Type,
+	); err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"chunk_id": chunk.ID,
+		})
+		return nil, fmt.Errorf("failed to delete chunk vector: %w", err)
+	}
+	indexInfo := []*types.IndexInfo{{
+		Content:         chunk.Content,
+		SourceID:        chunk.ID,
+		SourceType:      types.ChunkSourceType,
+		ChunkID:         chunk.ID,
+		KnowledgeID:     chunk.KnowledgeID,
+		KnowledgeBaseID: chunk.KnowledgeBaseID,
+		TagID:           chunk.TagID,
+		IsEnabled:       chunk.IsEnabled,
+		IsRecommended:   chunk.Flags.HasFlag(types.ChunkFlagRecommended),
+	}}
+	if err := retrieveEngine.BatchIndex(ctx, embeddingModel, indexInfo); err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"chunk_id": chunk.ID,
+		})
+		return nil, fmt.Errorf("failed to re-embed chunk: %w", err)
+	}
+
+	logger.Infof(ctx, "Chunk text updated and re-embedded, chunk ID: %s, knowledge ID: %s", chunk.ID, knowledgeID)
+	return chunk, nil
+}
+
+// vectorStoreFor returns the knowledge base's embedding model and the tenant's retrieve engine
+func (s *chunkService) vectorStoreFor(
+	ctx context.Context, kb *types.KnowledgeBase,
+) (embedding.Embedder, *retriever.CompositeRetrieveEngine, error) {
+	embeddingModel, err := s.modelService.GetEmbeddingModel(ctx, kb.EmbeddingModelID)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"embedding_model_id": kb.EmbeddingModelID,
+		})
+		return nil, nil, fmt.Errorf("failed to get embedding model: %w", err)
+	}
+	tenantInfo, _ := types.TenantInfoFromContext(ctx)
+	retrieveEngine, err := retriever.NewCompositeRetrieveEngine(s.retrieveEngine, tenantInfo.GetEffectiveEngines())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create retrieve engine: %w", err)
+	}
+	return embeddingModel, retrieveEngine, nil
+}
+
 // UpdateChunks updates chunks in batch
 func (s *chunkService) UpdateChunks(ctx context.Context, chunks []*types.Chunk) error {
 	if len(chunks) == 0 {
@@ -248,6 +382,88 @@ func (s *chunkService) DeleteChunk(ctx context.Context, id string) error {
 	return nil
 }
 
+// DeleteKnowledgeChunk deletes a chunk of a knowledge document, e.g. a junk header or footer
+// Its embeddings (including generated questions) and image OCR/caption children are removed too,
+// and the neighbouring chunks are relinked. Knowledge base chunk counts are derived from chunk rows,
+// so they reflect the deletion; the knowledge item stays listed even if no chunks remain.
+// Parameters:
+//   - ctx: Context with authentication and request information
+//   - knowledgeID: ID of the knowledge the chunk must belong to
+//   - chunkID: ID of the chunk to delete
+//
+// Returns:
+//   - error: ErrChunkNotFound, ErrFAQChunkDelete or any error during deletion
+func (s *chunkService) DeleteKnowledgeChunk(ctx context.Context, knowledgeID string, chunkID string) error {
+	tenantID := types.MustTenantIDFromContext(ctx)
+
+	chunk, err := s.chunkRepository.GetChunkByID(ctx, tenantID, chunkID)
+	if err != nil || chunk.KnowledgeID != knowledgeID {
+		return ErrChunkNotFound
+	}
+	// FAQ entries keep per-question vectors that only the FAQ entry deletion cleans up
+	if chunk.ChunkType == types.ChunkTypeFAQ {
+		return ErrFAQChunkDelete
+	}
+
+	kb, err := s.kbRepository.GetKnowledgeBaseByID(ctx, chunk.KnowledgeBaseID)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"knowledge_base_id": chunk.KnowledgeBaseID,
+		})
+		return fmt.Errorf("failed to get knowledge base: %w", err)
+	}
+
+	children, err := s.chunkRepository.ListChunkByParentID(ctx, tenantID, chunk.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list child chunks: %w", err)
+	}
+	ids := make([]string, 0, len(children)+1)
+	ids = append(ids, chunk.ID)
+	for _, child := range children {
+		ids = append(ids, child.ID)
+	}
+
+	embeddingModel, retrieveEngine, err := s.vectorStoreFor(ctx, kb)
+	if err != nil {
+		return err
+	}
+	if err := retrieveEngine.DeleteByChunkIDList(ctx, ids, embeddingModel.GetDimensions(), kb.Type); err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"chunk_ids": ids,
+		})
+		return fmt.Errorf("failed to delete chunk vectors: %w", err)
+	}
+
+	// Relink neighbours so context expansion skips the removed chunk
+	if chunk.PreChunkID != "" {
+		if prev, err := s.chunkRepository.GetChunkByID(ctx, tenantID, chunk.PreChunkID); err == nil {
+			prev.NextChunkID = chunk.NextChunkID
+			if err := s.chunkRepository.UpdateChunk(ctx, prev); err != nil {
+				logger.Warnf(ctx, "Failed to relink previous chunk %s: %v", prev.ID, err)
+			}
+		}
+	}
+	if chunk.NextChunkID != "" {
+		if next, err := s.chunkRepository.GetChunkByID(ctx, tenantID, chunk.NextChunkID); err == nil {
+			next.PreChunkID = chunk.PreChunkID
+			if err := s.chunkRepository.UpdateChunk(ctx, next); err != nil {
+				logger.Warnf(ctx, "Failed to relink next chunk %s: %v", next.ID, err)
+			}
+		}
+	}
+
+	if err := s.chunkRepository.DeleteChunks(ctx, tenantID, ids); err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"chunk_ids": ids,
+			"tenant_id": tenantID,
+		})
+		return err
+	}
+
+	logger.Infof(ctx, "Deleted chunk %s and %d child chunks from knowledge %s", chunk.ID, len(children), knowledgeID)
+	return nil
+}
+
 // DeleteChunks deletes chunks by IDs in batch
 // This method removes multiple chunks from the repository in a single operation
 // Parameters:
@@ -0,0 +1,427 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// ErrUnsupportedBundleVersion is returned when importing a bundle written by an unknown format version
+var ErrUnsupportedBundleVersion = errors.New("unsupported config bundle version")
+
+// Kinds of objects reported in config import issues
+const (
+	configIssueKnowledgeBase = "knowledge_base"
+	configIssueAgent         = "agent"
+	configIssueOrganization  = "organization"
+)
+
+// configBundleService implements interfaces.ConfigBundleService
+type configBundleService struct {
+	kbService       interfaces.KnowledgeBaseService
+	agentService    interfaces.CustomAgentService
+	modelService    interfaces.ModelService
+	mcpService      interfaces.MCPServiceService
+	orgService      interfaces.OrganizationService
+	templateService interfaces.PromptTemplateService
+}
+
+// NewConfigBundleService creates a new config bundle service
+func NewConfigBundleService(
+	kbService interfaces.KnowledgeBaseService,
+	agentService interfaces.CustomAgentService,
+	modelService interfaces.ModelService,
+	mcpService interfaces.MCPServiceService,
+	orgService interfaces.OrganizationService,
+	templateService interfaces.PromptTemplateService,
+) interfaces.ConfigBundleService {
+	return &configBundleService{
+		kbService:       kbService,
+		agentService:    agentService,
+		modelService:    modelService,
+		mcpService:      mcpService,
+		orgService:      orgService,
+		templateService: templateService,
+	}
+}
+
+// ExportConfig exports the knowledge base and agent settings of the tenant in context
+func (s *configBundleService) ExportConfig(ctx context.Context) (*types.TenantConfigBundle, error) {
+	tenantID := types.MustTenantIDFromContext(ctx)
+
+	kbs, err := s.kbService.ListKnowledgeBases(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list knowledge bases: %w", err)
+	}
+	agents, err := s.agentService.ListAgents(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list agents: %w", err)
+	}
+	models, err := s.modelService.ListModels(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list models: %w", err)
+	}
+	mcpServices, err := s.mcpService.ListMCPServices(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list MCP services: %w", err)
+	}
+
+	bundle := &types.TenantConfigBundle{
+		Version:        types.TenantConfigBundleVersion,
+		ExportedAt:     time.Now(),
+		SourceTenantID: tenantID,
+		Models:         []types.ConfigBundleRef{},
+		MCPServices:    []types.ConfigBundleRef{},
+		KnowledgeBases: []types.ConfigBundleKnowledgeBase{},
+		Agents:         []types.ConfigBundleAgent{},
+		Organizations:  []types.ConfigBundleOrganizationMember{},
+	}
+
+	modelByID := make(map[string]*types.Model, len(models))
+	for _, m := range models {
+		if m != nil {
+			modelByID[m.ID] = m
+		}
+	}
+	referencedModels := make(map[string]bool)
+	addModel := func(id string) {
+		if m, ok := modelByID[id]; ok && !referencedModels[id] {
+			referencedModels[id] = true
+			bundle.Models = append(bundle.Models, types.ConfigBundleRef{ID: m.ID, Name: m.Name, Type: string(m.Type)})
+		}
+	}
+
+	for _, kb := range kbs {
+		if kb == nil || kb.IsTemporary {
+			continue
+		}
+		bundle.KnowledgeBases = append(bundle.KnowledgeBases, types.ConfigBundleKnowledgeBase{
+			ID:                    kb.ID,
+			Name:                  kb.Name,
+			Type:                  kb.Type,
+			Description:           kb.Description,
+			ChunkingConfig:        kb.ChunkingConfig,
+			ImageProcessingConfig: kb.ImageProcessingConfig,
+			EmbeddingModelID:      kb.EmbeddingModelID,
+			SummaryModelID:        kb.SummaryModelID,
+			// Legacy inline VLM endpoints carry credentials and are not exported
+			VLMConfig:                types.VLMConfig{Enabled: kb.VLMConfig.Enabled, ModelID: kb.VLMConfig.ModelID},
+			StorageProviderConfig:    kb.StorageProviderConfig,
+			ExtractConfig:            kb.ExtractConfig,
+			FAQConfig:                kb.FAQConfig,
+			QuestionGenerationConfig: kb.QuestionGenerationConfig,
+			DuplicateScope:           kb.DuplicateScope,
+			FallbackResponse:         kb.FallbackResponse,
+		})
+		addModel(kb.EmbeddingModelID)
+		addModel(kb.SummaryModelID)
+		addModel(kb.VLMConfig.ModelID)
+	}
+
+	mcpByID := make(map[string]*types.MCPService, len(mcpServices))
+	for _, svc := range mcpServices {
+		if svc != nil {
+			mcpByID[svc.ID] = svc
+		}
+	}
+	referencedMCP := make(map[string]bool)
+	for _, agent := range agents {
+		if agent == nil || agent.IsBuiltin {
+			continue
+		}
+		bundle.Agents = append(bundle.Agents, types.ConfigBundleAgent{
+			ID:          agent.ID,
+			Name:        agent.Name,
+			Description: agent.Description,
+			Avatar:      agent.Avatar,
+			Config:      agent.Config,
+		})
+		addModel(agent.Config.ModelID)
+		addModel(agent.Config.RerankModelID)
+		for _, id := range agent.Config.MCPServices {
+			if svc, ok := mcpByID[id]; ok && !referencedMCP[id] {
+				referencedMCP[id] = true
+				bundle.MCPServices = append(bundle.MCPServices, types.ConfigBundleRef{ID: svc.ID, Name: svc.Name})
+			}
+		}
+	}
+
+	if userID, ok := types.UserIDFromContext(ctx); ok && userID != "" {
+		orgs, err := s.orgService.ListUserOrganizations(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list organizations: %w", err)
+		}
+		for _, org := range orgs {
+			role, err := s.orgService.GetUserRoleInOrg(ctx, org.ID, userID)
+			if err != nil {
+				logger.Warnf(ctx, "Failed to get role in organization %s: %v", org.ID, err)
+			}
+			bundle.Organizations = append(bundle.Organizations, types.ConfigBundleOrganizationMember{
+				OrganizationID: org.ID,
+				Name:           org.Name,
+				Role:           role,
+			})
+		}
+	}
+
+	logger.Infof(ctx, "Exported config of tenant %d: %d knowledge bases, %d agents, %d organizations",
+		tenantID, len(bundle.KnowledgeBases), len(bundle.Agents), len(bundle.Organizations))
+	return bundle, nil
+}
+
+// ImportConfig creates the knowledge bases and agents of a bundle in the tenant in context.
+// Import is best effort: objects that fail are reported and the rest is still imported.
+func (s *configBundleService) ImportConfig(
+	ctx context.Context, bundle *types.TenantConfigBundle,
+) (*types.TenantConfigImportResult, error) {
+	if bundle == nil || bundle.Version != types.TenantConfigBundleVersion {
+		return nil, ErrUnsupportedBundleVersion
+	}
+	tenantID := types.MustTenantIDFromContext(ctx)
+	userID, _ := types.UserIDFromContext(ctx)
+
+	result := &types.TenantConfigImportResult{
+		KnowledgeBases: []types.ConfigImportItem{},
+		Agents:         []types.ConfigImportItem{},
+		Unresolved:     []types.ConfigImportIssue{},
+	}
+	report := func(kind, name, field, ref, message string) {
+		result.Unresolved = append(result.Unresolved, types.ConfigImportIssue{
+			Kind: kind, Name: name, Field: field, Reference: ref, Message: message,
+		})
+	}
+
+	resolveModel, err := s.modelResolver(ctx, bundle.Models)
+	if err != nil {
+		return nil, err
+	}
+
+	// Knowledge bases first, so agents can be pointed at the new IDs
+	kbIDs := make(map[string]string, len(bundle.KnowledgeBases))
+	for _, src := range bundle.KnowledgeBases {
+		embeddingModelID, ok := resolveModel(src.EmbeddingModelID)
+		if !ok || embeddingModelID == "" {
+			report(configIssueKnowledgeBase, src.Name, "embedding_model_id", src.EmbeddingModelID,
+				"embedding model not found, knowledge base not created")
+			continue
+		}
+		kb := &types.KnowledgeBase{
+			Name:                     src.Name,
+			Type:                     src.Type,
+			Description:              src.Description,
+			ChunkingConfig:           src.ChunkingConfig,
+			ImageProcessingConfig:    src.ImageProcessingConfig,
+			EmbeddingModelID:         embeddingModelID,
+			VLMConfig:                types.VLMConfig{Enabled: src.VLMConfig.Enabled},
+			StorageProviderConfig:    src.StorageProviderConfig,
+			ExtractConfig:            src.ExtractConfig,
+			FAQConfig:                src.FAQConfig,
+			QuestionGenerationConfig: src.QuestionGenerationConfig,
+			DuplicateScope:           src.DuplicateScope,
+			FallbackResponse:         src.FallbackResponse,
+		}
+		if id, ok := resolveModel(src.SummaryModelID); ok {
+			kb.SummaryModelID = id
+		} else {
+			report(configIssueKnowledgeBase, src.Name, "summary_model_id", src.SummaryModelID, "summary model not found, cleared")
+		}
+		if id, ok := resolveModel(src.VLMConfig.ModelID); ok {
+			kb.VLMConfig.ModelID = id
+		} else {
+			kb.VLMConfig.Enabled = false
+			report(configIssueKnowledgeBase, src.Name, "vlm_config.model_id", src.VLMConfig.ModelID, "VLM model not found, VLM disabled")
+		}
+		if !types.IsValidDuplicateScope(kb.DuplicateScope) {
+			kb.DuplicateScope = ""
+		}
+
+		created, err := s.kbService.CreateKnowledgeBase(ctx, kb)
+		if err != nil {
+			report(configIssueKnowledgeBase, src.Name, "", "", fmt.Sprintf("failed to create knowledge base: %v", err))
+			continue
+		}
+		kbIDs[src.ID] = created.ID
+		result.KnowledgeBases = append(result.KnowledgeBases,
+			types.ConfigImportItem{SourceID: src.ID, ID: created.ID, Name: created.Name})
+	}
+
+	resolveMCP, err := s.mcpResolver(ctx, tenantID, bundle.MCPServices)
+	if err != nil {
+		return nil, err
+	}
+	// Agents may also reference knowledge bases that already exist in this tenant
+	existingKBs, err := s.kbService.ListKnowledgeBases(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list knowledge bases: %w", err)
+	}
+	existingKBIDs := make(map[string]bool, len(existingKBs))
+	for _, kb := range existingKBs {
+		if kb != nil {
+			existingKBIDs[kb.ID] = true
+		}
+	}
+
+	for _, src := range bundle.Agents {
+		cfg := src.Config
+		if id, ok := resolveModel(cfg.ModelID); ok {
+			cfg.ModelID = id
+		} else {
+			report(configIssueAgent, src.Name, "model_id", cfg.ModelID, "chat model not found, the default model is used")
+			cfg.ModelID = ""
+		}
+		if id, ok := resolveModel(cfg.RerankModelID); ok {
+			cfg.RerankModelID = id
+		} else {
+			report(configIssueAgent, src.Name, "rerank_model_id", cfg.RerankModelID, "rerank model not found, cleared")
+			cfg.RerankModelID = ""
+		}
+
+		knowledgeBases := make([]string, 0, len(cfg.KnowledgeBases))
+		for _, id := range cfg.KnowledgeBases {
+			if newID, ok := kbIDs[id]; ok {
+				knowledgeBases = append(knowledgeBases, newID)
+			} else if existingKBIDs[id] {
+				knowledgeBases = append(knowledgeBases, id)
+			} else {
+				report(configIssueAgent, src.Name, "knowledge_bases", id, "knowledge base not found, removed")
+			}
+		}
+		cfg.KnowledgeBases = knowledgeBases
+
+		mcpServices := make([]string, 0, len(cfg.MCPServices))
+		for _, id := range cfg.MCPServices {
+			if newID, ok := resolveMCP(id); ok {
+				mcpServices = append(mcpServices, newID)
+			} else {
+				report(configIssueAgent, src.Name, "mcp_services", id, "MCP service not found, removed")
+			}
+		}
+		cfg.MCPServices = mcpServices
+
+		if cfg.SystemPromptRef != "" {
+			_, err := s.templateService.GetTemplate(ctx, cfg.SystemPromptRef)
+			if errors.Is(err, repository.ErrPromptTemplateNotFound) {
+				report(configIssueAgent, src.Name, "system_prompt_ref", cfg.SystemPromptRef,
+					"prompt template not found, the inline system prompt is used")
+				cfg.SystemPromptRef = ""
+			} else if err != nil {
+				return nil, fmt.Errorf("failed to load prompt template: %w", err)
+			}
+		}
+
+		created, err := s.agentService.CreateAgent(ctx, &types.CustomAgent{
+			Name:        src.Name,
+			Description: src.Description,
+			Avatar:      src.Avatar,
+			CreatedBy:   userID,
+			Config:      cfg,
+		})
+		if err != nil {
+			report(configIssueAgent, src.Name, "", "", fmt.Sprintf("failed to create agent: %v", err))
+			continue
+		}
+		result.Agents = append(result.Agents, types.ConfigImportItem{SourceID: src.ID, ID: created.ID, Name: created.Name})
+	}
+
+	// Memberships cannot be granted by an import; report those the user still has to join
+	for _, org := range bundle.Organizations {
+		if userID != "" {
+			if member, err := s.orgService.GetMember(ctx, org.OrganizationID, userID); err == nil && member != nil {
+				continue
+			}
+		}
+		report(configIssueOrganization, org.Name, "organization_id", org.OrganizationID,
+			"not a member of this organization, join it with an invite code")
+	}
+
+	logger.Infof(ctx, "Imported config into tenant %d: %d knowledge bases, %d agents, %d unresolved references",
+		tenantID, len(result.KnowledgeBases), len(result.Agents), len(result.Unresolved))
+	return result, nil
+}
+
+// modelResolver returns a function mapping a model ID of the bundle to a model available to the
+// tenant in context: the same ID when it exists, otherwise a model with the same name and type.
+// Empty IDs resolve to empty.
+func (s *configBundleService) modelResolver(
+	ctx context.Context, refs []types.ConfigBundleRef,
+) (func(id string) (string, bool), error) {
+	models, err := s.modelService.ListModels(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list models: %w", err)
+	}
+	byID := make(map[string]bool, len(models))
+	byName := make(map[string]string, len(models))
+	for _, m := range models {
+		if m == nil {
+			continue
+		}
+		byID[m.ID] = true
+		key := string(m.Type) + "/" + m.Name
+		if _, exists := byName[key]; !exists {
+			byName[key] = m.ID
+		}
+	}
+	refByID := make(map[string]types.ConfigBundleRef, len(refs))
+	for _, ref := range refs {
+		refByID[ref.ID] = ref
+	}
+
+	return func(id string) (string, bool) {
+		if id == "" {
+			return "", true
+		}
+		if byID[id] {
+			return id, true
+		}
+		if ref, ok := refByID[id]; ok {
+			if match, ok := byName[ref.Type+"/"+ref.Name]; ok {
+				return match, true
+			}
+		}
+		return "", false
+	}, nil
+}
+
+// mcpResolver returns a function mapping an MCP service ID of the bundle to a service of the tenant,
+// by ID first and then by name
+func (s *configBundleService) mcpResolver(
+	ctx context.Context, tenantID uint64, refs []types.ConfigBundleRef,
+) (func(id string) (string, bool), error) {
+	services, err := s.mcpService.ListMCPServices(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list MCP services: %w", err)
+	}
+	byID := make(map[string]bool, len(services))
+	byName := make(map[string]string, len(services))
+	for _, svc := range services {
+		if svc == nil {
+			continue
+		}
+		byID[svc.ID] = true
+		if _, exists := byName[svc.Name]; !exists {
+			byName[svc.Name] = svc.ID
+		}
+	}
+	refByID := make(map[string]types.ConfigBundleRef, len(refs))
+	for _, ref := range refs {
+		refByID[ref.ID] = ref
+	}
+
+	return func(id string) (string, bool) {
+		if byID[id] {
+			return id, true
+		}
+		if ref, ok := refByID[id]; ok {
+			if match, ok := byName[ref.Name]; ok {
+				return match, true
+			}
+		}
+		return "", false
+	}, nil
+}
@@ -0,0 +1,337 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// bundleModelService lists the models of the importing tenant
+type bundleModelService struct {
+	interfaces.ModelService
+	models []*types.Model
+}
+
+func (s *bundleModelService) ListModels(ctx context.Context) ([]*types.Model, error) {
+	return s.models, nil
+}
+
+// bundleMCPService lists the MCP services of the importing tenant
+type bundleMCPService struct {
+	interfaces.MCPServiceService
+	services []*types.MCPService
+}
+
+func (s *bundleMCPService) ListMCPServices(ctx context.Context, tenantID uint64) ([]*types.MCPService, error) {
+	return s.services, nil
+}
+
+// bundleKBService creates knowledge bases with an ID derived from their name
+type bundleKBService struct {
+	interfaces.KnowledgeBaseService
+	kbs []*types.KnowledgeBase
+}
+
+func (s *bundleKBService) CreateKnowledgeBase(ctx context.Context, kb *types.KnowledgeBase) (*types.KnowledgeBase, error) {
+	kb.ID = "new-" + kb.Name
+	s.kbs = append(s.kbs, kb)
+	return kb, nil
+}
+
+func (s *bundleKBService) ListKnowledgeBases(ctx context.Context) ([]*types.KnowledgeBase, error) {
+	return s.kbs, nil
+}
+
+// bundleAgentService records the created agents
+type bundleAgentService struct {
+	interfaces.CustomAgentService
+	agents []*types.CustomAgent
+}
+
+func (s *bundleAgentService) CreateAgent(ctx context.Context, agent *types.CustomAgent) (*types.CustomAgent, error) {
+	agent.ID = "new-" + agent.Name
+	s.agents = append(s.agents, agent)
+	return agent, nil
+}
+
+// bundleOrgService knows the organizations the user is a member of
+type bundleOrgService struct {
+	interfaces.OrganizationService
+	memberOf map[string]bool
+}
+
+func (s *bundleOrgService) GetMember(ctx context.Context, orgID string, userID string) (*types.OrganizationMember, error) {
+	if !s.memberOf[orgID] {
+		return nil, ErrUserNotInOrg
+	}
+	return &types.OrganizationMember{OrganizationID: orgID, UserID: userID}, nil
+}
+
+// bundleTemplateService knows a single prompt template
+type bundleTemplateService struct {
+	interfaces.PromptTemplateService
+}
+
+func (s *bundleTemplateService) GetTemplate(ctx context.Context, id string) (*types.PromptTemplate, error) {
+	if id != "tpl-1" {
+		return nil, repository.ErrPromptTemplateNotFound
+	}
+	return &types.PromptTemplate{ID: id}, nil
+}
+
+func newConfigBundleTestService() (*configBundleService, *bundleKBService, *bundleAgentService) {
+	kbs := &bundleKBService{kbs: []*types.KnowledgeBase{{ID: "kb-existing", Name: "existing"}}}
+	agents := &bundleAgentService{}
+	return &configBundleService{
+		kbService:    kbs,
+		agentService: agents,
+		modelService: &bundleModelService{models: []*types.Model{
+			{ID: "emb-1", Name: "bge", Type: types.ModelTypeEmbedding},
+			{ID: "emb-local", Name: "text-embedding", Type: types.ModelTypeEmbedding},
+			{ID: "chat-local", Name: "qwen", Type: types.ModelTypeKnowledgeQA},
+		}},
+		mcpService: &bundleMCPService{services: []*types.MCPService{
+			{ID: "mcp-1", Name: "search"},
+			{ID: "mcp-local", Name: "weather"},
+		}},
+		orgService:      &bundleOrgService{memberOf: map[string]bool{"org-joined": true}},
+		templateService: &bundleTemplateService{},
+	}, kbs, agents
+}
+
+func newConfigBundleTestContext() context.Context {
+	ctx := context.WithValue(context.Background(), types.TenantIDContextKey, uint64(1))
+	return context.WithValue(ctx, types.UserIDContextKey, "user-1")
+}
+
+func TestConfigBundleModelResolver(t *testing.T) {
+	s, _, _ := newConfigBundleTestService()
+	resolve, err := s.modelResolver(newConfigBundleTestContext(), []types.ConfigBundleRef{
+		{ID: "emb-src", Name: "text-embedding", Type: string(types.ModelTypeEmbedding)},
+		{ID: "chat-src", Name: "qwen", Type: string(types.ModelTypeKnowledgeQA)},
+		{ID: "rerank-src", Name: "qwen", Type: string(types.ModelTypeRerank)},
+	})
+	if err != nil {
+		t.Fatalf("modelResolver() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		id     string
+		want   string
+		wantOK bool
+	}{
+		{name: "empty ID", id: "", want: "", wantOK: true},
+		{name: "same ID in tenant", id: "emb-1", want: "emb-1", wantOK: true},
+		{name: "same name and type", id: "emb-src", want: "emb-local", wantOK: true},
+		{name: "chat model by name", id: "chat-src", want: "chat-local", wantOK: true},
+		{name: "same name but other type", id: "rerank-src", wantOK: false},
+		{name: "not in bundle", id: "unknown", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := resolve(tt.id)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("resolve(%q) = %q, %v, want %q, %v", tt.id, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestConfigBundleMCPResolver(t *testing.T) {
+	s, _, _ := newConfigBundleTestService()
+	resolve, err := s.mcpResolver(newConfigBundleTestContext(), 1, []types.ConfigBundleRef{
+		{ID: "mcp-src", Name: "weather"},
+		{ID: "mcp-gone", Name: "calendar"},
+	})
+	if err != nil {
+		t.Fatalf("mcpResolver() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		id     string
+		want   string
+		wantOK bool
+	}{
+		{name: "same ID in tenant", id: "mcp-1", want: "mcp-1", wantOK: true},
+		{name: "same name", id: "mcp-src", want: "mcp-local", wantOK: true},
+		{name: "name not in tenant", id: "mcp-gone", wantOK: false},
+		{name: "not in bundle", id: "unknown", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := resolve(tt.id)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("resolve(%q) = %q, %v, want %q, %v", tt.id, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestConfigBundleImportRemapsIDs(t *testing.T) {
+	s, kbs, agents := newConfigBundleTestService()
+	bundle := &types.TenantConfigBundle{
+		Version:     types.TenantConfigBundleVersion,
+		Models:      []types.ConfigBundleRef{{ID: "emb-src", Name: "text-embedding", Type: string(types.ModelTypeEmbedding)}},
+		MCPServices: []types.ConfigBundleRef{{ID: "mcp-src", Name: "weather"}},
+		KnowledgeBases: []types.ConfigBundleKnowledgeBase{
+			{ID: "kb-src", Name: "docs", EmbeddingModelID: "emb-src"},
+		},
+		Agents: []types.ConfigBundleAgent{{ID: "agent-src", Name: "helper", Config: types.CustomAgentConfig{
+			ModelID:        "chat-local",
+			KnowledgeBases: []string{"kb-src", "kb-existing"},
+			MCPServices:    []string{"mcp-src", "mcp-1"},
+		}}},
+	}
+
+	result, err := s.ImportConfig(newConfigBundleTestContext(), bundle)
+	if err != nil {
+		t.Fatalf("ImportConfig() error = %v", err)
+	}
+	if len(result.Unresolved) != 0 {
+		t.Errorf("unresolved = %+v, want none", result.Unresolved)
+	}
+
+	tests := []struct {
+		name string
+		got  []string
+		want []string
+	}{
+		{name: "knowledge base items", got: []string{result.KnowledgeBases[0].SourceID, result.KnowledgeBases[0].ID}, want: []string{"kb-src", "new-docs"}},
+		{name: "embedding model", got: []string{kbs.kbs[1].EmbeddingModelID}, want: []string{"emb-local"}},
+		{name: "agent items", got: []string{result.Agents[0].SourceID, result.Agents[0].ID}, want: []string{"agent-src", "new-helper"}},
+		{name: "agent chat model", got: []string{agents.agents[0].Config.ModelID}, want: []string{"chat-local"}},
+		{name: "agent knowledge bases", got: agents.agents[0].Config.KnowledgeBases, want: []string{"new-docs", "kb-existing"}},
+		{name: "agent MCP services", got: agents.agents[0].Config.MCPServices, want: []string{"mcp-local", "mcp-1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !slices.Equal(tt.got, tt.want) {
+				t.Errorf("got %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigBundleImportReportsUnresolved(t *testing.T) {
+	tests := []struct {
+		name      string
+		bundle    types.TenantConfigBundle
+		want      []types.ConfigImportIssue
+		wantKBs   int
+		wantAgent bool
+	}{
+		{
+			name: "unknown embedding model skips the knowledge base",
+			bundle: types.TenantConfigBundle{KnowledgeBases: []types.ConfigBundleKnowledgeBase{
+				{ID: "kb-src", Name: "docs", EmbeddingModelID: "emb-gone"},
+			}},
+			want: []types.ConfigImportIssue{
+				{Kind: configIssueKnowledgeBase, Name: "docs", Field: "embedding_model_id", Reference: "emb-gone"},
+			},
+		},
+		{
+			name: "unknown summary and VLM models are cleared",
+			bundle: types.TenantConfigBundle{KnowledgeBases: []types.ConfigBundleKnowledgeBase{{
+				ID: "kb-src", Name: "docs", EmbeddingModelID: "emb-1", SummaryModelID: "chat-gone",
+				VLMConfig: types.VLMConfig{Enabled: true, ModelID: "vlm-gone"},
+			}}},
+			want: []types.ConfigImportIssue{
+				{Kind: configIssueKnowledgeBase, Name: "docs", Field: "summary_model_id", Reference: "chat-gone"},
+				{Kind: configIssueKnowledgeBase, Name: "docs", Field: "vlm_config.model_id", Reference: "vlm-gone"},
+			},
+			wantKBs: 1,
+		},
+		{
+			name: "unknown agent references are dropped",
+			bundle: types.TenantConfigBundle{Agents: []types.ConfigBundleAgent{{ID: "agent-src", Name: "helper", Config: types.CustomAgentConfig{
+				ModelID:         "chat-gone",
+				RerankModelID:   "rerank-gone",
+				KnowledgeBases:  []string{"kb-gone"},
+				MCPServices:     []string{"mcp-gone"},
+				SystemPromptRef: "tpl-gone",
+			}}}},
+			want: []types.ConfigImportIssue{
+				{Kind: configIssueAgent, Name: "helper", Field: "model_id", Reference: "chat-gone"},
+				{Kind: configIssueAgent, Name: "helper", Field: "rerank_model_id", Reference: "rerank-gone"},
+				{Kind: configIssueAgent, Name: "helper", Field: "knowledge_bases", Reference: "kb-gone"},
+				{Kind: configIssueAgent, Name: "helper", Field: "mcp_services", Reference: "mcp-gone"},
+				{Kind: configIssueAgent, Name: "helper", Field: "system_prompt_ref", Reference: "tpl-gone"},
+			},
+			wantAgent: true,
+		},
+		{
+			name: "known prompt template is kept",
+			bundle: types.TenantConfigBundle{Agents: []types.ConfigBundleAgent{
+				{ID: "agent-src", Name: "helper", Config: types.CustomAgentConfig{SystemPromptRef: "tpl-1"}},
+			}},
+			wantAgent: true,
+		},
+		{
+			name: "organizations the user has not joined",
+			bundle: types.TenantConfigBundle{Organizations: []types.ConfigBundleOrganizationMember{
+				{OrganizationID: "org-joined", Name: "joined"},
+				{OrganizationID: "org-other", Name: "other"},
+			}},
+			want: []types.ConfigImportIssue{
+				{Kind: configIssueOrganization, Name: "other", Field: "organization_id", Reference: "org-other"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, kbs, agents := newConfigBundleTestService()
+			tt.bundle.Version = types.TenantConfigBundleVersion
+
+			result, err := s.ImportConfig(newConfigBundleTestContext(), &tt.bundle)
+			if err != nil {
+				t.Fatalf("ImportConfig() error = %v", err)
+			}
+			if len(result.Unresolved) != len(tt.want) {
+				t.Fatalf("unresolved = %+v, want %d issues", result.Unresolved, len(tt.want))
+			}
+			for i, issue := range result.Unresolved {
+				want := tt.want[i]
+				if issue.Kind != want.Kind || issue.Name != want.Name || issue.Field != want.Field ||
+					issue.Reference != want.Reference || issue.Message == "" {
+					t.Errorf("issue %d = %+v, want %+v with a message", i, issue, want)
+				}
+			}
+			if len(result.KnowledgeBases) != tt.wantKBs {
+				t.Errorf("imported %d knowledge bases, want %d", len(result.KnowledgeBases), tt.wantKBs)
+			}
+			if (len(agents.agents) == 1) != tt.wantAgent {
+				t.Errorf("created %d agents, want agent created = %v", len(agents.agents), tt.wantAgent)
+			}
+			if tt.wantKBs == 1 {
+				kb := kbs.kbs[len(kbs.kbs)-1]
+				if kb.SummaryModelID != "" || kb.VLMConfig.ModelID != "" || kb.VLMConfig.Enabled {
+					t.Errorf("knowledge base kept unresolved models: summary %q, VLM %+v", kb.SummaryModelID, kb.VLMConfig)
+				}
+			}
+			if tt.wantAgent {
+				cfg := agents.agents[0].Config
+				if cfg.ModelID != "" || cfg.RerankModelID != "" || len(cfg.KnowledgeBases) != 0 || len(cfg.MCPServices) != 0 {
+					t.Errorf("agent kept unresolved references: %+v", cfg)
+				}
+				if want := tt.bundle.Agents[0].Config.SystemPromptRef; want == "tpl-1" && cfg.SystemPromptRef != want {
+					t.Errorf("system prompt ref = %q, want %q", cfg.SystemPromptRef, want)
+				}
+			}
+		})
+	}
+}
+
+func TestConfigBundleImportRejectsUnknownVersion(t *testing.T) {
+	s, _, _ := newConfigBundleTestService()
+	_, err := s.ImportConfig(newConfigBundleTestContext(), &types.TenantConfigBundle{Version: types.TenantConfigBundleVersion + 1})
+	if !errors.Is(err, ErrUnsupportedBundleVersion) {
+		t.Errorf("ImportConfig() error = %v, want ErrUnsupportedBundleVersion", err)
+	}
+}
@@ -2,34 +2,73 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/Tencent/WeKnora/internal/application/repository"
+	"github.com/Tencent/WeKnora/internal/event"
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 	"github.com/google/uuid"
+	"golang.org/x/sync/errgroup"
 )
 
 // Custom agent related errors
 var (
-	ErrAgentNotFound       = errors.New("agent not found")
-	ErrCannotModifyBuiltin = errors.New("cannot modify built-in agent basic info")
-	ErrCannotDeleteBuiltin = errors.New("cannot delete built-in agent")
-	ErrAgentNameRequired   = errors.New("agent name is required")
+	ErrAgentNotFound         = errors.New("agent not found")
+	ErrCannotModifyBuiltin   = errors.New("cannot modify built-in agent basic info")
+	ErrCannotDeleteBuiltin   = errors.New("cannot delete built-in agent")
+	ErrAgentNameRequired     = errors.New("agent name is required")
+	ErrTooManyStopSequences  = errors.New("too many stop sequences")
+	ErrEmptyStopSequence     = errors.New("stop sequence cannot be empty")
+	ErrInvalidResponseFormat = errors.New("response format must be \"text\" or \"json_object\"")
+	ErrInvalidResponseSchema = errors.New("response schema must be valid JSON")
+	ErrInvalidContextConfig  = errors.New("context config values are out of range")
+	ErrInvalidCompression    = errors.New("compression strategy must be \"sliding_window\" or \"smart\"")
+	ErrTooManyFewShots       = errors.New("too many few-shot examples")
+	ErrFewShotsTooLong       = errors.New("few-shot examples exceed the token limit")
+	ErrEmptyFewShotExample   = errors.New("few-shot example user and assistant text cannot be empty")
+	ErrNoMatchPrefixTooLong  = fmt.Errorf("no-match prefix cannot exceed %d characters", types.MaxNoMatchPrefixLength)
+	ErrNoEvaluationQuestions = errors.New("at least one evaluation question is required")
+	ErrTooManyEvalQuestions  = errors.New("too many evaluation questions")
+	ErrEmptyEvalQuestion     = errors.New("evaluation question cannot be empty")
 )
 
+// evaluationQuestionTimeout bounds how long a single evaluation question may run
+const evaluationQuestionTimeout = 2 * time.Minute
+
 // customAgentService implements the CustomAgentService interface
 type customAgentService struct {
-	repo interfaces.CustomAgentRepository
+	repo              interfaces.CustomAgentRepository
+	sessionService    interfaces.SessionService
+	agentShareService interfaces.AgentShareService
+	kbShareService    interfaces.KBShareService
+	kbRepo            interfaces.KnowledgeBaseRepository
+	modelRepo         interfaces.ModelRepository
 }
 
 // NewCustomAgentService creates a new custom agent service
-func NewCustomAgentService(repo interfaces.CustomAgentRepository) interfaces.CustomAgentService {
+func NewCustomAgentService(
+	repo interfaces.CustomAgentRepository,
+	sessionService interfaces.SessionService,
+	agentShareService interfaces.AgentShareService,
+	kbShareService interfaces.KBShareService,
+	kbRepo interfaces.KnowledgeBaseRepository,
+	modelRepo interfaces.ModelRepository,
+) interfaces.CustomAgentService {
 	return &customAgentService{
-		repo: repo,
+		repo:              repo,
+		sessionService:    sessionService,
+		agentShareService: agentShareService,
+		kbShareService:    kbShareService,
+		kbRepo:            kbRepo,
+		modelRepo:         modelRepo,
 	}
 }
 
@@ -40,6 +79,10 @@ func (s *customAgentService) CreateAgent(ctx context.Context, agent *types.Custo
 		return nil, ErrAgentNameRequired
 	}
 
+	if err := validateAgentConfig(&agent.Config); err != nil {
+		return nil, err
+	}
+
 	// Generate UUID and set creation timestamps
 	if agent.ID == "" {
 		agent.ID = uuid.New().String()
@@ -232,6 +275,9 @@ func (s *customAgentService) UpdateAgent(ctx context.Context, agent *types.Custo
 	if strings.TrimSpace(agent.Name) == "" {
 		return nil, ErrAgentNameRequired
 	}
+	if err := validateAgentConfig(&agent.Config); err != nil {
+		return nil, err
+	}
 
 	// Update fields
 	existingAgent.Name = agent.Name
@@ -258,6 +304,10 @@ func (s *customAgentService) UpdateAgent(ctx context.Context, agent *types.Custo
 
 // updateBuiltinAgent updates a built-in agent's configuration (but not basic info)
 func (s *customAgentService) updateBuiltinAgent(ctx context.Context, agent *types.CustomAgent, tenantID uint64) (*types.CustomAgent, error) {
+	if err := validateAgentConfig(&agent.Config); err != nil {
+		return nil, err
+	}
+
 	// Get the default built-in agent from registry
 	defaultAgent := types.GetBuiltinAgent(agent.ID, tenantID)
 	if defaultAgent == nil {
@@ -410,3 +460,489 @@ func (s *customAgentService) CopyAgent(ctx context.Context, id string) (*types.C
 	logger.Infof(ctx, "Agent copied successfully, source ID: %s, new ID: %s", id, newAgent.ID)
 	return newAgent, nil
 }
+
+// CloneAgent creates a new agent in the current tenant with the config of an owned or shared agent.
+// Shares are not copied. References the current tenant cannot access are kept and reported as warnings.
+func (s *customAgentService) CloneAgent(
+	ctx context.Context, id string, newName string,
+) (*types.AgentCloneResult, error) {
+	if id == "" {
+		logger.Error(ctx, "Agent ID is empty")
+		return nil, errors.New("agent ID cannot be empty")
+	}
+
+	tenantID, ok := types.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, ErrInvalidTenantID
+	}
+	userID, _ := types.UserIDFromContext(ctx)
+
+	// Own (or built-in) agent first, then an agent shared with the user
+	sourceAgent, err := s.GetAgentByID(ctx, id)
+	if errors.Is(err, ErrAgentNotFound) && userID != "" {
+		sourceAgent, err = s.agentShareService.GetSharedAgentForUser(ctx, userID, tenantID, id)
+		if errors.Is(err, ErrAgentSharePermission) || errors.Is(err, ErrAgentNotFoundForShare) {
+			err = ErrAgentNotFound
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(newName)
+	if name == "" {
+		name = sourceAgent.Name + " (副本)"
+	}
+
+	newAgent := &types.CustomAgent{
+		ID:          uuid.New().String(),
+		Name:        name,
+		Description: sourceAgent.Description,
+		Avatar:      sourceAgent.Avatar,
+		IsBuiltin:   false,
+		TenantID:    tenantID,
+		Config:      sourceAgent.Config,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	newAgent.EnsureDefaults()
+
+	warnings := s.checkAgentReferences(ctx, &newAgent.Config, tenantID, userID)
+
+	logger.Infof(ctx, "Cloning agent, source ID: %s, source tenant: %d, new ID: %s",
+		id, sourceAgent.TenantID, newAgent.ID)
+
+	if err := s.repo.CreateAgent(ctx, newAgent); err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"source_agent_id": id,
+			"new_agent_id":    newAgent.ID,
+		})
+		return nil, err
+	}
+
+	logger.Infof(ctx, "Agent cloned successfully, source ID: %s, new ID: %s, warnings: %d",
+		id, newAgent.ID, len(warnings))
+	return &types.AgentCloneResult{Agent: newAgent, Warnings: warnings}, nil
+}
+
+// checkAgentReferences reports selected knowledge bases and models that tenantID (or userID through a
+// knowledge base share) cannot access
+func (s *customAgentService) checkAgentReferences(
+	ctx context.Context, config *types.CustomAgentConfig, tenantID uint64, userID string,
+) []string {
+	warnings := []string{}
+	if config.KBSelectionMode == "selected" {
+		for _, kbID := range config.KnowledgeBases {
+			kb, err := s.kbRepo.GetKnowledgeBaseByID(ctx, kbID)
+			if err != nil {
+				if !errors.Is(err, repository.ErrKnowledgeBaseNotFound) {
+					logger.Warnf(ctx, "Failed to check knowledge base %s: %v", kbID, err)
+				}
+				warnings = append(warnings, fmt.Sprintf("knowledge base %s not found", kbID))
+				continue
+			}
+			if kb.TenantID == tenantID {
+				continue
+			}
+			if userID != "" {
+				if _, shared, err := s.kbShareService.CheckUserKBPermission(ctx, kbID, userID); err == nil && shared {
+					continue
+				}
+			}
+			warnings = append(warnings, fmt.Sprintf("knowledge base %s is not accessible", kbID))
+		}
+	}
+	for _, ref := range []struct{ field, id string }{
+		{"model_id", config.ModelID},
+		{"rerank_model_id", config.RerankModelID},
+	} {
+		if ref.id == "" {
+			continue
+		}
+		model, err := s.modelRepo.GetByID(ctx, tenantID, ref.id)
+		if err != nil {
+			logger.Warnf(ctx, "Failed to check model %s: %v", ref.id, err)
+		}
+		if model == nil {
+			warnings = append(warnings, fmt.Sprintf("%s %s is not accessible", ref.field, ref.id))
+		}
+	}
+	return warnings
+}
+
+// PreviewResolvedKBs resolves the agent's knowledge bases the same way a chat request does, so for
+// mode "all" the result reflects the live knowledge base list of the tenant
+func (s *customAgentService) PreviewResolvedKBs(ctx context.Context, id string) (*types.AgentResolvedKBs, error) {
+	agent, err := s.GetAgentByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	agent.EnsureDefaults()
+	tenantID := types.MustTenantIDFromContext(ctx)
+
+	kbIDs := s.sessionService.ResolveAgentKnowledgeBases(ctx, agent, tenantID)
+	kbs, err := s.kbRepo.GetKnowledgeBaseByIDs(ctx, kbIDs)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"agent_id": id,
+		})
+		return nil, err
+	}
+	kbMap := make(map[string]*types.KnowledgeBase, len(kbs))
+	for _, kb := range kbs {
+		kbMap[kb.ID] = kb
+	}
+
+	result := &types.AgentResolvedKBs{
+		KBSelectionMode:             agent.Config.KBSelectionMode,
+		RetrieveKBOnlyWhenMentioned: agent.Config.RetrieveKBOnlyWhenMentioned,
+		KnowledgeBases:              make([]types.AgentResolvedKB, 0, len(kbIDs)),
+	}
+	for _, kbID := range kbIDs {
+		kb, ok := kbMap[kbID]
+		if !ok {
+			result.KnowledgeBases = append(result.KnowledgeBases, types.AgentResolvedKB{ID: kbID, Missing: true})
+			continue
+		}
+		result.KnowledgeBases = append(result.KnowledgeBases, types.AgentResolvedKB{
+			ID:       kb.ID,
+			Name:     kb.Name,
+			TenantID: kb.TenantID,
+		})
+	}
+	return result, nil
+}
+
+// ResetAgentConfig resets an agent's configuration to defaults. Built-in agents go back to their
+// registry config; custom agents get a fresh config. Only agents of the current tenant can be reset.
+func (s *customAgentService) ResetAgentConfig(ctx context.Context, id string) (*types.CustomAgent, error) {
+	if id == "" {
+		logger.Error(ctx, "Agent ID is empty")
+		return nil, errors.New("agent ID cannot be empty")
+	}
+
+	// Get tenant ID from context
+	tenantID, ok := types.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, ErrInvalidTenantID
+	}
+
+	var base types.CustomAgentConfig
+	if types.IsBuiltinAgentID(id) {
+		base = types.GetBuiltinAgent(id, tenantID).Config
+	}
+
+	agent, err := s.repo.GetAgentByID(ctx, id, tenantID)
+	if err != nil {
+		if !errors.Is(err, repository.ErrCustomAgentNotFound) {
+			return nil, err
+		}
+		if !types.IsBuiltinAgentID(id) {
+			return nil, ErrAgentNotFound
+		}
+		// Built-in agent was never customized, its config is already the default
+		defaultAgent := types.GetBuiltinAgent(id, tenantID)
+		defaultAgent.EnsureDefaults()
+		return defaultAgent, nil
+	}
+
+	agent.ResetConfig(base)
+	agent.UpdatedAt = time.Now()
+
+	logger.Infof(ctx, "Resetting agent config, ID: %s", id)
+
+	if err := s.repo.UpdateAgent(ctx, agent); err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"agent_id": id,
+		})
+		return nil, err
+	}
+
+	logger.Infof(ctx, "Agent config reset successfully, ID: %s", id)
+	return agent, nil
+}
+
+// EvaluateAgent runs each question through the agent and collects answers and references.
+// Each question runs in its own temporary session, deleted afterwards, with bounded concurrency.
+func (s *customAgentService) EvaluateAgent(
+	ctx context.Context, id string, questions []string,
+) ([]*types.AgentEvaluationResult, error) {
+	if len(questions) == 0 {
+		return nil, ErrNoEvaluationQuestions
+	}
+	if len(questions) > types.MaxEvaluationQuestions {
+		return nil, ErrTooManyEvalQuestions
+	}
+	for _, question := range questions {
+		if strings.TrimSpace(question) == "" {
+			return nil, ErrEmptyEvalQuestion
+		}
+	}
+
+	tenantID, ok := types.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, ErrInvalidTenantID
+	}
+
+	agent, err := s.GetAgentByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof(ctx, "Evaluating agent %s with %d questions", id, len(questions))
+
+	results := make([]*types.AgentEvaluationResult, len(questions))
+	var g errgroup.Group
+	g.SetLimit(types.EvaluationConcurrency)
+	for i, question := range questions {
+		g.Go(func() error {
+			results[i] = s.evaluateQuestion(ctx, agent, tenantID, question)
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	return results, nil
+}
+
+// CompareAgents runs the same query through two agents concurrently.
+// Each run gets its own temporary session, so the two never share conversation context.
+func (s *customAgentService) CompareAgents(
+	ctx context.Context, idA, idB string, query string,
+) (*types.AgentComparisonResult, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, ErrEmptyEvalQuestion
+	}
+
+	tenantID, ok := types.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, ErrInvalidTenantID
+	}
+
+	// Resolve both agents up front so the caller's access to each is checked before running anything
+	agentA, err := s.GetAgentByID(ctx, idA)
+	if err != nil {
+		return nil, err
+	}
+	agentB, err := s.GetAgentByID(ctx, idB)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof(ctx, "Comparing agents %s and %s", idA, idB)
+
+	result := &types.AgentComparisonResult{Query: query}
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		result.AgentA = &types.AgentComparisonRun{
+			AgentID:               agentA.ID,
+			AgentName:             agentA.Name,
+			AgentEvaluationResult: s.evaluateQuestion(ctx, agentA, tenantID, query),
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		result.AgentB = &types.AgentComparisonRun{
+			AgentID:               agentB.ID,
+			AgentName:             agentB.Name,
+			AgentEvaluationResult: s.evaluateQuestion(ctx, agentB, tenantID, query),
+		}
+	}()
+	wg.Wait()
+
+	return result, nil
+}
+
+// evaluateQuestion answers a single question with the agent in a temporary session.
+// The session is persisted because the QA pipeline keeps its context per session, and is
+// deleted together with that context once the question is answered.
+// Failures are reported on the result rather than aborting the whole evaluation.
+func (s *customAgentService) evaluateQuestion(
+	ctx context.Context, agent *types.CustomAgent, tenantID uint64, question string,
+) *types.AgentEvaluationResult {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, evaluationQuestionTimeout)
+	defer cancel()
+
+	session, err := s.sessionService.CreateSession(ctx, &types.Session{
+		TenantID:          tenantID,
+		Title:             "Agent evaluation",
+		AutoTitleDisabled: true,
+	})
+	if err != nil {
+		return &types.AgentEvaluationResult{
+			Question:   question,
+			Error:      err.Error(),
+			References: types.References{},
+			DurationMs: time.Since(start).Milliseconds(),
+		}
+	}
+	defer func() {
+		if err := s.sessionService.DeleteSession(context.WithoutCancel(ctx), session.ID); err != nil {
+			logger.Warnf(ctx, "Failed to delete evaluation session %s: %v", session.ID, err)
+		}
+	}()
+
+	eventBus := event.NewEventBus()
+	event.InstallAnswerFilters(ctx, eventBus)
+
+	var mu sync.Mutex
+	var answer strings.Builder
+	var references types.References
+	var qaErr string
+	done := make(chan struct{})
+	var closeOnce sync.Once
+	closeDone := func() { closeOnce.Do(func() { close(done) }) }
+
+	eventBus.On(event.EventAgentFinalAnswer, func(ctx context.Context, evt event.Event) error {
+		data, ok := evt.Data.(event.AgentFinalAnswerData)
+		if !ok {
+			return nil
+		}
+		mu.Lock()
+		answer.WriteString(data.Content)
+		mu.Unlock()
+		if data.Done {
+			closeDone()
+		}
+		return nil
+	})
+	eventBus.On(event.EventAgentReferences, func(ctx context.Context, evt event.Event) error {
+		data, ok := evt.Data.(event.AgentReferencesData)
+		if !ok {
+			return nil
+		}
+		mu.Lock()
+		switch refs := data.References.(type) {
+		case []*types.SearchResult:
+			references = refs
+		case types.References:
+			references = refs
+		}
+		mu.Unlock()
+		return nil
+	})
+	eventBus.On(event.EventError, func(ctx context.Context, evt event.Event) error {
+		data, ok := evt.Data.(event.ErrorData)
+		if !ok {
+			return nil
+		}
+		mu.Lock()
+		qaErr = data.Error
+		mu.Unlock()
+		closeDone()
+		return nil
+	})
+
+	qaDone := make(chan struct{})
+	go func() {
+		defer close(qaDone)
+		assistantMessageID := uuid.New().String()
+		var err error
+		req := &types.QARequest{
+			Session:            session,
+			Query:              question,
+			AssistantMessageID: assistantMessageID,
+			CustomAgent:        agent,
+		}
+		if agent.IsAgentMode() {
+			err = s.sessionService.AgentQA(ctx, req, eventBus)
+		} else {
+			req.WebSearchEnabled = agent.Config.WebSearchEnabled
+			err = s.sessionService.KnowledgeQA(ctx, req, eventBus)
+		}
+		if err != nil {
+			mu.Lock()
+			qaErr = err.Error()
+			mu.Unlock()
+			closeDone()
+		}
+	}()
+
+	// Normal mode may keep streaming the answer after KnowledgeQA returns, while agent mode
+	// emits references before returning, so wait for both the final answer and the call itself.
+	timedOut := false
+	select {
+	case <-done:
+		select {
+		case <-qaDone:
+		case <-ctx.Done():
+		}
+	case <-ctx.Done():
+		timedOut = true
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	result := &types.AgentEvaluationResult{
+		Question:   question,
+		Answer:     answer.String(),
+		References: references,
+		Error:      qaErr,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if timedOut && result.Error == "" {
+		result.Error = fmt.Sprintf("evaluation timed out after %v", evaluationQuestionTimeout)
+	}
+	if result.References == nil {
+		result.References = types.References{}
+	}
+	return result
+}
+
+// validateAgentConfig validates user-supplied agent configuration fields
+func validateAgentConfig(config *types.CustomAgentConfig) error {
+	if len(config.StopSequences) > types.MaxStopSequences {
+		return ErrTooManyStopSequences
+	}
+	for _, stop := range config.StopSequences {
+		if stop == "" {
+			return ErrEmptyStopSequence
+		}
+	}
+	switch config.ResponseFormat {
+	case "", types.ResponseFormatText, types.ResponseFormatJSONObject:
+	default:
+		return ErrInvalidResponseFormat
+	}
+	if config.ResponseSchema != "" && !json.Valid([]byte(config.ResponseSchema)) {
+		return ErrInvalidResponseSchema
+	}
+	if len(config.FewShotExamples) > types.MaxFewShotExamples {
+		return ErrTooManyFewShots
+	}
+	for _, example := range config.FewShotExamples {
+		if strings.TrimSpace(example.User) == "" || strings.TrimSpace(example.Assistant) == "" {
+			return ErrEmptyFewShotExample
+		}
+	}
+	if types.EstimateChatExampleTokens(config.FewShotExamples) > types.MaxFewShotExampleTokens {
+		return ErrFewShotsTooLong
+	}
+	if utf8.RuneCountInString(config.NoMatchPrefix) > types.MaxNoMatchPrefixLength {
+		return ErrNoMatchPrefixTooLong
+	}
+	if config.ContextConfig != nil {
+		if err := validateContextConfig(config.ContextConfig); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateContextConfig checks agent-level context compression overrides, zero values mean "inherit"
+func validateContextConfig(config *types.ContextConfig) error {
+	switch config.CompressionStrategy {
+	case "", types.ContextCompressionSlidingWindow, types.ContextCompressionSmart:
+	default:
+		return ErrInvalidCompression
+	}
+	if config.MaxTokens < 0 || config.MaxTokens > types.MaxContextTokens ||
+		config.RecentMessageCount < 0 || config.RecentMessageCount > types.MaxContextRecentMessageCount ||
+		config.SummarizeThreshold < 0 || config.SummarizeThreshold > types.MaxContextSummarizeThreshold {
+		return ErrInvalidContextConfig
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	"github.com/Tencent/WeKnora/internal/event"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// mapAgentRepo serves agents by ID
+type mapAgentRepo struct {
+	interfaces.CustomAgentRepository
+	agents map[string]*types.CustomAgent
+}
+
+func (r *mapAgentRepo) GetAgentByID(ctx context.Context, id string, tenantID uint64) (*types.CustomAgent, error) {
+	if agent, ok := r.agents[id]; ok {
+		return agent, nil
+	}
+	return nil, repository.ErrCustomAgentNotFound
+}
+
+// echoSessionService answers every question with the agent name and the question, and tracks
+// which sessions are alive. The question "fail" makes the QA call return an error.
+type echoSessionService struct {
+	interfaces.SessionService
+	mu       sync.Mutex
+	sessions map[string]bool
+	created  int
+	answered map[string]string // question -> QA mode
+}
+
+func newEchoSessionService() *echoSessionService {
+	return &echoSessionService{sessions: map[string]bool{}, answered: map[string]string{}}
+}
+
+func (s *echoSessionService) CreateSession(ctx context.Context, session *types.Session) (*types.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.created++
+	session.ID = fmt.Sprintf("session-%d", s.created)
+	s.sessions[session.ID] = true
+	return session, nil
+}
+
+func (s *echoSessionService) DeleteSession(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, id)
+	return nil
+}
+
+func (s *echoSessionService) answer(ctx context.Context,
+	session *types.Session, query string, eventBus *event.EventBus, agent *types.CustomAgent, mode string,
+) error {
+	s.mu.Lock()
+	alive := s.sessions[session.ID]
+	s.answered[query] = mode
+	s.mu.Unlock()
+	if !alive {
+		return errors.New("session not persisted")
+	}
+	if query == "fail" {
+		return errors.New("model unavailable")
+	}
+	eventBus.Emit(ctx, event.Event{
+		Type: event.EventAgentReferences,
+		Data: event.AgentReferencesData{References: []*types.SearchResult{{ID: "chunk-" + agent.ID}}},
+	})
+	eventBus.Emit(ctx, event.Event{
+		ID:   "answer",
+		Type: event.EventAgentFinalAnswer,
+		Data: event.AgentFinalAnswerData{Content: agent.Name + ": " + query},
+	})
+	eventBus.Emit(ctx, event.Event{
+		ID:   "answer-done",
+		Type: event.EventAgentFinalAnswer,
+		Data: event.AgentFinalAnswerData{Done: true},
+	})
+	return nil
+}
+
+func (s *echoSessionService) AgentQA(ctx context.Context, req *types.QARequest, eventBus *event.EventBus) error {
+	return s.answer(ctx, req.Session, req.Query, eventBus, req.CustomAgent, "agent")
+}
+
+func (s *echoSessionService) KnowledgeQA(ctx context.Context, req *types.QARequest, eventBus *event.EventBus) error {
+	return s.answer(ctx, req.Session, req.Query, eventBus, req.CustomAgent, "normal")
+}
+
+func newEvalTestService() (*customAgentService, *echoSessionService) {
+	sessions := newEchoSessionService()
+	return &customAgentService{
+		repo: &mapAgentRepo{agents: map[string]*types.CustomAgent{
+			"agent-a": {ID: "agent-a", Name: "A", Config: types.CustomAgentConfig{AgentMode: types.AgentModeSmartReasoning}},
+			"agent-b": {ID: "agent-b", Name: "B"},
+		}},
+		sessionService: sessions,
+	}, sessions
+}
+
+func TestCustomAgentServiceEvaluateAgent(t *testing.T) {
+	s, sessions := newEvalTestService()
+	ctx := context.WithValue(context.Background(), types.TenantIDContextKey, uint64(1))
+	ctx = context.WithValue(ctx, types.TenantInfoContextKey, &types.Tenant{
+		ID:                1,
+		BannedWordsConfig: &types.BannedWordsConfig{Enabled: true, Phrases: []string{"secret"}, Mask: "***"},
+	})
+
+	results, err := s.EvaluateAgent(ctx, "agent-a", []string{"what is WeKnora", "fail", "tell a secret"})
+	if err != nil {
+		t.Fatalf("EvaluateAgent() error = %v", err)
+	}
+	want := []struct{ answer, err string }{
+		{"A: what is WeKnora", ""},
+		{"", "model unavailable"},
+		{"A: tell a ***", ""},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("EvaluateAgent() returned %d results, want %d", len(results), len(want))
+	}
+	for i, result := range results {
+		if result.Answer != want[i].answer || result.Error != want[i].err {
+			t.Errorf("result %d = %q, %q, want %q, %q", i, result.Answer, result.Error, want[i].answer, want[i].err)
+		}
+	}
+	if refs := results[0].References; len(refs) != 1 || refs[0].ID != "chunk-agent-a" {
+		t.Errorf("references = %v, want chunk-agent-a", refs)
+	}
+	if results[1].References == nil {
+		t.Error("references of a failed question are nil, want empty")
+	}
+	if sessions.created != 3 || len(sessions.sessions) != 0 {
+		t.Errorf("created %d sessions, %d left, want 3 created and none left", sessions.created, len(sessions.sessions))
+	}
+	if mode := sessions.answered["what is WeKnora"]; mode != "agent" {
+		t.Errorf("question answered in %q mode, want agent", mode)
+	}
+}
+
+func TestCustomAgentServiceEvaluateAgentValidation(t *testing.T) {
+	s, _ := newEvalTestService()
+	ctx := context.WithValue(context.Background(), types.TenantIDContextKey, uint64(1))
+	tests := []struct {
+		name      string
+		id        string
+		questions []string
+		wantErr   error
+	}{
+		{"no questions", "agent-a", nil, ErrNoEvaluationQuestions},
+		{"too many questions", "agent-a", make([]string, types.MaxEvaluationQuestions+1), ErrTooManyEvalQuestions},
+		{"blank question", "agent-a", []string{"ok", " "}, ErrEmptyEvalQuestion},
+		{"unknown agent", "agent-x", []string{"ok"}, ErrAgentNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.EvaluateAgent(ctx, tt.id, tt.questions); !errors.Is(err, tt.wantErr) {
+				t.Errorf("EvaluateAgent() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCustomAgentServiceCompareAgents(t *testing.T) {
+	s, sessions := newEvalTestService()
+	ctx := context.WithValue(context.Background(), types.TenantIDContextKey, uint64(1))
+
+	result, err := s.CompareAgents(ctx, "agent-a", "agent-b", "hello")
+	if err != nil {
+		t.Fatalf("CompareAgents() error = %v", err)
+	}
+	if result.Query != "hello" {
+		t.Errorf("query = %q, want hello", result.Query)
+	}
+	for _, tc := range []struct {
+		run        *types.AgentComparisonRun
+		id, answer string
+	}{
+		{result.AgentA, "agent-a", "A: hello"},
+		{result.AgentB, "agent-b", "B: hello"},
+	} {
+		if tc.run.AgentID != tc.id || tc.run.Answer != tc.answer || tc.run.Error != "" {
+			t.Errorf("run = %s, %q, %q, want %s, %q", tc.run.AgentID, tc.run.Answer, tc.run.Error, tc.id, tc.answer)
+		}
+	}
+	if sessions.created != 2 || len(sessions.sessions) != 0 {
+		t.Errorf("created %d sessions, %d left, want 2 created and none left", sessions.created, len(sessions.sessions))
+	}
+
+	if _, err := s.CompareAgents(ctx, "agent-a", "agent-b", " "); !errors.Is(err, ErrEmptyEvalQuestion) {
+		t.Errorf("CompareAgents() with blank query error = %v, want %v", err, ErrEmptyEvalQuestion)
+	}
+	if _, err := s.CompareAgents(ctx, "agent-a", "agent-x", "hello"); !errors.Is(err, ErrAgentNotFound) {
+		t.Errorf("CompareAgents() with unknown agent error = %v, want %v", err, ErrAgentNotFound)
+	}
+}
@@ -266,14 +266,16 @@ func (e *EvaluationService) Evaluation(ctx context.Context,
 			StartTime: time.Now(),
 		},
 		Params: &types.ChatManage{
-			VectorThreshold:  e.config.Conversation.VectorThreshold,
-			KeywordThreshold: e.config.Conversation.KeywordThreshold,
-			EmbeddingTopK:    e.config.Conversation.EmbeddingTopK,
-			MaxRounds:        e.config.Conversation.MaxRounds,
-			RerankModelID:    rerankModelID,
-			RerankTopK:       e.config.Conversation.RerankTopK,
-			RerankThreshold:  e.config.Conversation.RerankThreshold,
-			ChatModelID:      chatModelID,
+			VectorThreshold:    e.config.Conversation.VectorThreshold,
+			KeywordThreshold:   e.config.Conversation.KeywordThreshold,
+			EmbeddingTopK:      e.config.Conversation.EmbeddingTopK,
+			MaxRounds:          e.config.Conversation.MaxRounds,
+			RerankModelID:      rerankModelID,
+			RerankTopK:         e.config.Conversation.RerankTopK,
+			RerankThreshold:    e.config.Conversation.RerankThreshold,
+			DedupThreshold:     e.config.Conversation.GetDedupThreshold(),
+			ChunkContextWindow: e.config.Conversation.GetChunkContextWindow(),
+			ChatModelID:        chatModelID,
 			SummaryConfig: types.SummaryConfig{
 				MaxTokens:           e.config.Conversation.Summary.MaxTokens,
 				RepeatPenalty:       e.config.Conversation.Summary.RepeatPenalty,
@@ -288,9 +290,10 @@ func (e *EvaluationService) Evaluation(ctx context.Context,
 				Seed:                e.config.Conversation.Summary.Seed,
 				MaxCompletionTokens: e.config.Conversation.Summary.MaxCompletionTokens,
 			},
-			FallbackResponse:    e.config.Conversation.FallbackResponse,
-			RewritePromptSystem: e.config.Conversation.RewritePromptSystem,
-			RewritePromptUser:   e.config.Conversation.RewritePromptUser,
+			FallbackResponse:         e.config.Conversation.FallbackResponse,
+			RewritePromptSystem:      e.config.Conversation.RewritePromptSystem,
+			RewritePromptUser:        e.config.Conversation.RewritePromptUser,
+			RewritePromptsByLanguage: e.config.Conversation.RewritePromptsByLanguage,
 		},
 	}
 
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrGenerationNotFound is returned when cancelling a generation that is not in flight
+var ErrGenerationNotFound = errors.New("generation not found")
+
+const (
+	activeGenerationKeyPrefix = "active_generation:"
+	// generationCancelChannel notifies every instance of cancelled generations, so the instance
+	// streaming the answer can stop it
+	generationCancelChannel = "active_generation_cancel"
+	// activeGenerationTTL bounds how long an entry outlives an instance that died mid-generation
+	activeGenerationTTL = time.Hour
+)
+
+// activeGeneration is a registry entry together with the function that stops it
+type activeGeneration struct {
+	info types.ActiveGeneration
+	stop func()
+}
+
+// generationRegistry tracks the answers being streamed. The generations are shared through Redis
+// when available so any instance can list and cancel them; the stop functions stay with the
+// instance running the generation. Without Redis (Lite mode) only this instance is covered.
+type generationRegistry struct {
+	redisClient *redis.Client
+	mu          sync.Mutex
+	// items holds the generations running on this instance by assistant message ID
+	items map[string]*activeGeneration
+}
+
+// newGenerationRegistry creates an empty registry, listening for cancellations from other
+// instances when Redis is available
+func newGenerationRegistry(redisClient *redis.Client) *generationRegistry {
+	r := &generationRegistry{redisClient: redisClient, items: make(map[string]*activeGeneration)}
+	if redisClient != nil {
+		go r.listenForCancellations(context.Background())
+	}
+	return r
+}
+
+func getActiveGenerationKey(messageID string) string {
+	return activeGenerationKeyPrefix + messageID
+}
+
+// listenForCancellations stops the local generations cancelled through another instance
+func (r *generationRegistry) listenForCancellations(ctx context.Context) {
+	pubsub := r.redisClient.Subscribe(ctx, generationCancelChannel)
+	defer pubsub.Close()
+	for msg := range pubsub.Channel() {
+		if found := r.takeLocal(msg.Payload); found != nil {
+			logger.Infof(ctx, "Cancelling generation of message %s on request of another instance", msg.Payload)
+			found.stop()
+		}
+	}
+}
+
+// takeLocal removes and returns a generation running on this instance
+func (r *generationRegistry) takeLocal(messageID string) *activeGeneration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	found := r.items[messageID]
+	delete(r.items, messageID)
+	return found
+}
+
+// StartGeneration registers an in-flight answer generation; stop is invoked when it is cancelled
+func (s *sessionService) StartGeneration(ctx context.Context, gen *types.ActiveGeneration, stop func()) {
+	if gen == nil {
+		return
+	}
+	if stop == nil {
+		stop = func() {}
+	}
+	r := s.generations
+	r.mu.Lock()
+	r.items[gen.MessageID] = &activeGeneration{info: *gen, stop: stop}
+	r.mu.Unlock()
+
+	if r.redisClient == nil {
+		return
+	}
+	data, err := json.Marshal(gen)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to marshal active generation: %v", err)
+		return
+	}
+	if err := r.redisClient.Set(ctx, getActiveGenerationKey(gen.MessageID), data, activeGenerationTTL).Err(); err != nil {
+		logger.Warnf(ctx, "Failed to register generation of message %s in Redis: %v", gen.MessageID, err)
+	}
+}
+
+// FinishGeneration removes a generation from the registry
+func (s *sessionService) FinishGeneration(sessionID, messageID string) {
+	r := s.generations
+	r.takeLocal(messageID)
+	if r.redisClient == nil {
+		return
+	}
+	ctx := context.Background()
+	if err := r.redisClient.Del(ctx, getActiveGenerationKey(messageID)).Err(); err != nil {
+		logger.Warnf(ctx, "Failed to remove generation of message %s from Redis: %v", messageID, err)
+	}
+}
+
+// ListActiveGenerations lists the generations in flight, oldest first. tenantID 0 lists all tenants.
+func (s *sessionService) ListActiveGenerations(ctx context.Context, tenantID uint64) ([]*types.ActiveGeneration, error) {
+	all, err := s.generations.list(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*types.ActiveGeneration, 0, len(all))
+	for _, gen := range all {
+		if tenantID == 0 || gen.TenantID == tenantID {
+			result = append(result, gen)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].StartedAt.Before(result[j].StartedAt)
+	})
+	return result, nil
+}
+
+// list returns every registered generation, from Redis when available
+func (r *generationRegistry) list(ctx context.Context) ([]*types.ActiveGeneration, error) {
+	if r.redisClient == nil {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		result := make([]*types.ActiveGeneration, 0, len(r.items))
+		for _, item := range r.items {
+			info := item.info
+			result = append(result, &info)
+		}
+		return result, nil
+	}
+
+	var keys []string
+	iter := r.redisClient.Scan(ctx, 0, activeGenerationKeyPrefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan active generations: %w", err)
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	values, err := r.redisClient.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active generations: %w", err)
+	}
+	result := make([]*types.ActiveGeneration, 0, len(values))
+	for _, value := range values {
+		// Entries finished between the scan and the read are nil
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var gen types.ActiveGeneration
+		if err := json.Unmarshal([]byte(data), &gen); err != nil {
+			logger.Warnf(ctx, "Failed to unmarshal active generation: %v", err)
+			continue
+		}
+		result = append(result, &gen)
+	}
+	return result, nil
+}
+
+// CancelGeneration stops the in-flight generation of an assistant message, whichever instance runs it
+func (s *sessionService) CancelGeneration(ctx context.Context, messageID string) (*types.ActiveGeneration, error) {
+	r := s.generations
+	if found := r.takeLocal(messageID); found != nil {
+		if r.redisClient != nil {
+			if err := r.redisClient.Del(ctx, getActiveGenerationKey(messageID)).Err(); err != nil {
+				logger.Warnf(ctx, "Failed to remove generation of message %s from Redis: %v", messageID, err)
+			}
+		}
+		logger.Infof(ctx, "Cancelling generation of message %s in session %s (tenant %d)",
+			messageID, found.info.SessionID, found.info.TenantID)
+		found.stop()
+		info := found.info
+		return &info, nil
+	}
+	if r.redisClient == nil {
+		return nil, ErrGenerationNotFound
+	}
+
+	// The generation runs on another instance: claim the entry and ask that instance to stop it
+	data, err := r.redisClient.GetDel(ctx, getActiveGenerationKey(messageID)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrGenerationNotFound
+		}
+		return nil, fmt.Errorf("failed to get active generation: %w", err)
+	}
+	var info types.ActiveGeneration
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal active generation: %w", err)
+	}
+	if err := r.redisClient.Publish(ctx, generationCancelChannel, messageID).Err(); err != nil {
+		return nil, fmt.Errorf("failed to publish generation cancellation: %w", err)
+	}
+	logger.Infof(ctx, "Cancelling generation of message %s in session %s (tenant %d) on another instance",
+		messageID, info.SessionID, info.TenantID)
+	return &info, nil
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestGenerationRegistryWithoutRedis(t *testing.T) {
+	ctx := context.Background()
+	s := &sessionService{generations: newGenerationRegistry(nil)}
+	start := time.Now()
+	stopped := map[string]int{}
+	for _, gen := range []*types.ActiveGeneration{
+		{SessionID: "s-1", MessageID: "m-2", TenantID: 1, StartedAt: start.Add(time.Second)},
+		{SessionID: "s-1", MessageID: "m-1", TenantID: 1, StartedAt: start},
+		{SessionID: "s-2", MessageID: "m-3", TenantID: 2, StartedAt: start.Add(time.Minute)},
+	} {
+		messageID := gen.MessageID
+		s.StartGeneration(ctx, gen, func() { stopped[messageID]++ })
+	}
+
+	all, err := s.ListActiveGenerations(ctx, 0)
+	if err != nil || len(all) != 3 || all[0].MessageID != "m-1" || all[1].MessageID != "m-2" {
+		t.Fatalf("ListActiveGenerations(0) = %+v, %v, want 3 generations oldest first", all, err)
+	}
+	tenant, err := s.ListActiveGenerations(ctx, 2)
+	if err != nil || len(tenant) != 1 || tenant[0].MessageID != "m-3" {
+		t.Fatalf("ListActiveGenerations(2) = %+v, %v, want m-3", tenant, err)
+	}
+
+	gen, err := s.CancelGeneration(ctx, "m-1")
+	if err != nil || gen.SessionID != "s-1" || stopped["m-1"] != 1 {
+		t.Fatalf("CancelGeneration() = %+v, %v, stopped %d times", gen, err, stopped["m-1"])
+	}
+	if _, err := s.CancelGeneration(ctx, "m-1"); !errors.Is(err, ErrGenerationNotFound) {
+		t.Errorf("second CancelGeneration() error = %v, want %v", err, ErrGenerationNotFound)
+	}
+
+	s.FinishGeneration("s-1", "m-2")
+	if _, err := s.CancelGeneration(ctx, "m-2"); !errors.Is(err, ErrGenerationNotFound) {
+		t.Errorf("CancelGeneration() of a finished generation error = %v, want %v", err, ErrGenerationNotFound)
+	}
+	if stopped["m-2"] != 0 {
+		t.Errorf("finished generation was stopped")
+	}
+	if left, _ := s.ListActiveGenerations(ctx, 0); len(left) != 1 {
+		t.Errorf("%d generations left, want 1", len(left))
+	}
+}
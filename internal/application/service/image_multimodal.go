@@ -127,7 +127,11 @@ func (s *ImageMultimodalService) Handle(ctx context.Context, task *asynq.Task) e
 	}
 
 	if payload.EnableOCR {
-		ocrText, ocrErr := vlmModel.Predict(ctx, imgBytes, vlmOCRPrompt)
+		ocrPrompt := vlmOCRPrompt
+		if names := (types.OCRSettings{Languages: payload.OCRLanguages}).LanguageNames(); names != "" {
+			ocrPrompt += "\nThe text in the image is written in: " + names + "."
+		}
+		ocrText, ocrErr := vlmModel.Predict(ctx, imgBytes, ocrPrompt)
 		if ocrErr != nil {
 			logger.Warnf(ctx, "[ImageMultimodal] OCR failed for %s: %v", payload.ImageURL, ocrErr)
 		} else {
@@ -3,7 +3,11 @@ package service
 import (
 	"context"
 	"errors"
+	"slices"
+	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/Tencent/WeKnora/internal/application/repository"
 	"github.com/Tencent/WeKnora/internal/logger"
@@ -18,7 +22,18 @@ var (
 	ErrKBNotFound            = errors.New("knowledge base not found")
 	ErrNotKBOwner            = errors.New("only knowledge base owner can share")
 	// ErrOrgRoleCannotShare: only editors and admins in the org can share KBs to that org; viewers cannot
-	ErrOrgRoleCannotShare = errors.New("only editors and admins can share knowledge bases to this organization")
+	ErrOrgRoleCannotShare   = errors.New("only editors and admins can share knowledge bases to this organization")
+	ErrDynamicShareNotFound = errors.New("dynamic share not found")
+	// ErrDynamicShareAdminOnly: only admins in the org can create dynamic share rules
+	ErrDynamicShareAdminOnly = errors.New("only organization admins can create dynamic shares")
+	ErrInvalidShareCriteria  = errors.New("dynamic share criteria must specify a label")
+	// ErrPartialShareReadOnly: members of a partial share may only read the shared documents
+	ErrPartialShareReadOnly = errors.New("partial shares only support the viewer permission")
+	// ErrPartialShareUnsupported: FAQ knowledge bases can only be shared as a whole
+	ErrPartialShareUnsupported = errors.New("partial shares are only supported for document knowledge bases")
+	ErrInvalidShareKnowledge   = errors.New("knowledge ids must belong to the shared knowledge base")
+	// ErrSharePermissionExceedsRole: members cannot grant more than their own role in the organization
+	ErrSharePermissionExceedsRole = errors.New("share permission cannot exceed your own role in the organization")
 )
 
 // kbShareService implements KBShareService interface
@@ -28,6 +43,12 @@ type kbShareService struct {
 	kbRepo    interfaces.KnowledgeBaseRepository
 	kgRepo    interfaces.KnowledgeRepository
 	chunkRepo interfaces.ChunkRepository
+	userRepo   interfaces.UserRepository
+	modelRepo  interfaces.ModelRepository
+	tenantRepo interfaces.TenantRepository
+	task       interfaces.TaskEnqueuer
+	// storageUsage caches the storage usage per organization ID (types.OrgStorageUsage)
+	storageUsage sync.Map
 }
 
 // NewKBShareService creates a new knowledge base share service
@@ -37,18 +58,27 @@ func NewKBShareService(
 	kbRepo interfaces.KnowledgeBaseRepository,
 	kgRepo interfaces.KnowledgeRepository,
 	chunkRepo interfaces.ChunkRepository,
+	userRepo interfaces.UserRepository,
+	modelRepo interfaces.ModelRepository,
+	tenantRepo interfaces.TenantRepository,
+	task interfaces.TaskEnqueuer,
 ) interfaces.KBShareService {
 	return &kbShareService{
-		shareRepo: shareRepo,
-		orgRepo:   orgRepo,
-		kbRepo:    kbRepo,
-		kgRepo:    kgRepo,
-		chunkRepo: chunkRepo,
+		shareRepo:  shareRepo,
+		orgRepo:    orgRepo,
+		kbRepo:     kbRepo,
+		kgRepo:     kgRepo,
+		chunkRepo:  chunkRepo,
+		userRepo:   userRepo,
+		modelRepo:  modelRepo,
+		tenantRepo: tenantRepo,
+		task:       task,
 	}
 }
 
 // ShareKnowledgeBase shares a knowledge base to an organization
-func (s *kbShareService) ShareKnowledgeBase(ctx context.Context, kbID string, orgID string, userID string, tenantID uint64, permission types.OrgMemberRole) (*types.KnowledgeBaseShare, error) {
+// A non-empty knowledgeIDs restricts the share to those documents (viewer permission only).
+func (s *kbShareService) ShareKnowledgeBase(ctx context.Context, kbID string, orgID string, userID string, tenantID uint64, permission types.OrgMemberRole, knowledgeIDs []string) (*types.KnowledgeBaseShare, error) {
 	logger.Infof(ctx, "Sharing knowledge base %s to organization %s", kbID, orgID)
 
 	// Verify knowledge base exists and user is the owner (same tenant)
@@ -63,7 +93,7 @@ func (s *kbShareService) ShareKnowledgeBase(ctx context.Context, kbID string, or
 	}
 
 	// Verify organization exists
-	_, err = s.orgRepo.GetByID(ctx, orgID)
+	org, err := s.orgRepo.GetByID(ctx, orgID)
 	if err != nil {
 		if errors.Is(err, repository.ErrOrganizationNotFound) {
 			return nil, ErrOrgNotFound
@@ -83,9 +113,24 @@ func (s *kbShareService) ShareKnowledgeBase(ctx context.Context, kbID string, or
 		return nil, ErrOrgRoleCannotShare
 	}
 
-	if !permission.IsValid() {
-		return nil, ErrInvalidRole
+	// Partial shares are read-only, so they default to viewer rather than the organization default
+	if permission == "" && len(knowledgeIDs) > 0 {
+		permission = types.OrgRoleViewer
+	}
+	permission, err = resolveSharePermission(org, member.Role, permission)
+	if err != nil {
+		return nil, err
+	}
+
+	knowledgeIDs, err = s.validateShareKnowledgeIDs(ctx, kb, permission, knowledgeIDs)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkOrgStorageQuota(ctx, orgID); err != nil {
+		return nil, err
 	}
+	// The share changes what counts towards the organization's storage
+	defer s.storageUsage.Delete(orgID)
 
 	share := &types.KnowledgeBaseShare{
 		ID:              uuid.New().String(),
@@ -94,6 +139,7 @@ func (s *kbShareService) ShareKnowledgeBase(ctx context.Context, kbID string, or
 		SharedByUserID:  userID,
 		SourceTenantID:  tenantID,
 		Permission:      permission,
+		KnowledgeIDs:    knowledgeIDs,
 		CreatedAt:       time.Now(),
 		UpdatedAt:       time.Now(),
 	}
@@ -106,6 +152,7 @@ func (s *kbShareService) ShareKnowledgeBase(ctx context.Context, kbID string, or
 				return nil, err
 			}
 			existingShare.Permission = permission
+			existingShare.KnowledgeIDs = knowledgeIDs
 			existingShare.UpdatedAt = time.Now()
 			if err := s.shareRepo.Update(ctx, existingShare); err != nil {
 				return nil, err
@@ -119,6 +166,68 @@ func (s *kbShareService) ShareKnowledgeBase(ctx context.Context, kbID string, or
 	return share, nil
 }
 
+// resolveSharePermission falls back to the organization's default share permission when none is
+// requested and checks the sharer does not grant more than their own role in the organization
+func resolveSharePermission(org *types.Organization,
+	sharerRole types.OrgMemberRole, permission types.OrgMemberRole,
+) (types.OrgMemberRole, error) {
+	if permission == "" {
+		permission = org.GetDefaultSharePermission()
+		// The default is capped to the sharer's role instead of rejecting a share they did not configure
+		if !sharerRole.HasPermission(permission) {
+			permission = sharerRole
+		}
+	}
+	if !permission.IsValid() {
+		return "", ErrInvalidRole
+	}
+	if !sharerRole.HasPermission(permission) {
+		return "", ErrSharePermissionExceedsRole
+	}
+	return permission, nil
+}
+
+// validateShareKnowledgeIDs deduplicates the documents of a partial share and checks they belong to the KB.
+// Returns nil when the whole knowledge base is shared.
+func (s *kbShareService) validateShareKnowledgeIDs(
+	ctx context.Context, kb *types.KnowledgeBase, permission types.OrgMemberRole, knowledgeIDs []string,
+) (types.StringArray, error) {
+	var ids types.StringArray
+	seen := make(map[string]bool, len(knowledgeIDs))
+	for _, id := range knowledgeIDs {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	if kb.Type == types.KnowledgeBaseTypeFAQ {
+		return nil, ErrPartialShareUnsupported
+	}
+	if permission != types.OrgRoleViewer {
+		return nil, ErrPartialShareReadOnly
+	}
+
+	knowledges, err := s.kgRepo.GetKnowledgeBatch(ctx, kb.TenantID, ids)
+	if err != nil {
+		return nil, err
+	}
+	found := 0
+	for _, k := range knowledges {
+		if k != nil && seen[k.ID] && k.KnowledgeBaseID == kb.ID {
+			found++
+		}
+	}
+	if found != len(ids) {
+		return nil, ErrInvalidShareKnowledge
+	}
+	return ids, nil
+}
+
 // UpdateSharePermission updates the permission of a share.
 // Allowed if: (1) current user is the sharer, or (2) current user is admin of the target organization.
 func (s *kbShareService) UpdateSharePermission(ctx context.Context, shareID string, permission types.OrgMemberRole, userID string) error {
@@ -131,9 +240,9 @@ func (s *kbShareService) UpdateSharePermission(ctx context.Context, shareID stri
 	}
 
 	// Sharer can always update; org admin can also update (e.g. when sharer left)
+	member, memberErr := s.orgRepo.GetMember(ctx, share.OrganizationID, userID)
 	if share.SharedByUserID != userID {
-		member, err := s.orgRepo.GetMember(ctx, share.OrganizationID, userID)
-		if err != nil || member.Role != types.OrgRoleAdmin {
+		if memberErr != nil || member.Role != types.OrgRoleAdmin {
 			return ErrSharePermissionDenied
 		}
 	}
@@ -141,6 +250,13 @@ func (s *kbShareService) UpdateSharePermission(ctx context.Context, shareID stri
 	if !permission.IsValid() {
 		return ErrInvalidRole
 	}
+	// A sharer who is still a member cannot raise the share above their own role
+	if memberErr == nil && !member.Role.HasPermission(permission) {
+		return ErrSharePermissionExceedsRole
+	}
+	if share.IsPartial() && permission != types.OrgRoleViewer {
+		return ErrPartialShareReadOnly
+	}
 
 	share.Permission = permission
 	share.UpdatedAt = time.Now()
@@ -159,6 +275,7 @@ func (s *kbShareService) RemoveShare(ctx context.Context, shareID string, userID
 		}
 		return err
 	}
+	defer s.storageUsage.Delete(share.OrganizationID)
 
 	// Sharer can always remove their own share
 	if share.SharedByUserID == userID {
@@ -186,9 +303,18 @@ func (s *kbShareService) ListSharesByKnowledgeBase(ctx context.Context, kbID str
 	return s.shareRepo.ListByKnowledgeBase(ctx, kbID)
 }
 
-// ListSharesByOrganization lists all shares for an organization
+// ListSharesByOrganization lists all shares for an organization, including the knowledge bases
+// currently matched by the organization's dynamic share rules
 func (s *kbShareService) ListSharesByOrganization(ctx context.Context, orgID string) ([]*types.KnowledgeBaseShare, error) {
-	return s.shareRepo.ListByOrganization(ctx, orgID)
+	shares, err := s.shareRepo.ListByOrganization(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := s.shareRepo.ListDynamicByOrganization(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	return s.appendDynamicShares(ctx, shares, rules), nil
 }
 
 // ListSharedKnowledgeBases lists all knowledge bases shared to the user through organizations
@@ -199,6 +325,11 @@ func (s *kbShareService) ListSharedKnowledgeBases(ctx context.Context, userID st
 	if err != nil {
 		return nil, err
 	}
+	rules, err := s.shareRepo.ListDynamicForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	shares = s.appendDynamicShares(ctx, shares, rules)
 
 	// Use a map to deduplicate by knowledge base ID, keeping the one with highest permission
 	kbInfoMap := make(map[string]*types.SharedKnowledgeBaseInfo)
@@ -299,7 +430,7 @@ func (s *kbShareService) ListSharedKnowledgeBasesInOrganization(ctx context.Cont
 		return nil, err
 	}
 
-	shares, err := s.shareRepo.ListByOrganization(ctx, orgID)
+	shares, err := s.ListSharesByOrganization(ctx, orgID)
 	if err != nil {
 		return nil, err
 	}
@@ -404,14 +535,69 @@ func (s *kbShareService) GetShareByKBAndOrg(ctx context.Context, kbID string, or
 // CheckUserKBPermission checks a user's permission for a knowledge base
 // Returns: permission level, isShared, error
 func (s *kbShareService) CheckUserKBPermission(ctx context.Context, kbID string, userID string) (types.OrgMemberRole, bool, error) {
-	// Get all shares for this knowledge base
-	shares, err := s.shareRepo.ListByKnowledgeBase(ctx, kbID)
+	access, err := s.resolveUserKBAccess(ctx, kbID, userID)
 	if err != nil {
 		return "", false, err
 	}
+	return access.permission, access.isShared, nil
+}
 
-	var highestPermission types.OrgMemberRole
-	isShared := false
+// GetSharedKnowledgeIDs returns the documents a user can access in a knowledge base shared with them.
+// restricted is false when one of the user's shares covers the whole KB or the KB is not shared with the user.
+func (s *kbShareService) GetSharedKnowledgeIDs(ctx context.Context, kbID string, userID string) ([]string, bool, error) {
+	access, err := s.resolveUserKBAccess(ctx, kbID, userID)
+	if err != nil {
+		return nil, false, err
+	}
+	if !access.isShared || access.knowledgeIDs == nil {
+		return nil, false, nil
+	}
+	return access.knowledgeIDs, true, nil
+}
+
+// HasKnowledgePermission checks if a user has at least the required permission for a document of a shared
+// knowledge base; partial shares only grant access to the documents they list.
+func (s *kbShareService) HasKnowledgePermission(ctx context.Context, kbID string, knowledgeID string, userID string, requiredRole types.OrgMemberRole) (bool, error) {
+	access, err := s.resolveUserKBAccess(ctx, kbID, userID)
+	if err != nil {
+		return false, err
+	}
+	if !access.isShared || !access.permission.HasPermission(requiredRole) {
+		return false, nil
+	}
+	return access.knowledgeIDs == nil || slices.Contains(access.knowledgeIDs, knowledgeID), nil
+}
+
+// userKBAccess is a user's access to a knowledge base resolved from the shares of their organizations
+type userKBAccess struct {
+	permission types.OrgMemberRole
+	isShared   bool
+	// knowledgeIDs is the union of the documents of the user's partial shares; nil when a share covers the whole KB
+	knowledgeIDs []string
+}
+
+// resolveUserKBAccess resolves a user's access to a knowledge base from its static shares and matching dynamic rules.
+// Static shares and dynamic rules are merged: the highest permission wins and the whole KB is accessible
+// when any of them covers it.
+func (s *kbShareService) resolveUserKBAccess(ctx context.Context, kbID string, userID string) (*userKBAccess, error) {
+	// Get all shares for this knowledge base, including the dynamic rules matching it
+	shares, err := s.shareRepo.ListByKnowledgeBase(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := s.shareRepo.ListDynamicMatchingKnowledgeBase(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	for _, rule := range rules {
+		share := dynamicShareFor(rule, nil)
+		share.KnowledgeBaseID = kbID
+		shares = append(shares, share)
+	}
+
+	access := &userKBAccess{}
+	wholeKB := false
+	seen := make(map[string]bool)
 
 	for _, share := range shares {
 		// Check if user is a member of the organization
@@ -420,7 +606,7 @@ func (s *kbShareService) CheckUserKBPermission(ctx context.Context, kbID string,
 			continue // User is not a member of this org
 		}
 
-		isShared = true
+		access.isShared = true
 
 		// Effective permission is the lower of share permission and user's org role
 		effectivePermission := share.Permission
@@ -429,12 +615,26 @@ func (s *kbShareService) CheckUserKBPermission(ctx context.Context, kbID string,
 		}
 
 		// Keep the highest permission
-		if highestPermission == "" || effectivePermission.HasPermission(highestPermission) {
-			highestPermission = effectivePermission
+		if access.permission == "" || effectivePermission.HasPermission(access.permission) {
+			access.permission = effectivePermission
+		}
+
+		if !share.IsPartial() {
+			wholeKB = true
+			continue
+		}
+		for _, id := range share.KnowledgeIDs {
+			if !seen[id] {
+				seen[id] = true
+				access.knowledgeIDs = append(access.knowledgeIDs, id)
+			}
 		}
 	}
 
-	return highestPermission, isShared, nil
+	if wholeKB {
+		access.knowledgeIDs = nil
+	}
+	return access, nil
 }
 
 // HasKBPermission checks if a user has at least the required permission level for a knowledge base
@@ -481,3 +681,157 @@ func (s *kbShareService) CountSharesByKnowledgeBaseIDs(ctx context.Context, kbID
 func (s *kbShareService) CountByOrganizations(ctx context.Context, orgIDs []string) (map[string]int64, error) {
 	return s.shareRepo.CountByOrganizations(ctx, orgIDs)
 }
+
+// CreateDynamicShare creates a rule sharing every knowledge base of the user's tenant whose share
+// label is criteria.Label to the organization. Knowledge bases labeled later are covered automatically.
+// Only organization admins can create rules.
+func (s *kbShareService) CreateDynamicShare(ctx context.Context, orgID string, criteria types.KBShareCriteria, userID string, tenantID uint64, permission types.OrgMemberRole) (*types.KnowledgeBaseDynamicShare, error) {
+	criteria.Label = strings.TrimSpace(criteria.Label)
+	if criteria.Label == "" || utf8.RuneCountInString(criteria.Label) > types.MaxShareLabelLength {
+		return nil, ErrInvalidShareCriteria
+	}
+	logger.Infof(ctx, "Creating dynamic share of label %q to organization %s", criteria.Label, orgID)
+
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if err != nil {
+		if errors.Is(err, repository.ErrOrganizationNotFound) {
+			return nil, ErrOrgNotFound
+		}
+		return nil, err
+	}
+
+	// A rule keeps sharing knowledge bases as they are labeled, so it takes an admin of the org
+	member, err := s.orgRepo.GetMember(ctx, orgID, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrOrgMemberNotFound) {
+			return nil, ErrUserNotInOrg
+		}
+		return nil, err
+	}
+	if member.Role != types.OrgRoleAdmin {
+		return nil, ErrDynamicShareAdminOnly
+	}
+
+	permission, err = resolveSharePermission(org, member.Role, permission)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkOrgStorageQuota(ctx, orgID); err != nil {
+		return nil, err
+	}
+	// The rule changes what counts towards the organization's storage
+	defer s.storageUsage.Delete(orgID)
+
+	rule := &types.KnowledgeBaseDynamicShare{
+		ID:              uuid.New().String(),
+		OrganizationID:  orgID,
+		CreatedByUserID: userID,
+		SourceTenantID:  tenantID,
+		Criteria:        criteria,
+		Permission:      permission,
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+	}
+	if err := s.shareRepo.CreateDynamic(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	logger.Infof(ctx, "Dynamic share %s created for organization %s", rule.ID, orgID)
+	return rule, nil
+}
+
+// ListDynamicShares lists the dynamic share rules of an organization
+func (s *kbShareService) ListDynamicShares(ctx context.Context, orgID string) ([]*types.KnowledgeBaseDynamicShare, error) {
+	return s.shareRepo.ListDynamicByOrganization(ctx, orgID)
+}
+
+// RemoveDynamicShare removes a dynamic share rule; knowledge bases it matched stop being shared
+// unless they are also shared statically or by another rule.
+// Allowed if: (1) current user created the rule, or (2) current user is admin of the target organization.
+func (s *kbShareService) RemoveDynamicShare(ctx context.Context, orgID string, ruleID string, userID string) error {
+	rule, err := s.shareRepo.GetDynamicByID(ctx, ruleID)
+	if err != nil {
+		if errors.Is(err, repository.ErrKBDynamicShareNotFound) {
+			return ErrDynamicShareNotFound
+		}
+		return err
+	}
+	if rule.OrganizationID != orgID {
+		return ErrDynamicShareNotFound
+	}
+
+	if rule.CreatedByUserID != userID {
+		member, err := s.orgRepo.GetMember(ctx, rule.OrganizationID, userID)
+		if err != nil || member.Role != types.OrgRoleAdmin {
+			return ErrSharePermissionDenied
+		}
+	}
+	// The rule no longer counts towards the organization's storage
+	defer s.storageUsage.Delete(rule.OrganizationID)
+	return s.shareRepo.DeleteDynamic(ctx, ruleID)
+}
+
+// appendDynamicShares resolves the knowledge bases matched by the rules and appends them as shares.
+// A static share of the same KB to the same org takes precedence; among rules the highest permission wins.
+func (s *kbShareService) appendDynamicShares(
+	ctx context.Context, shares []*types.KnowledgeBaseShare, rules []*types.KnowledgeBaseDynamicShare,
+) []*types.KnowledgeBaseShare {
+	if len(rules) == 0 {
+		return shares
+	}
+	shareKey := func(kbID, orgID string) string { return orgID + "/" + kbID }
+
+	static := make(map[string]bool, len(shares))
+	for _, share := range shares {
+		static[shareKey(share.KnowledgeBaseID, share.OrganizationID)] = true
+	}
+
+	dynamic := make(map[string]*types.KnowledgeBaseShare)
+	var order []string
+	for _, rule := range rules {
+		kbs, err := s.shareRepo.ListKnowledgeBasesMatchingDynamic(ctx, rule)
+		if err != nil {
+			logger.Warnf(ctx, "Failed to resolve dynamic share %s: %v", rule.ID, err)
+			continue
+		}
+		for _, kb := range kbs {
+			key := shareKey(kb.ID, rule.OrganizationID)
+			if static[key] {
+				continue
+			}
+			existing, ok := dynamic[key]
+			if !ok {
+				order = append(order, key)
+			} else if !rule.Permission.HasPermission(existing.Permission) || rule.Permission == existing.Permission {
+				continue
+			}
+			dynamic[key] = dynamicShareFor(rule, kb)
+		}
+	}
+
+	for _, key := range order {
+		shares = append(shares, dynamic[key])
+	}
+	return shares
+}
+
+// dynamicShareFor builds the share record of a knowledge base matched by a dynamic rule.
+// The share ID is the rule ID, so it cannot be updated or removed through the static share endpoints.
+func dynamicShareFor(rule *types.KnowledgeBaseDynamicShare, kb *types.KnowledgeBase) *types.KnowledgeBaseShare {
+	share := &types.KnowledgeBaseShare{
+		ID:             rule.ID,
+		OrganizationID: rule.OrganizationID,
+		SharedByUserID: rule.CreatedByUserID,
+		SourceTenantID: rule.SourceTenantID,
+		Permission:     rule.Permission,
+		CreatedAt:      rule.CreatedAt,
+		UpdatedAt:      rule.UpdatedAt,
+		Organization:   rule.Organization,
+		DynamicShareID: rule.ID,
+	}
+	if kb != nil {
+		share.KnowledgeBaseID = kb.ID
+		share.KnowledgeBase = kb
+	}
+	return share
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/utils"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+)
+
+var (
+	ErrCopyTargetUserNotFound = errors.New("target user not found")
+	ErrCannotCopyToSelf       = errors.New("cannot copy a knowledge base to yourself")
+	ErrCopyTargetNotConnected = errors.New("target user is not a member of any organization you belong to")
+	ErrCopyModelUnavailable   = errors.New("the embedding model of the knowledge base is not available to the target user")
+)
+
+// CopyKBToUser gives the target user an independent copy of a knowledge base: an empty KB is created
+// in the target's tenant and filled by the regular KB clone task. Only the owner tenant can copy, and the
+// target has to be a member of an organization the caller belongs to.
+func (s *kbShareService) CopyKBToUser(ctx context.Context, kbID string, targetUserID string) (*types.KBCloneProgress, error) {
+	tenantID := types.MustTenantIDFromContext(ctx)
+	userID, _ := ctx.Value(types.UserIDContextKey).(string)
+
+	kb, err := s.kbRepo.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil || kb.IsTemporary {
+		return nil, ErrKBNotFound
+	}
+	if kb.TenantID != tenantID {
+		return nil, ErrNotKBOwner
+	}
+	if targetUserID == userID {
+		return nil, ErrCannotCopyToSelf
+	}
+	targetUser, err := s.userRepo.GetUserByID(ctx, targetUserID)
+	if err != nil || targetUser == nil {
+		return nil, ErrCopyTargetUserNotFound
+	}
+	if err := s.checkSharedOrganization(ctx, userID, targetUserID); err != nil {
+		return nil, err
+	}
+	if err := s.checkCopyStorageQuota(ctx, kb, targetUser.TenantID); err != nil {
+		return nil, err
+	}
+
+	targetKB, err := s.newKBCopyForTenant(ctx, kb, targetUser.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.kbRepo.CreateKnowledgeBase(ctx, targetKB); err != nil {
+		return nil, fmt.Errorf("failed to create target knowledge base: %w", err)
+	}
+
+	payload := types.KBClonePayload{
+		TenantID:       targetUser.TenantID,
+		SourceTenantID: tenantID,
+		TaskID:         utils.GenerateTaskID("kb_clone", targetUser.TenantID, kb.ID),
+		SourceID:       kb.ID,
+		TargetID:       targetKB.ID,
+	}
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal KB clone payload: %w", err)
+	}
+	task := asynq.NewTask(types.TypeKBClone, payloadBytes,
+		asynq.TaskID(payload.TaskID), asynq.Queue("default"), asynq.MaxRetry(3))
+	if _, err := s.task.Enqueue(task); err != nil {
+		// The empty copy is useless without the clone task
+		if delErr := s.kbRepo.DeleteKnowledgeBase(ctx, targetKB.ID); delErr != nil {
+			logger.Warnf(ctx, "Failed to delete copy %s after enqueue failure: %v", targetKB.ID, delErr)
+		}
+		return nil, fmt.Errorf("failed to enqueue KB clone task: %w", err)
+	}
+
+	logger.Infof(ctx, "Copying knowledge base %s to user %s (tenant %d) as %s, task: %s",
+		kb.ID, targetUserID, targetUser.TenantID, targetKB.ID, payload.TaskID)
+
+	now := time.Now().Unix()
+	return &types.KBCloneProgress{
+		TaskID:    payload.TaskID,
+		SourceID:  payload.SourceID,
+		TargetID:  payload.TargetID,
+		Status:    types.KBCloneStatusPending,
+		Message:   "Task queued, waiting to start...",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}, nil
+}
+
+// checkSharedOrganization returns ErrCopyTargetNotConnected unless both users are members of a common organization
+func (s *kbShareService) checkSharedOrganization(ctx context.Context, userID string, targetUserID string) error {
+	orgs, err := s.orgRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if len(orgs) == 0 {
+		return ErrCopyTargetNotConnected
+	}
+	orgIDs := make([]string, 0, len(orgs))
+	for _, org := range orgs {
+		orgIDs = append(orgIDs, org.ID)
+	}
+	members, err := s.orgRepo.ListMembersByUserForOrgs(ctx, targetUserID, orgIDs)
+	if err != nil {
+		return err
+	}
+	if len(members) == 0 {
+		return ErrCopyTargetNotConnected
+	}
+	return nil
+}
+
+// checkCopyStorageQuota returns a StorageQuotaExceededError if the knowledge base does not fit in the
+// remaining storage quota of the target tenant
+func (s *kbShareService) checkCopyStorageQuota(ctx context.Context, kb *types.KnowledgeBase, tenantID uint64) error {
+	tenant, err := s.tenantRepo.GetTenantByID(ctx, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to get target tenant: %w", err)
+	}
+	if tenant.StorageQuota <= 0 {
+		return nil
+	}
+	size, err := s.kgRepo.SumKnowledgeSize(ctx, []string{kb.ID}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get knowledge base size: %w", err)
+	}
+	if tenant.StorageUsed+size > tenant.StorageQuota {
+		logger.Warnf(ctx, "Copy of knowledge base %s (%d bytes) exceeds the storage quota of tenant %d: %d/%d bytes",
+			kb.ID, size, tenantID, tenant.StorageUsed, tenant.StorageQuota)
+		return types.NewCopyStorageQuotaExceededError()
+	}
+	return nil
+}
+
+// newKBCopyForTenant builds the target KB of a copy into tenantID. Vectors are copied as they are, so the
+// embedding model must be usable by the target tenant; other models are dropped when they are not.
+// Legacy storage credentials belong to the source tenant and are never copied across tenants.
+func (s *kbShareService) newKBCopyForTenant(
+	ctx context.Context, kb *types.KnowledgeBase, tenantID uint64,
+) (*types.KnowledgeBase, error) {
+	modelAvailable := func(id string) bool {
+		if id == "" {
+			return false
+		}
+		model, err := s.modelRepo.GetByID(ctx, tenantID, id)
+		return err == nil && model != nil
+	}
+	if !modelAvailable(kb.EmbeddingModelID) {
+		return nil, ErrCopyModelUnavailable
+	}
+
+	var faqConfig *types.FAQConfig
+	if kb.FAQConfig != nil {
+		cfg := *kb.FAQConfig
+		faqConfig = &cfg
+	}
+	target := &types.KnowledgeBase{
+		ID:                    uuid.New().String(),
+		Name:                  kb.Name,
+		Type:                  kb.Type,
+		Description:           kb.Description,
+		TenantID:              tenantID,
+		ChunkingConfig:        kb.ChunkingConfig,
+		ImageProcessingConfig: kb.ImageProcessingConfig,
+		EmbeddingModelID:      kb.EmbeddingModelID,
+		VLMConfig:             kb.VLMConfig,
+		StorageProviderConfig: kb.StorageProviderConfig,
+		FAQConfig:             faqConfig,
+		DuplicateScope:        kb.DuplicateScope,
+		CreatedAt:             time.Now(),
+		UpdatedAt:             time.Now(),
+	}
+	if modelAvailable(kb.SummaryModelID) {
+		target.SummaryModelID = kb.SummaryModelID
+	}
+	if !modelAvailable(target.VLMConfig.ModelID) {
+		// Also drops legacy inline VLM endpoints, which may carry the source tenant's credentials
+		target.VLMConfig = types.VLMConfig{}
+	}
+	target.EnsureDefaults()
+	return target, nil
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// orgStorageUsageTTL is how long a computed organization storage usage is reused
+const orgStorageUsageTTL = 5 * time.Minute
+
+// GetOrgStorageUsage returns the bytes used by the knowledge bases shared to an organization.
+// The usage is summed from the knowledge records and cached for orgStorageUsageTTL, so object
+// storage is never scanned.
+func (s *kbShareService) GetOrgStorageUsage(ctx context.Context, orgID string) (*types.OrgStorageUsage, error) {
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if err != nil {
+		if errors.Is(err, repository.ErrOrganizationNotFound) {
+			return nil, ErrOrgNotFound
+		}
+		return nil, err
+	}
+
+	usage, ok := s.cachedOrgStorageUsage(orgID)
+	if !ok {
+		kbIDs, knowledgeIDs, kbCount, err := s.orgSharedContent(ctx, orgID)
+		if err != nil {
+			return nil, err
+		}
+		used, err := s.kgRepo.SumKnowledgeSize(ctx, kbIDs, knowledgeIDs)
+		if err != nil {
+			return nil, err
+		}
+		usage = types.OrgStorageUsage{
+			OrganizationID:     orgID,
+			UsedBytes:          used,
+			KnowledgeBaseCount: kbCount,
+			ComputedAt:         time.Now(),
+		}
+		s.storageUsage.Store(orgID, usage)
+	}
+
+	// The quota is always read fresh so changing it takes effect immediately
+	usage.QuotaBytes = org.StorageQuota
+	usage.Exceeded = org.StorageQuota > 0 && usage.UsedBytes >= org.StorageQuota
+	return &usage, nil
+}
+
+// orgSharedContent returns the knowledge bases shared to an organization as a whole, the documents
+// of the knowledge bases only partially shared to it, and the number of distinct knowledge bases.
+// Static shares and dynamic rules are merged as for access: a knowledge base matched by a rule or
+// fully shared counts as a whole, otherwise only the documents of its partial shares count.
+func (s *kbShareService) orgSharedContent(ctx context.Context,
+	orgID string,
+) (kbIDs []string, knowledgeIDs []string, kbCount int, err error) {
+	shares, err := s.shareRepo.ListByOrganization(ctx, orgID)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	rules, err := s.shareRepo.ListDynamicByOrganization(ctx, orgID)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	whole := make(map[string]bool)
+	partial := make(map[string][]string)
+	for _, share := range shares {
+		if share.IsPartial() {
+			partial[share.KnowledgeBaseID] = append(partial[share.KnowledgeBaseID], share.KnowledgeIDs...)
+		} else {
+			whole[share.KnowledgeBaseID] = true
+		}
+	}
+	for _, rule := range rules {
+		kbs, err := s.shareRepo.ListKnowledgeBasesMatchingDynamic(ctx, rule)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		for _, kb := range kbs {
+			whole[kb.ID] = true
+		}
+	}
+
+	for kbID := range whole {
+		kbIDs = append(kbIDs, kbID)
+	}
+	seen := make(map[string]bool)
+	for kbID, ids := range partial {
+		if whole[kbID] {
+			continue
+		}
+		kbCount++
+		for _, id := range ids {
+			if !seen[id] {
+				seen[id] = true
+				knowledgeIDs = append(knowledgeIDs, id)
+			}
+		}
+	}
+	return kbIDs, knowledgeIDs, kbCount + len(kbIDs), nil
+}
+
+// cachedOrgStorageUsage returns the cached usage of an organization if it has not expired
+func (s *kbShareService) cachedOrgStorageUsage(orgID string) (types.OrgStorageUsage, bool) {
+	cached, ok := s.storageUsage.Load(orgID)
+	if !ok {
+		return types.OrgStorageUsage{}, false
+	}
+	usage := cached.(types.OrgStorageUsage)
+	return usage, time.Since(usage.ComputedAt) < orgStorageUsageTTL
+}
+
+// CheckOrgStorageQuota returns a StorageQuotaExceededError if a knowledge base is fully shared to
+// an organization, statically or through a dynamic rule, whose storage quota is used up, so nothing
+// more can be uploaded to it
+func (s *kbShareService) CheckOrgStorageQuota(ctx context.Context, kbID string) error {
+	shares, err := s.shareRepo.ListByKnowledgeBase(ctx, kbID)
+	if err != nil {
+		return err
+	}
+	rules, err := s.shareRepo.ListDynamicMatchingKnowledgeBase(ctx, kbID)
+	if err != nil {
+		return err
+	}
+
+	var orgIDs []string
+	for _, share := range shares {
+		// New documents are not part of a partial share
+		if !share.IsPartial() {
+			orgIDs = append(orgIDs, share.OrganizationID)
+		}
+	}
+	for _, rule := range rules {
+		orgIDs = append(orgIDs, rule.OrganizationID)
+	}
+	checked := make(map[string]bool, len(orgIDs))
+	for _, orgID := range orgIDs {
+		if checked[orgID] {
+			continue
+		}
+		checked[orgID] = true
+		if err := s.checkOrgStorageQuota(ctx, orgID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkOrgStorageQuota returns a StorageQuotaExceededError if the organization's storage quota is used up
+func (s *kbShareService) checkOrgStorageQuota(ctx context.Context, orgID string) error {
+	usage, err := s.GetOrgStorageUsage(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	if !usage.Exceeded {
+		return nil
+	}
+	logger.Warnf(ctx, "Storage quota of organization %s exceeded: %d/%d bytes", orgID, usage.UsedBytes, usage.QuotaBytes)
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	return types.NewOrgStorageQuotaExceededError(org.Name)
+}
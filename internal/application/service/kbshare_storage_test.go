@@ -0,0 +1,314 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// orgShareRepo serves static shares and dynamic rules, with the knowledge bases each rule matches
+type orgShareRepo struct {
+	interfaces.KBShareRepository
+	shares  []*types.KnowledgeBaseShare
+	rules   []*types.KnowledgeBaseDynamicShare
+	matches map[string][]string // rule ID -> matched knowledge base IDs
+}
+
+func (r *orgShareRepo) ListByOrganization(ctx context.Context, orgID string) ([]*types.KnowledgeBaseShare, error) {
+	var shares []*types.KnowledgeBaseShare
+	for _, share := range r.shares {
+		if share.OrganizationID == orgID {
+			shares = append(shares, share)
+		}
+	}
+	return shares, nil
+}
+
+func (r *orgShareRepo) ListByKnowledgeBase(ctx context.Context, kbID string) ([]*types.KnowledgeBaseShare, error) {
+	var shares []*types.KnowledgeBaseShare
+	for _, share := range r.shares {
+		if share.KnowledgeBaseID == kbID {
+			shares = append(shares, share)
+		}
+	}
+	return shares, nil
+}
+
+func (r *orgShareRepo) ListDynamicByOrganization(ctx context.Context,
+	orgID string,
+) ([]*types.KnowledgeBaseDynamicShare, error) {
+	var rules []*types.KnowledgeBaseDynamicShare
+	for _, rule := range r.rules {
+		if rule.OrganizationID == orgID {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+func (r *orgShareRepo) ListDynamicMatchingKnowledgeBase(ctx context.Context,
+	kbID string,
+) ([]*types.KnowledgeBaseDynamicShare, error) {
+	var rules []*types.KnowledgeBaseDynamicShare
+	for _, rule := range r.rules {
+		if slices.Contains(r.matches[rule.ID], kbID) {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+func (r *orgShareRepo) ListKnowledgeBasesMatchingDynamic(ctx context.Context,
+	rule *types.KnowledgeBaseDynamicShare,
+) ([]*types.KnowledgeBase, error) {
+	var kbs []*types.KnowledgeBase
+	for _, kbID := range r.matches[rule.ID] {
+		kbs = append(kbs, &types.KnowledgeBase{ID: kbID})
+	}
+	return kbs, nil
+}
+
+// quotaOrgRepo serves organizations with their storage quota
+type quotaOrgRepo struct {
+	interfaces.OrganizationRepository
+	quotas map[string]int64
+}
+
+func (r *quotaOrgRepo) GetByID(ctx context.Context, id string) (*types.Organization, error) {
+	return &types.Organization{ID: id, Name: id, StorageQuota: r.quotas[id]}, nil
+}
+
+// sizedKnowledgeRepo sums the sizes of a fixed set of knowledge
+type sizedKnowledgeRepo struct {
+	interfaces.KnowledgeRepository
+	knowledge []*types.Knowledge
+}
+
+func (r *sizedKnowledgeRepo) SumKnowledgeSize(ctx context.Context,
+	kbIDs []string, knowledgeIDs []string,
+) (int64, error) {
+	var total int64
+	for _, k := range r.knowledge {
+		if slices.Contains(kbIDs, k.KnowledgeBaseID) || slices.Contains(knowledgeIDs, k.ID) {
+			total += k.FileSize + k.StorageSize
+		}
+	}
+	return total, nil
+}
+
+// storageKnowledge is kb-1 (k1, k2) and kb-2 (k3, k4), 100 bytes per document
+var storageKnowledge = []*types.Knowledge{
+	{ID: "k1", KnowledgeBaseID: "kb-1", FileSize: 60, StorageSize: 40},
+	{ID: "k2", KnowledgeBaseID: "kb-1", FileSize: 60, StorageSize: 40},
+	{ID: "k3", KnowledgeBaseID: "kb-2", FileSize: 60, StorageSize: 40},
+	{ID: "k4", KnowledgeBaseID: "kb-2", FileSize: 60, StorageSize: 40},
+}
+
+func storageShare(kbID, orgID string, knowledgeIDs ...string) *types.KnowledgeBaseShare {
+	return &types.KnowledgeBaseShare{
+		ID: kbID + "-" + orgID, KnowledgeBaseID: kbID, OrganizationID: orgID, KnowledgeIDs: knowledgeIDs,
+	}
+}
+
+func TestGetOrgStorageUsage(t *testing.T) {
+	tests := []struct {
+		name      string
+		shares    []*types.KnowledgeBaseShare
+		rules     []*types.KnowledgeBaseDynamicShare
+		matches   map[string][]string
+		wantBytes int64
+		wantKBs   int
+	}{
+		{
+			name:      "static shares",
+			shares:    []*types.KnowledgeBaseShare{storageShare("kb-1", "org-1"), storageShare("kb-2", "org-1", "k3")},
+			wantBytes: 300,
+			wantKBs:   2,
+		},
+		{
+			name:      "dynamic rule",
+			rules:     []*types.KnowledgeBaseDynamicShare{{ID: "rule-1", OrganizationID: "org-1"}},
+			matches:   map[string][]string{"rule-1": {"kb-1", "kb-2"}},
+			wantBytes: 400,
+			wantKBs:   2,
+		},
+		{
+			name:      "knowledge base covered by a static share and a rule is counted once",
+			shares:    []*types.KnowledgeBaseShare{storageShare("kb-1", "org-1")},
+			rules:     []*types.KnowledgeBaseDynamicShare{{ID: "rule-1", OrganizationID: "org-1"}},
+			matches:   map[string][]string{"rule-1": {"kb-1"}},
+			wantBytes: 200,
+			wantKBs:   1,
+		},
+		{
+			name:      "rule widens a partial share to the whole knowledge base",
+			shares:    []*types.KnowledgeBaseShare{storageShare("kb-2", "org-1", "k3")},
+			rules:     []*types.KnowledgeBaseDynamicShare{{ID: "rule-1", OrganizationID: "org-1"}},
+			matches:   map[string][]string{"rule-1": {"kb-2"}},
+			wantBytes: 200,
+			wantKBs:   1,
+		},
+		{
+			name: "other organizations are ignored",
+			shares: []*types.KnowledgeBaseShare{
+				storageShare("kb-1", "org-1", "k1"), storageShare("kb-2", "org-2"),
+			},
+			rules:     []*types.KnowledgeBaseDynamicShare{{ID: "rule-2", OrganizationID: "org-2"}},
+			matches:   map[string][]string{"rule-2": {"kb-1"}},
+			wantBytes: 100,
+			wantKBs:   1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &kbShareService{
+				shareRepo: &orgShareRepo{shares: tt.shares, rules: tt.rules, matches: tt.matches},
+				orgRepo:   &quotaOrgRepo{},
+				kgRepo:    &sizedKnowledgeRepo{knowledge: storageKnowledge},
+			}
+			usage, err := s.GetOrgStorageUsage(context.Background(), "org-1")
+			if err != nil {
+				t.Fatalf("GetOrgStorageUsage() error = %v", err)
+			}
+			if usage.UsedBytes != tt.wantBytes || usage.KnowledgeBaseCount != tt.wantKBs {
+				t.Errorf("usage = %d bytes in %d knowledge bases, want %d bytes in %d",
+					usage.UsedBytes, usage.KnowledgeBaseCount, tt.wantBytes, tt.wantKBs)
+			}
+		})
+	}
+}
+
+func TestCheckOrgStorageQuota(t *testing.T) {
+	tests := []struct {
+		name     string
+		shares   []*types.KnowledgeBaseShare
+		rules    []*types.KnowledgeBaseDynamicShare
+		matches  map[string][]string
+		quota    int64
+		kbID     string
+		wantFull bool
+	}{
+		{
+			name:     "whole share to a full organization",
+			shares:   []*types.KnowledgeBaseShare{storageShare("kb-1", "org-1")},
+			quota:    200,
+			kbID:     "kb-1",
+			wantFull: true,
+		},
+		{
+			name:   "whole share within quota",
+			shares: []*types.KnowledgeBaseShare{storageShare("kb-1", "org-1")},
+			quota:  201,
+			kbID:   "kb-1",
+		},
+		{
+			name:   "partial share does not take new documents",
+			shares: []*types.KnowledgeBaseShare{storageShare("kb-1", "org-1", "k1")},
+			quota:  100,
+			kbID:   "kb-1",
+		},
+		{
+			name:     "rule share to a full organization",
+			rules:    []*types.KnowledgeBaseDynamicShare{{ID: "rule-1", OrganizationID: "org-1"}},
+			matches:  map[string][]string{"rule-1": {"kb-1", "kb-2"}},
+			quota:    300,
+			kbID:     "kb-2",
+			wantFull: true,
+		},
+		{
+			name:    "knowledge base not shared to the full organization",
+			rules:   []*types.KnowledgeBaseDynamicShare{{ID: "rule-1", OrganizationID: "org-1"}},
+			matches: map[string][]string{"rule-1": {"kb-1"}},
+			quota:   100,
+			kbID:    "kb-2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &kbShareService{
+				shareRepo: &orgShareRepo{shares: tt.shares, rules: tt.rules, matches: tt.matches},
+				orgRepo:   &quotaOrgRepo{quotas: map[string]int64{"org-1": tt.quota}},
+				kgRepo:    &sizedKnowledgeRepo{knowledge: storageKnowledge},
+			}
+			err := s.CheckOrgStorageQuota(context.Background(), tt.kbID)
+			var quotaErr *types.StorageQuotaExceededError
+			if full := errors.As(err, &quotaErr); full != tt.wantFull {
+				t.Errorf("CheckOrgStorageQuota() error = %v, want quota exceeded %v", err, tt.wantFull)
+			}
+			if err != nil && quotaErr == nil {
+				t.Errorf("CheckOrgStorageQuota() unexpected error = %v", err)
+			}
+		})
+	}
+}
+
+// copyUserRepo serves the users a knowledge base can be copied to
+type copyUserRepo struct {
+	interfaces.UserRepository
+	users map[string]*types.User
+}
+
+func (r *copyUserRepo) GetUserByID(ctx context.Context, id string) (*types.User, error) {
+	return r.users[id], nil
+}
+
+func TestCheckCopyStorageQuota(t *testing.T) {
+	tests := []struct {
+		name     string
+		quota    int64
+		used     int64
+		wantFull bool
+	}{
+		{name: "no quota", quota: 0, used: 1000},
+		{name: "fits exactly", quota: 300, used: 100},
+		{name: "does not fit", quota: 299, used: 100, wantFull: true},
+		{name: "quota used up", quota: 100, used: 100, wantFull: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &kbShareService{
+				kgRepo: &sizedKnowledgeRepo{knowledge: storageKnowledge},
+				tenantRepo: &storageTenantRepo{tenants: map[uint64]*types.Tenant{
+					2: {ID: 2, StorageQuota: tt.quota, StorageUsed: tt.used},
+				}},
+			}
+			err := s.checkCopyStorageQuota(context.Background(), &types.KnowledgeBase{ID: "kb-1"}, 2)
+			var quotaErr *types.StorageQuotaExceededError
+			if full := errors.As(err, &quotaErr); full != tt.wantFull {
+				t.Errorf("checkCopyStorageQuota() error = %v, want quota exceeded %v", err, tt.wantFull)
+			}
+			if err != nil && quotaErr == nil {
+				t.Errorf("checkCopyStorageQuota() unexpected error = %v", err)
+			}
+		})
+	}
+}
+
+func TestCopyKBToUserRejectsOverQuota(t *testing.T) {
+	ctx := context.WithValue(context.Background(), types.TenantIDContextKey, uint64(7))
+	ctx = context.WithValue(ctx, types.UserIDContextKey, "owner")
+	s := &kbShareService{
+		// No knowledge base may be created: the embedded nil repository panics on CreateKnowledgeBase
+		kbRepo: &statsKBRepo{},
+		kgRepo: &sizedKnowledgeRepo{knowledge: storageKnowledge},
+		orgRepo: newOrgTestRepo(t,
+			&types.Organization{ID: "org-1", Name: "team", OwnerID: "owner"},
+			&types.OrganizationMember{ID: "m-1", OrganizationID: "org-1", UserID: "owner", TenantID: 7, Role: types.OrgRoleAdmin},
+			&types.OrganizationMember{ID: "m-2", OrganizationID: "org-1", UserID: "target", TenantID: 2, Role: types.OrgRoleViewer},
+		),
+		userRepo: &copyUserRepo{users: map[string]*types.User{"target": {ID: "target", TenantID: 2}}},
+		tenantRepo: &storageTenantRepo{tenants: map[uint64]*types.Tenant{
+			2: {ID: 2, StorageQuota: 250, StorageUsed: 100},
+		}},
+	}
+
+	_, err := s.CopyKBToUser(ctx, "kb-1", "target")
+	var quotaErr *types.StorageQuotaExceededError
+	if !errors.As(err, &quotaErr) {
+		t.Errorf("CopyKBToUser() error = %v, want a storage quota exceeded error", err)
+	}
+}
@@ -0,0 +1,284 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// staticShareRepo serves fixed static shares and dynamic rules for every knowledge base
+type staticShareRepo struct {
+	interfaces.KBShareRepository
+	shares []*types.KnowledgeBaseShare
+	rules  []*types.KnowledgeBaseDynamicShare
+}
+
+func (r *staticShareRepo) ListByKnowledgeBase(ctx context.Context, kbID string) ([]*types.KnowledgeBaseShare, error) {
+	return slices.Clone(r.shares), nil
+}
+
+func (r *staticShareRepo) ListDynamicMatchingKnowledgeBase(ctx context.Context,
+	kbID string,
+) ([]*types.KnowledgeBaseDynamicShare, error) {
+	return r.rules, nil
+}
+
+func (r *staticShareRepo) GetByID(ctx context.Context, id string) (*types.KnowledgeBaseShare, error) {
+	for _, share := range r.shares {
+		if share.ID == id {
+			return share, nil
+		}
+	}
+	return nil, repository.ErrKBShareNotFound
+}
+
+// memberOrgRepo reports the user's role per organization ID
+type memberOrgRepo struct {
+	interfaces.OrganizationRepository
+	roles map[string]types.OrgMemberRole
+}
+
+func (r *memberOrgRepo) GetMember(ctx context.Context, orgID string, userID string) (*types.OrganizationMember, error) {
+	role, ok := r.roles[orgID]
+	if !ok {
+		return nil, repository.ErrOrgMemberNotFound
+	}
+	return &types.OrganizationMember{OrganizationID: orgID, UserID: userID, Role: role}, nil
+}
+
+// batchKnowledgeRepo serves knowledge by ID
+type batchKnowledgeRepo struct {
+	interfaces.KnowledgeRepository
+	knowledge map[string]*types.Knowledge
+}
+
+func (r *batchKnowledgeRepo) GetKnowledgeBatch(ctx context.Context,
+	tenantID uint64, ids []string,
+) ([]*types.Knowledge, error) {
+	var result []*types.Knowledge
+	for _, id := range ids {
+		if k, ok := r.knowledge[id]; ok {
+			result = append(result, k)
+		}
+	}
+	return result, nil
+}
+
+func partialShare(orgID string, knowledgeIDs ...string) *types.KnowledgeBaseShare {
+	return &types.KnowledgeBaseShare{
+		ID: "share-" + orgID, OrganizationID: orgID, Permission: types.OrgRoleViewer, KnowledgeIDs: knowledgeIDs,
+	}
+}
+
+func wholeShare(orgID string, permission types.OrgMemberRole) *types.KnowledgeBaseShare {
+	return &types.KnowledgeBaseShare{ID: "share-" + orgID, OrganizationID: orgID, Permission: permission}
+}
+
+func TestKBShareServiceKnowledgeAccess(t *testing.T) {
+	roles := map[string]types.OrgMemberRole{
+		"org-a": types.OrgRoleViewer, "org-b": types.OrgRoleEditor, "org-c": types.OrgRoleAdmin,
+	}
+	tests := []struct {
+		name           string
+		shares         []*types.KnowledgeBaseShare
+		rules          []*types.KnowledgeBaseDynamicShare
+		wantIDs        []string // nil when the whole KB is accessible
+		wantPermission types.OrgMemberRole
+		readable       []string
+		unreadable     []string
+		editable       []string
+	}{
+		{
+			name:           "union of partial shares",
+			shares:         []*types.KnowledgeBaseShare{partialShare("org-a", "k1", "k2"), partialShare("org-b", "k2", "k3")},
+			wantIDs:        []string{"k1", "k2", "k3"},
+			wantPermission: types.OrgRoleViewer,
+			readable:       []string{"k1", "k2", "k3"},
+			unreadable:     []string{"k4"},
+		},
+		{
+			name:           "whole share overrides partial share",
+			shares:         []*types.KnowledgeBaseShare{partialShare("org-a", "k1"), wholeShare("org-b", types.OrgRoleEditor)},
+			wantPermission: types.OrgRoleEditor,
+			readable:       []string{"k1", "k4"},
+			editable:       []string{"k1", "k4"},
+		},
+		{
+			name:   "static partial share is merged with dynamic rule of the same organization",
+			shares: []*types.KnowledgeBaseShare{partialShare("org-c", "k1")},
+			rules: []*types.KnowledgeBaseDynamicShare{
+				{ID: "rule-c", OrganizationID: "org-c", Permission: types.OrgRoleAdmin},
+			},
+			wantPermission: types.OrgRoleAdmin,
+			readable:       []string{"k1", "k2"},
+			editable:       []string{"k1", "k2"},
+		},
+		{
+			name:   "static whole share keeps the higher permission of a dynamic rule",
+			shares: []*types.KnowledgeBaseShare{wholeShare("org-c", types.OrgRoleViewer)},
+			rules: []*types.KnowledgeBaseDynamicShare{
+				{ID: "rule-c", OrganizationID: "org-c", Permission: types.OrgRoleEditor},
+			},
+			wantPermission: types.OrgRoleEditor,
+			readable:       []string{"k1"},
+			editable:       []string{"k1"},
+		},
+		{
+			name:   "dynamic rule of another organization applies",
+			shares: []*types.KnowledgeBaseShare{partialShare("org-a", "k1")},
+			rules: []*types.KnowledgeBaseDynamicShare{
+				{ID: "rule-b", OrganizationID: "org-b", Permission: types.OrgRoleEditor},
+			},
+			wantPermission: types.OrgRoleEditor,
+			readable:       []string{"k1", "k2"},
+			editable:       []string{"k2"},
+		},
+		{
+			name:           "share of a foreign organization is ignored",
+			shares:         []*types.KnowledgeBaseShare{partialShare("org-a", "k1"), wholeShare("org-x", types.OrgRoleAdmin)},
+			wantIDs:        []string{"k1"},
+			wantPermission: types.OrgRoleViewer,
+			readable:       []string{"k1"},
+			unreadable:     []string{"k2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := &kbShareService{
+				shareRepo: &staticShareRepo{shares: tt.shares, rules: tt.rules},
+				orgRepo:   &memberOrgRepo{roles: roles},
+			}
+
+			ids, restricted, err := s.GetSharedKnowledgeIDs(ctx, "kb-1", "user-1")
+			if err != nil {
+				t.Fatalf("GetSharedKnowledgeIDs() error = %v", err)
+			}
+			if restricted != (tt.wantIDs != nil) || !slices.Equal(ids, tt.wantIDs) {
+				t.Errorf("GetSharedKnowledgeIDs() = %v, %v, want %v", ids, restricted, tt.wantIDs)
+			}
+			permission, shared, err := s.CheckUserKBPermission(ctx, "kb-1", "user-1")
+			if err != nil || !shared || permission != tt.wantPermission {
+				t.Errorf("CheckUserKBPermission() = %q, %v, %v, want %q", permission, shared, err, tt.wantPermission)
+			}
+
+			check := func(knowledgeIDs []string, role types.OrgMemberRole, want bool) {
+				t.Helper()
+				for _, id := range knowledgeIDs {
+					got, err := s.HasKnowledgePermission(ctx, "kb-1", id, "user-1", role)
+					if err != nil || got != want {
+						t.Errorf("HasKnowledgePermission(%s, %s) = %v, %v, want %v", id, role, got, err, want)
+					}
+				}
+			}
+			check(tt.readable, types.OrgRoleViewer, true)
+			check(tt.unreadable, types.OrgRoleViewer, false)
+			check(tt.editable, types.OrgRoleEditor, true)
+			if tt.wantIDs != nil {
+				// Partial shares are read-only
+				check(tt.readable, types.OrgRoleEditor, false)
+			}
+		})
+	}
+}
+
+func TestKBShareServiceValidateShareKnowledgeIDs(t *testing.T) {
+	s := &kbShareService{kgRepo: &batchKnowledgeRepo{knowledge: map[string]*types.Knowledge{
+		"k1": {ID: "k1", KnowledgeBaseID: "kb-1"},
+		"k2": {ID: "k2", KnowledgeBaseID: "kb-1"},
+		"k9": {ID: "k9", KnowledgeBaseID: "kb-2"},
+	}}}
+	kb := &types.KnowledgeBase{ID: "kb-1", TenantID: 1, Type: types.KnowledgeBaseTypeDocument}
+	tests := []struct {
+		name       string
+		kb         *types.KnowledgeBase
+		permission types.OrgMemberRole
+		ids        []string
+		want       []string
+		wantErr    error
+	}{
+		{"whole knowledge base", kb, types.OrgRoleEditor, nil, nil, nil},
+		{"deduplicated", kb, types.OrgRoleViewer, []string{"k1", " k2", "k1", ""}, []string{"k1", "k2"}, nil},
+		{"knowledge of another knowledge base", kb, types.OrgRoleViewer, []string{"k1", "k9"}, nil, ErrInvalidShareKnowledge},
+		{"unknown knowledge", kb, types.OrgRoleViewer, []string{"k1", "k404"}, nil, ErrInvalidShareKnowledge},
+		{"editor permission", kb, types.OrgRoleEditor, []string{"k1"}, nil, ErrPartialShareReadOnly},
+		{
+			"faq knowledge base", &types.KnowledgeBase{ID: "kb-1", Type: types.KnowledgeBaseTypeFAQ},
+			types.OrgRoleViewer, []string{"k1"}, nil, ErrPartialShareUnsupported,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids, err := s.validateShareKnowledgeIDs(context.Background(), tt.kb, tt.permission, tt.ids)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("validateShareKnowledgeIDs() error = %v, want %v", err, tt.wantErr)
+			}
+			if !slices.Equal(ids, tt.want) {
+				t.Errorf("validateShareKnowledgeIDs() = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestKBShareServiceUpdatePartialSharePermission(t *testing.T) {
+	share := partialShare("org-c", "k1")
+	share.SharedByUserID = "user-1"
+	s := &kbShareService{
+		shareRepo: &staticShareRepo{shares: []*types.KnowledgeBaseShare{share}},
+		orgRepo:   &memberOrgRepo{roles: map[string]types.OrgMemberRole{"org-c": types.OrgRoleAdmin}},
+	}
+	err := s.UpdateSharePermission(context.Background(), share.ID, types.OrgRoleEditor, "user-1")
+	if !errors.Is(err, ErrPartialShareReadOnly) {
+		t.Errorf("UpdateSharePermission() error = %v, want %v", err, ErrPartialShareReadOnly)
+	}
+	if share.Permission != types.OrgRoleViewer {
+		t.Errorf("share permission = %q, want %q", share.Permission, types.OrgRoleViewer)
+	}
+}
+
+func TestKBShareServiceSuspendedMemberLosesAccess(t *testing.T) {
+	ctx := context.Background()
+	s := &kbShareService{
+		shareRepo: &staticShareRepo{
+			shares: []*types.KnowledgeBaseShare{wholeShare("org-1", types.OrgRoleEditor)},
+			rules:  []*types.KnowledgeBaseDynamicShare{{ID: "rule-1", OrganizationID: "org-1", Permission: types.OrgRoleEditor}},
+		},
+		orgRepo: newSuspensionOrgRepo(t),
+	}
+
+	permission, isShared, err := s.CheckUserKBPermission(ctx, "kb-1", "viewer")
+	if err != nil || !isShared || permission != types.OrgRoleViewer {
+		t.Fatalf("CheckUserKBPermission(viewer) = %q, %v, %v, want viewer access", permission, isShared, err)
+	}
+	permission, isShared, err = s.CheckUserKBPermission(ctx, "kb-1", "suspended")
+	if err != nil || isShared || permission != "" {
+		t.Fatalf("CheckUserKBPermission(suspended) = %q, %v, %v, want no access", permission, isShared, err)
+	}
+	ok, err := s.HasKnowledgePermission(ctx, "kb-1", "k1", "suspended", types.OrgRoleViewer)
+	if err != nil || ok {
+		t.Fatalf("HasKnowledgePermission(suspended) = %v, %v, want false", ok, err)
+	}
+}
+
+func TestKBShareServiceCreateDynamicShareAdminOnly(t *testing.T) {
+	ctx := context.Background()
+	orgRepo := newOrgTestRepo(t,
+		&types.Organization{ID: "org-1", Name: "team", OwnerID: "owner", InviteCode: "code"},
+		&types.OrganizationMember{ID: "m-1", OrganizationID: "org-1", UserID: "owner", TenantID: 1, Role: types.OrgRoleAdmin},
+		&types.OrganizationMember{ID: "m-2", OrganizationID: "org-1", UserID: "editor", TenantID: 2, Role: types.OrgRoleEditor},
+	)
+	s := &kbShareService{orgRepo: orgRepo}
+	criteria := types.KBShareCriteria{Label: "public"}
+
+	if _, err := s.CreateDynamicShare(ctx, "org-1", criteria, "editor", 2, types.OrgRoleViewer); !errors.Is(err, ErrDynamicShareAdminOnly) {
+		t.Errorf("CreateDynamicShare() by editor error = %v, want %v", err, ErrDynamicShareAdminOnly)
+	}
+	if _, err := s.CreateDynamicShare(ctx, "org-1", types.KBShareCriteria{Label: " "}, "owner", 1, types.OrgRoleViewer); !errors.Is(err, ErrInvalidShareCriteria) {
+		t.Errorf("CreateDynamicShare() without label error = %v, want %v", err, ErrInvalidShareCriteria)
+	}
+}
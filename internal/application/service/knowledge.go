@@ -55,6 +55,12 @@ var (
 	ErrDuplicateURL = errors.New("URL already exists")
 	// ErrImageNotParse is returned when trying to update image information without enabling multimodel
 	ErrImageNotParse = errors.New("image not parse without enable multimodel")
+	// ErrEmptyChunkText is returned when a chunk's text is replaced with blank content
+	ErrEmptyChunkText = errors.New("chunk text cannot be empty")
+	// ErrFAQChunkTextEdit is returned when editing FAQ chunk text directly instead of through its FAQ entry
+	ErrFAQChunkTextEdit = errors.New("FAQ chunks must be edited through their FAQ entry")
+	// ErrFAQChunkDelete is returned when deleting an FAQ chunk directly instead of through its FAQ entry
+	ErrFAQChunkDelete = errors.New("FAQ chunks must be deleted through their FAQ entry")
 )
 
 // knowledgeService implements the knowledge service interface
@@ -165,6 +171,7 @@ func (s *knowledgeService) isKnowledgeDeleting(ctx context.Context, tenantID uin
 // CreateKnowledgeFromFile creates a knowledge entry from an uploaded file
 func (s *knowledgeService) CreateKnowledgeFromFile(ctx context.Context,
 	kbID string, file *multipart.FileHeader, metadata map[string]string, enableMultimodel *bool, customFileName string, tagID string,
+	ocr *types.OCRSettings,
 ) (*types.Knowledge, error) {
 	logger.Info(ctx, "Start creating knowledge from file")
 
@@ -189,46 +196,9 @@ func (s *knowledgeService) CreateKnowledgeFromFile(ctx context.Context,
 	if !IsImageType(getFileType(fileName)) {
 		logger.Info(ctx, "Non-image file with multimodal enabled, skipping COS/VLM validation")
 	} else {
-		// 解析有效 provider：优先 KB 级别（新字段 > 旧字段），其次租户默认
-		provider := kb.GetStorageProvider()
-		tenant, _ := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
-		if provider == "" && tenant != nil && tenant.StorageEngineConfig != nil {
-			provider = strings.ToLower(strings.TrimSpace(tenant.StorageEngineConfig.DefaultProvider))
-		}
-
-		// 根据 provider 校验租户级存储引擎配置
-		switch provider {
-		case "cos":
-			if tenant == nil || tenant.StorageEngineConfig == nil || tenant.StorageEngineConfig.COS == nil ||
-				tenant.StorageEngineConfig.COS.SecretID == "" || tenant.StorageEngineConfig.COS.SecretKey == "" ||
-				tenant.StorageEngineConfig.COS.Region == "" || tenant.StorageEngineConfig.COS.BucketName == "" {
-				logger.Error(ctx, "COS configuration incomplete for image multimodal processing")
-				return nil, werrors.NewBadRequestError("上传图片文件需要完整的对象存储配置信息, 请前往知识库存储设置或系统设置页面进行补全")
-			}
-		case "minio":
-			ok := false
-			if tenant != nil && tenant.StorageEngineConfig != nil && tenant.StorageEngineConfig.MinIO != nil {
-				m := tenant.StorageEngineConfig.MinIO
-				if m.Mode == "remote" {
-					ok = m.Endpoint != "" && m.AccessKeyID != "" && m.SecretAccessKey != "" && m.BucketName != ""
-				} else {
-					ok = os.Getenv("MINIO_ENDPOINT") != "" && os.Getenv("MINIO_ACCESS_KEY_ID") != "" &&
-						os.Getenv("MINIO_SECRET_ACCESS_KEY") != "" &&
-						(m.BucketName != "" || os.Getenv("MINIO_BUCKET_NAME") != "")
-				}
-			}
-			if !ok {
-				logger.Error(ctx, "MinIO configuration incomplete for image multimodal processing")
-				return nil, werrors.NewBadRequestError("上传图片文件需要完整的对象存储配置信息, 请前往知识库存储设置或系统设置页面进行补全")
-			}
-		}
-
-		// 检查VLM配置
-		if !kb.VLMConfig.Enabled || kb.VLMConfig.ModelID == "" {
-			logger.Error(ctx, "VLM model is not configured")
-			return nil, werrors.NewBadRequestError("上传图片文件需要设置VLM模型")
+		if err := checkImageUploadConfig(ctx, kb); err != nil {
+			return nil, err
 		}
-
 		logger.Info(ctx, "Image multimodal configuration validation passed")
 	}
 
@@ -255,17 +225,20 @@ func (s *knowledgeService) CreateKnowledgeFromFile(ctx context.Context,
 		FileName: fileName,
 		FileSize: file.Size,
 		FileHash: hash,
+		Scope:    kb.DuplicateScope,
 	})
 	if err != nil {
 		logger.Errorf(ctx, "Failed to check knowledge existence: %v", err)
 		return nil, err
 	}
 	if exists {
-		logger.Infof(ctx, "File already exists: %s", fileName)
-		// Update creation time for existing knowledge
-		if err := s.repo.UpdateKnowledgeColumn(ctx, existingKnowledge.ID, "created_at", time.Now()); err != nil {
-			logger.Errorf(ctx, "Failed to update existing knowledge: %v", err)
-			return nil, err
+		logger.Infof(ctx, "File already exists: %s, knowledge base: %s", fileName, existingKnowledge.KnowledgeBaseID)
+		// Update creation time for existing knowledge in the same knowledge base
+		if existingKnowledge.KnowledgeBaseID == kbID {
+			if err := s.repo.UpdateKnowledgeColumn(ctx, existingKnowledge.ID, "created_at", time.Now()); err != nil {
+				logger.Errorf(ctx, "Failed to update existing knowledge: %v", err)
+				return nil, err
+			}
 		}
 		return existingKnowledge, types.NewDuplicateFileError(existingKnowledge)
 	}
@@ -276,6 +249,9 @@ func (s *knowledgeService) CreateKnowledgeFromFile(ctx context.Context,
 		logger.Error(ctx, "Storage quota exceeded")
 		return nil, types.NewStorageQuotaExceededError()
 	}
+	if err := s.kbShareService.CheckOrgStorageQuota(ctx, kbID); err != nil {
+		return nil, err
+	}
 
 	// Convert metadata to JSON format if provided
 	var metadataJSON types.JSON
@@ -366,6 +342,10 @@ func (s *knowledgeService) CreateKnowledgeFromFile(ctx context.Context,
 		EnableQuestionGeneration: enableQuestionGeneration,
 		QuestionCount:            questionCount,
 	}
+	if ocr != nil {
+		taskPayload.OCREnabled = ocr.Enabled
+		taskPayload.OCRLanguages = ocr.Languages
+	}
 
 	payloadBytes, err := json.Marshal(taskPayload)
 	if err != nil {
@@ -455,19 +435,22 @@ func (s *knowledgeService) CreateKnowledgeFromURL(ctx context.Context,
 		Type:     "url",
 		URL:      url,
 		FileHash: fileHash,
+		Scope:    kb.DuplicateScope,
 	})
 	if err != nil {
 		logger.Errorf(ctx, "Failed to check knowledge existence: %v", err)
 		return nil, err
 	}
 	if exists {
-		logger.Infof(ctx, "URL already exists: %s", url)
-		// Update creation time for existing knowledge
-		existingKnowledge.CreatedAt = time.Now()
-		existingKnowledge.UpdatedAt = time.Now()
-		if err := s.repo.UpdateKnowledge(ctx, existingKnowledge); err != nil {
-			logger.Errorf(ctx, "Failed to update existing knowledge: %v", err)
-			return nil, err
+		logger.Infof(ctx, "URL already exists: %s, knowledge base: %s", url, existingKnowledge.KnowledgeBaseID)
+		// Update creation time for existing knowledge in the same knowledge base
+		if existingKnowledge.KnowledgeBaseID == kbID {
+			existingKnowledge.CreatedAt = time.Now()
+			existingKnowledge.UpdatedAt = time.Now()
+			if err := s.repo.UpdateKnowledge(ctx, existingKnowledge); err != nil {
+				logger.Errorf(ctx, "Failed to update existing knowledge: %v", err)
+				return nil, err
+			}
 		}
 		return existingKnowledge, types.NewDuplicateURLError(existingKnowledge)
 	}
@@ -478,6 +461,9 @@ func (s *knowledgeService) CreateKnowledgeFromURL(ctx context.Context,
 		logger.Error(ctx, "Storage quota exceeded")
 		return nil, types.NewStorageQuotaExceededError()
 	}
+	if err := s.kbShareService.CheckOrgStorageQuota(ctx, kbID); err != nil {
+		return nil, err
+	}
 
 	// Create knowledge record
 	logger.Info(ctx, "Creating knowledge record")
@@ -663,18 +649,21 @@ func (s *knowledgeService) createKnowledgeFromFileURL(
 		Type:     "file_url",
 		URL:      fileURL,
 		FileHash: fileHash,
+		Scope:    kb.DuplicateScope,
 	})
 	if err != nil {
 		logger.Errorf(ctx, "Failed to check knowledge existence: %v", err)
 		return nil, err
 	}
 	if exists {
-		logger.Infof(ctx, "File URL already exists: %s", fileURL)
-		existingKnowledge.CreatedAt = time.Now()
-		existingKnowledge.UpdatedAt = time.Now()
-		if err := s.repo.UpdateKnowledge(ctx, existingKnowledge); err != nil {
-			logger.Errorf(ctx, "Failed to update existing knowledge: %v", err)
-			return nil, err
+		logger.Infof(ctx, "File URL already exists: %s, knowledge base: %s", fileURL, existingKnowledge.KnowledgeBaseID)
+		if existingKnowledge.KnowledgeBaseID == kbID {
+			existingKnowledge.CreatedAt = time.Now()
+			existingKnowledge.UpdatedAt = time.Now()
+			if err := s.repo.UpdateKnowledge(ctx, existingKnowledge); err != nil {
+				logger.Errorf(ctx, "Failed to update existing knowledge: %v", err)
+				return nil, err
+			}
 		}
 		return existingKnowledge, types.NewDuplicateURLError(existingKnowledge)
 	}
@@ -685,6 +674,9 @@ func (s *knowledgeService) createKnowledgeFromFileURL(
 		logger.Error(ctx, "Storage quota exceeded")
 		return nil, types.NewStorageQuotaExceededError()
 	}
+	if err := s.kbShareService.CheckOrgStorageQuota(ctx, kbID); err != nil {
+		return nil, err
+	}
 
 	// Create knowledge record
 	knowledge := &types.Knowledge{
@@ -994,12 +986,20 @@ func (s *knowledgeService) ListKnowledgeByKnowledgeBaseID(ctx context.Context,
 	return s.repo.ListKnowledgeByKnowledgeBaseID(ctx, ctx.Value(types.TenantIDContextKey).(uint64), kbID)
 }
 
+// GetKnowledgeByFilename returns the knowledge in a knowledge base uploaded with the given
+// original file name, most recent first
+func (s *knowledgeService) GetKnowledgeByFilename(ctx context.Context,
+	kbID string, filename string,
+) ([]*types.Knowledge, error) {
+	return s.repo.ListKnowledgeByFileName(ctx, ctx.Value(types.TenantIDContextKey).(uint64), kbID, filename)
+}
+
 // ListPagedKnowledgeByKnowledgeBaseID returns paginated knowledge entries in a knowledge base
 func (s *knowledgeService) ListPagedKnowledgeByKnowledgeBaseID(ctx context.Context,
-	kbID string, page *types.Pagination, tagID string, keyword string, fileType string,
+	kbID string, page *types.Pagination, tagID string, keyword string, fileType string, knowledgeIDs []string,
 ) (*types.PageResult, error) {
 	knowledges, total, err := s.repo.ListPagedKnowledgeByKnowledgeBaseID(ctx,
-		ctx.Value(types.TenantIDContextKey).(uint64), kbID, page, tagID, keyword, fileType)
+		ctx.Value(types.TenantIDContextKey).(uint64), kbID, page, tagID, keyword, fileType, knowledgeIDs)
 	if err != nil {
 		return nil, err
 	}
@@ -1007,6 +1007,21 @@ func (s *knowledgeService) ListPagedKnowledgeByKnowledgeBaseID(ctx context.Conte
 	return types.NewPageResult(total, page, knowledges), nil
 }
 
+// ListAllKnowledge lists knowledge across the document knowledge bases of the tenant in context
+func (s *knowledgeService) ListAllKnowledge(ctx context.Context,
+	page *types.Pagination, filter *types.KnowledgeListFilter,
+) (*types.PageResult, error) {
+	tenantID, ok := types.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, werrors.NewUnauthorizedError("Tenant ID not found in context")
+	}
+	knowledges, total, err := s.repo.ListPagedKnowledgeByTenant(ctx, tenantID, page, filter)
+	if err != nil {
+		return nil, err
+	}
+	return types.NewPageResult(total, page, knowledges), nil
+}
+
 // DeleteKnowledge deletes a knowledge entry and all related resources
 func (s *knowledgeService) DeleteKnowledge(ctx context.Context, id string) error {
 	// Get the knowledge entry
@@ -1221,6 +1236,7 @@ func (s *knowledgeService) DeleteKnowledgeList(ctx context.Context, ids []string
 func (s *knowledgeService) cloneKnowledge(
 	ctx context.Context,
 	src *types.Knowledge,
+	srcKB *types.KnowledgeBase,
 	targetKB *types.KnowledgeBase,
 ) (err error) {
 	if src.ParseStatus != "completed" {
@@ -1243,7 +1259,6 @@ func (s *knowledgeService) cloneKnowledge(
 		FileType:         src.FileType,
 		FileSize:         src.FileSize,
 		FileHash:         src.FileHash,
-		FilePath:         src.FilePath,
 		StorageSize:      src.StorageSize,
 		Metadata:         src.Metadata,
 	}
@@ -1265,6 +1280,13 @@ func (s *knowledgeService) cloneKnowledge(
 		logger.GetLogger(ctx).WithField("error", err).Errorf("MoveKnowledge create knowledge failed")
 		return
 	}
+	if src.FilePath != "" {
+		// The copy owns its file, so deleting either knowledge leaves the other one's file in place
+		if dst.FilePath, err = s.copyKnowledgeFile(ctx, src, srcKB, targetKB); err != nil {
+			logger.GetLogger(ctx).WithField("error", err).Errorf("MoveKnowledge copy file failed")
+			return
+		}
+	}
 	tenantInfo.StorageUsed += dst.StorageSize
 	if err = s.tenantRepo.AdjustStorageUsed(ctx, tenantInfo.ID, dst.StorageSize); err != nil {
 		logger.GetLogger(ctx).WithField("error", err).Errorf("MoveKnowledge update tenant storage used failed")
@@ -1278,6 +1300,40 @@ func (s *knowledgeService) cloneKnowledge(
 	return
 }
 
+// copyKnowledgeFile copies the file of a knowledge into the storage of the target knowledge base
+// and returns the path of the copy. The source file is read with the storage of its own tenant.
+func (s *knowledgeService) copyKnowledgeFile(ctx context.Context,
+	src *types.Knowledge, srcKB *types.KnowledgeBase, targetKB *types.KnowledgeBase,
+) (string, error) {
+	srcCtx := ctx
+	if srcKB.TenantID != targetKB.TenantID {
+		srcTenant, err := s.tenantRepo.GetTenantByID(ctx, srcKB.TenantID)
+		if err != nil {
+			return "", fmt.Errorf("failed to get source tenant: %w", err)
+		}
+		srcCtx = context.WithValue(ctx, types.TenantInfoContextKey, srcTenant)
+	}
+	reader, err := s.resolveFileServiceForPath(srcCtx, srcKB, src.FilePath).GetFile(srcCtx, src.FilePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read source file: %w", err)
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return "", fmt.Errorf("failed to read source file: %w", err)
+	}
+
+	fileName := src.FileName
+	if fileName == "" {
+		fileName = path.Base(src.FilePath)
+	}
+	filePath, err := s.resolveFileService(ctx, targetKB).SaveBytes(ctx, data, targetKB.TenantID, fileName, false)
+	if err != nil {
+		return "", fmt.Errorf("failed to save file copy: %w", err)
+	}
+	return filePath, nil
+}
+
 // processDocumentFromPassage handles asynchronous processing of text passages
 func (s *knowledgeService) processDocumentFromPassage(ctx context.Context,
 	kb *types.KnowledgeBase, knowledge *types.Knowledge, passage []string,
@@ -1315,6 +1371,8 @@ type ProcessChunksOptions struct {
 	QuestionCount            int
 	EnableMultimodel         bool
 	StoredImages             []docparser.StoredImage
+	// OCR settings for the images of the document
+	OCR types.OCRSettings
 	// ParentChunks holds parent chunk data when parent-child chunking is enabled.
 	// When set, the chunks passed to processChunks are child chunks, and each
 	// child's ParentIndex references an entry in this slice.
@@ -1749,7 +1807,7 @@ func (s *knowledgeService) processChunks(ctx context.Context,
 
 	// Enqueue multimodal tasks for images (async, non-blocking)
 	if options.EnableMultimodel && len(options.StoredImages) > 0 {
-		s.enqueueImageMultimodalTasks(ctx, knowledge, kb, options.StoredImages, chunks)
+		s.enqueueImageMultimodalTasks(ctx, knowledge, kb, options.StoredImages, chunks, options.OCR)
 	}
 
 	// Update tenant's storage usage
@@ -2402,6 +2460,14 @@ func (s *knowledgeService) UpdateKnowledge(ctx context.Context, knowledge *types
 	if knowledge.Title != "" {
 		record.Title = knowledge.Title
 	}
+	// Zero means the boost was not supplied; a document cannot be boosted to zero
+	if knowledge.RetrievalBoost != 0 {
+		if knowledge.RetrievalBoost < 0 || knowledge.RetrievalBoost > types.MaxRetrievalBoost {
+			return werrors.NewValidationError(
+				fmt.Sprintf("retrieval_boost must be greater than 0 and at most %v", types.MaxRetrievalBoost))
+		}
+		record.RetrievalBoost = knowledge.RetrievalBoost
+	}
 
 	// Update knowledge record in the repository
 	if err := s.repo.UpdateKnowledge(ctx, record); err != nil {
@@ -2550,6 +2616,7 @@ func (s *knowledgeService) ReparseKnowledge(ctx context.Context, knowledgeID str
 	existing.ParseStatus = "pending"
 	existing.EnableStatus = "disabled"
 	existing.Description = ""
+	existing.ErrorMessage = ""
 	existing.ProcessedAt = nil
 	existing.EmbeddingModelID = kb.EmbeddingModelID
 
@@ -2715,6 +2782,69 @@ func (s *knowledgeService) ReparseKnowledge(ctx context.Context, knowledgeID str
 	return existing, nil
 }
 
+// RetryProcessing re-runs the processing of a knowledge item whose parsing or embedding failed.
+// Partial results are cleaned up and the stored original file, URL or manual content is processed again.
+func (s *knowledgeService) RetryProcessing(ctx context.Context, knowledgeID string) (*types.Knowledge, error) {
+	tenantID := types.MustTenantIDFromContext(ctx)
+	existing, err := s.repo.GetKnowledgeByID(ctx, tenantID, knowledgeID)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to load knowledge for retry: %v", err)
+		return nil, err
+	}
+	if existing.ParseStatus != types.ParseStatusFailed {
+		return nil, werrors.NewBadRequestError("只有处理失败的知识可以重试").
+			WithDetails(fmt.Sprintf("parse_status=%s", existing.ParseStatus))
+	}
+
+	logger.Infof(ctx, "Retrying failed knowledge %s, last error: %s", knowledgeID, existing.ErrorMessage)
+	return s.ReparseKnowledge(ctx, knowledgeID)
+}
+
+// checkImageUploadConfig checks that the storage engine and VLM model needed to process
+// uploaded images are configured for the knowledge base
+func checkImageUploadConfig(ctx context.Context, kb *types.KnowledgeBase) error {
+	// 解析有效 provider：优先 KB 级别（新字段 > 旧字段），其次租户默认
+	provider := kb.GetStorageProvider()
+	tenant, _ := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
+	if provider == "" && tenant != nil && tenant.StorageEngineConfig != nil {
+		provider = strings.ToLower(strings.TrimSpace(tenant.StorageEngineConfig.DefaultProvider))
+	}
+
+	// 根据 provider 校验租户级存储引擎配置
+	switch provider {
+	case "cos":
+		if tenant == nil || tenant.StorageEngineConfig == nil || tenant.StorageEngineConfig.COS == nil ||
+			tenant.StorageEngineConfig.COS.SecretID == "" || tenant.StorageEngineConfig.COS.SecretKey == "" ||
+			tenant.StorageEngineConfig.COS.Region == "" || tenant.StorageEngineConfig.COS.BucketName == "" {
+			logger.Error(ctx, "COS configuration incomplete for image multimodal processing")
+			return werrors.NewBadRequestError("上传图片文件需要完整的对象存储配置信息, 请前往知识库存储设置或系统设置页面进行补全")
+		}
+	case "minio":
+		ok := false
+		if tenant != nil && tenant.StorageEngineConfig != nil && tenant.StorageEngineConfig.MinIO != nil {
+			m := tenant.StorageEngineConfig.MinIO
+			if m.Mode == "remote" {
+				ok = m.Endpoint != "" && m.AccessKeyID != "" && m.SecretAccessKey != "" && m.BucketName != ""
+			} else {
+				ok = os.Getenv("MINIO_ENDPOINT") != "" && os.Getenv("MINIO_ACCESS_KEY_ID") != "" &&
+					os.Getenv("MINIO_SECRET_ACCESS_KEY") != "" &&
+					(m.BucketName != "" || os.Getenv("MINIO_BUCKET_NAME") != "")
+			}
+		}
+		if !ok {
+			logger.Error(ctx, "MinIO configuration incomplete for image multimodal processing")
+			return werrors.NewBadRequestError("上传图片文件需要完整的对象存储配置信息, 请前往知识库存储设置或系统设置页面进行补全")
+		}
+	}
+
+	// 检查VLM配置
+	if !kb.VLMConfig.Enabled || kb.VLMConfig.ModelID == "" {
+		logger.Error(ctx, "VLM model is not configured")
+		return werrors.NewBadRequestError("上传图片文件需要设置VLM模型")
+	}
+	return nil
+}
+
 // isValidFileType checks if a file type is supported
 func isValidFileType(filename string) bool {
 	switch strings.ToLower(getFileType(filename)) {
@@ -2787,7 +2917,7 @@ func (s *knowledgeService) GetKnowledgeBatchWithSharedAccess(ctx context.Context
 		if err != nil || k == nil || k.KnowledgeBaseID == "" {
 			continue
 		}
-		hasPermission, err := s.kbShareService.HasKBPermission(ctx, k.KnowledgeBaseID, userID, types.OrgRoleViewer)
+		hasPermission, err := s.kbShareService.HasKnowledgePermission(ctx, k.KnowledgeBaseID, k.ID, userID, types.OrgRoleViewer)
 		if err != nil || !hasPermission {
 			continue
 		}
@@ -2873,7 +3003,7 @@ func (s *knowledgeService) CloneKnowledgeBase(ctx context.Context, srcID, dstID
 				logger.Errorf(gctx, "get knowledge %s: %v", knowledge, err)
 				return err
 			}
-			err = s.cloneKnowledge(gctx, srcKn, dstKB)
+			err = s.cloneKnowledge(gctx, srcKn, srcKB, dstKB)
 			if err != nil {
 				logger.Errorf(gctx, "clone knowledge %s: %v", knowledge, err)
 				return err
@@ -7320,6 +7450,7 @@ func (s *knowledgeService) ProcessDocument(ctx context.Context, t *asynq.Task) e
 		QuestionCount:            payload.QuestionCount,
 		EnableMultimodel:         payload.EnableMultimodel,
 		StoredImages:             storedImages,
+		OCR:                      types.ResolveOCRSettings(kb.ChunkingConfig, payload.OCREnabled, payload.OCRLanguages),
 	}
 
 	if kb.ChunkingConfig.EnableParentChild {
@@ -7372,7 +7503,8 @@ func (s *knowledgeService) convert(
 ) (*types.ReadResult, error) {
 	isURL := payload.URL != ""
 	fileType := payload.FileType
-	overrides := s.getParserEngineOverridesFromContext(ctx)
+	ocr := types.ResolveOCRSettings(kb.ChunkingConfig, payload.OCREnabled, payload.OCRLanguages)
+	overrides := ocr.ApplyToParserOverrides(s.getParserEngineOverridesFromContext(ctx))
 
 	if isURL {
 		if safe, reason := secutils.IsSSRFSafeURL(payload.URL); !safe {
@@ -7486,6 +7618,7 @@ func (s *knowledgeService) enqueueImageMultimodalTasks(
 	kb *types.KnowledgeBase,
 	images []docparser.StoredImage,
 	chunks []types.ParsedChunk,
+	ocr types.OCRSettings,
 ) {
 	if s.task == nil || len(images) == 0 {
 		return
@@ -7511,8 +7644,9 @@ func (s *knowledgeService) enqueueImageMultimodalTasks(
 			KnowledgeBaseID: kb.ID,
 			ChunkID:         chunkID,
 			ImageURL:        img.ServingURL,
-			EnableOCR:       true,
+			EnableOCR:       ocr.IsEnabled(),
 			EnableCaption:   true,
+			OCRLanguages:    ocr.Languages,
 		}
 
 		payloadBytes, err := json.Marshal(payload)
@@ -8015,18 +8149,35 @@ func (s *knowledgeService) ProcessKBClone(ctx context.Context, t *asynq.Task) er
 	}
 
 	// Get source and target knowledge bases
-	srcKB, dstKB, err := s.kbService.CopyKnowledgeBase(ctx, payload.SourceID, payload.TargetID)
+	var srcKB, dstKB *types.KnowledgeBase
+	if payload.SourceTenantID != 0 && payload.SourceTenantID != payload.TenantID {
+		srcKB, dstKB, err = s.getCrossTenantCloneKBs(ctx, payload)
+	} else {
+		srcKB, dstKB, err = s.kbService.CopyKnowledgeBase(ctx, payload.SourceID, payload.TargetID)
+	}
 	if err != nil {
 		logger.Errorf(ctx, "Failed to copy knowledge base: %v", err)
 		handleError(progress, err, "Failed to copy knowledge base configuration")
 		return err
 	}
 
+	// The target may have been created by the task; record it so failed items can be retried
+	progress.TargetID = dstKB.ID
+
 	// Use different sync strategies based on knowledge base type
 	if srcKB.Type == types.KnowledgeBaseTypeFAQ {
 		return s.cloneFAQKnowledgeBase(ctx, srcKB, dstKB, progress, handleError)
 	}
 
+	// A retry of failed items must not be blocked by the failed copies left in the target
+	if len(payload.KnowledgeIDs) > 0 {
+		if err := s.deleteFailedCloneCopies(ctx, srcKB, dstKB, payload.KnowledgeIDs); err != nil {
+			logger.Errorf(ctx, "Failed to delete failed copies before retry: %v", err)
+			handleError(progress, err, "Failed to clean up failed knowledge copies")
+			return err
+		}
+	}
+
 	// Document type: use Knowledge-level diff based on file_hash
 	addKnowledge, err := s.repo.AminusB(ctx, srcKB.TenantID, srcKB.ID, dstKB.TenantID, dstKB.ID)
 	if err != nil {
@@ -8035,11 +8186,18 @@ func (s *knowledgeService) ProcessKBClone(ctx context.Context, t *asynq.Task) er
 		return err
 	}
 
-	delKnowledge, err := s.repo.AminusB(ctx, dstKB.TenantID, dstKB.ID, srcKB.TenantID, srcKB.ID)
-	if err != nil {
-		logger.Errorf(ctx, "Failed to get knowledge to delete: %v", err)
-		handleError(progress, err, "Failed to calculate knowledge difference")
-		return err
+	var delKnowledge []string
+	if len(payload.KnowledgeIDs) > 0 {
+		addKnowledge = slices.DeleteFunc(addKnowledge, func(id string) bool {
+			return !slices.Contains(payload.KnowledgeIDs, id)
+		})
+	} else {
+		delKnowledge, err = s.repo.AminusB(ctx, dstKB.TenantID, dstKB.ID, srcKB.TenantID, srcKB.ID)
+		if err != nil {
+			logger.Errorf(ctx, "Failed to get knowledge to delete: %v", err)
+			handleError(progress, err, "Failed to calculate knowledge difference")
+			return err
+		}
 	}
 
 	totalOperations := len(addKnowledge) + len(delKnowledge)
@@ -8080,23 +8238,34 @@ func (s *knowledgeService) ProcessKBClone(ctx context.Context, t *asynq.Task) er
 	progress.UpdatedAt = time.Now().Unix()
 	_ = s.saveKBCloneProgress(ctx, progress)
 
-	// Clone knowledge from source to target
-	g, gctx = errgroup.WithContext(ctx)
+	// Clone knowledge from source to target. A knowledge that fails is recorded and skipped
+	// so one bad file does not fail the whole clone.
+	var mu sync.Mutex
+	g = &errgroup.Group{}
 	g.SetLimit(batch)
 	for _, knowledge := range addKnowledge {
 		g.Go(func() error {
-			srcKn, err := s.repo.GetKnowledgeByID(gctx, srcKB.TenantID, knowledge)
-			if err != nil {
-				logger.Errorf(gctx, "get knowledge %s: %v", knowledge, err)
-				return err
-			}
-			err = s.cloneKnowledge(gctx, srcKn, dstKB)
+			title := ""
+			srcKn, err := s.repo.GetKnowledgeByID(ctx, srcKB.TenantID, knowledge)
 			if err != nil {
-				logger.Errorf(gctx, "clone knowledge %s: %v", knowledge, err)
-				return err
+				logger.Errorf(ctx, "get knowledge %s: %v", knowledge, err)
+			} else {
+				title = srcKn.Title
+				if err = s.cloneKnowledge(ctx, srcKn, srcKB, dstKB); err != nil {
+					logger.Errorf(ctx, "clone knowledge %s: %v", knowledge, err)
+				}
 			}
 
 			// Update progress
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				progress.FailedItems = append(progress.FailedItems, types.KBCloneFailedItem{
+					KnowledgeID: knowledge,
+					Title:       title,
+					Error:       err.Error(),
+				})
+			}
 			processedCount++
 			if totalOperations > 0 {
 				progress.Progress = processedCount * 100 / totalOperations
@@ -8105,30 +8274,81 @@ func (s *knowledgeService) ProcessKBClone(ctx context.Context, t *asynq.Task) er
 			progress.Message = fmt.Sprintf("Cloned %d/%d knowledge", processedCount-len(delKnowledge), len(addKnowledge))
 			progress.UpdatedAt = time.Now().Unix()
 			_ = s.saveKBCloneProgress(ctx, progress)
-
 			return nil
 		})
 	}
-	if err := g.Wait(); err != nil {
-		logger.Errorf(ctx, "add total knowledge %d: %v", len(addKnowledge), err)
-		handleError(progress, err, "Failed to clone knowledge")
-		return err
-	}
+	_ = g.Wait()
 
 	// Mark as completed
-	progress.Status = types.KBCloneStatusCompleted
 	progress.Progress = 100
 	progress.Processed = totalOperations
-	progress.Message = "Knowledge base clone completed successfully"
 	progress.UpdatedAt = time.Now().Unix()
+	if len(progress.FailedItems) > 0 {
+		progress.Status = types.KBCloneStatusCompletedWithErrors
+		progress.Message = fmt.Sprintf("Knowledge base clone completed, %d of %d knowledge failed",
+			len(progress.FailedItems), len(addKnowledge))
+	} else {
+		progress.Status = types.KBCloneStatusCompleted
+		progress.Message = "Knowledge base clone completed successfully"
+	}
 	if err := s.saveKBCloneProgress(ctx, progress); err != nil {
 		logger.Errorf(ctx, "Failed to update KB clone progress to completed: %v", err)
 	}
 
-	logger.Infof(ctx, "KB clone task completed: %s", payload.TaskID)
+	logger.Infof(ctx, "KB clone task completed: %s, failed items: %d", payload.TaskID, len(progress.FailedItems))
 	return nil
 }
 
+// getCrossTenantCloneKBs loads the KBs of a copy into another tenant. The target was created by
+// the copy request; the task runs in the target tenant, so both tenants are verified here.
+func (s *knowledgeService) getCrossTenantCloneKBs(
+	ctx context.Context, payload types.KBClonePayload,
+) (*types.KnowledgeBase, *types.KnowledgeBase, error) {
+	srcKB, err := s.kbService.GetKnowledgeBaseByIDOnly(ctx, payload.SourceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	dstKB, err := s.kbService.GetKnowledgeBaseByIDOnly(ctx, payload.TargetID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if srcKB.TenantID != payload.SourceTenantID || dstKB.TenantID != payload.TenantID {
+		return nil, nil, fmt.Errorf("knowledge base tenant mismatch for clone task %s", payload.TaskID)
+	}
+	return srcKB, dstKB, nil
+}
+
+// deleteFailedCloneCopies removes the target copies of the given source knowledge that were
+// left in failed state by a previous clone, so that the file_hash diff picks them up again
+func (s *knowledgeService) deleteFailedCloneCopies(
+	ctx context.Context, srcKB, dstKB *types.KnowledgeBase, knowledgeIDs []string,
+) error {
+	srcKnowledge, err := s.repo.GetKnowledgeBatch(ctx, srcKB.TenantID, knowledgeIDs)
+	if err != nil {
+		return fmt.Errorf("failed to get source knowledge: %w", err)
+	}
+	hashes := make(map[string]bool, len(srcKnowledge))
+	for _, k := range srcKnowledge {
+		hashes[k.FileHash] = true
+	}
+
+	dstKnowledge, err := s.repo.ListKnowledgeByKnowledgeBaseID(ctx, dstKB.TenantID, dstKB.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list target knowledge: %w", err)
+	}
+	var failedCopies []string
+	for _, k := range dstKnowledge {
+		if k.ParseStatus == "failed" && hashes[k.FileHash] {
+			failedCopies = append(failedCopies, k.ID)
+		}
+	}
+	if len(failedCopies) == 0 {
+		return nil
+	}
+	logger.Infof(ctx, "Deleting %d failed knowledge copies from target %s before retry", len(failedCopies), dstKB.ID)
+	return s.DeleteKnowledgeList(ctx, failedCopies)
+}
+
 // cloneFAQKnowledgeBase handles FAQ knowledge base cloning with chunk-level incremental sync
 func (s *knowledgeService) cloneFAQKnowledgeBase(
 	ctx context.Context,
@@ -8811,10 +9031,18 @@ func (s *knowledgeService) SearchKnowledge(ctx context.Context, keyword string,
 			if err == nil {
 				for _, info := range sharedList {
 					if info != nil && info.KnowledgeBase != nil && info.KnowledgeBase.Type == types.KnowledgeBaseTypeDocument {
-						scopes = append(scopes, types.KnowledgeSearchScope{
+						knowledgeIDs, restricted, err := s.kbShareService.GetSharedKnowledgeIDs(ctx, info.KnowledgeBase.ID, userID)
+						if err != nil {
+							continue
+						}
+						scope := types.KnowledgeSearchScope{
 							TenantID: info.SourceTenantID,
 							KBID:     info.KnowledgeBase.ID,
-						})
+						}
+						if restricted {
+							scope.KnowledgeIDs = knowledgeIDs
+						}
+						scopes = append(scopes, scope)
 					}
 				}
 			}
@@ -8835,6 +9063,46 @@ func (s *knowledgeService) SearchKnowledgeForScopes(ctx context.Context, scopes
 	return s.repo.SearchKnowledgeInScopes(ctx, scopes, keyword, offset, limit, fileTypes)
 }
 
+// ResolveRetrievalScope returns the IDs of knowledge in kbID matching the retrieval filters.
+// Metadata filters are dropped when no knowledge in the base has any of the filter keys; if no
+// date range remains either, the filters are reported as not applied.
+func (s *knowledgeService) ResolveRetrievalScope(
+	ctx context.Context, tenantID uint64, kbID string, filters *types.RetrievalFilters,
+) ([]string, bool, error) {
+	if filters.IsEmpty() {
+		return nil, false, nil
+	}
+	ids, err := s.repo.ListIDsByRetrievalFilters(ctx, tenantID, kbID, filters)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(ids) > 0 || len(filters.MetadataFilters) == 0 {
+		return ids, true, nil
+	}
+
+	keys := make([]string, 0, len(filters.MetadataFilters))
+	for key := range filters.MetadataFilters {
+		keys = append(keys, key)
+	}
+	hasKeys, err := s.repo.HasMetadataKeys(ctx, tenantID, kbID, keys)
+	if err != nil {
+		return nil, false, err
+	}
+	if hasKeys {
+		return nil, true, nil
+	}
+	if !filters.HasDateRange() {
+		return nil, false, nil
+	}
+	logger.Infof(ctx, "No knowledge in %s has the requested metadata keys, applying date range only", kbID)
+	dateOnly := &types.RetrievalFilters{CreatedAfter: filters.CreatedAfter, CreatedBefore: filters.CreatedBefore}
+	ids, err = s.repo.ListIDsByRetrievalFilters(ctx, tenantID, kbID, dateOnly)
+	if err != nil {
+		return nil, false, err
+	}
+	return ids, true, nil
+}
+
 // ProcessKnowledgeListDelete handles Asynq knowledge list delete tasks
 func (s *knowledgeService) ProcessKnowledgeListDelete(ctx context.Context, t *asynq.Task) error {
 	var payload types.KnowledgeListDeletePayload
@@ -8865,3 +9133,39 @@ func (s *knowledgeService) ProcessKnowledgeListDelete(ctx context.Context, t *as
 	logger.Infof(ctx, "Successfully deleted %d knowledge items", len(payload.KnowledgeIDs))
 	return nil
 }
+
+// ProcessKBReindex re-parses all knowledge of a knowledge base so their vectors are rebuilt with
+// its current embedding model. Knowledge that fails to be scheduled is logged and skipped rather
+// than retried, since a retry would re-parse the knowledge already scheduled again.
+func (s *knowledgeService) ProcessKBReindex(ctx context.Context, t *asynq.Task) error {
+	var payload types.KBReindexPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		logger.Errorf(ctx, "Failed to unmarshal KB reindex payload: %v", err)
+		return err
+	}
+
+	tenant, err := s.tenantRepo.GetTenantByID(ctx, payload.TenantID)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to get tenant %d: %v", payload.TenantID, err)
+		return err
+	}
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, payload.TenantID)
+	ctx = context.WithValue(ctx, types.TenantInfoContextKey, tenant)
+
+	knowledgeList, err := s.repo.ListKnowledgeByKnowledgeBaseID(ctx, payload.TenantID, payload.KnowledgeBaseID)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to list knowledge for reindex of knowledge base %s: %v", payload.KnowledgeBaseID, err)
+		return err
+	}
+
+	failed := 0
+	for _, knowledge := range knowledgeList {
+		if _, err := s.ReparseKnowledge(ctx, knowledge.ID); err != nil {
+			logger.Warnf(ctx, "Failed to reindex knowledge %s: %v", knowledge.ID, err)
+			failed++
+		}
+	}
+	logger.Infof(ctx, "Reindex of knowledge base %s scheduled: %d knowledge, %d failed",
+		payload.KnowledgeBaseID, len(knowledgeList), failed)
+	return nil
+}
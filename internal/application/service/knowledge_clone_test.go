@@ -0,0 +1,199 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/models/embedding"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// memoryFileService stores files in memory under generated paths
+type memoryFileService struct {
+	interfaces.FileService
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+func (s *memoryFileService) SaveBytes(ctx context.Context,
+	data []byte, tenantID uint64, fileName string, temp bool,
+) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	filePath := fmt.Sprintf("%d/%d/%s", tenantID, len(s.files), fileName)
+	s.files[filePath] = data
+	return filePath, nil
+}
+
+func (s *memoryFileService) GetFile(ctx context.Context, filePath string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.files[filePath]
+	if !ok {
+		return nil, errors.New("file not found")
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *memoryFileService) DeleteFile(ctx context.Context, filePath string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.files, filePath)
+	return nil
+}
+
+// memoryKnowledgeRepo keeps knowledge by ID
+type memoryKnowledgeRepo struct {
+	interfaces.KnowledgeRepository
+	knowledge map[string]*types.Knowledge
+}
+
+func (r *memoryKnowledgeRepo) CreateKnowledge(ctx context.Context, knowledge *types.Knowledge) error {
+	r.knowledge[knowledge.ID] = knowledge
+	return nil
+}
+
+func (r *memoryKnowledgeRepo) UpdateKnowledge(ctx context.Context, knowledge *types.Knowledge) error {
+	r.knowledge[knowledge.ID] = knowledge
+	return nil
+}
+
+func (r *memoryKnowledgeRepo) GetKnowledgeByID(ctx context.Context,
+	tenantID uint64, id string,
+) (*types.Knowledge, error) {
+	if k, ok := r.knowledge[id]; ok && k.TenantID == tenantID {
+		return k, nil
+	}
+	return nil, errors.New("knowledge not found")
+}
+
+func (r *memoryKnowledgeRepo) DeleteKnowledge(ctx context.Context, tenantID uint64, id string) error {
+	delete(r.knowledge, id)
+	return nil
+}
+
+type storageTenantRepo struct {
+	interfaces.TenantRepository
+	tenants map[uint64]*types.Tenant
+}
+
+func (r *storageTenantRepo) GetTenantByID(ctx context.Context, id uint64) (*types.Tenant, error) {
+	return r.tenants[id], nil
+}
+
+func (r *storageTenantRepo) AdjustStorageUsed(ctx context.Context, tenantID uint64, delta int64) error {
+	r.tenants[tenantID].StorageUsed += delta
+	return nil
+}
+
+type emptyChunkRepo struct {
+	interfaces.ChunkRepository
+}
+
+func (r *emptyChunkRepo) ListPagedChunksByKnowledgeID(ctx context.Context,
+	tenantID uint64, knowledgeID string, page *types.Pagination, chunkType []types.ChunkType,
+	tagID string, keyword string, searchField string, sortOrder string, knowledgeType string,
+) ([]*types.Chunk, int64, error) {
+	return nil, 0, nil
+}
+
+type fixedKBService struct {
+	interfaces.KnowledgeBaseService
+	kb *types.KnowledgeBase
+}
+
+func (s *fixedKBService) GetKnowledgeBaseByID(ctx context.Context, id string) (*types.KnowledgeBase, error) {
+	return s.kb, nil
+}
+
+type fixedEmbedder struct {
+	embedding.Embedder
+}
+
+func (e *fixedEmbedder) GetDimensions() int { return 8 }
+
+type fixedEmbeddingModelService struct {
+	interfaces.ModelService
+}
+
+func (s *fixedEmbeddingModelService) GetEmbeddingModel(ctx context.Context, modelID string) (embedding.Embedder, error) {
+	return &fixedEmbedder{}, nil
+}
+
+type noopChunkService struct {
+	interfaces.ChunkService
+}
+
+func (s *noopChunkService) DeleteChunksByKnowledgeID(ctx context.Context, knowledgeID string) error {
+	return nil
+}
+
+type noopGraphRepo struct {
+	interfaces.RetrieveGraphRepository
+}
+
+func (r *noopGraphRepo) DelGraph(ctx context.Context, namespaces []types.NameSpace) error {
+	return nil
+}
+
+func TestCloneKnowledgeCopiesFile(t *testing.T) {
+	// No retrieval engine is configured, so indices are neither copied nor deleted
+	t.Setenv("RETRIEVE_DRIVER", "")
+	files := &memoryFileService{files: map[string][]byte{}}
+	srcPath, _ := files.SaveBytes(context.Background(), []byte("content"), 1, "a.txt", false)
+	src := &types.Knowledge{
+		ID: "k-1", TenantID: 1, KnowledgeBaseID: "kb-src", ParseStatus: "completed",
+		FileName: "a.txt", FilePath: srcPath, StorageSize: 7,
+	}
+	srcKB := &types.KnowledgeBase{ID: "kb-src", TenantID: 1}
+	dstKB := &types.KnowledgeBase{ID: "kb-dst", TenantID: 2}
+	tenants := &storageTenantRepo{tenants: map[uint64]*types.Tenant{1: {ID: 1}, 2: {ID: 2}}}
+	repo := &memoryKnowledgeRepo{knowledge: map[string]*types.Knowledge{src.ID: src}}
+	s := &knowledgeService{
+		repo:         repo,
+		tenantRepo:   tenants,
+		chunkRepo:    &emptyChunkRepo{},
+		fileSvc:      files,
+		kbService:    &fixedKBService{kb: dstKB},
+		modelService: &fixedEmbeddingModelService{},
+		chunkService: &noopChunkService{},
+		graphEngine:  &noopGraphRepo{},
+	}
+	ctx := context.WithValue(context.Background(), types.TenantIDContextKey, uint64(2))
+	ctx = context.WithValue(ctx, types.TenantInfoContextKey, &types.Tenant{ID: 2})
+
+	if err := s.cloneKnowledge(ctx, src, srcKB, dstKB); err != nil {
+		t.Fatalf("cloneKnowledge() error = %v", err)
+	}
+	var dst *types.Knowledge
+	for _, k := range repo.knowledge {
+		if k.KnowledgeBaseID == dstKB.ID {
+			dst = k
+		}
+	}
+	if dst == nil {
+		t.Fatal("no copy created")
+	}
+	if dst.FilePath == "" || dst.FilePath == src.FilePath {
+		t.Fatalf("copy file path = %q, want a path of its own", dst.FilePath)
+	}
+	if got := tenants.tenants[2].StorageUsed; got != src.StorageSize {
+		t.Errorf("target StorageUsed = %d, want %d", got, src.StorageSize)
+	}
+
+	if err := s.DeleteKnowledge(ctx, dst.ID); err != nil {
+		t.Fatalf("DeleteKnowledge() error = %v", err)
+	}
+	if _, ok := files.files[dst.FilePath]; ok {
+		t.Error("file of the copy not deleted")
+	}
+	if _, ok := files.files[src.FilePath]; !ok {
+		t.Error("source file deleted together with the copy")
+	}
+}
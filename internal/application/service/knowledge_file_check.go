@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+)
+
+// sniffedTypesByExtension lists the content types http.DetectContentType reports for each supported
+// file type. Legacy Office files (doc/xls/ppt) are not recognized by the sniffer and are not checked.
+var sniffedTypesByExtension = map[string][]string{
+	"pdf":      {"application/pdf"},
+	"png":      {"image/png"},
+	"jpg":      {"image/jpeg"},
+	"jpeg":     {"image/jpeg"},
+	"gif":      {"image/gif"},
+	"docx":     {"application/zip"},
+	"xlsx":     {"application/zip"},
+	"pptx":     {"application/zip"},
+	"txt":      {"text/plain"},
+	"md":       {"text/plain"},
+	"markdown": {"text/plain"},
+	"csv":      {"text/plain"},
+}
+
+// ValidateFileUpload checks a file against the rules CreateKnowledgeFromFile applies: the file type,
+// the maximum file size and, for images, the storage and VLM configuration of the knowledge base.
+// When the head of the file is given, its sniffed content type must match the file extension.
+func (s *knowledgeService) ValidateFileUpload(ctx context.Context,
+	kbID string, req *types.FileUploadCheckRequest,
+) (*types.FileUploadCheckResult, error) {
+	if req.FileSize < 0 {
+		return nil, werrors.NewBadRequestError("file_size must not be negative")
+	}
+	kb, err := s.kbService.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to get knowledge base: %v", err)
+		return nil, err
+	}
+
+	fileType := strings.ToLower(getFileType(req.FileName))
+	result := &types.FileUploadCheckResult{
+		FileType:    fileType,
+		MaxFileSize: secutils.GetMaxFileSize(),
+		Errors:      []string{},
+	}
+
+	if !isValidFileType(req.FileName) {
+		result.Errors = append(result.Errors, fmt.Sprintf("unsupported file type: %s", fileType))
+	}
+
+	if req.FileSize > result.MaxFileSize {
+		result.ExceedsMaxSize = true
+		result.Errors = append(result.Errors,
+			fmt.Sprintf("file size exceeds the limit of %dMB", secutils.GetMaxFileSizeMB()))
+	}
+
+	if len(req.Head) > 0 {
+		result.DetectedMimeType = baseMimeType(http.DetectContentType(req.Head))
+		if expected, ok := sniffedTypesByExtension[fileType]; ok && !slices.Contains(expected, result.DetectedMimeType) {
+			result.Errors = append(result.Errors,
+				fmt.Sprintf("file content looks like %s, not a .%s file", result.DetectedMimeType, fileType))
+		}
+	} else if req.MimeType != "" {
+		result.DetectedMimeType = baseMimeType(req.MimeType)
+	}
+
+	if IsImageType(fileType) {
+		if err := checkImageUploadConfig(ctx, kb); err != nil {
+			if appErr, ok := werrors.IsAppError(err); ok {
+				result.Errors = append(result.Errors, appErr.Message)
+			} else {
+				result.Errors = append(result.Errors, err.Error())
+			}
+		}
+	}
+
+	result.Supported = len(result.Errors) == 0
+	logger.Infof(ctx, "Validated file upload %s for knowledge base %s: supported=%v, errors=%d",
+		secutils.SanitizeForLog(req.FileName), kbID, result.Supported, len(result.Errors))
+	return result, nil
+}
+
+// baseMimeType strips parameters such as charset from a content type
+func baseMimeType(contentType string) string {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		return mediaType
+	}
+	return strings.TrimSpace(strings.ToLower(contentType))
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestSniffedTypesByExtension(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileType string
+		head     []byte
+		match    bool
+	}{
+		{name: "pdf", fileType: "pdf", head: []byte("%PDF-1.7\n"), match: true},
+		{name: "png", fileType: "png", head: []byte("\x89PNG\r\n\x1a\n"), match: true},
+		{name: "docx is a zip archive", fileType: "docx", head: []byte("PK\x03\x04"), match: true},
+		{name: "markdown text", fileType: "md", head: []byte("# Title\n\nSome text"), match: true},
+		{name: "pdf renamed to txt", fileType: "txt", head: []byte("%PDF-1.7\n"), match: false},
+		{name: "text renamed to pdf", fileType: "pdf", head: []byte("plain text"), match: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detected := baseMimeType(http.DetectContentType(tt.head))
+			expected := sniffedTypesByExtension[tt.fileType]
+			match := false
+			for _, e := range expected {
+				if e == detected {
+					match = true
+				}
+			}
+			if match != tt.match {
+				t.Errorf("detected %q for .%s, match = %v, want %v", detected, tt.fileType, match, tt.match)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+type statusKnowledgeRepo struct {
+	interfaces.KnowledgeRepository
+	status string
+}
+
+func (r *statusKnowledgeRepo) GetKnowledgeByID(ctx context.Context,
+	tenantID uint64, id string,
+) (*types.Knowledge, error) {
+	return &types.Knowledge{ID: id, KnowledgeBaseID: "kb-1", ParseStatus: r.status}, nil
+}
+
+// errReparseStarted stops the reparse at its first step so tests can tell it was reached
+var errReparseStarted = errors.New("reparse started")
+
+type reparseStartKBService struct {
+	interfaces.KnowledgeBaseService
+}
+
+func (s *reparseStartKBService) GetKnowledgeBaseByID(ctx context.Context, id string) (*types.KnowledgeBase, error) {
+	return nil, errReparseStarted
+}
+
+func TestRetryProcessingRequiresFailedStatus(t *testing.T) {
+	ctx := context.WithValue(context.Background(), types.TenantIDContextKey, uint64(1))
+	tests := []struct {
+		status      string
+		wantReparse bool
+	}{
+		{types.ParseStatusFailed, true},
+		{types.ParseStatusCompleted, false},
+		{types.ParseStatusProcessing, false},
+		{types.ParseStatusPending, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			s := &knowledgeService{repo: &statusKnowledgeRepo{status: tt.status}, kbService: &reparseStartKBService{}}
+
+			_, err := s.RetryProcessing(ctx, "k-1")
+			if tt.wantReparse {
+				if !errors.Is(err, errReparseStarted) {
+					t.Fatalf("RetryProcessing() error = %v, want reparse to start", err)
+				}
+				return
+			}
+			if appErr, ok := werrors.IsAppError(err); !ok || appErr.Code != werrors.ErrBadRequest {
+				t.Errorf("RetryProcessing() error = %v, want bad request", err)
+			}
+		})
+	}
+}
@@ -4,17 +4,22 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"slices"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/Tencent/WeKnora/internal/application/service/retriever"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/models/embedding"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 	"github.com/google/uuid"
 	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
 )
 
 // ErrInvalidTenantID represents an error for invalid tenant ID
@@ -33,6 +38,9 @@ type knowledgeBaseService struct {
 	fileSvc        interfaces.FileService
 	graphEngine    interfaces.RetrieveGraphRepository
 	asynqClient    interfaces.TaskEnqueuer
+	redisClient    *redis.Client
+	// keywordIndexProgress holds keyword index rebuild progress when Redis is unavailable (Lite mode)
+	keywordIndexProgress sync.Map
 }
 
 // NewKnowledgeBaseService creates a new knowledge base service
@@ -47,6 +55,7 @@ func NewKnowledgeBaseService(repo interfaces.KnowledgeBaseRepository,
 	fileSvc interfaces.FileService,
 	graphEngine interfaces.RetrieveGraphRepository,
 	asynqClient interfaces.TaskEnqueuer,
+	redisClient *redis.Client,
 ) interfaces.KnowledgeBaseService {
 	return &knowledgeBaseService{
 		repo:           repo,
@@ -60,6 +69,7 @@ func NewKnowledgeBaseService(repo interfaces.KnowledgeBaseRepository,
 		fileSvc:        fileSvc,
 		graphEngine:    graphEngine,
 		asynqClient:    asynqClient,
+		redisClient:    redisClient,
 	}
 }
 
@@ -265,6 +275,49 @@ func (s *knowledgeBaseService) FillKnowledgeBaseCounts(ctx context.Context, kb *
 	return nil
 }
 
+// GetKnowledgeBaseStats returns aggregate statistics of a knowledge base. All queries use kb.TenantID,
+// so shared knowledge bases are aggregated in their owner's tenant. A non-nil knowledgeIDs limits the
+// statistics to those knowledge items.
+func (s *knowledgeBaseService) GetKnowledgeBaseStats(ctx context.Context,
+	kbID string, knowledgeIDs []string,
+) (*types.KnowledgeBaseStats, error) {
+	kb, err := s.repo.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	tenantID := kb.TenantID
+
+	stats := &types.KnowledgeBaseStats{
+		KnowledgeBaseID: kb.ID,
+		LastUpdatedAt:   kb.UpdatedAt,
+	}
+	knowledgeAgg, err := s.kgRepo.AggregateKnowledgeByKnowledgeBaseID(ctx, tenantID, kb.ID, knowledgeIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate knowledge: %w", err)
+	}
+	stats.KnowledgeCount = knowledgeAgg.Count
+	stats.TotalFileSize = knowledgeAgg.TotalFileSize
+	stats.FileTypes = knowledgeAgg.FileTypes
+	stats.ParseStatuses = knowledgeAgg.ParseStatuses
+	if knowledgeAgg.LastUpdatedAt != nil && knowledgeAgg.LastUpdatedAt.After(stats.LastUpdatedAt) {
+		stats.LastUpdatedAt = *knowledgeAgg.LastUpdatedAt
+	}
+
+	chunkAgg, err := s.chunkRepo.AggregateChunksByKnowledgeBaseID(ctx, tenantID, kb.ID, knowledgeIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate chunks: %w", err)
+	}
+	stats.ChunkCount = chunkAgg.Count
+	stats.AvgChunkSize = chunkAgg.AvgSize
+	stats.IndexableChunkCount = chunkAgg.IndexableCount
+	stats.IndexedChunkCount = chunkAgg.IndexedCount
+	stats.EmbeddingCoverage = 1
+	if chunkAgg.IndexableCount > 0 {
+		stats.EmbeddingCoverage = float64(chunkAgg.IndexedCount) / float64(chunkAgg.IndexableCount)
+	}
+	return stats, nil
+}
+
 // UpdateKnowledgeBase updates a knowledge base's properties
 func (s *knowledgeBaseService) UpdateKnowledgeBase(ctx context.Context,
 	id string,
@@ -288,6 +341,21 @@ func (s *knowledgeBaseService) UpdateKnowledgeBase(ctx context.Context,
 		return nil, err
 	}
 
+	// Kept to undo the update if the reindex can't be scheduled
+	previous := *kb
+	reindex := false
+
+	// Switching the embedding model must not leave vectors of another model behind
+	if config.EmbeddingModelID != "" && config.EmbeddingModelID != kb.EmbeddingModelID {
+		if err := s.checkEmbeddingModelChange(ctx, kb, config.EmbeddingModelID, config.Reindex); err != nil {
+			return nil, err
+		}
+		logger.Infof(ctx, "Changing embedding model of knowledge base %s from %s to %s, reindex: %v",
+			id, kb.EmbeddingModelID, config.EmbeddingModelID, config.Reindex)
+		kb.EmbeddingModelID = config.EmbeddingModelID
+		reindex = config.Reindex
+	}
+
 	// Update the knowledge base properties
 	kb.Name = name
 	kb.Description = description
@@ -297,6 +365,12 @@ func (s *knowledgeBaseService) UpdateKnowledgeBase(ctx context.Context,
 	if config.FAQConfig != nil {
 		kb.FAQConfig = config.FAQConfig
 	}
+	if config.DuplicateScope != "" {
+		kb.DuplicateScope = config.DuplicateScope
+	}
+	if config.FallbackResponse != nil {
+		kb.FallbackResponse = strings.TrimSpace(*config.FallbackResponse)
+	}
 	kb.UpdatedAt = time.Now()
 	kb.EnsureDefaults()
 
@@ -308,10 +382,84 @@ func (s *knowledgeBaseService) UpdateKnowledgeBase(ctx context.Context,
 		return nil, err
 	}
 
+	if reindex {
+		if err := s.enqueueKBReindex(ctx, kb); err != nil {
+			// Without the reindex the knowledge would keep the vectors of the previous model
+			logger.Errorf(ctx, "Failed to enqueue reindex of knowledge base %s, reverting the update: %v", kb.ID, err)
+			if err := s.repo.UpdateKnowledgeBase(ctx, &previous); err != nil {
+				logger.Errorf(ctx, "Failed to revert update of knowledge base %s: %v", kb.ID, err)
+			}
+			return nil, werrors.NewInternalServerError("Failed to schedule the reindex, the knowledge base was not updated")
+		}
+	}
+
 	logger.Infof(ctx, "Knowledge base updated successfully, ID: %s, name: %s", kb.ID, kb.Name)
 	return kb, nil
 }
 
+// enqueueKBReindex enqueues a task re-parsing all knowledge of a knowledge base so its vectors are
+// rebuilt with its current embedding model
+func (s *knowledgeBaseService) enqueueKBReindex(ctx context.Context, kb *types.KnowledgeBase) error {
+	payloadBytes, err := json.Marshal(types.KBReindexPayload{TenantID: kb.TenantID, KnowledgeBaseID: kb.ID})
+	if err != nil {
+		return err
+	}
+
+	task := asynq.NewTask(types.TypeKBReindex, payloadBytes, asynq.Queue("low"), asynq.MaxRetry(3))
+	info, err := s.asynqClient.Enqueue(task)
+	if err != nil {
+		return err
+	}
+	logger.Infof(ctx, "KB reindex task enqueued: %s, knowledge base ID: %s", info.ID, kb.ID)
+	return nil
+}
+
+// checkEmbeddingModelChange validates switching a knowledge base to another embedding model.
+// Existing vectors can only be searched with the model that produced them, so any change is
+// rejected while the knowledge base has content, unless a reindex is requested. A change of
+// dimension, including an unknown one, is reported as such.
+func (s *knowledgeBaseService) checkEmbeddingModelChange(ctx context.Context,
+	kb *types.KnowledgeBase, modelID string, reindex bool,
+) error {
+	model, err := s.modelService.GetModelByID(ctx, modelID)
+	if err != nil || model == nil {
+		return werrors.NewBadRequestError("Embedding model not found")
+	}
+	if model.Type != types.ModelTypeEmbedding {
+		return werrors.NewBadRequestError("Model is not an embedding model")
+	}
+	if reindex {
+		// FAQ entries are indexed from their questions, not re-parsed from a source
+		if kb.Type == types.KnowledgeBaseTypeFAQ {
+			return werrors.NewBadRequestError("FAQ knowledge bases cannot be reindexed with another embedding model")
+		}
+		return nil
+	}
+	if kb.EmbeddingModelID == "" {
+		return nil
+	}
+
+	count, err := s.kgRepo.CountKnowledgeByKnowledgeBaseID(ctx, kb.TenantID, kb.ID)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return nil
+	}
+
+	currentDimension := 0
+	if current, err := s.modelService.GetModelByID(ctx, kb.EmbeddingModelID); err == nil && current != nil {
+		currentDimension = current.Parameters.EmbeddingParameters.Dimension
+	}
+	newDimension := model.Parameters.EmbeddingParameters.Dimension
+	logger.Warnf(ctx, "Rejecting embedding model change of knowledge base %s without reindex: dimension %d -> %d",
+		kb.ID, currentDimension, newDimension)
+	if currentDimension == 0 || newDimension == 0 || currentDimension != newDimension {
+		return werrors.NewEmbeddingDimensionMismatchError(currentDimension, newDimension)
+	}
+	return werrors.NewEmbeddingReindexRequiredError()
+}
+
 // TogglePinKnowledgeBase toggles the pin status of a knowledge base
 func (s *knowledgeBaseService) TogglePinKnowledgeBase(ctx context.Context, id string) (*types.KnowledgeBase, error) {
 	if id == "" {
@@ -329,6 +477,27 @@ func (s *knowledgeBaseService) TogglePinKnowledgeBase(ctx context.Context, id st
 	return kb, nil
 }
 
+// SetShareLabel sets the label matched by dynamic share rules on a knowledge base of the current
+// tenant. Only the owning tenant can label its knowledge bases, so members of organizations it
+// shares with can't pull more knowledge bases into a rule.
+func (s *knowledgeBaseService) SetShareLabel(ctx context.Context, id string, label string) (*types.KnowledgeBase, error) {
+	if id == "" {
+		return nil, errors.New("knowledge base ID cannot be empty")
+	}
+	label = strings.TrimSpace(label)
+	if utf8.RuneCountInString(label) > types.MaxShareLabelLength {
+		return nil, werrors.NewBadRequestError(
+			fmt.Sprintf("share_label must be at most %d characters", types.MaxShareLabelLength))
+	}
+	tenantID := types.MustTenantIDFromContext(ctx)
+	kb, err := s.repo.SetShareLabel(ctx, id, tenantID, label)
+	if err != nil {
+		return nil, err
+	}
+	logger.Infof(ctx, "Knowledge base share label set, ID: %s", id)
+	return kb, nil
+}
+
 // DeleteKnowledgeBase deletes a knowledge base by its ID
 // This method marks the knowledge base as deleted and enqueues an async task
 // to handle the heavy cleanup operations (embeddings, chunks, files, graph data)
@@ -743,6 +912,9 @@ func (s *knowledgeBaseService) HybridSearch(ctx context.Context,
 		logger.Info(ctx, "No search results found")
 		return nil, nil
 	}
+	// Capture raw retriever scores before fusion overwrites them
+	vectorScores := bestScoresByChunk(vectorResults)
+	keywordScores := bestScoresByChunk(keywordResults)
 	logger.Infof(ctx, "Result count before fusion: vector=%d, keyword=%d", len(vectorResults), len(keywordResults))
 
 	var deduplicatedChunks []*types.IndexWithScore
@@ -879,7 +1051,44 @@ func (s *knowledgeBaseService) HybridSearch(ctx context.Context,
 		deduplicatedChunks = deduplicatedChunks[:params.MatchCount]
 	}
 
-	return s.processSearchResults(ctx, deduplicatedChunks, params.SkipContextEnrichment)
+	results, err := s.processSearchResults(ctx, deduplicatedChunks, params.SkipContextEnrichment, params.IncludeMetadata)
+	if err != nil {
+		return nil, err
+	}
+	annotateRetrievalScores(results, vectorScores, keywordScores, params)
+	return results, nil
+}
+
+// bestScoresByChunk returns the highest score of each chunk
+func bestScoresByChunk(results []*types.IndexWithScore) map[string]float64 {
+	scores := make(map[string]float64, len(results))
+	for _, r := range results {
+		if existing, ok := scores[r.ChunkID]; !ok || r.Score > existing {
+			scores[r.ChunkID] = r.Score
+		}
+	}
+	return scores
+}
+
+// annotateRetrievalScores fills the raw retriever scores and the threshold each result passed.
+// Results that were not direct hits (parent, nearby or related chunks) are left untouched.
+func annotateRetrievalScores(
+	results []*types.SearchResult, vectorScores, keywordScores map[string]float64, params types.SearchParams,
+) {
+	for _, r := range results {
+		if score, ok := vectorScores[r.ID]; ok {
+			r.VectorScore = &score
+			threshold := params.VectorThreshold
+			r.ThresholdApplied = &threshold
+		}
+		if score, ok := keywordScores[r.ID]; ok {
+			r.KeywordScore = &score
+			if r.ThresholdApplied == nil {
+				threshold := params.KeywordThreshold
+				r.ThresholdApplied = &threshold
+			}
+		}
+	}
 }
 
 // iterativeRetrieveWithDeduplication performs iterative retrieval until enough unique chunks are found
@@ -1122,6 +1331,7 @@ func (s *knowledgeBaseService) matchesNegativeQuestions(queryTextLower string, n
 func (s *knowledgeBaseService) processSearchResults(ctx context.Context,
 	chunks []*types.IndexWithScore,
 	skipEnrichment bool,
+	includeMetadata []string,
 ) ([]*types.SearchResult, error) {
 	if len(chunks) == 0 {
 		return nil, nil
@@ -1283,6 +1493,9 @@ func (s *knowledgeBaseService) processSearchResults(ctx context.Context,
 			}
 		}
 	}
+	if len(includeMetadata) > 0 {
+		types.AttachSearchResultDocuments(searchResults, knowledgeMap, includeMetadata)
+	}
 	logger.Infof(ctx, "Search results processed, total: %d", len(searchResults))
 	return searchResults, nil
 }
@@ -1331,6 +1544,7 @@ func (s *knowledgeBaseService) buildSearchResult(chunk *types.Chunk,
 		KnowledgeSource:   knowledge.Source,
 		ChunkMetadata:     chunk.Metadata,
 		MatchedContent:    matchedContent,
+		RetrievalBoost:    knowledge.EffectiveRetrievalBoost(),
 	}
 }
 
@@ -1414,7 +1628,7 @@ func (s *knowledgeBaseService) fetchKnowledgeDataWithShared(ctx context.Context,
 			logger.Debugf(ctx, "[fetchKnowledgeDataWithShared] Knowledge %s not found or has no KB", id)
 			continue
 		}
-		hasPermission, err := s.kbShareService.HasKBPermission(ctx, k.KnowledgeBaseID, userID, types.OrgRoleViewer)
+		hasPermission, err := s.kbShareService.HasKnowledgePermission(ctx, k.KnowledgeBaseID, k.ID, userID, types.OrgRoleViewer)
 		if err != nil {
 			logger.Debugf(ctx, "[fetchKnowledgeDataWithShared] Permission check error for KB %s: %v", k.KnowledgeBaseID, err)
 			continue
@@ -1482,7 +1696,7 @@ func (s *knowledgeBaseService) listChunksByIDWithShared(ctx context.Context,
 		if c == nil || c.KnowledgeBaseID == "" {
 			continue
 		}
-		hasPermission, err := s.kbShareService.HasKBPermission(ctx, c.KnowledgeBaseID, userID, types.OrgRoleViewer)
+		hasPermission, err := s.kbShareService.HasKnowledgePermission(ctx, c.KnowledgeBaseID, c.KnowledgeID, userID, types.OrgRoleViewer)
 		if err != nil {
 			logger.Debugf(ctx, "[listChunksByIDWithShared] Permission check error for KB %s: %v", c.KnowledgeBaseID, err)
 			continue
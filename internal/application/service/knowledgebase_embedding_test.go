@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/hibiken/asynq"
+)
+
+// embeddingModelService serves embedding models with fixed dimensions
+type embeddingModelService struct {
+	interfaces.ModelService
+	dimensions map[string]int
+}
+
+func (s *embeddingModelService) GetModelByID(ctx context.Context, id string) (*types.Model, error) {
+	dimension, ok := s.dimensions[id]
+	if !ok {
+		return nil, nil
+	}
+	model := &types.Model{ID: id, Type: types.ModelTypeEmbedding}
+	model.Parameters.EmbeddingParameters.Dimension = dimension
+	return model, nil
+}
+
+// countKnowledgeRepo reports a fixed number of knowledge per knowledge base
+type countKnowledgeRepo struct {
+	interfaces.KnowledgeRepository
+	count int64
+}
+
+func (r *countKnowledgeRepo) CountKnowledgeByKnowledgeBaseID(ctx context.Context,
+	tenantID uint64, kbID string,
+) (int64, error) {
+	return r.count, nil
+}
+
+func TestCheckEmbeddingModelChange(t *testing.T) {
+	models := &embeddingModelService{dimensions: map[string]int{
+		"m-768": 768, "m-768-b": 768, "m-1024": 1024, "m-unknown": 0,
+	}}
+	tests := []struct {
+		name         string
+		kbType       string
+		knowledge    int64
+		model        string
+		reindex      bool
+		wantMismatch bool
+		wantErr      bool
+	}{
+		{"same dimension", types.KnowledgeBaseTypeDocument, 3, "m-768-b", false, false, true},
+		{"same dimension with reindex", types.KnowledgeBaseTypeDocument, 3, "m-768-b", true, false, false},
+		{"same dimension without content", types.KnowledgeBaseTypeDocument, 0, "m-768-b", false, false, false},
+		{"other dimension", types.KnowledgeBaseTypeDocument, 3, "m-1024", false, true, true},
+		{"unknown dimension", types.KnowledgeBaseTypeDocument, 3, "m-unknown", false, true, true},
+		{"other dimension with reindex", types.KnowledgeBaseTypeDocument, 3, "m-1024", true, false, false},
+		{"other dimension without content", types.KnowledgeBaseTypeDocument, 0, "m-1024", false, false, false},
+		{"missing model", types.KnowledgeBaseTypeDocument, 3, "m-missing", true, false, true},
+		{"faq reindex", types.KnowledgeBaseTypeFAQ, 3, "m-1024", true, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &knowledgeBaseService{modelService: models, kgRepo: &countKnowledgeRepo{count: tt.knowledge}}
+			kb := &types.KnowledgeBase{ID: "kb-1", TenantID: 1, Type: tt.kbType, EmbeddingModelID: "m-768"}
+
+			err := s.checkEmbeddingModelChange(context.Background(), kb, tt.model, tt.reindex)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkEmbeddingModelChange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			appErr, ok := werrors.IsAppError(err)
+			if mismatch := ok && appErr.Code == werrors.ErrEmbeddingDimensionMismatch; mismatch != tt.wantMismatch {
+				t.Errorf("checkEmbeddingModelChange() error = %v, want dimension mismatch %v", err, tt.wantMismatch)
+			}
+		})
+	}
+}
+
+// savingKBRepo serves one knowledge base and records every saved version of it
+type savingKBRepo struct {
+	interfaces.KnowledgeBaseRepository
+	kb    *types.KnowledgeBase
+	saved []types.KnowledgeBase
+}
+
+func (r *savingKBRepo) GetKnowledgeBaseByID(ctx context.Context, id string) (*types.KnowledgeBase, error) {
+	kb := *r.kb
+	return &kb, nil
+}
+
+func (r *savingKBRepo) UpdateKnowledgeBase(ctx context.Context, kb *types.KnowledgeBase) error {
+	r.saved = append(r.saved, *kb)
+	return nil
+}
+
+// enqueuer records the enqueued tasks, or fails every enqueue with err
+type enqueuer struct {
+	tasks []*asynq.Task
+	err   error
+}
+
+func (e *enqueuer) Enqueue(task *asynq.Task, opts ...asynq.Option) (*asynq.TaskInfo, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	e.tasks = append(e.tasks, task)
+	return &asynq.TaskInfo{ID: "task-1"}, nil
+}
+
+func TestUpdateKnowledgeBaseReindex(t *testing.T) {
+	ctx := context.Background()
+	models := &embeddingModelService{dimensions: map[string]int{"m-768": 768, "m-1024": 1024}}
+	config := &types.KnowledgeBaseConfig{EmbeddingModelID: "m-1024", Reindex: true}
+	newKB := func() *types.KnowledgeBase {
+		return &types.KnowledgeBase{ID: "kb-1", TenantID: 7, Name: "docs", Type: types.KnowledgeBaseTypeDocument, EmbeddingModelID: "m-768"}
+	}
+
+	t.Run("enqueued", func(t *testing.T) {
+		repo := &savingKBRepo{kb: newKB()}
+		tasks := &enqueuer{}
+		s := &knowledgeBaseService{repo: repo, modelService: models,
+			kgRepo: &countKnowledgeRepo{count: 3}, asynqClient: tasks}
+
+		kb, err := s.UpdateKnowledgeBase(ctx, "kb-1", "renamed", "", config)
+		if err != nil || kb.EmbeddingModelID != "m-1024" {
+			t.Fatalf("UpdateKnowledgeBase() = %+v, %v, want the new model", kb, err)
+		}
+		if len(tasks.tasks) != 1 || tasks.tasks[0].Type() != types.TypeKBReindex {
+			t.Fatalf("enqueued %d tasks, want one %s task", len(tasks.tasks), types.TypeKBReindex)
+		}
+		var payload types.KBReindexPayload
+		if err := json.Unmarshal(tasks.tasks[0].Payload(), &payload); err != nil {
+			t.Fatalf("unmarshal payload: %v", err)
+		}
+		if payload.TenantID != 7 || payload.KnowledgeBaseID != "kb-1" {
+			t.Errorf("payload = %+v, want the knowledge base of tenant 7", payload)
+		}
+	})
+
+	t.Run("enqueue fails", func(t *testing.T) {
+		repo := &savingKBRepo{kb: newKB()}
+		s := &knowledgeBaseService{repo: repo, modelService: models,
+			kgRepo: &countKnowledgeRepo{count: 3}, asynqClient: &enqueuer{err: errors.New("redis down")}}
+
+		if _, err := s.UpdateKnowledgeBase(ctx, "kb-1", "renamed", "", config); err == nil {
+			t.Fatal("UpdateKnowledgeBase() succeeded without scheduling the reindex")
+		}
+		last := repo.saved[len(repo.saved)-1]
+		if last.EmbeddingModelID != "m-768" || last.Name != "docs" {
+			t.Errorf("last saved knowledge base = %s with %s, want the update reverted", last.Name, last.EmbeddingModelID)
+		}
+	})
+}
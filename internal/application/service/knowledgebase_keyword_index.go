@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/application/service/retriever"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	kbKeywordIndexProgressKeyPrefix = "kb_keyword_index_progress:"
+	kbKeywordIndexProgressTTL       = 24 * time.Hour
+	// keywordIndexBatchSize is how many knowledge are re-indexed per engine call
+	keywordIndexBatchSize = 200
+)
+
+// getKBKeywordIndexProgressKey returns the Redis key for storing a KB's keyword index rebuild progress
+func getKBKeywordIndexProgressKey(kbID string) string {
+	return kbKeywordIndexProgressKeyPrefix + kbID
+}
+
+// RebuildKeywordIndex queues a rebuild of the keyword index of a knowledge base owned by the
+// current tenant. Only one rebuild per knowledge base runs at a time.
+func (s *knowledgeBaseService) RebuildKeywordIndex(ctx context.Context,
+	id string,
+) (*types.KBKeywordIndexProgress, error) {
+	tenantID := types.MustTenantIDFromContext(ctx)
+	kb, err := s.repo.GetKnowledgeBaseByID(ctx, id)
+	if err != nil || kb.TenantID != tenantID {
+		return nil, werrors.NewNotFoundError("Knowledge base not found")
+	}
+
+	if current, err := s.GetKeywordIndexProgress(ctx, id); err == nil && current.IsRunning() {
+		return nil, werrors.NewConflictError("Keyword index rebuild is already running for this knowledge base")
+	}
+
+	tenantInfo, _ := types.TenantInfoFromContext(ctx)
+	payload := types.KBKeywordIndexPayload{
+		TenantID:         tenantID,
+		KnowledgeBaseID:  id,
+		EffectiveEngines: tenantInfo.GetEffectiveEngines(),
+	}
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal keyword index payload: %w", err)
+	}
+
+	// Save the initial progress before enqueueing so the task never gets overwritten by it
+	now := time.Now().Unix()
+	progress := &types.KBKeywordIndexProgress{
+		KnowledgeBaseID: id,
+		Status:          types.KBCloneStatusPending,
+		Message:         "Task queued, waiting to start...",
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	if err := s.saveKeywordIndexProgress(ctx, progress); err != nil {
+		logger.Warnf(ctx, "Failed to save initial keyword index progress: %v", err)
+	}
+
+	task := asynq.NewTask(types.TypeKBKeywordIndex, payloadBytes, asynq.Queue("low"), asynq.MaxRetry(3))
+	info, err := s.asynqClient.Enqueue(task)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to enqueue keyword index rebuild task: %v", err)
+		progress.Status = types.KBCloneStatusFailed
+		progress.Error = err.Error()
+		progress.Message = "Failed to enqueue task"
+		_ = s.saveKeywordIndexProgress(ctx, progress)
+		return nil, werrors.NewInternalServerError("Failed to enqueue task")
+	}
+
+	logger.Infof(ctx, "Keyword index rebuild task enqueued: %s, knowledge base ID: %s", info.ID, id)
+	return progress, nil
+}
+
+// GetKeywordIndexProgress retrieves the progress of the latest keyword index rebuild of a knowledge base
+func (s *knowledgeBaseService) GetKeywordIndexProgress(ctx context.Context,
+	id string,
+) (*types.KBKeywordIndexProgress, error) {
+	if s.redisClient == nil {
+		if progress, ok := s.keywordIndexProgress.Load(id); ok {
+			copied := *progress.(*types.KBKeywordIndexProgress)
+			return &copied, nil
+		}
+		return nil, werrors.NewNotFoundError("Keyword index rebuild not found")
+	}
+
+	data, err := s.redisClient.Get(ctx, getKBKeywordIndexProgressKey(id)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, werrors.NewNotFoundError("Keyword index rebuild not found")
+		}
+		return nil, fmt.Errorf("failed to get progress from Redis: %w", err)
+	}
+
+	var progress types.KBKeywordIndexProgress
+	if err := json.Unmarshal(data, &progress); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal progress: %w", err)
+	}
+	return &progress, nil
+}
+
+// saveKeywordIndexProgress stores the rebuild progress in Redis, or in memory in Lite mode
+func (s *knowledgeBaseService) saveKeywordIndexProgress(ctx context.Context,
+	progress *types.KBKeywordIndexProgress,
+) error {
+	progress.UpdatedAt = time.Now().Unix()
+	if s.redisClient == nil {
+		copied := *progress
+		s.keywordIndexProgress.Store(progress.KnowledgeBaseID, &copied)
+		return nil
+	}
+
+	data, err := json.Marshal(progress)
+	if err != nil {
+		return fmt.Errorf("failed to marshal progress: %w", err)
+	}
+	return s.redisClient.Set(ctx, getKBKeywordIndexProgressKey(progress.KnowledgeBaseID),
+		data, kbKeywordIndexProgressTTL).Err()
+}
+
+// ProcessKeywordIndexRebuild handles the async keyword index rebuild task. The knowledge of the
+// knowledge base is re-indexed in batches so the progress can be followed.
+func (s *knowledgeBaseService) ProcessKeywordIndexRebuild(ctx context.Context, t *asynq.Task) error {
+	var payload types.KBKeywordIndexPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		logger.Errorf(ctx, "Failed to unmarshal keyword index payload: %v", err)
+		return err
+	}
+
+	tenantID := payload.TenantID
+	kbID := payload.KnowledgeBaseID
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, tenantID)
+
+	retryCount, _ := asynq.GetRetryCount(ctx)
+	maxRetry, _ := asynq.GetMaxRetry(ctx)
+	isLastRetry := retryCount >= maxRetry
+
+	logger.Infof(ctx, "Processing keyword index rebuild for knowledge base: %s, retry: %d/%d",
+		kbID, retryCount, maxRetry)
+
+	progress, err := s.GetKeywordIndexProgress(ctx, kbID)
+	if err != nil {
+		progress = &types.KBKeywordIndexProgress{KnowledgeBaseID: kbID, CreatedAt: time.Now().Unix()}
+	}
+	// Only mark the rebuild as failed once no retry is left
+	fail := func(err error, message string) error {
+		if isLastRetry {
+			progress.Status = types.KBCloneStatusFailed
+			progress.Error = err.Error()
+			progress.Message = message
+			_ = s.saveKeywordIndexProgress(ctx, progress)
+		}
+		return err
+	}
+
+	progress.Status = types.KBCloneStatusProcessing
+	progress.Progress = 0
+	progress.Processed = 0
+	progress.Error = ""
+	progress.Message = "Rebuilding keyword index..."
+	if err := s.saveKeywordIndexProgress(ctx, progress); err != nil {
+		logger.Errorf(ctx, "Failed to update keyword index progress: %v", err)
+	}
+
+	knowledgeList, err := s.kgRepo.ListKnowledgeByKnowledgeBaseID(ctx, tenantID, kbID)
+	if err != nil {
+		return fail(err, "Failed to list knowledge")
+	}
+	retrieveEngine, err := retriever.NewCompositeRetrieveEngine(s.retrieveEngine, payload.EffectiveEngines)
+	if err != nil {
+		return fail(err, "Failed to create retrieve engine")
+	}
+
+	knowledgeIDs := make([]string, 0, len(knowledgeList))
+	for _, knowledge := range knowledgeList {
+		knowledgeIDs = append(knowledgeIDs, knowledge.ID)
+	}
+	progress.Total = len(knowledgeIDs)
+
+	for start := 0; start < len(knowledgeIDs); start += keywordIndexBatchSize {
+		end := min(start+keywordIndexBatchSize, len(knowledgeIDs))
+		if err := retrieveEngine.RebuildKeywordIndex(ctx, knowledgeIDs[start:end]); err != nil {
+			logger.Errorf(ctx, "Failed to rebuild keyword index for knowledge base %s: %v", kbID, err)
+			return fail(err, "Failed to rebuild keyword index")
+		}
+		progress.Processed = end
+		progress.Progress = end * 100 / len(knowledgeIDs)
+		if err := s.saveKeywordIndexProgress(ctx, progress); err != nil {
+			logger.Warnf(ctx, "Failed to update keyword index progress: %v", err)
+		}
+	}
+
+	progress.Status = types.KBCloneStatusCompleted
+	progress.Progress = 100
+	progress.Message = "Keyword index rebuilt"
+	if err := s.saveKeywordIndexProgress(ctx, progress); err != nil {
+		logger.Warnf(ctx, "Failed to update keyword index progress: %v", err)
+	}
+	logger.Infof(ctx, "Keyword index rebuilt for knowledge base %s, knowledge: %d", kbID, len(knowledgeIDs))
+	return nil
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/hibiken/asynq"
+)
+
+// keywordIndexEngine records the knowledge batches it is asked to re-index
+type keywordIndexEngine struct {
+	interfaces.RetrieveEngineService
+	engineType types.RetrieverEngineType
+	batches    [][]string
+}
+
+func (e *keywordIndexEngine) EngineType() types.RetrieverEngineType { return e.engineType }
+
+func (e *keywordIndexEngine) Support() []types.RetrieverType {
+	return []types.RetrieverType{types.KeywordsRetrieverType, types.VectorRetrieverType}
+}
+
+func (e *keywordIndexEngine) RebuildKeywordIndex(ctx context.Context, knowledgeIDList []string) error {
+	e.batches = append(e.batches, append([]string(nil), knowledgeIDList...))
+	return nil
+}
+
+// keywordIndexRegistry serves a single retrieve engine
+type keywordIndexRegistry struct {
+	interfaces.RetrieveEngineRegistry
+	engine *keywordIndexEngine
+}
+
+func (r *keywordIndexRegistry) GetRetrieveEngineService(
+	engineType types.RetrieverEngineType,
+) (interfaces.RetrieveEngineService, error) {
+	if engineType != r.engine.engineType {
+		return nil, fmt.Errorf("engine %s not registered", engineType)
+	}
+	return r.engine, nil
+}
+
+// listKnowledgeRepo lists a fixed number of knowledge per knowledge base
+type listKnowledgeRepo struct {
+	interfaces.KnowledgeRepository
+	count int
+}
+
+func (r *listKnowledgeRepo) ListKnowledgeByKnowledgeBaseID(ctx context.Context,
+	tenantID uint64, kbID string,
+) ([]*types.Knowledge, error) {
+	knowledgeList := make([]*types.Knowledge, 0, r.count)
+	for i := 0; i < r.count; i++ {
+		knowledgeList = append(knowledgeList, &types.Knowledge{ID: fmt.Sprintf("k-%d", i)})
+	}
+	return knowledgeList, nil
+}
+
+func TestProcessKeywordIndexRebuild(t *testing.T) {
+	ctx := context.Background()
+	engine := &keywordIndexEngine{engineType: types.SQLiteRetrieverEngineType}
+	s := &knowledgeBaseService{
+		kgRepo:         &listKnowledgeRepo{count: 2*keywordIndexBatchSize + 1},
+		retrieveEngine: &keywordIndexRegistry{engine: engine},
+	}
+	payload, err := json.Marshal(types.KBKeywordIndexPayload{
+		TenantID:        1,
+		KnowledgeBaseID: "kb-1",
+		EffectiveEngines: []types.RetrieverEngineParams{
+			{RetrieverEngineType: types.SQLiteRetrieverEngineType, RetrieverType: types.KeywordsRetrieverType},
+		},
+	})
+	if err != nil {
+		t.Fatalf("marshal payload: %v", err)
+	}
+
+	if err := s.ProcessKeywordIndexRebuild(ctx, asynq.NewTask(types.TypeKBKeywordIndex, payload)); err != nil {
+		t.Fatalf("ProcessKeywordIndexRebuild() error = %v", err)
+	}
+
+	// Each engine call covers one batch of the knowledge base only
+	if len(engine.batches) != 3 {
+		t.Fatalf("engine called %d times, want 3", len(engine.batches))
+	}
+	seen := make(map[string]bool)
+	for i, batch := range engine.batches {
+		if len(batch) > keywordIndexBatchSize {
+			t.Errorf("batch %d holds %d knowledge, want at most %d", i, len(batch), keywordIndexBatchSize)
+		}
+		for _, id := range batch {
+			seen[id] = true
+		}
+	}
+	if len(seen) != 2*keywordIndexBatchSize+1 {
+		t.Errorf("re-indexed %d distinct knowledge, want %d", len(seen), 2*keywordIndexBatchSize+1)
+	}
+
+	progress, err := s.GetKeywordIndexProgress(ctx, "kb-1")
+	if err != nil {
+		t.Fatalf("GetKeywordIndexProgress() error = %v", err)
+	}
+	if progress.Status != types.KBCloneStatusCompleted || progress.Progress != 100 ||
+		progress.Processed != progress.Total || progress.Total != 2*keywordIndexBatchSize+1 {
+		t.Errorf("progress = %+v, want completed with every knowledge processed", progress)
+	}
+}
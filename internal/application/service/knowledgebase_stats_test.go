@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// statsKBRepo serves a single knowledge base
+type statsKBRepo struct {
+	interfaces.KnowledgeBaseRepository
+}
+
+func (r *statsKBRepo) GetKnowledgeBaseByID(ctx context.Context, id string) (*types.KnowledgeBase, error) {
+	return &types.KnowledgeBase{ID: id, TenantID: 7}, nil
+}
+
+// statsKnowledgeRepo reports one knowledge item per requested ID, or three for the whole KB
+type statsKnowledgeRepo struct {
+	interfaces.KnowledgeRepository
+	knowledgeIDs []string
+}
+
+func (r *statsKnowledgeRepo) AggregateKnowledgeByKnowledgeBaseID(ctx context.Context,
+	tenantID uint64, kbID string, knowledgeIDs []string,
+) (*types.KnowledgeAggregates, error) {
+	r.knowledgeIDs = knowledgeIDs
+	count := int64(3)
+	if knowledgeIDs != nil {
+		count = int64(len(knowledgeIDs))
+	}
+	return &types.KnowledgeAggregates{Count: count, FileTypes: map[string]int64{"pdf": count}}, nil
+}
+
+// statsChunkRepo reports ten chunks per knowledge item
+type statsChunkRepo struct {
+	interfaces.ChunkRepository
+	knowledgeIDs []string
+}
+
+func (r *statsChunkRepo) AggregateChunksByKnowledgeBaseID(ctx context.Context,
+	tenantID uint64, kbID string, knowledgeIDs []string,
+) (*types.ChunkAggregates, error) {
+	r.knowledgeIDs = knowledgeIDs
+	count := int64(30)
+	if knowledgeIDs != nil {
+		count = int64(10 * len(knowledgeIDs))
+	}
+	return &types.ChunkAggregates{Count: count, IndexableCount: count, IndexedCount: count / 2}, nil
+}
+
+func TestGetKnowledgeBaseStatsScope(t *testing.T) {
+	tests := []struct {
+		name         string
+		knowledgeIDs []string
+		wantCount    int64
+		wantChunks   int64
+	}{
+		{"whole knowledge base", nil, 3, 30},
+		{"partial share", []string{"k1"}, 1, 10},
+		{"partial share without documents", []string{}, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kgRepo, chunkRepo := &statsKnowledgeRepo{}, &statsChunkRepo{}
+			s := &knowledgeBaseService{repo: &statsKBRepo{}, kgRepo: kgRepo, chunkRepo: chunkRepo}
+
+			stats, err := s.GetKnowledgeBaseStats(context.Background(), "kb-1", tt.knowledgeIDs)
+			if err != nil {
+				t.Fatalf("GetKnowledgeBaseStats() error = %v", err)
+			}
+			if stats.KnowledgeCount != tt.wantCount || stats.ChunkCount != tt.wantChunks {
+				t.Errorf("counts = %d knowledge, %d chunks, want %d, %d",
+					stats.KnowledgeCount, stats.ChunkCount, tt.wantCount, tt.wantChunks)
+			}
+			if (kgRepo.knowledgeIDs == nil) != (tt.knowledgeIDs == nil) || !slices.Equal(kgRepo.knowledgeIDs, tt.knowledgeIDs) ||
+				(chunkRepo.knowledgeIDs == nil) != (tt.knowledgeIDs == nil) {
+				t.Errorf("aggregations scoped to %v / %v, want %v", kgRepo.knowledgeIDs, chunkRepo.knowledgeIDs, tt.knowledgeIDs)
+			}
+		})
+	}
+}
@@ -164,7 +164,7 @@ func (s *smartCompressionStrategy) Compress(
 	result = append(result, systemMessages...)
 	result = append(result, chat.Message{
 		Role:    "system",
-		Content: fmt.Sprintf("Previous conversation summary:\n%s", summary),
+		Content: SummaryMessagePrefix + summary,
 	})
 	result = append(result, recentMessages...)
 
@@ -0,0 +1,59 @@
+package llmcontext
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/models/chat"
+)
+
+// TestContextManager_ConcurrentSessionsSameAgent runs two sessions of the same agent
+// (shared storage, strategy and system prompt) concurrently and checks that no message
+// written for one session shows up in the other's context.
+func TestContextManager_ConcurrentSessionsSameAgent(t *testing.T) {
+	ctx := context.Background()
+	storage := NewMemoryStorage()
+	sessions := []string{"session-a", "session-b"}
+	const turns = 50
+
+	var wg sync.WaitGroup
+	for _, sessionID := range sessions {
+		wg.Add(1)
+		go func(sessionID string) {
+			defer wg.Done()
+			// Each request builds its own manager from the agent's config, as AgentQA does
+			cm := NewContextManager(storage, NewSlidingWindowStrategy(DefaultRecentMessageCount*10), DefaultMaxTokens)
+			if err := cm.SetSystemPrompt(ctx, sessionID, "agent system prompt"); err != nil {
+				t.Errorf("SetSystemPrompt(%s) error = %v", sessionID, err)
+				return
+			}
+			for i := 0; i < turns; i++ {
+				msg := chat.Message{Role: "assistant", Content: fmt.Sprintf("%s turn %d", sessionID, i)}
+				if err := cm.AddMessage(ctx, sessionID, msg); err != nil {
+					t.Errorf("AddMessage(%s) error = %v", sessionID, err)
+					return
+				}
+			}
+		}(sessionID)
+	}
+	wg.Wait()
+
+	cm := NewContextManager(storage, NewSlidingWindowStrategy(DefaultRecentMessageCount*10), DefaultMaxTokens)
+	for _, sessionID := range sessions {
+		messages, err := cm.GetContext(ctx, sessionID)
+		if err != nil {
+			t.Fatalf("GetContext(%s) error = %v", sessionID, err)
+		}
+		if len(messages) != turns+1 {
+			t.Errorf("session %s has %d messages, want %d", sessionID, len(messages), turns+1)
+		}
+		for _, msg := range messages[1:] {
+			if !strings.HasPrefix(msg.Content, sessionID+" ") {
+				t.Errorf("session %s context contains foreign message %q", sessionID, msg.Content)
+			}
+		}
+	}
+}
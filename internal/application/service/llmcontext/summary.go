@@ -0,0 +1,52 @@
+package llmcontext
+
+import (
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/models/chat"
+)
+
+// SummaryMessagePrefix marks the system message that carries the compressed conversation summary
+const SummaryMessagePrefix = "Previous conversation summary:\n"
+
+// FindSummary returns the stored conversation summary and its index in messages, or -1 if none exists
+func FindSummary(messages []chat.Message) (string, int) {
+	for i, msg := range messages {
+		if msg.Role == "system" && strings.HasPrefix(msg.Content, SummaryMessagePrefix) {
+			return strings.TrimPrefix(msg.Content, SummaryMessagePrefix), i
+		}
+	}
+	return "", -1
+}
+
+// ReplaceSummary returns messages with the conversation summary set to summary.
+// An existing summary is replaced in place; otherwise the summary is inserted after the leading
+// system messages. An empty summary removes the existing one.
+func ReplaceSummary(messages []chat.Message, summary string) []chat.Message {
+	_, idx := FindSummary(messages)
+	if summary == "" {
+		if idx < 0 {
+			return messages
+		}
+		result := make([]chat.Message, 0, len(messages)-1)
+		result = append(result, messages[:idx]...)
+		return append(result, messages[idx+1:]...)
+	}
+
+	summaryMessage := chat.Message{Role: "system", Content: SummaryMessagePrefix + summary}
+	if idx >= 0 {
+		result := make([]chat.Message, len(messages))
+		copy(result, messages)
+		result[idx] = summaryMessage
+		return result
+	}
+
+	insertAt := 0
+	for insertAt < len(messages) && messages[insertAt].Role == "system" {
+		insertAt++
+	}
+	result := make([]chat.Message, 0, len(messages)+1)
+	result = append(result, messages[:insertAt]...)
+	result = append(result, summaryMessage)
+	return append(result, messages[insertAt:]...)
+}
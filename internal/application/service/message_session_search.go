@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+const (
+	// defaultSessionSearchLimit is the number of matches returned when no limit is given
+	defaultSessionSearchLimit = 50
+	// maxSessionSearchLimit caps the number of matches of one search
+	maxSessionSearchLimit = 200
+	// sessionSearchSnippetRadius is the number of runes kept on each side of the match in a snippet
+	sessionSearchSnippetRadius = 60
+)
+
+// SearchSessionMessages finds the messages of a session of the current tenant containing the keyword
+func (s *messageService) SearchSessionMessages(
+	ctx context.Context, sessionID string, keyword string, limit int,
+) ([]*types.SessionMessageMatch, error) {
+	keyword = strings.TrimSpace(keyword)
+	if limit <= 0 {
+		limit = defaultSessionSearchLimit
+	}
+	limit = min(limit, maxSessionSearchLimit)
+
+	tenantID := types.MustTenantIDFromContext(ctx)
+	if _, err := s.sessionRepo.Get(ctx, tenantID, sessionID); err != nil {
+		logger.Errorf(ctx, "Failed to get session: %v", err)
+		return nil, err
+	}
+
+	messages, err := s.messageRepo.SearchMessages(ctx, sessionID, keyword, limit)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"session_id": sessionID,
+		})
+		return nil, err
+	}
+
+	matches := make([]*types.SessionMessageMatch, 0, len(messages))
+	for _, m := range messages {
+		matches = append(matches, &types.SessionMessageMatch{
+			ID:        m.ID,
+			RequestID: m.RequestID,
+			Role:      m.Role,
+			Snippet:   keywordSnippet(m.Content, keyword, sessionSearchSnippetRadius),
+			CreatedAt: m.CreatedAt,
+		})
+	}
+	logger.Infof(ctx, "Found %d messages matching keyword in session %s", len(matches), sessionID)
+	return matches, nil
+}
+
+// keywordSnippet returns the text around the first case-insensitive occurrence of keyword, with
+// radius runes on each side and "..." marking cut ends. Without a match the start of text is returned.
+func keywordSnippet(text string, keyword string, radius int) string {
+	runes := []rune(text)
+	needle := []rune(keyword)
+	start := 0
+	if len(needle) > 0 {
+		start = -1
+		for i := 0; i+len(needle) <= len(runes) && start < 0; i++ {
+			matched := true
+			for k, r := range needle {
+				if unicode.ToLower(runes[i+k]) != unicode.ToLower(r) {
+					matched = false
+					break
+				}
+			}
+			if matched {
+				start = i
+			}
+		}
+		start = max(start, 0)
+	}
+
+	from := max(0, start-radius)
+	to := min(len(runes), start+len(needle)+radius)
+	snippet := string(runes[from:to])
+	if from > 0 {
+		snippet = "..." + snippet
+	}
+	if to < len(runes) {
+		snippet += "..."
+	}
+	return snippet
+}
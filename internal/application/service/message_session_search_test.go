@@ -0,0 +1,25 @@
+package service
+
+import "testing"
+
+func TestKeywordSnippet(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		keyword string
+		radius  int
+		want    string
+	}{
+		{name: "match in the middle", text: "deploy WeKnora with Docker compose today", keyword: "docker", radius: 5, want: "...with Docker comp..."},
+		{name: "match at the start", text: "Docker compose", keyword: "DOCKER", radius: 3, want: "Docker co..."},
+		{name: "whole text fits", text: "如何部署", keyword: "部署", radius: 10, want: "如何部署"},
+		{name: "no match falls back to the start", text: "abcdefgh", keyword: "xyz", radius: 2, want: "abcde..."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := keywordSnippet(tt.text, tt.keyword, tt.radius); got != tt.want {
+				t.Errorf("keywordSnippet() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
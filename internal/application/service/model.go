@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 
+	"github.com/Tencent/WeKnora/internal/config"
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/models/chat"
 	"github.com/Tencent/WeKnora/internal/models/embedding"
@@ -19,20 +20,52 @@ var ErrModelNotFound = errors.New("model not found")
 
 // modelService implements the model service interface
 type modelService struct {
-	repo          interfaces.ModelRepository
-	ollamaService *ollama.OllamaService
-	pooler        embedding.EmbedderPooler
+	repo           interfaces.ModelRepository
+	ollamaService  *ollama.OllamaService
+	pooler         embedding.EmbedderPooler
+	embeddingCache embedding.VectorCache // nil when the embedding cache is disabled
+	config         *config.Config
 }
 
 // NewModelService creates a new model service instance
-func NewModelService(repo interfaces.ModelRepository, ollamaService *ollama.OllamaService, pooler embedding.EmbedderPooler) interfaces.ModelService {
+func NewModelService(
+	repo interfaces.ModelRepository,
+	ollamaService *ollama.OllamaService,
+	pooler embedding.EmbedderPooler,
+	embeddingCache embedding.VectorCache,
+	cfg *config.Config,
+) interfaces.ModelService {
 	return &modelService{
-		repo:          repo,
-		ollamaService: ollamaService,
-		pooler:        pooler,
+		repo:           repo,
+		ollamaService:  ollamaService,
+		pooler:         pooler,
+		embeddingCache: embeddingCache,
+		config:         cfg,
 	}
 }
 
+// invalidateEmbeddingCache drops the cached vectors of an embedding model whose configuration changed
+func (s *modelService) invalidateEmbeddingCache(ctx context.Context, model *types.Model) {
+	if s.embeddingCache == nil || model == nil || model.Type != types.ModelTypeEmbedding {
+		return
+	}
+	if err := s.embeddingCache.DeleteModel(ctx, model.ID); err != nil {
+		logger.Warnf(ctx, "Failed to invalidate embedding cache of model %s: %v", model.ID, err)
+	}
+}
+
+// retryConfig returns the retry policy for model calls: the tenant override from the context
+// when set, otherwise the global default
+func (s *modelService) retryConfig(ctx context.Context) *types.ModelRetryConfig {
+	if tenant, _ := types.TenantInfoFromContext(ctx); tenant != nil && tenant.ModelRetryConfig != nil {
+		return tenant.ModelRetryConfig
+	}
+	if s.config != nil {
+		return s.config.ModelRetry
+	}
+	return nil
+}
+
 // CreateModel creates a new model in the repository
 // For local models, it initiates an asynchronous download process
 // Remote models are immediately set to active status
@@ -164,6 +197,16 @@ func (s *modelService) ListModels(ctx context.Context) ([]*types.Model, error) {
 	return models, nil
 }
 
+// ListModelsGrouped returns the tenant's models grouped by type, with availability and
+// title generation capability, for populating model selectors
+func (s *modelService) ListModelsGrouped(ctx context.Context) (*types.ModelCatalog, error) {
+	models, err := s.ListModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return types.NewModelCatalog(models), nil
+}
+
 // UpdateModel updates an existing model in the repository
 func (s *modelService) UpdateModel(ctx context.Context, model *types.Model) error {
 	logger.Info(ctx, "Start updating model")
@@ -192,6 +235,7 @@ func (s *modelService) UpdateModel(ctx context.Context, model *types.Model) erro
 		})
 		return err
 	}
+	s.invalidateEmbeddingCache(ctx, existingModel)
 
 	logger.Infof(ctx, "Model updated successfully: %s", model.ID)
 	return nil
@@ -227,6 +271,7 @@ func (s *modelService) DeleteModel(ctx context.Context, id string) error {
 		})
 		return err
 	}
+	s.invalidateEmbeddingCache(ctx, existingModel)
 
 	logger.Infof(ctx, "Model deleted successfully: %s", id)
 	return nil
@@ -246,7 +291,17 @@ func (s *modelService) GetEmbeddingModel(ctx context.Context, modelId string) (e
 
 	logger.Infof(ctx, "Getting embedding model: %s, source: %s", model.Name, model.Source)
 
-	// Initialize the embedder with model configuration
+	embedder, err := s.newEmbedder(ctx, model)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info(ctx, "Embedding model initialized successfully")
+	return embedding.WithCache(embedder, s.embeddingCache), nil
+}
+
+// newEmbedder initializes an embedder with retries for the model, without the vector cache
+func (s *modelService) newEmbedder(ctx context.Context, model *types.Model) (embedding.Embedder, error) {
 	embedder, err := embedding.NewEmbedder(embedding.Config{
 		Source:               model.Source,
 		BaseURL:              model.Parameters.BaseURL,
@@ -264,9 +319,7 @@ func (s *modelService) GetEmbeddingModel(ctx context.Context, modelId string) (e
 		})
 		return nil, err
 	}
-
-	logger.Info(ctx, "Embedding model initialized successfully")
-	return embedder, nil
+	return embedding.WithRetry(embedder, s.retryConfig(ctx)), nil
 }
 
 // GetEmbeddingModelForTenant retrieves and initializes an embedding model for a specific tenant
@@ -322,7 +375,7 @@ func (s *modelService) GetEmbeddingModelForTenant(ctx context.Context, modelId s
 	}
 
 	logger.Info(ctx, "Cross-tenant embedding model initialized successfully")
-	return embedder, nil
+	return embedding.WithCache(embedding.WithRetry(embedder, s.retryConfig(ctx)), s.embeddingCache), nil
 }
 
 // GetRerankModel retrieves and initializes a reranking model instance
@@ -356,7 +409,7 @@ func (s *modelService) GetRerankModel(ctx context.Context, modelId string) (rera
 	}
 
 	logger.Info(ctx, "Rerank model initialized successfully")
-	return reranker, nil
+	return rerank.WithRetry(reranker, s.retryConfig(ctx)), nil
 }
 
 // GetChatModel retrieves and initializes a chat model instance
@@ -403,7 +456,7 @@ func (s *modelService) GetChatModel(ctx context.Context, modelId string) (chat.C
 		return nil, err
 	}
 
-	return chatModel, nil
+	return chat.WithRetry(chatModel, s.retryConfig(ctx)), nil
 }
 
 // GetVLMModel retrieves and initializes a vision language model instance.
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"sync"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/models/embedding"
+	"github.com/Tencent/WeKnora/internal/models/rerank"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// modelWarmupTimeout bounds a single warmup; local backends may need a while to load weights
+const modelWarmupTimeout = 2 * time.Minute
+
+// WarmupModel sends a minimal request to a model so its connection and weights are loaded before
+// real traffic arrives. A failing request is reported in the result rather than as an error.
+func (s *modelService) WarmupModel(ctx context.Context, id string) (*types.ModelWarmupResult, error) {
+	model, err := s.GetModelByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.warmupModel(ctx, model), nil
+}
+
+// WarmupModels warms up all active models of the tenant concurrently. Models that are not
+// active (e.g. still downloading) are reported as failed without sending a request.
+func (s *modelService) WarmupModels(ctx context.Context) ([]*types.ModelWarmupResult, error) {
+	models, err := s.ListModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*types.ModelWarmupResult, len(models))
+	var wg sync.WaitGroup
+	for i, model := range models {
+		if model.Status != types.ModelStatusActive {
+			results[i] = &types.ModelWarmupResult{
+				ModelID: model.ID,
+				Name:    model.Name,
+				Type:    model.Type,
+				Error:   fmt.Sprintf("model is not active, status: %s", model.Status),
+			}
+			continue
+		}
+		wg.Add(1)
+		go func(i int, model *types.Model) {
+			defer wg.Done()
+			results[i] = s.warmupModel(ctx, model)
+		}(i, model)
+	}
+	wg.Wait()
+	return results, nil
+}
+
+// warmupModel sends the warmup request and measures its latency
+func (s *modelService) warmupModel(ctx context.Context, model *types.Model) *types.ModelWarmupResult {
+	ctx, cancel := context.WithTimeout(ctx, modelWarmupTimeout)
+	defer cancel()
+
+	result := &types.ModelWarmupResult{ModelID: model.ID, Name: model.Name, Type: model.Type}
+	start := time.Now()
+	err := s.sendWarmupRequest(ctx, model)
+	result.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		logger.Warnf(ctx, "Warmup of model %s (%s) failed after %dms: %v",
+			model.ID, model.Name, result.LatencyMs, err)
+		result.Error = err.Error()
+		return result
+	}
+	logger.Infof(ctx, "Model %s (%s) warmed up in %dms", model.ID, model.Name, result.LatencyMs)
+	result.Success = true
+	return result
+}
+
+// sendWarmupRequest issues the smallest request each model type accepts. Chat, embedding and
+// rerank models get the probes the model connection checks use.
+func (s *modelService) sendWarmupRequest(ctx context.Context, model *types.Model) error {
+	switch model.Type {
+	case types.ModelTypeKnowledgeQA:
+		chatModel, err := s.GetChatModel(ctx, model.ID)
+		if err != nil {
+			return err
+		}
+		return chat.Probe(ctx, chatModel)
+	case types.ModelTypeEmbedding:
+		// Bypass the vector cache so the request reaches the backend
+		embedder, err := s.newEmbedder(ctx, model)
+		if err != nil {
+			return err
+		}
+		_, err = embedding.Probe(ctx, embedder)
+		return err
+	case types.ModelTypeRerank:
+		reranker, err := s.GetRerankModel(ctx, model.ID)
+		if err != nil {
+			return err
+		}
+		_, err = rerank.Probe(ctx, reranker)
+		return err
+	case types.ModelTypeVLLM:
+		vlmModel, err := s.GetVLMModel(ctx, model.ID)
+		if err != nil {
+			return err
+		}
+		img, err := warmupImage()
+		if err != nil {
+			return err
+		}
+		_, err = vlmModel.Predict(ctx, img, "test")
+		return err
+	default:
+		return fmt.Errorf("unsupported model type: %s", model.Type)
+	}
+}
+
+// warmupImage returns a 1x1 PNG for warming up vision models
+func warmupImage() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1, 1))); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
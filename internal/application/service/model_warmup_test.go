@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// listModelRepo lists a fixed set of models
+type listModelRepo struct {
+	interfaces.ModelRepository
+	models []*types.Model
+}
+
+func (r *listModelRepo) List(ctx context.Context,
+	tenantID uint64, modelType types.ModelType, source types.ModelSource,
+) ([]*types.Model, error) {
+	return r.models, nil
+}
+
+func TestWarmupModelsReportsEachModel(t *testing.T) {
+	ctx := context.WithValue(context.Background(), types.TenantIDContextKey, uint64(1))
+	s := &modelService{repo: &listModelRepo{models: []*types.Model{
+		{ID: "m-1", Name: "pulling", Type: types.ModelTypeKnowledgeQA, Status: types.ModelStatusDownloading},
+		{ID: "m-2", Name: "custom", Type: types.ModelType("Custom"), Status: types.ModelStatusActive},
+	}}}
+
+	results, err := s.WarmupModels(ctx)
+	if err != nil {
+		t.Fatalf("WarmupModels() error = %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("WarmupModels() returned %d results, want 2", len(results))
+	}
+	for i, want := range []string{"not active", "unsupported model type"} {
+		r := results[i]
+		if r.Success || !strings.Contains(r.Error, want) {
+			t.Errorf("result %s = {success %v, error %q}, want failure containing %q", r.ModelID, r.Success, r.Error, want)
+		}
+	}
+}
@@ -4,7 +4,14 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -21,6 +28,31 @@ const DefaultInviteCodeValidityDays = 7
 // DefaultMemberLimit is the default max members per organization (0 = unlimited)
 const DefaultMemberLimit = 200
 
+// DefaultJoinRequestTTLDays is how many days join requests stay pending before they expire, for
+// organizations without their own TTL. WEKNORA_JOIN_REQUEST_TTL_DAYS overrides it (0 = never expire).
+const DefaultJoinRequestTTLDays = 30
+
+// DefaultMaxOwnedOrganizations is how many organizations a user can own when
+// WEKNORA_MAX_OWNED_ORGS is not set (0 = unlimited)
+const DefaultMaxOwnedOrganizations = 0
+
+// MaxJoinRequestTTLDays is the largest join request TTL an organization can set
+const MaxJoinRequestTTLDays = 365
+
+// MaxOrgAvatarSize is the largest organization avatar image accepted on upload
+const MaxOrgAvatarSize = 2 << 20
+
+// orgAvatarExtensions maps the accepted avatar image types to the extension they are stored with
+var orgAvatarExtensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// maxInviteCodeAttempts bounds how many random invite codes are tried before giving up
+const maxInviteCodeAttempts = 5
+
 // ValidInviteCodeValidityDays are the allowed values for invite_code_validity_days
 var ValidInviteCodeValidityDays = map[int]bool{0: true, 1: true, 7: true, 30: true}
 
@@ -29,20 +61,37 @@ var (
 	ErrOrgPermissionDenied   = errors.New("permission denied for this organization")
 	ErrCannotRemoveOwner     = errors.New("cannot remove organization owner")
 	ErrCannotChangeOwnerRole = errors.New("cannot change organization owner role")
+	ErrCannotSuspendOwner    = errors.New("cannot suspend organization owner")
+	ErrMemberSuspended       = errors.New("user is suspended in this organization")
 	ErrUserNotInOrg          = errors.New("user is not a member of this organization")
 	ErrInvalidRole           = errors.New("invalid role")
 	ErrInviteCodeExpired     = errors.New("invite code has expired")
 	ErrInvalidValidityDays   = errors.New("invite_code_validity_days must be 0, 1, 7, or 30")
 	ErrOrgMemberLimitReached = errors.New("organization member limit reached")
 	ErrOrgMemberLimitTooLow  = errors.New("member limit cannot be lower than current member count")
+	ErrInviteCodeGeneration  = errors.New("failed to generate a unique invite code")
+	ErrInvalidAvatar         = fmt.Errorf("avatar must be a PNG, JPEG, GIF or WebP image of at most %d MB", MaxOrgAvatarSize>>20)
+	ErrInvalidAvatarURL      = errors.New("avatar must be an http or https URL")
+	ErrOrgAvatarNotFound     = errors.New("organization has no uploaded avatar")
+	ErrOwnedOrgLimitReached  = errors.New("maximum number of owned organizations reached")
+	ErrInvalidStorageQuota   = errors.New("storage_quota cannot be negative")
+	ErrInvalidJoinRequestTTL = fmt.Errorf("join_request_ttl_days must be between 0 and %d", MaxJoinRequestTTLDays)
 )
 
 // organizationService implements OrganizationService interface
 type organizationService struct {
-	orgRepo        interfaces.OrganizationRepository
-	userRepo       interfaces.UserRepository
-	shareRepo      interfaces.KBShareRepository
-	agentShareRepo interfaces.AgentShareRepository
+	orgRepo          interfaces.OrganizationRepository
+	userRepo         interfaces.UserRepository
+	shareRepo        interfaces.KBShareRepository
+	agentShareRepo   interfaces.AgentShareRepository
+	notificationRepo interfaces.UserNotificationRepository
+	fileService      interfaces.FileService
+	// joinRequestTTLDays is the global join request TTL, used by organizations without their own
+	joinRequestTTLDays int
+	// maxOwnedOrgs caps how many organizations a user can own; 0 means unlimited
+	maxOwnedOrgs int
+	// newInviteCode generates a candidate invite code
+	newInviteCode func() string
 }
 
 // NewOrganizationService creates a new organization service
@@ -51,13 +100,77 @@ func NewOrganizationService(
 	userRepo interfaces.UserRepository,
 	shareRepo interfaces.KBShareRepository,
 	agentShareRepo interfaces.AgentShareRepository,
+	notificationRepo interfaces.UserNotificationRepository,
+	fileService interfaces.FileService,
 ) interfaces.OrganizationService {
 	return &organizationService{
-		orgRepo:        orgRepo,
-		userRepo:       userRepo,
-		shareRepo:      shareRepo,
-		agentShareRepo: agentShareRepo,
+		orgRepo:            orgRepo,
+		userRepo:           userRepo,
+		shareRepo:          shareRepo,
+		agentShareRepo:     agentShareRepo,
+		notificationRepo:   notificationRepo,
+		fileService:        fileService,
+		joinRequestTTLDays: globalJoinRequestTTLDays(),
+		maxOwnedOrgs:       globalMaxOwnedOrganizations(),
+		newInviteCode:      generateInviteCode,
+	}
+}
+
+// globalMaxOwnedOrganizations reads the per-user owned organization cap from WEKNORA_MAX_OWNED_ORGS
+func globalMaxOwnedOrganizations() int {
+	if v, err := strconv.Atoi(strings.TrimSpace(os.Getenv("WEKNORA_MAX_OWNED_ORGS"))); err == nil && v >= 0 {
+		return v
+	}
+	return DefaultMaxOwnedOrganizations
+}
+
+// globalJoinRequestTTLDays reads the global join request TTL from WEKNORA_JOIN_REQUEST_TTL_DAYS
+func globalJoinRequestTTLDays() int {
+	if v, err := strconv.Atoi(strings.TrimSpace(os.Getenv("WEKNORA_JOIN_REQUEST_TTL_DAYS"))); err == nil && v >= 0 {
+		return v
 	}
+	return DefaultJoinRequestTTLDays
+}
+
+// joinRequestCutoff returns the creation time at or before which pending join requests of org are
+// expired, nil if they never expire
+func (s *organizationService) joinRequestCutoff(org *types.Organization, now time.Time) *time.Time {
+	ttlDays := org.JoinRequestTTLDays
+	if ttlDays <= 0 {
+		ttlDays = s.joinRequestTTLDays
+	}
+	if ttlDays <= 0 {
+		return nil
+	}
+	cutoff := now.AddDate(0, 0, -ttlDays)
+	return &cutoff
+}
+
+// isJoinRequestExpired reports whether a pending request was created at or before cutoff
+func isJoinRequestExpired(request *types.OrganizationJoinRequest, cutoff *time.Time) bool {
+	return cutoff != nil && request.Status == types.JoinRequestStatusPending && !request.CreatedAt.After(*cutoff)
+}
+
+// validateJoinRequestTTLDays checks a join request TTL set on an organization
+func validateJoinRequestTTLDays(days int) error {
+	if days < 0 || days > MaxJoinRequestTTLDays {
+		return ErrInvalidJoinRequestTTL
+	}
+	return nil
+}
+
+// normalizeAvatarURL trims an avatar set through create/update and checks it is empty or an
+// http(s) URL. Storage paths are rejected since only uploads may point the avatar at storage.
+func normalizeAvatarURL(avatar string) (string, error) {
+	avatar = strings.TrimSpace(avatar)
+	if avatar == "" {
+		return "", nil
+	}
+	u, err := url.Parse(avatar)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", ErrInvalidAvatarURL
+	}
+	return avatar, nil
 }
 
 // resolveInviteExpiry returns expiresAt for the given validity days (0 = never, nil expiresAt).
@@ -87,23 +200,56 @@ func (s *organizationService) CreateOrganization(ctx context.Context, userID str
 		}
 		memberLimit = *req.MemberLimit
 	}
+	defaultSharePermission := types.OrgRoleViewer
+	if req.DefaultSharePermission != nil {
+		if !req.DefaultSharePermission.IsValid() {
+			return nil, ErrInvalidRole
+		}
+		defaultSharePermission = *req.DefaultSharePermission
+	}
+	joinRequestTTLDays := 0
+	if req.JoinRequestTTLDays != nil {
+		if err := validateJoinRequestTTLDays(*req.JoinRequestTTLDays); err != nil {
+			return nil, err
+		}
+		joinRequestTTLDays = *req.JoinRequestTTLDays
+	}
+	avatar, err := normalizeAvatarURL(req.Avatar)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.maxOwnedOrgs > 0 {
+		owned, err := s.orgRepo.CountByOwnerID(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if owned >= int64(s.maxOwnedOrgs) {
+			return nil, ErrOwnedOrgLimitReached
+		}
+	}
 
 	now := time.Now()
 	org := &types.Organization{
 		ID:                     uuid.New().String(),
 		Name:                   req.Name,
 		Description:            req.Description,
-		Avatar:                 strings.TrimSpace(req.Avatar),
+		Avatar:                 avatar,
 		OwnerID:                userID,
-		InviteCode:             generateInviteCode(),
 		InviteCodeExpiresAt:    resolveInviteExpiry(validityDays, now),
 		InviteCodeValidityDays: validityDays,
 		MemberLimit:            memberLimit,
+		DefaultSharePermission: defaultSharePermission,
+		JoinRequestTTLDays:     joinRequestTTLDays,
 		CreatedAt:              now,
 		UpdatedAt:              now,
 	}
 
-	if err := s.orgRepo.Create(ctx, org); err != nil {
+	err = s.withUniqueInviteCode(ctx, func(code string) error {
+		org.InviteCode = code
+		return s.orgRepo.Create(ctx, org)
+	})
+	if err != nil {
 		logger.Errorf(ctx, "Failed to create organization: %v", err)
 		return nil, err
 	}
@@ -142,6 +288,87 @@ func (s *organizationService) GetOrganization(ctx context.Context, id string) (*
 	return org, nil
 }
 
+// UploadAvatar stores an avatar image for an organization in object storage and replaces the
+// avatar URL with it. The previously uploaded avatar, if any, is deleted.
+func (s *organizationService) UploadAvatar(ctx context.Context,
+	orgID string, userID string, tenantID uint64, data []byte,
+) (*types.Organization, error) {
+	isAdmin, err := s.IsOrgAdmin(ctx, orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin {
+		return nil, ErrOrgPermissionDenied
+	}
+
+	if len(data) == 0 || len(data) > MaxOrgAvatarSize {
+		return nil, ErrInvalidAvatar
+	}
+	ext, ok := orgAvatarExtensions[http.DetectContentType(data)]
+	if !ok {
+		return nil, ErrInvalidAvatar
+	}
+
+	org, err := s.GetOrganization(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	previous := org.AvatarPath
+
+	filePath, err := s.fileService.SaveBytes(ctx, data, tenantID, "org_avatar_"+orgID+ext, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save avatar: %w", err)
+	}
+	org.Avatar = ""
+	org.AvatarPath = filePath
+	org.UpdatedAt = time.Now()
+	if err := s.orgRepo.Update(ctx, org); err != nil {
+		if delErr := s.fileService.DeleteFile(ctx, filePath); delErr != nil {
+			logger.Warnf(ctx, "Failed to delete unused avatar %s: %v", filePath, delErr)
+		}
+		return nil, err
+	}
+
+	s.deleteStoredAvatar(ctx, orgID, previous)
+	logger.Infof(ctx, "Avatar of organization %s uploaded by user %s", orgID, userID)
+	return org, nil
+}
+
+// deleteStoredAvatar deletes an avatar that is no longer referenced by the organization
+func (s *organizationService) deleteStoredAvatar(ctx context.Context, orgID string, filePath string) {
+	if filePath == "" {
+		return
+	}
+	if err := s.fileService.DeleteFile(ctx, filePath); err != nil {
+		logger.Warnf(ctx, "Failed to delete previous avatar of organization %s: %v", orgID, err)
+	}
+}
+
+// GetAvatar opens the uploaded avatar of an organization and returns it with its storage path.
+// Only members can read the avatar of an organization that is not searchable.
+func (s *organizationService) GetAvatar(ctx context.Context, orgID string, userID string) (io.ReadCloser, string, error) {
+	org, err := s.GetOrganization(ctx, orgID)
+	if err != nil {
+		return nil, "", err
+	}
+	if !org.Searchable {
+		if _, err := s.orgRepo.GetMember(ctx, orgID, userID); err != nil {
+			if errors.Is(err, repository.ErrOrgMemberNotFound) {
+				return nil, "", ErrOrgNotFound
+			}
+			return nil, "", err
+		}
+	}
+	if !org.HasStoredAvatar() {
+		return nil, "", ErrOrgAvatarNotFound
+	}
+	reader, err := s.fileService.GetFile(ctx, org.AvatarPath)
+	if err != nil {
+		return nil, "", err
+	}
+	return reader, org.AvatarPath, nil
+}
+
 // GetOrganizationByInviteCode gets an organization by invite code
 func (s *organizationService) GetOrganizationByInviteCode(ctx context.Context, inviteCode string) (*types.Organization, error) {
 	org, err := s.orgRepo.GetByInviteCode(ctx, inviteCode)
@@ -157,6 +384,16 @@ func (s *organizationService) GetOrganizationByInviteCode(ctx context.Context, i
 	return org, nil
 }
 
+// CountOwnedOrganizations returns how many organizations a user owns
+func (s *organizationService) CountOwnedOrganizations(ctx context.Context, userID string) (int64, error) {
+	return s.orgRepo.CountByOwnerID(ctx, userID)
+}
+
+// GetMaxOwnedOrganizations returns how many organizations a user can own, 0 if unlimited
+func (s *organizationService) GetMaxOwnedOrganizations() int {
+	return s.maxOwnedOrgs
+}
+
 // ListUserOrganizations lists all organizations that a user belongs to
 func (s *organizationService) ListUserOrganizations(ctx context.Context, userID string) ([]*types.Organization, error) {
 	return s.orgRepo.ListByUserID(ctx, userID)
@@ -184,8 +421,15 @@ func (s *organizationService) UpdateOrganization(ctx context.Context, id string,
 	if req.Description != nil {
 		org.Description = *req.Description
 	}
+	previousAvatarPath := ""
 	if req.Avatar != nil {
-		org.Avatar = strings.TrimSpace(*req.Avatar)
+		avatar, err := normalizeAvatarURL(*req.Avatar)
+		if err != nil {
+			return nil, err
+		}
+		// Setting the avatar URL replaces an uploaded avatar
+		org.Avatar = avatar
+		previousAvatarPath, org.AvatarPath = org.AvatarPath, ""
 	}
 	if req.RequireApproval != nil {
 		org.RequireApproval = *req.RequireApproval
@@ -214,11 +458,30 @@ func (s *organizationService) UpdateOrganization(ctx context.Context, id string,
 		}
 		org.MemberLimit = *req.MemberLimit
 	}
+	if req.DefaultSharePermission != nil {
+		if !req.DefaultSharePermission.IsValid() {
+			return nil, ErrInvalidRole
+		}
+		org.DefaultSharePermission = *req.DefaultSharePermission
+	}
+	if req.JoinRequestTTLDays != nil {
+		if err := validateJoinRequestTTLDays(*req.JoinRequestTTLDays); err != nil {
+			return nil, err
+		}
+		org.JoinRequestTTLDays = *req.JoinRequestTTLDays
+	}
+	if req.StorageQuota != nil {
+		if *req.StorageQuota < 0 {
+			return nil, ErrInvalidStorageQuota
+		}
+		org.StorageQuota = *req.StorageQuota
+	}
 	org.UpdatedAt = time.Now()
 
 	if err := s.orgRepo.Update(ctx, org); err != nil {
 		return nil, err
 	}
+	s.deleteStoredAvatar(ctx, id, previousAvatarPath)
 
 	return org, nil
 }
@@ -232,35 +495,61 @@ func (s *organizationService) SearchSearchableOrganizations(ctx context.Context,
 	if err != nil {
 		return nil, err
 	}
-	memberCounts := make(map[string]int64)
-	shareCounts := make(map[string]int64)
-	agentShareCounts := make(map[string]int)
-	memberOrgIDs := make(map[string]bool)
+	orgIDs := make([]string, 0, len(orgs))
 	for _, org := range orgs {
-		if mc, err := s.orgRepo.CountMembers(ctx, org.ID); err == nil {
-			memberCounts[org.ID] = mc
-		}
-		shares, _ := s.shareRepo.ListByOrganization(ctx, org.ID)
-		shareCounts[org.ID] = int64(len(shares))
-		if agentShares, err := s.agentShareRepo.ListByOrganization(ctx, org.ID); err == nil {
-			agentShareCounts[org.ID] = len(agentShares)
+		orgIDs = append(orgIDs, org.ID)
+	}
+
+	// Look everything up for all organizations at once instead of per organization
+	memberCounts, err := s.orgRepo.CountMembersByOrganizations(ctx, orgIDs)
+	if err != nil {
+		return nil, err
+	}
+	shareCounts, err := s.shareRepo.CountByOrganizations(ctx, orgIDs)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to count shares of searchable organizations: %v", err)
+	}
+	agentShareCounts, err := s.agentShareRepo.CountByOrganizations(ctx, orgIDs)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to count agent shares of searchable organizations: %v", err)
+	}
+	memberships, err := s.orgRepo.ListMembersByUserForOrgs(ctx, userID, orgIDs)
+	if err != nil {
+		return nil, err
+	}
+	pendingRequests, err := s.orgRepo.ListPendingJoinRequestsByUser(ctx, userID, orgIDs)
+	if err != nil {
+		return nil, err
+	}
+	orgsByID := make(map[string]*types.Organization, len(orgs))
+	for _, org := range orgs {
+		orgsByID[org.ID] = org
+	}
+	now := time.Now()
+	pendingOrgIDs := make(map[string]bool, len(pendingRequests))
+	for _, request := range pendingRequests {
+		if org := orgsByID[request.OrganizationID]; org != nil &&
+			!isJoinRequestExpired(request, s.joinRequestCutoff(org, now)) {
+			pendingOrgIDs[request.OrganizationID] = true
 		}
-		_, err := s.orgRepo.GetMember(ctx, org.ID, userID)
-		memberOrgIDs[org.ID] = (err == nil)
 	}
+
 	items := make([]types.SearchableOrganizationItem, 0, len(orgs))
 	for _, org := range orgs {
+		memberCount := memberCounts[org.ID]
 		items = append(items, types.SearchableOrganizationItem{
-			ID:              org.ID,
-			Name:            org.Name,
-			Description:     org.Description,
-			Avatar:          org.Avatar,
-			MemberCount:     int(memberCounts[org.ID]),
-			MemberLimit:     org.MemberLimit,
-			ShareCount:      int(shareCounts[org.ID]),
-			AgentShareCount: agentShareCounts[org.ID],
-			IsAlreadyMember: memberOrgIDs[org.ID],
-			RequireApproval: org.RequireApproval,
+			ID:                org.ID,
+			Name:              org.Name,
+			Description:       org.Description,
+			Avatar:            org.AvatarURL(),
+			MemberCount:       int(memberCount),
+			MemberLimit:       org.MemberLimit,
+			ShareCount:        int(shareCounts[org.ID]),
+			AgentShareCount:   int(agentShareCounts[org.ID]),
+			IsAlreadyMember:   memberships[org.ID] != nil,
+			RequireApproval:   org.RequireApproval,
+			IsFull:            org.MemberLimit > 0 && memberCount >= int64(org.MemberLimit),
+			HasPendingRequest: pendingOrgIDs[org.ID],
 		})
 	}
 	return &types.ListSearchableOrganizationsResponse{
@@ -281,8 +570,11 @@ func (s *organizationService) JoinByOrganizationID(ctx context.Context, orgID st
 	if !org.Searchable {
 		return nil, ErrOrgPermissionDenied // or a dedicated "org not discoverable" error
 	}
-	_, err = s.orgRepo.GetMember(ctx, orgID, userID)
+	existing, err := s.orgRepo.GetMemberIncludingSuspended(ctx, orgID, userID)
 	if err == nil {
+		if existing.Suspended {
+			return nil, ErrMemberSuspended
+		}
 		return org, nil // already member
 	}
 	// Validate requested role if provided
@@ -324,6 +616,9 @@ func (s *organizationService) DeleteOrganization(ctx context.Context, id string,
 	if err := s.shareRepo.DeleteByOrganizationID(ctx, id); err != nil {
 		logger.Warnf(ctx, "Failed to delete KB shares for organization %s: %v", id, err)
 	}
+	if err := s.shareRepo.DeleteDynamicByOrganizationID(ctx, id); err != nil {
+		logger.Warnf(ctx, "Failed to delete dynamic shares of organization %s: %v", id, err)
+	}
 	if err := s.agentShareRepo.DeleteByOrganizationID(ctx, id); err != nil {
 		logger.Warnf(ctx, "Failed to delete agent shares for organization %s: %v", id, err)
 	}
@@ -336,6 +631,9 @@ func (s *organizationService) AddMember(ctx context.Context, orgID string, userI
 	if !role.IsValid() {
 		return ErrInvalidRole
 	}
+	if err := s.checkNotSuspended(ctx, orgID, userID); err != nil {
+		return err
+	}
 
 	org, err := s.orgRepo.GetByID(ctx, orgID)
 	if err != nil {
@@ -421,11 +719,74 @@ func (s *organizationService) UpdateMemberRole(ctx context.Context, orgID string
 	return s.orgRepo.UpdateMemberRole(ctx, orgID, memberUserID, role)
 }
 
+// SuspendMember revokes a member's access without removing the membership, so the role is kept
+// when the member is unsuspended
+func (s *organizationService) SuspendMember(ctx context.Context, orgID string, memberUserID string, operatorUserID string) error {
+	return s.setMemberSuspended(ctx, orgID, memberUserID, operatorUserID, true)
+}
+
+// UnsuspendMember restores the access of a suspended member
+func (s *organizationService) UnsuspendMember(ctx context.Context, orgID string, memberUserID string, operatorUserID string) error {
+	return s.setMemberSuspended(ctx, orgID, memberUserID, operatorUserID, false)
+}
+
+func (s *organizationService) setMemberSuspended(ctx context.Context,
+	orgID string, memberUserID string, operatorUserID string, suspended bool,
+) error {
+	isAdmin, err := s.IsOrgAdmin(ctx, orgID, operatorUserID)
+	if err != nil {
+		return err
+	}
+	if !isAdmin {
+		return ErrOrgPermissionDenied
+	}
+
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	if org.OwnerID == memberUserID {
+		return ErrCannotSuspendOwner
+	}
+
+	if err := s.orgRepo.SetMemberSuspended(ctx, orgID, memberUserID, suspended); err != nil {
+		if errors.Is(err, repository.ErrOrgMemberNotFound) {
+			return ErrUserNotInOrg
+		}
+		return err
+	}
+	logger.Infof(ctx, "Member %s of organization %s suspended=%t by %s", memberUserID, orgID, suspended, operatorUserID)
+	return nil
+}
+
+// checkNotSuspended returns ErrMemberSuspended if the user holds a suspended membership,
+// which must be lifted by an admin rather than replaced by joining again
+func (s *organizationService) checkNotSuspended(ctx context.Context, orgID string, userID string) error {
+	member, err := s.orgRepo.GetMemberIncludingSuspended(ctx, orgID, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrOrgMemberNotFound) {
+			return nil
+		}
+		return err
+	}
+	if member.Suspended {
+		return ErrMemberSuspended
+	}
+	return nil
+}
+
 // ListMembers lists all members of an organization
 func (s *organizationService) ListMembers(ctx context.Context, orgID string) ([]*types.OrganizationMember, error) {
 	return s.orgRepo.ListMembers(ctx, orgID)
 }
 
+// SearchMembers finds members of an organization by username or email
+func (s *organizationService) SearchMembers(ctx context.Context,
+	orgID string, query string, limit int,
+) ([]*types.OrganizationMember, error) {
+	return s.orgRepo.SearchMembers(ctx, orgID, strings.TrimSpace(query), limit)
+}
+
 // GetMember gets a specific member of an organization
 func (s *organizationService) GetMember(ctx context.Context, orgID string, userID string) (*types.OrganizationMember, error) {
 	member, err := s.orgRepo.GetMember(ctx, orgID, userID)
@@ -460,10 +821,14 @@ func (s *organizationService) GenerateInviteCode(ctx context.Context, orgID stri
 	}
 	// 0 = never expire (expiresAt nil); 1/7/30 = that many days
 
-	inviteCode := generateInviteCode()
 	now := time.Now()
 	expiresAt := resolveInviteExpiry(validityDays, now)
-	if err := s.orgRepo.UpdateInviteCode(ctx, orgID, inviteCode, expiresAt); err != nil {
+	var inviteCode string
+	err = s.withUniqueInviteCode(ctx, func(code string) error {
+		inviteCode = code
+		return s.orgRepo.UpdateInviteCode(ctx, orgID, code, expiresAt)
+	})
+	if err != nil {
 		return "", err
 	}
 
@@ -490,8 +855,11 @@ func (s *organizationService) JoinByInviteCode(ctx context.Context, inviteCode s
 	}
 
 	// Check if user is already a member
-	_, err = s.orgRepo.GetMember(ctx, org.ID, userID)
+	existing, err := s.orgRepo.GetMemberIncludingSuspended(ctx, org.ID, userID)
 	if err == nil {
+		if existing.Suspended {
+			return nil, ErrMemberSuspended
+		}
 		// User is already a member, just return the organization
 		return org, nil
 	}
@@ -553,6 +921,31 @@ func (s *organizationService) GetUserRoleInOrg(ctx context.Context, orgID string
 	return member.Role, nil
 }
 
+// withUniqueInviteCode calls save with a newly generated invite code that no organization uses.
+// A code taken concurrently between the check and save is retried too, up to maxInviteCodeAttempts.
+func (s *organizationService) withUniqueInviteCode(ctx context.Context, save func(code string) error) error {
+	for attempt := 0; attempt < maxInviteCodeAttempts; attempt++ {
+		code := s.newInviteCode()
+		exists, err := s.orgRepo.InviteCodeExists(ctx, code)
+		if err != nil {
+			return err
+		}
+		if exists {
+			logger.Warnf(ctx, "Generated invite code collides with an existing one, retrying")
+			continue
+		}
+		if err := save(code); err != nil {
+			if errors.Is(err, repository.ErrInviteCodeTaken) {
+				logger.Warnf(ctx, "Generated invite code was taken concurrently, retrying")
+				continue
+			}
+			return err
+		}
+		return nil
+	}
+	return ErrInviteCodeGeneration
+}
+
 // generateInviteCode generates a random 16-character invite code
 func generateInviteCode() string {
 	bytes := make([]byte, 8)
@@ -569,6 +962,8 @@ var (
 	ErrJoinRequestNotFound     = errors.New("join request not found")
 	ErrCannotUpgradeToSameRole = errors.New("cannot request upgrade to same or lower role")
 	ErrAlreadyAdmin            = errors.New("user is already an admin")
+	ErrJoinRequestReviewed     = errors.New("request has already been reviewed")
+	ErrJoinRequestExpired      = errors.New("request has expired")
 )
 
 // SubmitJoinRequest submits a request to join an organization
@@ -580,6 +975,9 @@ func (s *organizationService) SubmitJoinRequest(ctx context.Context, orgID strin
 	if err == nil && existing != nil {
 		return nil, ErrPendingRequestExists
 	}
+	if err := s.checkNotSuspended(ctx, orgID, userID); err != nil {
+		return nil, err
+	}
 
 	// Reject if organization is already at member limit
 	org, err := s.orgRepo.GetByID(ctx, orgID)
@@ -625,14 +1023,91 @@ func (s *organizationService) SubmitJoinRequest(ctx context.Context, orgID strin
 	return request, nil
 }
 
-// ListJoinRequests lists all join requests for an organization
+// ListJoinRequests lists all join requests for an organization. Pending requests past the
+// organization's TTL are reported as expired even before the sweeper has marked them.
 func (s *organizationService) ListJoinRequests(ctx context.Context, orgID string) ([]*types.OrganizationJoinRequest, error) {
-	return s.orgRepo.ListJoinRequests(ctx, orgID, "")
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	requests, err := s.orgRepo.ListJoinRequests(ctx, orgID, "")
+	if err != nil {
+		return nil, err
+	}
+	cutoff := s.joinRequestCutoff(org, time.Now())
+	for _, request := range requests {
+		if isJoinRequestExpired(request, cutoff) {
+			request.Status = types.JoinRequestStatusExpired
+		}
+	}
+	return requests, nil
 }
 
-// CountPendingJoinRequests returns the number of pending join requests for an organization
+// ListUpgradeRequests lists the pending role upgrade requests of an organization.
+// They are reviewed through ReviewJoinRequest like join requests.
+func (s *organizationService) ListUpgradeRequests(ctx context.Context,
+	orgID string,
+) ([]*types.OrganizationJoinRequest, error) {
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	requests, err := s.orgRepo.ListJoinRequestsByType(ctx, orgID, types.JoinRequestTypeUpgrade, types.JoinRequestStatusPending)
+	if err != nil {
+		return nil, err
+	}
+	cutoff := s.joinRequestCutoff(org, time.Now())
+	pending := make([]*types.OrganizationJoinRequest, 0, len(requests))
+	for _, request := range requests {
+		if !isJoinRequestExpired(request, cutoff) {
+			pending = append(pending, request)
+		}
+	}
+	return pending, nil
+}
+
+// CountPendingJoinRequests returns the number of pending join requests for an organization,
+// leaving out those past the organization's TTL
 func (s *organizationService) CountPendingJoinRequests(ctx context.Context, orgID string) (int64, error) {
-	return s.orgRepo.CountJoinRequests(ctx, orgID, types.JoinRequestStatusPending)
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if err != nil {
+		return 0, err
+	}
+	cutoff := s.joinRequestCutoff(org, time.Now())
+	return s.orgRepo.CountPendingJoinRequestsSince(ctx, orgID, cutoff)
+}
+
+// ExpireStaleJoinRequests marks the pending join and upgrade requests that outlived their
+// organization's TTL as expired. It is run periodically by a background sweeper.
+func (s *organizationService) ExpireStaleJoinRequests(ctx context.Context) error {
+	orgIDs, err := s.orgRepo.ListOrganizationIDsWithPendingJoinRequests(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list organizations with pending join requests: %w", err)
+	}
+
+	now := time.Now()
+	for _, orgID := range orgIDs {
+		org, err := s.orgRepo.GetByID(ctx, orgID)
+		if err != nil {
+			if !errors.Is(err, repository.ErrOrganizationNotFound) {
+				logger.Warnf(ctx, "Failed to get organization %s for join request expiry: %v", orgID, err)
+			}
+			continue
+		}
+		cutoff := s.joinRequestCutoff(org, now)
+		if cutoff == nil {
+			continue
+		}
+		expired, err := s.orgRepo.ExpireJoinRequests(ctx, orgID, *cutoff)
+		if err != nil {
+			logger.Warnf(ctx, "Failed to expire join requests of organization %s: %v", orgID, err)
+			continue
+		}
+		if expired > 0 {
+			logger.Infof(ctx, "Expired %d pending join request(s) of organization %s", expired, orgID)
+		}
+	}
+	return nil
 }
 
 // ReviewJoinRequest reviews a join request or upgrade request (approve or reject).
@@ -646,16 +1121,28 @@ func (s *organizationService) ReviewJoinRequest(ctx context.Context, orgID strin
 		return ErrJoinRequestNotFound
 	}
 
+	if request.Status == types.JoinRequestStatusExpired {
+		return ErrJoinRequestExpired
+	}
 	if request.Status != types.JoinRequestStatusPending {
-		return errors.New("request has already been reviewed")
+		return ErrJoinRequestReviewed
+	}
+
+	org, err := s.orgRepo.GetByID(ctx, request.OrganizationID)
+	if err != nil {
+		return err
+	}
+	if isJoinRequestExpired(request, s.joinRequestCutoff(org, time.Now())) {
+		return ErrJoinRequestExpired
 	}
 
 	var status types.JoinRequestStatus
+	var role types.OrgMemberRole
 	if approved {
 		status = types.JoinRequestStatusApproved
 
 		// Role to assign: admin override > applicant's requested role > viewer
-		role := types.OrgRoleViewer
+		role = types.OrgRoleViewer
 		if assignRole != nil && assignRole.IsValid() {
 			role = *assignRole
 		} else if request.RequestedRole != "" && request.RequestedRole.IsValid() {
@@ -671,10 +1158,6 @@ func (s *organizationService) ReviewJoinRequest(ctx context.Context, orgID strin
 			logger.Infof(ctx, "Upgrade request %s approved, user %s role updated to %s in organization %s", requestID, request.UserID, role, request.OrganizationID)
 		} else {
 			// Join: check member limit then add new member
-			org, errOrg := s.orgRepo.GetByID(ctx, request.OrganizationID)
-			if errOrg != nil {
-				return errOrg
-			}
 			if org.MemberLimit > 0 {
 				count, errCount := s.orgRepo.CountMembers(ctx, request.OrganizationID)
 				if errCount != nil {
@@ -703,7 +1186,50 @@ func (s *organizationService) ReviewJoinRequest(ctx context.Context, orgID strin
 		logger.Infof(ctx, "Request %s rejected for user %s", requestID, request.UserID)
 	}
 
-	return s.orgRepo.UpdateJoinRequestStatus(ctx, requestID, status, reviewerID, message)
+	if err := s.orgRepo.UpdateJoinRequestStatus(ctx, requestID, status, reviewerID, message); err != nil {
+		return err
+	}
+
+	// Tell the requester about the outcome without holding up the review
+	go s.notifyRequestReviewed(context.WithoutCancel(ctx), request, status, role, message)
+	return nil
+}
+
+// notifyRequestReviewed stores a notification for the user whose join or upgrade request was reviewed
+func (s *organizationService) notifyRequestReviewed(ctx context.Context,
+	request *types.OrganizationJoinRequest, status types.JoinRequestStatus, role types.OrgMemberRole, message string,
+) {
+	requestType := request.RequestType
+	if requestType == "" {
+		requestType = types.JoinRequestTypeJoin
+	}
+	data := types.OrgRequestReviewedData{
+		OrganizationID: request.OrganizationID,
+		RequestID:      request.ID,
+		RequestType:    requestType,
+		Status:         status,
+		Role:           role,
+		ReviewMessage:  message,
+	}
+	if org, err := s.orgRepo.GetByID(ctx, request.OrganizationID); err == nil {
+		data.OrganizationName = org.Name
+	}
+	payload, err := json.Marshal(data)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to marshal review notification for request %s: %v", request.ID, err)
+		return
+	}
+
+	notification := &types.UserNotification{
+		ID:        uuid.New().String(),
+		UserID:    request.UserID,
+		Type:      types.UserNotificationOrgRequestReviewed,
+		Data:      types.JSON(payload),
+		CreatedAt: time.Now(),
+	}
+	if err := s.notificationRepo.Create(ctx, notification); err != nil {
+		logger.Warnf(ctx, "Failed to store review notification for request %s: %v", request.ID, err)
+	}
 }
 
 // RequestRoleUpgrade submits a request to upgrade role in an organization
@@ -762,6 +1288,34 @@ func (s *organizationService) RequestRoleUpgrade(ctx context.Context, orgID stri
 	return request, nil
 }
 
+// CancelRequest withdraws a pending join or upgrade request. Only the requester can cancel it,
+// and requests that were already reviewed are left untouched.
+func (s *organizationService) CancelRequest(ctx context.Context, requestID string, userID string) error {
+	request, err := s.orgRepo.GetJoinRequestByID(ctx, requestID)
+	if err != nil {
+		if errors.Is(err, repository.ErrJoinRequestNotFound) {
+			return ErrJoinRequestNotFound
+		}
+		return err
+	}
+	// Hide requests of other users behind not found
+	if request.UserID != userID {
+		return ErrJoinRequestNotFound
+	}
+	if request.Status != types.JoinRequestStatusPending {
+		return ErrJoinRequestReviewed
+	}
+
+	if err := s.orgRepo.CancelJoinRequest(ctx, requestID); err != nil {
+		if errors.Is(err, repository.ErrJoinRequestNotPending) {
+			return ErrJoinRequestReviewed
+		}
+		return err
+	}
+	logger.Infof(ctx, "Request %s for organization %s cancelled by user %s", requestID, request.OrganizationID, userID)
+	return nil
+}
+
 // GetPendingUpgradeRequest gets a pending upgrade request for a user in an organization
 func (s *organizationService) GetPendingUpgradeRequest(ctx context.Context, orgID string, userID string) (*types.OrganizationJoinRequest, error) {
 	request, err := s.orgRepo.GetPendingRequestByType(ctx, orgID, userID, types.JoinRequestTypeUpgrade)
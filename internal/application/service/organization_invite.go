@@ -0,0 +1,270 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrTooManyInviteEmails is returned when a bulk invite exceeds types.MaxBulkInviteEmails
+	ErrTooManyInviteEmails   = errors.New("too many emails in one invite request")
+	ErrPendingInviteNotFound = errors.New("pending invite not found")
+	ErrPendingInviteExpired  = errors.New("pending invite has expired")
+)
+
+// BulkInviteMembers invites users to an organization by email. Users with an account are added
+// directly; for other emails a pending invite is recorded, which the user who signs up with the
+// email is notified of and can accept until it expires. Pending invites can't carry the admin
+// role. Existing members are skipped and the member limit is respected. Duplicate emails are
+// only reported once.
+func (s *organizationService) BulkInviteMembers(ctx context.Context,
+	orgID string, operatorUserID string, emails []string, role types.OrgMemberRole,
+) ([]*types.BulkInviteResult, error) {
+	if !role.IsValid() {
+		return nil, ErrInvalidRole
+	}
+	if len(emails) > types.MaxBulkInviteEmails {
+		return nil, ErrTooManyInviteEmails
+	}
+
+	isAdmin, err := s.IsOrgAdmin(ctx, orgID, operatorUserID)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin {
+		return nil, ErrOrgPermissionDenied
+	}
+
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if err != nil {
+		if errors.Is(err, repository.ErrOrganizationNotFound) {
+			return nil, ErrOrgNotFound
+		}
+		return nil, err
+	}
+	memberCount, err := s.orgRepo.CountMembers(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*types.BulkInviteResult, 0, len(emails))
+	seen := make(map[string]bool, len(emails))
+	for _, raw := range emails {
+		email := strings.TrimSpace(raw)
+		key := strings.ToLower(email)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		result := s.inviteByEmail(ctx, org, operatorUserID, email, role, memberCount)
+		if result.Status == types.BulkInviteStatusAdded {
+			memberCount++
+		}
+		results = append(results, result)
+	}
+
+	logger.Infof(ctx, "User %s bulk invited %d emails to organization %s", operatorUserID, len(results), orgID)
+	return results, nil
+}
+
+// inviteByEmail adds the user with the email to the organization, or records a pending invite
+// if no user has it yet
+func (s *organizationService) inviteByEmail(ctx context.Context,
+	org *types.Organization, operatorUserID string, email string, role types.OrgMemberRole, memberCount int64,
+) *types.BulkInviteResult {
+	result := &types.BulkInviteResult{Email: email}
+	if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+		result.Status = types.BulkInviteStatusInvalid
+		return result
+	}
+	full := org.MemberLimit > 0 && memberCount >= int64(org.MemberLimit)
+
+	user, err := s.userRepo.GetUserByEmail(ctx, email)
+	if err != nil {
+		if !errors.Is(err, repository.ErrUserNotFound) {
+			logger.Errorf(ctx, "Failed to look up invited email: %v", err)
+			result.Status = types.BulkInviteStatusFailed
+			return result
+		}
+		if role == types.OrgRoleAdmin {
+			result.Status = types.BulkInviteStatusRoleNotAllowed
+			return result
+		}
+		if full {
+			result.Status = types.BulkInviteStatusLimitReached
+			return result
+		}
+		invite := &types.OrganizationPendingInvite{
+			ID:             uuid.New().String(),
+			OrganizationID: org.ID,
+			Email:          strings.ToLower(email),
+			Role:           role,
+			InvitedBy:      operatorUserID,
+			ExpiresAt:      time.Now().Add(types.PendingInviteValidity),
+			CreatedAt:      time.Now(),
+			UpdatedAt:      time.Now(),
+		}
+		if err := s.orgRepo.UpsertPendingInvite(ctx, invite); err != nil {
+			logger.Errorf(ctx, "Failed to record pending invite: %v", err)
+			result.Status = types.BulkInviteStatusFailed
+			return result
+		}
+		result.Status = types.BulkInviteStatusPending
+		return result
+	}
+	result.UserID = user.ID
+
+	existing, err := s.orgRepo.GetMemberIncludingSuspended(ctx, org.ID, user.ID)
+	switch {
+	case err == nil && existing.Suspended:
+		result.Status = types.BulkInviteStatusSuspended
+		return result
+	case err == nil:
+		result.Status = types.BulkInviteStatusAlreadyMember
+		return result
+	case !errors.Is(err, repository.ErrOrgMemberNotFound):
+		logger.Errorf(ctx, "Failed to check membership of invited user: %v", err)
+		result.Status = types.BulkInviteStatusFailed
+		return result
+	}
+	if full {
+		result.Status = types.BulkInviteStatusLimitReached
+		return result
+	}
+
+	member := &types.OrganizationMember{
+		ID:             uuid.New().String(),
+		OrganizationID: org.ID,
+		UserID:         user.ID,
+		TenantID:       user.TenantID,
+		Role:           role,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}
+	if err := s.orgRepo.AddMember(ctx, member); err != nil {
+		if errors.Is(err, repository.ErrOrgMemberAlreadyExists) {
+			result.Status = types.BulkInviteStatusAlreadyMember
+			return result
+		}
+		logger.Errorf(ctx, "Failed to add invited user: %v", err)
+		result.Status = types.BulkInviteStatusFailed
+		return result
+	}
+	result.Status = types.BulkInviteStatusAdded
+	return result
+}
+
+// NotifyPendingInvites stores a notification for each unexpired invite sent to the email of a
+// newly registered user. The user joins only by accepting the invite.
+func (s *organizationService) NotifyPendingInvites(ctx context.Context, user *types.User) error {
+	invites, err := s.orgRepo.ListPendingInvitesByEmail(ctx, strings.ToLower(user.Email))
+	if err != nil {
+		return err
+	}
+	for _, invite := range invites {
+		data := types.OrgInviteReceivedData{
+			OrganizationID: invite.OrganizationID,
+			InviteID:       invite.ID,
+			Role:           invite.Role,
+			ExpiresAt:      invite.ExpiresAt,
+		}
+		if org, err := s.orgRepo.GetByID(ctx, invite.OrganizationID); err == nil {
+			data.OrganizationName = org.Name
+		}
+		payload, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		notification := &types.UserNotification{
+			ID:        uuid.New().String(),
+			UserID:    user.ID,
+			Type:      types.UserNotificationOrgInviteReceived,
+			Data:      types.JSON(payload),
+			CreatedAt: time.Now(),
+		}
+		if err := s.notificationRepo.Create(ctx, notification); err != nil {
+			logger.Warnf(ctx, "Failed to notify user %s of invite %s: %v", user.ID, invite.ID, err)
+		}
+	}
+	return nil
+}
+
+// getPendingInviteForUser gets an unexpired invite sent to the user's email. Invites to other
+// emails are reported as not found.
+func (s *organizationService) getPendingInviteForUser(ctx context.Context,
+	inviteID string, user *types.User,
+) (*types.OrganizationPendingInvite, error) {
+	invite, err := s.orgRepo.GetPendingInvite(ctx, inviteID)
+	if err != nil {
+		if errors.Is(err, repository.ErrPendingInviteNotFound) {
+			return nil, ErrPendingInviteNotFound
+		}
+		return nil, err
+	}
+	if user.Email == "" || !strings.EqualFold(invite.Email, user.Email) {
+		return nil, ErrPendingInviteNotFound
+	}
+	if !invite.ExpiresAt.After(time.Now()) {
+		return nil, ErrPendingInviteExpired
+	}
+	return invite, nil
+}
+
+// AcceptPendingInvite adds the user to the organization with the role of an invite sent to their
+// email and deletes the invite
+func (s *organizationService) AcceptPendingInvite(ctx context.Context,
+	inviteID string, user *types.User,
+) (*types.OrganizationMember, error) {
+	invite, err := s.getPendingInviteForUser(ctx, inviteID, user)
+	if err != nil {
+		return nil, err
+	}
+	err = s.AddMember(ctx, invite.OrganizationID, user.ID, user.TenantID, invite.Role)
+	switch {
+	case errors.Is(err, repository.ErrOrganizationNotFound):
+		return nil, ErrOrgNotFound
+	case err != nil && !errors.Is(err, repository.ErrOrgMemberAlreadyExists):
+		return nil, err
+	}
+	if err := s.orgRepo.DeletePendingInvite(ctx, invite.ID); err != nil {
+		logger.Warnf(ctx, "Failed to delete accepted invite %s: %v", invite.ID, err)
+	}
+	logger.Infof(ctx, "User %s joined organization %s by invite", user.ID, invite.OrganizationID)
+	return s.orgRepo.GetMember(ctx, invite.OrganizationID, user.ID)
+}
+
+// DeclinePendingInvite deletes an invite sent to the user's email
+func (s *organizationService) DeclinePendingInvite(ctx context.Context, inviteID string, user *types.User) error {
+	invite, err := s.getPendingInviteForUser(ctx, inviteID, user)
+	if err != nil && !errors.Is(err, ErrPendingInviteExpired) {
+		return err
+	}
+	if invite == nil {
+		// Expired invites are removed by the sweeper anyway
+		return nil
+	}
+	return s.orgRepo.DeletePendingInvite(ctx, invite.ID)
+}
+
+// DeleteExpiredPendingInvites deletes the pending invites that can no longer be accepted
+func (s *organizationService) DeleteExpiredPendingInvites(ctx context.Context) error {
+	deleted, err := s.orgRepo.DeleteExpiredPendingInvites(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to delete expired pending invites: %w", err)
+	}
+	if deleted > 0 {
+		logger.Infof(ctx, "Deleted %d expired pending invite(s)", deleted)
+	}
+	return nil
+}
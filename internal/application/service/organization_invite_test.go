@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// emailUserRepo looks users up by lower-cased email
+type emailUserRepo struct {
+	interfaces.UserRepository
+	users map[string]*types.User
+}
+
+func (r *emailUserRepo) GetUserByEmail(ctx context.Context, email string) (*types.User, error) {
+	user, ok := r.users[strings.ToLower(email)]
+	if !ok {
+		return nil, repository.ErrUserNotFound
+	}
+	return user, nil
+}
+
+func TestBulkInviteMembers(t *testing.T) {
+	ctx := context.Background()
+	orgRepo := newOrgTestRepo(t,
+		&types.Organization{ID: "org-1", Name: "team", OwnerID: "owner", InviteCode: "code", MemberLimit: 4},
+		&types.OrganizationMember{ID: "m-1", OrganizationID: "org-1", UserID: "owner", TenantID: 1, Role: types.OrgRoleAdmin},
+		&types.OrganizationMember{ID: "m-2", OrganizationID: "org-1", UserID: "suspended", TenantID: 2, Role: types.OrgRoleViewer, Suspended: true},
+	)
+	userRepo := &emailUserRepo{users: map[string]*types.User{
+		"owner@example.com":     {ID: "owner", TenantID: 1},
+		"suspended@example.com": {ID: "suspended", TenantID: 2},
+		"alice@example.com":     {ID: "alice", TenantID: 3},
+		"bob@example.com":       {ID: "bob", TenantID: 4},
+		"carol@example.com":     {ID: "carol", TenantID: 5},
+	}}
+	s := &organizationService{orgRepo: orgRepo, userRepo: userRepo}
+
+	emails := []string{
+		"alice@example.com",
+		" Alice@Example.com ",
+		"not-an-email",
+		"New@Example.com",
+		"owner@example.com",
+		"suspended@example.com",
+		"bob@example.com",
+		"carol@example.com",
+		"late@example.com",
+	}
+	results, err := s.BulkInviteMembers(ctx, "org-1", "owner", emails, types.OrgRoleEditor)
+	if err != nil {
+		t.Fatalf("BulkInviteMembers() error = %v", err)
+	}
+
+	want := map[string]types.BulkInviteStatus{
+		"alice@example.com":     types.BulkInviteStatusAdded,
+		"not-an-email":          types.BulkInviteStatusInvalid,
+		"New@Example.com":       types.BulkInviteStatusPending,
+		"owner@example.com":     types.BulkInviteStatusAlreadyMember,
+		"suspended@example.com": types.BulkInviteStatusSuspended,
+		"bob@example.com":       types.BulkInviteStatusAdded,
+		"carol@example.com":     types.BulkInviteStatusLimitReached,
+		"late@example.com":      types.BulkInviteStatusLimitReached,
+	}
+	if len(results) != len(want) {
+		t.Fatalf("got %d results, want %d with duplicates reported once", len(results), len(want))
+	}
+	for _, result := range results {
+		if result.Status != want[result.Email] {
+			t.Errorf("status of %q = %q, want %q", result.Email, result.Status, want[result.Email])
+		}
+	}
+
+	if count, _ := orgRepo.CountMembers(ctx, "org-1"); count != 4 {
+		t.Errorf("member count = %d, want the limit of 4", count)
+	}
+	invites, err := orgRepo.ListPendingInvitesByEmail(ctx, "new@example.com")
+	if err != nil || len(invites) != 1 || invites[0].Role != types.OrgRoleEditor {
+		t.Fatalf("pending invites = %+v, %v, want one editor invite", invites, err)
+	}
+	if !invites[0].ExpiresAt.After(time.Now().Add(types.PendingInviteValidity - time.Minute)) {
+		t.Errorf("invite expires at %v, want %v from now", invites[0].ExpiresAt, types.PendingInviteValidity)
+	}
+
+	results, err = s.BulkInviteMembers(ctx, "org-1", "owner",
+		[]string{"admin@example.com", "alice@example.com"}, types.OrgRoleAdmin)
+	if err != nil {
+		t.Fatalf("BulkInviteMembers() as admin error = %v", err)
+	}
+	if results[0].Status != types.BulkInviteStatusRoleNotAllowed {
+		t.Errorf("status of an admin invite to an unknown email = %q, want %q",
+			results[0].Status, types.BulkInviteStatusRoleNotAllowed)
+	}
+	if invites, _ := orgRepo.ListPendingInvitesByEmail(ctx, "admin@example.com"); len(invites) != 0 {
+		t.Errorf("recorded %d admin invites, want none", len(invites))
+	}
+
+	if _, err := s.BulkInviteMembers(ctx, "org-1", "alice", []string{"dave@example.com"}, types.OrgRoleViewer); !errors.Is(err, ErrOrgPermissionDenied) {
+		t.Errorf("BulkInviteMembers() by editor error = %v, want %v", err, ErrOrgPermissionDenied)
+	}
+}
+
+func TestNotifyPendingInvites(t *testing.T) {
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour)
+	orgRepo := newOrgTestRepo(t,
+		&types.Organization{ID: "org-1", Name: "team", OwnerID: "owner", InviteCode: "code-1"},
+		&types.OrganizationPendingInvite{ID: "i-1", OrganizationID: "org-1", Email: "new@example.com",
+			Role: types.OrgRoleEditor, InvitedBy: "owner", ExpiresAt: expiresAt},
+		&types.OrganizationPendingInvite{ID: "i-2", OrganizationID: "org-1", Email: "stale@example.com",
+			Role: types.OrgRoleViewer, InvitedBy: "owner", ExpiresAt: time.Now().Add(-time.Hour)},
+	)
+	notifications := &recordingNotificationRepo{created: make(chan *types.UserNotification, 4)}
+	s := &organizationService{orgRepo: orgRepo, notificationRepo: notifications}
+
+	if err := s.NotifyPendingInvites(ctx, &types.User{ID: "new", TenantID: 7, Email: "New@Example.com"}); err != nil {
+		t.Fatalf("NotifyPendingInvites() error = %v", err)
+	}
+	if len(notifications.created) != 1 {
+		t.Fatalf("stored %d notifications, want 1", len(notifications.created))
+	}
+	notification := <-notifications.created
+	var data types.OrgInviteReceivedData
+	if err := json.Unmarshal(notification.Data, &data); err != nil {
+		t.Fatalf("unmarshal notification data: %v", err)
+	}
+	if notification.UserID != "new" || notification.Type != types.UserNotificationOrgInviteReceived ||
+		data.InviteID != "i-1" || data.OrganizationName != "team" || data.Role != types.OrgRoleEditor {
+		t.Errorf("notification = %+v with %+v, want the invite to team", notification, data)
+	}
+	if _, err := orgRepo.GetMember(ctx, "org-1", "new"); !errors.Is(err, repository.ErrOrgMemberNotFound) {
+		t.Errorf("joined without accepting, GetMember() error = %v", err)
+	}
+
+	if err := s.NotifyPendingInvites(ctx, &types.User{ID: "stale", Email: "stale@example.com"}); err != nil {
+		t.Fatalf("NotifyPendingInvites() error = %v", err)
+	}
+	if len(notifications.created) != 0 {
+		t.Errorf("notified an expired invite")
+	}
+}
+
+func TestAcceptPendingInvite(t *testing.T) {
+	ctx := context.Background()
+	future := time.Now().Add(time.Hour)
+	orgRepo := newOrgTestRepo(t,
+		&types.Organization{ID: "org-1", Name: "open", OwnerID: "owner", InviteCode: "code-1"},
+		&types.Organization{ID: "org-2", Name: "full", OwnerID: "owner", InviteCode: "code-2", MemberLimit: 1},
+		&types.OrganizationMember{ID: "m-1", OrganizationID: "org-2", UserID: "owner", TenantID: 1, Role: types.OrgRoleAdmin},
+		&types.OrganizationPendingInvite{ID: "i-1", OrganizationID: "org-1", Email: "new@example.com",
+			Role: types.OrgRoleEditor, InvitedBy: "owner", ExpiresAt: future},
+		&types.OrganizationPendingInvite{ID: "i-2", OrganizationID: "org-2", Email: "new@example.com",
+			Role: types.OrgRoleViewer, InvitedBy: "owner", ExpiresAt: future},
+		&types.OrganizationPendingInvite{ID: "i-3", OrganizationID: "org-1", Email: "other@example.com",
+			Role: types.OrgRoleViewer, InvitedBy: "owner", ExpiresAt: future},
+		&types.OrganizationPendingInvite{ID: "i-4", OrganizationID: "org-1", Email: "late@example.com",
+			Role: types.OrgRoleViewer, InvitedBy: "owner", ExpiresAt: time.Now().Add(-time.Hour)},
+	)
+	s := &organizationService{orgRepo: orgRepo}
+	user := &types.User{ID: "new", TenantID: 7, Email: "New@Example.com"}
+
+	if _, err := s.AcceptPendingInvite(ctx, "i-3", user); !errors.Is(err, ErrPendingInviteNotFound) {
+		t.Errorf("accepting another email's invite error = %v, want %v", err, ErrPendingInviteNotFound)
+	}
+	late := &types.User{ID: "late", TenantID: 8, Email: "late@example.com"}
+	if _, err := s.AcceptPendingInvite(ctx, "i-4", late); !errors.Is(err, ErrPendingInviteExpired) {
+		t.Errorf("accepting an expired invite error = %v, want %v", err, ErrPendingInviteExpired)
+	}
+	if _, err := s.AcceptPendingInvite(ctx, "i-2", user); !errors.Is(err, ErrOrgMemberLimitReached) {
+		t.Errorf("accepting an invite to a full organization error = %v, want %v", err, ErrOrgMemberLimitReached)
+	}
+
+	member, err := s.AcceptPendingInvite(ctx, "i-1", user)
+	if err != nil || member.Role != types.OrgRoleEditor || member.TenantID != 7 {
+		t.Fatalf("AcceptPendingInvite() = %+v, %v, want the invited editor", member, err)
+	}
+	if _, err := s.AcceptPendingInvite(ctx, "i-1", user); !errors.Is(err, ErrPendingInviteNotFound) {
+		t.Errorf("accepting twice error = %v, want %v", err, ErrPendingInviteNotFound)
+	}
+
+	if err := s.DeclinePendingInvite(ctx, "i-2", user); err != nil {
+		t.Fatalf("DeclinePendingInvite() error = %v", err)
+	}
+	if invites, _ := orgRepo.ListPendingInvitesByEmail(ctx, "new@example.com"); len(invites) != 0 {
+		t.Errorf("%d invites left after accepting and declining, want none", len(invites))
+	}
+
+	if err := s.DeleteExpiredPendingInvites(ctx); err != nil {
+		t.Fatalf("DeleteExpiredPendingInvites() error = %v", err)
+	}
+	if _, err := orgRepo.GetPendingInvite(ctx, "i-4"); !errors.Is(err, repository.ErrPendingInviteNotFound) {
+		t.Errorf("expired invite kept, GetPendingInvite() error = %v", err)
+	}
+	if _, err := orgRepo.GetPendingInvite(ctx, "i-3"); err != nil {
+		t.Errorf("unexpired invite of another email deleted: %v", err)
+	}
+}
@@ -0,0 +1,265 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// recordingNotificationRepo hands the stored notifications to the test, which waits for the
+// asynchronous writes on the channel
+type recordingNotificationRepo struct {
+	interfaces.UserNotificationRepository
+	created chan *types.UserNotification
+}
+
+func (r *recordingNotificationRepo) Create(ctx context.Context, notification *types.UserNotification) error {
+	r.created <- notification
+	return nil
+}
+
+// reviewOrgRepo records the review status instead of writing it, since the repository sets
+// reviewed_at with NOW(), which sqlite lacks
+type reviewOrgRepo struct {
+	interfaces.OrganizationRepository
+	status types.JoinRequestStatus
+}
+
+func (r *reviewOrgRepo) UpdateJoinRequestStatus(ctx context.Context,
+	id string, status types.JoinRequestStatus, reviewedBy string, reviewMessage string,
+) error {
+	r.status = status
+	return nil
+}
+
+func TestReviewJoinRequestNotifiesRequester(t *testing.T) {
+	ctx := context.Background()
+	editor := types.OrgRoleEditor
+
+	tests := []struct {
+		name        string
+		request     *types.OrganizationJoinRequest
+		approved    bool
+		assignRole  *types.OrgMemberRole
+		wantStatus  types.JoinRequestStatus
+		wantRole    types.OrgMemberRole
+		wantReqType types.JoinRequestType
+	}{
+		{
+			name:        "approved join",
+			request:     &types.OrganizationJoinRequest{ID: "req-1", OrganizationID: "org-1", UserID: "applicant", TenantID: 5, RequestType: types.JoinRequestTypeJoin, RequestedRole: types.OrgRoleViewer},
+			approved:    true,
+			assignRole:  &editor,
+			wantStatus:  types.JoinRequestStatusApproved,
+			wantRole:    types.OrgRoleEditor,
+			wantReqType: types.JoinRequestTypeJoin,
+		},
+		{
+			name:        "rejected upgrade",
+			request:     &types.OrganizationJoinRequest{ID: "req-1", OrganizationID: "org-1", UserID: "viewer", TenantID: 2, RequestType: types.JoinRequestTypeUpgrade, RequestedRole: types.OrgRoleAdmin, PrevRole: types.OrgRoleViewer},
+			wantStatus:  types.JoinRequestStatusRejected,
+			wantReqType: types.JoinRequestTypeUpgrade,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.request.Status = types.JoinRequestStatusPending
+			tt.request.CreatedAt = time.Now()
+			notifications := &recordingNotificationRepo{created: make(chan *types.UserNotification, 1)}
+			orgRepo := &reviewOrgRepo{OrganizationRepository: newOrgTestRepo(t,
+				&types.Organization{ID: "org-1", Name: "team", OwnerID: "owner", InviteCode: "code"},
+				&types.OrganizationMember{ID: "m-1", OrganizationID: "org-1", UserID: "owner", TenantID: 1, Role: types.OrgRoleAdmin},
+				&types.OrganizationMember{ID: "m-2", OrganizationID: "org-1", UserID: "viewer", TenantID: 2, Role: types.OrgRoleViewer},
+				tt.request,
+			)}
+			s := &organizationService{orgRepo: orgRepo, notificationRepo: notifications}
+
+			if err := s.ReviewJoinRequest(ctx, "org-1", "req-1", tt.approved, "owner", "see you", tt.assignRole); err != nil {
+				t.Fatalf("ReviewJoinRequest() error = %v", err)
+			}
+			if orgRepo.status != tt.wantStatus {
+				t.Fatalf("request status = %q, want %q", orgRepo.status, tt.wantStatus)
+			}
+
+			var notification *types.UserNotification
+			select {
+			case notification = <-notifications.created:
+			case <-time.After(5 * time.Second):
+				t.Fatal("no notification stored for the requester")
+			}
+			if notification.UserID != tt.request.UserID || notification.Type != types.UserNotificationOrgRequestReviewed {
+				t.Fatalf("notification = %+v, want an %s notification for %s",
+					notification, types.UserNotificationOrgRequestReviewed, tt.request.UserID)
+			}
+			var data types.OrgRequestReviewedData
+			if err := json.Unmarshal(notification.Data, &data); err != nil {
+				t.Fatalf("unmarshal notification data: %v", err)
+			}
+			want := types.OrgRequestReviewedData{
+				OrganizationID:   "org-1",
+				OrganizationName: "team",
+				RequestID:        "req-1",
+				RequestType:      tt.wantReqType,
+				Status:           tt.wantStatus,
+				Role:             tt.wantRole,
+				ReviewMessage:    "see you",
+			}
+			if data != want {
+				t.Errorf("notification data = %+v, want %+v", data, want)
+			}
+		})
+	}
+}
+
+func TestReviewJoinRequestDoesNotNotifyWhenRefused(t *testing.T) {
+	ctx := context.Background()
+	notifications := &recordingNotificationRepo{created: make(chan *types.UserNotification, 1)}
+	s := &organizationService{
+		orgRepo: newOrgTestRepo(t,
+			&types.Organization{ID: "org-1", Name: "team", OwnerID: "owner", InviteCode: "code"},
+			&types.OrganizationJoinRequest{ID: "req-1", OrganizationID: "org-1", UserID: "applicant", TenantID: 5,
+				RequestType: types.JoinRequestTypeJoin, Status: types.JoinRequestStatusApproved, CreatedAt: time.Now()},
+		),
+		notificationRepo: notifications,
+	}
+
+	if err := s.ReviewJoinRequest(ctx, "org-1", "req-1", false, "owner", "", nil); err != ErrJoinRequestReviewed {
+		t.Fatalf("ReviewJoinRequest() error = %v, want %v", err, ErrJoinRequestReviewed)
+	}
+	select {
+	case notification := <-notifications.created:
+		t.Fatalf("notification stored for a refused review: %+v", notification)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestJoinRequestCutoff(t *testing.T) {
+	now := time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		globalDays int
+		orgDays    int
+		want       *time.Time
+	}{
+		{"organization TTL wins", 30, 7, ptrTime(now.AddDate(0, 0, -7))},
+		{"global TTL by default", 30, 0, ptrTime(now.AddDate(0, 0, -30))},
+		{"never expire", 0, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &organizationService{joinRequestTTLDays: tt.globalDays}
+			got := s.joinRequestCutoff(&types.Organization{JoinRequestTTLDays: tt.orgDays}, now)
+			if (got == nil) != (tt.want == nil) || (got != nil && !got.Equal(*tt.want)) {
+				t.Fatalf("joinRequestCutoff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func ptrTime(t time.Time) *time.Time {
+	return &t
+}
+
+// newExpiryOrgRepo returns a repository with org-1 expiring requests after 7 days and org-2
+// using the global TTL, each with a stale and a fresh pending request
+func newExpiryOrgRepo(t *testing.T) interfaces.OrganizationRepository {
+	t.Helper()
+	now := time.Now()
+	request := func(id, orgID string, age time.Duration, status types.JoinRequestStatus) *types.OrganizationJoinRequest {
+		return &types.OrganizationJoinRequest{ID: id, OrganizationID: orgID, UserID: "user-" + id, TenantID: 9,
+			RequestType: types.JoinRequestTypeJoin, Status: status, CreatedAt: now.Add(-age)}
+	}
+	day := 24 * time.Hour
+	return newOrgTestRepo(t,
+		&types.Organization{ID: "org-1", Name: "short", OwnerID: "owner", InviteCode: "code-1", JoinRequestTTLDays: 7},
+		&types.Organization{ID: "org-2", Name: "default", OwnerID: "owner", InviteCode: "code-2"},
+		request("stale-1", "org-1", 10*day, types.JoinRequestStatusPending),
+		request("fresh-1", "org-1", 3*day, types.JoinRequestStatusPending),
+		request("rejected-1", "org-1", 10*day, types.JoinRequestStatusRejected),
+		request("stale-2", "org-2", 40*day, types.JoinRequestStatusPending),
+		request("fresh-2", "org-2", 10*day, types.JoinRequestStatusPending),
+	)
+}
+
+func TestExpireStaleJoinRequests(t *testing.T) {
+	ctx := context.Background()
+	orgRepo := newExpiryOrgRepo(t)
+	s := &organizationService{orgRepo: orgRepo, joinRequestTTLDays: 30}
+
+	if err := s.ExpireStaleJoinRequests(ctx); err != nil {
+		t.Fatalf("ExpireStaleJoinRequests() error = %v", err)
+	}
+
+	want := map[string]types.JoinRequestStatus{
+		"stale-1":    types.JoinRequestStatusExpired,
+		"fresh-1":    types.JoinRequestStatusPending,
+		"rejected-1": types.JoinRequestStatusRejected,
+		"stale-2":    types.JoinRequestStatusExpired,
+		"fresh-2":    types.JoinRequestStatusPending,
+	}
+	for id, status := range want {
+		request, err := orgRepo.GetJoinRequestByID(ctx, id)
+		if err != nil {
+			t.Fatalf("GetJoinRequestByID(%s) error = %v", id, err)
+		}
+		if request.Status != status {
+			t.Errorf("request %s status = %q, want %q", id, request.Status, status)
+		}
+	}
+}
+
+func TestExpireStaleJoinRequestsWithoutTTL(t *testing.T) {
+	ctx := context.Background()
+	orgRepo := newExpiryOrgRepo(t)
+	s := &organizationService{orgRepo: orgRepo}
+
+	if err := s.ExpireStaleJoinRequests(ctx); err != nil {
+		t.Fatalf("ExpireStaleJoinRequests() error = %v", err)
+	}
+	request, err := orgRepo.GetJoinRequestByID(ctx, "stale-2")
+	if err != nil {
+		t.Fatalf("GetJoinRequestByID() error = %v", err)
+	}
+	if request.Status != types.JoinRequestStatusPending {
+		t.Errorf("status = %q, want requests of organizations without a TTL kept pending", request.Status)
+	}
+}
+
+func TestStaleJoinRequestsExpireBeforeTheSweep(t *testing.T) {
+	ctx := context.Background()
+	s := &organizationService{orgRepo: newExpiryOrgRepo(t), joinRequestTTLDays: 30}
+
+	requests, err := s.ListJoinRequests(ctx, "org-1")
+	if err != nil {
+		t.Fatalf("ListJoinRequests() error = %v", err)
+	}
+	for _, request := range requests {
+		wantStatus := types.JoinRequestStatusPending
+		switch request.ID {
+		case "stale-1":
+			wantStatus = types.JoinRequestStatusExpired
+		case "rejected-1":
+			wantStatus = types.JoinRequestStatusRejected
+		}
+		if request.Status != wantStatus {
+			t.Errorf("request %s status = %q, want %q", request.ID, request.Status, wantStatus)
+		}
+	}
+
+	count, err := s.CountPendingJoinRequests(ctx, "org-1")
+	if err != nil {
+		t.Fatalf("CountPendingJoinRequests() error = %v", err)
+	}
+	if count != 1 {
+		t.Errorf("CountPendingJoinRequests() = %d, want 1", count)
+	}
+
+	if err := s.ReviewJoinRequest(ctx, "org-1", "stale-1", true, "owner", "", nil); err != ErrJoinRequestExpired {
+		t.Errorf("ReviewJoinRequest() error = %v, want %v", err, ErrJoinRequestExpired)
+	}
+}
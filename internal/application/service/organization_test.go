@@ -0,0 +1,509 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// inviteCodeRepo keeps the invite codes in use and can fail the next creates as if another
+// organization had taken the code concurrently
+type inviteCodeRepo struct {
+	interfaces.OrganizationRepository
+	codes      map[string]bool
+	raceLosses int
+}
+
+func (r *inviteCodeRepo) InviteCodeExists(ctx context.Context, inviteCode string) (bool, error) {
+	return r.codes[inviteCode], nil
+}
+
+func (r *inviteCodeRepo) Create(ctx context.Context, org *types.Organization) error {
+	if r.raceLosses > 0 {
+		r.raceLosses--
+		return repository.ErrInviteCodeTaken
+	}
+	if r.codes[org.InviteCode] {
+		return repository.ErrInviteCodeTaken
+	}
+	r.codes[org.InviteCode] = true
+	return nil
+}
+
+func (r *inviteCodeRepo) AddMember(ctx context.Context, member *types.OrganizationMember) error {
+	return nil
+}
+
+// sequenceCodes returns a generator yielding codes in order, repeating the last one
+func sequenceCodes(codes ...string) func() string {
+	i := 0
+	return func() string {
+		code := codes[min(i, len(codes)-1)]
+		i++
+		return code
+	}
+}
+
+func TestCreateOrganizationRetriesInviteCodeCollisions(t *testing.T) {
+	ctx := context.Background()
+	req := &types.CreateOrganizationRequest{Name: "team"}
+
+	tests := []struct {
+		name       string
+		codes      []string
+		raceLosses int
+		want       string
+		wantErr    error
+	}{
+		{"existing code", []string{"taken", "fresh"}, 0, "fresh", nil},
+		{"code taken concurrently", []string{"first", "second"}, 1, "second", nil},
+		{"every attempt collides", []string{"taken"}, 0, "", ErrInviteCodeGeneration},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &inviteCodeRepo{codes: map[string]bool{"taken": true}, raceLosses: tt.raceLosses}
+			s := &organizationService{orgRepo: repo, newInviteCode: sequenceCodes(tt.codes...)}
+
+			org, err := s.CreateOrganization(ctx, "user-1", 1, req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CreateOrganization() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && org.InviteCode != tt.want {
+				t.Errorf("invite code = %q, want %q", org.InviteCode, tt.want)
+			}
+		})
+	}
+}
+
+// avatarOrgRepo holds a single organization and its members
+type avatarOrgRepo struct {
+	interfaces.OrganizationRepository
+	org     *types.Organization
+	members map[string]types.OrgMemberRole
+}
+
+func (r *avatarOrgRepo) GetByID(ctx context.Context, id string) (*types.Organization, error) {
+	if r.org == nil || r.org.ID != id {
+		return nil, repository.ErrOrganizationNotFound
+	}
+	org := *r.org
+	return &org, nil
+}
+
+func (r *avatarOrgRepo) Update(ctx context.Context, org *types.Organization) error {
+	r.org = org
+	return nil
+}
+
+func (r *avatarOrgRepo) GetMember(ctx context.Context, orgID string, userID string) (*types.OrganizationMember, error) {
+	role, ok := r.members[userID]
+	if !ok {
+		return nil, repository.ErrOrgMemberNotFound
+	}
+	return &types.OrganizationMember{OrganizationID: orgID, UserID: userID, Role: role}, nil
+}
+
+// avatarFileService records saved and deleted files
+type avatarFileService struct {
+	interfaces.FileService
+	deleted []string
+}
+
+func (f *avatarFileService) SaveBytes(ctx context.Context,
+	data []byte, tenantID uint64, fileName string, temp bool,
+) (string, error) {
+	return "local://uploads/" + fileName, nil
+}
+
+func (f *avatarFileService) GetFile(ctx context.Context, filePath string) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader(filePath)), nil
+}
+
+func (f *avatarFileService) DeleteFile(ctx context.Context, filePath string) error {
+	f.deleted = append(f.deleted, filePath)
+	return nil
+}
+
+func TestCreateOrganizationAvatarURL(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		avatar  string
+		want    string
+		wantErr error
+	}{
+		{"", "", nil},
+		{" https://example.com/logo.png ", "https://example.com/logo.png", nil},
+		{"http://example.com/logo.png", "http://example.com/logo.png", nil},
+		{"local://1/exports/other-tenant.pdf", "", ErrInvalidAvatarURL},
+		{"minio://bucket/2/exports/report.pdf", "", ErrInvalidAvatarURL},
+		{"javascript:alert(1)", "", ErrInvalidAvatarURL},
+		{"https://", "", ErrInvalidAvatarURL},
+	}
+	for _, tt := range tests {
+		t.Run(tt.avatar, func(t *testing.T) {
+			repo := &inviteCodeRepo{codes: map[string]bool{}}
+			s := &organizationService{orgRepo: repo, newInviteCode: sequenceCodes("code")}
+
+			org, err := s.CreateOrganization(ctx, "user-1", 1,
+				&types.CreateOrganizationRequest{Name: "team", Avatar: tt.avatar})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CreateOrganization() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (org.Avatar != tt.want || org.HasStoredAvatar()) {
+				t.Errorf("avatar = %q, path = %q, want %q and no path", org.Avatar, org.AvatarPath, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateOrganizationAvatarURL(t *testing.T) {
+	ctx := context.Background()
+	uploaded := "local://uploads/org_avatar_org-1.png"
+
+	tests := []struct {
+		name        string
+		avatar      string
+		wantErr     error
+		wantAvatar  string
+		wantDeleted []string
+	}{
+		{"url replaces upload", "https://example.com/logo.png", nil, "https://example.com/logo.png", []string{uploaded}},
+		{"clear avatar", "", nil, "", []string{uploaded}},
+		{"storage path rejected", "minio://bucket/2/exports/report.pdf", ErrInvalidAvatarURL, "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &avatarOrgRepo{
+				org:     &types.Organization{ID: "org-1", AvatarPath: uploaded},
+				members: map[string]types.OrgMemberRole{"admin": types.OrgRoleAdmin},
+			}
+			files := &avatarFileService{}
+			s := &organizationService{orgRepo: repo, fileService: files}
+
+			org, err := s.UpdateOrganization(ctx, "org-1", "admin", &types.UpdateOrganizationRequest{Avatar: &tt.avatar})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UpdateOrganization() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (org.Avatar != tt.wantAvatar || org.HasStoredAvatar()) {
+				t.Errorf("avatar = %q, path = %q, want %q and no path", org.Avatar, org.AvatarPath, tt.wantAvatar)
+			}
+			if err != nil && repo.org.AvatarPath != uploaded {
+				t.Errorf("stored avatar = %q after rejected update, want %q", repo.org.AvatarPath, uploaded)
+			}
+			if strings.Join(files.deleted, ",") != strings.Join(tt.wantDeleted, ",") {
+				t.Errorf("deleted = %v, want %v", files.deleted, tt.wantDeleted)
+			}
+		})
+	}
+}
+
+func TestUploadAvatarStoresPathSeparately(t *testing.T) {
+	ctx := context.Background()
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	repo := &avatarOrgRepo{
+		org:     &types.Organization{ID: "org-1", Avatar: "https://example.com/logo.png", AvatarPath: "local://uploads/old.png"},
+		members: map[string]types.OrgMemberRole{"admin": types.OrgRoleAdmin, "viewer": types.OrgRoleViewer},
+	}
+	files := &avatarFileService{}
+	s := &organizationService{orgRepo: repo, fileService: files}
+
+	if _, err := s.UploadAvatar(ctx, "org-1", "viewer", 1, png); !errors.Is(err, ErrOrgPermissionDenied) {
+		t.Fatalf("UploadAvatar() by viewer error = %v, want %v", err, ErrOrgPermissionDenied)
+	}
+
+	org, err := s.UploadAvatar(ctx, "org-1", "admin", 1, png)
+	if err != nil {
+		t.Fatalf("UploadAvatar() error = %v", err)
+	}
+	if org.AvatarPath != "local://uploads/org_avatar_org-1.png" || org.Avatar != "" {
+		t.Errorf("avatar = %q, path = %q, want the upload in the path only", org.Avatar, org.AvatarPath)
+	}
+	if got := org.AvatarURL(); got != "/api/v1/organizations/org-1/avatar" {
+		t.Errorf("AvatarURL() = %q", got)
+	}
+	if len(files.deleted) != 1 || files.deleted[0] != "local://uploads/old.png" {
+		t.Errorf("deleted = %v, want the previous upload", files.deleted)
+	}
+}
+
+func TestGetAvatarRequiresMembershipUnlessSearchable(t *testing.T) {
+	ctx := context.Background()
+	uploaded := "local://uploads/org_avatar_org-1.png"
+
+	tests := []struct {
+		name       string
+		searchable bool
+		avatarPath string
+		userID     string
+		wantErr    error
+	}{
+		{"member", false, uploaded, "member", nil},
+		{"non-member of private org", false, uploaded, "outsider", ErrOrgNotFound},
+		{"non-member of searchable org", true, uploaded, "outsider", nil},
+		{"no uploaded avatar", false, "", "member", ErrOrgAvatarNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &avatarOrgRepo{
+				org: &types.Organization{
+					ID: "org-1", Avatar: "https://example.com/logo.png",
+					AvatarPath: tt.avatarPath, Searchable: tt.searchable,
+				},
+				members: map[string]types.OrgMemberRole{"member": types.OrgRoleViewer},
+			}
+			s := &organizationService{orgRepo: repo, fileService: &avatarFileService{}}
+
+			reader, filePath, err := s.GetAvatar(ctx, "org-1", tt.userID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetAvatar() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer reader.Close()
+			if filePath != uploaded {
+				t.Errorf("file path = %q, want %q", filePath, uploaded)
+			}
+		})
+	}
+}
+
+// newOrgTestRepo returns an organization repository on an in-memory database seeded with records
+func newOrgTestRepo(t *testing.T, records ...any) interfaces.OrganizationRepository {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger:                                   gormlogger.Discard,
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	// A single connection keeps every query on the same in-memory database
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("get sql db: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&types.Organization{}, &types.OrganizationMember{},
+		&types.OrganizationJoinRequest{}, &types.OrganizationPendingInvite{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	// Pending invites are upserted on this index, which the migrations create
+	if err := db.Exec("CREATE UNIQUE INDEX idx_org_pending_invites_org_email " +
+		"ON organization_pending_invites(organization_id, email)").Error; err != nil {
+		t.Fatalf("create index: %v", err)
+	}
+	for _, record := range records {
+		if err := db.Create(record).Error; err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+	return repository.NewOrganizationRepository(db)
+}
+
+// newSuspensionOrgRepo returns an organization repository holding org-1, owned by "owner",
+// with an active admin, an active viewer and a suspended editor
+func newSuspensionOrgRepo(t *testing.T) interfaces.OrganizationRepository {
+	t.Helper()
+	return newOrgTestRepo(t,
+		&types.Organization{ID: "org-1", Name: "team", OwnerID: "owner", InviteCode: "code", Searchable: true},
+		&types.OrganizationMember{ID: "m-1", OrganizationID: "org-1", UserID: "owner", TenantID: 1, Role: types.OrgRoleAdmin},
+		&types.OrganizationMember{ID: "m-2", OrganizationID: "org-1", UserID: "viewer", TenantID: 2, Role: types.OrgRoleViewer},
+		&types.OrganizationMember{ID: "m-3", OrganizationID: "org-1", UserID: "suspended", TenantID: 3, Role: types.OrgRoleEditor, Suspended: true},
+	)
+}
+
+func TestSuspendedMemberHasNoAccess(t *testing.T) {
+	ctx := context.Background()
+	s := &organizationService{orgRepo: newSuspensionOrgRepo(t)}
+
+	if _, err := s.GetMember(ctx, "org-1", "suspended"); !errors.Is(err, ErrUserNotInOrg) {
+		t.Errorf("GetMember() error = %v, want %v", err, ErrUserNotInOrg)
+	}
+	if _, err := s.GetUserRoleInOrg(ctx, "org-1", "suspended"); !errors.Is(err, ErrUserNotInOrg) {
+		t.Errorf("GetUserRoleInOrg() error = %v, want %v", err, ErrUserNotInOrg)
+	}
+
+	if err := s.UnsuspendMember(ctx, "org-1", "suspended", "owner"); err != nil {
+		t.Fatalf("UnsuspendMember() error = %v", err)
+	}
+	member, err := s.GetMember(ctx, "org-1", "suspended")
+	if err != nil || member.Role != types.OrgRoleEditor {
+		t.Fatalf("GetMember() after unsuspending = %+v, %v, want the editor role kept", member, err)
+	}
+}
+
+func TestSuspendMemberPermissions(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		member   string
+		operator string
+		wantErr  error
+	}{
+		{"admin suspends viewer", "viewer", "owner", nil},
+		{"owner cannot be suspended", "owner", "owner", ErrCannotSuspendOwner},
+		{"viewer cannot suspend", "viewer", "viewer", ErrOrgPermissionDenied},
+		{"suspended editor cannot suspend", "viewer", "suspended", ErrOrgPermissionDenied},
+		{"unknown member", "stranger", "owner", ErrUserNotInOrg},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &organizationService{orgRepo: newSuspensionOrgRepo(t)}
+			if err := s.SuspendMember(ctx, "org-1", tt.member, tt.operator); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SuspendMember() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSuspendedMemberCannotRejoin(t *testing.T) {
+	ctx := context.Background()
+	s := &organizationService{orgRepo: newSuspensionOrgRepo(t)}
+
+	if _, err := s.JoinByInviteCode(ctx, "code", "suspended", 3); !errors.Is(err, ErrMemberSuspended) {
+		t.Errorf("JoinByInviteCode() error = %v, want %v", err, ErrMemberSuspended)
+	}
+	if _, err := s.JoinByOrganizationID(ctx, "org-1", "suspended", 3, "", ""); !errors.Is(err, ErrMemberSuspended) {
+		t.Errorf("JoinByOrganizationID() error = %v, want %v", err, ErrMemberSuspended)
+	}
+	if _, err := s.SubmitJoinRequest(ctx, "org-1", "suspended", 3, "", types.OrgRoleViewer); !errors.Is(err, ErrMemberSuspended) {
+		t.Errorf("SubmitJoinRequest() error = %v, want %v", err, ErrMemberSuspended)
+	}
+	if err := s.AddMember(ctx, "org-1", "suspended", 3, types.OrgRoleViewer); !errors.Is(err, ErrMemberSuspended) {
+		t.Errorf("AddMember() error = %v, want %v", err, ErrMemberSuspended)
+	}
+}
+
+func TestCreateOrganizationOwnedLimit(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		max     int
+		wantErr error
+	}{
+		{"unlimited", 0, nil},
+		{"below the cap", 3, nil},
+		{"at the cap", 2, ErrOwnedOrgLimitReached},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// user-1 owns two organizations; the one owned by user-2 does not count
+			s := &organizationService{
+				orgRepo: newOrgTestRepo(t,
+					&types.Organization{ID: "org-1", Name: "a", OwnerID: "user-1", InviteCode: "code-1"},
+					&types.Organization{ID: "org-2", Name: "b", OwnerID: "user-1", InviteCode: "code-2"},
+					&types.Organization{ID: "org-3", Name: "c", OwnerID: "user-2", InviteCode: "code-3"},
+				),
+				maxOwnedOrgs:  tt.max,
+				newInviteCode: sequenceCodes("code-4"),
+			}
+
+			_, err := s.CreateOrganization(ctx, "user-1", 1, &types.CreateOrganizationRequest{Name: "team"})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CreateOrganization() error = %v, want %v", err, tt.wantErr)
+			}
+			owned, err := s.CountOwnedOrganizations(ctx, "user-1")
+			if err != nil {
+				t.Fatalf("CountOwnedOrganizations() error = %v", err)
+			}
+			wantOwned := int64(3)
+			if tt.wantErr != nil {
+				wantOwned = 2
+			}
+			if owned != wantOwned {
+				t.Errorf("CountOwnedOrganizations() = %d, want %d", owned, wantOwned)
+			}
+		})
+	}
+}
+
+// countingShareRepo reports fixed share counts per organization
+type countingShareRepo struct {
+	interfaces.KBShareRepository
+	counts map[string]int64
+}
+
+func (r *countingShareRepo) CountByOrganizations(ctx context.Context, orgIDs []string) (map[string]int64, error) {
+	return r.counts, nil
+}
+
+// countingAgentShareRepo reports fixed agent share counts per organization
+type countingAgentShareRepo struct {
+	interfaces.AgentShareRepository
+	counts map[string]int64
+}
+
+func (r *countingAgentShareRepo) CountByOrganizations(ctx context.Context, orgIDs []string) (map[string]int64, error) {
+	return r.counts, nil
+}
+
+func TestSearchSearchableOrganizationsMembershipHints(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	s := &organizationService{
+		orgRepo: newOrgTestRepo(t,
+			&types.Organization{ID: "member", Name: "member", OwnerID: "owner", InviteCode: "code-1", Searchable: true},
+			&types.Organization{ID: "full", Name: "full", OwnerID: "owner", InviteCode: "code-2", Searchable: true, MemberLimit: 1},
+			&types.Organization{ID: "stale", Name: "stale", OwnerID: "owner", InviteCode: "code-3", Searchable: true, JoinRequestTTLDays: 7},
+			&types.Organization{ID: "suspended", Name: "suspended", OwnerID: "owner", InviteCode: "code-4", Searchable: true},
+			&types.Organization{ID: "hidden", Name: "hidden", OwnerID: "owner", InviteCode: "code-5"},
+			&types.OrganizationMember{ID: "m-1", OrganizationID: "member", UserID: "user", TenantID: 2, Role: types.OrgRoleViewer},
+			&types.OrganizationMember{ID: "m-2", OrganizationID: "full", UserID: "owner", TenantID: 1, Role: types.OrgRoleAdmin},
+			&types.OrganizationMember{ID: "m-3", OrganizationID: "suspended", UserID: "user", TenantID: 2, Role: types.OrgRoleViewer, Suspended: true},
+			&types.OrganizationJoinRequest{ID: "r-1", OrganizationID: "full", UserID: "user", TenantID: 2,
+				RequestType: types.JoinRequestTypeJoin, Status: types.JoinRequestStatusPending, CreatedAt: now},
+			&types.OrganizationJoinRequest{ID: "r-2", OrganizationID: "stale", UserID: "user", TenantID: 2,
+				RequestType: types.JoinRequestTypeJoin, Status: types.JoinRequestStatusPending, CreatedAt: now.AddDate(0, 0, -10)},
+		),
+		shareRepo:      &countingShareRepo{counts: map[string]int64{"member": 3}},
+		agentShareRepo: &countingAgentShareRepo{counts: map[string]int64{"full": 2}},
+	}
+
+	resp, err := s.SearchSearchableOrganizations(ctx, "user", "", 10)
+	if err != nil {
+		t.Fatalf("SearchSearchableOrganizations() error = %v", err)
+	}
+	got := make(map[string]types.SearchableOrganizationItem, len(resp.Organizations))
+	for _, item := range resp.Organizations {
+		got[item.ID] = item
+	}
+	if _, ok := got["hidden"]; ok || len(got) != 4 {
+		t.Fatalf("organizations = %v, want the four searchable ones", got)
+	}
+
+	want := map[string]struct {
+		member, full, pending bool
+		members, shares       int
+		agentShares           int
+	}{
+		"member":    {member: true, members: 1, shares: 3},
+		"full":      {full: true, pending: true, members: 1, agentShares: 2},
+		"stale":     {},
+		"suspended": {members: 1},
+	}
+	for id, w := range want {
+		item := got[id]
+		if item.IsAlreadyMember != w.member || item.IsFull != w.full || item.HasPendingRequest != w.pending {
+			t.Errorf("%s: member = %v, full = %v, pending = %v, want %v, %v, %v", id,
+				item.IsAlreadyMember, item.IsFull, item.HasPendingRequest, w.member, w.full, w.pending)
+		}
+		if item.MemberCount != w.members || item.ShareCount != w.shares || item.AgentShareCount != w.agentShares {
+			t.Errorf("%s: members = %d, shares = %d, agent shares = %d, want %d, %d, %d", id,
+				item.MemberCount, item.ShareCount, item.AgentShareCount, w.members, w.shares, w.agentShares)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/google/uuid"
+)
+
+// Prompt template related errors
+var (
+	ErrPromptTemplateNameRequired = errors.New("prompt template name is required")
+	ErrPromptTemplateContentEmpty = errors.New("prompt template content cannot be empty")
+)
+
+// promptTemplateService implements the PromptTemplateService interface
+type promptTemplateService struct {
+	repo interfaces.PromptTemplateRepository
+}
+
+// NewPromptTemplateService creates a new prompt template service
+func NewPromptTemplateService(repo interfaces.PromptTemplateRepository) interfaces.PromptTemplateService {
+	return &promptTemplateService{repo: repo}
+}
+
+// CreateTemplate creates a new prompt template
+func (s *promptTemplateService) CreateTemplate(
+	ctx context.Context, template *types.PromptTemplate,
+) (*types.PromptTemplate, error) {
+	if err := validatePromptTemplate(template); err != nil {
+		return nil, err
+	}
+	tenantID, ok := types.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, ErrInvalidTenantID
+	}
+
+	template.ID = uuid.New().String()
+	template.TenantID = tenantID
+	template.CreatedAt = time.Now()
+	template.UpdatedAt = template.CreatedAt
+
+	if err := s.repo.Create(ctx, template); err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"tenant_id": tenantID,
+		})
+		return nil, err
+	}
+	logger.Infof(ctx, "Prompt template created, ID: %s, tenant ID: %d", template.ID, tenantID)
+	return template, nil
+}
+
+// GetTemplate retrieves a prompt template by ID
+func (s *promptTemplateService) GetTemplate(ctx context.Context, id string) (*types.PromptTemplate, error) {
+	tenantID, ok := types.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, ErrInvalidTenantID
+	}
+	return s.repo.GetByID(ctx, tenantID, id)
+}
+
+// ListTemplates lists all prompt templates of the current tenant
+func (s *promptTemplateService) ListTemplates(ctx context.Context) ([]*types.PromptTemplate, error) {
+	tenantID, ok := types.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, ErrInvalidTenantID
+	}
+	return s.repo.List(ctx, tenantID)
+}
+
+// UpdateTemplate updates a prompt template; agents referencing it pick up the new text on their next request
+func (s *promptTemplateService) UpdateTemplate(
+	ctx context.Context, template *types.PromptTemplate,
+) (*types.PromptTemplate, error) {
+	if err := validatePromptTemplate(template); err != nil {
+		return nil, err
+	}
+	tenantID, ok := types.TenantIDFromContext(ctx)
+	if !ok {
+		return nil, ErrInvalidTenantID
+	}
+
+	existing, err := s.repo.GetByID(ctx, tenantID, template.ID)
+	if err != nil {
+		return nil, err
+	}
+	existing.Name = template.Name
+	existing.Description = template.Description
+	existing.Content = template.Content
+	existing.UpdatedAt = time.Now()
+
+	if err := s.repo.Update(ctx, existing); err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"template_id": template.ID,
+		})
+		return nil, err
+	}
+	logger.Infof(ctx, "Prompt template updated, ID: %s", existing.ID)
+	return existing, nil
+}
+
+// DeleteTemplate deletes a prompt template
+func (s *promptTemplateService) DeleteTemplate(ctx context.Context, id string) error {
+	tenantID, ok := types.TenantIDFromContext(ctx)
+	if !ok {
+		return ErrInvalidTenantID
+	}
+	if _, err := s.repo.GetByID(ctx, tenantID, id); err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, tenantID, id); err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"template_id": id,
+		})
+		return err
+	}
+	logger.Infof(ctx, "Prompt template deleted, ID: %s", id)
+	return nil
+}
+
+// ResolveSystemPrompt returns the referenced template text, falling back to the inline prompt,
+// with the request variables (see types.RequestPromptPlaceholders) substituted.
+// The template is looked up in the agent's own tenant so shared agents resolve against their source tenant.
+func (s *promptTemplateService) ResolveSystemPrompt(ctx context.Context, agent *types.CustomAgent) string {
+	if agent == nil {
+		return ""
+	}
+	prompt := agent.Config.SystemPrompt
+	if ref := agent.Config.SystemPromptRef; ref != "" {
+		template, err := s.repo.GetByID(ctx, agent.TenantID, ref)
+		if err != nil {
+			logger.Warnf(ctx, "Agent %s references unavailable prompt template %s, using inline system prompt: %v",
+				agent.ID, ref, err)
+		} else {
+			prompt = template.Content
+		}
+	}
+	return renderTemplate(prompt, requestPromptVariables(ctx))
+}
+
+// requestPromptVariables collects the safe request-scoped prompt variables available in ctx.
+// Variables whose source is missing are left out so their placeholders stay untouched.
+func requestPromptVariables(ctx context.Context) map[string]string {
+	vars := map[string]string{
+		types.PlaceholderDate.Name: time.Now().Format("2006-01-02"),
+	}
+	if user, ok := ctx.Value(types.UserContextKey).(*types.User); ok && user != nil && user.Username != "" {
+		vars[types.PlaceholderUserName.Name] = user.Username
+	}
+	if tenant, ok := types.TenantInfoFromContext(ctx); ok && tenant.Name != "" {
+		vars[types.PlaceholderOrgName.Name] = tenant.Name
+	}
+	return vars
+}
+
+// renderTemplate replaces {{name}} placeholders with the given values.
+// Placeholders without a value are left untouched so later rendering stages can still fill them.
+func renderTemplate(tmpl string, vars map[string]string) string {
+	if tmpl == "" || len(vars) == 0 || !strings.Contains(tmpl, "{{") {
+		return tmpl
+	}
+	pairs := make([]string, 0, len(vars)*2)
+	for name, value := range vars {
+		pairs = append(pairs, "{{"+name+"}}", value)
+	}
+	return strings.NewReplacer(pairs...).Replace(tmpl)
+}
+
+// validatePromptTemplate validates user-supplied prompt template fields
+func validatePromptTemplate(template *types.PromptTemplate) error {
+	if strings.TrimSpace(template.Name) == "" {
+		return ErrPromptTemplateNameRequired
+	}
+	if strings.TrimSpace(template.Content) == "" {
+		return ErrPromptTemplateContentEmpty
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package service
+
+import "testing"
+
+func TestRenderTemplate(t *testing.T) {
+	vars := map[string]string{"user_name": "alice", "date": "2026-01-02"}
+	tests := []struct {
+		name string
+		tmpl string
+		want string
+	}{
+		{"known variables", "Hi {{user_name}}, today is {{date}}.", "Hi alice, today is 2026-01-02."},
+		{"repeated variable", "{{user_name}}/{{user_name}}", "alice/alice"},
+		{"unknown variable untouched", "{{user_name}} asks {{query}} in {{org_name}}", "alice asks {{query}} in {{org_name}}"},
+		{"no placeholders", "plain prompt", "plain prompt"},
+		{"value is not re-expanded", "{{date}}", "2026-01-02"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderTemplate(tt.tmpl, vars); got != tt.want {
+				t.Errorf("renderTemplate(%q) = %q, want %q", tt.tmpl, got, tt.want)
+			}
+		})
+	}
+
+	if got := renderTemplate("{{user_name}}", map[string]string{"user_name": "{{date}}", "date": "x"}); got != "{{date}}" {
+		t.Errorf("renderTemplate substituted inside a value: %q", got)
+	}
+}
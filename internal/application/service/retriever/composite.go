@@ -128,6 +128,17 @@ func (c *CompositeRetrieveEngine) BatchUpdateChunkTagID(
 	})
 }
 
+// RebuildKeywordIndex rebuilds the keyword index of the given knowledge in the engines
+// used for keyword retrieval; vector-only engines are skipped
+func (c *CompositeRetrieveEngine) RebuildKeywordIndex(ctx context.Context, knowledgeIDList []string) error {
+	return c.concurrentExecWithError(ctx, func(ctx context.Context, engineInfo *engineInfo) error {
+		if !slices.Contains(engineInfo.retrieverType, types.KeywordsRetrieverType) {
+			return nil
+		}
+		return engineInfo.retrieveEngine.RebuildKeywordIndex(ctx, knowledgeIDList)
+	})
+}
+
 // concurrentRetrieve is a helper function for concurrent processing of retrieval parameters
 // and collecting results
 func concurrentRetrieve(
@@ -3,7 +3,6 @@ package retriever
 import (
 	"context"
 	"slices"
-	"time"
 
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/models/embedding"
@@ -72,18 +71,10 @@ func (v *KeywordsVectorHybridRetrieveEngineService) BatchIndex(ctx context.Conte
 		for _, indexInfo := range indexInfoList {
 			contentList = append(contentList, indexInfo.Content)
 		}
-		var embeddings [][]float32
-		var err error
-		for range 5 {
-			embeddings, err = embedder.BatchEmbedWithPool(ctx, embedder, contentList)
-			if err == nil {
-				break
-			} else {
-				logger.Errorf(ctx, "BatchEmbedWithPool failed: %v", err)
-				time.Sleep(100 * time.Millisecond)
-			}
-		}
+		// The pooler retries the failed batches itself
+		embeddings, err := embedder.BatchEmbedWithPool(ctx, embedder, contentList)
 		if err != nil {
+			logger.Errorf(ctx, "BatchEmbedWithPool failed: %v", err)
 			return err
 		}
 
@@ -283,3 +274,11 @@ func (v *KeywordsVectorHybridRetrieveEngineService) BatchUpdateChunkTagID(
 ) error {
 	return v.indexRepository.BatchUpdateChunkTagID(ctx, chunkTagMap)
 }
+
+// RebuildKeywordIndex rebuilds the keyword index of the given knowledge
+func (v *KeywordsVectorHybridRetrieveEngineService) RebuildKeywordIndex(
+	ctx context.Context,
+	knowledgeIDList []string,
+) error {
+	return v.indexRepository.RebuildKeywordIndex(ctx, knowledgeIDList)
+}
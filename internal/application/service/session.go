@@ -6,12 +6,14 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"slices"
 	"strings"
 
 	"github.com/Tencent/WeKnora/internal/agent/tools"
 	chatpipline "github.com/Tencent/WeKnora/internal/application/service/chat_pipline"
 	llmcontext "github.com/Tencent/WeKnora/internal/application/service/llmcontext"
 	"github.com/Tencent/WeKnora/internal/config"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
 	"github.com/Tencent/WeKnora/internal/event"
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/models/chat"
@@ -20,10 +22,14 @@ import (
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 )
 
+// titleConversationMessageLimit caps how many recent messages are read when regenerating a title
+const titleConversationMessageLimit = 20
+
 // generateEventID generates a unique event ID with type suffix for better traceability
 func generateEventID(suffix string) string {
 	return fmt.Sprintf("%s-%s", uuid.New().String()[:8], suffix)
@@ -45,6 +51,8 @@ type sessionService struct {
 	webSearchStateRepo   interfaces.WebSearchStateService // Service for web search state
 	kbShareService       interfaces.KBShareService        // Service for KB sharing operations
 	memoryService        interfaces.MemoryService         // Service for memory operations
+	promptTemplates      interfaces.PromptTemplateService // Service for resolving agent prompt template references
+	generations          *generationRegistry              // Answers currently being streamed
 }
 
 // NewSessionService creates a new session service instance with all required dependencies
@@ -62,6 +70,8 @@ func NewSessionService(cfg *config.Config,
 	webSearchStateRepo interfaces.WebSearchStateService,
 	kbShareService interfaces.KBShareService,
 	memoryService interfaces.MemoryService,
+	promptTemplates interfaces.PromptTemplateService,
+	redisClient *redis.Client,
 ) interfaces.SessionService {
 	return &sessionService{
 		cfg:                  cfg,
@@ -78,6 +88,8 @@ func NewSessionService(cfg *config.Config,
 		webSearchStateRepo:   webSearchStateRepo,
 		kbShareService:       kbShareService,
 		memoryService:        memoryService,
+		promptTemplates:      promptTemplates,
+		generations:          newGenerationRegistry(redisClient),
 	}
 }
 
@@ -172,6 +184,25 @@ func (s *sessionService) GetPagedSessionsByTenant(ctx context.Context,
 	return types.NewPageResult(total, pagination, sessions), nil
 }
 
+// GetPagedSessionsByAgent retrieves the current tenant's sessions started with an agent, with pagination
+func (s *sessionService) GetPagedSessionsByAgent(ctx context.Context,
+	agentID string, pagination *types.Pagination,
+) (*types.PageResult, error) {
+	tenantID := types.MustTenantIDFromContext(ctx)
+	sessions, total, err := s.sessionRepo.GetPagedByTenantAndAgent(ctx, tenantID, agentID, pagination)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"tenant_id": tenantID,
+			"agent_id":  agentID,
+			"page":      pagination.Page,
+			"page_size": pagination.PageSize,
+		})
+		return nil, err
+	}
+
+	return types.NewPageResult(total, pagination, sessions), nil
+}
+
 // UpdateSession updates an existing session's properties
 func (s *sessionService) UpdateSession(ctx context.Context, session *types.Session) error {
 	// Validate session ID
@@ -376,6 +407,90 @@ func (s *sessionService) GenerateTitle(ctx context.Context,
 		return "", errors.New("no user message found")
 	}
 
+	title, err := s.generateTitleText(ctx, message.Content, modelID)
+	if err != nil {
+		return "", err
+	}
+
+	// Store the title unless the user named the session while it was being generated
+	updated, err := s.sessionRepo.UpdateTitleIfEmpty(ctx, session.TenantID, session.ID, title)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		return "", err
+	}
+	if !updated {
+		current, err := s.sessionRepo.Get(ctx, session.TenantID, session.ID)
+		if err != nil {
+			return "", err
+		}
+		logger.Infof(ctx, "Session %s was titled meanwhile, keeping its title", session.ID)
+		title = current.Title
+	}
+	session.Title = title
+
+	return session.Title, nil
+}
+
+// RegenerateTitle replaces the session title with a freshly generated one, even if the session
+// is already titled. A single-question session is titled from that question, a longer one from
+// its recent questions so the title follows where the conversation went.
+func (s *sessionService) RegenerateTitle(ctx context.Context, sessionID string, modelID string) (string, error) {
+	session, err := s.GetSession(ctx, sessionID)
+	if err != nil {
+		return "", err
+	}
+
+	recent, err := s.messageRepo.GetRecentMessagesBySession(ctx, session.ID, titleConversationMessageLimit)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"session_id": session.ID,
+		})
+		return "", err
+	}
+	var questions []string
+	for _, m := range recent {
+		if m.Role == "user" && strings.TrimSpace(m.Content) != "" {
+			questions = append(questions, strings.TrimSpace(m.Content))
+		}
+	}
+	if len(questions) == 0 {
+		logger.Error(ctx, "No user message found, cannot regenerate title")
+		return "", errors.New("no user message found")
+	}
+
+	title, err := s.generateTitleText(ctx, strings.Join(questions, "\n"), modelID)
+	if err != nil {
+		return "", err
+	}
+
+	session.Title = title
+	if err := s.sessionRepo.Update(ctx, session); err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"session_id": session.ID,
+		})
+		return "", err
+	}
+
+	if err := event.Emit(ctx, event.Event{
+		Type:      event.EventSessionTitle,
+		SessionID: session.ID,
+		Data: event.SessionTitleData{
+			SessionID: session.ID,
+			Title:     title,
+		},
+	}); err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"session_id": session.ID,
+		})
+	}
+
+	logger.Infof(ctx, "Session title regenerated, session ID: %s, title: %s", session.ID, title)
+	return title, nil
+}
+
+// generateTitleText asks the chat model for a title summarizing the given content
+// modelID: optional model ID to use for title generation (if empty, uses first available KnowledgeQA model)
+func (s *sessionService) generateTitleText(ctx context.Context, content string, modelID string) (string, error) {
 	// Use provided modelID, or fallback to first available KnowledgeQA model
 	if modelID == "" {
 		models, err := s.modelService.ListModels(ctx)
@@ -415,7 +530,7 @@ func (s *sessionService) GenerateTitle(ctx context.Context,
 		chat.Message{Role: "system", Content: s.cfg.Conversation.GenerateSessionTitlePrompt},
 	)
 	chatMessages = append(chatMessages,
-		chat.Message{Role: "user", Content: message.Content},
+		chat.Message{Role: "user", Content: content},
 	)
 
 	// Call model to generate title
@@ -429,17 +544,7 @@ func (s *sessionService) GenerateTitle(ctx context.Context,
 		return "", err
 	}
 
-	// Process and store the generated title
-	session.Title = strings.TrimPrefix(response.Content, "<think>\n\n</think>")
-
-	// Update session with new title
-	err = s.sessionRepo.Update(ctx, session)
-	if err != nil {
-		logger.ErrorWithFields(ctx, err, nil)
-		return "", err
-	}
-
-	return session.Title, nil
+	return strings.TrimPrefix(response.Content, "<think>\n\n</think>"), nil
 }
 
 // GenerateTitleAsync generates a title for the session asynchronously
@@ -466,8 +571,8 @@ func (s *sessionService) GenerateTitleAsync(
 			bgCtx = context.WithValue(bgCtx, types.RequestIDContextKey, requestID)
 		}
 
-		// Skip if title already exists
-		if session.Title != "" {
+		// Skip if title already exists or the user turned auto-titling off
+		if !session.NeedsAutoTitle() {
 			return
 		}
 
@@ -510,27 +615,22 @@ func (s *sessionService) GenerateTitleAsync(
 
 // KnowledgeQA performs knowledge base question answering with LLM summarization
 // Events are emitted through eventBus (references, answer chunks, completion)
-// customAgent is optional - if provided, uses custom agent configuration for multiTurnEnabled and historyTurns
-func (s *sessionService) KnowledgeQA(
-	ctx context.Context,
-	session *types.Session,
-	query string,
-	knowledgeBaseIDs []string,
-	knowledgeIDs []string,
-	assistantMessageID string,
-	summaryModelID string,
-	webSearchEnabled bool,
-	eventBus *event.EventBus,
-	customAgent *types.CustomAgent,
-	enableMemory bool,
-) error {
+// req.CustomAgent is optional - if provided, uses custom agent configuration for multiTurnEnabled and historyTurns
+func (s *sessionService) KnowledgeQA(ctx context.Context, req *types.QARequest, eventBus *event.EventBus) error {
+	session, query, assistantMessageID := req.Session, req.Query, req.AssistantMessageID
+	knowledgeBaseIDs, knowledgeIDs := req.KnowledgeBaseIDs, req.KnowledgeIDs
+	summaryModelID, customAgent, sampling := req.SummaryModelID, req.CustomAgent, req.Sampling
+	filters, includeMetadata := req.Filters, req.IncludeMetadata
+	webSearchEnabled, enableMemory, verbosity := req.WebSearchEnabled, req.EnableMemory, req.Verbosity
+
 	logger.Infof(
 		ctx,
-		"Knowledge base question answering parameters, session ID: %s, query: %s, webSearchEnabled: %v, enableMemory: %v",
+		"Knowledge base question answering parameters, session ID: %s, query: %s, webSearchEnabled: %v, enableMemory: %v, verbosity: %s",
 		session.ID,
 		query,
 		webSearchEnabled,
 		enableMemory,
+		verbosity,
 	)
 
 	// Use custom agent's knowledge bases only if request didn't specify any
@@ -551,6 +651,16 @@ func (s *sessionService) KnowledgeQA(
 	} else {
 		knowledgeBaseIDs = s.resolveKnowledgeBasesFromAgent(ctx, customAgent, session.TenantID)
 	}
+	// Pinned knowledge bases are always searched, even when retrieval requires an explicit mention
+	if len(session.PinnedKnowledgeBaseIDs) > 0 {
+		knowledgeBaseIDs = session.WithPinnedKnowledgeBases(knowledgeBaseIDs)
+		logger.Infof(ctx, "Added session pinned knowledge bases: %v", session.PinnedKnowledgeBaseIDs)
+	}
+	// Files attached to the session are always searched as well
+	if _, attachmentIDs := s.webSearchStateRepo.GetSessionAttachments(ctx, session.ID); len(attachmentIDs) > 0 {
+		knowledgeIDs = appendUniqueIDs(knowledgeIDs, attachmentIDs)
+		logger.Infof(ctx, "Added session attachments: %v", attachmentIDs)
+	}
 
 	// Determine chat model ID: prioritize request's summaryModelID, then Remote models
 	chatModelID, err := s.selectChatModelIDWithOverride(ctx, session, knowledgeBaseIDs, knowledgeIDs, summaryModelID)
@@ -561,11 +671,14 @@ func (s *sessionService) KnowledgeQA(
 	// Initialize default values from config.yaml
 	rewritePromptSystem := s.cfg.Conversation.RewritePromptSystem
 	rewritePromptUser := s.cfg.Conversation.RewritePromptUser
+	rewritePromptsByLanguage := s.cfg.Conversation.RewritePromptsByLanguage
 	vectorThreshold := s.cfg.Conversation.VectorThreshold
 	keywordThreshold := s.cfg.Conversation.KeywordThreshold
 	embeddingTopK := s.cfg.Conversation.EmbeddingTopK
 	rerankTopK := s.cfg.Conversation.RerankTopK
 	rerankThreshold := s.cfg.Conversation.RerankThreshold
+	chunkContextWindow := s.cfg.Conversation.GetChunkContextWindow()
+	citationRequired := s.cfg.Conversation.CitationRequired
 	maxRounds := s.cfg.Conversation.MaxRounds
 	fallbackStrategy := types.FallbackStrategy(s.cfg.Conversation.FallbackStrategy)
 	fallbackResponse := s.cfg.Conversation.FallbackResponse
@@ -600,11 +713,16 @@ func (s *sessionService) KnowledgeQA(
 			chatModelID = customAgent.Config.ModelID
 			logger.Infof(ctx, "Using custom agent's model_id: %s", chatModelID)
 		}
-		// Override system prompt
-		if customAgent.Config.SystemPrompt != "" {
-			summaryConfig.Prompt = customAgent.Config.SystemPrompt
+		// Override system prompt (a referenced template takes precedence over the inline prompt)
+		if systemPrompt := s.promptTemplates.ResolveSystemPrompt(ctx, customAgent); systemPrompt != "" {
+			summaryConfig.Prompt = systemPrompt
 			logger.Infof(ctx, "Using custom agent's system_prompt")
 		}
+		// Few-shot examples
+		if len(customAgent.Config.FewShotExamples) > 0 {
+			summaryConfig.FewShotExamples = customAgent.Config.FewShotExamples
+			logger.Infof(ctx, "Using custom agent's %d few-shot examples", len(customAgent.Config.FewShotExamples))
+		}
 		// Override context template
 		if customAgent.Config.ContextTemplate != "" {
 			summaryConfig.ContextTemplate = customAgent.Config.ContextTemplate
@@ -626,6 +744,12 @@ func (s *sessionService) KnowledgeQA(
 		if customAgent.Config.Thinking != nil {
 			logger.Infof(ctx, "Using custom agent's thinking: %v", *customAgent.Config.Thinking)
 		}
+		// Structured output settings
+		if customAgent.Config.IsJSONMode() {
+			summaryConfig.ResponseFormat = customAgent.Config.ResponseFormat
+			summaryConfig.ResponseSchema = customAgent.Config.ResponseSchema
+			logger.Infof(ctx, "Using custom agent's response_format: %s", customAgent.Config.ResponseFormat)
+		}
 		// Override retrieval strategy settings
 		if customAgent.Config.EmbeddingTopK > 0 {
 			embeddingTopK = customAgent.Config.EmbeddingTopK
@@ -645,6 +769,9 @@ func (s *sessionService) KnowledgeQA(
 		if customAgent.Config.RerankModelID != "" {
 			rerankModelID = customAgent.Config.RerankModelID
 		}
+		if customAgent.Config.ChunkContextWindow > 0 {
+			chunkContextWindow = min(customAgent.Config.ChunkContextWindow, types.MaxChunkContextWindow)
+		}
 		// Override rewrite settings
 		enableRewrite = customAgent.Config.EnableRewrite
 		enableQueryExpansion = customAgent.Config.EnableQueryExpansion
@@ -654,6 +781,11 @@ func (s *sessionService) KnowledgeQA(
 		if customAgent.Config.RewritePromptUser != "" {
 			rewritePromptUser = customAgent.Config.RewritePromptUser
 		}
+		// An agent's own rewrite prompts take precedence over the global per-language prompts
+		if customAgent.Config.RewritePromptSystem != "" || customAgent.Config.RewritePromptUser != "" {
+			rewritePromptsByLanguage = nil
+		}
+		rewritePromptsByLanguage = rewritePromptsByLanguage.Merge(customAgent.Config.RewritePromptsByLanguage)
 		// Override fallback settings
 		if customAgent.Config.FallbackStrategy != "" {
 			fallbackStrategy = types.FallbackStrategy(customAgent.Config.FallbackStrategy)
@@ -664,6 +796,13 @@ func (s *sessionService) KnowledgeQA(
 		if customAgent.Config.FallbackPrompt != "" {
 			fallbackPrompt = customAgent.Config.FallbackPrompt
 		}
+		if customAgent.Config.NoMatchPrefix != "" {
+			summaryConfig.NoMatchPrefix = customAgent.Config.NoMatchPrefix
+		}
+		// An agent can require citations but not lift a global requirement
+		if customAgent.Config.CitationRequired {
+			citationRequired = true
+		}
 		// Override history turns
 		if customAgent.Config.HistoryTurns > 0 {
 			maxRounds = customAgent.Config.HistoryTurns
@@ -676,17 +815,30 @@ func (s *sessionService) KnowledgeQA(
 		}
 	}
 
+	// Request verbosity is applied last so a brief answer stays capped whatever the configured limit
+	if verbosity != "" {
+		summaryConfig.ApplyVerbosity(verbosity)
+	}
+	// Request sampling parameters override the tenant and agent configuration
+	if !sampling.IsEmpty() {
+		summaryConfig.ApplySampling(sampling)
+		logger.Infof(ctx, "Using request sampling override: temperature=%.2f, top_p=%.2f",
+			summaryConfig.Temperature, summaryConfig.TopP)
+	}
+
 	// Extract FAQ strategy settings from custom agent
 	var faqPriorityEnabled bool
 	var faqDirectAnswerThreshold float64
 	var faqScoreBoost float64
+	var faqExclusiveAboveThreshold bool
 	if customAgent != nil {
 		faqPriorityEnabled = customAgent.Config.FAQPriorityEnabled
 		faqDirectAnswerThreshold = customAgent.Config.FAQDirectAnswerThreshold
 		faqScoreBoost = customAgent.Config.FAQScoreBoost
+		faqExclusiveAboveThreshold = customAgent.Config.FAQExclusiveAboveThreshold
 		if faqPriorityEnabled {
-			logger.Infof(ctx, "FAQ priority enabled: threshold=%.2f, boost=%.2f",
-				faqDirectAnswerThreshold, faqScoreBoost)
+			logger.Infof(ctx, "FAQ priority enabled: threshold=%.2f, boost=%.2f, exclusive=%v",
+				faqDirectAnswerThreshold, faqScoreBoost, faqExclusiveAboveThreshold)
 		}
 	}
 
@@ -708,6 +860,13 @@ func (s *sessionService) KnowledgeQA(
 		logger.Warnf(ctx, "Failed to build search targets: %v", err)
 	}
 
+	// A knowledge base's own fallback response applies unless the agent overrides it
+	if customAgent == nil || customAgent.Config.FallbackResponse == "" {
+		if kbFallback := s.knowledgeBaseFallbackResponse(ctx, knowledgeBaseIDs, searchTargets); kbFallback != "" {
+			fallbackResponse = kbFallback
+		}
+	}
+
 	// Create chat management object with session settings
 	logger.Infof(
 		ctx,
@@ -722,38 +881,45 @@ func (s *sessionService) KnowledgeQA(
 	userID, _ := types.UserIDFromContext(ctx)
 
 	chatManage := &types.ChatManage{
-		Query:                query,
-		RewriteQuery:         query,
-		SessionID:            session.ID,
-		UserID:               userID,
-		MessageID:            assistantMessageID, // NEW: For event emission in pipeline
-		KnowledgeBaseIDs:     knowledgeBaseIDs,   // Multi-KB support
-		KnowledgeIDs:         knowledgeIDs,       // Specific knowledge (file) IDs
-		SearchTargets:        searchTargets,      // Pre-computed search targets
-		VectorThreshold:      vectorThreshold,
-		KeywordThreshold:     keywordThreshold,
-		EmbeddingTopK:        embeddingTopK,
-		RerankModelID:        rerankModelID,
-		RerankTopK:           rerankTopK,
-		RerankThreshold:      rerankThreshold,
-		MaxRounds:            maxRounds,
-		ChatModelID:          chatModelID,
-		SummaryConfig:        summaryConfig,
-		FallbackStrategy:     fallbackStrategy,
-		FallbackResponse:     fallbackResponse,
-		FallbackPrompt:       fallbackPrompt,
-		EventBus:             eventBus.AsEventBusInterface(), // NEW: For pipeline to emit events directly
-		WebSearchEnabled:     webSearchEnabled,
-		EnableMemory:         enableMemory,      // Enable memory feature
-		TenantID:             retrievalTenantID, // Effective tenant for retrieval (shared agent = agent's tenant)
-		RewritePromptSystem:  rewritePromptSystem,
-		RewritePromptUser:    rewritePromptUser,
-		EnableRewrite:        enableRewrite,
-		EnableQueryExpansion: enableQueryExpansion,
+		Query:                    query,
+		RewriteQuery:             query,
+		SessionID:                session.ID,
+		UserID:                   userID,
+		MessageID:                assistantMessageID, // NEW: For event emission in pipeline
+		KnowledgeBaseIDs:         knowledgeBaseIDs,   // Multi-KB support
+		KnowledgeIDs:             knowledgeIDs,       // Specific knowledge (file) IDs
+		RetrievalFilters:         filters.Clone(),    // Restrict retrieval to documents matching metadata/date filters
+		SearchTargets:            searchTargets,      // Pre-computed search targets
+		VectorThreshold:          vectorThreshold,
+		KeywordThreshold:         keywordThreshold,
+		EmbeddingTopK:            embeddingTopK,
+		RerankModelID:            rerankModelID,
+		RerankTopK:               rerankTopK,
+		RerankThreshold:          rerankThreshold,
+		DedupThreshold:           s.cfg.Conversation.GetDedupThreshold(),
+		MaxRounds:                maxRounds,
+		MinResultsForAnswer:      s.cfg.Conversation.GetMinResultsForAnswer(),
+		CitationRequired:         citationRequired,
+		ChunkContextWindow:       chunkContextWindow,
+		ChatModelID:              chatModelID,
+		SummaryConfig:            summaryConfig,
+		FallbackStrategy:         fallbackStrategy,
+		FallbackResponse:         fallbackResponse,
+		FallbackPrompt:           fallbackPrompt,
+		EventBus:                 eventBus.AsEventBusInterface(), // NEW: For pipeline to emit events directly
+		WebSearchEnabled:         webSearchEnabled,
+		EnableMemory:             enableMemory,      // Enable memory feature
+		TenantID:                 retrievalTenantID, // Effective tenant for retrieval (shared agent = agent's tenant)
+		RewritePromptSystem:      rewritePromptSystem,
+		RewritePromptUser:        rewritePromptUser,
+		RewritePromptsByLanguage: rewritePromptsByLanguage,
+		EnableRewrite:            enableRewrite,
+		EnableQueryExpansion:     enableQueryExpansion,
 		// FAQ Strategy Settings
-		FAQPriorityEnabled:       faqPriorityEnabled,
-		FAQDirectAnswerThreshold: faqDirectAnswerThreshold,
-		FAQScoreBoost:            faqScoreBoost,
+		FAQPriorityEnabled:         faqPriorityEnabled,
+		FAQDirectAnswerThreshold:   faqDirectAnswerThreshold,
+		FAQScoreBoost:              faqScoreBoost,
+		FAQExclusiveAboveThreshold: faqExclusiveAboveThreshold,
 	}
 
 	// Determine pipeline based on knowledge bases availability and web search setting
@@ -795,6 +961,9 @@ func (s *sessionService) KnowledgeQA(
 
 	// Emit references event if we have search results
 	if len(chatManage.MergeResult) > 0 {
+		if len(includeMetadata) > 0 {
+			s.attachReferenceDocuments(ctx, retrievalTenantID, chatManage.MergeResult, includeMetadata)
+		}
 		logger.Infof(ctx, "Emitting references event with %d results", len(chatManage.MergeResult))
 		if err := eventBus.Emit(ctx, event.Event{
 			ID:        generateEventID("references"),
@@ -817,6 +986,31 @@ func (s *sessionService) KnowledgeQA(
 	return nil
 }
 
+// attachReferenceDocuments sets the document fields of knowledge references, looking up their knowledge in one batch
+func (s *sessionService) attachReferenceDocuments(ctx context.Context,
+	tenantID uint64, references []*types.SearchResult, includeMetadata []string,
+) {
+	var knowledgeIDs []string
+	for _, ref := range references {
+		if ref.KnowledgeID != "" && !slices.Contains(knowledgeIDs, ref.KnowledgeID) {
+			knowledgeIDs = append(knowledgeIDs, ref.KnowledgeID)
+		}
+	}
+	if len(knowledgeIDs) == 0 {
+		return
+	}
+	knowledges, err := s.knowledgeService.GetKnowledgeBatchWithSharedAccess(ctx, tenantID, knowledgeIDs)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to fetch knowledge for reference documents: %v", err)
+		return
+	}
+	knowledgeMap := make(map[string]*types.Knowledge, len(knowledges))
+	for _, k := range knowledges {
+		knowledgeMap[k.ID] = k
+	}
+	types.AttachSearchResultDocuments(references, knowledgeMap, includeMetadata)
+}
+
 // selectChatModelIDWithOverride selects the appropriate chat model ID with priority for request override
 // Priority order:
 // 1. Request's summaryModelID (if provided and valid)
@@ -858,6 +1052,8 @@ func (s *sessionService) selectChatModelIDWithOverride(
 // 2. First knowledge base with a Remote model (from knowledgeBaseIDs or derived from knowledgeIDs)
 // 3. Session's SummaryModelID (if not Remote)
 // 4. First knowledge base's SummaryModelID
+// 5. Tenant's default summary model for the first knowledge base's type
+// 6. First available KnowledgeQA model
 func (s *sessionService) selectChatModelID(
 	ctx context.Context,
 	session *types.Session,
@@ -918,6 +1114,18 @@ func (s *sessionService) selectChatModelID(
 			)
 			return kb.SummaryModelID, nil
 		}
+		if kb != nil {
+			if modelID := s.tenantDefaultSummaryModelID(ctx, kb.Type); modelID != "" {
+				logger.Infof(
+					ctx,
+					"Using tenant default summary model for %s knowledge base %s: %s",
+					kb.Type,
+					knowledgeBaseIDs[0],
+					modelID,
+				)
+				return modelID, nil
+			}
+		}
 	}
 
 	// No knowledge bases - try to find any available chat model
@@ -937,6 +1145,32 @@ func (s *sessionService) selectChatModelID(
 	return "", errors.New("no chat model ID available: no knowledge bases configured and no available models")
 }
 
+// tenantDefaultSummaryModelID returns the tenant's default summary model for the given knowledge base type,
+// or an empty string if none is configured or the configured model no longer exists.
+func (s *sessionService) tenantDefaultSummaryModelID(ctx context.Context, kbType string) string {
+	tenant, _ := types.TenantInfoFromContext(ctx)
+	if tenant == nil {
+		return ""
+	}
+	modelID := tenant.SummaryModelDefaults.ModelIDForType(kbType)
+	if modelID == "" {
+		return ""
+	}
+	model, err := s.modelService.GetModelByID(ctx, modelID)
+	if err != nil || model == nil {
+		logger.Warnf(ctx, "Tenant default summary model %s for %s knowledge bases is unavailable: %v", modelID, kbType, err)
+		return ""
+	}
+	return modelID
+}
+
+// ResolveAgentKnowledgeBases exposes resolveKnowledgeBasesFromAgent, e.g. to preview an agent's scope
+func (s *sessionService) ResolveAgentKnowledgeBases(
+	ctx context.Context, customAgent *types.CustomAgent, sessionTenantID uint64,
+) []string {
+	return s.resolveKnowledgeBasesFromAgent(ctx, customAgent, sessionTenantID)
+}
+
 // resolveKnowledgeBasesFromAgent resolves knowledge base IDs based on agent's KBSelectionMode.
 // sessionTenantID is the tenant of the current session (caller); it is compared with
 // customAgent.TenantID to detect the shared-agent scenario and avoid leaking the
@@ -1068,11 +1302,59 @@ func (s *sessionService) configureSkillsFromAgent(
 
 }
 
+// knowledgeBaseFallbackResponse returns the fallback response of the first searched knowledge base
+// that sets one. Knowledge bases are taken in request order, followed by the knowledge bases of
+// searched documents in ID order, so the choice is deterministic.
+func (s *sessionService) knowledgeBaseFallbackResponse(ctx context.Context,
+	knowledgeBaseIDs []string, targets types.SearchTargets,
+) string {
+	ordered := make([]string, 0, len(targets))
+	seen := make(map[string]bool, len(targets))
+	for _, id := range knowledgeBaseIDs {
+		if !seen[id] {
+			seen[id] = true
+			ordered = append(ordered, id)
+		}
+	}
+	var documentKBs []string
+	for _, target := range targets {
+		if !seen[target.KnowledgeBaseID] {
+			seen[target.KnowledgeBaseID] = true
+			documentKBs = append(documentKBs, target.KnowledgeBaseID)
+		}
+	}
+	slices.Sort(documentKBs)
+	ordered = append(ordered, documentKBs...)
+	if len(ordered) == 0 {
+		return ""
+	}
+
+	kbs, err := s.knowledgeBaseService.GetKnowledgeBasesByIDsOnly(ctx, ordered)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to load knowledge bases for fallback response: %v", err)
+		return ""
+	}
+	fallbacks := make(map[string]string, len(kbs))
+	for _, kb := range kbs {
+		if kb != nil && kb.FallbackResponse != "" {
+			fallbacks[kb.ID] = kb.FallbackResponse
+		}
+	}
+	for _, id := range ordered {
+		if fallback, ok := fallbacks[id]; ok {
+			logger.Infof(ctx, "Using fallback response of knowledge base %s", id)
+			return fallback
+		}
+	}
+	return ""
+}
+
 // buildSearchTargets computes the unified search targets from knowledgeBaseIDs and knowledgeIDs.
 // tenantID is the retrieval scope: session.TenantID or effective tenant from shared agent (set by handler).
 // This is called once at the request entry point to avoid repeated queries later in the pipeline.
 // Logic:
-//   - For each knowledgeBaseID: resolve actual TenantID (own, org-shared, or in retrieval-tenant scope for shared agent)
+//   - For each knowledgeBaseID: resolve actual TenantID (own, org-shared, or in retrieval-tenant scope for shared agent);
+//     a KB reached through partial shares becomes a SearchTargetTypeKnowledge target over the shared documents
 //   - For each knowledgeID: find its knowledgeBaseID; if the KB is already in the list, skip; otherwise add SearchTargetTypeKnowledge
 func (s *sessionService) buildSearchTargets(
 	ctx context.Context,
@@ -1109,6 +1391,20 @@ func (s *sessionService) buildSearchTargets(
 				hasAccess, _ := s.kbShareService.HasKBPermission(ctx, kbID, userID, types.OrgRoleViewer)
 				if hasAccess {
 					kbTenantMap[kbID] = kb.TenantID
+					sharedIDs, restricted, err := s.kbShareService.GetSharedKnowledgeIDs(ctx, kbID, userID)
+					if err != nil {
+						logger.Warnf(ctx, "Failed to resolve shared documents of KB %s, skipping: %v", kbID, err)
+						continue
+					}
+					if restricted {
+						targets = append(targets, &types.SearchTarget{
+							Type:            types.SearchTargetTypeKnowledge,
+							KnowledgeBaseID: kbID,
+							TenantID:        kb.TenantID,
+							KnowledgeIDs:    sharedIDs,
+						})
+						continue
+					}
 				} else {
 					kbTenantMap[kbID] = tenantID
 				}
@@ -1197,6 +1493,14 @@ func (s *sessionService) KnowledgeQAByEvent(ctx context.Context,
 
 	// Process each event in sequence
 	for _, eventType := range eventList {
+		// Nothing to cite: answering would be ungrounded, so fall back before calling the model
+		if chatManage.CitationRequired && len(chatManage.MergeResult) == 0 &&
+			(eventType == types.CHAT_COMPLETION || eventType == types.CHAT_COMPLETION_STREAM) {
+			logger.Warnf(ctx, "Citation required but no merged results before %v, using fallback response", eventType)
+			s.handleFallbackResponse(ctx, chatManage)
+			return nil
+		}
+
 		logger.Infof(ctx, "Starting to trigger event: %v", eventType)
 		err := s.eventManager.Trigger(ctx, eventType, chatManage)
 
@@ -1222,6 +1526,16 @@ func (s *sessionService) KnowledgeQAByEvent(ctx context.Context,
 			return err.Err
 		}
 		logger.Infof(ctx, "Event %v triggered successfully", eventType)
+
+		// Too few merged results to ground an answer: fall back as if nothing was found.
+		// An empty search already falls back above, so only thresholds above the default apply here.
+		if eventType == types.CHUNK_MERGE && chatManage.MinResultsForAnswer > types.DefaultMinResultsForAnswer &&
+			len(chatManage.MergeResult) < chatManage.MinResultsForAnswer {
+			logger.Warnf(ctx, "Merged %d results, below minimum %d, using fallback response, strategy: %v",
+				len(chatManage.MergeResult), chatManage.MinResultsForAnswer, chatManage.FallbackStrategy)
+			s.handleFallbackResponse(ctx, chatManage)
+			return nil
+		}
 	}
 
 	logger.Info(ctx, "All events triggered successfully")
@@ -1232,7 +1546,7 @@ func (s *sessionService) KnowledgeQAByEvent(ctx context.Context,
 // knowledgeBaseIDs: list of knowledge base IDs to search (supports multi-KB)
 // knowledgeIDs: list of specific knowledge (file) IDs to search
 func (s *sessionService) SearchKnowledge(ctx context.Context,
-	knowledgeBaseIDs []string, knowledgeIDs []string, query string,
+	knowledgeBaseIDs []string, knowledgeIDs []string, query string, filters *types.RetrievalFilters,
 ) ([]*types.SearchResult, error) {
 	logger.Info(ctx, "Start knowledge base search without LLM summary")
 	logger.Infof(ctx, "Knowledge base search parameters, knowledge base IDs: %v, knowledge IDs: %v, query: %s",
@@ -1271,6 +1585,7 @@ func (s *sessionService) SearchKnowledge(ctx context.Context,
 		UserID:           userID,
 		KnowledgeBaseIDs: knowledgeBaseIDs,
 		KnowledgeIDs:     knowledgeIDs,
+		RetrievalFilters: filters.Clone(),
 		SearchTargets:    searchTargets,
 		MaxRounds:        s.cfg.Conversation.MaxRounds,
 		EmbeddingTopK:    rc.GetEffectiveEmbeddingTopK(),
@@ -1278,6 +1593,7 @@ func (s *sessionService) SearchKnowledge(ctx context.Context,
 		KeywordThreshold: rc.GetEffectiveKeywordThreshold(),
 		RerankTopK:       rc.GetEffectiveRerankTopK(),
 		RerankThreshold:  rc.GetEffectiveRerankThreshold(),
+		DedupThreshold:   s.cfg.Conversation.GetDedupThreshold(),
 	}
 
 	// Get default models
@@ -1356,19 +1672,13 @@ func (s *sessionService) SearchKnowledge(ctx context.Context,
 }
 
 // AgentQA performs agent-based question answering with conversation history and streaming support
-// customAgent is optional - if provided, uses custom agent configuration instead of tenant defaults
-// summaryModelID is optional - if provided, overrides the model from customAgent config
-func (s *sessionService) AgentQA(
-	ctx context.Context,
-	session *types.Session,
-	query string,
-	assistantMessageID string,
-	summaryModelID string,
-	eventBus *event.EventBus,
-	customAgent *types.CustomAgent,
-	knowledgeBaseIDs []string,
-	knowledgeIDs []string,
-) error {
+// req.CustomAgent is required; req.SummaryModelID is optional - if provided, overrides the model from the agent config
+func (s *sessionService) AgentQA(ctx context.Context, req *types.QARequest, eventBus *event.EventBus) error {
+	session, query, assistantMessageID := req.Session, req.Query, req.AssistantMessageID
+	knowledgeBaseIDs, knowledgeIDs := req.KnowledgeBaseIDs, req.KnowledgeIDs
+	summaryModelID, customAgent, sampling := req.SummaryModelID, req.CustomAgent, req.Sampling
+	disabledTools := req.DisabledTools
+
 	sessionID := session.ID
 	sessionJSON, err := json.Marshal(session)
 	if err != nil {
@@ -1417,6 +1727,10 @@ func (s *sessionService) AgentQA(
 		MaxIterations:               customAgent.Config.MaxIterations,
 		ReflectionEnabled:           customAgent.Config.ReflectionEnabled,
 		Temperature:                 customAgent.Config.Temperature,
+		MaxCompletionTokens:         customAgent.Config.MaxCompletionTokens,
+		StopSequences:               customAgent.Config.StopSequences,
+		ResponseFormat:              customAgent.Config.ResponseFormat,
+		ResponseSchema:              customAgent.Config.ResponseSchema,
 		WebSearchEnabled:            customAgent.Config.WebSearchEnabled,
 		WebSearchMaxResults:         customAgent.Config.WebSearchMaxResults,
 		MultiTurnEnabled:            customAgent.Config.MultiTurnEnabled,
@@ -1425,6 +1739,17 @@ func (s *sessionService) AgentQA(
 		MCPServices:                 customAgent.Config.MCPServices,
 		Thinking:                    customAgent.Config.Thinking,
 		RetrieveKBOnlyWhenMentioned: customAgent.Config.RetrieveKBOnlyWhenMentioned,
+		FewShotExamples:             customAgent.Config.FewShotExamples,
+	}
+
+	// Request sampling parameters override the agent configuration
+	if sampling != nil {
+		if sampling.Temperature != nil {
+			agentConfig.Temperature = *sampling.Temperature
+		}
+		if sampling.TopP != nil {
+			agentConfig.TopP = *sampling.TopP
+		}
 	}
 
 	// Configure skills based on CustomAgentConfig
@@ -1454,6 +1779,11 @@ func (s *sessionService) AgentQA(
 		// Use agent's configured knowledge bases based on KBSelectionMode
 		agentConfig.KnowledgeBases = s.resolveKnowledgeBasesFromAgent(ctx, customAgent, session.TenantID)
 	}
+	// Pinned knowledge bases are always searched, even when retrieval requires an explicit mention
+	if len(session.PinnedKnowledgeBaseIDs) > 0 {
+		agentConfig.KnowledgeBases = session.WithPinnedKnowledgeBases(agentConfig.KnowledgeBases)
+		logger.Infof(ctx, "Added session pinned knowledge bases: %v", session.PinnedKnowledgeBaseIDs)
+	}
 
 	// Use custom agent's allowed tools if specified, otherwise use defaults
 	if len(customAgent.Config.AllowedTools) > 0 {
@@ -1462,10 +1792,29 @@ func (s *sessionService) AgentQA(
 		agentConfig.AllowedTools = tools.DefaultAllowedTools()
 	}
 
-	// Use custom agent's system prompt if specified
-	if customAgent.Config.SystemPrompt != "" {
+	// Tools disabled by the request are removed for this call only
+	if len(disabledTools) > 0 {
+		disabled := make(map[string]bool, len(disabledTools))
+		for _, name := range disabledTools {
+			disabled[name] = true
+		}
+		allowedTools := make([]string, 0, len(agentConfig.AllowedTools))
+		for _, name := range agentConfig.AllowedTools {
+			if !disabled[name] {
+				allowedTools = append(allowedTools, name)
+			}
+		}
+		agentConfig.AllowedTools = allowedTools
+		if disabled[tools.ToolWebSearch] {
+			agentConfig.WebSearchEnabled = false
+		}
+		logger.Infof(ctx, "Request disabled tools: %v", disabledTools)
+	}
+
+	// Use custom agent's system prompt if specified (a referenced template takes precedence over the inline prompt)
+	if systemPrompt := s.promptTemplates.ResolveSystemPrompt(ctx, customAgent); systemPrompt != "" {
 		agentConfig.UseCustomSystemPrompt = true
-		agentConfig.SystemPrompt = customAgent.Config.SystemPrompt
+		agentConfig.SystemPrompt = systemPrompt
 	}
 
 	logger.Infof(ctx, "Custom agent config applied: MaxIterations=%d, Temperature=%.2f, AllowedTools=%v, WebSearchEnabled=%v",
@@ -1541,7 +1890,7 @@ func (s *sessionService) AgentQA(
 	}
 
 	// Get or create contextManager for this session
-	contextManager := s.getContextManagerForSession(ctx, session, summaryModel)
+	contextManager := s.getContextManagerForSession(ctx, session, summaryModel, customAgent.Config.ContextConfig)
 
 	// Set system prompt for the current agent in context manager
 	// This ensures the context uses the correct system prompt when switching agents
@@ -1608,11 +1957,13 @@ func (s *sessionService) AgentQA(
 }
 
 // getContextManagerForSession creates a context manager for the session based on configuration
-// Returns the configured context manager (tenant-level or session-level) or default
+// Returns the configured context manager (tenant-level or session-level) or default,
+// with any non-zero agent-level overrides applied on top
 func (s *sessionService) getContextManagerForSession(
 	ctx context.Context,
 	session *types.Session,
 	chatModel chat.Chat,
+	agentOverride *types.ContextConfig,
 ) interfaces.ContextManager {
 	// Get tenant to access global context configuration
 	tenant, _ := types.TenantInfoFromContext(ctx)
@@ -1632,6 +1983,10 @@ func (s *sessionService) getContextManagerForSession(
 			SummarizeThreshold:  llmcontext.DefaultSummarizeThreshold,
 		}
 	}
+	if agentOverride != nil {
+		contextConfig = contextConfig.Merge(agentOverride)
+		logger.Infof(ctx, "Applying agent-level context config override for session %s: %+v", session.ID, *contextConfig)
+	}
 	return llmcontext.NewContextManagerFromConfig(contextConfig, s.sessionStorage, chatModel)
 }
 
@@ -1663,9 +2018,100 @@ func (s *sessionService) ClearContext(ctx context.Context, sessionID string) err
 	return s.sessionStorage.Delete(ctx, sessionID)
 }
 
+// GetContextSummary returns the compressed conversation summary stored for a session
+// An empty string is returned when the context has not been summarized yet
+func (s *sessionService) GetContextSummary(ctx context.Context, sessionID string) (string, error) {
+	if _, err := s.GetSession(ctx, sessionID); err != nil {
+		return "", err
+	}
+	messages, err := s.sessionStorage.Load(ctx, sessionID)
+	if err != nil {
+		return "", fmt.Errorf("failed to load context: %w", err)
+	}
+	summary, _ := llmcontext.FindSummary(messages)
+	return summary, nil
+}
+
+// UpdateContextSummary replaces the compressed conversation summary stored for a session
+// so a bad summary can be corrected before it derails later answers
+func (s *sessionService) UpdateContextSummary(ctx context.Context, sessionID string, text string) error {
+	if _, err := s.GetSession(ctx, sessionID); err != nil {
+		return err
+	}
+	messages, err := s.sessionStorage.Load(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to load context: %w", err)
+	}
+	if err := s.sessionStorage.Save(ctx, sessionID, llmcontext.ReplaceSummary(messages, text)); err != nil {
+		return fmt.Errorf("failed to save context: %w", err)
+	}
+	logger.Infof(ctx, "Context summary updated for session: %s", sessionID)
+	return nil
+}
+
+// PinKnowledgeBases replaces the knowledge bases pinned to a session. Each knowledge base must
+// belong to the current tenant or be shared with the current user with at least viewer permission.
+func (s *sessionService) PinKnowledgeBases(ctx context.Context, sessionID string, kbIDs []string) (*types.Session, error) {
+	session, err := s.GetSession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	pinned := make([]string, 0, len(kbIDs))
+	seen := make(map[string]bool, len(kbIDs))
+	for _, id := range kbIDs {
+		id = strings.TrimSpace(id)
+		if id != "" && !seen[id] {
+			seen[id] = true
+			pinned = append(pinned, id)
+		}
+	}
+
+	if len(pinned) > 0 {
+		kbs, err := s.knowledgeBaseService.GetKnowledgeBasesByIDsOnly(ctx, pinned)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get knowledge bases: %w", err)
+		}
+		kbByID := make(map[string]*types.KnowledgeBase, len(kbs))
+		for _, kb := range kbs {
+			if kb != nil {
+				kbByID[kb.ID] = kb
+			}
+		}
+		userID, _ := types.UserIDFromContext(ctx)
+		for _, id := range pinned {
+			kb := kbByID[id]
+			if kb == nil {
+				return nil, werrors.NewNotFoundError(fmt.Sprintf("knowledge base %s not found", id))
+			}
+			if kb.TenantID == session.TenantID {
+				continue
+			}
+			if s.kbShareService == nil || userID == "" {
+				return nil, werrors.NewForbiddenError(fmt.Sprintf("no access to knowledge base %s", id))
+			}
+			hasAccess, err := s.kbShareService.HasKBPermission(ctx, id, userID, types.OrgRoleViewer)
+			if err != nil || !hasAccess {
+				return nil, werrors.NewForbiddenError(fmt.Sprintf("no access to knowledge base %s", id))
+			}
+		}
+	}
+
+	if err := s.sessionRepo.UpdatePinnedKnowledgeBases(ctx, session.TenantID, session.ID, pinned); err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"session_id": session.ID,
+		})
+		return nil, err
+	}
+	session.PinnedKnowledgeBaseIDs = pinned
+	logger.Infof(ctx, "Pinned %d knowledge base(s) to session: %s", len(pinned), session.ID)
+	return session, nil
+}
+
 // handleFallbackResponse handles fallback response based on strategy
 func (s *sessionService) handleFallbackResponse(ctx context.Context, chatManage *types.ChatManage) {
-	if chatManage.FallbackStrategy == types.FallbackStrategyModel {
+	// A model-generated fallback is not grounded in any source, so citation-required mode always uses the fixed response
+	if chatManage.FallbackStrategy == types.FallbackStrategyModel && !chatManage.CitationRequired {
 		s.handleModelFallback(ctx, chatManage)
 	} else {
 		s.handleFixedFallback(ctx, chatManage)
@@ -1715,6 +2161,7 @@ func (s *sessionService) handleModelFallback(ctx context.Context, chatManage *ty
 	thinking := false
 	opt := &chat.ChatOptions{
 		Temperature:         chatManage.SummaryConfig.Temperature,
+		TopP:                chatManage.SummaryConfig.TopP,
 		MaxCompletionTokens: chatManage.SummaryConfig.MaxCompletionTokens,
 		Thinking:            &thinking,
 	}
@@ -1742,11 +2189,15 @@ func (s *sessionService) handleModelFallback(ctx context.Context, chatManage *ty
 // renderFallbackPrompt renders the fallback prompt template with Query variable
 func (s *sessionService) renderFallbackPrompt(ctx context.Context, chatManage *types.ChatManage) (string, error) {
 	// Use simple string replacement instead of Go template
-	result := strings.ReplaceAll(chatManage.FallbackPrompt, "{{query}}", chatManage.Query)
+	result := renderTemplate(chatManage.FallbackPrompt, map[string]string{
+		types.PlaceholderQuery.Name: chatManage.Query,
+	})
 	return result, nil
 }
 
-// consumeFallbackStream consumes the streaming response and emits events
+// consumeFallbackStream consumes the streaming response and emits events.
+// Chunks are emitted as non-final answer events so the stream consumer persists them
+// incrementally, exactly like the regular answer stream.
 func (s *sessionService) consumeFallbackStream(
 	ctx context.Context,
 	chatManage *types.ChatManage,
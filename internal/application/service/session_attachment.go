@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"mime/multipart"
+	"strings"
+	"time"
+
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// AttachFileToSession uploads a file into the session's temporary knowledge base (the same one used
+// for web search compression) so that KnowledgeQA can retrieve from it in subsequent turns.
+// The knowledge is parsed asynchronously like any other uploaded file and is removed on DeleteSession.
+func (s *sessionService) AttachFileToSession(
+	ctx context.Context, sessionID string, file *multipart.FileHeader, enableMultimodel *bool,
+) (*types.Knowledge, error) {
+	session, err := s.GetSession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	kbID, err := s.ensureSessionTempKB(ctx, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	knowledge, err := s.knowledgeService.CreateKnowledgeFromFile(ctx, kbID, file, nil, enableMultimodel, "", "", nil)
+	if err != nil {
+		return knowledge, err
+	}
+
+	if err := s.webSearchStateRepo.AddSessionAttachment(ctx, session.ID, kbID, knowledge.ID); err != nil {
+		// Without the state record the knowledge would never be cleaned up or retrieved
+		if delErr := s.knowledgeService.DeleteKnowledge(ctx, knowledge.ID); delErr != nil {
+			logger.Warnf(ctx, "Failed to delete unrecorded session attachment %s: %v", knowledge.ID, delErr)
+		}
+		return nil, fmt.Errorf("failed to record session attachment: %w", err)
+	}
+
+	logger.Infof(ctx, "Attached file %s to session %s as knowledge %s (temp KB %s)",
+		knowledge.FileName, session.ID, knowledge.ID, kbID)
+	return knowledge, nil
+}
+
+// ensureSessionTempKB returns the session's temporary knowledge base, creating it when missing.
+// The embedding model is taken from the tenant's web search config, falling back to the first
+// available embedding model.
+func (s *sessionService) ensureSessionTempKB(ctx context.Context, sessionID string) (string, error) {
+	tempKBID, _, _ := s.webSearchStateRepo.GetWebSearchTempKBState(ctx, sessionID)
+	if strings.TrimSpace(tempKBID) != "" {
+		if kb, err := s.knowledgeBaseService.GetKnowledgeBaseByID(ctx, tempKBID); err == nil && kb != nil {
+			return kb.ID, nil
+		}
+		logger.Warnf(ctx, "Temp KB %s of session %s not available, recreating", tempKBID, sessionID)
+	}
+
+	embeddingModelID, err := s.sessionTempKBEmbeddingModelID(ctx)
+	if err != nil {
+		return "", err
+	}
+	kb, err := s.knowledgeBaseService.CreateKnowledgeBase(ctx, &types.KnowledgeBase{
+		Name:             fmt.Sprintf("tmp-session-%d", time.Now().UnixNano()),
+		Description:      "Ephemeral session attachment KB",
+		IsTemporary:      true,
+		EmbeddingModelID: embeddingModelID,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary knowledge base: %w", err)
+	}
+	return kb.ID, nil
+}
+
+// sessionTempKBEmbeddingModelID picks the embedding model for a session temporary knowledge base
+func (s *sessionService) sessionTempKBEmbeddingModelID(ctx context.Context) (string, error) {
+	if tenant, _ := types.TenantInfoFromContext(ctx); tenant != nil &&
+		tenant.WebSearchConfig != nil && tenant.WebSearchConfig.EmbeddingModelID != "" {
+		return tenant.WebSearchConfig.EmbeddingModelID, nil
+	}
+
+	models, err := s.modelService.ListModels(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to list models: %w", err)
+	}
+	for _, model := range models {
+		if model != nil && model.Type == types.ModelTypeEmbedding {
+			return model.ID, nil
+		}
+	}
+	return "", werrors.NewBadRequestError("no embedding model available for session attachments")
+}
+
+// appendUniqueIDs appends the ids that are not already present, preserving order
+func appendUniqueIDs(ids []string, extra []string) []string {
+	seen := make(map[string]bool, len(ids)+len(extra))
+	for _, id := range ids {
+		seen[id] = true
+	}
+	for _, id := range extra {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
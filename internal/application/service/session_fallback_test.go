@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// fallbackKBService returns the knowledge bases it holds, in reverse ID order to make sure the
+// caller does not rely on the order of the result
+type fallbackKBService struct {
+	interfaces.KnowledgeBaseService
+	kbs map[string]*types.KnowledgeBase
+	err error
+}
+
+func (f *fallbackKBService) GetKnowledgeBasesByIDsOnly(ctx context.Context, ids []string) ([]*types.KnowledgeBase, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	var result []*types.KnowledgeBase
+	for i := len(ids) - 1; i >= 0; i-- {
+		if kb, ok := f.kbs[ids[i]]; ok {
+			result = append(result, kb)
+		}
+	}
+	return result, nil
+}
+
+func TestKnowledgeBaseFallbackResponse(t *testing.T) {
+	ctx := context.Background()
+	kbs := map[string]*types.KnowledgeBase{
+		"kb-a": {ID: "kb-a"},
+		"kb-b": {ID: "kb-b", FallbackResponse: "ask team B"},
+		"kb-c": {ID: "kb-c", FallbackResponse: "ask team C"},
+		"kb-d": {ID: "kb-d", FallbackResponse: "ask team D"},
+	}
+	documentTarget := func(kbID string) *types.SearchTarget {
+		return &types.SearchTarget{Type: types.SearchTargetTypeKnowledge, KnowledgeBaseID: kbID}
+	}
+
+	tests := []struct {
+		name    string
+		kbIDs   []string
+		targets types.SearchTargets
+		want    string
+	}{
+		{"nothing searched", nil, nil, ""},
+		{"no knowledge base sets one", []string{"kb-a"}, nil, ""},
+		{"first in request order", []string{"kb-a", "kb-c", "kb-b"}, nil, "ask team C"},
+		{"requested before documents", []string{"kb-d"}, types.SearchTargets{documentTarget("kb-b")}, "ask team D"},
+		{"documents in ID order", nil, types.SearchTargets{documentTarget("kb-d"), documentTarget("kb-c")}, "ask team C"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &sessionService{knowledgeBaseService: &fallbackKBService{kbs: kbs}}
+			if got := s.knowledgeBaseFallbackResponse(ctx, tt.kbIDs, tt.targets); got != tt.want {
+				t.Errorf("knowledgeBaseFallbackResponse() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKnowledgeBaseFallbackResponseLookupFailure(t *testing.T) {
+	s := &sessionService{knowledgeBaseService: &fallbackKBService{err: errors.New("db down")}}
+	if got := s.knowledgeBaseFallbackResponse(context.Background(), []string{"kb-b"}, nil); got != "" {
+		t.Errorf("knowledgeBaseFallbackResponse() = %q, want the global fallback kept", got)
+	}
+}
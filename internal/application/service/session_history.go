@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// ErrHistoryRetentionNotConfigured is returned when pruning is requested but the tenant has no retention limits
+var ErrHistoryRetentionNotConfigured = errors.New("history retention is not configured")
+
+// PruneSessionHistory trims the oldest messages of a session according to the tenant's
+// history retention config. The first user message is always kept (title generation relies on it)
+// and the context-manager summary is left untouched.
+func (s *sessionService) PruneSessionHistory(ctx context.Context, sessionID string) (int, error) {
+	session, err := s.GetSession(ctx, sessionID)
+	if err != nil {
+		return 0, err
+	}
+	tenant, _ := types.TenantInfoFromContext(ctx)
+	if tenant == nil || !tenant.HistoryRetentionConfig.IsEnabled() {
+		return 0, ErrHistoryRetentionNotConfigured
+	}
+	return s.pruneSessionMessages(ctx, session.ID, tenant.HistoryRetentionConfig, time.Now())
+}
+
+// PruneAllSessionHistory applies history retention to every session of every tenant that configured it
+func (s *sessionService) PruneAllSessionHistory(ctx context.Context) error {
+	tenants, err := s.tenantService.ListTenants(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list tenants: %w", err)
+	}
+
+	now := time.Now()
+	for _, tenant := range tenants {
+		if tenant == nil || !tenant.HistoryRetentionConfig.IsEnabled() {
+			continue
+		}
+		tenantCtx := context.WithValue(ctx, types.TenantIDContextKey, tenant.ID)
+		tenantCtx = context.WithValue(tenantCtx, types.TenantInfoContextKey, tenant)
+
+		sessions, err := s.sessionRepo.GetByTenantID(tenantCtx, tenant.ID)
+		if err != nil {
+			logger.Warnf(tenantCtx, "Failed to list sessions of tenant %d for history pruning: %v", tenant.ID, err)
+			continue
+		}
+		pruned := 0
+		for _, session := range sessions {
+			n, err := s.pruneSessionMessages(tenantCtx, session.ID, tenant.HistoryRetentionConfig, now)
+			if err != nil {
+				logger.Warnf(tenantCtx, "Failed to prune history of session %s: %v", session.ID, err)
+				continue
+			}
+			pruned += n
+		}
+		if pruned > 0 {
+			logger.Infof(tenantCtx, "Pruned %d message(s) across %d session(s) of tenant %d", pruned, len(sessions), tenant.ID)
+		}
+	}
+	return nil
+}
+
+// pruneSessionMessages deletes the messages selected by selectMessagesToPrune together with
+// their chat history knowledge entries
+func (s *sessionService) pruneSessionMessages(
+	ctx context.Context, sessionID string, cfg *types.HistoryRetentionConfig, now time.Time,
+) (int, error) {
+	messages, err := s.messageRepo.ListMessageMetaBySession(ctx, sessionID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list messages: %w", err)
+	}
+	toPrune := selectMessagesToPrune(messages, cfg, now)
+	if len(toPrune) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]string, 0, len(toPrune))
+	var knowledgeIDs []string
+	for _, m := range toPrune {
+		ids = append(ids, m.ID)
+		if m.KnowledgeID != "" {
+			knowledgeIDs = append(knowledgeIDs, m.KnowledgeID)
+		}
+	}
+	if err := s.messageRepo.DeleteMessagesByIDs(ctx, sessionID, ids); err != nil {
+		return 0, fmt.Errorf("failed to delete messages: %w", err)
+	}
+
+	// Cleanup chat history knowledge entries of the pruned messages (best-effort)
+	if len(knowledgeIDs) > 0 {
+		if err := s.knowledgeService.DeleteKnowledgeList(ctx, knowledgeIDs); err != nil {
+			logger.Warnf(ctx, "Failed to delete chat history knowledge of pruned messages in session %s: %v", sessionID, err)
+		}
+	}
+
+	logger.Infof(ctx, "Pruned %d message(s) from session %s", len(ids), sessionID)
+	return len(ids), nil
+}
+
+// selectMessagesToPrune returns the messages (ordered oldest first) that exceed the retention limits.
+// The first user message and messages still being generated are never pruned; they still count
+// towards MaxMessages.
+func selectMessagesToPrune(
+	messages []*types.Message, cfg *types.HistoryRetentionConfig, now time.Time,
+) []*types.Message {
+	if !cfg.IsEnabled() || len(messages) == 0 {
+		return nil
+	}
+
+	firstUserID := ""
+	for _, m := range messages {
+		if m.Role == "user" {
+			firstUserID = m.ID
+			break
+		}
+	}
+	protected := func(m *types.Message) bool {
+		return m.ID == firstUserID || (m.Role == "assistant" && !m.IsCompleted)
+	}
+
+	var cutoff time.Time
+	if cfg.MaxAgeDays > 0 {
+		cutoff = now.AddDate(0, 0, -cfg.MaxAgeDays)
+	}
+
+	remaining := len(messages)
+	var pruned []*types.Message
+	for _, m := range messages {
+		if protected(m) {
+			continue
+		}
+		expired := !cutoff.IsZero() && m.CreatedAt.Before(cutoff)
+		overLimit := cfg.MaxMessages > 0 && remaining > cfg.MaxMessages
+		if !expired && !overLimit {
+			continue
+		}
+		pruned = append(pruned, m)
+		remaining--
+	}
+	return pruned
+}
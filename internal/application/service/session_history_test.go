@@ -0,0 +1,61 @@
+package service
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestSelectMessagesToPrune(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	// u1 a1 u2 a2 u3 a3, one day apart, oldest first
+	var messages []*types.Message
+	for i := 0; i < 6; i++ {
+		role := "user"
+		if i%2 == 1 {
+			role = "assistant"
+		}
+		messages = append(messages, &types.Message{
+			ID:          fmt.Sprintf("m%d", i),
+			Role:        role,
+			IsCompleted: true,
+			CreatedAt:   now.AddDate(0, 0, i-6),
+		})
+	}
+	ids := func(ms []*types.Message) []string {
+		var out []string
+		for _, m := range ms {
+			out = append(out, m.ID)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name string
+		cfg  *types.HistoryRetentionConfig
+		want []string
+	}{
+		{"disabled", nil, nil},
+		{"under limit", &types.HistoryRetentionConfig{MaxMessages: 10}, nil},
+		{"max messages keeps first user message", &types.HistoryRetentionConfig{MaxMessages: 3}, []string{"m1", "m2", "m3"}},
+		{"max age", &types.HistoryRetentionConfig{MaxAgeDays: 3}, []string{"m1", "m2"}},
+		{"both limits", &types.HistoryRetentionConfig{MaxMessages: 2, MaxAgeDays: 5}, []string{"m1", "m2", "m3", "m4"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ids(selectMessagesToPrune(messages, tt.cfg, now)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selectMessagesToPrune() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	streaming := append([]*types.Message{}, messages...)
+	streaming[1] = &types.Message{ID: "m1", Role: "assistant", CreatedAt: messages[1].CreatedAt}
+	got := ids(selectMessagesToPrune(streaming, &types.HistoryRetentionConfig{MaxMessages: 3}, now))
+	if want := []string{"m2", "m3", "m4"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("incomplete message pruned: got %v, want %v", got, want)
+	}
+}
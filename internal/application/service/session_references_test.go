@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// batchKnowledgeService serves knowledge batches and counts the lookups
+type batchKnowledgeService struct {
+	interfaces.KnowledgeService
+	knowledge map[string]*types.Knowledge
+	lookups   int
+}
+
+func (s *batchKnowledgeService) GetKnowledgeBatchWithSharedAccess(ctx context.Context,
+	tenantID uint64, ids []string,
+) ([]*types.Knowledge, error) {
+	s.lookups++
+	var out []*types.Knowledge
+	for _, id := range ids {
+		if k, ok := s.knowledge[id]; ok {
+			out = append(out, k)
+		}
+	}
+	return out, nil
+}
+
+func TestAttachReferenceDocuments(t *testing.T) {
+	metadata := types.JSON(`{"author": "alice", "url": "https://example.com/a", "internal": "x"}`)
+	kb := &batchKnowledgeService{knowledge: map[string]*types.Knowledge{
+		"k1": {ID: "k1", Title: "Guide", FileName: "guide.pdf", FileType: "pdf", Metadata: metadata},
+		"k2": {ID: "k2", Title: "Notes"},
+	}}
+	s := &sessionService{knowledgeService: kb}
+
+	tests := []struct {
+		name            string
+		includeMetadata []string
+		want            map[string]string
+	}{
+		{"selected keys", []string{"author", "url", "missing"}, map[string]string{"author": "alice", "url": "https://example.com/a"}},
+		{"all keys", []string{types.IncludeAllMetadata}, map[string]string{"author": "alice", "url": "https://example.com/a", "internal": "x"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kb.lookups = 0
+			refs := []*types.SearchResult{
+				{ID: "c1", KnowledgeID: "k1"},
+				{ID: "c2", KnowledgeID: "k1"},
+				{ID: "c3", KnowledgeID: "k2"},
+				{ID: "web", MatchType: types.MatchTypeWebSearch},
+			}
+			s.attachReferenceDocuments(context.Background(), 1, refs, tt.includeMetadata)
+
+			if kb.lookups != 1 {
+				t.Errorf("knowledge lookups = %d, want 1", kb.lookups)
+			}
+			for _, ref := range refs[:2] {
+				if ref.Document == nil || ref.Document.Title != "Guide" || ref.Document.FileName != "guide.pdf" {
+					t.Fatalf("reference %s document = %+v", ref.ID, ref.Document)
+				}
+				if !reflect.DeepEqual(ref.Document.Metadata, tt.want) {
+					t.Errorf("reference %s metadata = %v, want %v", ref.ID, ref.Document.Metadata, tt.want)
+				}
+			}
+			if refs[2].Document == nil || refs[2].Document.Metadata != nil {
+				t.Errorf("reference without metadata document = %+v", refs[2].Document)
+			}
+			if refs[3].Document != nil {
+				t.Errorf("web result got a document: %+v", refs[3].Document)
+			}
+		})
+	}
+}
@@ -146,6 +146,10 @@ func (s *knowledgeTagService) CreateTag(
 	if kbID == "" || name == "" {
 		return nil, werrors.NewBadRequestError("知识库ID和标签名称不能为空")
 	}
+	color = strings.TrimSpace(color)
+	if err := types.ValidateTagColor(color); err != nil {
+		return nil, werrors.NewBadRequestError("标签颜色格式不正确").WithDetails(err.Error())
+	}
 	kb, err := s.kbService.GetKnowledgeBaseByID(ctx, kbID)
 	if err != nil {
 		return nil, err
@@ -170,7 +174,7 @@ func (s *knowledgeTagService) CreateTag(
 		TenantID:        kb.TenantID,
 		KnowledgeBaseID: kb.ID,
 		Name:            name,
-		Color:           strings.TrimSpace(color),
+		Color:           color,
 		SortOrder:       sortOrder,
 		CreatedAt:       now,
 		UpdatedAt:       now,
@@ -206,7 +210,11 @@ func (s *knowledgeTagService) UpdateTag(
 		tag.Name = newName
 	}
 	if color != nil {
-		tag.Color = strings.TrimSpace(*color)
+		newColor := strings.TrimSpace(*color)
+		if err := types.ValidateTagColor(newColor); err != nil {
+			return nil, werrors.NewBadRequestError("标签颜色格式不正确").WithDetails(err.Error())
+		}
+		tag.Color = newColor
 	}
 	if sortOrder != nil {
 		tag.SortOrder = *sortOrder
@@ -218,8 +226,9 @@ func (s *knowledgeTagService) UpdateTag(
 	return tag, nil
 }
 
-// DeleteTag deletes a tag. When force=true, also deletes all chunks under this tag.
-// For document-type knowledge bases, also deletes all knowledge files under this tag.
+// DeleteTag deletes a tag. By default the knowledge and FAQ entries under the tag are kept and
+// become untagged. When force=true, also deletes all chunks under this tag, and for
+// document-type knowledge bases all knowledge files under this tag.
 // When contentOnly=true, only deletes the content under the tag but keeps the tag itself.
 func (s *knowledgeTagService) DeleteTag(ctx context.Context, id string, force bool, contentOnly bool, excludeIDs []string) error {
 	if id == "" {
@@ -313,8 +322,11 @@ func (s *knowledgeTagService) DeleteTag(ctx context.Context, id string, force bo
 		return nil
 	}
 
+	// Without force, the content under the tag is kept and becomes untagged
 	if !force && (kCount > 0 || cCount > 0) {
-		return werrors.NewBadRequestError("标签仍有知识或FAQ条目引用，无法删除")
+		if err := s.reassignTag(ctx, tenantID, tag.KnowledgeBaseID, tag.ID, ""); err != nil {
+			return err
+		}
 	}
 
 	// When force=true, delete all content under this tag first
@@ -339,6 +351,85 @@ func (s *knowledgeTagService) DeleteTag(ctx context.Context, id string, force bo
 	return s.repo.Delete(ctx, tenantID, id)
 }
 
+// MergeTags moves all knowledge and FAQ entries from the source tag to the target tag, then
+// deletes the source tag. Both tags must belong to the knowledge base.
+func (s *knowledgeTagService) MergeTags(ctx context.Context,
+	kbID string, sourceID string, targetID string,
+) (*types.KnowledgeTag, error) {
+	if sourceID == "" || targetID == "" {
+		return nil, werrors.NewBadRequestError("源标签和目标标签不能为空")
+	}
+	if sourceID == targetID {
+		return nil, werrors.NewBadRequestError("源标签和目标标签不能相同")
+	}
+	tenantID := types.MustTenantIDFromContext(ctx)
+	tags, err := s.repo.GetByIDs(ctx, tenantID, []string{sourceID, targetID})
+	if err != nil {
+		return nil, err
+	}
+	var source, target *types.KnowledgeTag
+	for _, tag := range tags {
+		if tag.KnowledgeBaseID != kbID {
+			continue
+		}
+		switch tag.ID {
+		case sourceID:
+			source = tag
+		case targetID:
+			target = tag
+		}
+	}
+	if source == nil || target == nil {
+		return nil, werrors.NewNotFoundError("标签不存在")
+	}
+
+	if err := s.reassignTag(ctx, tenantID, kbID, source.ID, target.ID); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Delete(ctx, tenantID, source.ID); err != nil {
+		return nil, err
+	}
+	logger.Infof(ctx, "Merged tag %s into tag %s in knowledge base %s", source.ID, target.ID, kbID)
+	return target, nil
+}
+
+// reassignTag moves the knowledge and chunks under a tag to another tag (empty means untagged)
+// and syncs the new chunk tags to the retriever engines
+func (s *knowledgeTagService) reassignTag(ctx context.Context,
+	tenantID uint64, kbID string, fromTagID string, toTagID string,
+) error {
+	movedKnowledge, err := s.knowledgeRepo.ReassignTagID(ctx, tenantID, kbID, fromTagID, toTagID)
+	if err != nil {
+		return err
+	}
+	chunkIDs, err := s.chunkRepo.ReassignTagID(ctx, tenantID, kbID, fromTagID, toTagID)
+	if err != nil {
+		return err
+	}
+
+	if len(chunkIDs) > 0 {
+		tenantInfo, ok := types.TenantInfoFromContext(ctx)
+		if !ok {
+			return werrors.NewUnauthorizedError("tenant info not found in context")
+		}
+		retrieveEngine, err := retriever.NewCompositeRetrieveEngine(s.retrieveEngine, tenantInfo.GetEffectiveEngines())
+		if err != nil {
+			return err
+		}
+		chunkTags := make(map[string]string, len(chunkIDs))
+		for _, id := range chunkIDs {
+			chunkTags[id] = toTagID
+		}
+		if err := retrieveEngine.BatchUpdateChunkTagID(ctx, chunkTags); err != nil {
+			return err
+		}
+	}
+
+	logger.Infof(ctx, "Moved %d knowledge and %d chunks from tag %s to tag %q",
+		movedKnowledge, len(chunkIDs), fromTagID, toTagID)
+	return nil
+}
+
 // enqueueIndexDeleteTask enqueues an async task for index deletion (low priority)
 func (s *knowledgeTagService) enqueueIndexDeleteTask(ctx context.Context,
 	tenantID uint64, kbID, embeddingModelID, kbType string, chunkIDs []string, effectiveEngines []types.RetrieverEngineParams,
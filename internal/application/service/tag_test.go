@@ -0,0 +1,265 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// memoryTagRepo keeps a single tag in memory
+type memoryTagRepo struct {
+	interfaces.KnowledgeTagRepository
+	tag *types.KnowledgeTag
+}
+
+func (r *memoryTagRepo) GetByID(ctx context.Context, tenantID uint64, id string) (*types.KnowledgeTag, error) {
+	copied := *r.tag
+	return &copied, nil
+}
+
+func (r *memoryTagRepo) Update(ctx context.Context, tag *types.KnowledgeTag) error {
+	r.tag = tag
+	return nil
+}
+
+func TestUpdateTagColorAndSortOrder(t *testing.T) {
+	ctx := context.WithValue(context.Background(), types.TenantIDContextKey, uint64(1))
+	order := 3
+
+	tests := []struct {
+		name      string
+		color     string
+		wantErr   bool
+		wantColor string
+	}{
+		{"six digit hex", "#1890ff", false, "#1890ff"},
+		{"three digit hex", " #F0A ", false, "#F0A"},
+		{"cleared", "", false, ""},
+		{"missing hash", "1890ff", true, "#000000"},
+		{"color name", "red", true, "#000000"},
+		{"alpha channel", "#1890ff80", true, "#000000"},
+		{"non hex digits", "#12345g", true, "#000000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &memoryTagRepo{tag: &types.KnowledgeTag{ID: "tag-1", Name: "docs", Color: "#000000"}}
+			s := &knowledgeTagService{repo: repo}
+
+			tag, err := s.UpdateTag(ctx, "tag-1", nil, &tt.color, &order)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UpdateTag() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if repo.tag.Color != tt.wantColor {
+				t.Errorf("stored color = %q, want %q", repo.tag.Color, tt.wantColor)
+			}
+			if err == nil && (tag.Color != tt.wantColor || tag.SortOrder != order) {
+				t.Errorf("UpdateTag() = {color %q, sort_order %d}, want {%q, %d}",
+					tag.Color, tag.SortOrder, tt.wantColor, order)
+			}
+		})
+	}
+}
+
+func TestCreateTagRejectsInvalidColor(t *testing.T) {
+	s := &knowledgeTagService{}
+	if _, err := s.CreateTag(context.Background(), "kb-1", "docs", "blue", 0); err == nil {
+		t.Fatal("CreateTag() accepted a non-hex color")
+	}
+}
+
+// mergeTagRepo holds the tags of a knowledge base with fixed reference counts
+type mergeTagRepo struct {
+	interfaces.KnowledgeTagRepository
+	tags    map[string]*types.KnowledgeTag
+	deleted []string
+}
+
+func (r *mergeTagRepo) GetByID(ctx context.Context, tenantID uint64, id string) (*types.KnowledgeTag, error) {
+	tag, ok := r.tags[id]
+	if !ok {
+		return nil, errors.New("tag not found")
+	}
+	return tag, nil
+}
+
+func (r *mergeTagRepo) GetByIDs(ctx context.Context, tenantID uint64, ids []string) ([]*types.KnowledgeTag, error) {
+	var tags []*types.KnowledgeTag
+	for _, id := range ids {
+		if tag, ok := r.tags[id]; ok {
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
+
+func (r *mergeTagRepo) CountReferences(ctx context.Context,
+	tenantID uint64, kbID string, tagID string,
+) (int64, int64, error) {
+	return 1, 2, nil
+}
+
+func (r *mergeTagRepo) Delete(ctx context.Context, tenantID uint64, id string) error {
+	r.deleted = append(r.deleted, id)
+	return nil
+}
+
+// reassignKnowledgeRepo records the tag moves of knowledge
+type reassignKnowledgeRepo struct {
+	interfaces.KnowledgeRepository
+	moves [][2]string
+}
+
+func (r *reassignKnowledgeRepo) ReassignTagID(ctx context.Context,
+	tenantID uint64, kbID string, fromTagID string, toTagID string,
+) (int64, error) {
+	r.moves = append(r.moves, [2]string{fromTagID, toTagID})
+	return 1, nil
+}
+
+// reassignChunkRepo moves the fixed chunks of a tag
+type reassignChunkRepo struct {
+	interfaces.ChunkRepository
+	chunkIDs map[string][]string
+}
+
+func (r *reassignChunkRepo) ReassignTagID(ctx context.Context,
+	tenantID uint64, kbID string, fromTagID string, toTagID string,
+) ([]string, error) {
+	return r.chunkIDs[fromTagID], nil
+}
+
+// chunkTagEngine records the chunk tags synced to the retriever engine
+type chunkTagEngine struct {
+	interfaces.RetrieveEngineService
+	chunkTags map[string]string
+}
+
+func (e *chunkTagEngine) EngineType() types.RetrieverEngineType { return types.SQLiteRetrieverEngineType }
+
+func (e *chunkTagEngine) Support() []types.RetrieverType {
+	return []types.RetrieverType{types.KeywordsRetrieverType, types.VectorRetrieverType}
+}
+
+func (e *chunkTagEngine) BatchUpdateChunkTagID(ctx context.Context, chunkTagMap map[string]string) error {
+	e.chunkTags = chunkTagMap
+	return nil
+}
+
+// chunkTagRegistry serves a single chunkTagEngine
+type chunkTagRegistry struct {
+	interfaces.RetrieveEngineRegistry
+	engine *chunkTagEngine
+}
+
+func (r *chunkTagRegistry) GetRetrieveEngineService(
+	engineType types.RetrieverEngineType,
+) (interfaces.RetrieveEngineService, error) {
+	return r.engine, nil
+}
+
+// tagKBService returns an FAQ knowledge base
+type tagKBService struct {
+	interfaces.KnowledgeBaseService
+}
+
+func (s *tagKBService) GetKnowledgeBaseByID(ctx context.Context, id string) (*types.KnowledgeBase, error) {
+	return &types.KnowledgeBase{ID: id, Type: types.KnowledgeBaseTypeFAQ}, nil
+}
+
+// newMergeTagService returns a tag service over kb-1 holding tags "a" and "b", and "c" in kb-2
+func newMergeTagService() (*knowledgeTagService, *mergeTagRepo, *reassignKnowledgeRepo, *chunkTagEngine) {
+	tags := &mergeTagRepo{tags: map[string]*types.KnowledgeTag{
+		"a": {ID: "a", KnowledgeBaseID: "kb-1"},
+		"b": {ID: "b", KnowledgeBaseID: "kb-1"},
+		"c": {ID: "c", KnowledgeBaseID: "kb-2"},
+	}}
+	knowledge := &reassignKnowledgeRepo{}
+	engine := &chunkTagEngine{}
+	return &knowledgeTagService{
+		kbService:      &tagKBService{},
+		repo:           tags,
+		knowledgeRepo:  knowledge,
+		chunkRepo:      &reassignChunkRepo{chunkIDs: map[string][]string{"a": {"chunk-1", "chunk-2"}}},
+		retrieveEngine: &chunkTagRegistry{engine: engine},
+	}, tags, knowledge, engine
+}
+
+func newTagTestContext() context.Context {
+	ctx := context.WithValue(context.Background(), types.TenantIDContextKey, uint64(1))
+	return context.WithValue(ctx, types.TenantInfoContextKey, &types.Tenant{
+		ID: 1,
+		RetrieverEngines: types.RetrieverEngines{Engines: []types.RetrieverEngineParams{
+			{RetrieverEngineType: types.SQLiteRetrieverEngineType, RetrieverType: types.KeywordsRetrieverType},
+		}},
+	})
+}
+
+func TestMergeTags(t *testing.T) {
+	ctx := newTagTestContext()
+	s, tags, knowledge, engine := newMergeTagService()
+
+	target, err := s.MergeTags(ctx, "kb-1", "a", "b")
+	if err != nil {
+		t.Fatalf("MergeTags() error = %v", err)
+	}
+	if target.ID != "b" {
+		t.Errorf("MergeTags() = %s, want the target tag", target.ID)
+	}
+	if want := [][2]string{{"a", "b"}}; !slices.Equal(knowledge.moves, want) {
+		t.Errorf("knowledge moves = %v, want %v", knowledge.moves, want)
+	}
+	if want := map[string]string{"chunk-1": "b", "chunk-2": "b"}; !maps.Equal(engine.chunkTags, want) {
+		t.Errorf("synced chunk tags = %v, want %v", engine.chunkTags, want)
+	}
+	if !slices.Equal(tags.deleted, []string{"a"}) {
+		t.Errorf("deleted tags = %v, want the source tag", tags.deleted)
+	}
+}
+
+func TestMergeTagsRejectsInvalidTags(t *testing.T) {
+	ctx := newTagTestContext()
+	tests := []struct {
+		name   string
+		source string
+		target string
+	}{
+		{"missing source", "", "b"},
+		{"same tag", "a", "a"},
+		{"unknown tag", "a", "x"},
+		{"other knowledge base", "a", "c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, tags, knowledge, _ := newMergeTagService()
+			if _, err := s.MergeTags(ctx, "kb-1", tt.source, tt.target); err == nil {
+				t.Fatal("MergeTags() error = nil, want an error")
+			}
+			if len(knowledge.moves) != 0 || len(tags.deleted) != 0 {
+				t.Errorf("moves = %v, deleted = %v, want nothing changed", knowledge.moves, tags.deleted)
+			}
+		})
+	}
+}
+
+func TestDeleteTagUntagsContent(t *testing.T) {
+	ctx := newTagTestContext()
+	s, tags, knowledge, engine := newMergeTagService()
+
+	if err := s.DeleteTag(ctx, "a", false, false, nil); err != nil {
+		t.Fatalf("DeleteTag() error = %v", err)
+	}
+	if want := [][2]string{{"a", ""}}; !slices.Equal(knowledge.moves, want) {
+		t.Errorf("knowledge moves = %v, want %v", knowledge.moves, want)
+	}
+	if want := map[string]string{"chunk-1": "", "chunk-2": ""}; !maps.Equal(engine.chunkTags, want) {
+		t.Errorf("synced chunk tags = %v, want %v", engine.chunkTags, want)
+	}
+	if !slices.Equal(tags.deleted, []string{"a"}) {
+		t.Errorf("deleted tags = %v, want the tag", tags.deleted)
+	}
+}
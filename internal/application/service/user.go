@@ -46,9 +46,10 @@ func getJwtSecret() string {
 
 // userService implements the UserService interface
 type userService struct {
-	userRepo      interfaces.UserRepository
-	tokenRepo     interfaces.AuthTokenRepository
-	tenantService interfaces.TenantService
+	userRepo         interfaces.UserRepository
+	tokenRepo        interfaces.AuthTokenRepository
+	tenantService    interfaces.TenantService
+	notificationRepo interfaces.UserNotificationRepository
 }
 
 // NewUserService creates a new user service instance
@@ -56,11 +57,13 @@ func NewUserService(
 	userRepo interfaces.UserRepository,
 	tokenRepo interfaces.AuthTokenRepository,
 	tenantService interfaces.TenantService,
+	notificationRepo interfaces.UserNotificationRepository,
 ) interfaces.UserService {
 	return &userService{
-		userRepo:      userRepo,
-		tokenRepo:     tokenRepo,
-		tenantService: tenantService,
+		userRepo:         userRepo,
+		tokenRepo:        tokenRepo,
+		tenantService:    tenantService,
+		notificationRepo: notificationRepo,
 	}
 }
 
@@ -203,6 +206,11 @@ func (s *userService) GetUserByID(ctx context.Context, id string) (*types.User,
 	return s.userRepo.GetUserByID(ctx, id)
 }
 
+// GetUsersByIDs gets the users with the given IDs
+func (s *userService) GetUsersByIDs(ctx context.Context, ids []string) ([]*types.User, error) {
+	return s.userRepo.GetUsersByIDs(ctx, ids)
+}
+
 // GetUserByEmail gets a user by email
 func (s *userService) GetUserByEmail(ctx context.Context, email string) (*types.User, error) {
 	return s.userRepo.GetUserByEmail(ctx, email)
@@ -439,3 +447,14 @@ func (s *userService) SearchUsers(ctx context.Context, query string, limit int)
 	}
 	return s.userRepo.SearchUsers(ctx, query, limit)
 }
+
+// ListNotifications lists a page of the notifications of a user, newest first
+func (s *userService) ListNotifications(ctx context.Context,
+	userID string, page *types.Pagination,
+) (*types.PageResult, error) {
+	notifications, total, err := s.notificationRepo.ListByUserID(ctx, userID, page)
+	if err != nil {
+		return nil, err
+	}
+	return types.NewPageResult(total, page, notifications), nil
+}
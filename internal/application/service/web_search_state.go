@@ -11,6 +11,15 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
+// tempKBState is the session-scoped temporary KB state stored in Redis.
+// AttachmentIDs is the subset of KnowledgeIDs uploaded by the user as session attachments.
+type tempKBState struct {
+	KBID          string          `json:"kbID"`
+	KnowledgeIDs  []string        `json:"knowledgeIDs"`
+	SeenURLs      map[string]bool `json:"seenURLs"`
+	AttachmentIDs []string        `json:"attachmentIDs,omitempty"`
+}
+
 // webSearchStateService implements the WebSearchStateService interface
 type webSearchStateService struct {
 	redisClient          *redis.Client
@@ -36,23 +45,13 @@ func (s *webSearchStateService) GetWebSearchTempKBState(
 	ctx context.Context,
 	sessionID string,
 ) (tempKBID string, seenURLs map[string]bool, knowledgeIDs []string) {
-	stateKey := fmt.Sprintf("tempkb:%s", sessionID)
-	if raw, getErr := s.redisClient.Get(ctx, stateKey).Bytes(); getErr == nil && len(raw) > 0 {
-		var state struct {
-			KBID         string          `json:"kbID"`
-			KnowledgeIDs []string        `json:"knowledgeIDs"`
-			SeenURLs     map[string]bool `json:"seenURLs"`
-		}
-		if err := json.Unmarshal(raw, &state); err == nil {
-			tempKBID = state.KBID
-			ids := state.KnowledgeIDs
-			if state.SeenURLs != nil {
-				seenURLs = state.SeenURLs
-			} else {
-				seenURLs = make(map[string]bool)
-			}
-			return tempKBID, seenURLs, ids
+	if state, ok := s.loadTempKBState(ctx, sessionID); ok {
+		if state.SeenURLs != nil {
+			seenURLs = state.SeenURLs
+		} else {
+			seenURLs = make(map[string]bool)
 		}
+		return state.KBID, seenURLs, state.KnowledgeIDs
 	}
 	return "", make(map[string]bool), []string{}
 }
@@ -65,19 +64,75 @@ func (s *webSearchStateService) SaveWebSearchTempKBState(
 	seenURLs map[string]bool,
 	knowledgeIDs []string,
 ) {
-	stateKey := fmt.Sprintf("tempkb:%s", sessionID)
-	state := struct {
-		KBID         string          `json:"kbID"`
-		KnowledgeIDs []string        `json:"knowledgeIDs"`
-		SeenURLs     map[string]bool `json:"seenURLs"`
-	}{
+	state := &tempKBState{
 		KBID:         tempKBID,
 		KnowledgeIDs: knowledgeIDs,
 		SeenURLs:     seenURLs,
 	}
-	if b, err := json.Marshal(state); err == nil {
-		_ = s.redisClient.Set(ctx, stateKey, b, 0).Err()
+	// Keep the attachments recorded for the same temp KB
+	if existing, ok := s.loadTempKBState(ctx, sessionID); ok && existing.KBID == tempKBID {
+		state.AttachmentIDs = existing.AttachmentIDs
+	}
+	_ = s.saveTempKBState(ctx, sessionID, state)
+}
+
+// AddSessionAttachment records a file attached to the session. The knowledge lives in the
+// session's temporary KB and is cleaned up together with it.
+func (s *webSearchStateService) AddSessionAttachment(
+	ctx context.Context,
+	sessionID string,
+	tempKBID string,
+	knowledgeID string,
+) error {
+	if s.redisClient == nil {
+		return fmt.Errorf("redis is required for session attachments")
+	}
+	state, ok := s.loadTempKBState(ctx, sessionID)
+	if !ok || state.KBID != tempKBID {
+		state = &tempKBState{KBID: tempKBID, SeenURLs: make(map[string]bool)}
+	}
+	state.KnowledgeIDs = append(state.KnowledgeIDs, knowledgeID)
+	state.AttachmentIDs = append(state.AttachmentIDs, knowledgeID)
+	return s.saveTempKBState(ctx, sessionID, state)
+}
+
+// GetSessionAttachments returns the session's temporary KB and the knowledge IDs of its attachments
+func (s *webSearchStateService) GetSessionAttachments(
+	ctx context.Context,
+	sessionID string,
+) (tempKBID string, attachmentIDs []string) {
+	if state, ok := s.loadTempKBState(ctx, sessionID); ok {
+		return state.KBID, state.AttachmentIDs
+	}
+	return "", nil
+}
+
+// loadTempKBState reads the session's temporary KB state from Redis
+func (s *webSearchStateService) loadTempKBState(ctx context.Context, sessionID string) (*tempKBState, bool) {
+	if s.redisClient == nil {
+		return nil, false
+	}
+	raw, err := s.redisClient.Get(ctx, fmt.Sprintf("tempkb:%s", sessionID)).Bytes()
+	if err != nil || len(raw) == 0 {
+		return nil, false
+	}
+	var state tempKBState
+	if err := json.Unmarshal(raw, &state); err != nil {
+		return nil, false
+	}
+	return &state, true
+}
+
+// saveTempKBState writes the session's temporary KB state to Redis
+func (s *webSearchStateService) saveTempKBState(ctx context.Context, sessionID string, state *tempKBState) error {
+	if s.redisClient == nil {
+		return nil
 	}
+	b, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return s.redisClient.Set(ctx, fmt.Sprintf("tempkb:%s", sessionID), b, 0).Err()
 }
 
 // DeleteWebSearchTempKBState deletes the temporary KB state for web search from Redis
@@ -94,11 +149,7 @@ func (s *webSearchStateService) DeleteWebSearchTempKBState(ctx context.Context,
 		return nil
 	}
 
-	var state struct {
-		KBID         string          `json:"kbID"`
-		KnowledgeIDs []string        `json:"knowledgeIDs"`
-		SeenURLs     map[string]bool `json:"seenURLs"`
-	}
+	var state tempKBState
 	if err := json.Unmarshal(raw, &state); err != nil {
 		// Invalid state, just delete the key
 		_ = s.redisClient.Del(ctx, stateKey).Err()
@@ -16,18 +16,19 @@ import (
 
 // Config 应用程序总配置
 type Config struct {
-	Conversation    *ConversationConfig    `yaml:"conversation"     json:"conversation"`
-	Server          *ServerConfig          `yaml:"server"           json:"server"`
-	KnowledgeBase   *KnowledgeBaseConfig   `yaml:"knowledge_base"   json:"knowledge_base"`
-	Tenant          *TenantConfig          `yaml:"tenant"           json:"tenant"`
-	Models          []ModelConfig          `yaml:"models"           json:"models"`
-	VectorDatabase  *VectorDatabaseConfig  `yaml:"vector_database"  json:"vector_database"`
-	DocReader       *DocReaderConfig       `yaml:"docreader"        json:"docreader"`
-	StreamManager   *StreamManagerConfig   `yaml:"stream_manager"   json:"stream_manager"`
-	ExtractManager  *ExtractManagerConfig  `yaml:"extract"          json:"extract"`
-	WebSearch       *WebSearchConfig       `yaml:"web_search"       json:"web_search"`
-	PromptTemplates *PromptTemplatesConfig `yaml:"prompt_templates" json:"prompt_templates"`
-	IM              *IMConfig              `yaml:"im"               json:"im"`
+	Conversation    *ConversationConfig     `yaml:"conversation"     json:"conversation"`
+	Server          *ServerConfig           `yaml:"server"           json:"server"`
+	KnowledgeBase   *KnowledgeBaseConfig    `yaml:"knowledge_base"   json:"knowledge_base"`
+	Tenant          *TenantConfig           `yaml:"tenant"           json:"tenant"`
+	Models          []ModelConfig           `yaml:"models"           json:"models"`
+	VectorDatabase  *VectorDatabaseConfig   `yaml:"vector_database"  json:"vector_database"`
+	DocReader       *DocReaderConfig        `yaml:"docreader"        json:"docreader"`
+	StreamManager   *StreamManagerConfig    `yaml:"stream_manager"   json:"stream_manager"`
+	ExtractManager  *ExtractManagerConfig   `yaml:"extract"          json:"extract"`
+	WebSearch       *WebSearchConfig        `yaml:"web_search"       json:"web_search"`
+	PromptTemplates *PromptTemplatesConfig  `yaml:"prompt_templates" json:"prompt_templates"`
+	IM              *IMConfig               `yaml:"im"               json:"im"`
+	ModelRetry      *types.ModelRetryConfig `yaml:"model_retry"      json:"model_retry"`
 }
 
 // IMConfig IM 集成配置
@@ -87,31 +88,70 @@ type VectorDatabaseConfig struct {
 
 // ConversationConfig 对话服务配置
 type ConversationConfig struct {
-	MaxRounds                  int            `yaml:"max_rounds"                    json:"max_rounds"`
-	KeywordThreshold           float64        `yaml:"keyword_threshold"             json:"keyword_threshold"`
-	EmbeddingTopK              int            `yaml:"embedding_top_k"               json:"embedding_top_k"`
-	VectorThreshold            float64        `yaml:"vector_threshold"              json:"vector_threshold"`
-	RerankTopK                 int            `yaml:"rerank_top_k"                  json:"rerank_top_k"`
-	RerankThreshold            float64        `yaml:"rerank_threshold"              json:"rerank_threshold"`
-	FallbackStrategy           string         `yaml:"fallback_strategy"             json:"fallback_strategy"`
-	FallbackResponse           string         `yaml:"fallback_response"             json:"fallback_response"`
-	FallbackPrompt             string         `yaml:"fallback_prompt"               json:"fallback_prompt"`
-	EnableRewrite              bool           `yaml:"enable_rewrite"                json:"enable_rewrite"`
-	EnableQueryExpansion       bool           `yaml:"enable_query_expansion"        json:"enable_query_expansion"`
-	EnableRerank               bool           `yaml:"enable_rerank"                 json:"enable_rerank"`
+	MaxRounds            int     `yaml:"max_rounds"                    json:"max_rounds"`
+	KeywordThreshold     float64 `yaml:"keyword_threshold"             json:"keyword_threshold"`
+	EmbeddingTopK        int     `yaml:"embedding_top_k"               json:"embedding_top_k"`
+	VectorThreshold      float64 `yaml:"vector_threshold"              json:"vector_threshold"`
+	RerankTopK           int     `yaml:"rerank_top_k"                  json:"rerank_top_k"`
+	RerankThreshold      float64 `yaml:"rerank_threshold"              json:"rerank_threshold"`
+	FallbackStrategy     string  `yaml:"fallback_strategy"             json:"fallback_strategy"`
+	FallbackResponse     string  `yaml:"fallback_response"             json:"fallback_response"`
+	FallbackPrompt       string  `yaml:"fallback_prompt"               json:"fallback_prompt"`
+	EnableRewrite        bool    `yaml:"enable_rewrite"                json:"enable_rewrite"`
+	EnableQueryExpansion bool    `yaml:"enable_query_expansion"        json:"enable_query_expansion"`
+	EnableRerank         bool    `yaml:"enable_rerank"                 json:"enable_rerank"`
+	// DedupThreshold is the token similarity at which retrieved chunks are treated as near-duplicates.
+	// Unset uses types.DefaultDedupThreshold; 0 disables near-duplicate removal.
+	DedupThreshold *float64 `yaml:"dedup_threshold"               json:"dedup_threshold"`
+	// MinResultsForAnswer is the number of merged chunks required before answering from
+	// the knowledge base; fewer triggers the fallback response. Defaults to 1.
+	MinResultsForAnswer int `yaml:"min_results_for_answer"        json:"min_results_for_answer"`
+	// CitationRequired only lets answers through that cite a retrieved chunk; answers without
+	// citations, and questions with nothing retrieved, get the fallback response instead.
+	CitationRequired bool `yaml:"citation_required"             json:"citation_required"`
+	// ChunkContextWindow adds this many adjacent chunks of the same document before and after each
+	// retrieved chunk when building the answer context. 0 keeps expanding only short chunks.
+	ChunkContextWindow         int            `yaml:"chunk_context_window"          json:"chunk_context_window"`
 	Summary                    *SummaryConfig `yaml:"summary"                       json:"summary"`
 	GenerateSessionTitlePrompt string         `yaml:"generate_session_title_prompt" json:"generate_session_title_prompt"`
 	GenerateSummaryPrompt      string         `yaml:"generate_summary_prompt"       json:"generate_summary_prompt"`
 	RewritePromptSystem        string         `yaml:"rewrite_prompt_system"         json:"rewrite_prompt_system"`
 	RewritePromptUser          string         `yaml:"rewrite_prompt_user"           json:"rewrite_prompt_user"`
-	SimplifyQueryPrompt        string         `yaml:"simplify_query_prompt"         json:"simplify_query_prompt"`
-	SimplifyQueryPromptUser    string         `yaml:"simplify_query_prompt_user"    json:"simplify_query_prompt_user"`
-	ExtractEntitiesPrompt      string         `yaml:"extract_entities_prompt"       json:"extract_entities_prompt"`
-	ExtractRelationshipsPrompt string         `yaml:"extract_relationships_prompt"  json:"extract_relationships_prompt"`
+	// RewritePromptsByLanguage overrides the rewrite prompts for queries detected in a given language
+	// (ISO 639-1 code such as "en", "ja"); other languages use RewritePromptSystem/RewritePromptUser
+	RewritePromptsByLanguage   types.RewritePrompts `yaml:"rewrite_prompts_by_language" json:"rewrite_prompts_by_language"`
+	SimplifyQueryPrompt        string               `yaml:"simplify_query_prompt"         json:"simplify_query_prompt"`
+	SimplifyQueryPromptUser    string               `yaml:"simplify_query_prompt_user"    json:"simplify_query_prompt_user"`
+	ExtractEntitiesPrompt      string               `yaml:"extract_entities_prompt"       json:"extract_entities_prompt"`
+	ExtractRelationshipsPrompt string               `yaml:"extract_relationships_prompt"  json:"extract_relationships_prompt"`
 	// GenerateQuestionsPrompt is used to generate questions for document chunks to improve recall
 	GenerateQuestionsPrompt string `yaml:"generate_questions_prompt" json:"generate_questions_prompt"`
 }
 
+// GetDedupThreshold returns the configured near-duplicate threshold, falling back to the default
+func (c *ConversationConfig) GetDedupThreshold() float64 {
+	if c == nil || c.DedupThreshold == nil {
+		return types.DefaultDedupThreshold
+	}
+	return *c.DedupThreshold
+}
+
+// GetMinResultsForAnswer returns the configured minimum merged result count, at least 1
+func (c *ConversationConfig) GetMinResultsForAnswer() int {
+	if c == nil || c.MinResultsForAnswer < types.DefaultMinResultsForAnswer {
+		return types.DefaultMinResultsForAnswer
+	}
+	return c.MinResultsForAnswer
+}
+
+// GetChunkContextWindow returns the configured chunk context window, clamped to [0, types.MaxChunkContextWindow]
+func (c *ConversationConfig) GetChunkContextWindow() int {
+	if c == nil {
+		return 0
+	}
+	return min(max(c.ChunkContextWindow, 0), types.MaxChunkContextWindow)
+}
+
 // SummaryConfig 摘要配置
 type SummaryConfig struct {
 	MaxTokens           int     `yaml:"max_tokens"            json:"max_tokens"`
@@ -144,6 +184,50 @@ type KnowledgeBaseConfig struct {
 	SplitMarkers    []string               `yaml:"split_markers"    json:"split_markers"`
 	KeepSeparator   bool                   `yaml:"keep_separator"   json:"keep_separator"`
 	ImageProcessing *ImageProcessingConfig `yaml:"image_processing" json:"image_processing"`
+	// EmbeddingBatchSize is the number of chunks sent to the embedding model per request (tenants can override it)
+	EmbeddingBatchSize int `yaml:"embedding_batch_size" json:"embedding_batch_size"`
+	// EmbeddingCache reuses the vectors of chunks whose text was already embedded with the same model
+	EmbeddingCache *EmbeddingCacheConfig `yaml:"embedding_cache" json:"embedding_cache"`
+	// ProcessingConcurrency limits the document processing tasks a tenant runs at the same time
+	ProcessingConcurrency *ProcessingConcurrencyConfig `yaml:"processing_concurrency" json:"processing_concurrency"`
+	// APIKeyUploadLimits bounds the uploads of each scoped API key
+	APIKeyUploadLimits *APIKeyUploadLimitsConfig `yaml:"api_key_upload_limits" json:"api_key_upload_limits"`
+}
+
+// APIKeyUploadLimitsConfig 限定知识库的 API Key 上传频率和每日配额的默认值，单个 API Key 可单独设置
+type APIKeyUploadLimitsConfig struct {
+	// PerMinute is the number of uploads a key may make per minute, 0 means unlimited
+	PerMinute int `yaml:"per_minute" json:"per_minute"`
+	// Daily is the number of uploads a key may make per day, 0 means unlimited
+	Daily int `yaml:"daily" json:"daily"`
+}
+
+// ProcessingConcurrencyConfig 每个租户同时处理的文档任务数限制，避免单个租户的批量上传占满所有 worker
+type ProcessingConcurrencyConfig struct {
+	// PerTenant is the default limit of every tenant, 0 means unlimited
+	PerTenant int `yaml:"per_tenant" json:"per_tenant"`
+	// TenantOverrides sets the limit of specific tenants by tenant ID, 0 means unlimited
+	TenantOverrides map[uint64]int `yaml:"tenant_overrides" json:"tenant_overrides"`
+}
+
+// LimitFor returns the number of document processing tasks the tenant may run at once, 0 means unlimited
+func (c *ProcessingConcurrencyConfig) LimitFor(tenantID uint64) int {
+	if c == nil {
+		return 0
+	}
+	if limit, ok := c.TenantOverrides[tenantID]; ok {
+		return max(limit, 0)
+	}
+	return max(c.PerTenant, 0)
+}
+
+// EmbeddingCacheConfig 向量缓存配置，相同文本和嵌入模型的分块复用已有向量
+type EmbeddingCacheConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// TTLHours is how long a cached vector is kept (default 168, i.e. 7 days)
+	TTLHours int `yaml:"ttl_hours" json:"ttl_hours"`
+	// MaxEntries bounds the in-memory cache used when Redis is not configured (default 10000)
+	MaxEntries int `yaml:"max_entries" json:"max_entries"`
 }
 
 // ImageProcessingConfig 图像处理配置
@@ -189,9 +273,10 @@ type ModelConfig struct {
 
 // StreamManagerConfig 流管理器配置
 type StreamManagerConfig struct {
-	Type           string        `yaml:"type"            json:"type"`            // 类型: "memory" 或 "redis"
-	Redis          RedisConfig   `yaml:"redis"           json:"redis"`           // Redis配置
-	CleanupTimeout time.Duration `yaml:"cleanup_timeout" json:"cleanup_timeout"` // 清理超时，单位秒
+	Type              string        `yaml:"type"               json:"type"`               // 类型: "memory" 或 "redis"
+	Redis             RedisConfig   `yaml:"redis"              json:"redis"`              // Redis配置
+	CleanupTimeout    time.Duration `yaml:"cleanup_timeout"    json:"cleanup_timeout"`    // 清理超时，单位秒
+	HeartbeatInterval time.Duration `yaml:"heartbeat_interval" json:"heartbeat_interval"` // SSE 心跳间隔，0 使用默认值(15s)，负数关闭
 }
 
 // RedisConfig Redis配置
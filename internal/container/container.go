@@ -128,10 +128,13 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	must(container.Provide(repository.NewModelRepository))
 	must(container.Provide(repository.NewUserRepository))
 	must(container.Provide(repository.NewAuthTokenRepository))
+	must(container.Provide(repository.NewUserNotificationRepository))
 	must(container.Provide(neo4jRepo.NewNeo4jRepository))
 	must(container.Provide(memoryRepo.NewMemoryRepository))
 	must(container.Provide(repository.NewMCPServiceRepository))
 	must(container.Provide(repository.NewCustomAgentRepository))
+	must(container.Provide(repository.NewPromptTemplateRepository))
+	must(container.Provide(repository.NewAPIKeyRepository))
 	must(container.Provide(repository.NewOrganizationRepository))
 	must(container.Provide(repository.NewKBShareRepository))
 	must(container.Provide(repository.NewAgentShareRepository))
@@ -153,6 +156,7 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	must(container.Provide(service.NewChunkService))
 	must(container.Provide(service.NewKnowledgeTagService))
 	must(container.Provide(embedding.NewBatchEmbedder))
+	must(container.Provide(embedding.NewVectorCache))
 	must(container.Provide(service.NewModelService))
 	must(container.Provide(service.NewDatasetService))
 	must(container.Provide(service.NewEvaluationService))
@@ -166,6 +170,9 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	must(container.Provide(service.NewMessageService))
 	must(container.Provide(service.NewMCPServiceService))
 	must(container.Provide(service.NewCustomAgentService))
+	must(container.Provide(service.NewPromptTemplateService))
+	must(container.Provide(service.NewAPIKeyService))
+	must(container.Provide(service.NewConfigBundleService))
 	must(container.Provide(memoryService.NewMemoryService))
 
 	// Web search service (needed by AgentService)
@@ -235,6 +242,9 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	must(container.Provide(handler.NewMCPServiceHandler))
 	must(container.Provide(handler.NewWebSearchHandler))
 	must(container.Provide(handler.NewCustomAgentHandler))
+	must(container.Provide(handler.NewPromptTemplateHandler))
+	must(container.Provide(handler.NewAPIKeyHandler))
+	must(container.Provide(handler.NewConfigBundleHandler))
 	must(container.Provide(service.NewSkillService))
 	must(container.Provide(handler.NewSkillHandler))
 	must(container.Provide(handler.NewOrganizationHandler))
@@ -246,6 +256,10 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	must(container.Provide(handler.NewIMHandler))
 	logger.Debugf(ctx, "[Container] HTTP handlers registered")
 
+	// Background conversation history pruning
+	must(container.Invoke(startHistoryPruner))
+	must(container.Invoke(startJoinRequestSweeper))
+
 	// Router configuration
 	logger.Debugf(ctx, "[Container] Registering router and starting task server...")
 	must(container.Provide(router.NewRouter))
@@ -819,6 +833,70 @@ func registerPoolCleanup(pool *ants.Pool, cleaner interfaces.ResourceCleaner) {
 	})
 }
 
+// historyPruneInterval is how often the background pruner applies tenant history retention limits
+const historyPruneInterval = time.Hour
+
+// startHistoryPruner starts the background goroutine that trims session history according to
+// each tenant's history retention config, and stops it on shutdown
+// Parameters:
+//   - sessionService: Session service performing the pruning
+//   - cleaner: Resource cleaner
+func startHistoryPruner(sessionService interfaces.SessionService, cleaner interfaces.ResourceCleaner) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		ticker := time.NewTicker(historyPruneInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := sessionService.PruneAllSessionHistory(ctx); err != nil {
+					logger.Warnf(ctx, "History pruning failed: %v", err)
+				}
+			}
+		}
+	}()
+	cleaner.RegisterWithName("HistoryPruner", func() error {
+		cancel()
+		return nil
+	})
+}
+
+// joinRequestSweepInterval is how often the background sweeper expires stale organization join requests
+const joinRequestSweepInterval = time.Hour
+
+// startJoinRequestSweeper starts the background goroutine that marks join requests left pending
+// longer than their organization's TTL as expired and deletes expired email invites, and stops it
+// on shutdown
+// Parameters:
+//   - orgService: Organization service expiring the requests
+//   - cleaner: Resource cleaner
+func startJoinRequestSweeper(orgService interfaces.OrganizationService, cleaner interfaces.ResourceCleaner) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		ticker := time.NewTicker(joinRequestSweepInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := orgService.ExpireStaleJoinRequests(ctx); err != nil {
+					logger.Warnf(ctx, "Join request expiry failed: %v", err)
+				}
+				if err := orgService.DeleteExpiredPendingInvites(ctx); err != nil {
+					logger.Warnf(ctx, "Pending invite cleanup failed: %v", err)
+				}
+			}
+		}
+	}()
+	cleaner.RegisterWithName("JoinRequestSweeper", func() error {
+		cancel()
+		return nil
+	})
+}
+
 // registerTracerCleanup registers the tracer for cleanup
 // Ensures proper cleanup of the tracer when application shuts down
 // Parameters:
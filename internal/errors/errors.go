@@ -36,6 +36,10 @@ const (
 	ErrAgentInvalidMaxIterations ErrorCode = 2102
 	ErrAgentInvalidTemperature   ErrorCode = 2103
 
+	// Knowledge base related error codes (2200-2299)
+	ErrEmbeddingDimensionMismatch ErrorCode = 2200
+	ErrEmbeddingReindexRequired   ErrorCode = 2201
+
 	// Add more error codes here
 )
 
@@ -103,6 +107,15 @@ func NewConflictError(message string) *AppError {
 	}
 }
 
+// NewTooManyRequestsError creates a too many requests error
+func NewTooManyRequestsError(message string) *AppError {
+	return &AppError{
+		Code:     ErrTooManyRequests,
+		Message:  message,
+		HTTPCode: http.StatusTooManyRequests,
+	}
+}
+
 // NewInternalServerError creates an internal server error
 func NewInternalServerError(message string) *AppError {
 	if message == "" {
@@ -184,6 +197,29 @@ func NewAgentInvalidTemperatureError() *AppError {
 	}
 }
 
+// Knowledge base related errors
+func NewEmbeddingDimensionMismatchError(currentDimension, newDimension int) *AppError {
+	return &AppError{
+		Code:     ErrEmbeddingDimensionMismatch,
+		Message:  "新Embedding模型的向量维度与知识库现有向量不一致，请同时设置 reindex 为 true 以重建索引",
+		HTTPCode: http.StatusBadRequest,
+		Details: map[string]int{
+			"current_dimension": currentDimension,
+			"new_dimension":     newDimension,
+		},
+	}
+}
+
+// NewEmbeddingReindexRequiredError reports an embedding model change without reindex on a knowledge
+// base that has content
+func NewEmbeddingReindexRequiredError() *AppError {
+	return &AppError{
+		Code:     ErrEmbeddingReindexRequired,
+		Message:  "知识库已有内容，更换Embedding模型需同时设置 reindex 为 true 以重建索引",
+		HTTPCode: http.StatusBadRequest,
+	}
+}
+
 // IsAppError checks if the error is an AppError type
 func IsAppError(err error) (*AppError, bool) {
 	appErr, ok := err.(*AppError)
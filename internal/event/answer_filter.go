@@ -0,0 +1,102 @@
+package event
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// InstallAnswerFilters installs the answer filters of the tenant in ctx on a QA event bus: banned
+// words are masked or halt the answer, then sensitive text is redacted. Every path that runs
+// KnowledgeQA or AgentQA (HTTP, IM, agent evaluation) calls it before the answer streams, so no
+// consumer of the bus sees the unfiltered answer.
+func InstallAnswerFilters(ctx context.Context, eventBus *EventBus) {
+	tenant, _ := types.TenantInfoFromContext(ctx)
+	if tenant == nil {
+		return
+	}
+	if filter := newBannedWordsFilter(tenant.BannedWordsConfig); filter != nil {
+		eventBus.AddFilter(newAnswerStreamFilter(filter).Filter)
+	}
+	if filter := newRedactionFilter(ctx, tenant.RedactionConfig); filter != nil {
+		eventBus.AddFilter(newAnswerStreamFilter(filter).Filter)
+	}
+}
+
+// answerTransformer rewrites the text of a streamed answer
+type answerTransformer interface {
+	// transform processes the text not emitted yet. Unless final, rest is held back and prepended to
+	// the next chunk. stop ends the answer: the chunk is marked done and later chunks are dropped.
+	transform(ctx context.Context, text string, final bool) (out string, rest string, stop bool)
+	// transformText processes a complete answer
+	transformText(text string) string
+}
+
+// answerStreamFilter applies an answerTransformer to the answer chunks of an event bus.
+// State is kept per session rather than per event ID: in agent mode the answer chunks share an ID
+// but the done marker that follows them has its own. The held-back text is flushed into the first
+// done chunk, and the state is dropped there or on EventAgentComplete, whichever comes first.
+type answerStreamFilter struct {
+	transformer answerTransformer
+
+	mu      sync.Mutex
+	streams map[string]*answerStream // keyed by session ID
+}
+
+// answerStream is the state of one streamed answer
+type answerStream struct {
+	pending string // text held back by the transformer
+	stopped bool
+}
+
+func newAnswerStreamFilter(transformer answerTransformer) *answerStreamFilter {
+	return &answerStreamFilter{
+		transformer: transformer,
+		streams:     make(map[string]*answerStream),
+	}
+}
+
+// Filter implements EmitFilter
+func (f *answerStreamFilter) Filter(ctx context.Context, evt Event) (Event, bool) {
+	switch data := evt.Data.(type) {
+	case AgentFinalAnswerData:
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		stream, ok := f.streams[evt.SessionID]
+		if !ok {
+			stream = &answerStream{}
+			f.streams[evt.SessionID] = stream
+		}
+		if stream.stopped {
+			if data.Done {
+				delete(f.streams, evt.SessionID)
+			}
+			return evt, false
+		}
+
+		out, rest, stop := f.transformer.transform(ctx, stream.pending+data.Content, data.Done)
+		data.Content = out
+		switch {
+		case stop && !data.Done:
+			stream.stopped = true
+			stream.pending = ""
+			data.Done = true
+		case data.Done:
+			delete(f.streams, evt.SessionID)
+		default:
+			stream.pending = rest
+		}
+		evt.Data = data
+	case AgentCompleteData:
+		f.mu.Lock()
+		delete(f.streams, evt.SessionID)
+		f.mu.Unlock()
+
+		// Agent mode persists the complete final answer from this event
+		data.FinalAnswer = f.transformer.transformText(data.FinalAnswer)
+		evt.Data = data
+	}
+	return evt, true
+}
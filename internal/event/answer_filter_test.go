@@ -0,0 +1,59 @@
+package event
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestInstallAnswerFilters(t *testing.T) {
+	bannedWords := &types.BannedWordsConfig{Enabled: true, Phrases: []string{"secret"}, Mask: "***"}
+	redaction := &types.RedactionConfig{Enabled: true, Rules: []types.RedactionRule{{Pattern: `\*+|b`}}}
+	tests := []struct {
+		name   string
+		tenant *types.Tenant
+		want   string
+	}{
+		{"banned words", &types.Tenant{BannedWordsConfig: bannedWords}, "a *** b"},
+		{"redaction", &types.Tenant{RedactionConfig: redaction}, "a secret [REDACTED]"},
+		{
+			"redaction after banned words",
+			&types.Tenant{BannedWordsConfig: bannedWords, RedactionConfig: redaction},
+			"a [REDACTED] [REDACTED]",
+		},
+		{"no tenant", nil, "a secret b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.tenant != nil {
+				ctx = context.WithValue(ctx, types.TenantInfoContextKey, tt.tenant)
+			}
+			bus := NewEventBus()
+			InstallAnswerFilters(ctx, bus)
+
+			answer, finalAnswer := "", ""
+			bus.On(EventAgentFinalAnswer, func(ctx context.Context, evt Event) error {
+				answer += evt.Data.(AgentFinalAnswerData).Content
+				return nil
+			})
+			bus.On(EventAgentComplete, func(ctx context.Context, evt Event) error {
+				finalAnswer = evt.Data.(AgentCompleteData).FinalAnswer
+				return nil
+			})
+			for _, evt := range agentAnswerEvents("a sec", "ret b") {
+				if data, ok := evt.Data.(AgentCompleteData); ok {
+					data.FinalAnswer = "a secret b"
+					evt.Data = data
+				}
+				if err := bus.Emit(ctx, evt); err != nil {
+					t.Fatalf("Emit() error = %v", err)
+				}
+			}
+			if answer != tt.want || finalAnswer != tt.want {
+				t.Errorf("answer = %q, final answer = %q, want %q", answer, finalAnswer, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package event
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// bannedWordsFilter masks banned phrases in streamed answers or halts the answer at the first one.
+// InstallAnswerFilters installs it through an answerStreamFilter on the QA event bus so every consumer
+// (SSE stream, IM reply, message persistence, partial answer saver) sees the filtered text.
+type bannedWordsFilter struct {
+	phrases     [][]rune // lower-cased phrases
+	maxLen      int      // longest phrase in runes
+	halt        bool
+	mask        []rune
+	haltMessage string
+}
+
+// newBannedWordsFilter returns nil when the config is not active
+func newBannedWordsFilter(cfg *types.BannedWordsConfig) *bannedWordsFilter {
+	if !cfg.IsActive() {
+		return nil
+	}
+	f := &bannedWordsFilter{
+		halt:        cfg.GetAction() == types.BannedWordsActionHalt,
+		mask:        []rune(cfg.GetMask()),
+		haltMessage: cfg.GetHaltMessage(),
+	}
+	for _, p := range cfg.Phrases {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		phrase := []rune(strings.ToLower(p))
+		f.phrases = append(f.phrases, phrase)
+		f.maxLen = max(f.maxLen, len(phrase))
+	}
+	return f
+}
+
+// transform implements answerTransformer
+func (f *bannedWordsFilter) transform(ctx context.Context, text string, final bool) (string, string, bool) {
+	out, rest, hit := f.scan([]rune(text), final)
+	if hit && f.halt {
+		logger.Warn(ctx, "Banned phrase detected in answer, halting")
+		return string(out) + f.haltMessage, "", true
+	}
+	return string(out), string(rest), false
+}
+
+// transformText implements answerTransformer
+func (f *bannedWordsFilter) transformText(text string) string {
+	out, _, hit := f.scan([]rune(text), true)
+	if hit && f.halt {
+		return string(out) + f.haltMessage
+	}
+	return string(out)
+}
+
+// scan returns the text that can be emitted and the text to hold back. Unless final, the last
+// maxLen-1 runes are held back so a phrase split across chunks is still detected.
+// In halt mode scanning stops at the first banned phrase and out ends right before it.
+func (f *bannedWordsFilter) scan(text []rune, final bool) (out []rune, rest []rune, hit bool) {
+	limit := len(text)
+	if !final {
+		limit = max(0, len(text)-(f.maxLen-1))
+	}
+	out = make([]rune, 0, limit)
+	i := 0
+	for i < limit {
+		if n := f.matchAt(text, i); n > 0 {
+			hit = true
+			if f.halt {
+				return out, nil, true
+			}
+			out = append(out, f.mask...)
+			i += n
+			continue
+		}
+		out = append(out, text[i])
+		i++
+	}
+	if i >= len(text) {
+		return out, nil, hit
+	}
+	return out, append([]rune(nil), text[i:]...), hit
+}
+
+// matchAt returns the length of the longest banned phrase starting at text[i], or 0
+func (f *bannedWordsFilter) matchAt(text []rune, i int) int {
+	best := 0
+	for _, phrase := range f.phrases {
+		if len(phrase) <= best || i+len(phrase) > len(text) {
+			continue
+		}
+		matched := true
+		for k, r := range phrase {
+			if unicode.ToLower(text[i+k]) != r {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			best = len(phrase)
+		}
+	}
+	return best
+}
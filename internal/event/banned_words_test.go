@@ -0,0 +1,133 @@
+package event
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// normalAnswerEvents streams chunks the way the normal mode pipeline does: one event ID, the last
+// chunk carries Done
+func normalAnswerEvents(chunks ...string) []Event {
+	events := make([]Event, 0, len(chunks)+1)
+	for i, chunk := range chunks {
+		events = append(events, Event{
+			ID:        "answer-1",
+			Type:      EventAgentFinalAnswer,
+			SessionID: "session-1",
+			Data:      AgentFinalAnswerData{Content: chunk, Done: i == len(chunks)-1},
+		})
+	}
+	return append(events, Event{
+		Type:      EventAgentComplete,
+		SessionID: "session-1",
+		Data:      AgentCompleteData{},
+	})
+}
+
+// agentAnswerEvents streams chunks the way the agent engine does: the chunks are never done and are
+// followed by an empty done marker with its own event ID, then by the complete event
+func agentAnswerEvents(chunks ...string) []Event {
+	events := make([]Event, 0, len(chunks)+2)
+	for _, chunk := range chunks {
+		events = append(events, Event{
+			ID:        "answer-1",
+			Type:      EventAgentFinalAnswer,
+			SessionID: "session-1",
+			Data:      AgentFinalAnswerData{Content: chunk},
+		})
+	}
+	return append(events,
+		Event{
+			ID:        "answer-done-1",
+			Type:      EventAgentFinalAnswer,
+			SessionID: "session-1",
+			Data:      AgentFinalAnswerData{Done: true},
+		},
+		Event{
+			Type:      EventAgentComplete,
+			SessionID: "session-1",
+			Data:      AgentCompleteData{},
+		})
+}
+
+// runAnswerFilter passes the events through the filter and returns the streamed answer and whether
+// a done chunk was delivered. It fails if the filter keeps state after the answer.
+func runAnswerFilter(t *testing.T, transformer answerTransformer, events []Event) (string, bool) {
+	t.Helper()
+	f := newAnswerStreamFilter(transformer)
+	answer, done := "", false
+	for _, evt := range events {
+		out, ok := f.Filter(context.Background(), evt)
+		if !ok {
+			continue
+		}
+		data, isAnswer := out.Data.(AgentFinalAnswerData)
+		if !isAnswer {
+			continue
+		}
+		if done {
+			t.Fatalf("chunk %q delivered after the done chunk", data.Content)
+		}
+		answer += data.Content
+		done = data.Done
+	}
+	if len(f.streams) != 0 {
+		t.Errorf("filter kept %d streams after the answer", len(f.streams))
+	}
+	return answer, done
+}
+
+func TestBannedWordsFilter(t *testing.T) {
+	mask := &types.BannedWordsConfig{Enabled: true, Phrases: []string{"secret", "top secret"}, Mask: "***"}
+	halt := &types.BannedWordsConfig{
+		Enabled: true, Phrases: []string{"secret"}, Action: types.BannedWordsActionHalt, HaltMessage: "[halted]",
+	}
+	tests := []struct {
+		name   string
+		cfg    *types.BannedWordsConfig
+		events []Event
+		want   string
+	}{
+		{"mask", mask, normalAnswerEvents("a secret b"), "a *** b"},
+		{"mask case insensitive", mask, normalAnswerEvents("a SeCrEt b"), "a *** b"},
+		{"mask longest phrase", mask, normalAnswerEvents("a top secret b"), "a *** b"},
+		{"mask split across chunks", mask, normalAnswerEvents("a se", "cr", "et b"), "a *** b"},
+		{"mask tail flushed", mask, normalAnswerEvents("abcdefgh", "ijklmnop", "q"), "abcdefghijklmnopq"},
+		{"mask agent mode", mask, agentAnswerEvents("a sec", "ret b", "xyz"), "a *** bxyz"},
+		{"mask tail flushed in agent mode", mask, agentAnswerEvents("abcdefgh", "ijklmnop"), "abcdefghijklmnop"},
+		{"halt", halt, normalAnswerEvents("a secret b"), "a [halted]"},
+		{"halt split across chunks", halt, normalAnswerEvents("a sec", "ret b", "c"), "a [halted]"},
+		{"halt agent mode", halt, agentAnswerEvents("a sec", "ret b", "c"), "a [halted]"},
+		{"halt without phrase", halt, agentAnswerEvents("abcdefgh", "ijk"), "abcdefghijk"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			answer, done := runAnswerFilter(t, newBannedWordsFilter(tt.cfg), tt.events)
+			if answer != tt.want {
+				t.Errorf("answer = %q, want %q", answer, tt.want)
+			}
+			if !done {
+				t.Error("no done chunk delivered")
+			}
+		})
+	}
+}
+
+func TestBannedWordsFilterFinalAnswer(t *testing.T) {
+	cfg := &types.BannedWordsConfig{
+		Enabled: true, Phrases: []string{"secret"}, Action: types.BannedWordsActionHalt, HaltMessage: "[halted]",
+	}
+	f := newAnswerStreamFilter(newBannedWordsFilter(cfg))
+	evt, ok := f.Filter(context.Background(), Event{
+		Type: EventAgentComplete,
+		Data: AgentCompleteData{FinalAnswer: "a secret b"},
+	})
+	if !ok {
+		t.Fatal("complete event dropped")
+	}
+	if got := evt.Data.(AgentCompleteData).FinalAnswer; got != "a [halted]" {
+		t.Errorf("FinalAnswer = %q, want %q", got, "a [halted]")
+	}
+}
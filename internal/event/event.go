@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"sync"
 
+	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/google/uuid"
 )
 
@@ -47,12 +48,13 @@ const (
 	EventAgentComplete EventType = "agent.complete" // Agent 完成
 
 	// Agent streaming events (for real-time feedback)
-	EventAgentThought     EventType = "thought"      // Agent 思考过程
-	EventAgentToolCall    EventType = "tool_call"    // 工具调用通知
-	EventAgentToolResult  EventType = "tool_result"  // 工具结果
-	EventAgentReflection  EventType = "reflection"   // Agent 反思
-	EventAgentReferences  EventType = "references"   // 知识引用
-	EventAgentFinalAnswer EventType = "final_answer" // 最终答案
+	EventAgentThought        EventType = "thought"         // Agent 思考过程
+	EventAgentToolCall       EventType = "tool_call"       // 工具调用通知
+	EventAgentToolResult     EventType = "tool_result"     // 工具结果
+	EventAgentReflection     EventType = "reflection"      // Agent 反思
+	EventAgentReferences     EventType = "references"      // 知识引用
+	EventAgentFinalAnswer    EventType = "final_answer"    // 最终答案
+	EventAgentBudgetExceeded EventType = "budget_exceeded" // Agent 达到最大迭代次数，答案可能不完整
 
 	// Error events
 	EventError EventType = "error" // 错误事件
@@ -61,7 +63,8 @@ const (
 	EventSessionTitle EventType = "session_title" // 会话标题更新
 
 	// Control events
-	EventStop EventType = "stop" // 停止对话生成
+	EventStop      EventType = "stop"      // 停止对话生成
+	EventHeartbeat EventType = "heartbeat" // 流式连接保活心跳
 )
 
 // Event represents an event in the system
@@ -77,26 +80,159 @@ type Event struct {
 // EventHandler is a function that handles events
 type EventHandler func(ctx context.Context, event Event) error
 
+// subscriberBufferSize is the channel buffer size of each subscription
+const subscriberBufferSize = 64
+
+// subscription is a channel-based consumer of events for one session
+type subscription struct {
+	sessionID  string             // 订阅的会话ID，为空表示所有会话
+	eventTypes map[EventType]bool // 订阅的事件类型，为 nil 表示所有类型
+	mu         sync.Mutex         // 保护 ch 的发送与关闭
+	ch         chan Event
+	closed     bool // 取消订阅或缓冲区溢出后为 true
+}
+
+// matches reports whether the event should be delivered to this subscription
+func (s *subscription) matches(event Event) bool {
+	if s.sessionID != "" && s.sessionID != event.SessionID {
+		return false
+	}
+	return s.eventTypes == nil || s.eventTypes[event.Type]
+}
+
+// send delivers the event without blocking, reporting false when the buffer is full.
+// A full buffer closes the channel, so the consumer learns it has missed events.
+func (s *subscription) send(event Event) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return true
+	}
+	select {
+	case s.ch <- event:
+		return true
+	default:
+		s.closed = true
+		close(s.ch)
+		return false
+	}
+}
+
+// close closes the channel unless it is already closed
+func (s *subscription) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.closed {
+		s.closed = true
+		close(s.ch)
+	}
+}
+
+// EmitFilter rewrites an event before it is delivered; returning false drops the event
+type EmitFilter func(ctx context.Context, event Event) (Event, bool)
+
 // EventBus manages event publishing and subscription
 type EventBus struct {
-	mu        sync.RWMutex
-	handlers  map[EventType][]EventHandler
-	asyncMode bool // 是否异步处理事件
+	mu            sync.RWMutex
+	handlers      map[EventType][]EventHandler
+	filters       []EmitFilter
+	subscriptions map[uint64]*subscription
+	nextSubID     uint64
+	asyncMode     bool // 是否异步处理事件
 }
 
 // NewEventBus creates a new EventBus instance
 func NewEventBus() *EventBus {
 	return &EventBus{
-		handlers:  make(map[EventType][]EventHandler),
-		asyncMode: false,
+		handlers:      make(map[EventType][]EventHandler),
+		subscriptions: make(map[uint64]*subscription),
+		asyncMode:     false,
 	}
 }
 
 // NewAsyncEventBus creates a new EventBus with async mode enabled
 func NewAsyncEventBus() *EventBus {
 	return &EventBus{
-		handlers:  make(map[EventType][]EventHandler),
-		asyncMode: true,
+		handlers:      make(map[EventType][]EventHandler),
+		subscriptions: make(map[uint64]*subscription),
+		asyncMode:     true,
+	}
+}
+
+// Subscribe returns a channel that receives every event of a session (all sessions if sessionID is empty).
+// The returned function cancels the subscription and must be called once the consumer is done.
+// The channel is closed on cancel, or early when the consumer falls more than the buffer size behind.
+func (eb *EventBus) Subscribe(sessionID string) (<-chan Event, func()) {
+	return eb.subscribe(sessionID, nil)
+}
+
+// SubscribeTypes returns a channel that only receives events of the given types for a session.
+// Events of other types are never delivered to the channel. With no types it behaves like Subscribe.
+// The returned function cancels the subscription and must be called once the consumer is done.
+// The channel is closed on cancel, or early when the consumer falls more than the buffer size behind.
+func (eb *EventBus) SubscribeTypes(sessionID string, eventTypes ...EventType) (<-chan Event, func()) {
+	if len(eventTypes) == 0 {
+		return eb.subscribe(sessionID, nil)
+	}
+	filter := make(map[EventType]bool, len(eventTypes))
+	for _, eventType := range eventTypes {
+		filter[eventType] = true
+	}
+	return eb.subscribe(sessionID, filter)
+}
+
+// subscribe registers a subscription and returns its channel and cancel function
+func (eb *EventBus) subscribe(sessionID string, eventTypes map[EventType]bool) (<-chan Event, func()) {
+	sub := &subscription{
+		sessionID:  sessionID,
+		eventTypes: eventTypes,
+		ch:         make(chan Event, subscriberBufferSize),
+	}
+
+	eb.mu.Lock()
+	if eb.subscriptions == nil {
+		eb.subscriptions = make(map[uint64]*subscription)
+	}
+	eb.nextSubID++
+	id := eb.nextSubID
+	eb.subscriptions[id] = sub
+	eb.mu.Unlock()
+
+	unsubscribe := func() {
+		eb.removeSubscription(id)
+		sub.close()
+	}
+	return sub.ch, unsubscribe
+}
+
+// removeSubscription stops delivering events to a subscription
+func (eb *EventBus) removeSubscription(id uint64) {
+	eb.mu.Lock()
+	defer eb.mu.Unlock()
+
+	delete(eb.subscriptions, id)
+}
+
+// publish delivers an event to all matching subscriptions without blocking the emitter.
+// A subscriber whose buffer is full loses the event and is unsubscribed, which closes its channel.
+func (eb *EventBus) publish(ctx context.Context, event Event) {
+	eb.mu.RLock()
+	targets := make(map[uint64]*subscription)
+	for id, sub := range eb.subscriptions {
+		if sub.matches(event) {
+			targets[id] = sub
+		}
+	}
+	eb.mu.RUnlock()
+
+	for id, sub := range targets {
+		if !sub.send(event) {
+			eb.removeSubscription(id)
+			logger.Warnf(ctx, "Event subscriber for session %q fell behind, dropped %s and unsubscribed it",
+				sub.sessionID, event.Type)
+		}
 	}
 }
 
@@ -109,6 +245,30 @@ func (eb *EventBus) On(eventType EventType, handler EventHandler) {
 	eb.handlers[eventType] = append(eb.handlers[eventType], handler)
 }
 
+// AddFilter registers a filter applied to every emitted event before handlers and subscriptions see it.
+// Filters run in registration order on the emitting goroutine.
+func (eb *EventBus) AddFilter(filter EmitFilter) {
+	eb.mu.Lock()
+	defer eb.mu.Unlock()
+
+	eb.filters = append(eb.filters, filter)
+}
+
+// applyFilters runs the registered filters, reporting false when the event was dropped
+func (eb *EventBus) applyFilters(ctx context.Context, event Event) (Event, bool) {
+	eb.mu.RLock()
+	filters := eb.filters
+	eb.mu.RUnlock()
+
+	for _, filter := range filters {
+		var ok bool
+		if event, ok = filter(ctx, event); !ok {
+			return event, false
+		}
+	}
+	return event, true
+}
+
 // Off removes all handlers for a specific event type
 func (eb *EventBus) Off(eventType EventType) {
 	eb.mu.Lock()
@@ -117,7 +277,7 @@ func (eb *EventBus) Off(eventType EventType) {
 	delete(eb.handlers, eventType)
 }
 
-// Emit publishes an event to all registered handlers
+// Emit publishes an event to all registered handlers and subscriptions
 // Returns error if any handler fails (in sync mode)
 // Automatically generates an ID for the event if not provided (from source)
 func (eb *EventBus) Emit(ctx context.Context, event Event) error {
@@ -126,6 +286,13 @@ func (eb *EventBus) Emit(ctx context.Context, event Event) error {
 		event.ID = uuid.New().String()
 	}
 
+	event, ok := eb.applyFilters(ctx, event)
+	if !ok {
+		return nil
+	}
+
+	eb.publish(ctx, event)
+
 	eb.mu.RLock()
 	handlers, exists := eb.handlers[event.Type]
 	eb.mu.RUnlock()
@@ -165,6 +332,13 @@ func (eb *EventBus) EmitAndWait(ctx context.Context, event Event) error {
 		event.ID = uuid.New().String()
 	}
 
+	event, ok := eb.applyFilters(ctx, event)
+	if !ok {
+		return nil
+	}
+
+	eb.publish(ctx, event)
+
 	eb.mu.RLock()
 	handlers, exists := eb.handlers[event.Type]
 	eb.mu.RUnlock()
@@ -142,6 +142,7 @@ type AgentCompleteData struct {
 	TotalDurationMs int64                  `json:"total_duration_ms"`
 	MessageID       string                 `json:"message_id,omitempty"` // Assistant message ID
 	RequestID       string                 `json:"request_id,omitempty"`
+	BudgetExceeded  bool                   `json:"budget_exceeded,omitempty"` // Stopped at max iterations
 	Extra           map[string]interface{} `json:"extra,omitempty"`
 }
 
@@ -188,6 +189,13 @@ type AgentFinalAnswerData struct {
 	IsFallback bool   `json:"is_fallback,omitempty"` // True when response is a fallback (no knowledge base match)
 }
 
+// AgentBudgetExceededData is emitted when the agent stops at its iteration limit instead of finishing
+// on its own; the final answer that follows is synthesized from the steps so far
+type AgentBudgetExceededData struct {
+	Iterations    int `json:"iterations"`
+	MaxIterations int `json:"max_iterations"`
+}
+
 // AgentReflectionData represents agent reflection data
 type AgentReflectionData struct {
 	ToolCallID string `json:"tool_call_id"` // Tool call ID for tracking
@@ -245,3 +245,116 @@ func BenchmarkEventBus_Emit(b *testing.B) {
 		_ = bus.Emit(ctx, event)
 	}
 }
+
+func TestEventBus_SubscribeTypes(t *testing.T) {
+	ctx := context.Background()
+	bus := NewEventBus()
+
+	ch, unsubscribe := bus.SubscribeTypes("session-1", EventSessionTitle, EventAgentFinalAnswer)
+	defer unsubscribe()
+
+	_ = bus.Emit(ctx, Event{Type: EventAgentThought, SessionID: "session-1"})
+	_ = bus.Emit(ctx, Event{Type: EventSessionTitle, SessionID: "session-2"})
+	_ = bus.Emit(ctx, Event{Type: EventSessionTitle, SessionID: "session-1"})
+	_ = bus.Emit(ctx, Event{Type: EventAgentFinalAnswer, SessionID: "session-1"})
+
+	for _, want := range []EventType{EventSessionTitle, EventAgentFinalAnswer} {
+		select {
+		case evt := <-ch:
+			if evt.Type != want || evt.SessionID != "session-1" {
+				t.Errorf("got %s for %s, want %s for session-1", evt.Type, evt.SessionID, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for %s", want)
+		}
+	}
+
+	select {
+	case evt := <-ch:
+		t.Errorf("unexpected event delivered: %s", evt.Type)
+	default:
+	}
+}
+
+func TestEventBus_Unsubscribe(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	bus := NewEventBus()
+
+	ch, unsubscribe := bus.Subscribe("session-1")
+	unsubscribe()
+
+	// Emitting more events than the buffer holds must not block after unsubscribing
+	for i := 0; i < subscriberBufferSize+1; i++ {
+		_ = bus.Emit(ctx, Event{Type: EventAgentThought, SessionID: "session-1"})
+	}
+	if ctx.Err() != nil {
+		t.Fatal("Emit blocked on an unsubscribed channel")
+	}
+	if len(ch) != 0 {
+		t.Errorf("expected no events after unsubscribe, got %d", len(ch))
+	}
+}
+
+func TestEventBus_SlowSubscriber(t *testing.T) {
+	ctx := context.Background()
+	bus := NewEventBus()
+
+	ch, unsubscribe := bus.Subscribe("session-1")
+	defer unsubscribe()
+
+	// Nobody reads the channel: the emitter must not block once the buffer is full
+	emitted := make(chan struct{})
+	go func() {
+		defer close(emitted)
+		for i := 0; i < subscriberBufferSize+2; i++ {
+			_ = bus.Emit(ctx, Event{Type: EventAgentThought, SessionID: "session-1"})
+		}
+	}()
+	select {
+	case <-emitted:
+	case <-time.After(time.Second):
+		t.Fatal("Emit blocked on a full subscriber channel")
+	}
+
+	received := 0
+	for range ch {
+		received++
+	}
+	if received != subscriberBufferSize {
+		t.Errorf("received %d events before the channel closed, want %d", received, subscriberBufferSize)
+	}
+}
+
+func TestEventBus_Subscribe(t *testing.T) {
+	ctx := context.Background()
+	bus := NewEventBus()
+
+	all, unsubscribeAll := bus.Subscribe("session-1")
+	defer unsubscribeAll()
+	filtered, unsubscribeFiltered := bus.SubscribeTypes("session-1", EventSessionTitle)
+	defer unsubscribeFiltered()
+
+	emitted := []EventType{EventAgentThought, EventSessionTitle, EventAgentFinalAnswer}
+	for _, eventType := range emitted {
+		_ = bus.Emit(ctx, Event{Type: eventType, SessionID: "session-1"})
+	}
+
+	for _, want := range emitted {
+		select {
+		case evt := <-all:
+			if evt.Type != want {
+				t.Errorf("full stream got %s, want %s", evt.Type, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for %s on the full stream", want)
+		}
+	}
+
+	if len(filtered) != 1 {
+		t.Fatalf("filtered channel holds %d events, want 1", len(filtered))
+	}
+	if evt := <-filtered; evt.Type != EventSessionTitle {
+		t.Errorf("filtered channel got %s, want %s", evt.Type, EventSessionTitle)
+	}
+}
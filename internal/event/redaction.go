@@ -0,0 +1,89 @@
+package event
+
+import (
+	"context"
+	"regexp"
+	"unicode/utf8"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// redactionFilter replaces regex matches (e.g. emails, phone numbers) in streamed answers.
+// Like bannedWordsFilter it is installed through an answerStreamFilter on the QA event bus, so every
+// consumer of the answer sees the redacted text.
+type redactionFilter struct {
+	rules  []redactionRule
+	window int // trailing runes held back until the next chunk
+}
+
+// redactionRule is a compiled types.RedactionRule
+type redactionRule struct {
+	re          *regexp.Regexp
+	replacement string
+}
+
+// newRedactionFilter returns nil when the config is not active or has no valid rule
+func newRedactionFilter(ctx context.Context, cfg *types.RedactionConfig) *redactionFilter {
+	if !cfg.IsActive() {
+		return nil
+	}
+	f := &redactionFilter{window: cfg.GetWindowSize()}
+	for _, rule := range cfg.Rules {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			logger.Warnf(ctx, "Skipping invalid redaction pattern %q: %v", rule.Pattern, err)
+			continue
+		}
+		f.rules = append(f.rules, redactionRule{re: re, replacement: rule.GetReplacement()})
+	}
+	if len(f.rules) == 0 {
+		return nil
+	}
+	return f
+}
+
+// transform implements answerTransformer
+func (f *redactionFilter) transform(_ context.Context, text string, final bool) (string, string, bool) {
+	cut := len(text)
+	if !final {
+		cut = f.safeCut(text)
+	}
+	return f.redact(text[:cut]), text[cut:], false
+}
+
+// transformText implements answerTransformer
+func (f *redactionFilter) transformText(text string) string {
+	return f.redact(text)
+}
+
+// redact applies the rules in order to a complete text
+func (f *redactionFilter) redact(text string) string {
+	for _, rule := range f.rules {
+		text = rule.re.ReplaceAllLiteralString(text, rule.replacement)
+	}
+	return text
+}
+
+// safeCut returns the byte offset up to which text can be emitted: the last window runes are held
+// back, and the cut moves back to the start of any match that runs across it so the match is
+// redacted as a whole once more text arrives.
+func (f *redactionFilter) safeCut(text string) int {
+	cut := len(text)
+	for n := 0; n < f.window && cut > 0; n++ {
+		_, size := utf8.DecodeLastRuneInString(text[:cut])
+		cut -= size
+	}
+	for moved := true; moved && cut > 0; {
+		moved = false
+		for _, rule := range f.rules {
+			for _, loc := range rule.re.FindAllStringIndex(text, -1) {
+				if loc[0] < cut && loc[1] > cut {
+					cut = loc[0]
+					moved = true
+				}
+			}
+		}
+	}
+	return cut
+}
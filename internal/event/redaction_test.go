@@ -0,0 +1,69 @@
+package event
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestRedactionFilter(t *testing.T) {
+	email := types.RedactionRule{Name: "email", Pattern: `[\w.]+@[\w.]+\w`}
+	phone := types.RedactionRule{Name: "phone", Pattern: `\d{11}`, Replacement: "$1"}
+	cfg := &types.RedactionConfig{Enabled: true, Rules: []types.RedactionRule{email, phone}, WindowSize: 16}
+	tests := []struct {
+		name   string
+		events []Event
+		want   string
+	}{
+		{"single chunk", normalAnswerEvents("mail alice@example.com now"), "mail [REDACTED] now"},
+		{
+			"split across chunks", normalAnswerEvents("mail alice@exa", "mple.c", "om now"),
+			"mail [REDACTED] now",
+		},
+		{
+			"literal replacement", normalAnswerEvents("call 1380013", "8000 to", "day"),
+			"call $1 today",
+		},
+		{
+			"match across the window", normalAnswerEvents("mail alice@example.com and", " more"),
+			"mail [REDACTED] and more",
+		},
+		{"tail flushed", normalAnswerEvents("abcdefgh", "ijkl", "m"), "abcdefghijklm"},
+		{
+			"split across chunks in agent mode", agentAnswerEvents("mail alice@exa", "mple.com"),
+			"mail [REDACTED]",
+		},
+		{"tail flushed in agent mode", agentAnswerEvents("abcdefgh", "ijkl"), "abcdefghijkl"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			answer, done := runAnswerFilter(t, newRedactionFilter(context.Background(), cfg), tt.events)
+			if answer != tt.want {
+				t.Errorf("answer = %q, want %q", answer, tt.want)
+			}
+			if !done {
+				t.Error("no done chunk delivered")
+			}
+		})
+	}
+}
+
+func TestNewRedactionFilterSkipsInvalidPatterns(t *testing.T) {
+	ctx := context.Background()
+	invalid := types.RedactionRule{Pattern: `(`}
+	if f := newRedactionFilter(ctx, &types.RedactionConfig{
+		Enabled: true, Rules: []types.RedactionRule{invalid},
+	}); f != nil {
+		t.Error("filter created without a valid rule")
+	}
+	f := newRedactionFilter(ctx, &types.RedactionConfig{
+		Enabled: true, Rules: []types.RedactionRule{invalid, {Pattern: `secret`}},
+	})
+	if f == nil {
+		t.Fatal("filter not created with a valid rule")
+	}
+	if got := f.transformText("a secret"); got != "a [REDACTED]" {
+		t.Errorf("transformText() = %q, want %q", got, "a [REDACTED]")
+	}
+}
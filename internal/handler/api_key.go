@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Tencent/WeKnora/internal/application/service"
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHandler defines the HTTP handler for scoped API keys
+type APIKeyHandler struct {
+	service interfaces.APIKeyService
+}
+
+// NewAPIKeyHandler creates a new scoped API key handler instance
+func NewAPIKeyHandler(service interfaces.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{service: service}
+}
+
+// CreateAPIKeyRequest defines the request body for creating a scoped API key
+type CreateAPIKeyRequest struct {
+	Name string `json:"name" binding:"required"`
+	// Knowledge bases the key may access
+	KnowledgeBaseIDs []string `json:"knowledge_base_ids" binding:"required"`
+	// viewer (default) or editor
+	Permission types.OrgMemberRole `json:"permission"`
+	// Uploads per minute and per day; 0 uses the configured defaults
+	UploadRateLimit  int `json:"upload_rate_limit"`
+	DailyUploadQuota int `json:"daily_upload_quota"`
+}
+
+// CreateAPIKey godoc
+// @Summary      创建限定知识库的 API Key
+// @Description  创建仅能访问指定知识库的 API Key，权限为 viewer 或 editor；明文密钥仅在创建时返回一次
+// @Tags         API Key
+// @Accept       json
+// @Produce      json
+// @Param        request  body      CreateAPIKeyRequest     true  "API Key 信息"
+// @Success      201      {object}  map[string]interface{}  "创建的 API Key，含明文密钥"
+// @Failure      400      {object}  errors.AppError         "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /api-keys [post]
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(errors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
+		return
+	}
+
+	key, err := h.service.CreateAPIKey(ctx, &types.APIKey{
+		Name:             req.Name,
+		KnowledgeBaseIDs: req.KnowledgeBaseIDs,
+		Permission:       req.Permission,
+		UploadRateLimit:  req.UploadRateLimit,
+		DailyUploadQuota: req.DailyUploadQuota,
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    key,
+	})
+}
+
+// ListAPIKeys godoc
+// @Summary      获取 API Key 列表
+// @Description  获取当前租户限定知识库的 API Key 及其当前上传用量，不包含明文密钥
+// @Tags         API Key
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "API Key 列表"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /api-keys [get]
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	keys, err := h.service.ListAPIKeys(ctx)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    keys,
+	})
+}
+
+// DeleteAPIKey godoc
+// @Summary      删除 API Key
+// @Description  吊销 API Key，使用该密钥的请求立即失效
+// @Tags         API Key
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "API Key ID"
+// @Success      200  {object}  map[string]interface{}  "删除成功"
+// @Failure      404  {object}  errors.AppError         "API Key 不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /api-keys/{id} [delete]
+func (h *APIKeyHandler) DeleteAPIKey(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id := secutils.SanitizeForLog(c.Param("id"))
+	if err := h.service.DeleteAPIKey(ctx, id); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "API key deleted successfully",
+	})
+}
+
+// handleError maps scoped API key service errors to HTTP errors
+func (h *APIKeyHandler) handleError(c *gin.Context, err error) {
+	logger.ErrorWithFields(c.Request.Context(), err, nil)
+	switch err {
+	case service.ErrAPIKeyNotFound:
+		c.Error(errors.NewNotFoundError(err.Error()))
+	case service.ErrAPIKeyNameRequired, service.ErrAPIKeyScopeRequired, service.ErrAPIKeyTooManyKnowledgeBases,
+		service.ErrAPIKeyInvalidPermission, service.ErrAPIKeyKnowledgeBaseNotOwned, service.ErrAPIKeyInvalidUploadLimits,
+		service.ErrInvalidTenantID:
+		c.Error(errors.NewBadRequestError(err.Error()))
+	default:
+		c.Error(errors.NewInternalServerError(err.Error()))
+	}
+}
+
+// checkAPIKeyScope rejects requests authenticated by a scoped API key that does not grant the
+// required permission on the knowledge base. Other requests are left to the usual access checks.
+func checkAPIKeyScope(c *gin.Context, kbID string, requiredPermission types.OrgMemberRole) error {
+	key, ok := types.APIKeyFromContext(c.Request.Context())
+	if !ok || key.Allows(kbID, requiredPermission) {
+		return nil
+	}
+	logger.Warnf(c.Request.Context(), "API key %s denied %s access to knowledge base %s",
+		key.ID, requiredPermission, kbID)
+	return errors.NewForbiddenError("API key is not allowed to access this knowledge base")
+}
+
+// consumeAPIKeyUpload counts an upload against the rate limit and daily quota of the scoped API key
+// authenticating the request. Uploads of users and tenant API keys are not limited.
+func consumeAPIKeyUpload(c *gin.Context, apiKeyService interfaces.APIKeyService) error {
+	key, ok := types.APIKeyFromContext(c.Request.Context())
+	if !ok || apiKeyService == nil {
+		return nil
+	}
+	err := apiKeyService.ConsumeUpload(c.Request.Context(), key)
+	switch err {
+	case nil:
+		return nil
+	case service.ErrAPIKeyUploadRateLimited:
+		return errors.NewTooManyRequestsError("API Key 上传过于频繁，请稍后重试").WithDetails(err.Error())
+	case service.ErrAPIKeyUploadQuotaExceeded:
+		return errors.NewTooManyRequestsError("API Key 今日上传次数已用完").WithDetails(err.Error())
+	default:
+		return errors.NewInternalServerError(err.Error())
+	}
+}
+
+// apiKeyPermission caps a permission on a knowledge base to that of the scoped API key authenticating the request
+func apiKeyPermission(c *gin.Context, permission types.OrgMemberRole) types.OrgMemberRole {
+	if key, ok := types.APIKeyFromContext(c.Request.Context()); ok {
+		return key.CapPermission(permission)
+	}
+	return permission
+}
@@ -21,6 +21,7 @@ import (
 type AuthHandler struct {
 	userService   interfaces.UserService
 	tenantService interfaces.TenantService
+	orgService    interfaces.OrganizationService
 	configInfo    *config.Config
 }
 
@@ -28,14 +29,18 @@ type AuthHandler struct {
 // Parameters:
 //   - userService: An implementation of the UserService interface for business logic
 //   - tenantService: An implementation of the TenantService interface for tenant management
+//   - orgService: An implementation of the OrganizationService interface for owned organization counts
+//     and claiming organization invites on signup
 //
 // Returns a pointer to the newly created AuthHandler
 func NewAuthHandler(configInfo *config.Config,
-	userService interfaces.UserService, tenantService interfaces.TenantService) *AuthHandler {
+	userService interfaces.UserService, tenantService interfaces.TenantService,
+	orgService interfaces.OrganizationService) *AuthHandler {
 	return &AuthHandler{
 		configInfo:    configInfo,
 		userService:   userService,
 		tenantService: tenantService,
+		orgService:    orgService,
 	}
 }
 
@@ -92,6 +97,12 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
+	// Tell the user about invites sent to this email before the account existed; joining takes
+	// an explicit accept since the email is not verified
+	if err := h.orgService.NotifyPendingInvites(ctx, user); err != nil {
+		logger.Warnf(ctx, "Failed to notify organization invites: %v", err)
+	}
+
 	// Return success response
 	response := &types.RegisterResponse{
 		Success: true,
@@ -285,6 +296,13 @@ func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
 	}
 	userInfo := user.ToUserInfo()
 	userInfo.CanAccessAllTenants = user.CanAccessAllTenants && h.configInfo.Tenant.EnableCrossTenantAccess
+	if owned, err := h.orgService.CountOwnedOrganizations(ctx, user.ID); err == nil {
+		maxOwned := h.orgService.GetMaxOwnedOrganizations()
+		userInfo.OwnedOrganizationCount = &owned
+		userInfo.MaxOwnedOrganizations = &maxOwned
+	} else {
+		logger.Warnf(ctx, "Failed to count organizations owned by user %s: %v", user.ID, err)
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data": gin.H{
@@ -294,6 +312,45 @@ func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
 	})
 }
 
+// ListNotifications godoc
+// @Summary      获取我的通知
+// @Description  分页获取当前用户的通知（如加入或权限升级申请的审核结果），按时间倒序
+// @Tags         认证
+// @Produce      json
+// @Param        page       query     int  false  "页码"
+// @Param        page_size  query     int  false  "每页数量"
+// @Success      200        {object}  map[string]interface{}  "通知列表"
+// @Failure      401        {object}  errors.AppError         "未授权"
+// @Security     Bearer
+// @Router       /me/notifications [get]
+func (h *AuthHandler) ListNotifications(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	userID := c.GetString(types.UserIDContextKey.String())
+	if userID == "" {
+		c.Error(errors.NewUnauthorizedError("Unauthorized"))
+		return
+	}
+
+	var page types.Pagination
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.Error(errors.NewBadRequestError("分页参数不合法").WithDetails(err.Error()))
+		return
+	}
+
+	result, err := h.userService.ListNotifications(ctx, userID, &page)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to list notifications: %v", err)
+		c.Error(errors.NewInternalServerError("Failed to list notifications"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
 // ChangePassword godoc
 // @Summary      修改密码
 // @Description  修改当前用户的登录密码
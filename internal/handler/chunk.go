@@ -51,6 +51,11 @@ func (h *ChunkHandler) effectiveCtxForKnowledge(c *gin.Context, knowledgeID stri
 			if !permission.HasPermission(requiredPermission) {
 				return nil, errors.NewForbiddenError("Insufficient permission for this operation")
 			}
+			// Partial shares only cover the documents they list
+			allowed, err := h.kbShareService.HasKnowledgePermission(ctx, knowledge.KnowledgeBaseID, knowledge.ID, userID.(string), requiredPermission)
+			if err != nil || !allowed {
+				return nil, errors.NewForbiddenError("Permission denied to access this knowledge")
+			}
 			return context.WithValue(ctx, types.TenantIDContextKey, knowledge.TenantID), nil
 		}
 	}
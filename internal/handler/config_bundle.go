@@ -0,0 +1,89 @@
+package handler
+
+import (
+	stderrors "errors"
+	"net/http"
+
+	"github.com/Tencent/WeKnora/internal/application/service"
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/gin-gonic/gin"
+)
+
+// ConfigBundleHandler handles exporting and importing tenant configuration for migration
+type ConfigBundleHandler struct {
+	service interfaces.ConfigBundleService
+}
+
+// NewConfigBundleHandler creates a new config bundle handler
+func NewConfigBundleHandler(service interfaces.ConfigBundleService) *ConfigBundleHandler {
+	return &ConfigBundleHandler{service: service}
+}
+
+// ExportConfig godoc
+// @Summary      导出租户配置
+// @Description  导出当前租户的知识库配置（不含文档内容）、自定义智能体及当前用户的组织成员关系，用于迁移到其他部署
+// @Tags         管理
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "配置包"
+// @Failure      403  {object}  errors.AppError         "需要系统管理员权限"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /admin/export-config [get]
+func (h *ConfigBundleHandler) ExportConfig(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	bundle, err := h.service.ExportConfig(ctx)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    bundle,
+	})
+}
+
+// ImportConfig godoc
+// @Summary      导入租户配置
+// @Description  将导出的配置包导入当前租户：创建知识库和智能体，并按 ID 或名称重新匹配模型、MCP 服务等引用，无法匹配的引用会在结果中列出
+// @Tags         管理
+// @Accept       json
+// @Produce      json
+// @Param        request  body      types.TenantConfigBundle  true  "配置包"
+// @Success      200      {object}  map[string]interface{}    "导入结果"
+// @Failure      400      {object}  errors.AppError           "请求参数错误"
+// @Failure      403      {object}  errors.AppError           "需要系统管理员权限"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /admin/import-config [post]
+func (h *ConfigBundleHandler) ImportConfig(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var bundle types.TenantConfigBundle
+	if err := c.ShouldBindJSON(&bundle); err != nil {
+		logger.Error(ctx, "Failed to parse config bundle", err)
+		c.Error(errors.NewBadRequestError("Invalid config bundle").WithDetails(err.Error()))
+		return
+	}
+
+	result, err := h.service.ImportConfig(ctx, &bundle)
+	if err != nil {
+		if stderrors.Is(err, service.ErrUnsupportedBundleVersion) {
+			c.Error(errors.NewBadRequestError(err.Error()))
+			return
+		}
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
@@ -42,6 +42,18 @@ type UpdateAgentRequest struct {
 	Config      types.CustomAgentConfig `json:"config"`
 }
 
+// EvaluateAgentRequest defines the request body for evaluating an agent
+type EvaluateAgentRequest struct {
+	Questions []string `json:"questions" binding:"required"`
+}
+
+// CompareAgentsRequest defines the request body for comparing two agents
+type CompareAgentsRequest struct {
+	AgentIDA string `json:"agent_id_a" binding:"required"`
+	AgentIDB string `json:"agent_id_b" binding:"required"`
+	Query    string `json:"query" binding:"required"`
+}
+
 // CreateAgent godoc
 // @Summary      创建智能体
 // @Description  创建新的自定义智能体
@@ -82,7 +94,12 @@ func (h *CustomAgentHandler) CreateAgent(c *gin.Context) {
 	createdAgent, err := h.service.CreateAgent(ctx, agent)
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, nil)
-		if err == service.ErrAgentNameRequired {
+		switch err {
+		case service.ErrAgentNameRequired, service.ErrTooManyStopSequences, service.ErrEmptyStopSequence,
+			service.ErrInvalidResponseFormat, service.ErrInvalidResponseSchema,
+			service.ErrInvalidContextConfig, service.ErrInvalidCompression,
+			service.ErrTooManyFewShots, service.ErrFewShotsTooLong, service.ErrEmptyFewShotExample,
+			service.ErrNoMatchPrefixTooLong:
 			c.Error(errors.NewBadRequestError(err.Error()))
 			return
 		}
@@ -232,7 +249,11 @@ func (h *CustomAgentHandler) UpdateAgent(c *gin.Context) {
 			c.Error(errors.NewNotFoundError("Agent not found"))
 		case service.ErrCannotModifyBuiltin:
 			c.Error(errors.NewForbiddenError("Cannot modify built-in agent"))
-		case service.ErrAgentNameRequired:
+		case service.ErrAgentNameRequired, service.ErrTooManyStopSequences, service.ErrEmptyStopSequence,
+			service.ErrInvalidResponseFormat, service.ErrInvalidResponseSchema,
+			service.ErrInvalidContextConfig, service.ErrInvalidCompression,
+			service.ErrTooManyFewShots, service.ErrFewShotsTooLong, service.ErrEmptyFewShotExample,
+			service.ErrNoMatchPrefixTooLong:
 			c.Error(errors.NewBadRequestError(err.Error()))
 		default:
 			c.Error(errors.NewInternalServerError(err.Error()))
@@ -351,6 +372,258 @@ func (h *CustomAgentHandler) CopyAgent(c *gin.Context) {
 	})
 }
 
+// PreviewResolvedKBs godoc
+// @Summary      预览智能体知识库范围
+// @Description  按智能体当前的知识库选择模式，返回未@提及知识库时会检索的知识库列表（"all" 模式实时读取当前知识库）
+// @Tags         智能体
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "智能体ID"
+// @Success      200  {object}  map[string]interface{}  "知识库列表"
+// @Failure      400  {object}  errors.AppError         "请求参数错误"
+// @Failure      404  {object}  errors.AppError         "智能体不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /agents/{id}/resolved-kbs [get]
+func (h *CustomAgentHandler) PreviewResolvedKBs(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id := secutils.SanitizeForLog(c.Param("id"))
+	if id == "" {
+		logger.Error(ctx, "Agent ID is empty")
+		c.Error(errors.NewBadRequestError("Agent ID cannot be empty"))
+		return
+	}
+
+	resolved, err := h.service.PreviewResolvedKBs(ctx, id)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"agent_id": id,
+		})
+		switch err {
+		case service.ErrAgentNotFound:
+			c.Error(errors.NewNotFoundError("Agent not found"))
+		default:
+			c.Error(errors.NewInternalServerError(err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    resolved,
+	})
+}
+
+// CloneAgentRequest defines the request body for cloning an agent
+type CloneAgentRequest struct {
+	Name string `json:"name"`
+}
+
+// CloneAgent godoc
+// @Summary      克隆智能体
+// @Description  基于自己的或共享给自己的智能体，在当前租户下创建一个新智能体（不复制共享关系），并提示当前租户无法访问的知识库和模型
+// @Tags         智能体
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string             true   "智能体ID"
+// @Param        request  body      CloneAgentRequest  false  "新智能体名称"
+// @Success      201      {object}  map[string]interface{}  "克隆成功"
+// @Failure      400      {object}  errors.AppError         "请求参数错误"
+// @Failure      404      {object}  errors.AppError         "智能体不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /agents/{id}/clone [post]
+func (h *CustomAgentHandler) CloneAgent(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id := secutils.SanitizeForLog(c.Param("id"))
+	if id == "" {
+		logger.Error(ctx, "Agent ID is empty")
+		c.Error(errors.NewBadRequestError("Agent ID cannot be empty"))
+		return
+	}
+
+	var req CloneAgentRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			logger.Error(ctx, "Failed to parse request parameters", err)
+			c.Error(errors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
+			return
+		}
+	}
+
+	logger.Infof(ctx, "Cloning custom agent, ID: %s", id)
+
+	result, err := h.service.CloneAgent(ctx, id, req.Name)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"agent_id": id,
+		})
+		switch err {
+		case service.ErrAgentNotFound:
+			c.Error(errors.NewNotFoundError("Agent not found"))
+		default:
+			c.Error(errors.NewInternalServerError(err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// ResetAgentConfig godoc
+// @Summary      重置智能体配置
+// @Description  将智能体配置恢复为默认值，保留名称、头像、运行模式和知识库选择
+// @Tags         智能体
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "智能体ID"
+// @Success      200  {object}  map[string]interface{}  "重置成功"
+// @Failure      400  {object}  errors.AppError         "请求参数错误"
+// @Failure      404  {object}  errors.AppError         "智能体不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /agents/{id}/reset-config [post]
+func (h *CustomAgentHandler) ResetAgentConfig(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id := secutils.SanitizeForLog(c.Param("id"))
+	if id == "" {
+		logger.Error(ctx, "Agent ID is empty")
+		c.Error(errors.NewBadRequestError("Agent ID cannot be empty"))
+		return
+	}
+
+	logger.Infof(ctx, "Resetting custom agent config, ID: %s", id)
+
+	agent, err := h.service.ResetAgentConfig(ctx, id)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"agent_id": id,
+		})
+		switch err {
+		case service.ErrAgentNotFound:
+			c.Error(errors.NewNotFoundError("Agent not found"))
+		default:
+			c.Error(errors.NewInternalServerError(err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    agent,
+	})
+}
+
+// EvaluateAgent godoc
+// @Summary      评测智能体
+// @Description  使用一组问题同步运行智能体，返回每个问题的回答和检索到的引用，评测使用的临时会话在结束后删除
+// @Tags         智能体
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                true  "智能体ID"
+// @Param        request  body      EvaluateAgentRequest  true  "评测问题"
+// @Success      200      {object}  map[string]interface{}  "评测结果"
+// @Failure      400      {object}  errors.AppError         "请求参数错误"
+// @Failure      404      {object}  errors.AppError         "智能体不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /agents/{id}/evaluate [post]
+func (h *CustomAgentHandler) EvaluateAgent(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	logger.Info(ctx, "Start evaluating custom agent")
+
+	id := secutils.SanitizeForLog(c.Param("id"))
+	if id == "" {
+		logger.Error(ctx, "Agent ID is empty")
+		c.Error(errors.NewBadRequestError("Agent ID cannot be empty"))
+		return
+	}
+
+	var req EvaluateAgentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(errors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
+		return
+	}
+
+	results, err := h.service.EvaluateAgent(ctx, id, req.Questions)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"agent_id": id,
+		})
+		switch err {
+		case service.ErrAgentNotFound:
+			c.Error(errors.NewNotFoundError("Agent not found"))
+		case service.ErrNoEvaluationQuestions, service.ErrTooManyEvalQuestions, service.ErrEmptyEvalQuestion:
+			c.Error(errors.NewBadRequestError(err.Error()))
+		default:
+			c.Error(errors.NewInternalServerError(err.Error()))
+		}
+		return
+	}
+
+	logger.Infof(ctx, "Custom agent evaluated successfully, ID: %s, questions: %d", id, len(results))
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    results,
+	})
+}
+
+// CompareAgents godoc
+// @Summary      对比两个智能体
+// @Description  使用同一个问题分别运行两个智能体，并排返回回答、引用和耗时，使用的临时会话在结束后删除
+// @Tags         智能体
+// @Accept       json
+// @Produce      json
+// @Param        request  body      CompareAgentsRequest  true  "对比请求"
+// @Success      200      {object}  map[string]interface{}  "对比结果"
+// @Failure      400      {object}  errors.AppError         "请求参数错误"
+// @Failure      404      {object}  errors.AppError         "智能体不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /agents/compare [post]
+func (h *CustomAgentHandler) CompareAgents(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	logger.Info(ctx, "Start comparing custom agents")
+
+	var req CompareAgentsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(errors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
+		return
+	}
+
+	result, err := h.service.CompareAgents(ctx, req.AgentIDA, req.AgentIDB, req.Query)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"agent_id_a": secutils.SanitizeForLog(req.AgentIDA),
+			"agent_id_b": secutils.SanitizeForLog(req.AgentIDB),
+		})
+		switch err {
+		case service.ErrAgentNotFound:
+			c.Error(errors.NewNotFoundError("Agent not found"))
+		case service.ErrEmptyEvalQuestion:
+			c.Error(errors.NewBadRequestError(err.Error()))
+		default:
+			c.Error(errors.NewInternalServerError(err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
 // GetPlaceholders godoc
 // @Summary      获取占位符定义
 // @Description  获取所有可用的提示词占位符定义，按字段类型分组
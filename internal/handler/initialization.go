@@ -239,7 +239,7 @@ func (h *InitializationHandler) UpdateKBConfig(c *gin.Context) {
 			kbIdStr, &types.Pagination{
 				Page:     1,
 				PageSize: 1,
-			}, "", "", "")
+			}, "", "", "", nil)
 		if err == nil && knowledgeList != nil && knowledgeList.Total > 0 {
 			logger.Error(ctx, "Cannot change embedding model when files exist")
 			c.Error(errors.NewBadRequestError("知识库中已有文件，无法修改Embedding模型"))
@@ -318,7 +318,7 @@ func (h *InitializationHandler) UpdateKBConfig(c *gin.Context) {
 	}
 	if oldProvider != provider {
 		knowledgeList, err := h.knowledgeService.ListPagedKnowledgeByKnowledgeBaseID(ctx,
-			kbIdStr, &types.Pagination{Page: 1, PageSize: 1}, "", "", "")
+			kbIdStr, &types.Pagination{Page: 1, PageSize: 1}, "", "", "", nil)
 		if err == nil && knowledgeList != nil && knowledgeList.Total > 0 {
 			logger.Error(ctx, "Cannot change storage engine when files exist")
 			c.Error(errors.NewBadRequestError("知识库中已有文件，无法切换存储引擎"))
@@ -1274,7 +1274,7 @@ func (h *InitializationHandler) GetCurrentConfigByKB(c *gin.Context) {
 		kbIdStr, &types.Pagination{
 			Page:     1,
 			PageSize: 1,
-		}, "", "", "")
+		}, "", "", "", nil)
 	hasFiles := err == nil && knowledgeList != nil && knowledgeList.Total > 0
 
 	// 构建配置响应
@@ -1560,8 +1560,7 @@ func (h *InitializationHandler) TestEmbeddingModel(c *gin.Context) {
 	}
 
 	// 执行一次最小化 embedding 调用
-	sample := "hello"
-	vec, err := emb.Embed(ctx, sample)
+	vec, err := embedding.Probe(ctx, emb)
 	if err != nil {
 		logger.Error(ctx, "Failed to create embedder", err)
 		c.JSON(http.StatusOK, gin.H{
@@ -1598,23 +1597,8 @@ func (h *InitializationHandler) checkRemoteModelConnection(ctx context.Context,
 		return false, fmt.Sprintf("创建聊天实例失败: %v", err)
 	}
 
-	// 构造测试消息
-	testMessages := []chat.Message{
-		{
-			Role:    "user",
-			Content: "test",
-		},
-	}
-
-	// 构造测试选项
-	testOptions := &chat.ChatOptions{
-		MaxTokens: 1,
-		Thinking:  &[]bool{false}[0], // for dashscope.aliyuncs qwen3-32b
-	}
-
 	// 使用聊天实例进行测试
-	_, err = chatInstance.Chat(ctx, testMessages, testOptions)
-	if err != nil {
+	if err := chat.Probe(ctx, chatInstance); err != nil {
 		// 根据错误类型返回不同的错误信息
 		if strings.Contains(err.Error(), "401") || strings.Contains(err.Error(), "unauthorized") {
 			return false, "认证失败，请检查API Key"
@@ -1651,14 +1635,8 @@ func (h *InitializationHandler) checkRerankModelConnection(ctx context.Context,
 		return false, fmt.Sprintf("创建Reranker失败: %v", err)
 	}
 
-	// 简化的测试数据
-	testQuery := "ping"
-	testDocuments := []string{
-		"pong",
-	}
-
 	// 使用Reranker进行测试
-	results, err := reranker.Rerank(ctx, testQuery, testDocuments)
+	results, err := rerank.Probe(ctx, reranker)
 	if err != nil {
 		return false, fmt.Sprintf("重排测试失败: %v", err)
 	}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -28,8 +29,10 @@ import (
 type KnowledgeHandler struct {
 	kgService         interfaces.KnowledgeService
 	kbService         interfaces.KnowledgeBaseService
+	chunkService      interfaces.ChunkService
 	kbShareService    interfaces.KBShareService
 	agentShareService interfaces.AgentShareService
+	apiKeyService     interfaces.APIKeyService
 	asynqClient       interfaces.TaskEnqueuer
 }
 
@@ -37,15 +40,19 @@ type KnowledgeHandler struct {
 func NewKnowledgeHandler(
 	kgService interfaces.KnowledgeService,
 	kbService interfaces.KnowledgeBaseService,
+	chunkService interfaces.ChunkService,
 	kbShareService interfaces.KBShareService,
 	agentShareService interfaces.AgentShareService,
+	apiKeyService interfaces.APIKeyService,
 	asynqClient interfaces.TaskEnqueuer,
 ) *KnowledgeHandler {
 	return &KnowledgeHandler{
 		kgService:         kgService,
 		kbService:         kbService,
+		chunkService:      chunkService,
 		kbShareService:    kbShareService,
 		agentShareService: agentShareService,
+		apiKeyService:     apiKeyService,
 		asynqClient:       asynqClient,
 	}
 }
@@ -71,13 +78,16 @@ func (h *KnowledgeHandler) validateKnowledgeBaseAccessWithKBID(c *gin.Context, k
 	if kbID == "" {
 		return nil, "", 0, "", errors.NewBadRequestError("Knowledge base ID cannot be empty")
 	}
+	if err := checkAPIKeyScope(c, kbID, types.OrgRoleViewer); err != nil {
+		return nil, kbID, 0, "", err
+	}
 	kb, err := h.kbService.GetKnowledgeBaseByID(ctx, kbID)
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, nil)
 		return nil, kbID, 0, "", errors.NewInternalServerError(err.Error())
 	}
 	if kb.TenantID == tenantID {
-		return kb, kbID, tenantID, types.OrgRoleAdmin, nil
+		return kb, kbID, tenantID, apiKeyPermission(c, types.OrgRoleAdmin), nil
 	}
 	if userExists && h.kbShareService != nil {
 		permission, isShared, permErr := h.kbShareService.CheckUserKBPermission(ctx, kbID, userID.(string))
@@ -101,6 +111,33 @@ func (h *KnowledgeHandler) validateKnowledgeBaseAccessWithKBID(c *gin.Context, k
 	return nil, kbID, 0, "", errors.NewForbiddenError("Permission denied to access this knowledge base")
 }
 
+// sharedKnowledgeScope returns the documents the caller may access in kb when it reaches them through partial shares.
+// restricted is false for the owner tenant and for members of a whole-KB share.
+func sharedKnowledgeScope(c *gin.Context, kbShareService interfaces.KBShareService, kb *types.KnowledgeBase) ([]string, bool, error) {
+	if kbShareService == nil || kb == nil || kb.TenantID == c.GetUint64(types.TenantIDContextKey.String()) {
+		return nil, false, nil
+	}
+	userID := c.GetString(types.UserIDContextKey.String())
+	if userID == "" {
+		return nil, false, nil
+	}
+	return kbShareService.GetSharedKnowledgeIDs(c.Request.Context(), kb.ID, userID)
+}
+
+// restrictKnowledgeIDs narrows the requested knowledge IDs to the allowed ones; an empty request means all allowed IDs.
+func restrictKnowledgeIDs(requested []string, allowed []string) []string {
+	if len(requested) == 0 {
+		return allowed
+	}
+	restricted := make([]string, 0, len(requested))
+	for _, id := range requested {
+		if slices.Contains(allowed, id) {
+			restricted = append(restricted, id)
+		}
+	}
+	return restricted
+}
+
 // resolveKnowledgeAndValidateKBAccess resolves knowledge by ID and validates KB access (owner or shared with required permission).
 // Returns the knowledge, context with effectiveTenantID set for downstream service calls, and error.
 func (h *KnowledgeHandler) resolveKnowledgeAndValidateKBAccess(c *gin.Context, knowledgeID string, requiredPermission types.OrgMemberRole) (*types.Knowledge, context.Context, error) {
@@ -115,16 +152,19 @@ func (h *KnowledgeHandler) resolveKnowledgeAndValidateKBAccess(c *gin.Context, k
 	if err != nil {
 		return nil, ctx, errors.NewNotFoundError("Knowledge not found")
 	}
+	if err := checkAPIKeyScope(c, knowledge.KnowledgeBaseID, requiredPermission); err != nil {
+		return nil, ctx, err
+	}
 
 	// Owner: knowledge belongs to caller's tenant
 	if knowledge.TenantID == tenantID {
 		return knowledge, context.WithValue(ctx, types.TenantIDContextKey, tenantID), nil
 	}
 
-	// Shared KB: check organization permission
+	// Shared KB: check organization permission (partial shares only cover their documents)
 	if userExists && h.kbShareService != nil {
-		permission, isShared, permErr := h.kbShareService.CheckUserKBPermission(ctx, knowledge.KnowledgeBaseID, userID.(string))
-		if permErr == nil && isShared && permission.HasPermission(requiredPermission) {
+		allowed, permErr := h.kbShareService.HasKnowledgePermission(ctx, knowledge.KnowledgeBaseID, knowledge.ID, userID.(string), requiredPermission)
+		if permErr == nil && allowed {
 			effectiveTenantID := knowledge.TenantID
 			return knowledge, context.WithValue(ctx, types.TenantIDContextKey, effectiveTenantID), nil
 		}
@@ -178,6 +218,8 @@ func (h *KnowledgeHandler) handleDuplicateKnowledgeError(c *gin.Context,
 			"message": dupErr.Error(),
 			"data":    knowledge, // knowledge contains the existing document
 			"code":    fmt.Sprintf("duplicate_%s", duplicateType),
+			// The existing document may live in another knowledge base when the scope is "tenant"
+			"knowledge_base_id": dupErr.KnowledgeBaseID(),
 		})
 		return true
 	}
@@ -195,9 +237,12 @@ func (h *KnowledgeHandler) handleDuplicateKnowledgeError(c *gin.Context,
 // @Param        fileName          formData  string  false  "自定义文件名"
 // @Param        metadata          formData  string  false  "元数据JSON"
 // @Param        enable_multimodel formData  bool    false  "启用多模态处理"
+// @Param        ocr_enabled       formData  bool    false  "是否启用 OCR（覆盖知识库配置）"
+// @Param        ocr_languages     formData  string  false  "OCR 语言，逗号分隔，如 ja,en（覆盖知识库配置）"
 // @Success      200               {object}  map[string]interface{}  "创建的知识"
 // @Failure      400               {object}  errors.AppError         "请求参数错误"
 // @Failure      409               {object}  map[string]interface{}  "文件重复"
+// @Failure      429               {object}  errors.AppError         "API Key 上传频率或每日配额超限"
 // @Security     Bearer
 // @Security     ApiKeyAuth
 // @Router       /knowledge-bases/{id}/knowledge/file [post]
@@ -272,6 +317,13 @@ func (h *KnowledgeHandler) CreateKnowledgeFromFile(c *gin.Context) {
 		enableMultimodel = &parseBool
 	}
 
+	ocr, err := parseOCRForm(c)
+	if err != nil {
+		logger.Error(ctx, "Failed to parse OCR settings", err)
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+
 	// 获取分类ID（如果提供），用于知识分类管理
 	tagID := c.PostForm("tag_id")
 	// 过滤特殊值，空字符串或 "__untagged__" 表示未分类
@@ -279,8 +331,14 @@ func (h *KnowledgeHandler) CreateKnowledgeFromFile(c *gin.Context) {
 		tagID = ""
 	}
 
+	// Count the upload only once the request is valid
+	if err := consumeAPIKeyUpload(c, h.apiKeyService); err != nil {
+		c.Error(err)
+		return
+	}
+
 	// Create knowledge entry from the file
-	knowledge, err := h.kgService.CreateKnowledgeFromFile(ctx, kbID, file, metadata, enableMultimodel, customFileName, tagID)
+	knowledge, err := h.kgService.CreateKnowledgeFromFile(ctx, kbID, file, metadata, enableMultimodel, customFileName, tagID, ocr)
 	// Check for duplicate knowledge error
 	if err != nil {
 		if h.handleDuplicateKnowledgeError(c, err, knowledge, "file") {
@@ -307,6 +365,91 @@ func (h *KnowledgeHandler) CreateKnowledgeFromFile(c *gin.Context) {
 	})
 }
 
+// parseOCRForm reads the per-upload OCR overrides from the ocr_enabled and ocr_languages
+// (comma-separated) form fields. It returns nil when neither is set.
+func parseOCRForm(c *gin.Context) (*types.OCRSettings, error) {
+	enabledForm := c.PostForm("ocr_enabled")
+	languagesForm := c.PostForm("ocr_languages")
+	if enabledForm == "" && languagesForm == "" {
+		return nil, nil
+	}
+	ocr := &types.OCRSettings{}
+	if enabledForm != "" {
+		enabled, err := strconv.ParseBool(enabledForm)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ocr_enabled format: %w", err)
+		}
+		ocr.Enabled = &enabled
+	}
+	if languagesForm != "" {
+		languages, err := types.NormalizeOCRLanguages(strings.Split(languagesForm, ","))
+		if err != nil {
+			return nil, err
+		}
+		ocr.Languages = languages
+	}
+	return ocr, nil
+}
+
+// maxUploadCheckHeadSize bounds the file head accepted by ValidateFileUpload; sniffing needs only 512 bytes
+const maxUploadCheckHeadSize = 64 * 1024
+
+// ValidateFileUpload godoc
+// @Summary      上传前校验文件
+// @Description  根据文件名、大小、MIME 类型（或文件开头的部分字节）校验文件能否上传到知识库，规则与从文件创建知识一致，不会真正上传文件
+// @Tags         知识管理
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                        true  "知识库ID"
+// @Param        request  body      types.FileUploadCheckRequest  true  "文件信息"
+// @Success      200      {object}  map[string]interface{}        "校验结果"
+// @Failure      400      {object}  errors.AppError               "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/knowledge/file/validate [post]
+func (h *KnowledgeHandler) ValidateFileUpload(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	_, kbID, effectiveTenantID, permission, err := h.validateKnowledgeBaseAccess(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, effectiveTenantID)
+
+	if permission != types.OrgRoleAdmin && permission != types.OrgRoleEditor {
+		c.Error(errors.NewForbiddenError("No permission to create knowledge"))
+		return
+	}
+
+	var req types.FileUploadCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to parse file upload check request", err)
+		c.Error(errors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
+		return
+	}
+	if len(req.Head) > maxUploadCheckHeadSize {
+		c.Error(errors.NewBadRequestError(fmt.Sprintf("head must not exceed %d bytes", maxUploadCheckHeadSize)))
+		return
+	}
+
+	result, err := h.kgService.ValidateFileUpload(ctx, kbID, &req)
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			c.Error(appErr)
+			return
+		}
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
 // CreateKnowledgeFromURL godoc
 // @Summary      从URL创建知识
 // @Description  从指定URL抓取内容并创建知识条目。当提供 file_name/file_type 或 URL 路径含已知文件扩展名时，自动切换为文件下载模式
@@ -318,6 +461,7 @@ func (h *KnowledgeHandler) CreateKnowledgeFromFile(c *gin.Context) {
 // @Success      201      {object}  map[string]interface{}  "创建的知识"
 // @Failure      400      {object}  errors.AppError         "请求参数错误"
 // @Failure      409      {object}  map[string]interface{}  "URL重复"
+// @Failure      429      {object}  errors.AppError         "API Key 上传频率或每日配额超限"
 // @Security     Bearer
 // @Security     ApiKeyAuth
 // @Router       /knowledge-bases/{id}/knowledge/url [post]
@@ -365,6 +509,12 @@ func (h *KnowledgeHandler) CreateKnowledgeFromURL(c *gin.Context) {
 		secutils.SanitizeForLog(req.URL),
 	)
 
+	// Count the upload only once the request is valid
+	if err := consumeAPIKeyUpload(c, h.apiKeyService); err != nil {
+		c.Error(err)
+		return
+	}
+
 	// Create knowledge entry from the URL
 	knowledge, err := h.kgService.CreateKnowledgeFromURL(ctx, kbID, req.URL, req.FileName, req.FileType, req.EnableMultimodel, req.Title, req.TagID)
 	// Check for duplicate knowledge error
@@ -492,6 +642,66 @@ func (h *KnowledgeHandler) GetKnowledge(c *gin.Context) {
 	})
 }
 
+// ListAllKnowledge godoc
+// @Summary      获取租户全部知识列表
+// @Description  跨当前租户的所有文档型知识库获取知识列表，支持分页和筛选，每项包含所属知识库名称
+// @Tags         知识管理
+// @Accept       json
+// @Produce      json
+// @Param        page               query     int     false  "页码"
+// @Param        page_size          query     int     false  "每页数量"
+// @Param        knowledge_base_id  query     string  false  "知识库ID筛选"
+// @Param        tag_id             query     string  false  "标签ID筛选"
+// @Param        keyword            query     string  false  "按文件名或标题搜索"
+// @Param        file_type          query     string  false  "文件类型筛选，manual/url 表示手工录入/网页"
+// @Success      200                {object}  map[string]interface{}  "知识列表"
+// @Failure      400                {object}  errors.AppError         "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge [get]
+func (h *KnowledgeHandler) ListAllKnowledge(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var pagination types.Pagination
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		logger.Error(ctx, "Failed to parse pagination parameters", err)
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+	var filter types.KnowledgeListFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		logger.Error(ctx, "Failed to parse filter parameters", err)
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	logger.Infof(
+		ctx,
+		"Retrieving knowledge list of tenant, knowledge base ID: %s, tag_id: %s, keyword: %s, file_type: %s, page: %d, page size: %d",
+		secutils.SanitizeForLog(filter.KnowledgeBaseID),
+		secutils.SanitizeForLog(filter.TagID),
+		secutils.SanitizeForLog(filter.Keyword),
+		secutils.SanitizeForLog(filter.FileType),
+		pagination.Page,
+		pagination.PageSize,
+	)
+
+	result, err := h.kgService.ListAllKnowledge(ctx, &pagination, &filter)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"data":      result.Data,
+		"total":     result.Total,
+		"page":      result.Page,
+		"page_size": result.PageSize,
+	})
+}
+
 // ListKnowledge godoc
 // @Summary      获取知识列表
 // @Description  获取知识库下的知识列表，支持分页和筛选
@@ -515,12 +725,20 @@ func (h *KnowledgeHandler) ListKnowledge(c *gin.Context) {
 	logger.Info(ctx, "Start retrieving knowledge list")
 
 	// Validate access to the knowledge base (read access - any permission level)
-	_, kbID, effectiveTenantID, _, err := h.validateKnowledgeBaseAccess(c)
+	kb, kbID, effectiveTenantID, _, err := h.validateKnowledgeBaseAccess(c)
 	if err != nil {
 		c.Error(err)
 		return
 	}
 
+	// Members of a partial share only see the shared documents (knowledgeIDs stays nil otherwise)
+	knowledgeIDs, _, err := sharedKnowledgeScope(c, h.kbShareService, kb)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+
 	// Update context with effective tenant ID for shared KB access
 	ctx = context.WithValue(ctx, types.TenantIDContextKey, effectiveTenantID)
 
@@ -549,7 +767,7 @@ func (h *KnowledgeHandler) ListKnowledge(c *gin.Context) {
 	)
 
 	// Retrieve paginated knowledge entries
-	result, err := h.kgService.ListPagedKnowledgeByKnowledgeBaseID(ctx, kbID, &pagination, tagID, keyword, fileType)
+	result, err := h.kgService.ListPagedKnowledgeByKnowledgeBaseID(ctx, kbID, &pagination, tagID, keyword, fileType, knowledgeIDs)
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, nil)
 		c.Error(errors.NewInternalServerError(err.Error()))
@@ -571,6 +789,67 @@ func (h *KnowledgeHandler) ListKnowledge(c *gin.Context) {
 	})
 }
 
+// GetKnowledgeByFilename godoc
+// @Summary      按文件名获取知识
+// @Description  按上传时的原始文件名获取知识库下的知识。同名文件可能有多个，按创建时间倒序全部返回，第一个为最新上传的
+// @Tags         知识管理
+// @Accept       json
+// @Produce      json
+// @Param        id    path      string  true  "知识库ID"
+// @Param        name  query     string  true  "原始文件名（完全匹配）"
+// @Success      200   {object}  map[string]interface{}  "匹配的知识列表"
+// @Failure      400   {object}  errors.AppError         "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/knowledge/by-filename [get]
+func (h *KnowledgeHandler) GetKnowledgeByFilename(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	name := c.Query("name")
+	if strings.TrimSpace(name) == "" {
+		c.Error(errors.NewBadRequestError("name is required"))
+		return
+	}
+
+	// Validate access to the knowledge base (read access - any permission level)
+	kb, kbID, effectiveTenantID, _, err := h.validateKnowledgeBaseAccess(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	// Members of a partial share only see the shared documents
+	knowledgeIDs, partial, err := sharedKnowledgeScope(c, h.kbShareService, kb)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+
+	// Update context with effective tenant ID for shared KB access
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, effectiveTenantID)
+
+	knowledges, err := h.kgService.GetKnowledgeByFilename(ctx, kbID, name)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+	if partial {
+		knowledges = slices.DeleteFunc(knowledges, func(k *types.Knowledge) bool {
+			return !slices.Contains(knowledgeIDs, k.ID)
+		})
+	}
+
+	logger.Infof(ctx, "Found %d knowledge with file name %s in knowledge base %s",
+		len(knowledges), secutils.SanitizeForLog(name), secutils.SanitizeForLog(kbID))
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    knowledges,
+		"total":   len(knowledges),
+	})
+}
+
 // DeleteKnowledge godoc
 // @Summary      删除知识
 // @Description  根据ID删除知识条目
@@ -845,7 +1124,7 @@ func (h *KnowledgeHandler) GetKnowledgeBatch(c *gin.Context) {
 
 	// Optional kb_id: validate KB access and use effective tenant for shared KB
 	if kbID := secutils.SanitizeForLog(req.KBID); kbID != "" {
-		_, _, effID, _, err := h.validateKnowledgeBaseAccessWithKBID(c, kbID)
+		kb, _, effID, _, err := h.validateKnowledgeBaseAccessWithKBID(c, kbID)
 		if err != nil {
 			c.Error(err)
 			return
@@ -853,10 +1132,22 @@ func (h *KnowledgeHandler) GetKnowledgeBatch(c *gin.Context) {
 		effectiveTenantID = effID
 		ctx = context.WithValue(ctx, types.TenantIDContextKey, effectiveTenantID)
 
+		// Members of a partial share only get the shared documents
+		ids := req.IDs
+		allowed, restricted, err := sharedKnowledgeScope(c, h.kbShareService, kb)
+		if err != nil {
+			logger.ErrorWithFields(ctx, err, nil)
+			c.Error(errors.NewInternalServerError(err.Error()))
+			return
+		}
+		if restricted {
+			ids = restrictKnowledgeIDs(req.IDs, allowed)
+		}
+
 		logger.Infof(ctx, "Batch retrieving knowledge with kb_id, effective tenant ID: %d, IDs count: %d",
-			effectiveTenantID, len(req.IDs))
+			effectiveTenantID, len(ids))
 
-		knowledges, err = h.kgService.GetKnowledgeBatch(ctx, effectiveTenantID, req.IDs)
+		knowledges, err = h.kgService.GetKnowledgeBatch(ctx, effectiveTenantID, ids)
 	} else {
 		// No kb_id: use GetKnowledgeBatchWithSharedAccess (or effectiveTenantID may already be set by agent_id for shared agent)
 		logger.Infof(ctx, "Batch retrieving knowledge without kb_id, effective tenant ID: %d, IDs count: %d",
@@ -918,6 +1209,10 @@ func (h *KnowledgeHandler) UpdateKnowledge(c *gin.Context) {
 	knowledge.ID = id
 
 	if err := h.kgService.UpdateKnowledge(effCtx, &knowledge); err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			c.Error(appErr)
+			return
+		}
 		logger.ErrorWithFields(ctx, err, nil)
 		c.Error(errors.NewInternalServerError(err.Error()))
 		return
@@ -1040,6 +1335,57 @@ func (h *KnowledgeHandler) ReparseKnowledge(c *gin.Context) {
 	})
 }
 
+// RetryKnowledgeProcessing godoc
+// @Summary      重试处理失败的知识
+// @Description  重新处理解析或向量化失败的知识，复用已存储的原始文件，无需删除后重新上传
+// @Tags         知识管理
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "知识ID"
+// @Success      200  {object}  map[string]interface{}  "重试任务已提交"
+// @Failure      400  {object}  errors.AppError         "知识不处于失败状态"
+// @Failure      403  {object}  errors.AppError         "权限不足"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge/{id}/retry [post]
+func (h *KnowledgeHandler) RetryKnowledgeProcessing(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id := secutils.SanitizeForLog(c.Param("id"))
+	if id == "" {
+		logger.Error(ctx, "Knowledge ID is empty")
+		c.Error(errors.NewBadRequestError("Knowledge ID cannot be empty"))
+		return
+	}
+
+	// Retrying rewrites the knowledge content, so editor permission is required
+	_, effCtx, err := h.resolveKnowledgeAndValidateKBAccess(c, id, types.OrgRoleEditor)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	knowledge, err := h.kgService.RetryProcessing(effCtx, id)
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			c.Error(appErr)
+			return
+		}
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"knowledge_id": id,
+		})
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+
+	logger.Infof(ctx, "Knowledge retry task submitted successfully, knowledge ID: %s", id)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Knowledge retry task submitted",
+		"data":    knowledge,
+	})
+}
+
 type knowledgeTagBatchRequest struct {
 	Updates map[string]*string `json:"updates" binding:"required,min=1"`
 	KBID    string             `json:"kb_id"` // Optional: scope to this KB (validates editor access and uses effective tenant for shared KB)
@@ -1174,6 +1520,189 @@ func (h *KnowledgeHandler) UpdateImageInfo(c *gin.Context) {
 	})
 }
 
+// ListKnowledgeChunks godoc
+// @Summary      获取知识分块详情
+// @Description  分页获取指定知识被切分和索引的全部分块（含文本、图片、表格、FAQ 分块），用于排查检索问题
+// @Tags         知识管理
+// @Accept       json
+// @Produce      json
+// @Param        id         path      string  true   "知识ID"
+// @Param        page       query     int     false  "页码"  default(1)
+// @Param        page_size  query     int     false  "每页数量"  default(20)
+// @Success      200        {object}  map[string]interface{}  "分块列表"
+// @Failure      400        {object}  errors.AppError         "请求参数错误"
+// @Failure      404        {object}  errors.AppError         "知识不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge/{id}/chunks [get]
+func (h *KnowledgeHandler) ListKnowledgeChunks(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id := secutils.SanitizeForLog(c.Param("id"))
+	if id == "" {
+		logger.Error(ctx, "Knowledge ID is empty")
+		c.Error(errors.NewBadRequestError("Knowledge ID cannot be empty"))
+		return
+	}
+
+	_, effCtx, err := h.resolveKnowledgeAndValidateKBAccess(c, id, types.OrgRoleViewer)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var pagination types.Pagination
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		logger.Error(ctx, "Failed to parse pagination parameters", err)
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+	if pagination.Page < 1 {
+		pagination.Page = 1
+	}
+	if pagination.PageSize < 1 {
+		pagination.PageSize = 20
+	}
+
+	result, err := h.chunkService.ListChunksByKnowledge(effCtx, id, &pagination)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+
+	for _, chunk := range result.Data.([]*types.Chunk) {
+		if chunk.Content != "" {
+			chunk.Content = secutils.SanitizeForDisplay(chunk.Content)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"data":      result.Data,
+		"total":     result.Total,
+		"page":      result.Page,
+		"page_size": result.PageSize,
+	})
+}
+
+// UpdateKnowledgeChunkRequest is the request body for editing a chunk's text
+type UpdateKnowledgeChunkRequest struct {
+	Content string `json:"content" binding:"required"`
+}
+
+// UpdateKnowledgeChunk godoc
+// @Summary      修改分块文本
+// @Description  修正分块文本（如 OCR/解析错误）并使用知识库的向量模型重新向量化该分块；FAQ 分块请通过 FAQ 条目接口修改
+// @Tags         知识管理
+// @Accept       json
+// @Produce      json
+// @Param        id        path      string                       true  "知识ID"
+// @Param        chunk_id  path      string                       true  "分块ID"
+// @Param        request   body      UpdateKnowledgeChunkRequest  true  "分块文本"
+// @Success      200       {object}  map[string]interface{}       "更新后的分块"
+// @Failure      400       {object}  errors.AppError              "请求参数错误"
+// @Failure      404       {object}  errors.AppError              "分块不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge/{id}/chunks/{chunk_id} [put]
+func (h *KnowledgeHandler) UpdateKnowledgeChunk(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id := secutils.SanitizeForLog(c.Param("id"))
+	chunkID := secutils.SanitizeForLog(c.Param("chunk_id"))
+	if id == "" || chunkID == "" {
+		logger.Error(ctx, "Knowledge ID or chunk ID is empty")
+		c.Error(errors.NewBadRequestError("Knowledge ID and chunk ID cannot be empty"))
+		return
+	}
+
+	_, effCtx, err := h.resolveKnowledgeAndValidateKBAccess(c, id, types.OrgRoleEditor)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var request UpdateKnowledgeChunkRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	chunk, err := h.chunkService.UpdateChunkText(effCtx, id, chunkID, request.Content)
+	if err != nil {
+		switch err {
+		case service.ErrChunkNotFound:
+			c.Error(errors.NewNotFoundError("Chunk not found"))
+		case service.ErrEmptyChunkText, service.ErrFAQChunkTextEdit:
+			c.Error(errors.NewBadRequestError(err.Error()))
+		default:
+			logger.ErrorWithFields(ctx, err, nil)
+			c.Error(errors.NewInternalServerError(err.Error()))
+		}
+		return
+	}
+
+	logger.Infof(ctx, "Knowledge chunk text updated, knowledge ID: %s, chunk ID: %s", id, chunkID)
+	chunk.Content = secutils.SanitizeForDisplay(chunk.Content)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    chunk,
+	})
+}
+
+// DeleteKnowledgeChunk godoc
+// @Summary      删除分块
+// @Description  删除知识中的指定分块（如页眉页脚等无效内容），同时清理其向量；FAQ 分块请通过 FAQ 条目接口删除
+// @Tags         知识管理
+// @Accept       json
+// @Produce      json
+// @Param        id        path      string  true  "知识ID"
+// @Param        chunk_id  path      string  true  "分块ID"
+// @Success      200       {object}  map[string]interface{}  "删除成功"
+// @Failure      400       {object}  errors.AppError         "请求参数错误"
+// @Failure      404       {object}  errors.AppError         "分块不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge/{id}/chunks/{chunk_id} [delete]
+func (h *KnowledgeHandler) DeleteKnowledgeChunk(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id := secutils.SanitizeForLog(c.Param("id"))
+	chunkID := secutils.SanitizeForLog(c.Param("chunk_id"))
+	if id == "" || chunkID == "" {
+		logger.Error(ctx, "Knowledge ID or chunk ID is empty")
+		c.Error(errors.NewBadRequestError("Knowledge ID and chunk ID cannot be empty"))
+		return
+	}
+
+	_, effCtx, err := h.resolveKnowledgeAndValidateKBAccess(c, id, types.OrgRoleEditor)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := h.chunkService.DeleteKnowledgeChunk(effCtx, id, chunkID); err != nil {
+		switch err {
+		case service.ErrChunkNotFound:
+			c.Error(errors.NewNotFoundError("Chunk not found"))
+		case service.ErrFAQChunkDelete:
+			c.Error(errors.NewBadRequestError(err.Error()))
+		default:
+			logger.ErrorWithFields(ctx, err, nil)
+			c.Error(errors.NewInternalServerError(err.Error()))
+		}
+		return
+	}
+
+	logger.Infof(ctx, "Knowledge chunk deleted, knowledge ID: %s, chunk ID: %s", id, chunkID)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Chunk deleted",
+	})
+}
+
 // SearchKnowledge godoc
 // @Summary      Search knowledge
 // @Description  Search knowledge files by keyword. When agent_id is set (shared agent), scope is the agent's configured knowledge bases.
@@ -1211,30 +1740,11 @@ func (h *KnowledgeHandler) SearchKnowledge(c *gin.Context) {
 
 	agentID := c.Query("agent_id")
 	if agentID != "" {
-		userIDVal, ok := c.Get(types.UserIDContextKey.String())
+		agent, ok := h.getSharedAgentForRequest(c, agentID)
 		if !ok {
-			c.Error(errors.NewUnauthorizedError("user ID not found"))
-			return
-		}
-		userID, _ := userIDVal.(string)
-		currentTenantID := c.GetUint64(types.TenantIDContextKey.String())
-		if currentTenantID == 0 {
-			c.Error(errors.NewUnauthorizedError("tenant ID not found"))
 			return
 		}
-		agent, err := h.agentShareService.GetSharedAgentForUser(ctx, userID, currentTenantID, agentID)
-		if err != nil {
-			if goerrors.Is(err, service.ErrAgentShareNotFound) || goerrors.Is(err, service.ErrAgentSharePermission) || goerrors.Is(err, service.ErrAgentNotFoundForShare) {
-				c.Error(errors.NewForbiddenError("no permission for this shared agent"))
-				return
-			}
-			logger.ErrorWithFields(ctx, err, nil)
-			c.Error(errors.NewInternalServerError("Failed to verify shared agent access").WithDetails(err.Error()))
-			return
-		}
-		sourceTenantID := agent.TenantID
-		mode := agent.Config.KBSelectionMode
-		if mode == "none" {
+		if agent.Config.KBSelectionMode == "none" {
 			c.JSON(http.StatusOK, gin.H{
 				"success":  true,
 				"data":     []interface{}{},
@@ -1242,26 +1752,11 @@ func (h *KnowledgeHandler) SearchKnowledge(c *gin.Context) {
 			})
 			return
 		}
-		var scopes []types.KnowledgeSearchScope
-		if mode == "selected" && len(agent.Config.KnowledgeBases) > 0 {
-			for _, kbID := range agent.Config.KnowledgeBases {
-				if kbID != "" {
-					scopes = append(scopes, types.KnowledgeSearchScope{TenantID: sourceTenantID, KBID: kbID})
-				}
-			}
-		}
-		if len(scopes) == 0 {
-			kbs, err := h.kbService.ListKnowledgeBasesByTenantID(ctx, sourceTenantID)
-			if err != nil {
-				logger.ErrorWithFields(ctx, err, nil)
-				c.Error(errors.NewInternalServerError("Failed to list knowledge bases").WithDetails(err.Error()))
-				return
-			}
-			for _, kb := range kbs {
-				if kb != nil && kb.Type == types.KnowledgeBaseTypeDocument {
-					scopes = append(scopes, types.KnowledgeSearchScope{TenantID: sourceTenantID, KBID: kb.ID})
-				}
-			}
+		scopes, err := h.sharedAgentSearchScopes(ctx, agent)
+		if err != nil {
+			logger.ErrorWithFields(ctx, err, nil)
+			c.Error(errors.NewInternalServerError("Failed to list knowledge bases").WithDetails(err.Error()))
+			return
 		}
 		knowledges, hasMore, err := h.kgService.SearchKnowledgeForScopes(ctx, scopes, keyword, offset, limit, fileTypes)
 		if err != nil {
@@ -1292,6 +1787,62 @@ func (h *KnowledgeHandler) SearchKnowledge(c *gin.Context) {
 	})
 }
 
+// getSharedAgentForRequest resolves a shared agent the current user may use (for @ mention scope).
+// On failure the error is written to the gin context and ok is false.
+func (h *KnowledgeHandler) getSharedAgentForRequest(c *gin.Context, agentID string) (*types.CustomAgent, bool) {
+	ctx := c.Request.Context()
+	userIDVal, ok := c.Get(types.UserIDContextKey.String())
+	if !ok {
+		c.Error(errors.NewUnauthorizedError("user ID not found"))
+		return nil, false
+	}
+	userID, _ := userIDVal.(string)
+	currentTenantID := c.GetUint64(types.TenantIDContextKey.String())
+	if currentTenantID == 0 {
+		c.Error(errors.NewUnauthorizedError("tenant ID not found"))
+		return nil, false
+	}
+	agent, err := h.agentShareService.GetSharedAgentForUser(ctx, userID, currentTenantID, agentID)
+	if err != nil {
+		if goerrors.Is(err, service.ErrAgentShareNotFound) || goerrors.Is(err, service.ErrAgentSharePermission) || goerrors.Is(err, service.ErrAgentNotFoundForShare) {
+			c.Error(errors.NewForbiddenError("no permission for this shared agent"))
+			return nil, false
+		}
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError("Failed to verify shared agent access").WithDetails(err.Error()))
+		return nil, false
+	}
+	return agent, true
+}
+
+// sharedAgentSearchScopes returns the document search scopes of a shared agent:
+// its selected knowledge bases, or every document knowledge base of its tenant.
+func (h *KnowledgeHandler) sharedAgentSearchScopes(
+	ctx context.Context, agent *types.CustomAgent,
+) ([]types.KnowledgeSearchScope, error) {
+	sourceTenantID := agent.TenantID
+	var scopes []types.KnowledgeSearchScope
+	if agent.Config.KBSelectionMode == "selected" && len(agent.Config.KnowledgeBases) > 0 {
+		for _, kbID := range agent.Config.KnowledgeBases {
+			if kbID != "" {
+				scopes = append(scopes, types.KnowledgeSearchScope{TenantID: sourceTenantID, KBID: kbID})
+			}
+		}
+	}
+	if len(scopes) == 0 {
+		kbs, err := h.kbService.ListKnowledgeBasesByTenantID(ctx, sourceTenantID)
+		if err != nil {
+			return nil, err
+		}
+		for _, kb := range kbs {
+			if kb != nil && kb.Type == types.KnowledgeBaseTypeDocument {
+				scopes = append(scopes, types.KnowledgeSearchScope{TenantID: sourceTenantID, KBID: kb.ID})
+			}
+		}
+	}
+	return scopes, nil
+}
+
 // MoveKnowledgeRequest defines the request for moving knowledge items
 type MoveKnowledgeRequest struct {
 	KnowledgeIDs []string `json:"knowledge_ids" binding:"required,min=1"`
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/gin-gonic/gin"
+)
+
+// partialShareService reports fixed partial share documents
+type partialShareService struct {
+	interfaces.KBShareService
+	knowledgeIDs []string
+	calls        int
+}
+
+func (s *partialShareService) GetSharedKnowledgeIDs(ctx context.Context,
+	kbID string, userID string,
+) ([]string, bool, error) {
+	s.calls++
+	return s.knowledgeIDs, s.knowledgeIDs != nil, nil
+}
+
+func TestSharedKnowledgeScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	kb := &types.KnowledgeBase{ID: "kb-1", TenantID: 1}
+	tests := []struct {
+		name           string
+		tenantID       uint64
+		userID         string
+		knowledgeIDs   []string
+		wantIDs        []string
+		wantRestricted bool
+		wantCalls      int
+	}{
+		{"owner tenant", 1, "user-1", []string{"k1"}, nil, false, 0},
+		{"no user", 2, "", []string{"k1"}, nil, false, 0},
+		{"partial share", 2, "user-1", []string{"k1", "k2"}, []string{"k1", "k2"}, true, 1},
+		{"whole share", 2, "user-1", nil, nil, false, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			c.Set(types.TenantIDContextKey.String(), tt.tenantID)
+			if tt.userID != "" {
+				c.Set(types.UserIDContextKey.String(), tt.userID)
+			}
+			shares := &partialShareService{knowledgeIDs: tt.knowledgeIDs}
+
+			ids, restricted, err := sharedKnowledgeScope(c, shares, kb)
+			if err != nil {
+				t.Fatalf("sharedKnowledgeScope() error = %v", err)
+			}
+			if restricted != tt.wantRestricted || !slices.Equal(ids, tt.wantIDs) {
+				t.Errorf("sharedKnowledgeScope() = %v, %v, want %v, %v", ids, restricted, tt.wantIDs, tt.wantRestricted)
+			}
+			if shares.calls != tt.wantCalls {
+				t.Errorf("share service called %d times, want %d", shares.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRestrictKnowledgeIDs(t *testing.T) {
+	allowed := []string{"k1", "k2"}
+	tests := []struct {
+		name      string
+		requested []string
+		want      []string
+	}{
+		{"all allowed", nil, []string{"k1", "k2"}},
+		{"subset", []string{"k2"}, []string{"k2"}},
+		{"other knowledge base", []string{"k2", "k9"}, []string{"k2"}},
+		{"only other knowledge base", []string{"k9"}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := restrictKnowledgeIDs(tt.requested, allowed); !slices.Equal(got, tt.want) {
+				t.Errorf("restrictKnowledgeIDs(%v) = %v, want %v", tt.requested, got, tt.want)
+			}
+		})
+	}
+}
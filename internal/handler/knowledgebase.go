@@ -1,11 +1,14 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	stderrors "errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
+	"unicode/utf8"
 
 	"github.com/Tencent/WeKnora/internal/application/repository"
 	"github.com/Tencent/WeKnora/internal/application/service"
@@ -65,7 +68,7 @@ func (h *KnowledgeBaseHandler) HybridSearch(c *gin.Context) {
 	logger.Info(ctx, "Start hybrid search")
 
 	// Validate and check permission for knowledge base access
-	_, id, effectiveTenantID, _, err := h.validateAndGetKnowledgeBase(c)
+	kb, id, effectiveTenantID, _, err := h.validateAndGetKnowledgeBase(c)
 	if err != nil {
 		c.Error(err)
 		return
@@ -78,6 +81,28 @@ func (h *KnowledgeBaseHandler) HybridSearch(c *gin.Context) {
 		c.Error(apperrors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
 		return
 	}
+	if err := types.ValidateIncludeMetadata(req.IncludeMetadata); err != nil {
+		c.Error(apperrors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	// Members of a partial share only search the shared documents
+	allowed, restricted, err := sharedKnowledgeScope(c, h.kbShareService, kb)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(apperrors.NewInternalServerError(err.Error()))
+		return
+	}
+	if restricted {
+		req.KnowledgeIDs = restrictKnowledgeIDs(req.KnowledgeIDs, allowed)
+		if len(req.KnowledgeIDs) == 0 {
+			c.JSON(http.StatusOK, gin.H{
+				"success": true,
+				"data":    []*types.SearchResult{},
+			})
+			return
+		}
+	}
 
 	logger.Infof(ctx, "Executing hybrid search, knowledge base ID: %s, query: %s, effectiveTenantID: %d",
 		secutils.SanitizeForLog(id), secutils.SanitizeForLog(req.QueryText), effectiveTenantID)
@@ -128,6 +153,18 @@ func (h *KnowledgeBaseHandler) CreateKnowledgeBase(c *gin.Context) {
 		c.Error(err)
 		return
 	}
+	if err := normalizeOCRConfig(&req.ChunkingConfig); err != nil {
+		c.Error(err)
+		return
+	}
+	if req.DuplicateScope != "" && !types.IsValidDuplicateScope(req.DuplicateScope) {
+		c.Error(apperrors.NewBadRequestError("duplicate_scope must be 'kb' or 'tenant'"))
+		return
+	}
+	if utf8.RuneCountInString(req.FallbackResponse) > types.MaxFallbackResponseLength {
+		c.Error(apperrors.NewBadRequestError(fmt.Sprintf("fallback_response must be at most %d characters", types.MaxFallbackResponseLength)))
+		return
+	}
 
 	logger.Infof(ctx, "Creating knowledge base, name: %s", secutils.SanitizeForLog(req.Name))
 	// Create knowledge base using the service
@@ -169,6 +206,9 @@ func (h *KnowledgeBaseHandler) validateAndGetKnowledgeBase(c *gin.Context) (*typ
 		logger.Error(ctx, "Knowledge base ID is empty")
 		return nil, "", 0, "", apperrors.NewBadRequestError("Knowledge base ID cannot be empty")
 	}
+	if err := checkAPIKeyScope(c, id, types.OrgRoleViewer); err != nil {
+		return nil, id, 0, "", err
+	}
 
 	// Verify tenant has permission to access this knowledge base
 	kb, err := h.service.GetKnowledgeBaseByID(ctx, id)
@@ -179,7 +219,7 @@ func (h *KnowledgeBaseHandler) validateAndGetKnowledgeBase(c *gin.Context) (*typ
 
 	// Check 1: Verify tenant ownership (owner has full access)
 	if kb.TenantID == tenantID.(uint64) {
-		return kb, id, tenantID.(uint64), types.OrgRoleAdmin, nil
+		return kb, id, tenantID.(uint64), apiKeyPermission(c, types.OrgRoleAdmin), nil
 	}
 
 	// Check 2: If not owner, check organization shared access
@@ -283,6 +323,54 @@ func (h *KnowledgeBaseHandler) GetKnowledgeBase(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"success": true, "data": data})
 }
 
+// GetKnowledgeBaseStats godoc
+// @Summary      获取知识库统计信息
+// @Description  获取知识库的聚合统计：知识数量、分块数量、平均分块长度、文件类型分布、向量化覆盖率和最近更新时间。共享知识库按所有者租户统计，部分共享只统计共享的知识。
+// @Tags         知识库
+// @Produce      json
+// @Param        id   path      string  true  "知识库ID"
+// @Success      200  {object}  map[string]interface{}  "统计信息"
+// @Failure      403  {object}  errors.AppError         "无权限"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/stats [get]
+func (h *KnowledgeBaseHandler) GetKnowledgeBaseStats(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// Any access level (viewer and above) may read the stats
+	kb, _, _, _, err := h.validateAndGetKnowledgeBase(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	// Members of a partial share only see the statistics of the shared documents
+	allowed, restricted, err := sharedKnowledgeScope(c, h.kbShareService, kb)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(apperrors.NewInternalServerError(err.Error()))
+		return
+	}
+	var knowledgeIDs []string
+	if restricted {
+		knowledgeIDs = append([]string{}, allowed...)
+	}
+
+	stats, err := h.service.GetKnowledgeBaseStats(ctx, kb.ID, knowledgeIDs)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"knowledge_base_id": kb.ID,
+		})
+		c.Error(apperrors.NewInternalServerError("Failed to get knowledge base stats").WithDetails(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    stats,
+	})
+}
+
 // ListKnowledgeBases godoc
 // @Summary      获取知识库列表
 // @Description  获取当前租户的所有知识库；或当传入 agent_id（共享智能体）时，校验权限后返回该智能体配置的知识库范围（用于 @ 提及）
@@ -423,6 +511,61 @@ func (h *KnowledgeBaseHandler) TogglePinKnowledgeBase(c *gin.Context) {
 	})
 }
 
+// SetShareLabelRequest defines the request body structure for setting the share label of a knowledge base
+type SetShareLabelRequest struct {
+	// ShareLabel is matched by dynamic share rules; empty clears it
+	ShareLabel string `json:"share_label"`
+}
+
+// SetShareLabel godoc
+// @Summary      设置知识库共享标签
+// @Description  设置组织动态共享规则匹配的知识库共享标签，传空字符串清除。仅知识库所属租户可设置，共享空间成员和限定范围的 API Key 不可调用
+// @Tags         知识库
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                true  "知识库ID"
+// @Param        request  body      SetShareLabelRequest  true  "共享标签"
+// @Success      200      {object}  map[string]interface{}  "更新后的知识库"
+// @Failure      400      {object}  errors.AppError         "共享标签过长"
+// @Failure      404      {object}  errors.AppError         "知识库不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/share-label [put]
+func (h *KnowledgeBaseHandler) SetShareLabel(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := c.Param("id")
+	if id == "" {
+		c.Error(apperrors.NewBadRequestError("knowledge base ID is required"))
+		return
+	}
+
+	var req SetShareLabelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
+		return
+	}
+
+	kb, err := h.service.SetShareLabel(ctx, id, req.ShareLabel)
+	if err != nil {
+		if stderrors.Is(err, repository.ErrKnowledgeBaseNotFound) {
+			c.Error(apperrors.NewNotFoundError("knowledge base not found"))
+			return
+		}
+		if appErr, ok := apperrors.IsAppError(err); ok {
+			c.Error(appErr)
+			return
+		}
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(apperrors.NewInternalServerError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    kb,
+	})
+}
+
 // UpdateKnowledgeBaseRequest defines the request body structure for updating a knowledge base
 type UpdateKnowledgeBaseRequest struct {
 	Name        string                     `json:"name"        binding:"required"`
@@ -439,7 +582,8 @@ type UpdateKnowledgeBaseRequest struct {
 // @Param        id       path      string                     true  "知识库ID"
 // @Param        request  body      UpdateKnowledgeBaseRequest true  "更新请求"
 // @Success      200      {object}  map[string]interface{}     "更新后的知识库"
-// @Failure      400      {object}  errors.AppError            "请求参数错误"
+// @Failure      400      {object}  errors.AppError            "请求参数错误，或知识库已有内容时更换Embedding模型但未要求重建索引"
+// @Failure      500      {object}  errors.AppError            "重建索引任务提交失败，更新已撤销"
 // @Security     Bearer
 // @Security     ApiKeyAuth
 // @Router       /knowledge-bases/{id} [put]
@@ -467,6 +611,19 @@ func (h *KnowledgeBaseHandler) UpdateKnowledgeBase(c *gin.Context) {
 		c.Error(apperrors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
 		return
 	}
+	if req.Config.DuplicateScope != "" && !types.IsValidDuplicateScope(req.Config.DuplicateScope) {
+		c.Error(apperrors.NewBadRequestError("duplicate_scope must be 'kb' or 'tenant'"))
+		return
+	}
+	if req.Config.FallbackResponse != nil &&
+		utf8.RuneCountInString(*req.Config.FallbackResponse) > types.MaxFallbackResponseLength {
+		c.Error(apperrors.NewBadRequestError(fmt.Sprintf("fallback_response must be at most %d characters", types.MaxFallbackResponseLength)))
+		return
+	}
+	if err := normalizeOCRConfig(&req.Config.ChunkingConfig); err != nil {
+		c.Error(err)
+		return
+	}
 
 	logger.Infof(ctx, "Updating knowledge base, ID: %s, name: %s",
 		secutils.SanitizeForLog(id), secutils.SanitizeForLog(req.Name))
@@ -475,6 +632,10 @@ func (h *KnowledgeBaseHandler) UpdateKnowledgeBase(c *gin.Context) {
 	kb, err := h.service.UpdateKnowledgeBase(ctx, id, req.Name, req.Description, req.Config)
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, nil)
+		if appErr, ok := apperrors.IsAppError(err); ok {
+			c.Error(appErr)
+			return
+		}
 		c.Error(apperrors.NewInternalServerError(err.Error()))
 		return
 	}
@@ -630,32 +791,48 @@ func (h *KnowledgeBaseHandler) CopyKnowledgeBase(c *gin.Context) {
 		SourceID: req.SourceID,
 		TargetID: req.TargetID,
 	}
+	if err := h.enqueueKBClone(ctx, payload); err != nil {
+		c.Error(err)
+		return
+	}
 
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": CopyKnowledgeBaseResponse{
+			TaskID:   taskID,
+			SourceID: req.SourceID,
+			TargetID: req.TargetID,
+			Message:  "Knowledge base copy task started",
+		},
+	})
+}
+
+// enqueueKBClone enqueues a KB clone task and saves its initial progress
+func (h *KnowledgeBaseHandler) enqueueKBClone(ctx context.Context, payload types.KBClonePayload) error {
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
 		logger.Errorf(ctx, "Failed to marshal KB clone payload: %v", err)
-		c.Error(apperrors.NewInternalServerError("Failed to create task"))
-		return
+		return apperrors.NewInternalServerError("Failed to create task")
 	}
 
 	// Enqueue KB clone task to Asynq
 	task := asynq.NewTask(types.TypeKBClone, payloadBytes,
-		asynq.TaskID(taskID), asynq.Queue("default"), asynq.MaxRetry(3))
+		asynq.TaskID(payload.TaskID), asynq.Queue("default"), asynq.MaxRetry(3))
 	info, err := h.asynqClient.Enqueue(task)
 	if err != nil {
 		logger.Errorf(ctx, "Failed to enqueue KB clone task: %v", err)
-		c.Error(apperrors.NewInternalServerError("Failed to enqueue task"))
-		return
+		return apperrors.NewInternalServerError("Failed to enqueue task")
 	}
 
-	logger.Infof(ctx, "KB clone task enqueued: %s, asynq task ID: %s, source: %s, target: %s",
-		taskID, info.ID, secutils.SanitizeForLog(req.SourceID), secutils.SanitizeForLog(req.TargetID))
+	logger.Infof(ctx, "KB clone task enqueued: %s, asynq task ID: %s, source: %s, target: %s, items: %d",
+		payload.TaskID, info.ID, secutils.SanitizeForLog(payload.SourceID),
+		secutils.SanitizeForLog(payload.TargetID), len(payload.KnowledgeIDs))
 
 	// Save initial progress to Redis so frontend can query immediately
 	initialProgress := &types.KBCloneProgress{
-		TaskID:    taskID,
-		SourceID:  req.SourceID,
-		TargetID:  req.TargetID,
+		TaskID:    payload.TaskID,
+		SourceID:  payload.SourceID,
+		TargetID:  payload.TargetID,
 		Status:    types.KBCloneStatusPending,
 		Progress:  0,
 		Message:   "Task queued, waiting to start...",
@@ -666,14 +843,73 @@ func (h *KnowledgeBaseHandler) CopyKnowledgeBase(c *gin.Context) {
 		logger.Warnf(ctx, "Failed to save initial KB clone progress: %v", err)
 		// Don't fail the request, task is already enqueued
 	}
+	return nil
+}
+
+// RetryKBCloneFailedItems godoc
+// @Summary      重试知识库复制失败项
+// @Description  针对状态为 completed_with_errors 的复制任务，仅重新复制失败的知识（异步任务）
+// @Tags         知识库
+// @Accept       json
+// @Produce      json
+// @Param        task_id  path      string  true  "原复制任务ID"
+// @Success      200      {object}  map[string]interface{}  "新任务ID"
+// @Failure      400      {object}  errors.AppError         "任务没有失败项"
+// @Failure      404      {object}  errors.AppError         "任务不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/copy/{task_id}/retry [post]
+func (h *KnowledgeBaseHandler) RetryKBCloneFailedItems(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	taskID := c.Param("task_id")
+	if taskID == "" {
+		c.Error(apperrors.NewBadRequestError("Task ID cannot be empty"))
+		return
+	}
+	tenantID := types.MustTenantIDFromContext(ctx)
+
+	progress, err := h.knowledgeService.GetKBCloneProgress(ctx, taskID)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(err)
+		return
+	}
+	if progress.Status != types.KBCloneStatusCompletedWithErrors || len(progress.FailedItems) == 0 {
+		c.Error(apperrors.NewBadRequestError("Only clone tasks completed with errors can be retried"))
+		return
+	}
+
+	// The progress record is not tenant scoped; make sure the source belongs to the caller
+	sourceKB, err := h.service.GetKnowledgeBaseByID(ctx, progress.SourceID)
+	if err != nil || sourceKB.TenantID != tenantID {
+		c.Error(apperrors.NewNotFoundError("KB clone task not found"))
+		return
+	}
+
+	knowledgeIDs := make([]string, 0, len(progress.FailedItems))
+	for _, item := range progress.FailedItems {
+		knowledgeIDs = append(knowledgeIDs, item.KnowledgeID)
+	}
+	payload := types.KBClonePayload{
+		TenantID:     tenantID,
+		TaskID:       utils.GenerateTaskID("kb_clone", tenantID, progress.SourceID),
+		SourceID:     progress.SourceID,
+		TargetID:     progress.TargetID,
+		KnowledgeIDs: knowledgeIDs,
+	}
+	if err := h.enqueueKBClone(ctx, payload); err != nil {
+		c.Error(err)
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data": CopyKnowledgeBaseResponse{
-			TaskID:   taskID,
-			SourceID: req.SourceID,
-			TargetID: req.TargetID,
-			Message:  "Knowledge base copy task started",
+			TaskID:   payload.TaskID,
+			SourceID: payload.SourceID,
+			TargetID: payload.TargetID,
+			Message:  fmt.Sprintf("Retrying %d failed knowledge", len(knowledgeIDs)),
 		},
 	})
 }
@@ -713,6 +949,95 @@ func (h *KnowledgeBaseHandler) GetKBCloneProgress(c *gin.Context) {
 	})
 }
 
+// RebuildKeywordIndex godoc
+// @Summary      重建知识库关键词索引
+// @Description  异步重建知识库分块的关键词（全文）索引，不重新解析和向量化；仅知识库所有者可操作
+// @Tags         知识库
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "知识库ID"
+// @Success      202  {object}  map[string]interface{}  "重建进度"
+// @Failure      403  {object}  errors.AppError         "无权限"
+// @Failure      409  {object}  errors.AppError         "重建任务已在运行"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/rebuild-index [post]
+func (h *KnowledgeBaseHandler) RebuildKeywordIndex(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	kb, id, _, permission, err := h.validateAndGetKnowledgeBase(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	// Only the owner can rebuild the index of a knowledge base
+	tenantID := types.MustTenantIDFromContext(ctx)
+	if kb.TenantID != tenantID || permission != types.OrgRoleAdmin {
+		c.Error(apperrors.NewForbiddenError("Only knowledge base owner can rebuild its index"))
+		return
+	}
+
+	progress, err := h.service.RebuildKeywordIndex(ctx, id)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"data":    progress,
+	})
+}
+
+// GetKeywordIndexProgress godoc
+// @Summary      获取关键词索引重建进度
+// @Description  获取知识库最近一次关键词索引重建任务的进度
+// @Tags         知识库
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "知识库ID"
+// @Success      200  {object}  map[string]interface{}  "重建进度"
+// @Failure      404  {object}  errors.AppError         "没有重建任务"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/rebuild-index [get]
+func (h *KnowledgeBaseHandler) GetKeywordIndexProgress(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	kb, id, _, _, err := h.validateAndGetKnowledgeBase(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if kb.TenantID != types.MustTenantIDFromContext(ctx) {
+		c.Error(apperrors.NewForbiddenError("Only knowledge base owner can view its index rebuild"))
+		return
+	}
+
+	progress, err := h.service.GetKeywordIndexProgress(ctx, id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    progress,
+	})
+}
+
+// normalizeOCRConfig validates and normalizes the OCR languages of a chunking config
+func normalizeOCRConfig(config *types.ChunkingConfig) error {
+	languages, err := types.NormalizeOCRLanguages(config.OCRLanguages)
+	if err != nil {
+		return apperrors.NewBadRequestError(err.Error())
+	}
+	config.OCRLanguages = languages
+	return nil
+}
+
 // validateExtractConfig validates the graph configuration parameters
 func validateExtractConfig(config *types.ExtractConfig) error {
 	if config == nil {
@@ -0,0 +1,257 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// defaultMentionLimit is the number of candidates returned per type when no limit is given
+	defaultMentionLimit = 10
+	// maxMentionLimit bounds the number of candidates returned per type
+	maxMentionLimit = 50
+	// mentionSearchFactor widens the document search so prefix matches can be ranked first
+	mentionSearchFactor = 3
+)
+
+// Mention types accepted by ResolveMentions
+const (
+	mentionTypeKB  = "kb"
+	mentionTypeDoc = "doc"
+)
+
+// MentionCandidate is a knowledge base or document that can be @mentioned in a QA request.
+// The embedded MentionedItem can be passed as-is in the request's mentioned_items.
+type MentionCandidate struct {
+	types.MentionedItem
+	// KnowledgeBaseID is the knowledge base the document belongs to (documents only)
+	KnowledgeBaseID string `json:"knowledge_base_id,omitempty"`
+	// Shared reports whether the knowledge base is shared with the user rather than owned
+	Shared bool `json:"shared"`
+}
+
+// MentionResolveResult holds the matching knowledge bases and documents
+type MentionResolveResult struct {
+	KnowledgeBases []*MentionCandidate `json:"knowledge_bases"`
+	Documents      []*MentionCandidate `json:"documents"`
+}
+
+// ResolveMentions godoc
+// @Summary      解析@提及
+// @Description  根据输入的名称匹配用户可访问的知识库和文档（自有、共享或共享智能体可见），前缀匹配优先
+// @Tags         知识
+// @Accept       json
+// @Produce      json
+// @Param        q         query     string  false  "名称关键字"
+// @Param        type      query     string  false  "提及类型：kb 或 doc，为空时同时返回两种"
+// @Param        limit     query     int     false  "每种类型返回的最大数量（默认10，最大50）"
+// @Param        agent_id  query     string  false  "共享智能体 ID（在该智能体的知识库范围内解析）"
+// @Success      200       {object}  map[string]interface{}  "匹配结果"
+// @Failure      400       {object}  errors.AppError         "请求参数错误"
+// @Failure      403       {object}  errors.AppError         "无权使用该共享智能体"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /mentions/resolve [get]
+func (h *KnowledgeHandler) ResolveMentions(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	query := strings.TrimSpace(c.Query("q"))
+	mentionType := c.Query("type")
+	if mentionType != "" && mentionType != mentionTypeKB && mentionType != mentionTypeDoc {
+		c.Error(errors.NewBadRequestError("type must be kb or doc"))
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultMentionLimit)))
+	if limit <= 0 {
+		limit = defaultMentionLimit
+	}
+	if limit > maxMentionLimit {
+		limit = maxMentionLimit
+	}
+
+	var agent *types.CustomAgent
+	if agentID := c.Query("agent_id"); agentID != "" {
+		var ok bool
+		if agent, ok = h.getSharedAgentForRequest(c, agentID); !ok {
+			return
+		}
+	}
+
+	result := &MentionResolveResult{
+		KnowledgeBases: []*MentionCandidate{},
+		Documents:      []*MentionCandidate{},
+	}
+	if agent != nil && agent.Config.KBSelectionMode == "none" {
+		c.JSON(http.StatusOK, gin.H{"success": true, "data": result})
+		return
+	}
+
+	if mentionType == "" || mentionType == mentionTypeKB {
+		kbs, err := h.mentionableKnowledgeBases(c, agent)
+		if err != nil {
+			logger.ErrorWithFields(ctx, err, nil)
+			c.Error(errors.NewInternalServerError("Failed to list knowledge bases").WithDetails(err.Error()))
+			return
+		}
+		result.KnowledgeBases = rankMentionCandidates(query, kbs, limit)
+	}
+
+	if mentionType == "" || mentionType == mentionTypeDoc {
+		docs, err := h.mentionableDocuments(c, agent, query, limit*mentionSearchFactor)
+		if err != nil {
+			logger.ErrorWithFields(ctx, err, nil)
+			c.Error(errors.NewInternalServerError("Failed to search knowledge").WithDetails(err.Error()))
+			return
+		}
+		result.Documents = rankMentionCandidates(query, docs, limit)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// mentionableKnowledgeBases returns the knowledge bases the user can @mention: the shared agent's
+// knowledge bases when an agent is given, otherwise the tenant's own plus those shared with the user.
+func (h *KnowledgeHandler) mentionableKnowledgeBases(
+	c *gin.Context, agent *types.CustomAgent,
+) ([]*MentionCandidate, error) {
+	ctx := c.Request.Context()
+	var candidates []*MentionCandidate
+	appendKB := func(kb *types.KnowledgeBase, shared bool) {
+		if kb == nil || kb.IsTemporary {
+			return
+		}
+		candidates = append(candidates, &MentionCandidate{
+			MentionedItem: types.MentionedItem{ID: kb.ID, Name: kb.Name, Type: "kb", KBType: kb.Type},
+			Shared:        shared,
+		})
+	}
+
+	if agent != nil {
+		var kbs []*types.KnowledgeBase
+		var err error
+		if agent.Config.KBSelectionMode == "selected" && len(agent.Config.KnowledgeBases) > 0 {
+			kbs, err = h.kbService.GetKnowledgeBasesByIDsOnly(ctx, agent.Config.KnowledgeBases)
+		} else {
+			kbs, err = h.kbService.ListKnowledgeBasesByTenantID(ctx, agent.TenantID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, kb := range kbs {
+			if kb != nil && kb.TenantID == agent.TenantID {
+				appendKB(kb, true)
+			}
+		}
+		return candidates, nil
+	}
+
+	kbs, err := h.kbService.ListKnowledgeBases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, kb := range kbs {
+		appendKB(kb, false)
+	}
+
+	userID := c.GetString(types.UserIDContextKey.String())
+	tenantID := c.GetUint64(types.TenantIDContextKey.String())
+	if h.kbShareService != nil && userID != "" {
+		sharedList, err := h.kbShareService.ListSharedKnowledgeBases(ctx, userID, tenantID)
+		if err != nil {
+			logger.Warnf(ctx, "Failed to list shared knowledge bases for mentions: %v", err)
+		}
+		for _, info := range sharedList {
+			if info != nil {
+				appendKB(info.KnowledgeBase, true)
+			}
+		}
+	}
+	return candidates, nil
+}
+
+// mentionableDocuments searches the documents the user can @mention by file name
+func (h *KnowledgeHandler) mentionableDocuments(
+	c *gin.Context, agent *types.CustomAgent, query string, limit int,
+) ([]*MentionCandidate, error) {
+	ctx := c.Request.Context()
+	var knowledges []*types.Knowledge
+	var err error
+	if agent != nil {
+		scopes, scopeErr := h.sharedAgentSearchScopes(ctx, agent)
+		if scopeErr != nil {
+			return nil, scopeErr
+		}
+		knowledges, _, err = h.kgService.SearchKnowledgeForScopes(ctx, scopes, query, 0, limit, nil)
+	} else {
+		if userID, ok := c.Get(types.UserIDContextKey.String()); ok {
+			ctx = context.WithValue(ctx, types.UserIDContextKey, userID)
+		}
+		knowledges, _, err = h.kgService.SearchKnowledge(ctx, query, 0, limit, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tenantID := c.GetUint64(types.TenantIDContextKey.String())
+	candidates := make([]*MentionCandidate, 0, len(knowledges))
+	for _, k := range knowledges {
+		if k == nil {
+			continue
+		}
+		name := k.FileName
+		if name == "" {
+			name = k.Title
+		}
+		candidates = append(candidates, &MentionCandidate{
+			MentionedItem:   types.MentionedItem{ID: k.ID, Name: name, Type: "file"},
+			KnowledgeBaseID: k.KnowledgeBaseID,
+			Shared:          k.TenantID != tenantID,
+		})
+	}
+	return candidates, nil
+}
+
+// rankMentionCandidates keeps the candidates whose name contains the query (case-insensitive),
+// orders prefix matches first and then by name, and returns at most limit entries.
+func rankMentionCandidates(query string, candidates []*MentionCandidate, limit int) []*MentionCandidate {
+	q := strings.ToLower(query)
+	type ranked struct {
+		candidate *MentionCandidate
+		name      string
+		prefix    bool
+	}
+	matches := make([]ranked, 0, len(candidates))
+	for _, cand := range candidates {
+		name := strings.ToLower(cand.Name)
+		if q != "" && !strings.Contains(name, q) {
+			continue
+		}
+		matches = append(matches, ranked{candidate: cand, name: name, prefix: strings.HasPrefix(name, q)})
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].prefix != matches[j].prefix {
+			return matches[i].prefix
+		}
+		return matches[i].name < matches[j].name
+	})
+
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	result := make([]*MentionCandidate, 0, len(matches))
+	for _, m := range matches {
+		result = append(result, m.candidate)
+	}
+	return result
+}
@@ -231,6 +231,40 @@ func (h *ModelHandler) ListModels(c *gin.Context) {
 	})
 }
 
+// ListModelsGrouped godoc
+// @Summary      获取分组模型目录
+// @Description  按类型（对话/向量/重排/多模态）分组返回当前租户的模型，包含来源、可用状态及是否可用于标题生成
+// @Tags         模型管理
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "分组模型目录"
+// @Failure      400  {object}  errors.AppError         "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /models/grouped [get]
+func (h *ModelHandler) ListModelsGrouped(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	tenantID := c.GetUint64(types.TenantIDContextKey.String())
+	if tenantID == 0 {
+		logger.Error(ctx, "Tenant ID is empty")
+		c.Error(errors.NewBadRequestError("Tenant ID cannot be empty"))
+		return
+	}
+
+	catalog, err := h.service.ListModelsGrouped(ctx)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    catalog,
+	})
+}
+
 // UpdateModelRequest defines the structure for model update requests
 // Contains fields that can be updated for an existing model
 type UpdateModelRequest struct {
@@ -359,6 +393,74 @@ func (h *ModelHandler) DeleteModel(c *gin.Context) {
 	})
 }
 
+// WarmupModel godoc
+// @Summary      预热模型
+// @Description  向模型发送一次最小请求以建立连接、加载权重，返回耗时。用于部署后避免首次对话过慢或超时
+// @Tags         模型管理
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "模型ID"
+// @Success      200  {object}  map[string]interface{}  "预热结果"
+// @Failure      404  {object}  errors.AppError         "模型不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /models/{id}/warmup [post]
+func (h *ModelHandler) WarmupModel(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id := secutils.SanitizeForLog(c.Param("id"))
+	if id == "" {
+		logger.Error(ctx, "Model ID is empty")
+		c.Error(errors.NewBadRequestError("Model ID cannot be empty"))
+		return
+	}
+
+	logger.Infof(ctx, "Warming up model, ID: %s", id)
+	result, err := h.service.WarmupModel(ctx, id)
+	if err != nil {
+		if err == service.ErrModelNotFound {
+			logger.Warnf(ctx, "Model not found, ID: %s", id)
+			c.Error(errors.NewNotFoundError("Model not found"))
+			return
+		}
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// WarmupModels godoc
+// @Summary      预热全部模型
+// @Description  并发预热当前租户的所有可用模型，返回每个模型的预热结果和耗时
+// @Tags         模型管理
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "预热结果列表"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /models/warmup [post]
+func (h *ModelHandler) WarmupModels(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	logger.Info(ctx, "Warming up all models")
+	results, err := h.service.WarmupModels(ctx)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    results,
+	})
+}
+
 // ModelProviderDTO 模型厂商信息 DTO
 type ModelProviderDTO struct {
 	Value       string            `json:"value"`       // provider 标识符
@@ -3,8 +3,13 @@ package handler
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 
@@ -26,6 +31,7 @@ type OrganizationHandler struct {
 	kbService          interfaces.KnowledgeBaseService
 	knowledgeRepo      interfaces.KnowledgeRepository
 	chunkRepo          interfaces.ChunkRepository
+	knowledgeService   interfaces.KnowledgeService
 }
 
 // NewOrganizationHandler creates a new organization handler
@@ -38,6 +44,7 @@ func NewOrganizationHandler(
 	kbService interfaces.KnowledgeBaseService,
 	knowledgeRepo interfaces.KnowledgeRepository,
 	chunkRepo interfaces.ChunkRepository,
+	knowledgeService interfaces.KnowledgeService,
 ) *OrganizationHandler {
 	return &OrganizationHandler{
 		orgService:         orgService,
@@ -48,6 +55,7 @@ func NewOrganizationHandler(
 		kbService:          kbService,
 		knowledgeRepo:      knowledgeRepo,
 		chunkRepo:          chunkRepo,
+		knowledgeService:   knowledgeService,
 	}
 }
 
@@ -78,10 +86,16 @@ func (h *OrganizationHandler) CreateOrganization(c *gin.Context) {
 	org, err := h.orgService.CreateOrganization(ctx, userID, tenantID, &req)
 	if err != nil {
 		logger.Errorf(ctx, "Failed to create organization: %v", err)
-		if errors.Is(err, service.ErrInvalidValidityDays) {
+		if errors.Is(err, service.ErrInvalidValidityDays) || errors.Is(err, service.ErrInvalidRole) ||
+			errors.Is(err, service.ErrInvalidJoinRequestTTL) || errors.Is(err, service.ErrInvalidAvatarURL) {
 			c.Error(apperrors.NewValidationError(err.Error()))
 			return
 		}
+		if errors.Is(err, service.ErrOwnedOrgLimitReached) {
+			c.Error(apperrors.NewForbiddenError(
+				fmt.Sprintf("已达到可创建组织的数量上限（%d 个）", h.orgService.GetMaxOwnedOrganizations())))
+			return
+		}
 		c.Error(apperrors.NewInternalServerError("Failed to create organization").WithDetails(err.Error()))
 		return
 	}
@@ -296,7 +310,9 @@ func (h *OrganizationHandler) UpdateOrganization(c *gin.Context) {
 	org, err := h.orgService.UpdateOrganization(ctx, orgID, userID, &req)
 	if err != nil {
 		logger.Errorf(ctx, "Failed to update organization: %v", err)
-		if errors.Is(err, service.ErrInvalidValidityDays) {
+		if errors.Is(err, service.ErrInvalidValidityDays) || errors.Is(err, service.ErrInvalidRole) ||
+			errors.Is(err, service.ErrInvalidJoinRequestTTL) || errors.Is(err, service.ErrInvalidStorageQuota) ||
+			errors.Is(err, service.ErrInvalidAvatarURL) {
 			c.Error(apperrors.NewValidationError(err.Error()))
 			return
 		}
@@ -362,14 +378,83 @@ func (h *OrganizationHandler) ListMembers(c *gin.Context) {
 		return
 	}
 
+	response := toMemberResponses(members)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": types.ListMembersResponse{
+			Members: response,
+			Total:   int64(len(response)),
+		},
+	})
+}
+
+// SearchMembers searches the members of an organization
+// @Summary      搜索组织成员
+// @Description  按用户名或邮箱（不区分大小写）搜索组织内的成员，供成员管理界面使用
+// @Tags         组织管理
+// @Produce      json
+// @Param        id     path   string  true   "组织ID"
+// @Param        q      query  string  true   "搜索关键词（用户名或邮箱）"
+// @Param        limit  query  int     false  "返回数量限制" default(20)
+// @Success      200    {object}  types.ListMembersResponse
+// @Failure      403    {object}  apperrors.AppError
+// @Security     Bearer
+// @Router       /organizations/{id}/members/search [get]
+func (h *OrganizationHandler) SearchMembers(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	orgID := c.Param("id")
+	userID := c.GetString(types.UserIDContextKey.String())
+	query := strings.TrimSpace(c.Query("q"))
+
+	if _, err := h.orgService.GetMember(ctx, orgID, userID); err != nil {
+		c.Error(apperrors.NewForbiddenError("You are not a member of this organization"))
+		return
+	}
+
+	if query == "" {
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    types.ListMembersResponse{Members: []types.OrganizationMemberResponse{}},
+		})
+		return
+	}
+
+	limit := 20
+	if l := c.Query("limit"); l != "" {
+		if n, err := strconv.Atoi(l); err == nil && n > 0 && n <= 100 {
+			limit = n
+		}
+	}
+
+	members, err := h.orgService.SearchMembers(ctx, orgID, query, limit)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to search members: %v", err)
+		c.Error(apperrors.NewInternalServerError("Failed to search members"))
+		return
+	}
+
+	response := toMemberResponses(members)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": types.ListMembersResponse{
+			Members: response,
+			Total:   int64(len(response)),
+		},
+	})
+}
+
+// toMemberResponses converts organization members to their API representation
+func toMemberResponses(members []*types.OrganizationMember) []types.OrganizationMemberResponse {
 	response := make([]types.OrganizationMemberResponse, 0, len(members))
 	for _, m := range members {
 		resp := types.OrganizationMemberResponse{
-			ID:       m.ID,
-			UserID:   m.UserID,
-			Role:     string(m.Role),
-			TenantID: m.TenantID,
-			JoinedAt: m.CreatedAt,
+			ID:        m.ID,
+			UserID:    m.UserID,
+			Role:      string(m.Role),
+			Suspended: m.Suspended,
+			TenantID:  m.TenantID,
+			JoinedAt:  m.CreatedAt,
 		}
 		if m.User != nil {
 			resp.Username = m.User.Username
@@ -378,14 +463,7 @@ func (h *OrganizationHandler) ListMembers(c *gin.Context) {
 		}
 		response = append(response, resp)
 	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data": types.ListMembersResponse{
-			Members: response,
-			Total:   int64(len(response)),
-		},
-	})
+	return response
 }
 
 // UpdateMemberRole updates a member's role
@@ -455,6 +533,174 @@ func (h *OrganizationHandler) RemoveMember(c *gin.Context) {
 	})
 }
 
+// SuspendMember suspends a member of an organization
+// @Summary      暂停成员
+// @Description  暂停组织成员的访问权限，保留其成员资格与角色（需要管理员权限，不能暂停所有者）
+// @Tags         组织管理
+// @Param        id       path  string  true  "组织ID"
+// @Param        user_id  path  string  true  "用户ID"
+// @Success      200      {object}  map[string]interface{}
+// @Failure      403      {object}  apperrors.AppError
+// @Failure      404      {object}  apperrors.AppError
+// @Security     Bearer
+// @Router       /organizations/{id}/members/{user_id}/suspend [post]
+func (h *OrganizationHandler) SuspendMember(c *gin.Context) {
+	h.setMemberSuspended(c, true)
+}
+
+// UnsuspendMember restores the access of a suspended member
+// @Summary      恢复成员
+// @Description  恢复被暂停成员的访问权限（需要管理员权限）
+// @Tags         组织管理
+// @Param        id       path  string  true  "组织ID"
+// @Param        user_id  path  string  true  "用户ID"
+// @Success      200      {object}  map[string]interface{}
+// @Failure      403      {object}  apperrors.AppError
+// @Failure      404      {object}  apperrors.AppError
+// @Security     Bearer
+// @Router       /organizations/{id}/members/{user_id}/unsuspend [post]
+func (h *OrganizationHandler) UnsuspendMember(c *gin.Context) {
+	h.setMemberSuspended(c, false)
+}
+
+func (h *OrganizationHandler) setMemberSuspended(c *gin.Context, suspended bool) {
+	ctx := c.Request.Context()
+
+	orgID := c.Param("id")
+	memberUserID := c.Param("user_id")
+	operatorUserID := c.GetString(types.UserIDContextKey.String())
+
+	var err error
+	if suspended {
+		err = h.orgService.SuspendMember(ctx, orgID, memberUserID, operatorUserID)
+	} else {
+		err = h.orgService.UnsuspendMember(ctx, orgID, memberUserID, operatorUserID)
+	}
+	if err != nil {
+		logger.Errorf(ctx, "Failed to update member suspension: %v", err)
+		switch {
+		case errors.Is(err, service.ErrCannotSuspendOwner):
+			c.Error(apperrors.NewForbiddenError("Cannot suspend organization owner"))
+		case errors.Is(err, service.ErrUserNotInOrg):
+			c.Error(apperrors.NewNotFoundError("Member not found"))
+		default:
+			c.Error(apperrors.NewForbiddenError("Permission denied or invalid operation"))
+		}
+		return
+	}
+
+	message := "Member unsuspended successfully"
+	if suspended {
+		message = "Member suspended successfully"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": message,
+	})
+}
+
+// UploadAvatar uploads the avatar image of an organization (admin only)
+// @Summary      上传组织头像
+// @Description  上传组织头像图片（PNG/JPEG/GIF/WebP，不超过 2MB），存储到对象存储后通过头像接口访问（需要管理员权限）
+// @Tags         组织管理
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        id    path      string  true  "组织ID"
+// @Param        file  formData  file    true  "头像图片"
+// @Success      200   {object}  types.OrganizationResponse
+// @Failure      400   {object}  apperrors.AppError
+// @Failure      403   {object}  apperrors.AppError
+// @Security     Bearer
+// @Router       /organizations/{id}/avatar [post]
+func (h *OrganizationHandler) UploadAvatar(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	orgID := c.Param("id")
+	userID := c.GetString(types.UserIDContextKey.String())
+	tenantID := c.GetUint64(types.TenantIDContextKey.String())
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.Error(apperrors.NewBadRequestError("File upload failed").WithDetails(err.Error()))
+		return
+	}
+	if file.Size > service.MaxOrgAvatarSize {
+		c.Error(apperrors.NewValidationError(service.ErrInvalidAvatar.Error()))
+		return
+	}
+	f, err := file.Open()
+	if err != nil {
+		c.Error(apperrors.NewBadRequestError("File upload failed").WithDetails(err.Error()))
+		return
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, service.MaxOrgAvatarSize+1))
+	if err != nil {
+		c.Error(apperrors.NewBadRequestError("File upload failed").WithDetails(err.Error()))
+		return
+	}
+
+	org, err := h.orgService.UploadAvatar(ctx, orgID, userID, tenantID, data)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to upload organization avatar: %v", err)
+		switch {
+		case errors.Is(err, service.ErrOrgPermissionDenied):
+			c.Error(apperrors.NewForbiddenError("Only organization admins can change the avatar"))
+		case errors.Is(err, service.ErrOrgNotFound):
+			c.Error(apperrors.NewNotFoundError("Organization not found"))
+		case errors.Is(err, service.ErrInvalidAvatar):
+			c.Error(apperrors.NewValidationError(err.Error()))
+		default:
+			c.Error(apperrors.NewInternalServerError("Failed to upload avatar"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    h.toOrgResponse(ctx, org, userID),
+	})
+}
+
+// GetAvatar serves the uploaded avatar image of an organization
+// @Summary      获取组织头像
+// @Description  返回通过上传接口设置的组织头像图片（非成员仅可获取可搜索组织的头像）
+// @Tags         组织管理
+// @Produce      image/png,image/jpeg,image/gif,image/webp
+// @Param        id   path  string  true  "组织ID"
+// @Success      200  {file}    file
+// @Failure      404  {object}  apperrors.AppError
+// @Security     Bearer
+// @Router       /organizations/{id}/avatar [get]
+func (h *OrganizationHandler) GetAvatar(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	userID := c.GetString(types.UserIDContextKey.String())
+
+	reader, filePath, err := h.orgService.GetAvatar(ctx, c.Param("id"), userID)
+	if err != nil {
+		if errors.Is(err, service.ErrOrgNotFound) || errors.Is(err, service.ErrOrgAvatarNotFound) {
+			c.Error(apperrors.NewNotFoundError("Avatar not found"))
+			return
+		}
+		logger.Errorf(ctx, "Failed to get organization avatar: %v", err)
+		c.Error(apperrors.NewInternalServerError("Failed to get avatar"))
+		return
+	}
+	defer reader.Close()
+
+	contentType := mime.TypeByExtension(filepath.Ext(filePath))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Cache-Control", "private, max-age=3600")
+	c.Status(http.StatusOK)
+	if _, err := io.Copy(c.Writer, reader); err != nil {
+		logger.Warnf(ctx, "Failed to write organization avatar: %v", err)
+	}
+}
+
 // GenerateInviteCode generates a new invite code
 // @Summary      生成邀请码
 // @Description  生成新的组织邀请码（需要管理员权限）
@@ -486,12 +732,13 @@ func (h *OrganizationHandler) GenerateInviteCode(c *gin.Context) {
 
 // PreviewByInviteCode previews organization info by invite code (without joining)
 // @Summary      通过邀请码预览组织
-// @Description  通过邀请码获取组织基本信息（不加入）
+// @Description  通过邀请码获取组织基本信息（不加入）；detail=true 时额外返回共享的知识库和智能体名称
 // @Tags         组织管理
 // @Produce      json
-// @Param        code  path  string  true  "邀请码"
-// @Success      200   {object}  map[string]interface{}
-// @Failure      404   {object}  apperrors.AppError
+// @Param        code    path   string  true   "邀请码"
+// @Param        detail  query  bool    false  "是否返回共享资源列表"
+// @Success      200     {object}  map[string]interface{}
+// @Failure      404     {object}  apperrors.AppError
 // @Security     Bearer
 // @Router       /organizations/preview/{code} [get]
 func (h *OrganizationHandler) PreviewByInviteCode(c *gin.Context) {
@@ -507,6 +754,54 @@ func (h *OrganizationHandler) PreviewByInviteCode(c *gin.Context) {
 		return
 	}
 
+	// Holding the invite code is enough to see what is shared
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    h.buildOrgPreview(ctx, org, userID, c.Query("detail") == "true"),
+	})
+}
+
+// PreviewOrganization previews a searchable organization by ID (without joining)
+// @Summary      预览可搜索的空间
+// @Description  获取已开放可被搜索的空间的基本信息（不加入）；detail=true 时额外返回共享的知识库和智能体名称
+// @Tags         组织管理
+// @Produce      json
+// @Param        id      path   string  true   "组织ID"
+// @Param        detail  query  bool    false  "是否返回共享资源列表"
+// @Success      200     {object}  map[string]interface{}
+// @Failure      404     {object}  apperrors.AppError
+// @Security     Bearer
+// @Router       /organizations/{id}/preview [get]
+func (h *OrganizationHandler) PreviewOrganization(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	orgID := c.Param("id")
+	userID := c.GetString(types.UserIDContextKey.String())
+
+	org, err := h.orgService.GetOrganization(ctx, orgID)
+	if err != nil {
+		c.Error(apperrors.NewNotFoundError("Organization not found or not open for search"))
+		return
+	}
+	// Without an invite code only searchable organizations can be previewed, except by their members
+	if !org.Searchable {
+		if _, err := h.orgService.GetMember(ctx, org.ID, userID); err != nil {
+			c.Error(apperrors.NewNotFoundError("Organization not found or not open for search"))
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    h.buildOrgPreview(ctx, org, userID, c.Query("detail") == "true"),
+	})
+}
+
+// buildOrgPreview builds the preview of an organization shown before joining. With detail the
+// names and types of the shared knowledge bases and agents are listed, never their content.
+func (h *OrganizationHandler) buildOrgPreview(ctx context.Context,
+	org *types.Organization, userID string, detail bool,
+) gin.H {
 	// Get member count
 	members, _ := h.orgService.ListMembers(ctx, org.ID)
 	memberCount := len(members)
@@ -522,21 +817,45 @@ func (h *OrganizationHandler) PreviewByInviteCode(c *gin.Context) {
 	_, memberErr := h.orgService.GetMember(ctx, org.ID, userID)
 	isAlreadyMember := memberErr == nil
 
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data": gin.H{
-			"id":                org.ID,
-			"name":              org.Name,
-			"description":       org.Description,
-			"avatar":            org.Avatar,
-			"member_count":      memberCount,
-			"share_count":       shareCount,
-			"agent_share_count": agentShareCount,
-			"is_already_member": isAlreadyMember,
-			"require_approval":  org.RequireApproval,
-			"created_at":        org.CreatedAt,
-		},
-	})
+	preview := gin.H{
+		"id":                org.ID,
+		"name":              org.Name,
+		"description":       org.Description,
+		"avatar":            org.AvatarURL(),
+		"member_count":      memberCount,
+		"share_count":       shareCount,
+		"agent_share_count": agentShareCount,
+		"is_already_member": isAlreadyMember,
+		"require_approval":  org.RequireApproval,
+		"created_at":        org.CreatedAt,
+	}
+	if !detail {
+		return preview
+	}
+
+	knowledgeBases := make([]types.OrgPreviewKnowledgeBase, 0, len(shares))
+	for _, share := range shares {
+		if share.KnowledgeBase == nil {
+			continue
+		}
+		knowledgeBases = append(knowledgeBases, types.OrgPreviewKnowledgeBase{
+			Name: share.KnowledgeBase.Name,
+			Type: share.KnowledgeBase.Type,
+		})
+	}
+	agents := make([]types.OrgPreviewAgent, 0, len(agentShares))
+	for _, share := range agentShares {
+		if share.Agent == nil {
+			continue
+		}
+		agents = append(agents, types.OrgPreviewAgent{
+			Name:   share.Agent.Name,
+			Avatar: share.Agent.Avatar,
+		})
+	}
+	preview["knowledge_bases"] = knowledgeBases
+	preview["agents"] = agents
+	return preview
 }
 
 // JoinByInviteCode joins an organization by invite code
@@ -569,6 +888,10 @@ func (h *OrganizationHandler) JoinByInviteCode(c *gin.Context) {
 			c.Error(apperrors.NewValidationError("该空间成员已满，无法加入"))
 			return
 		}
+		if errors.Is(err, service.ErrMemberSuspended) {
+			c.Error(apperrors.NewForbiddenError("你在该空间的成员资格已被暂停，请联系管理员"))
+			return
+		}
 		c.Error(apperrors.NewNotFoundError("Invalid invite code"))
 		return
 	}
@@ -638,6 +961,10 @@ func (h *OrganizationHandler) SubmitJoinRequest(c *gin.Context) {
 			c.Error(apperrors.NewValidationError("该空间成员已满，无法提交加入申请"))
 			return
 		}
+		if errors.Is(err, service.ErrMemberSuspended) {
+			c.Error(apperrors.NewForbiddenError("你在该空间的成员资格已被暂停，请联系管理员"))
+			return
+		}
 		if err.Error() == "pending request already exists" {
 			c.Error(apperrors.NewValidationError("You have already submitted a request to join this organization"))
 			return
@@ -727,6 +1054,10 @@ func (h *OrganizationHandler) JoinByOrganizationID(c *gin.Context) {
 			c.Error(apperrors.NewValidationError("该空间成员已满，无法加入"))
 			return
 		}
+		if errors.Is(err, service.ErrMemberSuspended) {
+			c.Error(apperrors.NewForbiddenError("你在该空间的成员资格已被暂停，请联系管理员"))
+			return
+		}
 		if errors.Is(err, service.ErrInvalidRole) {
 			c.Error(apperrors.NewValidationError("Invalid role"))
 			return
@@ -842,12 +1173,13 @@ func (h *OrganizationHandler) LeaveOrganization(c *gin.Context) {
 	})
 }
 
-// ListJoinRequests lists pending join requests for an organization (admin only)
+// ListJoinRequests lists join requests for an organization (admin only), pending ones by default
 // @Summary      获取待审核加入申请列表
-// @Description  获取组织的待审核加入申请（仅管理员）
+// @Description  获取组织的加入申请（仅管理员），默认只返回待审核申请；超过有效期的申请状态为 expired
 // @Tags         组织管理
 // @Produce      json
-// @Param        id   path  string  true  "组织ID"
+// @Param        id      path   string  true   "组织ID"
+// @Param        status  query  string  false  "按状态过滤：pending（默认）、approved、rejected、cancelled、expired 或 all"
 // @Success      200  {object}  map[string]interface{}
 // @Failure      403  {object}  apperrors.AppError
 // @Security     Bearer
@@ -865,6 +1197,13 @@ func (h *OrganizationHandler) ListJoinRequests(c *gin.Context) {
 		return
 	}
 
+	// Pending requests by default for the approval UI
+	status := types.JoinRequestStatus(c.DefaultQuery("status", string(types.JoinRequestStatusPending)))
+	if status != "all" && !status.IsValid() {
+		c.Error(apperrors.NewValidationError("Invalid status"))
+		return
+	}
+
 	requests, err := h.orgService.ListJoinRequests(ctx, orgID)
 	if err != nil {
 		logger.Errorf(ctx, "Failed to list join requests: %v", err)
@@ -872,32 +1211,55 @@ func (h *OrganizationHandler) ListJoinRequests(c *gin.Context) {
 		return
 	}
 
-	// Only return pending requests for approval UI
 	resp := make([]types.JoinRequestResponse, 0)
 	for _, r := range requests {
-		if r.Status != types.JoinRequestStatusPending {
+		if status != "all" && r.Status != status {
 			continue
 		}
-		item := types.JoinRequestResponse{
-			ID:            r.ID,
-			UserID:        r.UserID,
-			Message:       r.Message,
-			RequestType:   string(r.RequestType),
-			PrevRole:      string(r.PrevRole),
-			RequestedRole: string(r.RequestedRole),
-			Status:        string(r.Status),
-			CreatedAt:     r.CreatedAt,
-			ReviewedAt:    r.ReviewedAt,
-		}
-		// Default request_type to 'join' for backward compatibility
-		if item.RequestType == "" {
-			item.RequestType = string(types.JoinRequestTypeJoin)
-		}
-		if r.User != nil {
-			item.Username = r.User.Username
-			item.Email = r.User.Email
-		}
-		resp = append(resp, item)
+		resp = append(resp, toJoinRequestResponse(r))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": types.ListJoinRequestsResponse{
+			Requests: resp,
+			Total:    int64(len(resp)),
+		},
+	})
+}
+
+// ListUpgradeRequests lists pending role upgrade requests for an organization (admin only)
+// @Summary      获取待审核权限升级申请列表
+// @Description  获取组织成员的待审核权限升级申请（仅管理员），通过审核加入申请接口审批
+// @Tags         组织管理
+// @Produce      json
+// @Param        id   path  string  true  "组织ID"
+// @Success      200  {object}  types.ListJoinRequestsResponse
+// @Failure      403  {object}  apperrors.AppError
+// @Security     Bearer
+// @Router       /organizations/{id}/upgrade-requests [get]
+func (h *OrganizationHandler) ListUpgradeRequests(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	orgID := c.Param("id")
+	userID := c.GetString(types.UserIDContextKey.String())
+
+	isAdmin, err := h.orgService.IsOrgAdmin(ctx, orgID, userID)
+	if err != nil || !isAdmin {
+		c.Error(apperrors.NewForbiddenError("Only organization admins can view upgrade requests"))
+		return
+	}
+
+	requests, err := h.orgService.ListUpgradeRequests(ctx, orgID)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to list upgrade requests: %v", err)
+		c.Error(apperrors.NewInternalServerError("Failed to list upgrade requests"))
+		return
+	}
+
+	resp := make([]types.JoinRequestResponse, 0, len(requests))
+	for _, r := range requests {
+		resp = append(resp, toJoinRequestResponse(r))
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -909,6 +1271,30 @@ func (h *OrganizationHandler) ListJoinRequests(c *gin.Context) {
 	})
 }
 
+// toJoinRequestResponse converts a join or upgrade request to its API representation
+func toJoinRequestResponse(r *types.OrganizationJoinRequest) types.JoinRequestResponse {
+	item := types.JoinRequestResponse{
+		ID:            r.ID,
+		UserID:        r.UserID,
+		Message:       r.Message,
+		RequestType:   string(r.RequestType),
+		PrevRole:      string(r.PrevRole),
+		RequestedRole: string(r.RequestedRole),
+		Status:        string(r.Status),
+		CreatedAt:     r.CreatedAt,
+		ReviewedAt:    r.ReviewedAt,
+	}
+	// Default request_type to 'join' for backward compatibility
+	if item.RequestType == "" {
+		item.RequestType = string(types.JoinRequestTypeJoin)
+	}
+	if r.User != nil {
+		item.Username = r.User.Username
+		item.Email = r.User.Email
+	}
+	return item
+}
+
 // ReviewJoinRequest approves or rejects a join request (admin only)
 // @Summary      审核加入申请
 // @Description  通过或拒绝加入申请（仅管理员）
@@ -956,10 +1342,14 @@ func (h *OrganizationHandler) ReviewJoinRequest(c *gin.Context) {
 			c.Error(apperrors.NewValidationError("空间成员已满，无法通过该加入申请"))
 			return
 		}
-		if err.Error() == "request has already been reviewed" {
+		if errors.Is(err, service.ErrJoinRequestReviewed) {
 			c.Error(apperrors.NewValidationError("Request has already been reviewed"))
 			return
 		}
+		if errors.Is(err, service.ErrJoinRequestExpired) {
+			c.Error(apperrors.NewValidationError("Request has expired"))
+			return
+		}
 		c.Error(apperrors.NewInternalServerError("Failed to review join request"))
 		return
 	}
@@ -970,38 +1360,173 @@ func (h *OrganizationHandler) ReviewJoinRequest(c *gin.Context) {
 	})
 }
 
-// ShareKnowledgeBase shares a knowledge base to an organization
-// @Summary      共享知识库到组织
-// @Description  将知识库共享到指定组织
-// @Tags         知识库共享
-// @Accept       json
+// CancelRequest withdraws the current user's pending join or upgrade request
+// @Summary      撤回加入或权限升级申请
+// @Description  撤回当前用户提交的待审核加入申请或权限升级申请，已审核的申请不能撤回
+// @Tags         组织管理
 // @Produce      json
-// @Param        id       path      string                         true  "知识库ID"
-// @Param        request  body      types.ShareKnowledgeBaseRequest  true  "共享信息"
-// @Success      201      {object}  map[string]interface{}
-// @Failure      403      {object}  apperrors.AppError
+// @Param        request_id  path      string  true  "申请ID"
+// @Success      200         {object}  map[string]interface{}
+// @Failure      404         {object}  apperrors.AppError
+// @Failure      409         {object}  apperrors.AppError
 // @Security     Bearer
-// @Router       /knowledge-bases/{id}/shares [post]
-func (h *OrganizationHandler) ShareKnowledgeBase(c *gin.Context) {
+// @Router       /organizations/requests/{request_id} [delete]
+func (h *OrganizationHandler) CancelRequest(c *gin.Context) {
 	ctx := c.Request.Context()
 
-	kbID := c.Param("id")
+	requestID := c.Param("request_id")
 	userID := c.GetString(types.UserIDContextKey.String())
-	tenantID := c.GetUint64(types.TenantIDContextKey.String())
 
-	var req types.ShareKnowledgeBaseRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.Error(apperrors.NewValidationError("Invalid request parameters").WithDetails(err.Error()))
+	if err := h.orgService.CancelRequest(ctx, requestID, userID); err != nil {
+		logger.Errorf(ctx, "Failed to cancel request: %v", err)
+		switch {
+		case errors.Is(err, service.ErrJoinRequestNotFound):
+			c.Error(apperrors.NewNotFoundError("Request not found"))
+		case errors.Is(err, service.ErrJoinRequestReviewed):
+			c.Error(apperrors.NewConflictError("Request has already been reviewed"))
+		default:
+			c.Error(apperrors.NewInternalServerError("Failed to cancel request"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Request cancelled",
+	})
+}
+
+// AcceptInvite joins the organization of an invite sent to the current user's email
+// @Summary      接受邮箱邀请
+// @Description  注册前通过邮箱收到的空间邀请需由本人接受后才会加入，邀请过期后无法接受
+// @Tags         组织管理
+// @Produce      json
+// @Param        invite_id  path      string  true  "邀请ID"
+// @Success      200        {object}  map[string]interface{}
+// @Failure      400        {object}  apperrors.AppError
+// @Failure      404        {object}  apperrors.AppError
+// @Security     Bearer
+// @Router       /organizations/invites/{invite_id}/accept [post]
+func (h *OrganizationHandler) AcceptInvite(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	user, ok := ctx.Value(types.UserContextKey).(*types.User)
+	if !ok || user == nil {
+		c.Error(apperrors.NewUnauthorizedError("Authentication required"))
+		return
+	}
+	inviteID := c.Param("invite_id")
+
+	member, err := h.orgService.AcceptPendingInvite(ctx, inviteID, user)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to accept invite: %v", err)
+		switch {
+		case errors.Is(err, service.ErrPendingInviteNotFound), errors.Is(err, service.ErrOrgNotFound):
+			c.Error(apperrors.NewNotFoundError("Invite not found"))
+		case errors.Is(err, service.ErrPendingInviteExpired):
+			c.Error(apperrors.NewValidationError("邀请已过期"))
+		case errors.Is(err, service.ErrOrgMemberLimitReached):
+			c.Error(apperrors.NewValidationError("该空间成员已满，无法加入"))
+		case errors.Is(err, service.ErrMemberSuspended):
+			c.Error(apperrors.NewForbiddenError("你在该空间的成员资格已被暂停，请联系管理员"))
+		default:
+			c.Error(apperrors.NewInternalServerError("Failed to accept invite"))
+		}
+		return
+	}
+
+	logger.Infof(ctx, "User %s accepted invite %s", secutils.SanitizeForLog(user.ID), secutils.SanitizeForLog(inviteID))
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    member,
+	})
+}
+
+// DeclineInvite deletes an invite sent to the current user's email
+// @Summary      拒绝邮箱邀请
+// @Description  拒绝注册前通过邮箱收到的空间邀请
+// @Tags         组织管理
+// @Produce      json
+// @Param        invite_id  path      string  true  "邀请ID"
+// @Success      200        {object}  map[string]interface{}
+// @Failure      404        {object}  apperrors.AppError
+// @Security     Bearer
+// @Router       /organizations/invites/{invite_id}/decline [post]
+func (h *OrganizationHandler) DeclineInvite(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	user, ok := ctx.Value(types.UserContextKey).(*types.User)
+	if !ok || user == nil {
+		c.Error(apperrors.NewUnauthorizedError("Authentication required"))
+		return
+	}
+
+	if err := h.orgService.DeclinePendingInvite(ctx, c.Param("invite_id"), user); err != nil {
+		logger.Errorf(ctx, "Failed to decline invite: %v", err)
+		if errors.Is(err, service.ErrPendingInviteNotFound) {
+			c.Error(apperrors.NewNotFoundError("Invite not found"))
+			return
+		}
+		c.Error(apperrors.NewInternalServerError("Failed to decline invite"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Invite declined",
+	})
+}
+
+// ShareKnowledgeBase shares a knowledge base to an organization
+// @Summary      共享知识库到组织
+// @Description  将知识库共享到指定组织；传入 knowledge_ids 时仅共享其中的文档（仅支持只读权限）
+// @Tags         知识库共享
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                         true  "知识库ID"
+// @Param        request  body      types.ShareKnowledgeBaseRequest  true  "共享信息"
+// @Success      201      {object}  map[string]interface{}
+// @Failure      403      {object}  apperrors.AppError
+// @Security     Bearer
+// @Router       /knowledge-bases/{id}/shares [post]
+func (h *OrganizationHandler) ShareKnowledgeBase(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	kbID := c.Param("id")
+	userID := c.GetString(types.UserIDContextKey.String())
+	tenantID := c.GetUint64(types.TenantIDContextKey.String())
+
+	var req types.ShareKnowledgeBaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.NewValidationError("Invalid request parameters").WithDetails(err.Error()))
 		return
 	}
 
-	share, err := h.shareService.ShareKnowledgeBase(ctx, kbID, req.OrganizationID, userID, tenantID, req.Permission)
+	share, err := h.shareService.ShareKnowledgeBase(ctx, kbID, req.OrganizationID, userID, tenantID, req.Permission, req.KnowledgeIDs)
 	if err != nil {
 		logger.Errorf(ctx, "Failed to share knowledge base: %v", err)
 		if errors.Is(err, service.ErrOrgRoleCannotShare) {
 			c.Error(apperrors.NewForbiddenError("Only editors and admins can share knowledge bases to this organization"))
 			return
 		}
+		if errors.Is(err, service.ErrSharePermissionExceedsRole) {
+			c.Error(apperrors.NewForbiddenError(err.Error()))
+			return
+		}
+		if errors.Is(err, service.ErrInvalidRole) {
+			c.Error(apperrors.NewValidationError(err.Error()))
+			return
+		}
+		if errors.Is(err, service.ErrPartialShareReadOnly) || errors.Is(err, service.ErrPartialShareUnsupported) ||
+			errors.Is(err, service.ErrInvalidShareKnowledge) {
+			c.Error(apperrors.NewBadRequestError(err.Error()))
+			return
+		}
+		var quotaErr *types.StorageQuotaExceededError
+		if errors.As(err, &quotaErr) {
+			c.Error(apperrors.NewForbiddenError(quotaErr.Error()))
+			return
+		}
 		c.Error(apperrors.NewForbiddenError("Permission denied or invalid operation"))
 		return
 	}
@@ -1012,12 +1537,72 @@ func (h *OrganizationHandler) ShareKnowledgeBase(c *gin.Context) {
 	})
 }
 
+// CopyKBToUser gives another user an independent copy of a knowledge base
+// @Summary      复制知识库给其他用户
+// @Description  将知识库复制到目标用户所在租户（异步任务），副本可独立编辑且不影响原知识库。仅知识库所有者可操作，目标用户需与当前用户同属某个组织
+// @Tags         知识库共享
+// @Accept       json
+// @Produce      json
+// @Param        id       path  string                     true  "知识库ID"
+// @Param        request  body  types.CopyKBToUserRequest  true  "目标用户"
+// @Success      200  {object}  types.CopyKBToUserResponse
+// @Security     Bearer
+// @Router       /knowledge-bases/{id}/copy-to-user [post]
+func (h *OrganizationHandler) CopyKBToUser(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	kbID := c.Param("id")
+	var req types.CopyKBToUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.NewValidationError("Invalid request parameters").WithDetails(err.Error()))
+		return
+	}
+
+	progress, err := h.shareService.CopyKBToUser(ctx, kbID, req.TargetUserID)
+	if err != nil {
+		var quotaErr *types.StorageQuotaExceededError
+		switch {
+		case errors.Is(err, service.ErrKBNotFound):
+			c.Error(apperrors.NewNotFoundError("Knowledge base not found"))
+		case errors.Is(err, service.ErrNotKBOwner):
+			c.Error(apperrors.NewForbiddenError("Only the knowledge base owner can copy it to another user"))
+		case errors.Is(err, service.ErrCopyTargetUserNotFound):
+			c.Error(apperrors.NewNotFoundError("Target user not found"))
+		case errors.Is(err, service.ErrCannotCopyToSelf),
+			errors.Is(err, service.ErrCopyModelUnavailable):
+			c.Error(apperrors.NewBadRequestError(err.Error()))
+		case errors.Is(err, service.ErrCopyTargetNotConnected):
+			c.Error(apperrors.NewForbiddenError(err.Error()))
+		case errors.As(err, &quotaErr):
+			c.Error(apperrors.NewForbiddenError(quotaErr.Error()))
+		default:
+			logger.Errorf(ctx, "Failed to copy knowledge base to user: %v", err)
+			c.Error(apperrors.NewInternalServerError("Failed to copy knowledge base"))
+		}
+		return
+	}
+	if err := h.knowledgeService.SaveKBCloneProgress(ctx, progress); err != nil {
+		logger.Warnf(ctx, "Failed to save initial KB clone progress: %v", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": types.CopyKBToUserResponse{
+			TaskID:   progress.TaskID,
+			SourceID: progress.SourceID,
+			TargetID: progress.TargetID,
+		},
+	})
+}
+
 // ListKBShares lists all shares for a knowledge base
 // @Summary      获取知识库的共享列表
 // @Description  获取知识库的所有共享记录
 // @Tags         知识库共享
 // @Produce      json
-// @Param        id  path  string  true  "知识库ID"
+// @Param        id         path   string  true   "知识库ID"
+// @Param        page       query  int     false  "页码，不传则返回全部"
+// @Param        page_size  query  int     false  "每页数量"
 // @Success      200  {object}  types.ListSharesResponse
 // @Security     Bearer
 // @Router       /knowledge-bases/{id}/shares [get]
@@ -1030,6 +1615,10 @@ func (h *OrganizationHandler) ListKBShares(c *gin.Context) {
 		c.Error(apperrors.NewUnauthorizedError("Unauthorized"))
 		return
 	}
+	page, ok := bindSharePagination(c)
+	if !ok {
+		return
+	}
 
 	shares, err := h.shareService.ListSharesByKnowledgeBase(ctx, kbID, tenantID)
 	if err != nil {
@@ -1045,6 +1634,8 @@ func (h *OrganizationHandler) ListKBShares(c *gin.Context) {
 		c.Error(apperrors.NewInternalServerError("Failed to list shares"))
 		return
 	}
+	total := int64(len(shares))
+	shares = pageShares(shares, page)
 
 	response := make([]types.KnowledgeBaseShareResponse, 0, len(shares))
 	for _, s := range shares {
@@ -1056,6 +1647,7 @@ func (h *OrganizationHandler) ListKBShares(c *gin.Context) {
 			SourceTenantID:  s.SourceTenantID,
 			Permission:      string(s.Permission),
 			CreatedAt:       s.CreatedAt,
+			KnowledgeIDs:    s.KnowledgeIDs,
 		}
 		if s.Organization != nil {
 			resp.OrganizationName = s.Organization.Name
@@ -1065,10 +1657,7 @@ func (h *OrganizationHandler) ListKBShares(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"data": types.ListSharesResponse{
-			Shares: response,
-			Total:  int64(len(response)),
-		},
+		"data":    newListSharesResponse(response, total, page),
 	})
 }
 
@@ -1099,6 +1688,14 @@ func (h *OrganizationHandler) UpdateSharePermission(c *gin.Context) {
 
 	if err := h.shareService.UpdateSharePermission(ctx, shareID, req.Permission, userID); err != nil {
 		logger.Errorf(ctx, "Failed to update share permission: %v", err)
+		if errors.Is(err, service.ErrPartialShareReadOnly) {
+			c.Error(apperrors.NewBadRequestError(err.Error()))
+			return
+		}
+		if errors.Is(err, service.ErrSharePermissionExceedsRole) {
+			c.Error(apperrors.NewForbiddenError(err.Error()))
+			return
+		}
 		c.Error(apperrors.NewForbiddenError("Permission denied"))
 		return
 	}
@@ -1137,12 +1734,52 @@ func (h *OrganizationHandler) RemoveShare(c *gin.Context) {
 	})
 }
 
+// GetOrgStorage returns the storage used by the knowledge bases shared to an organization
+// @Summary      获取组织存储用量
+// @Description  获取共享到组织的知识库占用的存储（文件与索引），结果缓存数分钟；达到配额后将无法继续共享知识库或向已共享的知识库上传文件
+// @Tags         组织管理
+// @Produce      json
+// @Param        id   path      string  true  "组织ID"
+// @Success      200  {object}  types.OrgStorageUsage
+// @Failure      403  {object}  apperrors.AppError
+// @Security     Bearer
+// @Router       /organizations/{id}/storage [get]
+func (h *OrganizationHandler) GetOrgStorage(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	orgID := c.Param("id")
+	userID := c.GetString(types.UserIDContextKey.String())
+
+	if _, err := h.orgService.GetMember(ctx, orgID, userID); err != nil {
+		c.Error(apperrors.NewForbiddenError("You are not a member of this organization"))
+		return
+	}
+
+	usage, err := h.shareService.GetOrgStorageUsage(ctx, orgID)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to get organization storage usage: %v", err)
+		if errors.Is(err, service.ErrOrgNotFound) {
+			c.Error(apperrors.NewNotFoundError("Organization not found"))
+			return
+		}
+		c.Error(apperrors.NewInternalServerError("Failed to get storage usage"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    usage,
+	})
+}
+
 // ListOrgShares lists all knowledge bases shared to a specific organization
 // @Summary      获取组织的共享知识库列表
 // @Description  获取共享到指定组织的所有知识库
 // @Tags         组织管理
 // @Produce      json
-// @Param        id  path  string  true  "组织ID"
+// @Param        id         path   string  true   "组织ID"
+// @Param        page       query  int     false  "页码，不传则返回全部"
+// @Param        page_size  query  int     false  "每页数量"
 // @Success      200  {object}  types.ListSharesResponse
 // @Security     Bearer
 // @Router       /organizations/{id}/shares [get]
@@ -1151,6 +1788,10 @@ func (h *OrganizationHandler) ListOrgShares(c *gin.Context) {
 
 	orgID := c.Param("id")
 	userID := c.GetString(types.UserIDContextKey.String())
+	page, ok := bindSharePagination(c)
+	if !ok {
+		return
+	}
 
 	// Check if user is a member and get their role for effective-permission calculation
 	member, err := h.orgService.GetMember(ctx, orgID, userID)
@@ -1166,6 +1807,39 @@ func (h *OrganizationHandler) ListOrgShares(c *gin.Context) {
 		c.Error(apperrors.NewInternalServerError("Failed to list shares"))
 		return
 	}
+	total := int64(len(shares))
+	shares = pageShares(shares, page)
+
+	// Load the counts and sharer names of the page in one query each instead of per share
+	kbIDs := make([]string, 0, len(shares))
+	fullKBIDs := make([]string, 0, len(shares))
+	userIDs := make([]string, 0, len(shares))
+	for _, s := range shares {
+		userIDs = append(userIDs, s.SharedByUserID)
+		if s.KnowledgeBase == nil {
+			continue
+		}
+		kbIDs = append(kbIDs, s.KnowledgeBaseID)
+		if !s.IsPartial() {
+			fullKBIDs = append(fullKBIDs, s.KnowledgeBaseID)
+		}
+	}
+	knowledgeCounts, err := h.knowledgeRepo.CountKnowledgeByKnowledgeBaseIDs(ctx, fullKBIDs)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to count knowledge of shared knowledge bases: %v", err)
+	}
+	chunkCounts, err := h.chunkRepo.CountChunksByKnowledgeBaseIDs(ctx, kbIDs)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to count chunks of shared knowledge bases: %v", err)
+	}
+	usernames := make(map[string]string, len(userIDs))
+	if users, err := h.userService.GetUsersByIDs(ctx, userIDs); err != nil {
+		logger.Warnf(ctx, "Failed to get share owners: %v", err)
+	} else {
+		for _, user := range users {
+			usernames[user.ID] = user.Username
+		}
+	}
 
 	response := make([]types.KnowledgeBaseShareResponse, 0, len(shares))
 	for _, s := range shares {
@@ -1184,32 +1858,179 @@ func (h *OrganizationHandler) ListOrgShares(c *gin.Context) {
 			MyRoleInOrg:     string(myRoleInOrg),
 			MyPermission:    string(effectivePerm),
 			CreatedAt:       s.CreatedAt,
+			DynamicShareID:  s.DynamicShareID,
+			KnowledgeIDs:    s.KnowledgeIDs,
 		}
 		if s.KnowledgeBase != nil {
 			resp.KnowledgeBaseName = s.KnowledgeBase.Name
 			resp.KnowledgeBaseType = s.KnowledgeBase.Type
-			// Get knowledge count for document type
-			if count, err := h.knowledgeRepo.CountKnowledgeByKnowledgeBaseID(ctx, s.SourceTenantID, s.KnowledgeBaseID); err == nil {
-				resp.KnowledgeCount = count
-			}
-			// Get chunk count for FAQ type
-			if count, err := h.chunkRepo.CountChunksByKnowledgeBaseID(ctx, s.SourceTenantID, s.KnowledgeBaseID); err == nil {
-				resp.ChunkCount = count
+			// Knowledge count for document type (a partial share only exposes its documents)
+			if s.IsPartial() {
+				resp.KnowledgeCount = int64(len(s.KnowledgeIDs))
+			} else {
+				resp.KnowledgeCount = knowledgeCounts[s.KnowledgeBaseID]
 			}
+			// Chunk count for FAQ type
+			resp.ChunkCount = chunkCounts[s.KnowledgeBaseID]
 		}
-		// Get shared by user info
-		if user, err := h.userService.GetUserByID(ctx, s.SharedByUserID); err == nil && user != nil {
-			resp.SharedByUsername = user.Username
-		}
+		resp.SharedByUsername = usernames[s.SharedByUserID]
 		response = append(response, resp)
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"data": types.ListSharesResponse{
-			Shares: response,
-			Total:  int64(len(response)),
-		},
+		"data":    newListSharesResponse(response, total, page),
+	})
+}
+
+// bindSharePagination binds the optional page and page_size query parameters of the share
+// listings. It returns a nil pagination when neither is given, so all shares are listed.
+func bindSharePagination(c *gin.Context) (*types.Pagination, bool) {
+	if c.Query("page") == "" && c.Query("page_size") == "" {
+		return nil, true
+	}
+	var page types.Pagination
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.Error(apperrors.NewBadRequestError("分页参数不合法").WithDetails(err.Error()))
+		return nil, false
+	}
+	return &page, true
+}
+
+// pageShares returns the shares of the requested page, or all shares when page is nil
+func pageShares(shares []*types.KnowledgeBaseShare, page *types.Pagination) []*types.KnowledgeBaseShare {
+	if page == nil {
+		return shares
+	}
+	start := min(page.Offset(), len(shares))
+	end := min(start+page.Limit(), len(shares))
+	return shares[start:end]
+}
+
+// newListSharesResponse builds a share listing response; total counts all shares, not only the page
+func newListSharesResponse(shares []types.KnowledgeBaseShareResponse, total int64,
+	page *types.Pagination,
+) types.ListSharesResponse {
+	resp := types.ListSharesResponse{Shares: shares, Total: total}
+	if page != nil {
+		resp.Page = page.GetPage()
+		resp.PageSize = page.GetPageSize()
+	}
+	return resp
+}
+
+// CreateDynamicShare shares every knowledge base with a share label to an organization
+// @Summary      创建动态共享规则
+// @Description  将当前租户下共享标签匹配的知识库共享到组织，之后设置该共享标签的知识库自动可见。仅组织管理员可创建
+// @Tags         组织管理
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                           true  "组织ID"
+// @Param        request  body      types.CreateDynamicShareRequest  true  "规则信息"
+// @Success      201      {object}  map[string]interface{}
+// @Failure      403      {object}  apperrors.AppError
+// @Security     Bearer
+// @Router       /organizations/{id}/dynamic-shares [post]
+func (h *OrganizationHandler) CreateDynamicShare(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	orgID := c.Param("id")
+	userID := c.GetString(types.UserIDContextKey.String())
+	tenantID := c.GetUint64(types.TenantIDContextKey.String())
+
+	var req types.CreateDynamicShareRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.NewValidationError("Invalid request parameters").WithDetails(err.Error()))
+		return
+	}
+
+	rule, err := h.shareService.CreateDynamicShare(ctx, orgID, req.Criteria, userID, tenantID, req.Permission)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to create dynamic share: %v", err)
+		switch {
+		case errors.Is(err, service.ErrInvalidShareCriteria), errors.Is(err, service.ErrInvalidRole):
+			c.Error(apperrors.NewBadRequestError(err.Error()))
+		case errors.Is(err, service.ErrOrgNotFound):
+			c.Error(apperrors.NewNotFoundError("Organization not found"))
+		case errors.Is(err, service.ErrDynamicShareAdminOnly):
+			c.Error(apperrors.NewForbiddenError("Only organization admins can create dynamic shares"))
+		case errors.Is(err, service.ErrUserNotInOrg):
+			c.Error(apperrors.NewForbiddenError("You are not a member of this organization"))
+		default:
+			c.Error(apperrors.NewInternalServerError("Failed to create dynamic share"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    rule,
+	})
+}
+
+// ListDynamicShares lists the dynamic share rules of an organization
+// @Summary      获取组织的动态共享规则
+// @Description  获取组织的所有动态共享规则（不含静态共享记录）
+// @Tags         组织管理
+// @Produce      json
+// @Param        id  path  string  true  "组织ID"
+// @Success      200  {object}  map[string]interface{}
+// @Security     Bearer
+// @Router       /organizations/{id}/dynamic-shares [get]
+func (h *OrganizationHandler) ListDynamicShares(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	orgID := c.Param("id")
+	userID := c.GetString(types.UserIDContextKey.String())
+
+	if _, err := h.orgService.GetMember(ctx, orgID, userID); err != nil {
+		c.Error(apperrors.NewForbiddenError("You are not a member of this organization"))
+		return
+	}
+
+	rules, err := h.shareService.ListDynamicShares(ctx, orgID)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to list dynamic shares: %v", err)
+		c.Error(apperrors.NewInternalServerError("Failed to list dynamic shares"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    rules,
+	})
+}
+
+// RemoveDynamicShare removes a dynamic share rule
+// @Summary      删除动态共享规则
+// @Description  删除动态共享规则，规则匹配的知识库不再共享到组织（静态共享不受影响）
+// @Tags         组织管理
+// @Param        id       path  string  true  "组织ID"
+// @Param        rule_id  path  string  true  "规则ID"
+// @Success      200      {object}  map[string]interface{}
+// @Failure      403      {object}  apperrors.AppError
+// @Security     Bearer
+// @Router       /organizations/{id}/dynamic-shares/{rule_id} [delete]
+func (h *OrganizationHandler) RemoveDynamicShare(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	orgID := c.Param("id")
+	ruleID := c.Param("rule_id")
+	userID := c.GetString(types.UserIDContextKey.String())
+
+	if err := h.shareService.RemoveDynamicShare(ctx, orgID, ruleID, userID); err != nil {
+		logger.Errorf(ctx, "Failed to remove dynamic share: %v", err)
+		if errors.Is(err, service.ErrDynamicShareNotFound) {
+			c.Error(apperrors.NewNotFoundError("Dynamic share not found"))
+			return
+		}
+		c.Error(apperrors.NewForbiddenError("Permission denied"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Dynamic share removed successfully",
 	})
 }
 
@@ -1606,12 +2427,15 @@ func (h *OrganizationHandler) toOrgResponse(ctx context.Context, org *types.Orga
 		ID:                     org.ID,
 		Name:                   org.Name,
 		Description:            org.Description,
-		Avatar:                 org.Avatar,
+		Avatar:                 org.AvatarURL(),
 		OwnerID:                org.OwnerID,
 		IsOwner:                org.OwnerID == currentUserID,
 		RequireApproval:        org.RequireApproval,
 		Searchable:             org.Searchable,
 		MemberLimit:            org.MemberLimit,
+		DefaultSharePermission: string(org.GetDefaultSharePermission()),
+		JoinRequestTTLDays:     org.JoinRequestTTLDays,
+		StorageQuota:           org.StorageQuota,
 		InviteCodeValidityDays: org.InviteCodeValidityDays,
 		CreatedAt:              org.CreatedAt,
 		UpdatedAt:              org.UpdatedAt,
@@ -1792,6 +2616,10 @@ func (h *OrganizationHandler) InviteMember(c *gin.Context) {
 			c.Error(apperrors.NewValidationError("该空间成员已满，无法添加新成员"))
 			return
 		}
+		if errors.Is(err, service.ErrMemberSuspended) {
+			c.Error(apperrors.NewConflictError("该用户的成员资格已被暂停，请恢复后再操作"))
+			return
+		}
 		c.Error(apperrors.NewInternalServerError("Failed to add member"))
 		return
 	}
@@ -1807,3 +2635,64 @@ func (h *OrganizationHandler) InviteMember(c *gin.Context) {
 		"message": "Member added successfully",
 	})
 }
+
+// BulkInviteMembers invites several users to organization by email
+// @Summary      批量邀请成员
+// @Description  管理员按邮箱批量邀请成员：已注册用户直接加入，未注册邮箱记录为待领取邀请，用户注册后自动加入；已是成员的用户会被跳过
+// @Tags         组织管理
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                          true  "组织ID"
+// @Param        request  body      types.BulkInviteMembersRequest  true  "邀请信息"
+// @Success      200      {object}  map[string]interface{}
+// @Failure      400      {object}  apperrors.AppError
+// @Failure      403      {object}  apperrors.AppError
+// @Security     Bearer
+// @Router       /organizations/{id}/invite-bulk [post]
+func (h *OrganizationHandler) BulkInviteMembers(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	orgID := c.Param("id")
+	userID := c.GetString(types.UserIDContextKey.String())
+
+	// Check admin permission
+	isAdmin, err := h.orgService.IsOrgAdmin(ctx, orgID, userID)
+	if err != nil || !isAdmin {
+		c.Error(apperrors.NewForbiddenError("Only organization admins can invite members"))
+		return
+	}
+
+	var req types.BulkInviteMembersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.NewValidationError("Invalid request parameters").WithDetails(err.Error()))
+		return
+	}
+
+	// Validate role
+	if !req.Role.IsValid() {
+		c.Error(apperrors.NewValidationError("Invalid role; must be viewer, editor, or admin"))
+		return
+	}
+
+	results, err := h.orgService.BulkInviteMembers(ctx, orgID, userID, req.Emails, req.Role)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to bulk invite members: %v", err)
+		switch {
+		case errors.Is(err, service.ErrOrgPermissionDenied):
+			c.Error(apperrors.NewForbiddenError("Only organization admins can invite members"))
+		case errors.Is(err, service.ErrTooManyInviteEmails):
+			c.Error(apperrors.NewValidationError(
+				fmt.Sprintf("At most %d emails can be invited at once", types.MaxBulkInviteEmails)))
+		case errors.Is(err, service.ErrOrgNotFound):
+			c.Error(apperrors.NewNotFoundError("Organization not found"))
+		default:
+			c.Error(apperrors.NewInternalServerError("Failed to invite members"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    results,
+	})
+}
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/gin-gonic/gin"
+)
+
+// queryCounter counts the repository and service calls made by a handler
+type queryCounter struct {
+	queries int
+}
+
+type countingOrgService struct {
+	interfaces.OrganizationService
+}
+
+func (s *countingOrgService) GetMember(ctx context.Context, orgID, userID string) (*types.OrganizationMember, error) {
+	return &types.OrganizationMember{OrganizationID: orgID, UserID: userID, Role: types.OrgRoleViewer}, nil
+}
+
+type countingShareService struct {
+	interfaces.KBShareService
+	shares []*types.KnowledgeBaseShare
+}
+
+func (s *countingShareService) ListSharesByOrganization(ctx context.Context,
+	orgID string,
+) ([]*types.KnowledgeBaseShare, error) {
+	return s.shares, nil
+}
+
+type countingKnowledgeRepo struct {
+	interfaces.KnowledgeRepository
+	counter *queryCounter
+}
+
+func (r *countingKnowledgeRepo) CountKnowledgeByKnowledgeBaseIDs(ctx context.Context,
+	kbIDs []string,
+) (map[string]int64, error) {
+	r.counter.queries++
+	counts := make(map[string]int64, len(kbIDs))
+	for _, id := range kbIDs {
+		counts[id] = 2
+	}
+	return counts, nil
+}
+
+type countingChunkRepo struct {
+	interfaces.ChunkRepository
+	counter *queryCounter
+}
+
+func (r *countingChunkRepo) CountChunksByKnowledgeBaseIDs(ctx context.Context,
+	kbIDs []string,
+) (map[string]int64, error) {
+	r.counter.queries++
+	counts := make(map[string]int64, len(kbIDs))
+	for _, id := range kbIDs {
+		counts[id] = 5
+	}
+	return counts, nil
+}
+
+type countingUserService struct {
+	interfaces.UserService
+	counter *queryCounter
+}
+
+func (s *countingUserService) GetUsersByIDs(ctx context.Context, ids []string) ([]*types.User, error) {
+	s.counter.queries++
+	users := make([]*types.User, 0, len(ids))
+	for _, id := range ids {
+		users = append(users, &types.User{ID: id, Username: "user-" + id})
+	}
+	return users, nil
+}
+
+// BenchmarkListOrgShares reports the lookups made per request, which stay constant however many
+// knowledge bases are shared to the organization
+func BenchmarkListOrgShares(b *testing.B) {
+	gin.SetMode(gin.TestMode)
+	for _, n := range []int{10, 100, 1000} {
+		b.Run(fmt.Sprintf("shares=%d", n), func(b *testing.B) {
+			shares := make([]*types.KnowledgeBaseShare, 0, n)
+			for i := 0; i < n; i++ {
+				kbID := fmt.Sprintf("kb-%d", i)
+				shares = append(shares, &types.KnowledgeBaseShare{
+					ID:              fmt.Sprintf("kbs-%d", i),
+					KnowledgeBaseID: kbID,
+					SharedByUserID:  fmt.Sprintf("u-%d", i%10),
+					SourceTenantID:  uint64(i%3 + 1),
+					Permission:      types.OrgRoleEditor,
+					KnowledgeBase:   &types.KnowledgeBase{ID: kbID, Name: kbID},
+				})
+			}
+			counter := &queryCounter{}
+			h := NewOrganizationHandler(&countingOrgService{}, &countingShareService{shares: shares}, nil, nil,
+				&countingUserService{counter: counter}, nil,
+				&countingKnowledgeRepo{counter: counter}, &countingChunkRepo{counter: counter}, nil)
+			router := gin.New()
+			router.GET("/organizations/:id/shares", h.ListOrgShares)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				w := httptest.NewRecorder()
+				req := httptest.NewRequest(http.MethodGet, "/organizations/org-1/shares", nil)
+				router.ServeHTTP(w, req)
+				if w.Code != http.StatusOK {
+					b.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+				}
+			}
+			b.ReportMetric(float64(counter.queries)/float64(b.N), "queries/op")
+		})
+	}
+}
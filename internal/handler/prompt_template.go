@@ -0,0 +1,207 @@
+package handler
+
+import (
+	stderrors "errors"
+	"net/http"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	"github.com/Tencent/WeKnora/internal/application/service"
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// PromptTemplateHandler defines the HTTP handler for the tenant prompt library
+type PromptTemplateHandler struct {
+	service interfaces.PromptTemplateService
+}
+
+// NewPromptTemplateHandler creates a new prompt template handler instance
+func NewPromptTemplateHandler(service interfaces.PromptTemplateService) *PromptTemplateHandler {
+	return &PromptTemplateHandler{service: service}
+}
+
+// PromptTemplateRequest defines the request body for creating or updating a prompt template
+type PromptTemplateRequest struct {
+	Name        string `json:"name"        binding:"required"`
+	Description string `json:"description"`
+	Content     string `json:"content"     binding:"required"`
+}
+
+// CreateTemplate godoc
+// @Summary      创建提示词模板
+// @Description  在租户提示词库中创建模板，智能体可通过 system_prompt_ref 引用
+// @Tags         提示词模板
+// @Accept       json
+// @Produce      json
+// @Param        request  body      PromptTemplateRequest   true  "模板信息"
+// @Success      201      {object}  map[string]interface{}  "创建的模板"
+// @Failure      400      {object}  errors.AppError         "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /prompt-templates [post]
+func (h *PromptTemplateHandler) CreateTemplate(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req PromptTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(errors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
+		return
+	}
+
+	template, err := h.service.CreateTemplate(ctx, &types.PromptTemplate{
+		Name:        req.Name,
+		Description: req.Description,
+		Content:     req.Content,
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    template,
+	})
+}
+
+// ListTemplates godoc
+// @Summary      获取提示词模板列表
+// @Description  获取当前租户提示词库中的所有模板
+// @Tags         提示词模板
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "模板列表"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /prompt-templates [get]
+func (h *PromptTemplateHandler) ListTemplates(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	templates, err := h.service.ListTemplates(ctx)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    templates,
+	})
+}
+
+// GetTemplate godoc
+// @Summary      获取提示词模板详情
+// @Description  根据ID获取提示词模板
+// @Tags         提示词模板
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "模板ID"
+// @Success      200  {object}  map[string]interface{}  "模板详情"
+// @Failure      404  {object}  errors.AppError         "模板不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /prompt-templates/{id} [get]
+func (h *PromptTemplateHandler) GetTemplate(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id := secutils.SanitizeForLog(c.Param("id"))
+	template, err := h.service.GetTemplate(ctx, id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    template,
+	})
+}
+
+// UpdateTemplate godoc
+// @Summary      更新提示词模板
+// @Description  更新提示词模板，引用该模板的智能体在下次请求时使用新内容
+// @Tags         提示词模板
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                  true  "模板ID"
+// @Param        request  body      PromptTemplateRequest   true  "模板信息"
+// @Success      200      {object}  map[string]interface{}  "更新后的模板"
+// @Failure      400      {object}  errors.AppError         "请求参数错误"
+// @Failure      404      {object}  errors.AppError         "模板不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /prompt-templates/{id} [put]
+func (h *PromptTemplateHandler) UpdateTemplate(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id := secutils.SanitizeForLog(c.Param("id"))
+	var req PromptTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(errors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
+		return
+	}
+
+	template, err := h.service.UpdateTemplate(ctx, &types.PromptTemplate{
+		ID:          id,
+		Name:        req.Name,
+		Description: req.Description,
+		Content:     req.Content,
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    template,
+	})
+}
+
+// DeleteTemplate godoc
+// @Summary      删除提示词模板
+// @Description  删除提示词模板，仍引用该模板的智能体将回退到内联系统提示词
+// @Tags         提示词模板
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "模板ID"
+// @Success      200  {object}  map[string]interface{}  "删除成功"
+// @Failure      404  {object}  errors.AppError         "模板不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /prompt-templates/{id} [delete]
+func (h *PromptTemplateHandler) DeleteTemplate(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id := secutils.SanitizeForLog(c.Param("id"))
+	if err := h.service.DeleteTemplate(ctx, id); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Prompt template deleted successfully",
+	})
+}
+
+// handleError maps prompt template service errors to HTTP errors
+func (h *PromptTemplateHandler) handleError(c *gin.Context, err error) {
+	logger.ErrorWithFields(c.Request.Context(), err, nil)
+	switch {
+	case stderrors.Is(err, repository.ErrPromptTemplateNotFound):
+		c.Error(errors.NewNotFoundError(err.Error()))
+	case stderrors.Is(err, service.ErrPromptTemplateNameRequired),
+		stderrors.Is(err, service.ErrPromptTemplateContentEmpty),
+		stderrors.Is(err, service.ErrInvalidTenantID):
+		c.Error(errors.NewBadRequestError(err.Error()))
+	default:
+		c.Error(errors.NewInternalServerError(err.Error()))
+	}
+}
@@ -30,6 +30,10 @@ type AgentStreamHandler struct {
 	finalAnswer     string
 	eventStartTimes map[string]time.Time // Track start time for duration calculation
 	mu              sync.Mutex
+
+	// Agent mode only: persists the answer while it streams (normal mode saves it in its own handler)
+	partialSaver   *partialAnswerSaver
+	partialSaveCtx context.Context
 }
 
 // NewAgentStreamHandler creates a new handler for agent SSE streaming
@@ -63,11 +67,29 @@ func (h *AgentStreamHandler) Subscribe() {
 	h.eventBus.On(event.EventAgentReferences, h.handleReferences)
 	h.eventBus.On(event.EventAgentFinalAnswer, h.handleFinalAnswer)
 	h.eventBus.On(event.EventAgentReflection, h.handleReflection)
+	h.eventBus.On(event.EventAgentBudgetExceeded, h.handleBudgetExceeded)
 	h.eventBus.On(event.EventError, h.handleError)
 	h.eventBus.On(event.EventSessionTitle, h.handleSessionTitle)
 	h.eventBus.On(event.EventAgentComplete, h.handleComplete)
 }
 
+// savePartialAnswers makes the handler mirror the streamed answer into the assistant message and
+// save it periodically, so an interrupted agent answer can be recovered
+func (h *AgentStreamHandler) savePartialAnswers(ctx context.Context, saver *partialAnswerSaver) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.partialSaver = saver
+	h.partialSaveCtx = ctx
+}
+
+// withMessage runs fn while holding the lock that guards the assistant message, so handlers
+// registered outside the stream handler do not race its own updates to the message
+func (h *AgentStreamHandler) withMessage(fn func(message *types.Message)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	fn(h.assistantMessage)
+}
+
 // handleThought handles agent thought events
 func (h *AgentStreamHandler) handleThought(ctx context.Context, evt event.Event) error {
 	data, ok := evt.Data.(event.AgentThoughtData)
@@ -239,6 +261,13 @@ func (h *AgentStreamHandler) handleReferences(ctx context.Context, evt event.Eve
 					KnowledgeTitle:  getString(refMap, "knowledge_title"),
 					ChunkIndex:      int(getFloat64(refMap, "chunk_index")),
 					KnowledgeBaseID: getString(refMap, "knowledge_base_id"),
+					// Keep enough source metadata to re-render citation links after reload
+					KnowledgeFilename: getString(refMap, "knowledge_filename"),
+					KnowledgeSource:   getString(refMap, "knowledge_source"),
+					ChunkType:         getString(refMap, "chunk_type"),
+					ParentChunkID:     getString(refMap, "parent_chunk_id"),
+					StartAt:           int(getFloat64(refMap, "start_at")),
+					EndAt:             int(getFloat64(refMap, "end_at")),
 				}
 
 				if meta, ok := refMap["metadata"].(map[string]interface{}); ok {
@@ -294,6 +323,10 @@ func (h *AgentStreamHandler) handleFinalAnswer(ctx context.Context, evt event.Ev
 	if data.IsFallback {
 		h.assistantMessage.IsFallback = true
 	}
+	if h.partialSaver != nil && !data.Done && !h.assistantMessage.IsCompleted {
+		h.assistantMessage.Content = h.finalAnswer
+		h.partialSaver.Observe(h.partialSaveCtx)
+	}
 
 	// Calculate duration if done
 	var metadata map[string]interface{}
@@ -352,6 +385,30 @@ func (h *AgentStreamHandler) handleReflection(ctx context.Context, evt event.Eve
 	return nil
 }
 
+// handleBudgetExceeded notifies the client that the agent hit its iteration limit
+func (h *AgentStreamHandler) handleBudgetExceeded(ctx context.Context, evt event.Event) error {
+	data, ok := evt.Data.(event.AgentBudgetExceededData)
+	if !ok {
+		return nil
+	}
+
+	if err := h.streamManager.AppendEvent(h.ctx, h.sessionID, h.assistantMessageID, interfaces.StreamEvent{
+		ID:        evt.ID,
+		Type:      types.ResponseTypeBudgetExceeded,
+		Content:   "",
+		Done:      true,
+		Timestamp: time.Now(),
+		Data: map[string]interface{}{
+			"iterations":     data.Iterations,
+			"max_iterations": data.MaxIterations,
+		},
+	}); err != nil {
+		logger.GetLogger(h.ctx).Error("Append budget exceeded event to stream failed", "error", err)
+	}
+
+	return nil
+}
+
 // handleError handles error events
 func (h *AgentStreamHandler) handleError(ctx context.Context, evt event.Event) error {
 	data, ok := evt.Data.(event.ErrorData)
@@ -420,7 +477,6 @@ func (h *AgentStreamHandler) handleComplete(ctx context.Context, evt event.Event
 
 	// Update assistant message with final data
 	if data.MessageID == h.assistantMessageID {
-		// h.assistantMessage.Content = data.FinalAnswer
 		h.assistantMessage.IsCompleted = true
 		h.assistantMessage.AgentDurationMs = data.TotalDurationMs
 
@@ -435,7 +491,8 @@ func (h *AgentStreamHandler) handleComplete(ctx context.Context, evt event.Event
 			h.assistantMessage.KnowledgeReferences = knowledgeRefs
 		}
 
-		h.assistantMessage.Content += data.FinalAnswer
+		// Replaces the streamed answer mirrored for partial saves
+		h.assistantMessage.Content = data.FinalAnswer
 
 		// Update agent steps if provided
 		if data.AgentSteps != nil {
@@ -455,6 +512,7 @@ func (h *AgentStreamHandler) handleComplete(ctx context.Context, evt event.Event
 		Data: map[string]interface{}{
 			"total_steps":       data.TotalSteps,
 			"total_duration_ms": data.TotalDurationMs,
+			"budget_exceeded":   data.BudgetExceeded,
 		},
 	}); err != nil {
 		logger.GetLogger(h.ctx).Errorf("Append complete event to stream failed: %v", err)
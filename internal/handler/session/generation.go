@@ -0,0 +1,138 @@
+package session
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/application/service"
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/event"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/gin-gonic/gin"
+)
+
+// trackGeneration registers the stream in the active generation registry until it completes,
+// fails or is stopped. Cancelling it behaves like a user stop request.
+func (h *Handler) trackGeneration(reqCtx *qaRequestContext, streamCtx *sseStreamContext, mode string) {
+	sessionID := reqCtx.sessionID
+	messageID := reqCtx.assistantMessage.ID
+
+	h.sessionService.StartGeneration(reqCtx.ctx, &types.ActiveGeneration{
+		SessionID: sessionID,
+		MessageID: messageID,
+		TenantID:  reqCtx.session.TenantID,
+		Mode:      mode,
+		StartedAt: time.Now(),
+	}, func() {
+		// Notify a connected SSE client through the stream, and stop directly in case none is polling
+		stopEvent := interfaces.StreamEvent{
+			ID:        fmt.Sprintf("stop-%d", time.Now().UnixNano()),
+			Type:      types.ResponseType(event.EventStop),
+			Done:      true,
+			Timestamp: time.Now(),
+			Data: map[string]interface{}{
+				"session_id": sessionID,
+				"message_id": messageID,
+				"reason":     "admin_cancelled",
+			},
+		}
+		if err := h.streamManager.AppendEvent(streamCtx.asyncCtx, sessionID, messageID, stopEvent); err != nil {
+			logger.Warnf(streamCtx.asyncCtx, "Failed to write stop event for message %s: %v", messageID, err)
+		}
+		streamCtx.eventBus.Emit(streamCtx.asyncCtx, event.Event{
+			Type:      event.EventStop,
+			SessionID: sessionID,
+			Data: event.StopData{
+				SessionID: sessionID,
+				MessageID: messageID,
+				Reason:    "admin_cancelled",
+			},
+		})
+	})
+
+	finish := func(ctx context.Context, evt event.Event) error {
+		h.sessionService.FinishGeneration(sessionID, messageID)
+		return nil
+	}
+	streamCtx.eventBus.On(event.EventAgentComplete, finish)
+	streamCtx.eventBus.On(event.EventError, finish)
+	streamCtx.eventBus.On(event.EventStop, finish)
+}
+
+// ListActiveGenerations godoc
+// @Summary      列出进行中的生成
+// @Description  列出所有实例上正在流式生成的回答（会话ID、消息ID、开始时间），仅系统管理员可用
+// @Tags         系统
+// @Produce      json
+// @Param        tenant_id  query     int  false  "租户ID（为空时返回所有租户）"
+// @Success      200        {object}  map[string]interface{}  "进行中的生成列表"
+// @Failure      403        {object}  map[string]interface{}  "权限不足"
+// @Security     Bearer
+// @Router       /admin/active-generations [get]
+func (h *Handler) ListActiveGenerations(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var tenantID uint64
+	if raw := c.Query("tenant_id"); raw != "" {
+		parsed, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			c.Error(errors.NewBadRequestError("invalid tenant_id"))
+			return
+		}
+		tenantID = parsed
+	}
+
+	generations, err := h.sessionService.ListActiveGenerations(ctx, tenantID)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    generations,
+	})
+}
+
+// CancelGeneration godoc
+// @Summary      取消进行中的生成
+// @Description  按助手消息ID停止正在流式生成的回答，效果与用户停止相同，仅系统管理员可用
+// @Tags         系统
+// @Produce      json
+// @Param        id   path      string  true  "助手消息ID"
+// @Success      200  {object}  map[string]interface{}  "被取消的生成"
+// @Failure      403  {object}  map[string]interface{}  "权限不足"
+// @Failure      404  {object}  errors.AppError         "生成不存在或已结束"
+// @Security     Bearer
+// @Router       /admin/generations/{id}/cancel [post]
+func (h *Handler) CancelGeneration(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id := c.Param("id")
+	if id == "" {
+		c.Error(errors.NewBadRequestError("generation id is required"))
+		return
+	}
+
+	generation, err := h.sessionService.CancelGeneration(ctx, id)
+	if err != nil {
+		if stderrors.Is(err, service.ErrGenerationNotFound) {
+			c.Error(errors.NewNotFoundError("generation not found or already finished"))
+			return
+		}
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    generation,
+	})
+}
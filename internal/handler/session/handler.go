@@ -1,8 +1,13 @@
 package session
 
 import (
+	stderrors "errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"github.com/Tencent/WeKnora/internal/application/service"
 	"github.com/Tencent/WeKnora/internal/config"
 	"github.com/Tencent/WeKnora/internal/errors"
 	"github.com/Tencent/WeKnora/internal/logger"
@@ -10,6 +15,7 @@ import (
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 	secutils "github.com/Tencent/WeKnora/internal/utils"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // Handler handles all HTTP requests related to conversation sessions
@@ -86,10 +92,16 @@ func (h *Handler) CreateSession(c *gin.Context) {
 		tenantID,
 	)
 
+	if request.AgentID != "" && !h.canAccessAgent(c, request.AgentID) {
+		c.Error(errors.NewNotFoundError("Agent not found"))
+		return
+	}
+
 	// Create session object with base properties
 	createdSession := &types.Session{
 		TenantID:       tenantID.(uint64),
 		ExternalUserId: request.ExternalUserId,
+		AgentID:        request.AgentID,
 		Title:          request.Title,
 		Description:    request.Description,
 	}
@@ -160,14 +172,16 @@ func (h *Handler) GetSession(c *gin.Context) {
 
 // GetSessionsByTenant godoc
 // @Summary      获取会话列表
-// @Description  获取当前租户的会话列表，支持分页
+// @Description  获取当前租户的会话列表，支持分页，可按智能体过滤
 // @Tags         会话
 // @Accept       json
 // @Produce      json
-// @Param        page       query     int  false  "页码"
-// @Param        page_size  query     int  false  "每页数量"
+// @Param        page       query     int     false  "页码"
+// @Param        page_size  query     int     false  "每页数量"
+// @Param        agent_id   query     string  false  "智能体ID，仅返回使用该智能体的会话"
 // @Success      200        {object}  map[string]interface{}  "会话列表"
 // @Failure      400        {object}  errors.AppError         "请求参数错误"
+// @Failure      404        {object}  errors.AppError         "智能体不存在"
 // @Security     Bearer
 // @Security     ApiKeyAuth
 // @Router       /sessions [get]
@@ -182,8 +196,18 @@ func (h *Handler) GetSessionsByTenant(c *gin.Context) {
 		return
 	}
 
-	// Use paginated query to get sessions
-	result, err := h.sessionService.GetPagedSessionsByTenant(ctx, &pagination)
+	// Use paginated query to get sessions, optionally restricted to one agent
+	var result *types.PageResult
+	var err error
+	if agentID := c.Query("agent_id"); agentID != "" {
+		if !h.canAccessAgent(c, agentID) {
+			c.Error(errors.NewNotFoundError("Agent not found"))
+			return
+		}
+		result, err = h.sessionService.GetPagedSessionsByAgent(ctx, agentID, &pagination)
+	} else {
+		result, err = h.sessionService.GetPagedSessionsByTenant(ctx, &pagination)
+	}
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, nil)
 		c.Error(errors.NewInternalServerError(err.Error()))
@@ -202,12 +226,12 @@ func (h *Handler) GetSessionsByTenant(c *gin.Context) {
 
 // UpdateSession godoc
 // @Summary      更新会话
-// @Description  更新会话属性
+// @Description  更新会话标题、描述或关闭自动生成标题，未传的字段保持不变
 // @Tags         会话
 // @Accept       json
 // @Produce      json
-// @Param        id       path      string         true  "会话ID"
-// @Param        request  body      types.Session  true  "会话信息"
+// @Param        id       path      string                true  "会话ID"
+// @Param        request  body      UpdateSessionRequest  true  "会话信息"
 // @Success      200      {object}  map[string]interface{}  "更新后的会话"
 // @Failure      404      {object}  errors.AppError         "会话不存在"
 // @Security     Bearer
@@ -224,27 +248,41 @@ func (h *Handler) UpdateSession(c *gin.Context) {
 		return
 	}
 
-	// Verify tenant ID from context for authorization
-	tenantID, exists := c.Get(types.TenantIDContextKey.String())
-	if !exists {
-		logger.Error(ctx, "Failed to get tenant ID")
-		c.Error(errors.NewUnauthorizedError("Unauthorized"))
-		return
-	}
-
-	// Parse request body to session object
-	var session types.Session
-	if err := c.ShouldBindJSON(&session); err != nil {
+	var request UpdateSessionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
 		logger.Error(ctx, "Failed to parse session data", err)
 		c.Error(errors.NewBadRequestError(err.Error()))
 		return
 	}
 
-	session.ID = id
-	session.TenantID = tenantID.(uint64)
+	// Load the session of the current tenant and apply only the provided fields
+	session, err := h.sessionService.GetSession(ctx, id)
+	if err != nil {
+		if stderrors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Warnf(ctx, "Session not found, ID: %s", id)
+			c.Error(errors.NewNotFoundError(errors.ErrSessionNotFound.Error()))
+			return
+		}
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+	if request.Title != nil {
+		session.Title = strings.TrimSpace(*request.Title)
+		// A title chosen by the user is not replaced by a generated one
+		if session.Title != "" {
+			session.AutoTitleDisabled = true
+		}
+	}
+	if request.Description != nil {
+		session.Description = *request.Description
+	}
+	if request.AutoTitleDisabled != nil {
+		session.AutoTitleDisabled = *request.AutoTitleDisabled
+	}
 
 	// Call service to update session
-	if err := h.sessionService.UpdateSession(ctx, &session); err != nil {
+	if err := h.sessionService.UpdateSession(ctx, session); err != nil {
 		if err == errors.ErrSessionNotFound {
 			logger.Warnf(ctx, "Session not found, ID: %s", id)
 			c.Error(errors.NewNotFoundError(err.Error()))
@@ -376,3 +414,364 @@ func (h *Handler) BatchDeleteSessions(c *gin.Context) {
 		"message": "Sessions deleted successfully",
 	})
 }
+
+// GetMessageReferences godoc
+// @Summary      获取消息引用
+// @Description  获取助手消息持久化的知识引用，用于刷新页面后重新渲染引用链接
+// @Tags         会话
+// @Accept       json
+// @Produce      json
+// @Param        id          path      string  true  "会话ID"
+// @Param        message_id  path      string  true  "消息ID"
+// @Success      200         {object}  map[string]interface{}  "引用列表"
+// @Failure      404         {object}  errors.AppError         "消息不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /sessions/{id}/messages/{message_id}/references [get]
+func (h *Handler) GetMessageReferences(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	sessionID := secutils.SanitizeForLog(c.Param("id"))
+	messageID := secutils.SanitizeForLog(c.Param("message_id"))
+	if sessionID == "" || messageID == "" {
+		c.Error(errors.NewBadRequestError("session id and message id are required"))
+		return
+	}
+
+	message, err := h.messageService.GetMessage(ctx, sessionID, messageID)
+	if err != nil {
+		if stderrors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Warnf(ctx, "Message not found, session ID: %s, message ID: %s", sessionID, messageID)
+			c.Error(errors.NewNotFoundError("message not found"))
+			return
+		}
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+
+	references := message.KnowledgeReferences
+	if references == nil {
+		references = make(types.References, 0)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    references,
+	})
+}
+
+// SearchSessionMessages godoc
+// @Summary      搜索会话消息
+// @Description  在会话内按关键词（不区分大小写）搜索消息，按时间正序返回匹配消息及关键词附近的片段
+// @Tags         会话
+// @Accept       json
+// @Produce      json
+// @Param        id     path      string  true   "会话ID"
+// @Param        q      query     string  true   "关键词"
+// @Param        limit  query     int     false  "最大返回数量，默认 50，最大 200"
+// @Success      200    {object}  map[string]interface{}  "匹配的消息列表"
+// @Failure      400    {object}  errors.AppError         "请求参数错误"
+// @Failure      404    {object}  errors.AppError         "会话不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /sessions/{id}/messages/search [get]
+func (h *Handler) SearchSessionMessages(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	sessionID := secutils.SanitizeForLog(c.Param("id"))
+	if sessionID == "" {
+		c.Error(errors.NewBadRequestError(errors.ErrInvalidSessionID.Error()))
+		return
+	}
+	keyword := strings.TrimSpace(c.Query("q"))
+	if keyword == "" {
+		c.Error(errors.NewBadRequestError("q is required"))
+		return
+	}
+	limit := 0
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			c.Error(errors.NewBadRequestError("limit must be a positive integer"))
+			return
+		}
+		limit = n
+	}
+
+	matches, err := h.messageService.SearchSessionMessages(ctx, sessionID, keyword, limit)
+	if err != nil {
+		if stderrors.Is(err, gorm.ErrRecordNotFound) {
+			c.Error(errors.NewNotFoundError(errors.ErrSessionNotFound.Error()))
+			return
+		}
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    matches,
+	})
+}
+
+// GetContextSummary godoc
+// @Summary      获取上下文摘要
+// @Description  获取上下文管理器压缩历史对话后生成的摘要，未压缩时返回空字符串
+// @Tags         会话
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "会话ID"
+// @Success      200  {object}  map[string]interface{}  "上下文摘要"
+// @Failure      404  {object}  errors.AppError         "会话不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /sessions/{id}/context-summary [get]
+func (h *Handler) GetContextSummary(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id := secutils.SanitizeForLog(c.Param("id"))
+	if id == "" {
+		c.Error(errors.NewBadRequestError(errors.ErrInvalidSessionID.Error()))
+		return
+	}
+
+	summary, err := h.sessionService.GetContextSummary(ctx, id)
+	if err != nil {
+		h.handleContextSummaryError(c, id, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"summary": summary,
+		},
+	})
+}
+
+// UpdateContextSummary godoc
+// @Summary      修改上下文摘要
+// @Description  修正上下文管理器保存的历史对话摘要，后续对话将使用修改后的摘要；摘要为空时删除摘要
+// @Tags         会话
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                       true  "会话ID"
+// @Param        request  body      UpdateContextSummaryRequest  true  "摘要内容"
+// @Success      200      {object}  map[string]interface{}       "更新结果"
+// @Failure      400      {object}  errors.AppError              "请求参数错误"
+// @Failure      404      {object}  errors.AppError              "会话不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /sessions/{id}/context-summary [put]
+func (h *Handler) UpdateContextSummary(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id := secutils.SanitizeForLog(c.Param("id"))
+	if id == "" {
+		c.Error(errors.NewBadRequestError(errors.ErrInvalidSessionID.Error()))
+		return
+	}
+
+	var req UpdateContextSummaryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Errorf(ctx, "Invalid context summary request: %v", err)
+		c.Error(errors.NewBadRequestError("invalid request"))
+		return
+	}
+
+	if err := h.sessionService.UpdateContextSummary(ctx, id, req.Summary); err != nil {
+		h.handleContextSummaryError(c, id, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"summary": req.Summary,
+		},
+	})
+}
+
+// PinKnowledgeBases godoc
+// @Summary      设置会话固定知识库
+// @Description  替换会话的固定知识库列表，固定的知识库在每次问答中都会被检索（不受智能体知识库配置影响）；列表为空时取消全部固定
+// @Tags         会话
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                    true  "会话ID"
+// @Param        request  body      PinKnowledgeBasesRequest  true  "知识库ID列表"
+// @Success      200      {object}  map[string]interface{}    "更新后的会话"
+// @Failure      400      {object}  errors.AppError           "请求参数错误"
+// @Failure      403      {object}  errors.AppError           "无权访问知识库"
+// @Failure      404      {object}  errors.AppError           "会话或知识库不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /sessions/{id}/pinned-kbs [put]
+func (h *Handler) PinKnowledgeBases(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id := secutils.SanitizeForLog(c.Param("id"))
+	if id == "" {
+		c.Error(errors.NewBadRequestError(errors.ErrInvalidSessionID.Error()))
+		return
+	}
+
+	var req PinKnowledgeBasesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Errorf(ctx, "Invalid pinned knowledge bases request: %v", err)
+		c.Error(errors.NewBadRequestError("invalid request"))
+		return
+	}
+
+	session, err := h.sessionService.PinKnowledgeBases(ctx, id, req.KnowledgeBaseIDs)
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			c.Error(appErr)
+			return
+		}
+		if stderrors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Warnf(ctx, "Session not found, ID: %s", id)
+			c.Error(errors.NewNotFoundError(errors.ErrSessionNotFound.Error()))
+			return
+		}
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    session,
+	})
+}
+
+// PruneSessionHistory godoc
+// @Summary      裁剪会话历史
+// @Description  按租户的历史保留配置（最大消息数/最大保留天数）立即删除会话中最旧的消息，保留首条用户消息和上下文摘要
+// @Tags         会话
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "会话ID"
+// @Success      200  {object}  map[string]interface{}  "删除的消息数"
+// @Failure      400  {object}  errors.AppError         "未配置历史保留策略"
+// @Failure      404  {object}  errors.AppError         "会话不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /sessions/{id}/prune [post]
+func (h *Handler) PruneSessionHistory(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id := secutils.SanitizeForLog(c.Param("id"))
+	if id == "" {
+		c.Error(errors.NewBadRequestError(errors.ErrInvalidSessionID.Error()))
+		return
+	}
+
+	pruned, err := h.sessionService.PruneSessionHistory(ctx, id)
+	if err != nil {
+		if stderrors.Is(err, service.ErrHistoryRetentionNotConfigured) {
+			c.Error(errors.NewBadRequestError(err.Error()))
+			return
+		}
+		h.handleContextSummaryError(c, id, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"pruned": pruned,
+		},
+	})
+}
+
+// AttachFile godoc
+// @Summary      上传会话附件
+// @Description  上传仅对当前会话生效的文件，文件写入会话的临时知识库（与联网搜索共用），后续问答会检索该文件；删除会话时一并清理
+// @Tags         会话
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        id                 path      string  true   "会话ID"
+// @Param        file               formData  file    true   "上传的文件"
+// @Param        enable_multimodel  formData  bool    false  "是否启用多模态处理"
+// @Success      200                {object}  map[string]interface{}  "创建的知识条目"
+// @Failure      400                {object}  errors.AppError         "请求参数错误"
+// @Failure      404                {object}  errors.AppError         "会话不存在"
+// @Failure      409                {object}  map[string]interface{}  "文件已上传"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /sessions/{id}/attachments [post]
+func (h *Handler) AttachFile(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id := secutils.SanitizeForLog(c.Param("id"))
+	if id == "" {
+		c.Error(errors.NewBadRequestError(errors.ErrInvalidSessionID.Error()))
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		logger.Error(ctx, "File upload failed", err)
+		c.Error(errors.NewBadRequestError("File upload failed").WithDetails(err.Error()))
+		return
+	}
+
+	// Validate file size (configurable via MAX_FILE_SIZE_MB)
+	if file.Size > secutils.GetMaxFileSize() {
+		logger.Error(ctx, "File size too large")
+		c.Error(errors.NewBadRequestError(fmt.Sprintf("文件大小不能超过%dMB", secutils.GetMaxFileSizeMB())))
+		return
+	}
+
+	var enableMultimodel *bool
+	if form := c.PostForm("enable_multimodel"); form != "" {
+		parseBool, err := strconv.ParseBool(form)
+		if err != nil {
+			c.Error(errors.NewBadRequestError("Invalid enable_multimodel format").WithDetails(err.Error()))
+			return
+		}
+		enableMultimodel = &parseBool
+	}
+
+	logger.Infof(ctx, "Attaching file to session %s, filename: %s, size: %.2f KB",
+		id, secutils.SanitizeForLog(file.Filename), float64(file.Size)/1024)
+
+	knowledge, err := h.sessionService.AttachFileToSession(ctx, id, file, enableMultimodel)
+	if err != nil {
+		if dupErr, ok := err.(*types.DuplicateKnowledgeError); ok {
+			c.JSON(http.StatusConflict, gin.H{
+				"success":           false,
+				"message":           dupErr.Error(),
+				"data":              knowledge, // knowledge contains the existing attachment
+				"code":              "duplicate_file",
+				"knowledge_base_id": dupErr.KnowledgeBaseID(),
+			})
+			return
+		}
+		if appErr, ok := errors.IsAppError(err); ok {
+			c.Error(appErr)
+			return
+		}
+		h.handleContextSummaryError(c, id, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    knowledge,
+	})
+}
+
+// handleContextSummaryError maps context summary service errors to HTTP errors
+func (h *Handler) handleContextSummaryError(c *gin.Context, sessionID string, err error) {
+	ctx := c.Request.Context()
+	if stderrors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Warnf(ctx, "Session not found, ID: %s", sessionID)
+		c.Error(errors.NewNotFoundError(errors.ErrSessionNotFound.Error()))
+		return
+	}
+	logger.ErrorWithFields(ctx, err, nil)
+	c.Error(errors.NewInternalServerError(err.Error()))
+}
@@ -3,6 +3,7 @@ package session
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/Tencent/WeKnora/internal/event"
@@ -165,7 +166,14 @@ func (h *Handler) setupStopEventHandler(
 	assistantMessage *types.Message,
 	cancel context.CancelFunc,
 ) {
+	// The stop event may arrive twice (stream manager and direct cancellation), handle it once
+	var once sync.Once
 	eventBus.On(event.EventStop, func(ctx context.Context, evt event.Event) error {
+		stopped := false
+		once.Do(func() { stopped = true })
+		if !stopped {
+			return nil
+		}
 		logger.Infof(ctx, "Received stop event, cancelling async operations for session: %s", sessionID)
 		cancel()
 		assistantMessage.Content = "用户停止了本次对话"
@@ -312,3 +320,22 @@ func (h *Handler) fillSummaryConfigDefaults(ctx context.Context, config *types.S
 		config.NoMatchPrefix = defaultNoMatchPrefix
 	}
 }
+
+// canAccessAgent reports whether the caller can use the agent: one of the tenant's own (or built-in)
+// agents, or an agent shared with the user through an organization
+func (h *Handler) canAccessAgent(c *gin.Context, agentID string) bool {
+	ctx := c.Request.Context()
+	if agent, err := h.customAgentService.GetAgentByID(ctx, agentID); err == nil && agent != nil {
+		return true
+	}
+	if h.agentShareService == nil {
+		return false
+	}
+	userID := c.GetString(types.UserIDContextKey.String())
+	tenantID := c.GetUint64(types.TenantIDContextKey.String())
+	if userID == "" || tenantID == 0 {
+		return false
+	}
+	agent, err := h.agentShareService.GetSharedAgentForUser(ctx, userID, tenantID, agentID)
+	return err == nil && agent != nil
+}
@@ -0,0 +1,53 @@
+package session
+
+import (
+	"context"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+const (
+	// partialSaveChunkInterval is the number of streamed answer chunks between incremental saves
+	partialSaveChunkInterval = 20
+	// partialSaveTimeInterval is the maximum time between incremental saves while chunks keep arriving
+	partialSaveTimeInterval = 2 * time.Second
+)
+
+// partialAnswerSaver periodically persists a streaming assistant message so that the partial
+// answer survives a server crash mid-generation. The message stays IsCompleted=false until the
+// final chunk is handled by completeAssistantMessage.
+type partialAnswerSaver struct {
+	messageService interfaces.MessageService
+	message        *types.Message
+	pendingChunks  int
+	lastSavedAt    time.Time
+}
+
+// newPartialAnswerSaver creates a saver for the given assistant message
+func newPartialAnswerSaver(messageService interfaces.MessageService, message *types.Message) *partialAnswerSaver {
+	return &partialAnswerSaver{
+		messageService: messageService,
+		message:        message,
+		lastSavedAt:    time.Now(),
+	}
+}
+
+// Observe records that a chunk was appended to the message content and saves the message
+// once enough chunks or enough time have accumulated since the last save.
+func (s *partialAnswerSaver) Observe(ctx context.Context) {
+	s.pendingChunks++
+	if s.pendingChunks < partialSaveChunkInterval && time.Since(s.lastSavedAt) < partialSaveTimeInterval {
+		return
+	}
+	s.pendingChunks = 0
+	s.lastSavedAt = time.Now()
+
+	s.message.IsCompleted = false
+	s.message.UpdatedAt = s.lastSavedAt
+	if err := s.messageService.UpdateMessage(ctx, s.message); err != nil {
+		logger.Warnf(ctx, "Failed to save partial answer for message %s: %v", s.message.ID, err)
+	}
+}
@@ -0,0 +1,165 @@
+package session
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/event"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// countingMessageService counts message updates and records the last saved content
+type countingMessageService struct {
+	interfaces.MessageService
+	updates     int
+	lastContent string
+}
+
+func (s *countingMessageService) UpdateMessage(ctx context.Context, message *types.Message) error {
+	s.updates++
+	s.lastContent = message.Content
+	return nil
+}
+
+// IndexMessageToKB skips chat history indexing
+func (s *countingMessageService) IndexMessageToKB(ctx context.Context,
+	userQuery, assistantAnswer, messageID, sessionID string,
+) {
+}
+
+// discardStreamManager drops every stream event
+type discardStreamManager struct {
+	interfaces.StreamManager
+}
+
+func (m *discardStreamManager) AppendEvent(ctx context.Context,
+	sessionID, messageID string, evt interfaces.StreamEvent,
+) error {
+	return nil
+}
+
+func TestPartialAnswerSaverObserve(t *testing.T) {
+	ctx := context.Background()
+	messages := &countingMessageService{}
+	message := &types.Message{ID: "message-1", IsCompleted: true}
+	saver := newPartialAnswerSaver(messages, message)
+
+	for i := 1; i < partialSaveChunkInterval; i++ {
+		saver.Observe(ctx)
+	}
+	if messages.updates != 0 {
+		t.Fatalf("saved %d times before %d chunks", messages.updates, partialSaveChunkInterval)
+	}
+	saver.Observe(ctx)
+	if messages.updates != 1 {
+		t.Fatalf("saved %d times after %d chunks, want 1", messages.updates, partialSaveChunkInterval)
+	}
+	if message.IsCompleted {
+		t.Error("partial answer saved as completed")
+	}
+
+	saver.Observe(ctx)
+	if messages.updates != 1 {
+		t.Fatalf("saved again right after a save")
+	}
+	saver.lastSavedAt = time.Now().Add(-partialSaveTimeInterval)
+	saver.Observe(ctx)
+	if messages.updates != 2 {
+		t.Fatalf("saved %d times after %s, want 2", messages.updates, partialSaveTimeInterval)
+	}
+	if saver.pendingChunks != 0 {
+		t.Errorf("pendingChunks = %d after a save, want 0", saver.pendingChunks)
+	}
+}
+
+func TestAgentStreamHandlerSavesPartialAnswer(t *testing.T) {
+	ctx := context.Background()
+	messages := &countingMessageService{}
+	message := &types.Message{ID: "message-1"}
+	bus := event.NewEventBus()
+	handler := NewAgentStreamHandler(ctx, "session-1", message.ID, "request-1", message, &discardStreamManager{}, bus)
+	handler.Subscribe()
+	handler.savePartialAnswers(ctx, newPartialAnswerSaver(messages, message))
+
+	// The agent engine streams chunks that are never done, then an empty done marker
+	for i := 0; i < partialSaveChunkInterval; i++ {
+		bus.Emit(ctx, event.Event{
+			ID: "answer-1", Type: event.EventAgentFinalAnswer, Data: event.AgentFinalAnswerData{Content: "a"},
+		})
+	}
+	want := strings.Repeat("a", partialSaveChunkInterval)
+	if messages.updates != 1 || messages.lastContent != want {
+		t.Fatalf("saved %d times with %q, want 1 save with %q", messages.updates, messages.lastContent, want)
+	}
+	bus.Emit(ctx, event.Event{
+		ID: "answer-done-1", Type: event.EventAgentFinalAnswer, Data: event.AgentFinalAnswerData{Done: true},
+	})
+	bus.Emit(ctx, event.Event{
+		Type: event.EventAgentComplete,
+		Data: event.AgentCompleteData{MessageID: message.ID, FinalAnswer: want},
+	})
+	if message.Content != want {
+		t.Errorf("content = %q after completion, want %q", message.Content, want)
+	}
+	if !message.IsCompleted {
+		t.Error("message not completed")
+	}
+	if messages.updates != 1 {
+		t.Errorf("saved %d times, want 1", messages.updates)
+	}
+}
+
+func TestPersistNormalModeAnswerWithLateReferences(t *testing.T) {
+	ctx := context.Background()
+	messages := &countingMessageService{}
+	message := &types.Message{ID: "message-1", SessionID: "session-1"}
+	bus := event.NewEventBus()
+	streamCtx := &sseStreamContext{
+		eventBus:         bus,
+		asyncCtx:         ctx,
+		assistantMessage: message,
+		streamHandler: NewAgentStreamHandler(ctx, "session-1", message.ID, "request-1", message,
+			&discardStreamManager{}, bus),
+	}
+	streamCtx.streamHandler.Subscribe()
+	h := &Handler{messageService: messages}
+	h.persistNormalModeAnswer(streamCtx, 1, "session-1", "query")
+
+	// The answer stream and the references come from different goroutines
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < partialSaveChunkInterval; i++ {
+			bus.Emit(ctx, event.Event{
+				ID: "answer-1", Type: event.EventAgentFinalAnswer, Data: event.AgentFinalAnswerData{Content: "a"},
+			})
+		}
+		bus.Emit(ctx, event.Event{
+			ID: "answer-1", Type: event.EventAgentFinalAnswer, Data: event.AgentFinalAnswerData{Done: true},
+		})
+	}()
+	go func() {
+		defer wg.Done()
+		bus.Emit(ctx, event.Event{
+			Type: event.EventAgentReferences,
+			Data: event.AgentReferencesData{References: []*types.SearchResult{{ID: "chunk-1"}}},
+		})
+	}()
+	wg.Wait()
+
+	// Whichever came last, the message holds both the answer and the references
+	if !message.IsCompleted {
+		t.Fatal("message not completed")
+	}
+	if want := strings.Repeat("a", partialSaveChunkInterval); messages.lastContent != want {
+		t.Errorf("saved content %q, want %q", messages.lastContent, want)
+	}
+	if len(message.KnowledgeReferences) != 1 || message.KnowledgeReferences[0].ID != "chunk-1" {
+		t.Errorf("references = %v, want chunk-1", message.KnowledgeReferences)
+	}
+}
@@ -6,8 +6,10 @@ import (
 	"fmt"
 	"net/http"
 	"runtime"
+	"slices"
 	"time"
 
+	"github.com/Tencent/WeKnora/internal/agent/tools"
 	"github.com/Tencent/WeKnora/internal/errors"
 	"github.com/Tencent/WeKnora/internal/event"
 	"github.com/Tencent/WeKnora/internal/logger"
@@ -28,9 +30,14 @@ type qaRequestContext struct {
 	assistantMessage  *types.Message
 	knowledgeBaseIDs  []string
 	knowledgeIDs      []string
+	retrievalFilters  *types.RetrievalFilters
+	includeMetadata   []string // knowledge metadata keys returned with each reference
 	summaryModelID    string
 	webSearchEnabled  bool
 	enableMemory      bool // Whether memory feature is enabled
+	verbosity         types.AnswerVerbosity
+	sampling          *types.SamplingOverride
+	disabledTools     []string // tools disabled for this request only (agent mode)
 	mentionedItems    types.MentionedItems
 	effectiveTenantID uint64 // when using shared agent, tenant ID for model/KB/MCP resolution; 0 = use context tenant
 }
@@ -60,6 +67,33 @@ func (h *Handler) parseQARequest(c *gin.Context, logPrefix string) (*qaRequestCo
 		return nil, nil, errors.NewBadRequestError("Query content cannot be empty")
 	}
 
+	if err := request.retrievalFilters().Validate(); err != nil {
+		logger.Error(ctx, "Invalid retrieval filters", err)
+		return nil, nil, errors.NewBadRequestError(err.Error())
+	}
+
+	if err := types.ValidateIncludeMetadata(request.IncludeMetadata); err != nil {
+		logger.Error(ctx, "Invalid include_metadata", err)
+		return nil, nil, errors.NewBadRequestError(err.Error())
+	}
+
+	if !request.Verbosity.IsValid() {
+		logger.Errorf(ctx, "Invalid verbosity: %s", secutils.SanitizeForLog(string(request.Verbosity)))
+		return nil, nil, errors.NewBadRequestError("verbosity must be one of brief, normal, detailed")
+	}
+
+	if err := request.samplingOverride().Validate(); err != nil {
+		logger.Error(ctx, "Invalid sampling override", err)
+		return nil, nil, errors.NewBadRequestError(err.Error())
+	}
+
+	for _, name := range request.DisabledTools {
+		if !tools.CanDisableTool(name) {
+			logger.Errorf(ctx, "Invalid disabled tool: %s", secutils.SanitizeForLog(name))
+			return nil, nil, errors.NewBadRequestError(fmt.Sprintf("tool %q cannot be disabled", name))
+		}
+	}
+
 	// Log request details
 	if requestJSON, err := json.Marshal(request); err == nil {
 		logger.Infof(ctx, "[%s] Request: session_id=%s, request=%s",
@@ -106,6 +140,14 @@ func (h *Handler) parseQARequest(c *gin.Context, logPrefix string) (*qaRequestCo
 		}
 	}
 
+	// Remember the agent a session was started with so sessions can be listed per agent
+	if customAgent != nil && session.AgentID == "" {
+		session.AgentID = customAgent.ID
+		if err := h.sessionService.UpdateSession(ctx, session); err != nil {
+			logger.Warnf(ctx, "Failed to record agent %s on session %s: %v", customAgent.ID, sessionID, err)
+		}
+	}
+
 	// Merge @mentioned items into knowledge_base_ids and knowledge_ids so that
 	// retrieval (quick-answer and agent mode) uses the same targets the user @mentioned.
 	// This fixes the case where user only @mentions a (shared) KB in the input but
@@ -165,9 +207,14 @@ func (h *Handler) parseQARequest(c *gin.Context, logPrefix string) (*qaRequestCo
 		},
 		knowledgeBaseIDs:  secutils.SanitizeForLogArray(kbIDs),
 		knowledgeIDs:      secutils.SanitizeForLogArray(knowledgeIDs),
+		retrievalFilters:  request.retrievalFilters(),
+		includeMetadata:   request.IncludeMetadata,
 		summaryModelID:    secutils.SanitizeForLog(request.SummaryModelID),
-		webSearchEnabled:  request.WebSearchEnabled,
+		webSearchEnabled:  request.WebSearchEnabled && !slices.Contains(request.DisabledTools, tools.ToolWebSearch),
 		enableMemory:      request.EnableMemory,
+		verbosity:         request.Verbosity,
+		sampling:          request.samplingOverride(),
+		disabledTools:     request.DisabledTools,
 		mentionedItems:    convertMentionedItems(request.MentionedItems),
 		effectiveTenantID: effectiveTenantID,
 	}
@@ -181,6 +228,7 @@ type sseStreamContext struct {
 	asyncCtx         context.Context
 	cancel           context.CancelFunc
 	assistantMessage *types.Message
+	streamHandler    *AgentStreamHandler
 }
 
 // setupSSEStream sets up the SSE streaming context
@@ -202,6 +250,8 @@ func (h *Handler) setupSSEStream(reqCtx *qaRequestContext, generateTitle bool) *
 
 	// Create EventBus and cancellable context
 	eventBus := event.NewEventBus()
+	// Filter the answer before any consumer sees it (requester's tenant policy)
+	event.InstallAnswerFilters(reqCtx.ctx, eventBus)
 	asyncCtx, cancel := context.WithCancel(logger.CloneContext(baseCtx))
 
 	streamCtx := &sseStreamContext{
@@ -215,11 +265,11 @@ func (h *Handler) setupSSEStream(reqCtx *qaRequestContext, generateTitle bool) *
 	h.setupStopEventHandler(eventBus, reqCtx.sessionID, reqCtx.session.TenantID, reqCtx.assistantMessage, cancel)
 
 	// Setup stream handler
-	h.setupStreamHandler(asyncCtx, reqCtx.sessionID, reqCtx.assistantMessage.ID,
+	streamCtx.streamHandler = h.setupStreamHandler(asyncCtx, reqCtx.sessionID, reqCtx.assistantMessage.ID,
 		reqCtx.requestID, reqCtx.assistantMessage, eventBus)
 
 	// Generate title if needed
-	if generateTitle && reqCtx.session.Title == "" {
+	if generateTitle && reqCtx.session.NeedsAutoTitle() {
 		// Use the same model as the conversation for title generation
 		modelID := ""
 		if reqCtx.customAgent != nil && reqCtx.customAgent.Config.ModelID != "" {
@@ -263,6 +313,12 @@ func (h *Handler) SearchKnowledge(c *gin.Context) {
 		return
 	}
 
+	if err := request.retrievalFilters().Validate(); err != nil {
+		logger.Error(ctx, "Invalid retrieval filters", err)
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+
 	// Merge single knowledge_base_id into knowledge_base_ids for backward compatibility
 	knowledgeBaseIDs := request.KnowledgeBaseIDs
 	if request.KnowledgeBaseID != "" {
@@ -294,7 +350,8 @@ func (h *Handler) SearchKnowledge(c *gin.Context) {
 	)
 
 	// Directly call knowledge retrieval service without LLM summarization
-	searchResults, err := h.sessionService.SearchKnowledge(ctx, knowledgeBaseIDs, request.KnowledgeIDs, request.Query)
+	searchResults, err := h.sessionService.SearchKnowledge(ctx, knowledgeBaseIDs, request.KnowledgeIDs, request.Query,
+		request.retrievalFilters())
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, nil)
 		c.Error(errors.NewInternalServerError(err.Error()))
@@ -394,43 +451,10 @@ func (h *Handler) executeNormalModeQA(reqCtx *qaRequestContext, generateTitle bo
 
 	// Setup SSE stream
 	streamCtx := h.setupSSEStream(reqCtx, generateTitle)
+	h.trackGeneration(reqCtx, streamCtx, types.GenerationModeKnowledgeQA)
 
-	// Setup completion handler for normal mode
-	// Note: Thinking content is now embedded in answer stream with <think> tags
-	// by chat_completion_stream.go, so we don't need separate thinking event handling
-	var completionHandled bool // Prevent duplicate completion handling
-
-	streamCtx.eventBus.On(event.EventAgentFinalAnswer, func(ctx context.Context, evt event.Event) error {
-		data, ok := evt.Data.(event.AgentFinalAnswerData)
-		if !ok {
-			return nil
-		}
-		streamCtx.assistantMessage.Content += data.Content
-		if data.IsFallback {
-			streamCtx.assistantMessage.IsFallback = true
-		}
-		if data.Done {
-			// Prevent duplicate completion handling
-			if completionHandled {
-				return nil
-			}
-			completionHandled = true
-
-			logger.Infof(streamCtx.asyncCtx, "Knowledge QA service completed for session: %s", sessionID)
-			// Content already contains <think>...</think> tags from chat_completion_stream.go
-			// Use session's tenant for message update (asyncCtx may have effectiveTenantID when using shared agent)
-			updateCtx := context.WithValue(streamCtx.asyncCtx, types.TenantIDContextKey, reqCtx.session.TenantID)
-			h.completeAssistantMessage(updateCtx, streamCtx.assistantMessage, reqCtx.query)
-			// Emit EventAgentComplete - this will trigger handleComplete which sends the SSE complete event
-			// Note: Don't cancel context here, let the SSE handler close naturally after receiving the complete event
-			streamCtx.eventBus.Emit(streamCtx.asyncCtx, event.Event{
-				Type:      event.EventAgentComplete,
-				SessionID: sessionID,
-				Data:      event.AgentCompleteData{FinalAnswer: streamCtx.assistantMessage.Content},
-			})
-		}
-		return nil
-	})
+	// Persist the answer and its references as they stream in
+	h.persistNormalModeAnswer(streamCtx, reqCtx.session.TenantID, sessionID, reqCtx.query)
 
 	// Execute KnowledgeQA asynchronously
 	go func() {
@@ -440,22 +464,25 @@ func (h *Handler) executeNormalModeQA(reqCtx *qaRequestContext, generateTitle bo
 				runtime.Stack(buf, true)
 				logger.ErrorWithFields(streamCtx.asyncCtx,
 					errors.NewInternalServerError(fmt.Sprintf("Knowledge QA service panicked: %v\n%s", r, string(buf))), nil)
+				h.sessionService.FinishGeneration(sessionID, reqCtx.assistantMessage.ID)
 			}
 		}()
 
-		err := h.sessionService.KnowledgeQA(
-			streamCtx.asyncCtx,
-			reqCtx.session,
-			reqCtx.query,
-			reqCtx.knowledgeBaseIDs,
-			reqCtx.knowledgeIDs,
-			reqCtx.assistantMessage.ID,
-			reqCtx.summaryModelID,
-			reqCtx.webSearchEnabled,
-			streamCtx.eventBus,
-			reqCtx.customAgent,
-			reqCtx.enableMemory,
-		)
+		err := h.sessionService.KnowledgeQA(streamCtx.asyncCtx, &types.QARequest{
+			Session:            reqCtx.session,
+			Query:              reqCtx.query,
+			AssistantMessageID: reqCtx.assistantMessage.ID,
+			KnowledgeBaseIDs:   reqCtx.knowledgeBaseIDs,
+			KnowledgeIDs:       reqCtx.knowledgeIDs,
+			SummaryModelID:     reqCtx.summaryModelID,
+			CustomAgent:        reqCtx.customAgent,
+			Sampling:           reqCtx.sampling,
+			Filters:            reqCtx.retrievalFilters,
+			IncludeMetadata:    reqCtx.includeMetadata,
+			WebSearchEnabled:   reqCtx.webSearchEnabled,
+			EnableMemory:       reqCtx.enableMemory,
+			Verbosity:          reqCtx.verbosity,
+		}, streamCtx.eventBus)
 		if err != nil {
 			logger.ErrorWithFields(streamCtx.asyncCtx, err, nil)
 			streamCtx.eventBus.Emit(streamCtx.asyncCtx, event.Event{
@@ -471,11 +498,83 @@ func (h *Handler) executeNormalModeQA(reqCtx *qaRequestContext, generateTitle bo
 	}()
 
 	// Handle SSE events (blocking)
-	shouldWaitForTitle := generateTitle && reqCtx.session.Title == ""
+	shouldWaitForTitle := generateTitle && reqCtx.session.NeedsAutoTitle()
 	h.handleAgentEventsForSSE(ctx, reqCtx.c, sessionID, reqCtx.assistantMessage.ID,
 		reqCtx.requestID, streamCtx.eventBus, shouldWaitForTitle)
 }
 
+// persistNormalModeAnswer registers the handlers that save the assistant message of a normal
+// mode answer. The answer stream and the references are emitted from different goroutines, so
+// both handlers update the message under the stream handler's lock.
+func (h *Handler) persistNormalModeAnswer(streamCtx *sseStreamContext,
+	sessionTenantID uint64, sessionID, query string,
+) {
+	// Use session's tenant for message update (asyncCtx may have effectiveTenantID when using shared agent)
+	updateCtx := context.WithValue(streamCtx.asyncCtx, types.TenantIDContextKey, sessionTenantID)
+
+	// Note: Thinking content is now embedded in answer stream with <think> tags
+	// by chat_completion_stream.go, so we don't need separate thinking event handling
+	var completionHandled bool // Prevent duplicate completion handling, guarded by the stream handler's lock
+
+	// Persist the partial answer periodically while streaming (covers both the pipeline answer
+	// and the fallback stream, which are emitted through the same event) so it can be recovered
+	// if the server goes down before Done=true.
+	partialSaver := newPartialAnswerSaver(h.messageService, streamCtx.assistantMessage)
+
+	streamCtx.eventBus.On(event.EventAgentFinalAnswer, func(ctx context.Context, evt event.Event) error {
+		data, ok := evt.Data.(event.AgentFinalAnswerData)
+		if !ok {
+			return nil
+		}
+		var completed bool
+		var finalAnswer string
+		streamCtx.streamHandler.withMessage(func(message *types.Message) {
+			message.Content += data.Content
+			if data.IsFallback {
+				message.IsFallback = true
+			}
+			if !data.Done && !completionHandled {
+				partialSaver.Observe(updateCtx)
+			}
+			if data.Done && !completionHandled {
+				completionHandled = true
+				completed = true
+				// Content already contains <think>...</think> tags from chat_completion_stream.go
+				h.completeAssistantMessage(updateCtx, message, query)
+				finalAnswer = message.Content
+			}
+		})
+		if !completed {
+			return nil
+		}
+
+		logger.Infof(streamCtx.asyncCtx, "Knowledge QA service completed for session: %s", sessionID)
+		// Emit EventAgentComplete - this will trigger handleComplete which sends the SSE complete event
+		// Note: Don't cancel context here, let the SSE handler close naturally after receiving the complete event
+		streamCtx.eventBus.Emit(streamCtx.asyncCtx, event.Event{
+			Type:      event.EventAgentComplete,
+			SessionID: sessionID,
+			Data:      event.AgentCompleteData{FinalAnswer: finalAnswer},
+		})
+		return nil
+	})
+
+	// References are emitted after the pipeline returns, which can be after the answer stream
+	// has already completed and persisted the message; persist them again in that case so
+	// citations survive a reload even if the client missed the references event.
+	streamCtx.eventBus.On(event.EventAgentReferences, func(ctx context.Context, evt event.Event) error {
+		streamCtx.streamHandler.withMessage(func(message *types.Message) {
+			if !completionHandled {
+				return
+			}
+			if err := h.messageService.UpdateMessage(updateCtx, message); err != nil {
+				logger.Errorf(ctx, "Failed to persist references for message %s: %v", message.ID, err)
+			}
+		})
+		return nil
+	})
+}
+
 // executeAgentModeQA executes the agent mode
 func (h *Handler) executeAgentModeQA(reqCtx *qaRequestContext) {
 	ctx := reqCtx.ctx
@@ -514,6 +613,12 @@ func (h *Handler) executeAgentModeQA(reqCtx *qaRequestContext) {
 
 	// Setup SSE stream (agent mode always generates title)
 	streamCtx := h.setupSSEStream(reqCtx, true)
+	h.trackGeneration(reqCtx, streamCtx, types.GenerationModeAgentQA)
+
+	// Persist the partial answer periodically while the agent streams it, so it can be recovered
+	// if the server goes down before the agent completes
+	saveCtx := context.WithValue(streamCtx.asyncCtx, types.TenantIDContextKey, reqCtx.session.TenantID)
+	streamCtx.streamHandler.savePartialAnswers(saveCtx, newPartialAnswerSaver(h.messageService, streamCtx.assistantMessage))
 
 	// Execute AgentQA asynchronously
 	go func() {
@@ -528,20 +633,21 @@ func (h *Handler) executeAgentModeQA(reqCtx *qaRequestContext) {
 			// Use session's tenant for message update (session belongs to session.TenantID; asyncCtx may have effectiveTenantID when using shared agent)
 			updateCtx := context.WithValue(streamCtx.asyncCtx, types.TenantIDContextKey, reqCtx.session.TenantID)
 			h.completeAssistantMessage(updateCtx, streamCtx.assistantMessage, reqCtx.query)
+			h.sessionService.FinishGeneration(sessionID, reqCtx.assistantMessage.ID)
 			logger.Infof(streamCtx.asyncCtx, "Agent QA service completed for session: %s", sessionID)
 		}()
 
-		err := h.sessionService.AgentQA(
-			streamCtx.asyncCtx,
-			reqCtx.session,
-			reqCtx.query,
-			reqCtx.assistantMessage.ID,
-			reqCtx.summaryModelID,
-			streamCtx.eventBus,
-			reqCtx.customAgent,
-			reqCtx.knowledgeBaseIDs,
-			reqCtx.knowledgeIDs,
-		)
+		err := h.sessionService.AgentQA(streamCtx.asyncCtx, &types.QARequest{
+			Session:            reqCtx.session,
+			Query:              reqCtx.query,
+			AssistantMessageID: reqCtx.assistantMessage.ID,
+			KnowledgeBaseIDs:   reqCtx.knowledgeBaseIDs,
+			KnowledgeIDs:       reqCtx.knowledgeIDs,
+			SummaryModelID:     reqCtx.summaryModelID,
+			CustomAgent:        reqCtx.customAgent,
+			Sampling:           reqCtx.sampling,
+			DisabledTools:      reqCtx.disabledTools,
+		}, streamCtx.eventBus)
 		if err != nil {
 			logger.ErrorWithFields(streamCtx.asyncCtx, err, nil)
 			streamCtx.eventBus.Emit(streamCtx.asyncCtx, event.Event{
@@ -558,7 +664,7 @@ func (h *Handler) executeAgentModeQA(reqCtx *qaRequestContext) {
 
 	// Handle SSE events (blocking)
 	h.handleAgentEventsForSSE(ctx, reqCtx.c, sessionID, reqCtx.assistantMessage.ID,
-		reqCtx.requestID, streamCtx.eventBus, reqCtx.session.Title == "")
+		reqCtx.requestID, streamCtx.eventBus, reqCtx.session.NeedsAutoTitle())
 }
 
 // completeAssistantMessage marks an assistant message as complete, updates it,
@@ -0,0 +1,64 @@
+package session
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/gin-gonic/gin"
+)
+
+// searchMessageService records the keywords it is asked to search
+type searchMessageService struct {
+	interfaces.MessageService
+	keywords []string
+}
+
+func (s *searchMessageService) SearchSessionMessages(
+	ctx context.Context, sessionID string, keyword string, limit int,
+) ([]*types.SessionMessageMatch, error) {
+	s.keywords = append(s.keywords, keyword)
+	return []*types.SessionMessageMatch{}, nil
+}
+
+func TestSearchSessionMessagesRequiresKeyword(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name     string
+		query    string
+		wantCode int
+	}{
+		{"missing q", "", http.StatusBadRequest},
+		{"blank q", "?q=%20%20", http.StatusBadRequest},
+		{"keyword", "?q=docker", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			c.Request = httptest.NewRequest(http.MethodGet, "/sessions/s1/messages/search"+tt.query, nil)
+			c.Params = gin.Params{{Key: "id", Value: "s1"}}
+			messages := &searchMessageService{}
+			h := &Handler{messageService: messages}
+
+			h.SearchSessionMessages(c)
+
+			code := recorder.Code
+			var appErr *errors.AppError
+			if err := c.Errors.Last(); err != nil && stderrors.As(err.Err, &appErr) {
+				code = appErr.HTTPCode
+			}
+			if code != tt.wantCode {
+				t.Errorf("status = %d, want %d", code, tt.wantCode)
+			}
+			if searched := len(messages.keywords) > 0; searched != (tt.wantCode == http.StatusOK) {
+				t.Errorf("searched keywords %q, want a search only for a non-empty q", messages.keywords)
+			}
+		})
+	}
+}
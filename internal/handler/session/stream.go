@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/Tencent/WeKnora/internal/errors"
@@ -12,17 +13,70 @@ import (
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-contrib/sse"
 	"github.com/gin-gonic/gin"
 )
 
+// defaultHeartbeatInterval is the SSE heartbeat interval used when none is configured
+const defaultHeartbeatInterval = 15 * time.Second
+
+// heartbeatInterval returns the configured SSE heartbeat interval, 0 means heartbeats are disabled
+func (h *Handler) heartbeatInterval() time.Duration {
+	if h.config == nil || h.config.StreamManager == nil || h.config.StreamManager.HeartbeatInterval == 0 {
+		return defaultHeartbeatInterval
+	}
+	if h.config.StreamManager.HeartbeatInterval < 0 {
+		return 0
+	}
+	return h.config.StreamManager.HeartbeatInterval
+}
+
+// writeHeartbeat writes a content-less heartbeat so proxies don't drop an idle SSE connection.
+// It is sent as a separate SSE event type without an id, so it never moves the client's Last-Event-ID.
+func writeHeartbeat(c *gin.Context, requestID string) {
+	c.SSEvent(string(event.EventHeartbeat), &types.StreamResponse{
+		ID:           requestID,
+		ResponseType: types.ResponseTypeHeartbeat,
+	})
+	c.Writer.Flush()
+}
+
+// writeStreamEvent writes a stream response as an SSE message whose id is the event's
+// sequence number in the stream (offset + 1). Sequence numbers are monotonic per message,
+// so a reconnecting client can send the last one back as Last-Event-ID to resume without gaps.
+func writeStreamEvent(c *gin.Context, seq int, response *types.StreamResponse) {
+	c.Render(-1, sse.Event{
+		Id:    strconv.Itoa(seq),
+		Event: "message",
+		Data:  response,
+	})
+	c.Writer.Flush()
+}
+
+// lastEventOffset returns the stream offset to resume from, taken from the Last-Event-ID header
+// (sent automatically by EventSource on reconnect) or the last_event_id query parameter
+func lastEventOffset(c *gin.Context) int {
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	seq, err := strconv.Atoi(lastEventID)
+	if err != nil || seq < 0 {
+		return 0
+	}
+	return seq
+}
+
 // ContinueStream godoc
 // @Summary      继续流式响应
-// @Description  继续获取正在进行的流式响应
+// @Description  继续获取正在进行的流式响应；携带 Last-Event-ID 时只重放该事件之后的事件
 // @Tags         问答
 // @Accept       json
 // @Produce      text/event-stream
-// @Param        session_id  path      string  true  "会话ID"
-// @Param        message_id  query     string  true  "消息ID"
+// @Param        session_id     path      string  true   "会话ID"
+// @Param        message_id     query     string  true   "消息ID"
+// @Param        last_event_id  query     int     false  "最后收到的事件序号（也可通过 Last-Event-ID 请求头传递）"
+// @Param        Last-Event-ID  header    int     false  "最后收到的事件序号"
 // @Success      200         {object}  map[string]interface{}  "流式响应"
 // @Failure      404         {object}  errors.AppError         "会话或消息不存在"
 // @Security     Bearer
@@ -81,15 +135,23 @@ func (h *Handler) ContinueStream(c *gin.Context) {
 		return
 	}
 
-	// Get initial events from stream (offset 0)
-	events, currentOffset, err := h.streamManager.GetEvents(ctx, sessionID, messageID, 0)
+	// Get events from stream, resuming after the last event the client received
+	startOffset := lastEventOffset(c)
+	events, currentOffset, err := h.streamManager.GetEvents(ctx, sessionID, messageID, startOffset)
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, nil)
 		c.Error(errors.NewInternalServerError(fmt.Sprintf("Failed to get stream data: %s", err.Error())))
 		return
 	}
 
-	if len(events) == 0 {
+	// A resuming client that already received everything of a finished generation has nothing left to replay
+	if len(events) == 0 && startOffset > 0 && message.IsCompleted {
+		logger.Infof(ctx, "Nothing to replay after event %d, session ID: %s, message ID: %s", startOffset, sessionID, messageID)
+		setSSEHeaders(c)
+		return
+	}
+
+	if len(events) == 0 && startOffset == 0 {
 		logger.Warnf(ctx, "No events found in stream, session ID: %s, message ID: %s", sessionID, messageID)
 		c.JSON(http.StatusNotFound, gin.H{
 			"success": false,
@@ -99,8 +161,8 @@ func (h *Handler) ContinueStream(c *gin.Context) {
 	}
 
 	logger.Infof(
-		ctx, "Preparing to replay %d events and continue streaming, session ID: %s, message ID: %s",
-		len(events), sessionID, messageID,
+		ctx, "Preparing to replay %d events after event %d and continue streaming, session ID: %s, message ID: %s",
+		len(events), startOffset, sessionID, messageID,
 	)
 
 	// Set headers for SSE
@@ -117,10 +179,8 @@ func (h *Handler) ContinueStream(c *gin.Context) {
 
 	// Replay existing events
 	logger.Debugf(ctx, "Replaying %d existing events", len(events))
-	for _, evt := range events {
-		response := buildStreamResponse(evt, message.RequestID)
-		c.SSEvent("message", response)
-		c.Writer.Flush()
+	for i, evt := range events {
+		writeStreamEvent(c, startOffset+i+1, buildStreamResponse(evt, message.RequestID))
 	}
 
 	// If stream is already completed, send final event and return
@@ -134,6 +194,8 @@ func (h *Handler) ContinueStream(c *gin.Context) {
 	logger.Debug(ctx, "Starting event update monitoring")
 	ticker := time.NewTicker(100 * time.Millisecond)
 	defer ticker.Stop()
+	heartbeat := h.heartbeatInterval()
+	lastWrite := time.Now()
 
 	for {
 		select {
@@ -149,17 +211,23 @@ func (h *Handler) ContinueStream(c *gin.Context) {
 				return
 			}
 
+			// Keep the connection alive while the generation produces nothing
+			if len(newEvents) > 0 {
+				lastWrite = time.Now()
+			} else if heartbeat > 0 && time.Since(lastWrite) >= heartbeat {
+				writeHeartbeat(c, message.RequestID)
+				lastWrite = time.Now()
+			}
+
 			// Send new events
 			streamCompletedNow := false
-			for _, evt := range newEvents {
+			for i, evt := range newEvents {
 				// Check for completion event
 				if evt.Type == "complete" {
 					streamCompletedNow = true
 				}
 
-				response := buildStreamResponse(evt, message.RequestID)
-				c.SSEvent("message", response)
-				c.Writer.Flush()
+				writeStreamEvent(c, currentOffset+i+1, buildStreamResponse(evt, message.RequestID))
 			}
 
 			// Update offset
@@ -308,6 +376,8 @@ func (h *Handler) handleAgentEventsForSSE(
 	defer ticker.Stop()
 
 	lastOffset := 0
+	heartbeat := h.heartbeatInterval()
+	lastWrite := time.Now()
 	log := logger.GetLogger(ctx)
 
 	log.Infof("Starting pull-based SSE streaming for session=%s, message=%s", sessionID, assistantMessageID)
@@ -331,10 +401,18 @@ func (h *Handler) handleAgentEventsForSSE(
 				continue
 			}
 
+			// Keep the connection alive while the generation produces nothing
+			if len(events) > 0 {
+				lastWrite = time.Now()
+			} else if heartbeat > 0 && time.Since(lastWrite) >= heartbeat {
+				writeHeartbeat(c, requestID)
+				lastWrite = time.Now()
+			}
+
 			// Send any new events
 			streamCompleted := false
 			titleReceived := false
-			for _, evt := range events {
+			for i, evt := range events {
 				// Check for stop event
 				if evt.Type == types.ResponseType(event.EventStop) {
 					log.Infof("Detected stop event, triggering stop via EventBus for session=%s", sessionID)
@@ -382,8 +460,7 @@ func (h *Handler) handleAgentEventsForSSE(
 					return
 				}
 
-				c.SSEvent("message", response)
-				c.Writer.Flush()
+				writeStreamEvent(c, lastOffset+i+1, response)
 			}
 
 			// Update offset
@@ -412,10 +489,8 @@ func (h *Handler) handleAgentEventsForSSE(
 								break titleWaitLoop
 							}
 							if len(events) > 0 {
-								for _, evt := range events {
-									response := buildStreamResponse(evt, requestID)
-									c.SSEvent("message", response)
-									c.Writer.Flush()
+								for i, evt := range events {
+									writeStreamEvent(c, lastOffset+i+1, buildStreamResponse(evt, requestID))
 									// If we got the title, we can exit
 									if evt.Type == types.ResponseTypeSessionTitle {
 										log.Infof("Title event received: %s", evt.Content)
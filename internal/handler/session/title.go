@@ -1,11 +1,13 @@
 package session
 
 import (
+	stderrors "errors"
 	"net/http"
 
 	"github.com/Tencent/WeKnora/internal/errors"
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // GenerateTitle godoc
@@ -66,3 +68,53 @@ func (h *Handler) GenerateTitle(c *gin.Context) {
 		"data":    title,
 	})
 }
+
+// RegenerateTitle godoc
+// @Summary      重新生成会话标题
+// @Description  根据当前对话内容重新生成会话标题，覆盖已有标题并推送标题更新事件
+// @Tags         会话
+// @Accept       json
+// @Produce      json
+// @Param        session_id  path      string                  true   "会话ID"
+// @Param        request     body      RegenerateTitleRequest  false  "重新生成请求"
+// @Success      200         {object}  map[string]interface{}  "生成的标题"
+// @Failure      400         {object}  errors.AppError         "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /sessions/{session_id}/regenerate-title [post]
+func (h *Handler) RegenerateTitle(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	sessionID := c.Param("session_id")
+	if sessionID == "" {
+		logger.Error(ctx, "Session ID is empty")
+		c.Error(errors.NewBadRequestError(errors.ErrInvalidSessionID.Error()))
+		return
+	}
+
+	// The body is optional; an empty one uses the default title model
+	var request RegenerateTitleRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			logger.Error(ctx, "Failed to parse request data", err)
+			c.Error(errors.NewBadRequestError(err.Error()))
+			return
+		}
+	}
+
+	title, err := h.sessionService.RegenerateTitle(ctx, sessionID, request.ModelID)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		if stderrors.Is(err, gorm.ErrRecordNotFound) {
+			c.Error(errors.NewNotFoundError(errors.ErrSessionNotFound.Error()))
+			return
+		}
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    title,
+	})
+}
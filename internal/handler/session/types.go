@@ -1,6 +1,8 @@
 package session
 
 import (
+	"time"
+
 	"github.com/Tencent/WeKnora/internal/types"
 )
 
@@ -14,6 +16,18 @@ type CreateSessionRequest struct {
 	Description string `json:"description"`
 	// External user ID for the session (optional)
 	ExternalUserId string `json:"external_user_id"`
+	// Custom agent the session is started with (optional)
+	AgentID string `json:"agent_id"`
+}
+
+// UpdateSessionRequest defines the request structure for updating a session; omitted fields are unchanged
+type UpdateSessionRequest struct {
+	// New title; setting a non-empty title turns auto-titling off unless auto_title_disabled is given
+	Title *string `json:"title"`
+	// New description
+	Description *string `json:"description"`
+	// Whether the title is never generated automatically
+	AutoTitleDisabled *bool `json:"auto_title_disabled"`
 }
 
 // GenerateTitleRequest defines the request structure for generating a session title
@@ -21,6 +35,11 @@ type GenerateTitleRequest struct {
 	Messages []types.Message `json:"messages" binding:"required"` // Messages to use as context for title generation
 }
 
+// RegenerateTitleRequest defines the optional request body for regenerating a session title
+type RegenerateTitleRequest struct {
+	ModelID string `json:"model_id"` // Optional model ID; defaults to the first KnowledgeQA model
+}
+
 // MentionedItemRequest represents a mentioned item in the request
 type MentionedItemRequest struct {
 	ID     string `json:"id"`
@@ -41,14 +60,61 @@ type CreateKnowledgeQARequest struct {
 	MentionedItems   []MentionedItemRequest `json:"mentioned_items"`                       // @mentioned knowledge bases and files
 	DisableTitle     bool                   `json:"disable_title"`                         // Whether to disable auto title generation
 	EnableMemory     bool                   `json:"enable_memory"`                         // Whether memory feature is enabled for this request
+	MetadataFilters  map[string]string      `json:"metadata_filters"`                      // Only retrieve documents whose metadata matches all pairs
+	CreatedAfter     *time.Time             `json:"created_after"`                         // Only retrieve documents created at or after this time
+	CreatedBefore    *time.Time             `json:"created_before"`                        // Only retrieve documents created before this time
+	Verbosity        types.AnswerVerbosity  `json:"verbosity"`                             // Answer length: "brief", "normal" or "detailed" (knowledge QA only)
+	Temperature      *float64               `json:"temperature"`                           // Optional temperature override for this request, in (0, 2]
+	TopP             *float64               `json:"top_p"`                                 // Optional top_p override for this request, in (0, 1]
+	DisabledTools    []string               `json:"disabled_tools"`                        // Tools disabled for this request only (e.g. "web_search")
+	IncludeMetadata  []string               `json:"include_metadata"`                      // Knowledge metadata keys returned with each reference ("*" for all)
+}
+
+// samplingOverride returns the request's sampling overrides
+func (r *CreateKnowledgeQARequest) samplingOverride() *types.SamplingOverride {
+	return &types.SamplingOverride{
+		Temperature: r.Temperature,
+		TopP:        r.TopP,
+	}
+}
+
+// retrievalFilters returns the request's document filters
+func (r *CreateKnowledgeQARequest) retrievalFilters() *types.RetrievalFilters {
+	return &types.RetrievalFilters{
+		MetadataFilters: r.MetadataFilters,
+		CreatedAfter:    r.CreatedAfter,
+		CreatedBefore:   r.CreatedBefore,
+	}
 }
 
 // SearchKnowledgeRequest defines the request structure for searching knowledge without LLM summarization
 type SearchKnowledgeRequest struct {
-	Query            string   `json:"query"              binding:"required"` // Query text to search for
-	KnowledgeBaseID  string   `json:"knowledge_base_id"`                     // Single knowledge base ID (for backward compatibility)
-	KnowledgeBaseIDs []string `json:"knowledge_base_ids"`                    // IDs of knowledge bases to search (multi-KB support)
-	KnowledgeIDs     []string `json:"knowledge_ids"`                         // IDs of specific knowledge (files) to search
+	Query            string            `json:"query"              binding:"required"` // Query text to search for
+	KnowledgeBaseID  string            `json:"knowledge_base_id"`                     // Single knowledge base ID (for backward compatibility)
+	KnowledgeBaseIDs []string          `json:"knowledge_base_ids"`                    // IDs of knowledge bases to search (multi-KB support)
+	KnowledgeIDs     []string          `json:"knowledge_ids"`                         // IDs of specific knowledge (files) to search
+	MetadataFilters  map[string]string `json:"metadata_filters"`                      // Only retrieve documents whose metadata matches all pairs
+	CreatedAfter     *time.Time        `json:"created_after"`                         // Only retrieve documents created at or after this time
+	CreatedBefore    *time.Time        `json:"created_before"`                        // Only retrieve documents created before this time
+}
+
+// retrievalFilters returns the request's document filters
+func (r *SearchKnowledgeRequest) retrievalFilters() *types.RetrievalFilters {
+	return &types.RetrievalFilters{
+		MetadataFilters: r.MetadataFilters,
+		CreatedAfter:    r.CreatedAfter,
+		CreatedBefore:   r.CreatedBefore,
+	}
+}
+
+// UpdateContextSummaryRequest represents the request to edit the stored context summary
+type UpdateContextSummaryRequest struct {
+	Summary string `json:"summary"`
+}
+
+// PinKnowledgeBasesRequest represents the request to replace a session's pinned knowledge bases
+type PinKnowledgeBasesRequest struct {
+	KnowledgeBaseIDs []string `json:"knowledge_base_ids"`
 }
 
 // StopSessionRequest represents the stop session request
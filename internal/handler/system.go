@@ -16,6 +16,7 @@ import (
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/Tencent/WeKnora/internal/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
@@ -392,6 +393,125 @@ type StorageEngineStatusItem struct {
 	Description string `json:"description"` // short description for UI
 }
 
+// ParseSQLDebugRequest is the request body for POST /admin/sql/parse.
+type ParseSQLDebugRequest struct {
+	SQL string `json:"sql" binding:"required"`
+}
+
+// ParseSQLDebugResponse is the response for POST /admin/sql/parse.
+type ParseSQLDebugResponse struct {
+	ParseResult *utils.SQLParseResult `json:"parse_result"`
+	ParseTree   json.RawMessage       `json:"parse_tree,omitempty"`
+	ParseError  string                `json:"parse_error,omitempty"`
+}
+
+// ParseSQLDebug godoc
+// @Summary      解析 SQL 语法树（调试）
+// @Description  返回 SQL 的 PostgreSQL 语法树 JSON 及解析结果，用于排查 SQL 校验被拒绝的原因；仅解析不执行，仅系统管理员可用
+// @Tags         系统
+// @Accept       json
+// @Produce      json
+// @Param        body  body      ParseSQLDebugRequest  true  "待解析的 SQL"
+// @Success      200   {object}  ParseSQLDebugResponse
+// @Failure      403   {object}  map[string]interface{}  "权限不足"
+// @Security     Bearer
+// @Router       /admin/sql/parse [post]
+func (h *SystemHandler) ParseSQLDebug(c *gin.Context) {
+	ctx := logger.CloneContext(c.Request.Context())
+
+	var req ParseSQLDebugRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"code": 1, "msg": "请求体格式错误"})
+		return
+	}
+
+	response := ParseSQLDebugResponse{
+		ParseResult: utils.ParseSQL(req.SQL),
+	}
+	tree, err := utils.ParseSQLToJSON(req.SQL)
+	if err != nil {
+		logger.Infof(ctx, "SQL debug parse failed: %v", err)
+		response.ParseError = err.Error()
+	} else {
+		response.ParseTree = json.RawMessage(tree)
+	}
+
+	c.JSON(200, gin.H{
+		"code": 0,
+		"msg":  "success",
+		"data": response,
+	})
+}
+
+// ValidateSQLDebugRequest is the request body for POST /admin/sql/validate.
+type ValidateSQLDebugRequest struct {
+	SQL     string `json:"sql" binding:"required"`
+	Profile string `json:"profile" binding:"required"` // see utils.SQLValidationProfiles
+	// TenantID is the tenant the isolation rules are built for; defaults to the caller's tenant
+	TenantID uint64 `json:"tenant_id,omitempty"`
+}
+
+// ValidateSQLDebugResponse is the response for POST /admin/sql/validate.
+type ValidateSQLDebugResponse struct {
+	Profile          string                     `json:"profile"`
+	ParseResult      *utils.SQLParseResult      `json:"parse_result"`
+	ValidationResult *utils.SQLValidationResult `json:"validation_result"`
+	// SecuredSQL is the statement after tenant, soft delete and limit rewriting, if it passed
+	SecuredSQL string `json:"secured_sql,omitempty"`
+}
+
+// ValidateSQLDebug godoc
+// @Summary      按规则集校验 SQL（调试）
+// @Description  使用指定的规则集校验 SQL，返回解析结果、校验结果及改写后的 SQL，用于调试 Text-to-SQL 的校验规则；仅校验不执行，仅系统管理员可用
+// @Tags         系统
+// @Accept       json
+// @Produce      json
+// @Param        body  body      ValidateSQLDebugRequest  true  "待校验的 SQL 及规则集"
+// @Success      200   {object}  ValidateSQLDebugResponse
+// @Failure      400   {object}  map[string]interface{}  "请求参数错误或规则集不存在"
+// @Failure      403   {object}  map[string]interface{}  "权限不足"
+// @Security     Bearer
+// @Router       /admin/sql/validate [post]
+func (h *SystemHandler) ValidateSQLDebug(c *gin.Context) {
+	ctx := logger.CloneContext(c.Request.Context())
+
+	var req ValidateSQLDebugRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"code": 1, "msg": "请求体格式错误"})
+		return
+	}
+	tenantID := req.TenantID
+	if tenantID == 0 {
+		tenantID = c.GetUint64(types.TenantIDContextKey.String())
+	}
+	opts, err := utils.SQLProfileOptions(req.Profile, tenantID)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"code": 1,
+			"msg":  fmt.Sprintf("未知的规则集: %s，可选: %s", req.Profile, strings.Join(utils.SQLValidationProfiles(), ", ")),
+		})
+		return
+	}
+
+	response := ValidateSQLDebugResponse{Profile: req.Profile}
+	response.ParseResult, response.ValidationResult = utils.ValidateSQL(req.SQL, opts...)
+	if response.ValidationResult.Valid {
+		securedSQL, validationResult, err := utils.ValidateAndSecureSQL(req.SQL, opts...)
+		if err != nil {
+			logger.Infof(ctx, "SQL debug rewrite failed: %v", err)
+		} else {
+			response.SecuredSQL = securedSQL
+			response.ValidationResult = validationResult
+		}
+	}
+
+	c.JSON(200, gin.H{
+		"code": 0,
+		"msg":  "success",
+		"data": response,
+	})
+}
+
 // GetStorageEngineStatusResponse is the response for GET /system/storage-engine-status.
 type GetStorageEngineStatusResponse struct {
 	Engines           []StorageEngineStatusItem `json:"engines"`
@@ -751,7 +871,7 @@ func (h *SystemHandler) checkMinio(c *gin.Context, ctx context.Context, cfg *typ
 
 	if cfg.Mode == "remote" {
 		if blocked, reason := isBlockedStorageEndpoint(endpoint); blocked {
-			logger.Warnf(ctx, "Storage check: MinIO endpoint blocked by SSRF protection", "endpoint", endpoint)
+			logger.Warnf(ctx, "Storage check: MinIO endpoint %s blocked by SSRF protection", endpoint)
 			c.JSON(200, gin.H{"code": 0, "data": StorageCheckResponse{OK: false, Message: reason}})
 			return
 		}
@@ -41,8 +41,8 @@ func NewTagHandler(
 	return &TagHandler{tagService: tagService, tagRepo: tagRepo, chunkRepo: chunkRepo, kbService: kbService, kbShareService: kbShareService, agentShareService: agentShareService}
 }
 
-// effectiveCtxForKB validates KB access (owner or shared) and returns context with effectiveTenantID for downstream service calls.
-func (h *TagHandler) effectiveCtxForKB(c *gin.Context, kbID string) (context.Context, error) {
+// effectiveCtxForKB validates KB access (owner, shared, or via shared agent when requiredPermission is Viewer) and returns context with effectiveTenantID.
+func (h *TagHandler) effectiveCtxForKB(c *gin.Context, kbID string, requiredPermission types.OrgMemberRole) (context.Context, error) {
 	ctx := c.Request.Context()
 	tenantID := c.GetUint64(types.TenantIDContextKey.String())
 	if tenantID == 0 {
@@ -53,6 +53,9 @@ func (h *TagHandler) effectiveCtxForKB(c *gin.Context, kbID string) (context.Con
 	if kbID == "" {
 		return nil, errors.NewBadRequestError("Knowledge base ID cannot be empty")
 	}
+	if err := checkAPIKeyScope(c, kbID, requiredPermission); err != nil {
+		return nil, err
+	}
 	kb, err := h.kbService.GetKnowledgeBaseByID(ctx, kbID)
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, nil)
@@ -63,7 +66,7 @@ func (h *TagHandler) effectiveCtxForKB(c *gin.Context, kbID string) (context.Con
 	}
 	if userExists && h.kbShareService != nil {
 		permission, isShared, permErr := h.kbShareService.CheckUserKBPermission(ctx, kbID, userID.(string))
-		if permErr == nil && isShared {
+		if permErr == nil && isShared && permission.HasPermission(requiredPermission) {
 			sourceTenantID, srcErr := h.kbShareService.GetKBSourceTenant(ctx, kbID)
 			if srcErr == nil {
 				logger.Infof(ctx, "User %s accessing shared KB %s with permission %s, source tenant: %d",
@@ -72,7 +75,7 @@ func (h *TagHandler) effectiveCtxForKB(c *gin.Context, kbID string) (context.Con
 			}
 		}
 	}
-	if userExists && h.agentShareService != nil {
+	if requiredPermission == types.OrgRoleViewer && userExists && h.agentShareService != nil {
 		can, err := h.agentShareService.UserCanAccessKBViaSomeSharedAgent(ctx, userID.(string), tenantID, kb)
 		if err == nil && can {
 			logger.Infof(ctx, "User %s accessing KB %s via some shared agent", userID.(string), kbID)
@@ -83,25 +86,26 @@ func (h *TagHandler) effectiveCtxForKB(c *gin.Context, kbID string) (context.Con
 	return nil, errors.NewForbiddenError("Permission denied to access this knowledge base")
 }
 
-// resolveTagID resolves tag_id parameter which can be either UUID or seq_id (integer).
-// Uses tenant from c's context. Use resolveTagIDWithCtx when effectiveTenantID is set (e.g. shared KB).
-func (h *TagHandler) resolveTagID(c *gin.Context) (string, error) {
-	return h.resolveTagIDWithCtx(c, c.Request.Context())
+// resolveTagIDWithCtx resolves the tag_id path parameter using the given context for tenant (e.g. effCtx for shared KB).
+func (h *TagHandler) resolveTagIDWithCtx(c *gin.Context, ctx context.Context, kbID string) (string, error) {
+	return h.resolveKBTagID(ctx, kbID, secutils.SanitizeForLog(c.Param("tag_id")))
 }
 
-// resolveTagIDWithCtx resolves tag_id using the given context for tenant (e.g. effCtx for shared KB).
-func (h *TagHandler) resolveTagIDWithCtx(c *gin.Context, ctx context.Context) (string, error) {
-	tagIDParam := secutils.SanitizeForLog(c.Param("tag_id"))
-
-	if seqID, err := strconv.ParseInt(tagIDParam, 10, 64); err == nil {
-		tenantID := types.MustTenantIDFromContext(ctx)
-		tag, err := h.tagRepo.GetBySeqID(ctx, tenantID, seqID)
-		if err != nil {
-			return "", errors.NewNotFoundError("标签不存在")
-		}
-		return tag.ID, nil
+// resolveKBTagID resolves a tag reference which can be either UUID or seq_id (integer)
+// and makes sure the tag belongs to the knowledge base.
+func (h *TagHandler) resolveKBTagID(ctx context.Context, kbID string, tagRef string) (string, error) {
+	tenantID := types.MustTenantIDFromContext(ctx)
+	var tag *types.KnowledgeTag
+	var err error
+	if seqID, parseErr := strconv.ParseInt(tagRef, 10, 64); parseErr == nil {
+		tag, err = h.tagRepo.GetBySeqID(ctx, tenantID, seqID)
+	} else {
+		tag, err = h.tagRepo.GetByID(ctx, tenantID, tagRef)
+	}
+	if err != nil || tag.KnowledgeBaseID != kbID {
+		return "", errors.NewNotFoundError("标签不存在")
 	}
-	return tagIDParam, nil
+	return tag.ID, nil
 }
 
 // getChunksBySeqIDs retrieves chunks by their seq_ids.
@@ -128,7 +132,7 @@ func (h *TagHandler) ListTags(c *gin.Context) {
 	ctx := c.Request.Context()
 	kbID := secutils.SanitizeForLog(c.Param("id"))
 
-	effCtx, err := h.effectiveCtxForKB(c, kbID)
+	effCtx, err := h.effectiveCtxForKB(c, kbID, types.OrgRoleViewer)
 	if err != nil {
 		c.Error(err)
 		return
@@ -179,7 +183,7 @@ func (h *TagHandler) CreateTag(c *gin.Context) {
 	ctx := c.Request.Context()
 	kbID := secutils.SanitizeForLog(c.Param("id"))
 
-	effCtx, err := h.effectiveCtxForKB(c, kbID)
+	effCtx, err := h.effectiveCtxForKB(c, kbID, types.OrgRoleEditor)
 	if err != nil {
 		c.Error(err)
 		return
@@ -232,13 +236,13 @@ func (h *TagHandler) UpdateTag(c *gin.Context) {
 	ctx := c.Request.Context()
 	kbID := secutils.SanitizeForLog(c.Param("id"))
 
-	effCtx, err := h.effectiveCtxForKB(c, kbID)
+	effCtx, err := h.effectiveCtxForKB(c, kbID, types.OrgRoleEditor)
 	if err != nil {
 		c.Error(err)
 		return
 	}
 
-	tagID, err := h.resolveTagIDWithCtx(c, effCtx)
+	tagID, err := h.resolveTagIDWithCtx(c, effCtx, kbID)
 	if err != nil {
 		c.Error(err)
 		return
@@ -268,7 +272,7 @@ func (h *TagHandler) UpdateTag(c *gin.Context) {
 
 // DeleteTag godoc
 // @Summary      删除标签
-// @Description  删除标签，可使用force=true强制删除被引用的标签，content_only=true仅删除标签下的内容而保留标签本身
+// @Description  删除标签，标签下的知识和FAQ条目默认保留并变为未分类；force=true时一并删除标签下的内容，content_only=true仅删除标签下的内容而保留标签本身
 // @Tags         标签管理
 // @Accept       json
 // @Produce      json
@@ -286,13 +290,13 @@ func (h *TagHandler) DeleteTag(c *gin.Context) {
 	ctx := c.Request.Context()
 	kbID := secutils.SanitizeForLog(c.Param("id"))
 
-	effCtx, err := h.effectiveCtxForKB(c, kbID)
+	effCtx, err := h.effectiveCtxForKB(c, kbID, types.OrgRoleEditor)
 	if err != nil {
 		c.Error(err)
 		return
 	}
 
-	tagID, err := h.resolveTagIDWithCtx(c, effCtx)
+	tagID, err := h.resolveTagIDWithCtx(c, effCtx, kbID)
 	if err != nil {
 		c.Error(err)
 		return
@@ -331,5 +335,69 @@ func (h *TagHandler) DeleteTag(c *gin.Context) {
 	})
 }
 
+// MergeTagsRequest represents the request body for merging two tags
+type MergeTagsRequest struct {
+	SourceTagID string `json:"source_tag_id" binding:"required"` // Tag to merge and delete (UUID or seq_id)
+	TargetTagID string `json:"target_tag_id" binding:"required"` // Tag receiving the content (UUID or seq_id)
+}
+
+// MergeTags godoc
+// @Summary      合并标签
+// @Description  将源标签下的知识和FAQ条目移动到目标标签，然后删除源标签
+// @Tags         标签管理
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string            true  "知识库ID"
+// @Param        request  body      MergeTagsRequest  true  "合并选项"
+// @Success      200      {object}  map[string]interface{}  "目标标签"
+// @Failure      400      {object}  errors.AppError         "请求参数错误"
+// @Failure      404      {object}  errors.AppError         "标签不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/tags/merge [post]
+func (h *TagHandler) MergeTags(c *gin.Context) {
+	ctx := c.Request.Context()
+	kbID := secutils.SanitizeForLog(c.Param("id"))
+
+	effCtx, err := h.effectiveCtxForKB(c, kbID, types.OrgRoleEditor)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req MergeTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to bind merge tags payload", err)
+		c.Error(errors.NewBadRequestError("请求参数不合法").WithDetails(err.Error()))
+		return
+	}
+
+	sourceID, err := h.resolveKBTagID(effCtx, kbID, secutils.SanitizeForLog(req.SourceTagID))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	targetID, err := h.resolveKBTagID(effCtx, kbID, secutils.SanitizeForLog(req.TargetTagID))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	tag, err := h.tagService.MergeTags(effCtx, kbID, sourceID, targetID)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"source_tag_id": sourceID,
+			"target_tag_id": targetID,
+		})
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    tag,
+	})
+}
+
 // NOTE: TagHandler currently exposes CRUD for tags and statistics.
 // Knowledge / Chunk tagging is handled via dedicated knowledge and FAQ APIs.
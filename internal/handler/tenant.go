@@ -1,8 +1,10 @@
 package handler
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 
@@ -20,10 +22,11 @@ import (
 // Provides functionality for creating, retrieving, updating, and deleting tenants
 // through the REST API endpoints
 type TenantHandler struct {
-	service     interfaces.TenantService
-	userService interfaces.UserService
-	kbService   interfaces.KnowledgeBaseService
-	config      *config.Config
+	service      interfaces.TenantService
+	userService  interfaces.UserService
+	kbService    interfaces.KnowledgeBaseService
+	modelService interfaces.ModelService
+	config       *config.Config
 }
 
 // authorizeTenantAccess checks that the authenticated user owns the target tenant
@@ -55,15 +58,23 @@ func (h *TenantHandler) authorizeTenantAccess(c *gin.Context, targetTenantID uin
 // Parameters:
 //   - service: An implementation of the TenantService interface for business logic
 //   - userService: An implementation of the UserService interface for user operations
+//   - modelService: An implementation of the ModelService interface for validating model references
 //   - config: Application configuration
 //
 // Returns a pointer to the newly created TenantHandler
-func NewTenantHandler(service interfaces.TenantService, userService interfaces.UserService, kbService interfaces.KnowledgeBaseService, config *config.Config) *TenantHandler {
+func NewTenantHandler(
+	service interfaces.TenantService,
+	userService interfaces.UserService,
+	kbService interfaces.KnowledgeBaseService,
+	modelService interfaces.ModelService,
+	config *config.Config,
+) *TenantHandler {
 	return &TenantHandler{
-		service:     service,
-		userService: userService,
-		kbService:   kbService,
-		config:      config,
+		service:      service,
+		userService:  userService,
+		kbService:    kbService,
+		modelService: modelService,
+		config:       config,
 	}
 }
 
@@ -640,6 +651,24 @@ func (h *TenantHandler) GetTenantKV(c *gin.Context) {
 	case "retrieval-config":
 		h.GetTenantRetrievalConfig(c)
 		return
+	case "summary-model-defaults":
+		h.GetTenantSummaryModelDefaults(c)
+		return
+	case "history-retention-config":
+		h.GetTenantHistoryRetentionConfig(c)
+		return
+	case "banned-words-config":
+		h.GetTenantBannedWordsConfig(c)
+		return
+	case "redaction-config":
+		h.GetTenantRedactionConfig(c)
+		return
+	case "model-retry-config":
+		h.GetTenantModelRetryConfig(c)
+		return
+	case "embedding-batch-size":
+		h.GetTenantEmbeddingBatchSize(c)
+		return
 	default:
 		logger.Info(ctx, "KV key not supported", "key", key)
 		c.Error(errors.NewBadRequestError("unsupported key"))
@@ -686,6 +715,24 @@ func (h *TenantHandler) UpdateTenantKV(c *gin.Context) {
 	case "retrieval-config":
 		h.updateTenantRetrievalConfigInternal(c)
 		return
+	case "summary-model-defaults":
+		h.updateTenantSummaryModelDefaultsInternal(c)
+		return
+	case "history-retention-config":
+		h.updateTenantHistoryRetentionConfigInternal(c)
+		return
+	case "banned-words-config":
+		h.updateTenantBannedWordsConfigInternal(c)
+		return
+	case "redaction-config":
+		h.updateTenantRedactionConfigInternal(c)
+		return
+	case "model-retry-config":
+		h.updateTenantModelRetryConfigInternal(c)
+		return
+	case "embedding-batch-size":
+		h.updateTenantEmbeddingBatchSizeInternal(c)
+		return
 	default:
 		logger.Info(ctx, "KV key not supported", "key", key)
 		c.Error(errors.NewBadRequestError("unsupported key"))
@@ -1202,3 +1249,439 @@ func (h *TenantHandler) updateTenantRetrievalConfigInternal(c *gin.Context) {
 		"message": "Retrieval configuration updated successfully",
 	})
 }
+
+// GetTenantSummaryModelDefaults returns the tenant's default summary model per knowledge base type.
+func (h *TenantHandler) GetTenantSummaryModelDefaults(c *gin.Context) {
+	ctx := c.Request.Context()
+	tenant, _ := types.TenantInfoFromContext(ctx)
+	if tenant == nil {
+		logger.Error(ctx, "Tenant is empty")
+		c.Error(errors.NewBadRequestError("Tenant is empty"))
+		return
+	}
+	data := tenant.SummaryModelDefaults
+	if data == nil {
+		data = &types.SummaryModelDefaults{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// updateTenantSummaryModelDefaultsInternal updates the tenant's default summary model per knowledge base type.
+// Every configured model must exist and be a KnowledgeQA (chat) model.
+func (h *TenantHandler) updateTenantSummaryModelDefaultsInternal(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var cfg types.SummaryModelDefaults
+	if err := c.ShouldBindJSON(&cfg); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(errors.NewValidationError("Invalid request data").WithDetails(err.Error()))
+		return
+	}
+	cfg.Document = strings.TrimSpace(cfg.Document)
+	cfg.FAQ = strings.TrimSpace(cfg.FAQ)
+
+	tenant, _ := types.TenantInfoFromContext(ctx)
+	if tenant == nil {
+		logger.Error(ctx, "Tenant is empty")
+		c.Error(errors.NewBadRequestError("Tenant is empty"))
+		return
+	}
+
+	for _, kbType := range []string{types.KnowledgeBaseTypeDocument, types.KnowledgeBaseTypeFAQ} {
+		modelID := cfg.ModelIDForType(kbType)
+		if modelID == "" {
+			continue
+		}
+		model, err := h.modelService.GetModelByID(ctx, modelID)
+		if err != nil || model == nil {
+			c.Error(errors.NewBadRequestError(fmt.Sprintf("%s: model %s not found", kbType, modelID)))
+			return
+		}
+		if model.Type != types.ModelTypeKnowledgeQA {
+			c.Error(errors.NewBadRequestError(fmt.Sprintf("%s: model %s is not a chat model", kbType, modelID)))
+			return
+		}
+	}
+
+	tenant.SummaryModelDefaults = &cfg
+	updatedTenant, err := h.service.UpdateTenant(ctx, tenant)
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			c.Error(appErr)
+		} else {
+			logger.ErrorWithFields(ctx, err, nil)
+			c.Error(errors.NewInternalServerError("Failed to update summary model defaults").WithDetails(err.Error()))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    updatedTenant.SummaryModelDefaults,
+		"message": "Summary model defaults updated successfully",
+	})
+}
+
+// GetTenantHistoryRetentionConfig returns the tenant's per-session history retention limits.
+func (h *TenantHandler) GetTenantHistoryRetentionConfig(c *gin.Context) {
+	ctx := c.Request.Context()
+	tenant, _ := types.TenantInfoFromContext(ctx)
+	if tenant == nil {
+		logger.Error(ctx, "Tenant is empty")
+		c.Error(errors.NewBadRequestError("Tenant is empty"))
+		return
+	}
+	data := tenant.HistoryRetentionConfig
+	if data == nil {
+		data = &types.HistoryRetentionConfig{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// updateTenantHistoryRetentionConfigInternal updates the tenant's per-session history retention limits.
+func (h *TenantHandler) updateTenantHistoryRetentionConfigInternal(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var cfg types.HistoryRetentionConfig
+	if err := c.ShouldBindJSON(&cfg); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(errors.NewValidationError("Invalid request data").WithDetails(err.Error()))
+		return
+	}
+
+	if cfg.MaxMessages < 0 || cfg.MaxMessages > types.MaxHistoryRetentionMessages {
+		c.Error(errors.NewBadRequestError(
+			fmt.Sprintf("max_messages must be between 0 and %d", types.MaxHistoryRetentionMessages)))
+		return
+	}
+	if cfg.MaxMessages == 1 {
+		c.Error(errors.NewBadRequestError("max_messages must keep at least one question and answer"))
+		return
+	}
+	if cfg.MaxAgeDays < 0 || cfg.MaxAgeDays > types.MaxHistoryRetentionDays {
+		c.Error(errors.NewBadRequestError(
+			fmt.Sprintf("max_age_days must be between 0 and %d", types.MaxHistoryRetentionDays)))
+		return
+	}
+
+	tenant, _ := types.TenantInfoFromContext(ctx)
+	if tenant == nil {
+		logger.Error(ctx, "Tenant is empty")
+		c.Error(errors.NewBadRequestError("Tenant is empty"))
+		return
+	}
+
+	tenant.HistoryRetentionConfig = &cfg
+	updatedTenant, err := h.service.UpdateTenant(ctx, tenant)
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			c.Error(appErr)
+		} else {
+			logger.ErrorWithFields(ctx, err, nil)
+			c.Error(errors.NewInternalServerError("Failed to update history retention config").WithDetails(err.Error()))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    updatedTenant.HistoryRetentionConfig,
+		"message": "History retention configuration updated successfully",
+	})
+}
+
+// GetTenantBannedWordsConfig returns the tenant's banned words filter for generated answers.
+func (h *TenantHandler) GetTenantBannedWordsConfig(c *gin.Context) {
+	ctx := c.Request.Context()
+	tenant, _ := types.TenantInfoFromContext(ctx)
+	if tenant == nil {
+		logger.Error(ctx, "Tenant is empty")
+		c.Error(errors.NewBadRequestError("Tenant is empty"))
+		return
+	}
+	data := tenant.BannedWordsConfig
+	if data == nil {
+		data = &types.BannedWordsConfig{Phrases: []string{}, Action: types.BannedWordsActionMask}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// updateTenantBannedWordsConfigInternal updates the tenant's banned words filter for generated answers.
+func (h *TenantHandler) updateTenantBannedWordsConfigInternal(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var cfg types.BannedWordsConfig
+	if err := c.ShouldBindJSON(&cfg); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(errors.NewValidationError("Invalid request data").WithDetails(err.Error()))
+		return
+	}
+
+	if cfg.Action == "" {
+		cfg.Action = types.BannedWordsActionMask
+	}
+	if cfg.Action != types.BannedWordsActionMask && cfg.Action != types.BannedWordsActionHalt {
+		c.Error(errors.NewBadRequestError("action must be mask or halt"))
+		return
+	}
+	phrases := make([]string, 0, len(cfg.Phrases))
+	seen := make(map[string]bool, len(cfg.Phrases))
+	for _, p := range cfg.Phrases {
+		p = strings.TrimSpace(p)
+		if p == "" || seen[strings.ToLower(p)] {
+			continue
+		}
+		if len([]rune(p)) > types.MaxBannedPhraseLength {
+			c.Error(errors.NewBadRequestError(
+				fmt.Sprintf("each phrase must be at most %d characters", types.MaxBannedPhraseLength)))
+			return
+		}
+		seen[strings.ToLower(p)] = true
+		phrases = append(phrases, p)
+	}
+	if len(phrases) > types.MaxBannedPhrases {
+		c.Error(errors.NewBadRequestError(fmt.Sprintf("at most %d phrases are allowed", types.MaxBannedPhrases)))
+		return
+	}
+	if cfg.Enabled && len(phrases) == 0 {
+		c.Error(errors.NewBadRequestError("phrases are required when the filter is enabled"))
+		return
+	}
+	cfg.Phrases = phrases
+
+	tenant, _ := types.TenantInfoFromContext(ctx)
+	if tenant == nil {
+		logger.Error(ctx, "Tenant is empty")
+		c.Error(errors.NewBadRequestError("Tenant is empty"))
+		return
+	}
+
+	tenant.BannedWordsConfig = &cfg
+	updatedTenant, err := h.service.UpdateTenant(ctx, tenant)
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			c.Error(appErr)
+		} else {
+			logger.ErrorWithFields(ctx, err, nil)
+			c.Error(errors.NewInternalServerError("Failed to update banned words config").WithDetails(err.Error()))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    updatedTenant.BannedWordsConfig,
+		"message": "Banned words configuration updated successfully",
+	})
+}
+
+// GetTenantRedactionConfig returns the tenant's regex redaction rules for generated answers.
+func (h *TenantHandler) GetTenantRedactionConfig(c *gin.Context) {
+	ctx := c.Request.Context()
+	tenant, _ := types.TenantInfoFromContext(ctx)
+	if tenant == nil {
+		logger.Error(ctx, "Tenant is empty")
+		c.Error(errors.NewBadRequestError("Tenant is empty"))
+		return
+	}
+	data := tenant.RedactionConfig
+	if data == nil {
+		data = &types.RedactionConfig{Rules: []types.RedactionRule{}}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// updateTenantRedactionConfigInternal updates the tenant's regex redaction rules for generated answers.
+func (h *TenantHandler) updateTenantRedactionConfigInternal(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var cfg types.RedactionConfig
+	if err := c.ShouldBindJSON(&cfg); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(errors.NewValidationError("Invalid request data").WithDetails(err.Error()))
+		return
+	}
+	if err := cfg.Validate(); err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+	if cfg.Rules == nil {
+		cfg.Rules = []types.RedactionRule{}
+	}
+
+	tenant, _ := types.TenantInfoFromContext(ctx)
+	if tenant == nil {
+		logger.Error(ctx, "Tenant is empty")
+		c.Error(errors.NewBadRequestError("Tenant is empty"))
+		return
+	}
+
+	tenant.RedactionConfig = &cfg
+	updatedTenant, err := h.service.UpdateTenant(ctx, tenant)
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			c.Error(appErr)
+		} else {
+			logger.ErrorWithFields(ctx, err, nil)
+			c.Error(errors.NewInternalServerError("Failed to update redaction config").WithDetails(err.Error()))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    updatedTenant.RedactionConfig,
+		"message": "Redaction configuration updated successfully",
+	})
+}
+
+// GetTenantModelRetryConfig returns the retry policy applied to the tenant's model calls:
+// the tenant override when set, otherwise the global default.
+func (h *TenantHandler) GetTenantModelRetryConfig(c *gin.Context) {
+	ctx := c.Request.Context()
+	tenant, _ := types.TenantInfoFromContext(ctx)
+	if tenant == nil {
+		logger.Error(ctx, "Tenant is empty")
+		c.Error(errors.NewBadRequestError("Tenant is empty"))
+		return
+	}
+	data := tenant.ModelRetryConfig
+	if data == nil && h.config != nil {
+		data = h.config.ModelRetry
+	}
+	if data == nil {
+		data = &types.ModelRetryConfig{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// updateTenantModelRetryConfigInternal updates the tenant's retry policy for model calls.
+func (h *TenantHandler) updateTenantModelRetryConfigInternal(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var cfg types.ModelRetryConfig
+	if err := c.ShouldBindJSON(&cfg); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(errors.NewValidationError("Invalid request data").WithDetails(err.Error()))
+		return
+	}
+
+	if cfg.MaxAttempts < 0 || cfg.MaxAttempts > types.MaxModelRetryAttempts {
+		c.Error(errors.NewBadRequestError(
+			fmt.Sprintf("max_attempts must be between 0 and %d", types.MaxModelRetryAttempts)))
+		return
+	}
+	if cfg.InitialBackoffMs < 0 || cfg.InitialBackoffMs > types.MaxModelRetryBackoffMs ||
+		cfg.MaxBackoffMs < 0 || cfg.MaxBackoffMs > types.MaxModelRetryBackoffMs {
+		c.Error(errors.NewBadRequestError(
+			fmt.Sprintf("backoff values must be between 0 and %d ms", types.MaxModelRetryBackoffMs)))
+		return
+	}
+	if cfg.MaxBackoffMs > 0 && cfg.InitialBackoffMs > cfg.MaxBackoffMs {
+		c.Error(errors.NewBadRequestError("initial_backoff_ms must not exceed max_backoff_ms"))
+		return
+	}
+
+	tenant, _ := types.TenantInfoFromContext(ctx)
+	if tenant == nil {
+		logger.Error(ctx, "Tenant is empty")
+		c.Error(errors.NewBadRequestError("Tenant is empty"))
+		return
+	}
+
+	tenant.ModelRetryConfig = &cfg
+	updatedTenant, err := h.service.UpdateTenant(ctx, tenant)
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			c.Error(appErr)
+		} else {
+			logger.ErrorWithFields(ctx, err, nil)
+			c.Error(errors.NewInternalServerError("Failed to update model retry config").WithDetails(err.Error()))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    updatedTenant.ModelRetryConfig,
+		"message": "Model retry configuration updated successfully",
+	})
+}
+
+// embeddingBatchSizeRequest is the KV payload of "embedding-batch-size"
+type embeddingBatchSizeRequest struct {
+	EmbeddingBatchSize int `json:"embedding_batch_size"`
+}
+
+// GetTenantEmbeddingBatchSize returns the tenant's embedding batch size and the size in effect.
+func (h *TenantHandler) GetTenantEmbeddingBatchSize(c *gin.Context) {
+	ctx := c.Request.Context()
+	tenant, _ := types.TenantInfoFromContext(ctx)
+	if tenant == nil {
+		logger.Error(ctx, "Tenant is empty")
+		c.Error(errors.NewBadRequestError("Tenant is empty"))
+		return
+	}
+	globalSize := 0
+	if h.config != nil && h.config.KnowledgeBase != nil {
+		globalSize = h.config.KnowledgeBase.EmbeddingBatchSize
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"embedding_batch_size": tenant.EmbeddingBatchSize,
+			"effective":            types.ResolveEmbeddingBatchSize(tenant.EmbeddingBatchSize, globalSize),
+		},
+	})
+}
+
+// updateTenantEmbeddingBatchSizeInternal updates the tenant's embedding batch size.
+func (h *TenantHandler) updateTenantEmbeddingBatchSizeInternal(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req embeddingBatchSizeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(errors.NewValidationError("Invalid request data").WithDetails(err.Error()))
+		return
+	}
+	if !types.IsValidEmbeddingBatchSize(req.EmbeddingBatchSize) {
+		c.Error(errors.NewBadRequestError(
+			fmt.Sprintf("embedding_batch_size must be between 0 and %d", types.MaxEmbeddingBatchSize)))
+		return
+	}
+
+	tenant, _ := types.TenantInfoFromContext(ctx)
+	if tenant == nil {
+		logger.Error(ctx, "Tenant is empty")
+		c.Error(errors.NewBadRequestError("Tenant is empty"))
+		return
+	}
+
+	tenant.EmbeddingBatchSize = req.EmbeddingBatchSize
+	updatedTenant, err := h.service.UpdateTenant(ctx, tenant)
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			c.Error(appErr)
+		} else {
+			logger.ErrorWithFields(ctx, err, nil)
+			c.Error(errors.NewInternalServerError("Failed to update embedding batch size").WithDetails(err.Error()))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    embeddingBatchSizeRequest{EmbeddingBatchSize: updatedTenant.EmbeddingBatchSize},
+		"message": "Embedding batch size updated successfully",
+	})
+}
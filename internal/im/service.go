@@ -292,6 +292,7 @@ func (s *Service) handleMessageStream(ctx context.Context, msg *IncomingMessage,
 	defer qaCancel()
 
 	eventBus := event.NewEventBus()
+	event.InstallAnswerFilters(ctx, eventBus)
 
 	var (
 		bufMu         sync.Mutex
@@ -382,10 +383,17 @@ func (s *Service) handleMessageStream(ctx context.Context, msg *IncomingMessage,
 	// Run QA async
 	go func() {
 		var err error
+		req := &types.QARequest{
+			Session:            session,
+			Query:              msg.Content,
+			AssistantMessageID: assistantMsg.ID,
+			KnowledgeBaseIDs:   kbIDs,
+			CustomAgent:        customAgent,
+		}
 		if useAgent {
-			err = s.sessionService.AgentQA(qaCtx, session, msg.Content, assistantMsg.ID, "", eventBus, customAgent, kbIDs, nil)
+			err = s.sessionService.AgentQA(qaCtx, req, eventBus)
 		} else {
-			err = s.sessionService.KnowledgeQA(qaCtx, session, msg.Content, kbIDs, nil, assistantMsg.ID, "", false, eventBus, customAgent, false)
+			err = s.sessionService.KnowledgeQA(qaCtx, req, eventBus)
 		}
 		if err != nil {
 			logger.Errorf(ctx, "[IM] QA stream execution error: %v", err)
@@ -479,41 +487,12 @@ func (s *Service) runQA(ctx context.Context, session *types.Session, query strin
 	defer cancel()
 
 	eventBus := event.NewEventBus()
+	event.InstallAnswerFilters(ctx, eventBus)
 
-	// Thread-safe answer collection
-	var answerMu sync.Mutex
-	var answerBuilder strings.Builder
-	var qaErr error
-	done := make(chan struct{})
-	var closeOnce sync.Once
-	closeDone := func() { closeOnce.Do(func() { close(done) }) }
-
-	eventBus.On(event.EventAgentFinalAnswer, func(ctx context.Context, evt event.Event) error {
-		data, ok := evt.Data.(event.AgentFinalAnswerData)
-		if !ok {
-			return nil
-		}
-		answerMu.Lock()
-		answerBuilder.WriteString(data.Content)
-		answerMu.Unlock()
-		if data.Done {
-			closeDone()
-		}
-		return nil
-	})
-
-	eventBus.On(event.EventError, func(ctx context.Context, evt event.Event) error {
-		data, ok := evt.Data.(event.ErrorData)
-		if !ok {
-			return nil
-		}
-		logger.Errorf(ctx, "[IM] QA error: %s", data.Error)
-		answerMu.Lock()
-		qaErr = fmt.Errorf("QA pipeline error: %s", data.Error)
-		answerMu.Unlock()
-		closeDone()
-		return nil
-	})
+	// Only the answer and errors are needed; the bus belongs to this request, so no session filter
+	events, unsubscribe := eventBus.SubscribeTypes("", event.EventAgentFinalAnswer, event.EventError)
+	defer unsubscribe()
+	execErr := make(chan error, 1)
 
 	// Determine whether to use agent mode
 	useAgent := customAgent != nil && customAgent.IsAgentMode()
@@ -550,38 +529,62 @@ func (s *Service) runQA(ctx context.Context, session *types.Session, query strin
 	// Run QA async
 	go func() {
 		var err error
+		req := &types.QARequest{
+			Session:            session,
+			Query:              query,
+			AssistantMessageID: assistantMsg.ID,
+			KnowledgeBaseIDs:   kbIDs,
+			CustomAgent:        customAgent,
+		}
 		if useAgent {
-			err = s.sessionService.AgentQA(ctx, session, query, assistantMsg.ID, "", eventBus, customAgent, kbIDs, nil)
+			err = s.sessionService.AgentQA(ctx, req, eventBus)
 		} else {
-			err = s.sessionService.KnowledgeQA(ctx, session, query, kbIDs, nil, assistantMsg.ID, "", false, eventBus, customAgent, false)
+			err = s.sessionService.KnowledgeQA(ctx, req, eventBus)
 		}
 		if err != nil {
 			logger.Errorf(ctx, "[IM] QA execution error: %v", err)
-			answerMu.Lock()
-			qaErr = fmt.Errorf("QA execution error: %w", err)
-			answerMu.Unlock()
-			closeDone()
+			execErr <- fmt.Errorf("QA execution error: %w", err)
 		}
 	}()
 
-	// Wait for completion or timeout
-	select {
-	case <-done:
-	case <-ctx.Done():
-		// Mark assistant message as completed to avoid dangling incomplete records
-		assistantMsg.Content = "抱歉，回答超时，请稍后再试。"
-		assistantMsg.IsCompleted = true
-		// Use a fresh context since the original is cancelled
-		if updateErr := s.messageService.UpdateMessage(context.WithoutCancel(ctx), assistantMsg); updateErr != nil {
-			logger.Warnf(ctx, "[IM] Failed to update timed-out assistant message: %v", updateErr)
+	// Collect the answer until it completes, fails or times out
+	var answerBuilder strings.Builder
+	var qaError error
+collect:
+	for {
+		select {
+		case evt, ok := <-events:
+			if !ok {
+				qaError = fmt.Errorf("QA event stream closed before the answer completed")
+				break collect
+			}
+			switch data := evt.Data.(type) {
+			case event.AgentFinalAnswerData:
+				answerBuilder.WriteString(data.Content)
+				if data.Done {
+					break collect
+				}
+			case event.ErrorData:
+				logger.Errorf(ctx, "[IM] QA error: %s", data.Error)
+				qaError = fmt.Errorf("QA pipeline error: %s", data.Error)
+				break collect
+			}
+		case err := <-execErr:
+			qaError = err
+			break collect
+		case <-ctx.Done():
+			// Mark assistant message as completed to avoid dangling incomplete records
+			assistantMsg.Content = "抱歉，回答超时，请稍后再试。"
+			assistantMsg.IsCompleted = true
+			// Use a fresh context since the original is cancelled
+			if updateErr := s.messageService.UpdateMessage(context.WithoutCancel(ctx), assistantMsg); updateErr != nil {
+				logger.Warnf(ctx, "[IM] Failed to update timed-out assistant message: %v", updateErr)
+			}
+			return "", fmt.Errorf("QA timed out after %v", qaTimeout)
 		}
-		return "", fmt.Errorf("QA timed out after %v", qaTimeout)
 	}
 
-	answerMu.Lock()
 	answer := answerBuilder.String()
-	qaError := qaErr
-	answerMu.Unlock()
 
 	if answer == "" && qaError != nil {
 		return "", qaError
@@ -38,6 +38,82 @@ func isNoAuthAPI(path string, method string) bool {
 	return false
 }
 
+// scopedAPIKeyRoutes are the routes a scoped API key may call, as "METHOD path". Only the content
+// of a knowledge base (knowledge, chunks, FAQ entries, tags and search) is reachable; management
+// routes such as sharing, copying, pinning, updating or deleting the knowledge base are not.
+// The handlers behind these routes check the knowledge base of each request against the key's scope.
+var scopedAPIKeyRoutes = map[string]bool{
+	// Knowledge base
+	"GET /api/v1/knowledge-bases/:id":               true,
+	"GET /api/v1/knowledge-bases/:id/stats":         true,
+	"GET /api/v1/knowledge-bases/:id/hybrid-search": true,
+	// Knowledge in a knowledge base
+	"GET /api/v1/knowledge-bases/:id/knowledge":                true,
+	"GET /api/v1/knowledge-bases/:id/knowledge/by-filename":    true,
+	"POST /api/v1/knowledge-bases/:id/knowledge/file":          true,
+	"POST /api/v1/knowledge-bases/:id/knowledge/file/validate": true,
+	"POST /api/v1/knowledge-bases/:id/knowledge/url":           true,
+	"POST /api/v1/knowledge-bases/:id/knowledge/manual":        true,
+	// FAQ entries
+	"GET /api/v1/knowledge-bases/:id/faq/entries":                              true,
+	"GET /api/v1/knowledge-bases/:id/faq/entries/export":                       true,
+	"GET /api/v1/knowledge-bases/:id/faq/entries/:entry_id":                    true,
+	"POST /api/v1/knowledge-bases/:id/faq/entries":                             true,
+	"POST /api/v1/knowledge-bases/:id/faq/entry":                               true,
+	"PUT /api/v1/knowledge-bases/:id/faq/entries/:entry_id":                    true,
+	"POST /api/v1/knowledge-bases/:id/faq/entries/:entry_id/similar-questions": true,
+	"PUT /api/v1/knowledge-bases/:id/faq/entries/fields":                       true,
+	"PUT /api/v1/knowledge-bases/:id/faq/entries/tags":                         true,
+	"DELETE /api/v1/knowledge-bases/:id/faq/entries":                           true,
+	"POST /api/v1/knowledge-bases/:id/faq/search":                              true,
+	"PUT /api/v1/knowledge-bases/:id/faq/import/last-result/display":           true,
+	// Tags
+	"GET /api/v1/knowledge-bases/:id/tags":            true,
+	"POST /api/v1/knowledge-bases/:id/tags":           true,
+	"POST /api/v1/knowledge-bases/:id/tags/merge":     true,
+	"PUT /api/v1/knowledge-bases/:id/tags/:tag_id":    true,
+	"DELETE /api/v1/knowledge-bases/:id/tags/:tag_id": true,
+	// A single knowledge and its chunks
+	"GET /api/v1/knowledge/:id":                     true,
+	"PUT /api/v1/knowledge/:id":                     true,
+	"DELETE /api/v1/knowledge/:id":                  true,
+	"PUT /api/v1/knowledge/manual/:id":              true,
+	"POST /api/v1/knowledge/:id/reparse":            true,
+	"POST /api/v1/knowledge/:id/retry":              true,
+	"GET /api/v1/knowledge/:id/chunks":              true,
+	"PUT /api/v1/knowledge/:id/chunks/:chunk_id":    true,
+	"DELETE /api/v1/knowledge/:id/chunks/:chunk_id": true,
+	"GET /api/v1/knowledge/:id/download":            true,
+	"GET /api/v1/knowledge/:id/preview":             true,
+	"PUT /api/v1/knowledge/image/:id/:chunk_id":     true,
+}
+
+// scopedAPIKeyReadRoutes are POST routes that only read and stay available to viewer keys
+var scopedAPIKeyReadRoutes = []string{
+	"/api/v1/knowledge-bases/:id/faq/search",
+}
+
+// scopedAPIKeyAllows checks whether a scoped API key may call the matched route.
+// Knowledge base routes must name a knowledge base of the key, and viewer keys may only read.
+func scopedAPIKeyAllows(c *gin.Context, key *types.APIKey) bool {
+	route := c.FullPath()
+	method := c.Request.Method
+	if method == http.MethodHead {
+		method = http.MethodGet
+	}
+	if !scopedAPIKeyRoutes[method+" "+route] {
+		return false
+	}
+	if strings.HasPrefix(route, "/api/v1/knowledge-bases/:id") && !key.AllowsKnowledgeBase(c.Param("id")) {
+		return false
+	}
+	if key.Permission.HasPermission(types.OrgRoleEditor) {
+		return true
+	}
+	return method == http.MethodGet ||
+		(method == http.MethodPost && slices.Contains(scopedAPIKeyReadRoutes, route))
+}
+
 // canAccessTenant checks if a user can access a target tenant
 func canAccessTenant(user *types.User, targetTenantID uint64, cfg *config.Config) bool {
 	// 1. 检查功能是否启用
@@ -60,6 +136,7 @@ func canAccessTenant(user *types.User, targetTenantID uint64, cfg *config.Config
 func Auth(
 	tenantService interfaces.TenantService,
 	userService interfaces.UserService,
+	apiKeyService interfaces.APIKeyService,
 	cfg *config.Config,
 ) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -151,6 +228,54 @@ func Auth(
 
 		// 尝试X-API-Key认证（兼容模式）
 		apiKey := c.GetHeader("X-API-Key")
+		if apiKey != "" && types.IsScopedAPIKey(apiKey) && apiKeyService != nil {
+			// Scoped API key: limited to the knowledge bases and permission of the key
+			key, err := apiKeyService.Authenticate(c.Request.Context(), apiKey)
+			if err == nil && key != nil {
+				if !scopedAPIKeyAllows(c, key) {
+					c.JSON(http.StatusForbidden, gin.H{
+						"error": "Forbidden: API key is not allowed to access this resource",
+					})
+					c.Abort()
+					return
+				}
+				t, err := tenantService.GetTenantByID(c.Request.Context(), key.TenantID)
+				if err != nil || t == nil {
+					log.Printf("Error getting tenant of API key %s: %v, tenantID: %d", key.ID, err, key.TenantID)
+					c.JSON(http.StatusUnauthorized, gin.H{
+						"error": "Unauthorized: invalid API key",
+					})
+					c.Abort()
+					return
+				}
+
+				c.Set(types.TenantIDContextKey.String(), key.TenantID)
+				c.Set(types.TenantInfoContextKey.String(), t)
+				c.Set(types.APIKeyContextKey.String(), key)
+				ctx := context.WithValue(
+					context.WithValue(
+						context.WithValue(c.Request.Context(), types.TenantIDContextKey, key.TenantID),
+						types.TenantInfoContextKey, t,
+					),
+					types.APIKeyContextKey, key,
+				)
+
+				user, err := userService.GetUserByTenantID(c.Request.Context(), key.TenantID)
+				if err == nil && user != nil {
+					c.Set(types.UserContextKey.String(), user)
+					c.Set(types.UserIDContextKey.String(), user.ID)
+					ctx = context.WithValue(
+						context.WithValue(ctx, types.UserContextKey, user),
+						types.UserIDContextKey, user.ID,
+					)
+				}
+
+				c.Request = c.Request.WithContext(ctx)
+				c.Next()
+				return
+			}
+			// Not a scoped key after all: tenant API keys may start with the same characters
+		}
 		if apiKey != "" {
 			// Get tenant information
 			tenantID, err := tenantService.ExtractTenantIDFromAPIKey(apiKey)
@@ -212,6 +337,22 @@ func Auth(
 	}
 }
 
+// RequireSystemAdmin 仅允许系统管理员（具备跨租户访问权限的用户）访问，需在 Auth 之后使用
+func RequireSystemAdmin(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := c.Request.Context().Value(types.UserContextKey).(*types.User)
+		if !ok || user == nil || cfg == nil || cfg.Tenant == nil ||
+			!cfg.Tenant.EnableCrossTenantAccess || !user.CanAccessAllTenants {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Forbidden: system administrator access required",
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
 // GetTenantIDFromContext helper function to get tenant ID from context
 func GetTenantIDFromContext(ctx context.Context) (uint64, error) {
 	tenantID, ok := ctx.Value("tenantID").(uint64)
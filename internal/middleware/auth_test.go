@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/gin-gonic/gin"
+)
+
+type scopedKeyService struct {
+	interfaces.APIKeyService
+	keys map[string]*types.APIKey
+}
+
+func (s *scopedKeyService) Authenticate(ctx context.Context, rawKey string) (*types.APIKey, error) {
+	if key, ok := s.keys[rawKey]; ok {
+		return key, nil
+	}
+	return nil, errors.New("not found")
+}
+
+type stubTenantService struct {
+	interfaces.TenantService
+}
+
+func (s *stubTenantService) GetTenantByID(ctx context.Context, id uint64) (*types.Tenant, error) {
+	return &types.Tenant{ID: id}, nil
+}
+
+func (s *stubTenantService) ExtractTenantIDFromAPIKey(apiKey string) (uint64, error) {
+	return 0, errors.New("invalid API key format")
+}
+
+type stubUserService struct {
+	interfaces.UserService
+}
+
+func (s *stubUserService) GetUserByTenantID(ctx context.Context, tenantID uint64) (*types.User, error) {
+	return nil, errors.New("no user")
+}
+
+func TestAuthScopedAPIKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	keys := &scopedKeyService{keys: map[string]*types.APIKey{
+		"sk-kb-viewer": {ID: "viewer", TenantID: 1, KnowledgeBaseIDs: types.StringArray{"kb-1"}, Permission: types.OrgRoleViewer},
+		"sk-kb-editor": {ID: "editor", TenantID: 1, KnowledgeBaseIDs: types.StringArray{"kb-1"}, Permission: types.OrgRoleEditor},
+	}}
+	r := gin.New()
+	r.Use(Auth(&stubTenantService{}, &stubUserService{}, keys, nil))
+	ok := func(c *gin.Context) {
+		if key, found := types.APIKeyFromContext(c.Request.Context()); !found || c.GetUint64(types.TenantIDContextKey.String()) != key.TenantID {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.Status(http.StatusOK)
+	}
+	r.GET("/api/v1/knowledge-bases", ok)
+	r.GET("/api/v1/knowledge-bases/:id", ok)
+	r.GET("/api/v1/knowledge-bases/:id/move-targets", ok)
+	r.POST("/api/v1/knowledge-bases/:id/knowledge/file", ok)
+	r.POST("/api/v1/knowledge-bases/:id/faq/search", ok)
+	r.DELETE("/api/v1/knowledge/:id", ok)
+	r.GET("/api/v1/knowledge/search", ok)
+	r.POST("/api/v1/api-keys", ok)
+	r.PUT("/api/v1/knowledge-bases/:id", ok)
+	r.DELETE("/api/v1/knowledge-bases/:id", ok)
+	r.PUT("/api/v1/knowledge-bases/:id/pin", ok)
+	r.POST("/api/v1/knowledge-bases/:id/shares", ok)
+	r.PUT("/api/v1/knowledge-bases/:id/shares/:share_id", ok)
+	r.DELETE("/api/v1/knowledge-bases/:id/shares/:share_id", ok)
+	r.POST("/api/v1/knowledge-bases/:id/copy-to-user", ok)
+	r.POST("/api/v1/knowledge-bases/:id/tags", ok)
+	r.GET("/api/v1/knowledge/:id/chunks", ok)
+
+	tests := []struct {
+		key, method, path string
+		want              int
+	}{
+		{"sk-kb-viewer", http.MethodGet, "/api/v1/knowledge-bases/kb-1", http.StatusOK},
+		{"sk-kb-viewer", http.MethodPost, "/api/v1/knowledge-bases/kb-1/faq/search", http.StatusOK},
+		{"sk-kb-viewer", http.MethodPost, "/api/v1/knowledge-bases/kb-1/knowledge/file", http.StatusForbidden},
+		{"sk-kb-viewer", http.MethodDelete, "/api/v1/knowledge/k-1", http.StatusForbidden},
+		{"sk-kb-viewer", http.MethodGet, "/api/v1/knowledge-bases/kb-2", http.StatusForbidden},
+		{"sk-kb-editor", http.MethodPost, "/api/v1/knowledge-bases/kb-1/knowledge/file", http.StatusOK},
+		{"sk-kb-editor", http.MethodDelete, "/api/v1/knowledge/k-1", http.StatusOK},
+		{"sk-kb-editor", http.MethodGet, "/api/v1/knowledge-bases", http.StatusForbidden},
+		{"sk-kb-editor", http.MethodGet, "/api/v1/knowledge-bases/kb-1/move-targets", http.StatusForbidden},
+		{"sk-kb-editor", http.MethodGet, "/api/v1/knowledge/search", http.StatusForbidden},
+		{"sk-kb-editor", http.MethodPost, "/api/v1/api-keys", http.StatusForbidden},
+		{"sk-kb-editor", http.MethodPost, "/api/v1/knowledge-bases/kb-1/tags", http.StatusOK},
+		{"sk-kb-editor", http.MethodGet, "/api/v1/knowledge/k-1/chunks", http.StatusOK},
+		{"sk-kb-viewer", http.MethodPost, "/api/v1/knowledge-bases/kb-1/tags", http.StatusForbidden},
+		// Management routes stay out of reach even for editor keys in scope
+		{"sk-kb-editor", http.MethodPut, "/api/v1/knowledge-bases/kb-1", http.StatusForbidden},
+		{"sk-kb-editor", http.MethodDelete, "/api/v1/knowledge-bases/kb-1", http.StatusForbidden},
+		{"sk-kb-editor", http.MethodPut, "/api/v1/knowledge-bases/kb-1/pin", http.StatusForbidden},
+		{"sk-kb-editor", http.MethodPost, "/api/v1/knowledge-bases/kb-1/shares", http.StatusForbidden},
+		{"sk-kb-editor", http.MethodPut, "/api/v1/knowledge-bases/kb-1/shares/s-1", http.StatusForbidden},
+		{"sk-kb-editor", http.MethodDelete, "/api/v1/knowledge-bases/kb-1/shares/s-1", http.StatusForbidden},
+		{"sk-kb-editor", http.MethodPost, "/api/v1/knowledge-bases/kb-1/copy-to-user", http.StatusForbidden},
+		{"sk-kb-unknown", http.MethodGet, "/api/v1/knowledge-bases/kb-1", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key+" "+tt.method+" "+tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("X-API-Key", tt.key)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
@@ -26,17 +26,19 @@ type FunctionDef struct {
 
 // ChatOptions 聊天选项
 type ChatOptions struct {
-	Temperature         float64         `json:"temperature"`           // 温度参数
-	TopP                float64         `json:"top_p"`                 // Top P 参数
-	Seed                int             `json:"seed"`                  // 随机种子
-	MaxTokens           int             `json:"max_tokens"`            // 最大 token 数
-	MaxCompletionTokens int             `json:"max_completion_tokens"` // 最大完成 token 数
-	FrequencyPenalty    float64         `json:"frequency_penalty"`     // 频率惩罚
-	PresencePenalty     float64         `json:"presence_penalty"`      // 存在惩罚
-	Thinking            *bool           `json:"thinking"`              // 是否启用思考
-	Tools               []Tool          `json:"tools,omitempty"`       // 可用工具列表
-	ToolChoice          string          `json:"tool_choice,omitempty"` // "auto", "required", "none", or specific tool
-	Format              json.RawMessage `json:"format,omitempty"`      // 响应格式定义
+	Temperature         float64         `json:"temperature"`               // 温度参数
+	TopP                float64         `json:"top_p"`                     // Top P 参数
+	Seed                int             `json:"seed"`                      // 随机种子
+	MaxTokens           int             `json:"max_tokens"`                // 最大 token 数
+	MaxCompletionTokens int             `json:"max_completion_tokens"`     // 最大完成 token 数
+	FrequencyPenalty    float64         `json:"frequency_penalty"`         // 频率惩罚
+	PresencePenalty     float64         `json:"presence_penalty"`          // 存在惩罚
+	Thinking            *bool           `json:"thinking"`                  // 是否启用思考
+	Tools               []Tool          `json:"tools,omitempty"`           // 可用工具列表
+	ToolChoice          string          `json:"tool_choice,omitempty"`     // "auto", "required", "none", or specific tool
+	Format              json.RawMessage `json:"format,omitempty"`          // 响应格式定义
+	Stop                []string        `json:"stop,omitempty"`            // 停止序列
+	ResponseFormat      string          `json:"response_format,omitempty"` // 响应格式: "text" 或 "json_object"
 }
 
 // Message 表示聊天消息
@@ -76,6 +78,14 @@ type Chat interface {
 	GetModelID() string
 }
 
+// Probe 发送最小化的请求检查模型是否可用，模型连接检查和模型预热共用
+func Probe(ctx context.Context, c Chat) error {
+	thinking := false // for dashscope.aliyuncs qwen3-32b
+	_, err := c.Chat(ctx, []Message{{Role: "user", Content: "test"}},
+		&ChatOptions{MaxTokens: 1, Thinking: &thinking})
+	return err
+}
+
 type ChatConfig struct {
 	Source    types.ModelSource
 	BaseURL   string
@@ -0,0 +1,88 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// JSONModeSupporter is implemented by chat models that can enforce JSON output natively
+type JSONModeSupporter interface {
+	SupportsJSONMode() bool
+}
+
+// SupportsJSONMode reports whether the chat model enforces JSON output natively.
+// Models that do not implement JSONModeSupporter are treated as unsupported.
+func SupportsJSONMode(c Chat) bool {
+	s, ok := c.(JSONModeSupporter)
+	return ok && s.SupportsJSONMode()
+}
+
+// ApplyJSONMode configures opts for JSON output when the model supports it natively and reports
+// whether it did. Callers add BuildJSONModeInstruction to the prompt either way: OpenAI-compatible
+// APIs reject json_object requests whose messages do not mention JSON. The schema is only sent
+// through that instruction, as setting opts.Format would repeat it in the last message.
+func ApplyJSONMode(c Chat, opts *ChatOptions) bool {
+	if opts == nil || !SupportsJSONMode(c) {
+		return false
+	}
+	opts.ResponseFormat = types.ResponseFormatJSONObject
+	return true
+}
+
+// BuildJSONModeInstruction returns a prompt instruction that asks the model to reply with JSON only
+func BuildJSONModeInstruction(schema string) string {
+	instruction := "Respond ONLY with a single valid JSON object. " +
+		"Do not wrap it in markdown code fences and do not add any text before or after it."
+	if schema != "" {
+		instruction += fmt.Sprintf("\nThe JSON object must conform to this JSON schema:\n%s", schema)
+	}
+	return instruction
+}
+
+// NormalizeJSONAnswer strips surrounding whitespace and markdown code fences from an answer
+// and reports whether the remaining content is valid JSON
+func NormalizeJSONAnswer(answer string) (string, bool) {
+	normalized := strings.TrimSpace(answer)
+	if strings.HasPrefix(normalized, "```") {
+		normalized = strings.TrimPrefix(normalized, "```json")
+		normalized = strings.TrimPrefix(normalized, "```")
+		normalized = strings.TrimSuffix(normalized, "```")
+		normalized = strings.TrimSpace(normalized)
+	}
+	return normalized, normalized != "" && json.Valid([]byte(normalized))
+}
+
+// RepairJSONAnswer asks the model once to rewrite an invalid answer as valid JSON.
+// An error is returned if the model call fails or the repaired output is still not valid JSON.
+func RepairJSONAnswer(ctx context.Context, c Chat, answer string, schema string, opts *ChatOptions) (string, error) {
+	repairOpts := &ChatOptions{}
+	if opts != nil {
+		repairOpts.MaxCompletionTokens = opts.MaxCompletionTokens
+		repairOpts.ResponseFormat = opts.ResponseFormat
+	}
+	disableThinking := false
+	repairOpts.Thinking = &disableThinking
+
+	messages := []Message{
+		{Role: "system", Content: BuildJSONModeInstruction(schema)},
+		{
+			Role: "user",
+			Content: fmt.Sprintf("The following answer was supposed to be valid JSON but failed to parse. "+
+				"Rewrite it as valid JSON, preserving its content:\n\n%s", answer),
+		},
+	}
+
+	resp, err := c.Chat(ctx, messages, repairOpts)
+	if err != nil {
+		return "", fmt.Errorf("json repair call failed: %w", err)
+	}
+	repaired, ok := NormalizeJSONAnswer(resp.Content)
+	if !ok {
+		return "", fmt.Errorf("json repair produced invalid JSON")
+	}
+	return repaired, nil
+}
@@ -0,0 +1,96 @@
+package chat
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/models/provider"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestNormalizeJSONAnswer(t *testing.T) {
+	tests := []struct {
+		name   string
+		answer string
+		want   string
+		valid  bool
+	}{
+		{"plain object", `{"a":1}`, `{"a":1}`, true},
+		{"surrounding whitespace", "\n  {\"a\":1}  \n", `{"a":1}`, true},
+		{"json code fence", "```json\n{\"a\":1}\n```", `{"a":1}`, true},
+		{"bare code fence", "```\n[1,2]\n```", `[1,2]`, true},
+		{"prose", "The answer is 42", "The answer is 42", false},
+		{"truncated object", `{"a":`, `{"a":`, false},
+		{"empty", "   ", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, valid := NormalizeJSONAnswer(tt.answer)
+			if got != tt.want || valid != tt.valid {
+				t.Errorf("NormalizeJSONAnswer(%q) = (%q, %v), want (%q, %v)", tt.answer, got, valid, tt.want, tt.valid)
+			}
+		})
+	}
+}
+
+func TestRemoteAPIChatSupportsJSONMode(t *testing.T) {
+	tests := []struct {
+		provider provider.ProviderName
+		want     bool
+	}{
+		{provider.ProviderOpenAI, true},
+		{provider.ProviderDeepSeek, true},
+		{provider.ProviderAliyun, true},
+		{provider.ProviderGeneric, false},
+		{provider.ProviderLKEAP, false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.provider), func(t *testing.T) {
+			model, err := NewRemoteAPIChat(&ChatConfig{Provider: string(tt.provider), ModelName: "m"})
+			if err != nil {
+				t.Fatalf("NewRemoteAPIChat() error = %v", err)
+			}
+			if got := SupportsJSONMode(model); got != tt.want {
+				t.Errorf("SupportsJSONMode() = %v, want %v", got, tt.want)
+			}
+
+			opts := &ChatOptions{}
+			applied := ApplyJSONMode(model, opts)
+			if applied != tt.want || (opts.ResponseFormat == types.ResponseFormatJSONObject) != tt.want {
+				t.Errorf("ApplyJSONMode() = %v with response format %q, want %v", applied, opts.ResponseFormat, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONModeSendsSchemaOnce(t *testing.T) {
+	model, err := NewRemoteAPIChat(&ChatConfig{Provider: string(provider.ProviderOpenAI), ModelName: "m"})
+	if err != nil {
+		t.Fatalf("NewRemoteAPIChat() error = %v", err)
+	}
+	schema := `{"type":"object","properties":{"answer":{"type":"string"}}}`
+	opts := &ChatOptions{}
+	if !ApplyJSONMode(model, opts) {
+		t.Fatal("ApplyJSONMode() = false, want true")
+	}
+	messages := []Message{
+		{Role: "system", Content: "You are helpful.\n\n" + BuildJSONModeInstruction(schema)},
+		{Role: "user", Content: "question"},
+	}
+
+	req := model.BuildChatCompletionRequest(messages, opts, false)
+	if req.ResponseFormat == nil {
+		t.Fatal("request has no response format, want json_object")
+	}
+	count := 0
+	for _, msg := range req.Messages {
+		count += strings.Count(msg.Content, schema)
+	}
+	if count != 1 || !strings.Contains(req.Messages[0].Content, schema) {
+		t.Errorf("schema sent %d times, want once in the system message", count)
+	}
+	if req.Messages[len(req.Messages)-1].Content != "question" {
+		t.Errorf("last message = %q, want it unchanged", req.Messages[len(req.Messages)-1].Content)
+	}
+}
@@ -68,6 +68,11 @@ func (c *OllamaChat) buildChatRequest(messages []Message, opts *ChatOptions, isS
 		}
 		if opts.MaxTokens > 0 {
 			chatReq.Options["num_predict"] = opts.MaxTokens
+		} else if opts.MaxCompletionTokens > 0 {
+			chatReq.Options["num_predict"] = opts.MaxCompletionTokens
+		}
+		if len(opts.Stop) > 0 {
+			chatReq.Options["stop"] = opts.Stop
 		}
 		if opts.Thinking != nil {
 			chatReq.Think = &ollamaapi.ThinkValue{
@@ -76,6 +81,8 @@ func (c *OllamaChat) buildChatRequest(messages []Message, opts *ChatOptions, isS
 		}
 		if len(opts.Format) > 0 {
 			chatReq.Format = opts.Format
+		} else if opts.ResponseFormat == types.ResponseFormatJSONObject {
+			chatReq.Format = json.RawMessage(`"json"`)
 		}
 		if len(opts.Tools) > 0 {
 			chatReq.Tools = c.toolFrom(opts.Tools)
@@ -257,6 +264,11 @@ func (c *OllamaChat) ensureModelAvailable(ctx context.Context) error {
 	return c.ollamaService.EnsureModelAvailable(ctx, c.modelName)
 }
 
+// SupportsJSONMode 是否原生支持 JSON 输出模式
+func (c *OllamaChat) SupportsJSONMode() bool {
+	return true
+}
+
 // GetModelName 获取模型名称
 func (c *OllamaChat) GetModelName() string {
 	return c.modelName
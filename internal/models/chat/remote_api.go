@@ -131,6 +131,9 @@ func (c *RemoteAPIChat) BuildChatCompletionRequest(messages []Message, opts *Cha
 		if opts.PresencePenalty > 0 {
 			req.PresencePenalty = float32(opts.PresencePenalty)
 		}
+		if len(opts.Stop) > 0 {
+			req.Stop = opts.Stop
+		}
 
 		// 处理 Tools
 		if len(opts.Tools) > 0 {
@@ -172,6 +175,11 @@ func (c *RemoteAPIChat) BuildChatCompletionRequest(messages []Message, opts *Cha
 			}
 			req.Messages[len(req.Messages)-1].Content += fmt.Sprintf("\nUse this JSON schema: %s", opts.Format)
 		}
+		if opts.ResponseFormat == types.ResponseFormatJSONObject {
+			req.ResponseFormat = &openai.ChatCompletionResponseFormat{
+				Type: openai.ChatCompletionResponseFormatTypeJSONObject,
+			}
+		}
 	}
 
 	return req
@@ -661,6 +669,25 @@ func (c *RemoteAPIChat) processToolCallsDelta(toolCalls []openai.ToolCall, state
 	}
 }
 
+// jsonModeProviders 已知支持 response_format=json_object 的 provider
+// 其余 provider（包括 generic 等未知的兼容服务）通过提示词约束 JSON 输出
+var jsonModeProviders = map[provider.ProviderName]bool{
+	provider.ProviderOpenAI:      true,
+	provider.ProviderAliyun:      true,
+	provider.ProviderDeepSeek:    true,
+	provider.ProviderZhipu:       true,
+	provider.ProviderMoonshot:    true,
+	provider.ProviderSiliconFlow: true,
+	provider.ProviderVolcengine:  true,
+	provider.ProviderOpenRouter:  true,
+	provider.ProviderGemini:      true,
+}
+
+// SupportsJSONMode 是否原生支持 JSON 输出模式
+func (c *RemoteAPIChat) SupportsJSONMode() bool {
+	return jsonModeProviders[c.provider]
+}
+
 // GetModelName 获取模型名称
 func (c *RemoteAPIChat) GetModelName() string {
 	return c.modelName
@@ -0,0 +1,117 @@
+package chat
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Tencent/WeKnora/internal/models/utils"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// retryChat retries transient failures of the wrapped chat model
+type retryChat struct {
+	inner Chat
+	cfg   *types.ModelRetryConfig
+}
+
+// WithRetry wraps a chat model so that transient failures are retried according to cfg.
+// The model is returned unchanged when cfg allows a single attempt only.
+func WithRetry(model Chat, cfg *types.ModelRetryConfig) Chat {
+	if model == nil || cfg.GetMaxAttempts() <= 1 {
+		return model
+	}
+	return &retryChat{inner: model, cfg: cfg}
+}
+
+// Chat retries the whole non-streaming call
+func (r *retryChat) Chat(ctx context.Context, messages []Message, opts *ChatOptions) (*types.ChatResponse, error) {
+	var resp *types.ChatResponse
+	err := utils.Retry(ctx, r.cfg, "chat "+r.inner.GetModelName(), func() error {
+		var err error
+		resp, err = r.inner.Chat(ctx, messages, opts)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ChatStream retries opening the stream and, when the stream fails before delivering its first
+// chunk, reopens it. Once a chunk has been forwarded the stream is never retried, so callers
+// never see duplicated content.
+func (r *retryChat) ChatStream(
+	ctx context.Context, messages []Message, opts *ChatOptions,
+) (<-chan types.StreamResponse, error) {
+	op := "chat stream " + r.inner.GetModelName()
+	attempt := 0
+	var stream <-chan types.StreamResponse
+	open := func() error {
+		attempt++
+		var err error
+		stream, err = r.inner.ChatStream(ctx, messages, opts)
+		return err
+	}
+	if err := utils.Retry(ctx, r.cfg, op, open); err != nil {
+		return nil, err
+	}
+
+	out := make(chan types.StreamResponse)
+	go func() {
+		defer close(out)
+		for {
+			first, ok := <-stream
+			if !ok {
+				return
+			}
+			if first.ResponseType != types.ResponseTypeError || attempt >= r.cfg.GetMaxAttempts() ||
+				!utils.IsRetryableError(ctx, errors.New(first.Content)) {
+				out <- first
+				for resp := range stream {
+					out <- resp
+				}
+				return
+			}
+
+			// Nothing has been forwarded yet, so the stream can safely be reopened
+			go drainStream(stream)
+			for {
+				if !utils.WaitRetryBackoff(ctx, r.cfg, attempt, op, errors.New(first.Content)) {
+					out <- first
+					return
+				}
+				err := open()
+				if err == nil {
+					break
+				}
+				first = types.StreamResponse{ResponseType: types.ResponseTypeError, Content: err.Error(), Done: true}
+				if attempt >= r.cfg.GetMaxAttempts() || !utils.IsRetryableError(ctx, err) {
+					out <- first
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+// GetModelName returns the name of the wrapped model
+func (r *retryChat) GetModelName() string {
+	return r.inner.GetModelName()
+}
+
+// GetModelID returns the ID of the wrapped model
+func (r *retryChat) GetModelID() string {
+	return r.inner.GetModelID()
+}
+
+// SupportsJSONMode reports whether the wrapped model enforces JSON output natively
+func (r *retryChat) SupportsJSONMode() bool {
+	return SupportsJSONMode(r.inner)
+}
+
+// drainStream consumes the rest of an abandoned stream so its producer can exit
+func drainStream(stream <-chan types.StreamResponse) {
+	for range stream {
+	}
+}
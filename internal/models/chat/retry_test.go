@@ -0,0 +1,149 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// scriptedStreamChat returns the next scripted stream on each ChatStream call
+type scriptedStreamChat struct {
+	streams [][]types.StreamResponse
+	openErr []error
+	calls   int
+}
+
+func (s *scriptedStreamChat) Chat(context.Context, []Message, *ChatOptions) (*types.ChatResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (s *scriptedStreamChat) ChatStream(context.Context, []Message, *ChatOptions) (<-chan types.StreamResponse, error) {
+	i := s.calls
+	s.calls++
+	if i < len(s.openErr) && s.openErr[i] != nil {
+		return nil, s.openErr[i]
+	}
+	ch := make(chan types.StreamResponse, len(s.streams[i]))
+	for _, resp := range s.streams[i] {
+		ch <- resp
+	}
+	close(ch)
+	return ch, nil
+}
+
+func (s *scriptedStreamChat) GetModelName() string { return "scripted" }
+func (s *scriptedStreamChat) GetModelID() string   { return "scripted" }
+
+func collectContent(ch <-chan types.StreamResponse) []string {
+	var out []string
+	for resp := range ch {
+		out = append(out, string(resp.ResponseType)+":"+resp.Content)
+	}
+	return out
+}
+
+func TestRetryChatStream(t *testing.T) {
+	cfg := &types.ModelRetryConfig{MaxAttempts: 3, InitialBackoffMs: 1, MaxBackoffMs: 1}
+	errChunk := types.StreamResponse{ResponseType: types.ResponseTypeError, Content: "connection reset", Done: true}
+	answer := func(s string) types.StreamResponse {
+		return types.StreamResponse{ResponseType: types.ResponseTypeAnswer, Content: s}
+	}
+
+	tests := []struct {
+		name      string
+		model     *scriptedStreamChat
+		want      []string
+		wantCalls int
+	}{
+		{
+			name: "error before first chunk is retried",
+			model: &scriptedStreamChat{streams: [][]types.StreamResponse{
+				{errChunk},
+				{answer("a"), answer("b")},
+			}},
+			want:      []string{"answer:a", "answer:b"},
+			wantCalls: 2,
+		},
+		{
+			name: "error after first chunk is not retried",
+			model: &scriptedStreamChat{streams: [][]types.StreamResponse{
+				{answer("a"), errChunk},
+				{answer("x")},
+			}},
+			want:      []string{"answer:a", "error:connection reset"},
+			wantCalls: 1,
+		},
+		{
+			name: "open failure is retried",
+			model: &scriptedStreamChat{
+				openErr: []error{errors.New("send request: EOF")},
+				streams: [][]types.StreamResponse{nil, {answer("a")}},
+			},
+			want:      []string{"answer:a"},
+			wantCalls: 2,
+		},
+		{
+			name: "client errors are not retried",
+			model: &scriptedStreamChat{streams: [][]types.StreamResponse{
+				{{ResponseType: types.ResponseTypeError, Content: "API request failed with status 401", Done: true}},
+			}},
+			want:      []string{"error:API request failed with status 401"},
+			wantCalls: 1,
+		},
+		{
+			name: "last error is surfaced when attempts are exhausted",
+			model: &scriptedStreamChat{streams: [][]types.StreamResponse{
+				{errChunk}, {errChunk}, {errChunk}, {answer("never")},
+			}},
+			want:      []string{"error:connection reset"},
+			wantCalls: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream, err := WithRetry(tt.model, cfg).ChatStream(context.Background(), nil, nil)
+			if err != nil {
+				t.Fatalf("ChatStream() error = %v", err)
+			}
+			got := collectContent(stream)
+			if len(got) != len(tt.want) {
+				t.Fatalf("stream = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("stream = %v, want %v", got, tt.want)
+				}
+			}
+			if tt.model.calls != tt.wantCalls {
+				t.Errorf("ChatStream called %d times, want %d", tt.model.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+// jsonModeChat is a scriptedStreamChat that reports native JSON mode support
+type jsonModeChat struct {
+	scriptedStreamChat
+	jsonMode bool
+}
+
+func (c *jsonModeChat) SupportsJSONMode() bool { return c.jsonMode }
+
+func TestRetryChatSupportsJSONMode(t *testing.T) {
+	cfg := &types.ModelRetryConfig{MaxAttempts: 3}
+	for _, jsonMode := range []bool{true, false} {
+		model := WithRetry(&jsonModeChat{jsonMode: jsonMode}, cfg)
+		if _, ok := model.(*retryChat); !ok {
+			t.Fatalf("WithRetry() = %T, want *retryChat", model)
+		}
+		if got := SupportsJSONMode(model); got != jsonMode {
+			t.Errorf("SupportsJSONMode() = %v, want %v", got, jsonMode)
+		}
+	}
+	if SupportsJSONMode(WithRetry(&scriptedStreamChat{}, cfg)) {
+		t.Error("SupportsJSONMode() = true for a model without JSON mode")
+	}
+}
@@ -2,20 +2,44 @@ package embedding
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"strconv"
 	"sync"
+	"time"
 
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/models/utils"
+	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/panjf2000/ants/v2"
 )
 
+const (
+	// batchEmbedMaxRounds is how many times failed batches are submitted in total
+	batchEmbedMaxRounds = 5
+	// batchEmbedRetryDelay is the wait before re-submitting failed batches, multiplied by the round
+	batchEmbedRetryDelay = 100 * time.Millisecond
+)
+
 type batchEmbedder struct {
 	pool *ants.Pool
+	// batchSize is the global default; tenants may override it
+	batchSize int
 }
 
-func NewBatchEmbedder(pool *ants.Pool) EmbedderPooler {
-	return &batchEmbedder{pool: pool}
+func NewBatchEmbedder(pool *ants.Pool, cfg *config.Config) EmbedderPooler {
+	batchSize := 0
+	if cfg != nil && cfg.KnowledgeBase != nil {
+		batchSize = cfg.KnowledgeBase.EmbeddingBatchSize
+	}
+	// BATCH_EMBED_SIZE is kept for deployments that configured it before the config option existed
+	if batchSize <= 0 {
+		if size, err := strconv.Atoi(os.Getenv("BATCH_EMBED_SIZE")); err == nil {
+			batchSize = size
+		}
+	}
+	return &batchEmbedder{pool: pool, batchSize: batchSize}
 }
 
 type textEmbedding struct {
@@ -23,73 +47,95 @@ type textEmbedding struct {
 	results []float32
 }
 
-func (e *batchEmbedder) BatchEmbedWithPool(ctx context.Context, model Embedder, texts []string) ([][]float32, error) {
-	// Create goroutine pool for concurrent processing of document chunks
-	var wg sync.WaitGroup
-	var mu sync.Mutex  // For synchronizing access to error
-	var firstErr error // Record the first error that occurs
-	batchSizeStr := os.Getenv("BATCH_EMBED_SIZE")
-	if batchSizeStr == "" {
-		batchSizeStr = "5"
-	}
-	batchSize, err := strconv.Atoi(batchSizeStr)
-	if err != nil {
-		return nil, err
+// resolveBatchSize returns the tenant's embedding batch size, falling back to the global default
+func (e *batchEmbedder) resolveBatchSize(ctx context.Context) int {
+	tenantSize := 0
+	if tenant, _ := types.TenantInfoFromContext(ctx); tenant != nil {
+		tenantSize = tenant.EmbeddingBatchSize
 	}
+	return types.ResolveEmbeddingBatchSize(tenantSize, e.batchSize)
+}
+
+func (e *batchEmbedder) BatchEmbedWithPool(ctx context.Context, model Embedder, texts []string) ([][]float32, error) {
 	textEmbeddings := utils.MapSlice(texts, func(text string) *textEmbedding {
 		return &textEmbedding{text: text}
 	})
+	pending := utils.ChunkSlice(textEmbeddings, e.resolveBatchSize(ctx))
 
-	// Function to process each document chunk
-	processChunk := func(texts []*textEmbedding) func() {
+	// Only the batches that failed are submitted again, so a transient error on one batch
+	// does not re-embed the whole input
+	var lastErr error
+	for round := 1; round <= batchEmbedMaxRounds && len(pending) > 0; round++ {
+		if round > 1 {
+			logger.Warnf(ctx, "Retrying %d failed embedding batch(es), round %d: %v", len(pending), round, lastErr)
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(time.Duration(round-1) * batchEmbedRetryDelay):
+			}
+		}
+		failed, err := e.embedBatches(ctx, model, pending)
+		if err != nil {
+			return nil, err
+		}
+		pending, lastErr = failed.batches, failed.err
+	}
+	if len(pending) > 0 {
+		return nil, lastErr
+	}
+
+	results := utils.MapSlice(textEmbeddings, func(text *textEmbedding) []float32 {
+		return text.results
+	})
+	return results, nil
+}
+
+// failedBatches collects the batches of one round that could not be embedded
+type failedBatches struct {
+	mu      sync.Mutex
+	batches [][]*textEmbedding
+	err     error // the last error that occurred
+}
+
+// embedBatches embeds the batches concurrently on the goroutine pool. Results are stored on the
+// textEmbedding items; the batches that failed are returned. The error is only set when the pool
+// rejects a task.
+func (e *batchEmbedder) embedBatches(
+	ctx context.Context, model Embedder, batches [][]*textEmbedding,
+) (*failedBatches, error) {
+	var wg sync.WaitGroup
+	failed := &failedBatches{}
+
+	processBatch := func(texts []*textEmbedding) func() {
 		return func() {
 			defer wg.Done()
-			// If an error has already occurred, don't continue processing
-			if firstErr != nil {
-				return
-			}
-			// Embed text
 			embedding, err := model.BatchEmbed(ctx, utils.MapSlice(texts, func(text *textEmbedding) string {
 				return text.text
 			}))
+			if err == nil && len(embedding) != len(texts) {
+				err = fmt.Errorf("embedding model returned %d vectors for %d texts", len(embedding), len(texts))
+			}
 			if err != nil {
-				mu.Lock()
-				if firstErr == nil {
-					firstErr = err
-				}
-				mu.Unlock()
+				failed.mu.Lock()
+				failed.batches = append(failed.batches, texts)
+				failed.err = err
+				failed.mu.Unlock()
 				return
 			}
-			mu.Lock()
 			for i, text := range texts {
-				if text == nil {
-					continue
-				}
 				text.results = embedding[i]
 			}
-			mu.Unlock()
 		}
 	}
 
-	// Submit all tasks to the goroutine pool
-	for _, texts := range utils.ChunkSlice(textEmbeddings, batchSize) {
+	for _, texts := range batches {
 		wg.Add(1)
-		err := e.pool.Submit(processChunk(texts))
-		if err != nil {
+		if err := e.pool.Submit(processBatch(texts)); err != nil {
+			wg.Done()
+			wg.Wait()
 			return nil, err
 		}
 	}
-
-	// Wait for all tasks to complete
 	wg.Wait()
-
-	// Check if any errors occurred
-	if firstErr != nil {
-		return nil, firstErr
-	}
-
-	results := utils.MapSlice(textEmbeddings, func(text *textEmbedding) []float32 {
-		return text.results
-	})
-	return results, nil
+	return failed, nil
 }
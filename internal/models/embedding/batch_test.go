@@ -0,0 +1,71 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/panjf2000/ants/v2"
+)
+
+// flakyEmbedder fails the first call for every batch that starts with a text in failOnce
+type flakyEmbedder struct {
+	mu       sync.Mutex
+	failOnce map[string]bool
+	calls    map[string]int // first text of a batch -> number of calls
+}
+
+func (f *flakyEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	return []float32{float32(len(text))}, nil
+}
+
+func (f *flakyEmbedder) BatchEmbed(ctx context.Context, texts []string) ([][]float32, error) {
+	f.mu.Lock()
+	f.calls[texts[0]]++
+	fail := f.failOnce[texts[0]] && f.calls[texts[0]] == 1
+	f.mu.Unlock()
+	if fail {
+		return nil, errors.New("connection reset")
+	}
+	out := make([][]float32, len(texts))
+	for i, text := range texts {
+		out[i] = []float32{float32(len(text))}
+	}
+	return out, nil
+}
+
+func (f *flakyEmbedder) GetModelName() string { return "flaky" }
+func (f *flakyEmbedder) GetDimensions() int   { return 1 }
+func (f *flakyEmbedder) GetModelID() string   { return "flaky" }
+func (f *flakyEmbedder) BatchEmbedWithPool(ctx context.Context, model Embedder, texts []string) ([][]float32, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestBatchEmbedWithPoolRetriesFailedBatchesOnly(t *testing.T) {
+	pool, err := ants.NewPool(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Release()
+
+	model := &flakyEmbedder{failOnce: map[string]bool{"ccc": true}, calls: map[string]int{}}
+	pooler := &batchEmbedder{pool: pool, batchSize: 2}
+	texts := []string{"a", "bb", "ccc", "dddd", "eeeee"}
+
+	got, err := pooler.BatchEmbedWithPool(context.Background(), model, texts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i, text := range texts {
+		if len(got[i]) != 1 || got[i][0] != float32(len(text)) {
+			t.Errorf("vector %d = %v, want [%d]", i, got[i], len(text))
+		}
+	}
+	wantCalls := map[string]int{"a": 1, "ccc": 2, "eeeee": 1}
+	for first, want := range wantCalls {
+		if model.calls[first] != want {
+			t.Errorf("batch %q embedded %d times, want %d", first, model.calls[first], want)
+		}
+	}
+}
@@ -0,0 +1,358 @@
+package embedding
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// embeddingCachePrefix prefixes every cache key; keys are <prefix><model ID>:<hash>
+	embeddingCachePrefix = "embcache:"
+	// defaultEmbeddingCacheTTL applies when the config leaves ttl_hours unset
+	defaultEmbeddingCacheTTL = 7 * 24 * time.Hour
+	// defaultEmbeddingCacheMaxEntries bounds the in-memory cache when the config leaves max_entries unset
+	defaultEmbeddingCacheMaxEntries = 10000
+)
+
+// VectorCache stores embedding vectors by content key, see CacheKey
+type VectorCache interface {
+	// GetMany returns the cached vector of each key, nil for a miss
+	GetMany(ctx context.Context, keys []string) ([][]float32, error)
+	// SetMany stores the vectors under the given keys
+	SetMany(ctx context.Context, keys []string, vectors [][]float32) error
+	// DeleteModel drops every vector of the model, e.g. after its configuration changed
+	DeleteModel(ctx context.Context, modelID string) error
+}
+
+// NewVectorCache returns the embedding cache configured in knowledge_base.embedding_cache: Redis-backed
+// when Redis is available (shared by all workers), in-memory otherwise. Returns nil when disabled.
+func NewVectorCache(cfg *config.Config, client *redis.Client) VectorCache {
+	if cfg == nil || cfg.KnowledgeBase == nil || cfg.KnowledgeBase.EmbeddingCache == nil ||
+		!cfg.KnowledgeBase.EmbeddingCache.Enabled {
+		return nil
+	}
+	cacheCfg := cfg.KnowledgeBase.EmbeddingCache
+	ttl := defaultEmbeddingCacheTTL
+	if cacheCfg.TTLHours > 0 {
+		ttl = time.Duration(cacheCfg.TTLHours) * time.Hour
+	}
+	if client != nil {
+		logger.Infof(context.Background(), "[Embedding] Using Redis embedding cache, ttl: %s", ttl)
+		return &redisVectorCache{client: client, ttl: ttl}
+	}
+	maxEntries := cacheCfg.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = defaultEmbeddingCacheMaxEntries
+	}
+	logger.Infof(context.Background(), "[Embedding] Using in-memory embedding cache, ttl: %s, max entries: %d",
+		ttl, maxEntries)
+	return newMemoryVectorCache(ttl, maxEntries)
+}
+
+// CacheKey returns the cache key of text for the model. Text is normalized by trimming and collapsing
+// whitespace; the model name and dimensions are part of the hash so a reconfigured model never reuses
+// vectors of its previous configuration. Returns "" for blank text, which is not cached.
+func CacheKey(model Embedder, text string) string {
+	normalized := strings.Join(strings.Fields(text), " ")
+	if normalized == "" {
+		return ""
+	}
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%d\x00", model.GetModelName(), model.GetDimensions())
+	h.Write([]byte(normalized))
+	return embeddingCachePrefix + model.GetModelID() + ":" + hex.EncodeToString(h.Sum(nil))
+}
+
+// cachedEmbedder reuses cached vectors for texts embedded before with the same model
+type cachedEmbedder struct {
+	inner Embedder
+	cache VectorCache
+}
+
+// WithCache wraps an embedder so that batch embedding reuses cached vectors of identical texts.
+// Single Embed calls (queries) are not cached. The embedder is returned unchanged when cache is nil.
+func WithCache(model Embedder, cache VectorCache) Embedder {
+	if model == nil || cache == nil {
+		return model
+	}
+	return &cachedEmbedder{inner: model, cache: cache}
+}
+
+// Embed converts text to vector without caching
+func (c *cachedEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	return c.inner.Embed(ctx, text)
+}
+
+// BatchEmbed converts multiple texts to vectors, embedding only texts that are not cached
+func (c *cachedEmbedder) BatchEmbed(ctx context.Context, texts []string) ([][]float32, error) {
+	return c.embedCached(ctx, texts, func(misses []string) ([][]float32, error) {
+		return c.inner.BatchEmbed(ctx, misses)
+	})
+}
+
+// BatchEmbedWithPool embeds the texts that are not cached through the wrapped embedder's pooler
+func (c *cachedEmbedder) BatchEmbedWithPool(ctx context.Context, model Embedder, texts []string) ([][]float32, error) {
+	// The pool calls model.BatchEmbed; pass the wrapped embedder so misses are not looked up twice
+	if model == Embedder(c) {
+		model = c.inner
+	}
+	return c.embedCached(ctx, texts, func(misses []string) ([][]float32, error) {
+		return c.inner.BatchEmbedWithPool(ctx, model, misses)
+	})
+}
+
+// embedCached looks the texts up in the cache, embeds each distinct missing text once and caches the result.
+// Cache failures are logged and fall back to embedding.
+func (c *cachedEmbedder) embedCached(ctx context.Context, texts []string,
+	embed func(misses []string) ([][]float32, error),
+) ([][]float32, error) {
+	if len(texts) == 0 {
+		return embed(texts)
+	}
+	keys := make([]string, len(texts))
+	for i, text := range texts {
+		keys[i] = CacheKey(c.inner, text)
+	}
+
+	results := make([][]float32, len(texts))
+	cached, err := c.cache.GetMany(ctx, keys)
+	if err != nil {
+		logger.Warnf(ctx, "[Embedding] Cache lookup failed, embedding all %d texts: %v", len(texts), err)
+		cached = nil
+	}
+	dimensions := c.inner.GetDimensions()
+	for i := range cached {
+		if keys[i] != "" && len(cached[i]) > 0 && (dimensions <= 0 || len(cached[i]) == dimensions) {
+			results[i] = cached[i]
+		}
+	}
+
+	// Distinct missing texts; blank texts are embedded but never cached or deduplicated
+	var missTexts, missKeys []string
+	missIndex := make(map[string]int)
+	missOf := make([]int, len(texts))
+	for i := range texts {
+		if results[i] != nil {
+			missOf[i] = -1
+			continue
+		}
+		if idx, ok := missIndex[keys[i]]; ok && keys[i] != "" {
+			missOf[i] = idx
+			continue
+		}
+		missOf[i] = len(missTexts)
+		missIndex[keys[i]] = len(missTexts)
+		missTexts = append(missTexts, texts[i])
+		missKeys = append(missKeys, keys[i])
+	}
+	if len(missTexts) == 0 {
+		logger.Infof(ctx, "[Embedding] Reused %d cached embeddings", len(texts))
+		return results, nil
+	}
+
+	vectors, err := embed(missTexts)
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) != len(missTexts) {
+		return nil, fmt.Errorf("embedding returned %d vectors for %d texts", len(vectors), len(missTexts))
+	}
+	for i := range texts {
+		if missOf[i] >= 0 {
+			results[i] = vectors[missOf[i]]
+		}
+	}
+	logger.Infof(ctx, "[Embedding] Reused %d cached embeddings, embedded %d texts",
+		len(texts)-len(missTexts), len(missTexts))
+
+	var setKeys []string
+	var setVectors [][]float32
+	for i, key := range missKeys {
+		if key != "" {
+			setKeys = append(setKeys, key)
+			setVectors = append(setVectors, vectors[i])
+		}
+	}
+	if len(setKeys) > 0 {
+		if err := c.cache.SetMany(ctx, setKeys, setVectors); err != nil {
+			logger.Warnf(ctx, "[Embedding] Failed to cache %d embeddings: %v", len(setKeys), err)
+		}
+	}
+	return results, nil
+}
+
+// GetModelName returns the name of the wrapped model
+func (c *cachedEmbedder) GetModelName() string {
+	return c.inner.GetModelName()
+}
+
+// GetDimensions returns the vector dimensions of the wrapped model
+func (c *cachedEmbedder) GetDimensions() int {
+	return c.inner.GetDimensions()
+}
+
+// GetModelID returns the ID of the wrapped model
+func (c *cachedEmbedder) GetModelID() string {
+	return c.inner.GetModelID()
+}
+
+// redisVectorCache stores vectors as little-endian float32 bytes with a TTL
+type redisVectorCache struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+func (r *redisVectorCache) GetMany(ctx context.Context, keys []string) ([][]float32, error) {
+	values, err := r.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	vectors := make([][]float32, len(keys))
+	for i, value := range values {
+		if s, ok := value.(string); ok {
+			vectors[i] = decodeVector([]byte(s))
+		}
+	}
+	return vectors, nil
+}
+
+func (r *redisVectorCache) SetMany(ctx context.Context, keys []string, vectors [][]float32) error {
+	pipe := r.client.Pipeline()
+	for i, key := range keys {
+		pipe.Set(ctx, key, encodeVector(vectors[i]), r.ttl)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (r *redisVectorCache) DeleteModel(ctx context.Context, modelID string) error {
+	iter := r.client.Scan(ctx, 0, embeddingCachePrefix+modelID+":*", 1000).Iterator()
+	var batch []string
+	for iter.Next(ctx) {
+		batch = append(batch, iter.Val())
+		if len(batch) == 1000 {
+			if err := r.client.Del(ctx, batch...).Err(); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	if len(batch) > 0 {
+		return r.client.Del(ctx, batch...).Err()
+	}
+	return nil
+}
+
+func encodeVector(vector []float32) []byte {
+	buf := make([]byte, 4*len(vector))
+	for i, v := range vector {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(v))
+	}
+	return buf
+}
+
+func decodeVector(buf []byte) []float32 {
+	if len(buf) == 0 || len(buf)%4 != 0 {
+		return nil
+	}
+	vector := make([]float32, len(buf)/4)
+	for i := range vector {
+		vector[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[4*i:]))
+	}
+	return vector
+}
+
+// memoryVectorCache is a process-local LRU cache used when Redis is not configured
+type memoryVectorCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	order      *list.List // front = most recently used
+	entries    map[string]*list.Element
+}
+
+type memoryVectorEntry struct {
+	key       string
+	vector    []float32
+	expiresAt time.Time
+}
+
+func newMemoryVectorCache(ttl time.Duration, maxEntries int) *memoryVectorCache {
+	return &memoryVectorCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+}
+
+func (m *memoryVectorCache) GetMany(ctx context.Context, keys []string) ([][]float32, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	vectors := make([][]float32, len(keys))
+	for i, key := range keys {
+		elem, ok := m.entries[key]
+		if !ok {
+			continue
+		}
+		entry := elem.Value.(*memoryVectorEntry)
+		if now.After(entry.expiresAt) {
+			m.order.Remove(elem)
+			delete(m.entries, key)
+			continue
+		}
+		m.order.MoveToFront(elem)
+		vectors[i] = entry.vector
+	}
+	return vectors, nil
+}
+
+func (m *memoryVectorCache) SetMany(ctx context.Context, keys []string, vectors [][]float32) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	expiresAt := time.Now().Add(m.ttl)
+	for i, key := range keys {
+		if elem, ok := m.entries[key]; ok {
+			entry := elem.Value.(*memoryVectorEntry)
+			entry.vector, entry.expiresAt = vectors[i], expiresAt
+			m.order.MoveToFront(elem)
+			continue
+		}
+		m.entries[key] = m.order.PushFront(&memoryVectorEntry{key: key, vector: vectors[i], expiresAt: expiresAt})
+		for m.order.Len() > m.maxEntries {
+			oldest := m.order.Back()
+			m.order.Remove(oldest)
+			delete(m.entries, oldest.Value.(*memoryVectorEntry).key)
+		}
+	}
+	return nil
+}
+
+func (m *memoryVectorCache) DeleteModel(ctx context.Context, modelID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	prefix := embeddingCachePrefix + modelID + ":"
+	for key, elem := range m.entries {
+		if strings.HasPrefix(key, prefix) {
+			m.order.Remove(elem)
+			delete(m.entries, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package embedding
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// countingEmbedder records every text it embeds
+type countingEmbedder struct {
+	embedded []string
+}
+
+func (f *countingEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	return []float32{float32(len(text))}, nil
+}
+
+func (f *countingEmbedder) BatchEmbed(ctx context.Context, texts []string) ([][]float32, error) {
+	f.embedded = append(f.embedded, texts...)
+	out := make([][]float32, len(texts))
+	for i, text := range texts {
+		out[i] = []float32{float32(len(text))}
+	}
+	return out, nil
+}
+
+func (f *countingEmbedder) GetModelName() string { return "counting" }
+func (f *countingEmbedder) GetDimensions() int   { return 1 }
+func (f *countingEmbedder) GetModelID() string   { return "counting" }
+func (f *countingEmbedder) BatchEmbedWithPool(ctx context.Context, model Embedder, texts []string) ([][]float32, error) {
+	return model.BatchEmbed(ctx, texts)
+}
+
+func TestCachedEmbedderReusesIdenticalTexts(t *testing.T) {
+	ctx := context.Background()
+	inner := &countingEmbedder{}
+	cache := newMemoryVectorCache(time.Hour, 100)
+	model := WithCache(inner, cache)
+
+	got, err := model.BatchEmbedWithPool(ctx, model, []string{"a b", "ccc", "a  b\n", ""})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 4 || got[0][0] != 3 || got[1][0] != 3 || got[2][0] != 3 || got[3][0] != 0 {
+		t.Fatalf("unexpected vectors: %v", got)
+	}
+	// "a  b\n" normalizes to "a b" and is embedded once within the batch
+	if len(inner.embedded) != 3 {
+		t.Fatalf("expected 3 embedded texts, got %q", inner.embedded)
+	}
+
+	inner.embedded = nil
+	if _, err := model.BatchEmbed(ctx, []string{"ccc", "dddd", ""}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Blank texts are never cached
+	if len(inner.embedded) != 2 || inner.embedded[0] != "dddd" || inner.embedded[1] != "" {
+		t.Fatalf("expected only new and blank texts to be embedded, got %q", inner.embedded)
+	}
+
+	if err := cache.DeleteModel(ctx, "counting"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	inner.embedded = nil
+	if _, err := model.BatchEmbed(ctx, []string{"ccc"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(inner.embedded) != 1 {
+		t.Fatalf("expected re-embedding after invalidation, got %q", inner.embedded)
+	}
+}
+
+func TestMemoryVectorCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	cache := newMemoryVectorCache(time.Hour, 2)
+	_ = cache.SetMany(ctx, []string{"a", "b"}, [][]float32{{1}, {2}})
+	_, _ = cache.GetMany(ctx, []string{"a"})
+	_ = cache.SetMany(ctx, []string{"c"}, [][]float32{{3}})
+
+	got, _ := cache.GetMany(ctx, []string{"a", "b", "c"})
+	if got[0] == nil || got[1] != nil || got[2] == nil {
+		t.Fatalf("expected b to be evicted, got %v", got)
+	}
+}
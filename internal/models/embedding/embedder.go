@@ -30,6 +30,12 @@ type Embedder interface {
 	EmbedderPooler
 }
 
+// Probe embeds a minimal text to check that the model is usable.
+// It is shared by the model connection check and model warmup.
+func Probe(ctx context.Context, e Embedder) ([]float32, error) {
+	return e.Embed(ctx, "hello")
+}
+
 type EmbedderPooler interface {
 	BatchEmbedWithPool(ctx context.Context, model Embedder, texts []string) ([][]float32, error)
 }
@@ -0,0 +1,72 @@
+package embedding
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/models/utils"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// retryEmbedder retries transient failures of the wrapped embedder
+type retryEmbedder struct {
+	inner Embedder
+	cfg   *types.ModelRetryConfig
+}
+
+// WithRetry wraps an embedder so that transient failures are retried according to cfg.
+// The embedder is returned unchanged when cfg allows a single attempt only.
+func WithRetry(model Embedder, cfg *types.ModelRetryConfig) Embedder {
+	if model == nil || cfg.GetMaxAttempts() <= 1 {
+		return model
+	}
+	return &retryEmbedder{inner: model, cfg: cfg}
+}
+
+// Embed converts text to vector, retrying transient failures
+func (r *retryEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	var vector []float32
+	err := utils.Retry(ctx, r.cfg, "embed "+r.inner.GetModelName(), func() error {
+		var err error
+		vector, err = r.inner.Embed(ctx, text)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return vector, nil
+}
+
+// BatchEmbed converts multiple texts to vectors, retrying transient failures
+func (r *retryEmbedder) BatchEmbed(ctx context.Context, texts []string) ([][]float32, error) {
+	var vectors [][]float32
+	err := utils.Retry(ctx, r.cfg, "batch embed "+r.inner.GetModelName(), func() error {
+		var err error
+		vectors, err = r.inner.BatchEmbed(ctx, texts)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return vectors, nil
+}
+
+// BatchEmbedWithPool delegates to the wrapped embedder's pooler; the pool calls
+// model.BatchEmbed, so passing the wrapper keeps the retries
+func (r *retryEmbedder) BatchEmbedWithPool(ctx context.Context, model Embedder, texts []string) ([][]float32, error) {
+	return r.inner.BatchEmbedWithPool(ctx, model, texts)
+}
+
+// GetModelName returns the name of the wrapped model
+func (r *retryEmbedder) GetModelName() string {
+	return r.inner.GetModelName()
+}
+
+// GetDimensions returns the vector dimensions of the wrapped model
+func (r *retryEmbedder) GetDimensions() int {
+	return r.inner.GetDimensions()
+}
+
+// GetModelID returns the ID of the wrapped model
+func (r *retryEmbedder) GetModelID() string {
+	return r.inner.GetModelID()
+}
@@ -21,6 +21,12 @@ type Reranker interface {
 	GetModelID() string
 }
 
+// Probe reranks a minimal document to check that the model is usable.
+// It is shared by the model connection check and model warmup.
+func Probe(ctx context.Context, r Reranker) ([]RankResult, error) {
+	return r.Rerank(ctx, "ping", []string{"pong"})
+}
+
 type RankResult struct {
 	Index          int          `json:"index"`
 	Document       DocumentInfo `json:"document"`
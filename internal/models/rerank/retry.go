@@ -0,0 +1,47 @@
+package rerank
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/models/utils"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// retryReranker retries transient failures of the wrapped reranker
+type retryReranker struct {
+	inner Reranker
+	cfg   *types.ModelRetryConfig
+}
+
+// WithRetry wraps a reranker so that transient failures are retried according to cfg.
+// The reranker is returned unchanged when cfg allows a single attempt only.
+func WithRetry(model Reranker, cfg *types.ModelRetryConfig) Reranker {
+	if model == nil || cfg.GetMaxAttempts() <= 1 {
+		return model
+	}
+	return &retryReranker{inner: model, cfg: cfg}
+}
+
+// Rerank reranks documents, retrying transient failures
+func (r *retryReranker) Rerank(ctx context.Context, query string, documents []string) ([]RankResult, error) {
+	var results []RankResult
+	err := utils.Retry(ctx, r.cfg, "rerank "+r.inner.GetModelName(), func() error {
+		var err error
+		results, err = r.inner.Rerank(ctx, query, documents)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// GetModelName returns the name of the wrapped model
+func (r *retryReranker) GetModelName() string {
+	return r.inner.GetModelName()
+}
+
+// GetModelID returns the ID of the wrapped model
+func (r *retryReranker) GetModelID() string {
+	return r.inner.GetModelID()
+}
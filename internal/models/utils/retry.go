@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// httpStatusPattern extracts the HTTP status code from provider errors such as
+// "API request failed with status 429: ..." or "Rerank API error: Http Status: 503 Service Unavailable"
+var httpStatusPattern = regexp.MustCompile(`(?i)status:?\s*(\d{3})`)
+
+// Retry calls fn until it succeeds, the attempts allowed by cfg are used up or the error is not
+// transient. The error of the last attempt is returned.
+func Retry(ctx context.Context, cfg *types.ModelRetryConfig, op string, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		if attempt >= cfg.GetMaxAttempts() || !IsRetryableError(ctx, err) {
+			return err
+		}
+		if !WaitRetryBackoff(ctx, cfg, attempt, op, err) {
+			return err
+		}
+	}
+}
+
+// WaitRetryBackoff waits before the retry following the given failed attempt.
+// It returns false when the context is done first.
+func WaitRetryBackoff(ctx context.Context, cfg *types.ModelRetryConfig, attempt int, op string, err error) bool {
+	backoff := cfg.Backoff(attempt)
+	logger.Warnf(ctx, "%s failed (attempt %d/%d), retrying in %v: %v",
+		op, attempt, cfg.GetMaxAttempts(), backoff, err)
+
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// IsRetryableError reports whether a failed model call may succeed when repeated.
+// Cancellations and client errors (4xx except 408 and 429) are permanent.
+func IsRetryableError(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if m := httpStatusPattern.FindStringSubmatch(err.Error()); m != nil {
+		code, _ := strconv.Atoi(m[1])
+		if code >= 400 && code < 500 && code != 408 && code != 429 {
+			return false
+		}
+	}
+	return true
+}
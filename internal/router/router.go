@@ -44,6 +44,8 @@ type RouterParams struct {
 	KnowledgeHandler      *handler.KnowledgeHandler
 	TenantHandler         *handler.TenantHandler
 	TenantService         interfaces.TenantService
+	APIKeyService         interfaces.APIKeyService
+	APIKeyHandler         *handler.APIKeyHandler
 	ChunkHandler          *handler.ChunkHandler
 	SessionHandler        *session.Handler
 	MessageHandler        *handler.MessageHandler
@@ -57,6 +59,8 @@ type RouterParams struct {
 	FAQHandler            *handler.FAQHandler
 	TagHandler            *handler.TagHandler
 	CustomAgentHandler    *handler.CustomAgentHandler
+	PromptTemplateHandler *handler.PromptTemplateHandler
+	ConfigBundleHandler   *handler.ConfigBundleHandler
 	SkillHandler          *handler.SkillHandler
 	OrganizationHandler   *handler.OrganizationHandler
 	IMHandler             *handler.IMHandler
@@ -108,7 +112,7 @@ func NewRouter(params RouterParams) *gin.Engine {
 	RegisterIMRoutes(r, params.IMHandler)
 
 	// 认证中间件
-	r.Use(middleware.Auth(params.TenantService, params.UserService, params.Config))
+	r.Use(middleware.Auth(params.TenantService, params.UserService, params.APIKeyService, params.Config))
 
 	// 文件服务：统一代理本地/MinIO/COS/TOS存储后端（需要认证）
 	serveFiles(r)
@@ -121,6 +125,7 @@ func NewRouter(params RouterParams) *gin.Engine {
 	{
 		RegisterAuthRoutes(v1, params.AuthHandler)
 		RegisterTenantRoutes(v1, params.TenantHandler)
+		RegisterAPIKeyRoutes(v1, params.APIKeyHandler)
 		RegisterKnowledgeBaseRoutes(v1, params.KBHandler)
 		RegisterKnowledgeTagRoutes(v1, params.TagHandler)
 		RegisterKnowledgeRoutes(v1, params.KnowledgeHandler)
@@ -133,9 +138,11 @@ func NewRouter(params RouterParams) *gin.Engine {
 		RegisterEvaluationRoutes(v1, params.EvaluationHandler)
 		RegisterInitializationRoutes(v1, params.InitializationHandler)
 		RegisterSystemRoutes(v1, params.SystemHandler)
+		RegisterAdminRoutes(v1, params.SystemHandler, params.SessionHandler, params.ConfigBundleHandler, params.Config)
 		RegisterMCPServiceRoutes(v1, params.MCPServiceHandler)
 		RegisterWebSearchRoutes(v1, params.WebSearchHandler)
 		RegisterCustomAgentRoutes(v1, params.CustomAgentHandler)
+		RegisterPromptTemplateRoutes(v1, params.PromptTemplateHandler)
 		RegisterSkillRoutes(v1, params.SkillHandler)
 		RegisterOrganizationRoutes(v1, params.OrganizationHandler)
 	}
@@ -170,17 +177,23 @@ func RegisterKnowledgeRoutes(r *gin.RouterGroup, handler *handler.KnowledgeHandl
 	{
 		// 从文件创建知识
 		kb.POST("/file", handler.CreateKnowledgeFromFile)
+		// 上传前校验文件类型和大小
+		kb.POST("/file/validate", handler.ValidateFileUpload)
 		// 从URL创建知识（支持网页URL和文件URL，传 file_name/file_type 或 URL 含已知扩展名时自动切换为文件下载模式）
 		kb.POST("/url", handler.CreateKnowledgeFromURL)
 		// 手工 Markdown 录入
 		kb.POST("/manual", handler.CreateManualKnowledge)
 		// 获取知识库下的知识列表
 		kb.GET("", handler.ListKnowledge)
+		// 按原始文件名获取知识
+		kb.GET("/by-filename", handler.GetKnowledgeByFilename)
 	}
 
 	// 知识路由组
 	k := r.Group("/knowledge")
 	{
+		// 跨知识库获取租户的知识列表
+		k.GET("", handler.ListAllKnowledge)
 		// 批量获取知识
 		k.GET("/batch", handler.GetKnowledgeBatch)
 		// 获取知识详情
@@ -193,6 +206,14 @@ func RegisterKnowledgeRoutes(r *gin.RouterGroup, handler *handler.KnowledgeHandl
 		k.PUT("/manual/:id", handler.UpdateManualKnowledge)
 		// 重新解析知识
 		k.POST("/:id/reparse", handler.ReparseKnowledge)
+		// 重试处理失败的知识
+		k.POST("/:id/retry", handler.RetryKnowledgeProcessing)
+		// 获取知识的分块详情
+		k.GET("/:id/chunks", handler.ListKnowledgeChunks)
+		// 修改分块文本并重新向量化
+		k.PUT("/:id/chunks/:chunk_id", handler.UpdateKnowledgeChunk)
+		// 删除分块及其向量
+		k.DELETE("/:id/chunks/:chunk_id", handler.DeleteKnowledgeChunk)
 		// 获取知识文件
 		k.GET("/:id/download", handler.DownloadKnowledgeFile)
 		// 预览知识文件（内联显示，返回正确 Content-Type）
@@ -208,6 +229,13 @@ func RegisterKnowledgeRoutes(r *gin.RouterGroup, handler *handler.KnowledgeHandl
 		// 获取知识移动进度
 		k.GET("/move/progress/:task_id", handler.GetKnowledgeMoveProgress)
 	}
+
+	// @提及解析路由组
+	mentions := r.Group("/mentions")
+	{
+		// 按名称解析可提及的知识库和文档
+		mentions.GET("/resolve", handler.ResolveMentions)
+	}
 }
 
 // RegisterFAQRoutes 注册 FAQ 相关路由
@@ -250,20 +278,30 @@ func RegisterKnowledgeBaseRoutes(r *gin.RouterGroup, handler *handler.KnowledgeB
 		kb.GET("", handler.ListKnowledgeBases)
 		// 获取知识库详情
 		kb.GET("/:id", handler.GetKnowledgeBase)
+		// 获取知识库统计信息
+		kb.GET("/:id/stats", handler.GetKnowledgeBaseStats)
 		// 更新知识库
 		kb.PUT("/:id", handler.UpdateKnowledgeBase)
 		// 删除知识库
 		kb.DELETE("/:id", handler.DeleteKnowledgeBase)
 		// 置顶/取消置顶知识库
 		kb.PUT("/:id/pin", handler.TogglePinKnowledgeBase)
+		// 设置动态共享匹配的共享标签（仅所属租户）
+		kb.PUT("/:id/share-label", handler.SetShareLabel)
 		// 混合搜索
 		kb.GET("/:id/hybrid-search", handler.HybridSearch)
 		// 拷贝知识库
 		kb.POST("/copy", handler.CopyKnowledgeBase)
 		// 获取知识库复制进度
 		kb.GET("/copy/progress/:task_id", handler.GetKBCloneProgress)
+		// 重试复制失败的知识
+		kb.POST("/copy/:task_id/retry", handler.RetryKBCloneFailedItems)
 		// 获取可移动目标知识库列表
 		kb.GET("/:id/move-targets", handler.ListMoveTargets)
+		// 重建关键词索引
+		kb.POST("/:id/rebuild-index", handler.RebuildKeywordIndex)
+		// 获取关键词索引重建进度
+		kb.GET("/:id/rebuild-index", handler.GetKeywordIndexProgress)
 	}
 }
 
@@ -276,6 +314,7 @@ func RegisterKnowledgeTagRoutes(r *gin.RouterGroup, tagHandler *handler.TagHandl
 	{
 		kbTags.GET("", tagHandler.ListTags)
 		kbTags.POST("", tagHandler.CreateTag)
+		kbTags.POST("/merge", tagHandler.MergeTags)
 		kbTags.PUT("/:tag_id", tagHandler.UpdateTag)
 		kbTags.DELETE("/:tag_id", tagHandler.DeleteTag)
 	}
@@ -308,7 +347,15 @@ func RegisterSessionRoutes(r *gin.RouterGroup, handler *session.Handler) {
 		sessions.PUT("/:id", handler.UpdateSession)
 		sessions.DELETE("/:id", handler.DeleteSession)
 		sessions.POST("/:session_id/generate_title", handler.GenerateTitle)
+		sessions.POST("/:session_id/regenerate-title", handler.RegenerateTitle)
 		sessions.POST("/:session_id/stop", handler.StopSession)
+		sessions.GET("/:id/messages/search", handler.SearchSessionMessages)
+		sessions.GET("/:id/messages/:message_id/references", handler.GetMessageReferences)
+		sessions.GET("/:id/context-summary", handler.GetContextSummary)
+		sessions.PUT("/:id/context-summary", handler.UpdateContextSummary)
+		sessions.PUT("/:id/pinned-kbs", handler.PinKnowledgeBases)
+		sessions.POST("/:id/prune", handler.PruneSessionHistory)
+		sessions.POST("/:id/attachments", handler.AttachFile)
 		// 继续接收活跃流
 		sessions.GET("/continue-stream/:session_id", handler.ContinueStream)
 	}
@@ -367,12 +414,18 @@ func RegisterModelRoutes(r *gin.RouterGroup, handler *handler.ModelHandler) {
 		models.POST("", handler.CreateModel)
 		// 获取模型列表
 		models.GET("", handler.ListModels)
+		// 获取按类型分组的模型目录
+		models.GET("/grouped", handler.ListModelsGrouped)
 		// 获取单个模型
 		models.GET("/:id", handler.GetModel)
 		// 更新模型
 		models.PUT("/:id", handler.UpdateModel)
 		// 删除模型
 		models.DELETE("/:id", handler.DeleteModel)
+		// 预热全部模型
+		models.POST("/warmup", handler.WarmupModels)
+		// 预热单个模型
+		models.POST("/:id/warmup", handler.WarmupModel)
 	}
 }
 
@@ -392,6 +445,7 @@ func RegisterAuthRoutes(r *gin.RouterGroup, handler *handler.AuthHandler) {
 	r.GET("/auth/validate", handler.ValidateToken)
 	r.POST("/auth/logout", handler.Logout)
 	r.GET("/auth/me", handler.GetCurrentUser)
+	r.GET("/me/notifications", handler.ListNotifications)
 	r.POST("/auth/change-password", handler.ChangePassword)
 }
 
@@ -434,6 +488,27 @@ func RegisterSystemRoutes(r *gin.RouterGroup, handler *handler.SystemHandler) {
 	}
 }
 
+// RegisterAdminRoutes registers admin/debug routes, only reachable by system administrators
+func RegisterAdminRoutes(
+	r *gin.RouterGroup,
+	handler *handler.SystemHandler,
+	sessionHandler *session.Handler,
+	configBundleHandler *handler.ConfigBundleHandler,
+	cfg *config.Config,
+) {
+	adminRoutes := r.Group("/admin", middleware.RequireSystemAdmin(cfg))
+	{
+		adminRoutes.POST("/sql/parse", handler.ParseSQLDebug)
+		adminRoutes.POST("/sql/validate", handler.ValidateSQLDebug)
+		// 进行中的流式生成
+		adminRoutes.GET("/active-generations", sessionHandler.ListActiveGenerations)
+		adminRoutes.POST("/generations/:id/cancel", sessionHandler.CancelGeneration)
+		// 租户配置迁移（知识库配置、智能体、组织成员关系）
+		adminRoutes.GET("/export-config", configBundleHandler.ExportConfig)
+		adminRoutes.POST("/import-config", configBundleHandler.ImportConfig)
+	}
+}
+
 // RegisterMCPServiceRoutes registers MCP service routes
 func RegisterMCPServiceRoutes(r *gin.RouterGroup, handler *handler.MCPServiceHandler) {
 	mcpServices := r.Group("/mcp-services")
@@ -475,6 +550,8 @@ func RegisterCustomAgentRoutes(r *gin.RouterGroup, agentHandler *handler.CustomA
 		agents.GET("/placeholders", agentHandler.GetPlaceholders)
 		// Create custom agent
 		agents.POST("", agentHandler.CreateAgent)
+		// Compare two agents on the same query
+		agents.POST("/compare", agentHandler.CompareAgents)
 		// List all agents (including built-in)
 		agents.GET("", agentHandler.ListAgents)
 		// Get agent by ID
@@ -485,6 +562,36 @@ func RegisterCustomAgentRoutes(r *gin.RouterGroup, agentHandler *handler.CustomA
 		agents.DELETE("/:id", agentHandler.DeleteAgent)
 		// Copy agent
 		agents.POST("/:id/copy", agentHandler.CopyAgent)
+		// Preview the knowledge bases an agent resolves to
+		agents.GET("/:id/resolved-kbs", agentHandler.PreviewResolvedKBs)
+		// Clone an owned or shared agent into the current tenant
+		agents.POST("/:id/clone", agentHandler.CloneAgent)
+		// Reset agent config to defaults
+		agents.POST("/:id/reset-config", agentHandler.ResetAgentConfig)
+		// Evaluate agent against a set of questions
+		agents.POST("/:id/evaluate", agentHandler.EvaluateAgent)
+	}
+}
+
+// RegisterPromptTemplateRoutes registers prompt library routes
+func RegisterPromptTemplateRoutes(r *gin.RouterGroup, templateHandler *handler.PromptTemplateHandler) {
+	templates := r.Group("/prompt-templates")
+	{
+		templates.POST("", templateHandler.CreateTemplate)
+		templates.GET("", templateHandler.ListTemplates)
+		templates.GET("/:id", templateHandler.GetTemplate)
+		templates.PUT("/:id", templateHandler.UpdateTemplate)
+		templates.DELETE("/:id", templateHandler.DeleteTemplate)
+	}
+}
+
+// RegisterAPIKeyRoutes registers scoped API key routes
+func RegisterAPIKeyRoutes(r *gin.RouterGroup, apiKeyHandler *handler.APIKeyHandler) {
+	apiKeys := r.Group("/api-keys")
+	{
+		apiKeys.POST("", apiKeyHandler.CreateAPIKey)
+		apiKeys.GET("", apiKeyHandler.ListAPIKeys)
+		apiKeys.DELETE("/:id", apiKeyHandler.DeleteAPIKey)
 	}
 }
 
@@ -512,12 +619,22 @@ func RegisterOrganizationRoutes(r *gin.RouterGroup, orgHandler *handler.Organiza
 		orgs.POST("/join", orgHandler.JoinByInviteCode)
 		// Submit join request (for organizations that require approval)
 		orgs.POST("/join-request", orgHandler.SubmitJoinRequest)
+		// Cancel my pending join or upgrade request
+		orgs.DELETE("/requests/:request_id", orgHandler.CancelRequest)
+		// Accept or decline an invite sent to my email before I signed up
+		orgs.POST("/invites/:invite_id/accept", orgHandler.AcceptInvite)
+		orgs.POST("/invites/:invite_id/decline", orgHandler.DeclineInvite)
 		// Search searchable (discoverable) organizations
 		orgs.GET("/search", orgHandler.SearchOrganizations)
 		// Join searchable organization by ID (no invite code)
 		orgs.POST("/join-by-id", orgHandler.JoinByOrganizationID)
 		// Get organization by ID
 		orgs.GET("/:id", orgHandler.GetOrganization)
+		// Preview searchable organization by ID (without joining)
+		orgs.GET("/:id/preview", orgHandler.PreviewOrganization)
+		// Upload organization avatar (admin only) and serve the uploaded avatar
+		orgs.POST("/:id/avatar", orgHandler.UploadAvatar)
+		orgs.GET("/:id/avatar", orgHandler.GetAvatar)
 		// Update organization
 		orgs.PUT("/:id", orgHandler.UpdateOrganization)
 		// Delete organization
@@ -532,18 +649,33 @@ func RegisterOrganizationRoutes(r *gin.RouterGroup, orgHandler *handler.Organiza
 		orgs.GET("/:id/search-users", orgHandler.SearchUsersForInvite)
 		// Invite member directly (admin only)
 		orgs.POST("/:id/invite", orgHandler.InviteMember)
+		// Invite members by email in bulk (admin only)
+		orgs.POST("/:id/invite-bulk", orgHandler.BulkInviteMembers)
 		// List members
 		orgs.GET("/:id/members", orgHandler.ListMembers)
+		// Search members by username or email
+		orgs.GET("/:id/members/search", orgHandler.SearchMembers)
 		// Update member role
 		orgs.PUT("/:id/members/:user_id", orgHandler.UpdateMemberRole)
 		// Remove member
 		orgs.DELETE("/:id/members/:user_id", orgHandler.RemoveMember)
+		// Suspend / unsuspend member (admin only)
+		orgs.POST("/:id/members/:user_id/suspend", orgHandler.SuspendMember)
+		orgs.POST("/:id/members/:user_id/unsuspend", orgHandler.UnsuspendMember)
 		// List join requests (admin only)
 		orgs.GET("/:id/join-requests", orgHandler.ListJoinRequests)
+		// List pending role upgrade requests (admin only); reviewed via the join request review route
+		orgs.GET("/:id/upgrade-requests", orgHandler.ListUpgradeRequests)
 		// Review join request (admin only)
 		orgs.PUT("/:id/join-requests/:request_id/review", orgHandler.ReviewJoinRequest)
 		// List knowledge bases shared to this organization
 		orgs.GET("/:id/shares", orgHandler.ListOrgShares)
+		// Storage used by the knowledge bases shared to this organization
+		orgs.GET("/:id/storage", orgHandler.GetOrgStorage)
+		// Dynamic share rules (share every KB matching a tag)
+		orgs.POST("/:id/dynamic-shares", orgHandler.CreateDynamicShare)
+		orgs.GET("/:id/dynamic-shares", orgHandler.ListDynamicShares)
+		orgs.DELETE("/:id/dynamic-shares/:rule_id", orgHandler.RemoveDynamicShare)
 		// List agents shared to this organization
 		orgs.GET("/:id/agent-shares", orgHandler.ListOrgAgentShares)
 		// List all knowledge bases in this organization (including mine) for list-page space view
@@ -573,6 +705,9 @@ func RegisterOrganizationRoutes(r *gin.RouterGroup, orgHandler *handler.Organiza
 		agentShares.DELETE("/:share_id", orgHandler.RemoveAgentShare)
 	}
 
+	// Copy a knowledge base to another user (independent copy instead of a share)
+	r.POST("/knowledge-bases/:id/copy-to-user", orgHandler.CopyKBToUser)
+
 	// Shared knowledge bases route
 	r.GET("/shared-knowledge-bases", orgHandler.ListSharedKnowledgeBases)
 	// Shared agents route
@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/Tencent/WeKnora/internal/config"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 	"github.com/hibiken/asynq"
@@ -16,6 +17,7 @@ type AsynqTaskParams struct {
 	dig.In
 
 	Server               *asynq.Server
+	Config               *config.Config
 	KnowledgeService     interfaces.KnowledgeService
 	KnowledgeBaseService interfaces.KnowledgeBaseService
 	TagService           interfaces.KnowledgeTagService
@@ -62,6 +64,9 @@ func NewAsynqServer() *asynq.Server {
 				"default":  3, // Default priority queue
 				"low":      1, // Lowest priority queue
 			},
+			// Tasks postponed by the tenant concurrency limit are retried soon and not counted as failures
+			IsFailure:      isTaskFailure,
+			RetryDelayFunc: taskRetryDelay,
 		},
 	)
 	return srv
@@ -75,8 +80,13 @@ func RunAsynqServer(params AsynqTaskParams) *asynq.ServeMux {
 	mux.HandleFunc(types.TypeChunkExtract, params.ChunkExtractor.Handle)
 	mux.HandleFunc(types.TypeDataTableSummary, params.DataTableSummary.Handle)
 
-	// Register document processing handler
-	mux.HandleFunc(types.TypeDocumentProcess, params.KnowledgeService.ProcessDocument)
+	// Register document processing handler, limited per tenant
+	var processingConcurrency *config.ProcessingConcurrencyConfig
+	if params.Config != nil && params.Config.KnowledgeBase != nil {
+		processingConcurrency = params.Config.KnowledgeBase.ProcessingConcurrency
+	}
+	documentLimiter := NewTenantConcurrencyLimiter(processingConcurrency.LimitFor)
+	mux.HandleFunc(types.TypeDocumentProcess, documentLimiter.Wrap(params.KnowledgeService.ProcessDocument))
 
 	// Register FAQ import handler (includes dry run mode)
 	mux.HandleFunc(types.TypeFAQImport, params.KnowledgeService.ProcessFAQImport)
@@ -96,12 +106,18 @@ func RunAsynqServer(params AsynqTaskParams) *asynq.ServeMux {
 	// Register knowledge list delete handler
 	mux.HandleFunc(types.TypeKnowledgeListDelete, params.KnowledgeService.ProcessKnowledgeListDelete)
 
+	// Register KB reindex handler
+	mux.HandleFunc(types.TypeKBReindex, params.KnowledgeService.ProcessKBReindex)
+
 	// Register index delete handler
 	mux.HandleFunc(types.TypeIndexDelete, params.TagService.ProcessIndexDelete)
 
 	// Register KB delete handler
 	mux.HandleFunc(types.TypeKBDelete, params.KnowledgeBaseService.ProcessKBDelete)
 
+	// Register KB keyword index rebuild handler
+	mux.HandleFunc(types.TypeKBKeywordIndex, params.KnowledgeBaseService.ProcessKeywordIndexRebuild)
+
 	// Register image multimodal handler
 	mux.HandleFunc(types.TypeImageMultimodal, params.ImageMultimodal.Handle)
 
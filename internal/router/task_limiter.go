@@ -0,0 +1,133 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hibiken/asynq"
+)
+
+// ErrTenantConcurrencyLimit is returned when a tenant already runs as many tasks as allowed.
+// The task is not counted as failed and is picked up again after tenantConcurrencyRetryDelay.
+var ErrTenantConcurrencyLimit = errors.New("tenant concurrency limit reached")
+
+// tenantConcurrencyRetryDelay is how long a task rejected by the tenant limit waits before running again
+const tenantConcurrencyRetryDelay = 5 * time.Second
+
+// TenantConcurrencyLimiter is a semaphore keyed by tenant that bounds the tasks each tenant runs
+// at the same time in this worker, so one tenant's bulk upload cannot occupy every worker
+type TenantConcurrencyLimiter struct {
+	mu      sync.Mutex
+	running map[uint64]int
+	// released is closed and replaced whenever a slot is freed, waking up the tasks waiting for one
+	released chan struct{}
+	limitFor func(tenantID uint64) int
+	// isLastAttempt reports whether asynq archives the task instead of retrying it when it fails
+	isLastAttempt func(ctx context.Context) bool
+}
+
+// NewTenantConcurrencyLimiter creates a limiter; limitFor returns a tenant's limit, 0 meaning unlimited
+func NewTenantConcurrencyLimiter(limitFor func(tenantID uint64) int) *TenantConcurrencyLimiter {
+	return &TenantConcurrencyLimiter{
+		running:       make(map[uint64]int),
+		released:      make(chan struct{}),
+		limitFor:      limitFor,
+		isLastAttempt: isLastTaskAttempt,
+	}
+}
+
+// isLastTaskAttempt reports whether the task has used up its retries. asynq archives such a task
+// whatever IsFailure says, so it cannot be postponed.
+func isLastTaskAttempt(ctx context.Context) bool {
+	retried, ok := asynq.GetRetryCount(ctx)
+	if !ok {
+		return false
+	}
+	maxRetry, ok := asynq.GetMaxRetry(ctx)
+	return ok && retried >= maxRetry
+}
+
+// tryAcquire takes a slot of the tenant if one is free. Otherwise it returns a channel that is
+// closed when a slot of any tenant is released.
+func (l *TenantConcurrencyLimiter) tryAcquire(tenantID uint64) (bool, <-chan struct{}) {
+	limit := l.limitFor(tenantID)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if limit > 0 && l.running[tenantID] >= limit {
+		return false, l.released
+	}
+	l.running[tenantID]++
+	return true, nil
+}
+
+// acquire waits until a slot of the tenant is free and takes it, giving up when ctx is done
+func (l *TenantConcurrencyLimiter) acquire(ctx context.Context, tenantID uint64) error {
+	for {
+		ok, released := l.tryAcquire(tenantID)
+		if ok {
+			return nil
+		}
+		select {
+		case <-released:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// release frees a slot taken by tryAcquire or acquire
+func (l *TenantConcurrencyLimiter) release(tenantID uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.running[tenantID] <= 1 {
+		delete(l.running, tenantID)
+	} else {
+		l.running[tenantID]--
+	}
+	close(l.released)
+	l.released = make(chan struct{})
+}
+
+// Wrap limits a task handler by the tenant_id of the task payload. A task finding no free slot is
+// postponed, except on its last attempt, where it waits for a slot since postponing would archive
+// it. The slot is released however the handler ends, including errors and panics. Tasks without a
+// tenant ID are not limited.
+func (l *TenantConcurrencyLimiter) Wrap(
+	handler func(context.Context, *asynq.Task) error,
+) func(context.Context, *asynq.Task) error {
+	return func(ctx context.Context, t *asynq.Task) error {
+		var payload struct {
+			TenantID uint64 `json:"tenant_id"`
+		}
+		if err := json.Unmarshal(t.Payload(), &payload); err != nil || payload.TenantID == 0 {
+			return handler(ctx, t)
+		}
+		if ok, _ := l.tryAcquire(payload.TenantID); !ok {
+			if !l.isLastAttempt(ctx) {
+				return fmt.Errorf("%w: tenant %d", ErrTenantConcurrencyLimit, payload.TenantID)
+			}
+			if err := l.acquire(ctx, payload.TenantID); err != nil {
+				return err
+			}
+		}
+		defer l.release(payload.TenantID)
+		return handler(ctx, t)
+	}
+}
+
+// isTaskFailure does not count tasks postponed by the tenant limit as failures, so they keep their retries
+func isTaskFailure(err error) bool {
+	return err != nil && !errors.Is(err, ErrTenantConcurrencyLimit)
+}
+
+// taskRetryDelay retries tasks postponed by the tenant limit after a short fixed delay
+func taskRetryDelay(n int, err error, t *asynq.Task) time.Duration {
+	if errors.Is(err, ErrTenantConcurrencyLimit) {
+		return tenantConcurrencyRetryDelay
+	}
+	return asynq.DefaultRetryDelayFunc(n, err, t)
+}
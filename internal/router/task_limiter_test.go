@@ -0,0 +1,153 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hibiken/asynq"
+)
+
+func tenantTask(payload string) *asynq.Task {
+	return asynq.NewTask("document:process", []byte(payload))
+}
+
+func TestTenantConcurrencyLimiter(t *testing.T) {
+	limiter := NewTenantConcurrencyLimiter(func(tenantID uint64) int {
+		if tenantID == 2 {
+			return 0 // unlimited
+		}
+		return 1
+	})
+
+	// A task of tenant 1 holds its only slot while other tasks try to run
+	inner := make(map[string]error)
+	blocking := limiter.Wrap(func(ctx context.Context, t *asynq.Task) error {
+		run := limiter.Wrap(func(ctx context.Context, t *asynq.Task) error { return nil })
+		inner["same tenant"] = run(ctx, tenantTask(`{"tenant_id":1}`))
+		inner["other tenant"] = run(ctx, tenantTask(`{"tenant_id":3}`))
+		inner["unlimited tenant"] = run(ctx, tenantTask(`{"tenant_id":2}`))
+		inner["no tenant"] = run(ctx, tenantTask(`{}`))
+		return nil
+	})
+	if err := blocking(context.Background(), tenantTask(`{"tenant_id":1}`)); err != nil {
+		t.Fatalf("first task error = %v", err)
+	}
+	if !errors.Is(inner["same tenant"], ErrTenantConcurrencyLimit) {
+		t.Errorf("same tenant error = %v, want %v", inner["same tenant"], ErrTenantConcurrencyLimit)
+	}
+	for _, name := range []string{"other tenant", "unlimited tenant", "no tenant"} {
+		if inner[name] != nil {
+			t.Errorf("%s error = %v, want nil", name, inner[name])
+		}
+	}
+
+	if isTaskFailure(inner["same tenant"]) {
+		t.Error("a task postponed by the tenant limit counts as a failure")
+	}
+	if d := taskRetryDelay(10, inner["same tenant"], nil); d != tenantConcurrencyRetryDelay {
+		t.Errorf("retry delay = %v, want %v", d, tenantConcurrencyRetryDelay)
+	}
+}
+
+func TestTenantConcurrencyLimiterReleasesOnFailure(t *testing.T) {
+	limiter := NewTenantConcurrencyLimiter(func(uint64) int { return 1 })
+	task := tenantTask(`{"tenant_id":1}`)
+	errProcess := errors.New("parse failed")
+
+	failing := limiter.Wrap(func(ctx context.Context, t *asynq.Task) error { return errProcess })
+	if err := failing(context.Background(), task); !errors.Is(err, errProcess) {
+		t.Fatalf("failing task error = %v, want %v", err, errProcess)
+	}
+
+	panicking := limiter.Wrap(func(ctx context.Context, t *asynq.Task) error { panic("boom") })
+	func() {
+		defer func() { _ = recover() }()
+		_ = panicking(context.Background(), task)
+	}()
+
+	ok := limiter.Wrap(func(ctx context.Context, t *asynq.Task) error { return nil })
+	if err := ok(context.Background(), task); err != nil {
+		t.Errorf("task after failure and panic error = %v, want the slot to be free", err)
+	}
+	if len(limiter.running) != 0 {
+		t.Errorf("running = %v, want no slots held", limiter.running)
+	}
+}
+
+func TestTenantConcurrencyLimiterWaitsOnLastAttempt(t *testing.T) {
+	limiter := NewTenantConcurrencyLimiter(func(uint64) int { return 1 })
+	limiter.isLastAttempt = func(context.Context) bool { return true }
+	task := tenantTask(`{"tenant_id":1}`)
+
+	// The first task holds the only slot until unblocked
+	started, unblock := make(chan struct{}), make(chan struct{})
+	firstDone := make(chan error)
+	go func() {
+		firstDone <- limiter.Wrap(func(ctx context.Context, t *asynq.Task) error {
+			close(started)
+			<-unblock
+			return nil
+		})(context.Background(), task)
+	}()
+	<-started
+
+	// Postponing the last attempt would archive it, so it waits for the slot instead
+	ran := make(chan struct{})
+	secondDone := make(chan error)
+	go func() {
+		secondDone <- limiter.Wrap(func(ctx context.Context, t *asynq.Task) error {
+			close(ran)
+			return nil
+		})(context.Background(), task)
+	}()
+	select {
+	case <-ran:
+		t.Fatal("last attempt ran while the tenant slot was taken")
+	case err := <-secondDone:
+		t.Fatalf("last attempt returned %v while waiting for a slot", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(unblock)
+	if err := <-firstDone; err != nil {
+		t.Fatalf("first task error = %v", err)
+	}
+	if err := <-secondDone; err != nil {
+		t.Fatalf("last attempt error = %v, want it to run once the slot is free", err)
+	}
+	if len(limiter.running) != 0 {
+		t.Errorf("running = %v, want no slots held", limiter.running)
+	}
+}
+
+func TestTenantConcurrencyLimiterLastAttemptCancelled(t *testing.T) {
+	limiter := NewTenantConcurrencyLimiter(func(uint64) int { return 1 })
+	limiter.isLastAttempt = func(context.Context) bool { return true }
+	task := tenantTask(`{"tenant_id":1}`)
+
+	var waitErr error
+	holding := limiter.Wrap(func(ctx context.Context, t *asynq.Task) error {
+		waitCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		waitErr = limiter.Wrap(func(ctx context.Context, t *asynq.Task) error { return nil })(waitCtx, task)
+		return nil
+	})
+	if err := holding(context.Background(), task); err != nil {
+		t.Fatalf("holding task error = %v", err)
+	}
+	if !errors.Is(waitErr, context.DeadlineExceeded) {
+		t.Errorf("waiting task error = %v, want %v", waitErr, context.DeadlineExceeded)
+	}
+	if len(limiter.running) != 0 {
+		t.Errorf("running = %v, want no slots held", limiter.running)
+	}
+}
+
+func TestIsLastTaskAttempt(t *testing.T) {
+	// Outside an asynq worker the retry count is unknown, so the task is postponed as usual
+	if isLastTaskAttempt(context.Background()) {
+		t.Error("isLastTaskAttempt() = true without retry information")
+	}
+}
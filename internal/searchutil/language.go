@@ -0,0 +1,61 @@
+package searchutil
+
+import "unicode"
+
+// Language codes returned by DetectLanguage (ISO 639-1)
+const (
+	LanguageChinese  = "zh"
+	LanguageJapanese = "ja"
+	LanguageKorean   = "ko"
+	LanguageRussian  = "ru"
+	LanguageArabic   = "ar"
+	LanguageThai     = "th"
+	LanguageEnglish  = "en"
+)
+
+// DetectLanguage guesses the language of a short text such as a user query from the scripts it uses.
+// Kana marks Japanese even when mixed with Han characters; Latin-script text is reported as English.
+// An empty string is returned when the text has no letters.
+func DetectLanguage(text string) string {
+	counts := make(map[string]int)
+	hasKana := false
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			hasKana = true
+			counts[LanguageJapanese]++
+		case unicode.Is(unicode.Han, r):
+			counts[LanguageChinese]++
+		case unicode.Is(unicode.Hangul, r):
+			counts[LanguageKorean]++
+		case unicode.Is(unicode.Cyrillic, r):
+			counts[LanguageRussian]++
+		case unicode.Is(unicode.Arabic, r):
+			counts[LanguageArabic]++
+		case unicode.Is(unicode.Thai, r):
+			counts[LanguageThai]++
+		case unicode.Is(unicode.Latin, r):
+			counts[LanguageEnglish]++
+		}
+	}
+	if hasKana {
+		return LanguageJapanese
+	}
+
+	// CJK characters carry far more information than Latin letters, so any Han/Hangul
+	// text wins over embedded English terms (e.g. "WeKnora 怎么部署")
+	if counts[LanguageChinese] > 0 || counts[LanguageKorean] > 0 {
+		if counts[LanguageKorean] > counts[LanguageChinese] {
+			return LanguageKorean
+		}
+		return LanguageChinese
+	}
+
+	best, bestCount := "", 0
+	for _, lang := range []string{LanguageRussian, LanguageArabic, LanguageThai, LanguageEnglish} {
+		if counts[lang] > bestCount {
+			best, bestCount = lang, counts[lang]
+		}
+	}
+	return best
+}
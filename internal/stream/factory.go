@@ -14,6 +14,9 @@ const (
 	TypeRedis  = "redis"
 )
 
+// CompletedStreamRetention 生成完成后事件的保留时长，供断线重连的客户端补发遗漏的事件
+const CompletedStreamRetention = 10 * time.Minute
+
 // NewStreamManager 创建流管理器
 func NewStreamManager() (interfaces.StreamManager, error) {
 	switch os.Getenv("STREAM_MANAGER_TYPE") {
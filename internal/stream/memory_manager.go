@@ -5,21 +5,31 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 )
 
+const (
+	// idleStreamRetention bounds streams that never complete (e.g. an interrupted generation)
+	idleStreamRetention = time.Hour
+	// cleanupInterval is the minimum interval between two sweeps of expired streams
+	cleanupInterval = time.Minute
+)
+
 // memoryStreamData holds stream events in memory
 type memoryStreamData struct {
 	events      []interfaces.StreamEvent
 	lastUpdated time.Time
+	completedAt time.Time // zero until the complete event is appended
 	mu          sync.RWMutex
 }
 
 // MemoryStreamManager implements StreamManager using in-memory storage
 type MemoryStreamManager struct {
 	// Map: sessionID -> messageID -> stream data
-	streams map[string]map[string]*memoryStreamData
-	mu      sync.RWMutex
+	streams     map[string]map[string]*memoryStreamData
+	lastCleanup time.Time
+	mu          sync.RWMutex
 }
 
 // NewMemoryStreamManager creates a new in-memory stream manager
@@ -65,6 +75,7 @@ func (m *MemoryStreamManager) AppendEvent(
 	sessionID, messageID string,
 	event interfaces.StreamEvent,
 ) error {
+	m.cleanupExpired(time.Now())
 	stream := m.getOrCreateStream(sessionID, messageID)
 
 	stream.mu.Lock()
@@ -78,10 +89,40 @@ func (m *MemoryStreamManager) AppendEvent(
 	// Append event
 	stream.events = append(stream.events, event)
 	stream.lastUpdated = time.Now()
+	if event.Type == types.ResponseTypeComplete {
+		stream.completedAt = stream.lastUpdated
+	}
 
 	return nil
 }
 
+// cleanupExpired removes streams whose generation completed more than CompletedStreamRetention ago,
+// and streams that have been idle longer than idleStreamRetention, so memory stays bounded
+func (m *MemoryStreamManager) cleanupExpired(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if now.Sub(m.lastCleanup) < cleanupInterval {
+		return
+	}
+	m.lastCleanup = now
+
+	for sessionID, messages := range m.streams {
+		for messageID, stream := range messages {
+			stream.mu.RLock()
+			expired := (!stream.completedAt.IsZero() && now.Sub(stream.completedAt) > CompletedStreamRetention) ||
+				now.Sub(stream.lastUpdated) > idleStreamRetention
+			stream.mu.RUnlock()
+			if expired {
+				delete(messages, messageID)
+			}
+		}
+		if len(messages) == 0 {
+			delete(m.streams, sessionID)
+		}
+	}
+}
+
 // GetEvents gets events starting from offset
 // Returns: events slice, next offset, error
 func (m *MemoryStreamManager) GetEvents(
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 	"github.com/redis/go-redis/v9"
 )
@@ -78,8 +79,13 @@ func (r *RedisStreamManager) AppendEvent(
 		return fmt.Errorf("failed to append event to Redis: %w", err)
 	}
 
-	// Set/refresh TTL on the key
-	if err := r.client.Expire(ctx, key, r.ttl).Err(); err != nil {
+	// Set/refresh TTL on the key; once the generation completes, only keep the events
+	// long enough for a disconnected client to reconnect and replay them
+	ttl := r.ttl
+	if event.Type == types.ResponseTypeComplete && CompletedStreamRetention < ttl {
+		ttl = CompletedStreamRetention
+	}
+	if err := r.client.Expire(ctx, key, ttl).Err(); err != nil {
 		return fmt.Errorf("failed to set TTL: %w", err)
 	}
 
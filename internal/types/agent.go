@@ -10,13 +10,19 @@ import (
 // AgentConfig represents the full agent configuration (used at tenant level and runtime)
 // This includes all configuration parameters for agent execution
 type AgentConfig struct {
-	MaxIterations     int      `json:"max_iterations"`          // Maximum number of ReAct iterations
-	ReflectionEnabled bool     `json:"reflection_enabled"`      // Whether to enable reflection
-	AllowedTools      []string `json:"allowed_tools"`           // List of allowed tool names
-	Temperature       float64  `json:"temperature"`             // LLM temperature for agent
-	KnowledgeBases    []string `json:"knowledge_bases"`         // Accessible knowledge base IDs
-	KnowledgeIDs      []string `json:"knowledge_ids"`           // Accessible knowledge IDs (individual documents)
-	SystemPrompt      string   `json:"system_prompt,omitempty"` // Unified system prompt (uses web_search_status placeholder for dynamic behavior)
+	MaxIterations       int           `json:"max_iterations"`              // Maximum number of ReAct iterations
+	ReflectionEnabled   bool          `json:"reflection_enabled"`          // Whether to enable reflection
+	AllowedTools        []string      `json:"allowed_tools"`               // List of allowed tool names
+	Temperature         float64       `json:"temperature"`                 // LLM temperature for agent
+	TopP                float64       `json:"top_p,omitempty"`             // Nucleus sampling for agent (0 = model default)
+	MaxCompletionTokens int           `json:"max_completion_tokens"`       // Maximum completion tokens per LLM call
+	StopSequences       []string      `json:"stop_sequences"`              // Stop sequences that terminate generation
+	ResponseFormat      string        `json:"response_format"`             // Response format: "text" or "json_object"
+	ResponseSchema      string        `json:"response_schema"`             // Optional JSON schema for the final answer
+	KnowledgeBases      []string      `json:"knowledge_bases"`             // Accessible knowledge base IDs
+	KnowledgeIDs        []string      `json:"knowledge_ids"`               // Accessible knowledge IDs (individual documents)
+	SystemPrompt        string        `json:"system_prompt,omitempty"`     // Unified system prompt (uses web_search_status placeholder for dynamic behavior)
+	FewShotExamples     []ChatExample `json:"few_shot_examples,omitempty"` // Few-shot examples pinned after the system prompt
 	// Deprecated: Use SystemPrompt instead. Kept for backward compatibility during migration.
 	SystemPromptWebEnabled  string        `json:"system_prompt_web_enabled,omitempty"`  // Deprecated: Custom prompt when web search is enabled
 	SystemPromptWebDisabled string        `json:"system_prompt_web_disabled,omitempty"` // Deprecated: Custom prompt when web search is disabled
@@ -172,6 +178,8 @@ type AgentState struct {
 	IsComplete    bool            `json:"is_complete"`    // Whether agent has finished
 	FinalAnswer   string          `json:"final_answer"`   // The final answer to the query
 	KnowledgeRefs []*SearchResult `json:"knowledge_refs"` // Collected knowledge references
+	// Whether the agent stopped at MaxIterations rather than finishing on its own
+	BudgetExceeded bool `json:"budget_exceeded"`
 }
 
 // FunctionDefinition represents a function definition for LLM function calling
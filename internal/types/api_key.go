@@ -0,0 +1,100 @@
+package types
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// ScopedAPIKeyPrefix starts every scoped API key, telling them apart from tenant API keys
+const ScopedAPIKeyPrefix = "sk-kb-"
+
+// APIKey is an API key of a tenant that can only reach the listed knowledge bases,
+// with at most the given permission on them. Only the SHA-256 hash of the key is stored.
+type APIKey struct {
+	// Unique identifier of the key (UUID)
+	ID string `json:"id" gorm:"type:varchar(36);primaryKey"`
+	// Tenant that owns the key and its knowledge bases
+	TenantID uint64 `json:"tenant_id" gorm:"index"`
+	// Name of the key, e.g. the integration that uses it
+	Name string `json:"name" gorm:"type:varchar(255);not null"`
+	// Hex encoded SHA-256 hash of the key
+	KeyHash string `json:"-" gorm:"type:varchar(64);uniqueIndex"`
+	// First characters of the key, shown to tell keys apart
+	KeyPrefix string `json:"key_prefix" gorm:"type:varchar(16)"`
+	// Knowledge bases the key may access
+	KnowledgeBaseIDs StringArray `json:"knowledge_base_ids" gorm:"type:json"`
+	// Highest permission of the key on its knowledge bases: viewer or editor
+	Permission OrgMemberRole `json:"permission" gorm:"type:varchar(32)"`
+	// Uploads per minute allowed to the key, 0 uses the configured default
+	UploadRateLimit int `json:"upload_rate_limit"`
+	// Uploads per day allowed to the key, 0 uses the configured default
+	DailyUploadQuota int `json:"daily_upload_quota"`
+	// User who created the key
+	CreatedBy string `json:"created_by" gorm:"type:varchar(36)"`
+	// Last time the key authenticated a request, updated at most once a minute
+	LastUsedAt *time.Time `json:"last_used_at"`
+
+	// Timestamps
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Upload usage in the current windows, filled when keys are listed
+	Usage *APIKeyUsage `json:"usage,omitempty" gorm:"-"`
+}
+
+// APIKeyUsage is the upload usage of a scoped API key against its effective limits.
+// Limits of 0 are unlimited.
+type APIKeyUsage struct {
+	// Uploads in the current minute
+	UploadsThisMinute int64 `json:"uploads_this_minute"`
+	// Uploads allowed per minute
+	UploadRateLimit int `json:"upload_rate_limit"`
+	// Uploads since midnight (server time)
+	UploadsToday int64 `json:"uploads_today"`
+	// Uploads allowed per day
+	DailyUploadQuota int `json:"daily_upload_quota"`
+	// When the daily quota starts over
+	QuotaResetsAt time.Time `json:"quota_resets_at"`
+}
+
+// TableName returns the table name for APIKey
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// IsValidAPIKeyPermission reports whether a scoped API key may be granted the permission;
+// managing knowledge base settings (admin) is left to users
+func IsValidAPIKeyPermission(permission OrgMemberRole) bool {
+	return permission == OrgRoleViewer || permission == OrgRoleEditor
+}
+
+// AllowsKnowledgeBase reports whether the knowledge base is in the key's scope
+func (k *APIKey) AllowsKnowledgeBase(kbID string) bool {
+	return slices.Contains(k.KnowledgeBaseIDs, kbID)
+}
+
+// Allows reports whether the key may access the knowledge base with the required permission
+func (k *APIKey) Allows(kbID string, required OrgMemberRole) bool {
+	return k.AllowsKnowledgeBase(kbID) && k.Permission.HasPermission(required)
+}
+
+// CapPermission lowers a permission to the key's permission when it is higher
+func (k *APIKey) CapPermission(permission OrgMemberRole) OrgMemberRole {
+	if k.Permission.HasPermission(permission) {
+		return permission
+	}
+	return k.Permission
+}
+
+// IsScopedAPIKey reports whether a raw key is a scoped API key rather than a tenant API key
+func IsScopedAPIKey(rawKey string) bool {
+	return strings.HasPrefix(rawKey, ScopedAPIKeyPrefix)
+}
+
+// CreatedAPIKey is returned once when a key is created; the plaintext key cannot be retrieved later
+type CreatedAPIKey struct {
+	*APIKey
+	// Plaintext key, sent in the X-API-Key header
+	Key string `json:"key"`
+}
@@ -0,0 +1,97 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"strings"
+)
+
+// Actions taken when a banned phrase is found in a streamed answer
+const (
+	// BannedWordsActionMask replaces each banned phrase with the mask
+	BannedWordsActionMask = "mask"
+	// BannedWordsActionHalt stops the answer at the first banned phrase and appends the halt message
+	BannedWordsActionHalt = "halt"
+)
+
+// Defaults and limits for BannedWordsConfig
+const (
+	DefaultBannedWordsMask        = "***"
+	DefaultBannedWordsHaltMessage = "抱歉，回答内容包含不允许输出的词语，已停止生成。"
+	MaxBannedPhrases              = 500
+	MaxBannedPhraseLength         = 100
+)
+
+// BannedWordsConfig lists phrases that must not appear in generated answers (KnowledgeQA and AgentQA).
+// Matching is case-insensitive and happens while the answer streams: each chunk is scanned and up to
+// the longest phrase length of text is held back until the next chunk arrives, so streaming is delayed
+// by a few characters and the scanning cost grows with the number of phrases times the answer length.
+// Disabled by default.
+//
+// Stored as a JSONB column on the tenants table, managed via /tenants/kv/banned-words-config.
+type BannedWordsConfig struct {
+	// Enabled turns the filter on
+	Enabled bool `json:"enabled"`
+	// Phrases are the banned words or phrases
+	Phrases []string `json:"phrases"`
+	// Action is "mask" (default) or "halt"
+	Action string `json:"action"`
+	// Mask replaces banned phrases when Action is "mask" (default "***")
+	Mask string `json:"mask,omitempty"`
+	// HaltMessage is appended when Action is "halt"
+	HaltMessage string `json:"halt_message,omitempty"`
+}
+
+// IsActive reports whether the filter is enabled with at least one non-empty phrase
+func (c *BannedWordsConfig) IsActive() bool {
+	if c == nil || !c.Enabled {
+		return false
+	}
+	for _, p := range c.Phrases {
+		if strings.TrimSpace(p) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// GetAction returns the configured action, defaulting to mask
+func (c *BannedWordsConfig) GetAction() string {
+	if c != nil && c.Action == BannedWordsActionHalt {
+		return BannedWordsActionHalt
+	}
+	return BannedWordsActionMask
+}
+
+// GetMask returns the mask text, falling back to the default
+func (c *BannedWordsConfig) GetMask() string {
+	if c == nil || c.Mask == "" {
+		return DefaultBannedWordsMask
+	}
+	return c.Mask
+}
+
+// GetHaltMessage returns the halt message, falling back to the default
+func (c *BannedWordsConfig) GetHaltMessage() string {
+	if c == nil || c.HaltMessage == "" {
+		return DefaultBannedWordsHaltMessage
+	}
+	return c.HaltMessage
+}
+
+// Value implements the driver.Valuer interface for database serialization
+func (c BannedWordsConfig) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+// Scan implements the sql.Scanner interface for database deserialization
+func (c *BannedWordsConfig) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, c)
+}
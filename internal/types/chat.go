@@ -60,6 +60,10 @@ const (
 	ResponseTypeAgentQuery ResponseType = "agent_query"
 	// Complete response type (agent complete)
 	ResponseTypeComplete ResponseType = "complete"
+	// Budget exceeded response type (agent stopped at max iterations, the answer may be incomplete)
+	ResponseTypeBudgetExceeded ResponseType = "budget_exceeded"
+	// Heartbeat response type (keepalive while a generation is in flight, no content)
+	ResponseTypeHeartbeat ResponseType = "heartbeat"
 )
 
 // StreamResponse stream response
@@ -1,5 +1,16 @@
 package types
 
+// DefaultDedupThreshold is the conservative token similarity above which two retrieved chunks
+// are collapsed into the higher-scored one
+const DefaultDedupThreshold = 0.9
+
+// DefaultMinResultsForAnswer answers whenever retrieval found anything, matching the
+// behavior of falling back only on an empty search
+const DefaultMinResultsForAnswer = 1
+
+// MaxChunkContextWindow caps the number of adjacent chunks added on each side of a retrieved chunk
+const MaxChunkContextWindow = 5
+
 // ChatManage represents the configuration and state for a chat session
 // including query processing, search parameters, and model configurations
 type ChatManage struct {
@@ -12,6 +23,8 @@ type ChatManage struct {
 
 	KnowledgeBaseIDs []string `json:"knowledge_base_ids"`      // IDs of knowledge bases to search (multi-KB support)
 	KnowledgeIDs     []string `json:"knowledge_ids,omitempty"` // IDs of specific files to search (optional)
+	// RetrievalFilters restricts retrieval to documents matching metadata and creation date filters
+	RetrievalFilters
 	// SearchTargets is the pre-computed unified search targets
 	// Computed once at request entry point, used throughout the pipeline
 	SearchTargets    SearchTargets `json:"-"`
@@ -23,9 +36,16 @@ type ChatManage struct {
 	RerankModelID   string  `json:"rerank_model_id"`  // Model ID for reranking search results
 	RerankTopK      int     `json:"rerank_top_k"`     // Number of top results after reranking
 	RerankThreshold float64 `json:"rerank_threshold"` // Minimum score threshold for reranked results
+	DedupThreshold  float64 `json:"dedup_threshold"`  // Similarity at which chunks are treated as near-duplicates (0 disables)
 
 	MaxRounds int `json:"max_rounds"` // Maximum history rounds used for rewrite/context
 
+	MinResultsForAnswer int `json:"min_results_for_answer"` // Merged results required before answering; fewer triggers fallback
+
+	CitationRequired bool `json:"citation_required"` // Answers must cite a merged result; otherwise the fallback response is used
+
+	ChunkContextWindow int `json:"chunk_context_window"` // Adjacent chunks added on each side of a merged chunk (0 expands only short chunks)
+
 	ChatModelID      string           `json:"chat_model_id"`     // ID of the chat model to use
 	SummaryConfig    SummaryConfig    `json:"summary_config"`    // Configuration for summary generation
 	FallbackStrategy FallbackStrategy `json:"fallback_strategy"` // Strategy when no relevant results are found
@@ -36,6 +56,9 @@ type ChatManage struct {
 	EnableQueryExpansion bool   `json:"enable_query_expansion"` // Whether to enable query expansion with LLM
 	RewritePromptSystem  string `json:"rewrite_prompt_system"`  // Custom system prompt for rewrite stage
 	RewritePromptUser    string `json:"rewrite_prompt_user"`    // Custom user prompt for rewrite stage
+	// RewritePromptsByLanguage holds per-language rewrite prompts; the rewrite stage selects one by QueryLanguage
+	RewritePromptsByLanguage RewritePrompts `json:"rewrite_prompts_by_language,omitempty"`
+	QueryLanguage            string         `json:"query_language,omitempty"` // Detected language of the query (ISO 639-1)
 
 	// Internal fields for pipeline data processing
 	SearchResult    []*SearchResult   `json:"-"` // Results from search phase
@@ -60,6 +83,8 @@ type ChatManage struct {
 	FAQPriorityEnabled       bool    `json:"-"` // Whether FAQ priority strategy is enabled
 	FAQDirectAnswerThreshold float64 `json:"-"` // Threshold for direct FAQ answer (similarity > this value)
 	FAQScoreBoost            float64 `json:"-"` // Score multiplier for FAQ results
+	// Skip document search when an FAQ hit reaches FAQDirectAnswerThreshold
+	FAQExclusiveAboveThreshold bool `json:"-"`
 }
 
 // Clone creates a deep copy of the ChatManage object
@@ -87,21 +112,26 @@ func (c *ChatManage) Clone() *ChatManage {
 	}
 
 	return &ChatManage{
-		Query:            c.Query,
-		RewriteQuery:     c.RewriteQuery,
-		SessionID:        c.SessionID,
-		KnowledgeBaseIDs: knowledgeBaseIDs,
-		KnowledgeIDs:     knowledgeIDs,
-		SearchTargets:    searchTargets,
-		VectorThreshold:  c.VectorThreshold,
-		KeywordThreshold: c.KeywordThreshold,
-		EmbeddingTopK:    c.EmbeddingTopK,
-		MaxRounds:        c.MaxRounds,
-		VectorDatabase:   c.VectorDatabase,
-		RerankModelID:    c.RerankModelID,
-		RerankTopK:       c.RerankTopK,
-		RerankThreshold:  c.RerankThreshold,
-		ChatModelID:      c.ChatModelID,
+		Query:               c.Query,
+		RewriteQuery:        c.RewriteQuery,
+		SessionID:           c.SessionID,
+		KnowledgeBaseIDs:    knowledgeBaseIDs,
+		KnowledgeIDs:        knowledgeIDs,
+		RetrievalFilters:    c.RetrievalFilters.Clone(),
+		SearchTargets:       searchTargets,
+		VectorThreshold:     c.VectorThreshold,
+		KeywordThreshold:    c.KeywordThreshold,
+		EmbeddingTopK:       c.EmbeddingTopK,
+		MaxRounds:           c.MaxRounds,
+		MinResultsForAnswer: c.MinResultsForAnswer,
+		CitationRequired:    c.CitationRequired,
+		ChunkContextWindow:  c.ChunkContextWindow,
+		VectorDatabase:      c.VectorDatabase,
+		RerankModelID:       c.RerankModelID,
+		RerankTopK:          c.RerankTopK,
+		RerankThreshold:     c.RerankThreshold,
+		DedupThreshold:      c.DedupThreshold,
+		ChatModelID:         c.ChatModelID,
 		SummaryConfig: SummaryConfig{
 			MaxTokens:           c.SummaryConfig.MaxTokens,
 			RepeatPenalty:       c.SummaryConfig.RepeatPenalty,
@@ -116,19 +146,26 @@ func (c *ChatManage) Clone() *ChatManage {
 			Seed:                c.SummaryConfig.Seed,
 			MaxCompletionTokens: c.SummaryConfig.MaxCompletionTokens,
 			Thinking:            c.SummaryConfig.Thinking,
+			ResponseFormat:      c.SummaryConfig.ResponseFormat,
+			ResponseSchema:      c.SummaryConfig.ResponseSchema,
+			FewShotExamples:     c.SummaryConfig.FewShotExamples,
+			Verbosity:           c.SummaryConfig.Verbosity,
 		},
-		FallbackStrategy:     c.FallbackStrategy,
-		FallbackResponse:     c.FallbackResponse,
-		FallbackPrompt:       c.FallbackPrompt,
-		RewritePromptSystem:  c.RewritePromptSystem,
-		RewritePromptUser:    c.RewritePromptUser,
-		EnableRewrite:        c.EnableRewrite,
-		EnableQueryExpansion: c.EnableQueryExpansion,
-		TenantID:             c.TenantID,
+		FallbackStrategy:         c.FallbackStrategy,
+		FallbackResponse:         c.FallbackResponse,
+		FallbackPrompt:           c.FallbackPrompt,
+		RewritePromptSystem:      c.RewritePromptSystem,
+		RewritePromptUser:        c.RewritePromptUser,
+		RewritePromptsByLanguage: c.RewritePromptsByLanguage,
+		QueryLanguage:            c.QueryLanguage,
+		EnableRewrite:            c.EnableRewrite,
+		EnableQueryExpansion:     c.EnableQueryExpansion,
+		TenantID:                 c.TenantID,
 		// FAQ Strategy Settings
-		FAQPriorityEnabled:       c.FAQPriorityEnabled,
-		FAQDirectAnswerThreshold: c.FAQDirectAnswerThreshold,
-		FAQScoreBoost:            c.FAQScoreBoost,
+		FAQPriorityEnabled:         c.FAQPriorityEnabled,
+		FAQDirectAnswerThreshold:   c.FAQDirectAnswerThreshold,
+		FAQScoreBoost:              c.FAQScoreBoost,
+		FAQExclusiveAboveThreshold: c.FAQExclusiveAboveThreshold,
 	}
 }
 
@@ -0,0 +1,89 @@
+package types
+
+import "time"
+
+// TenantConfigBundleVersion is the format version written by the config export
+const TenantConfigBundleVersion = 1
+
+// TenantConfigBundle is the configuration of a tenant exported for migration to another deployment.
+// It holds knowledge base and agent settings but no documents, and no credentials.
+type TenantConfigBundle struct {
+	Version        int       `json:"version"`
+	ExportedAt     time.Time `json:"exported_at"`
+	SourceTenantID uint64    `json:"source_tenant_id"`
+	// Models referenced by the knowledge bases and agents, used to resolve them by name on import
+	Models []ConfigBundleRef `json:"models"`
+	// MCP services referenced by the agents, used to resolve them by name on import
+	MCPServices    []ConfigBundleRef                `json:"mcp_services"`
+	KnowledgeBases []ConfigBundleKnowledgeBase      `json:"knowledge_bases"`
+	Agents         []ConfigBundleAgent              `json:"agents"`
+	Organizations  []ConfigBundleOrganizationMember `json:"organizations"`
+}
+
+// ConfigBundleRef identifies a referenced object by its ID in the source deployment and its name
+type ConfigBundleRef struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type,omitempty"`
+}
+
+// ConfigBundleKnowledgeBase is the exported configuration of a knowledge base
+type ConfigBundleKnowledgeBase struct {
+	// ID in the source deployment, referenced by the agents of the bundle
+	ID                       string                    `json:"id"`
+	Name                     string                    `json:"name"`
+	Type                     string                    `json:"type"`
+	Description              string                    `json:"description"`
+	ChunkingConfig           ChunkingConfig            `json:"chunking_config"`
+	ImageProcessingConfig    ImageProcessingConfig     `json:"image_processing_config"`
+	EmbeddingModelID         string                    `json:"embedding_model_id"`
+	SummaryModelID           string                    `json:"summary_model_id"`
+	VLMConfig                VLMConfig                 `json:"vlm_config"`
+	StorageProviderConfig    *StorageProviderConfig    `json:"storage_provider_config,omitempty"`
+	ExtractConfig            *ExtractConfig            `json:"extract_config,omitempty"`
+	FAQConfig                *FAQConfig                `json:"faq_config,omitempty"`
+	QuestionGenerationConfig *QuestionGenerationConfig `json:"question_generation_config,omitempty"`
+	DuplicateScope           string                    `json:"duplicate_scope"`
+	FallbackResponse         string                    `json:"fallback_response,omitempty"`
+}
+
+// ConfigBundleAgent is the exported configuration of a custom agent
+type ConfigBundleAgent struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Avatar      string            `json:"avatar"`
+	Config      CustomAgentConfig `json:"config"`
+}
+
+// ConfigBundleOrganizationMember records the membership of the exporting user in an organization
+type ConfigBundleOrganizationMember struct {
+	OrganizationID string        `json:"organization_id"`
+	Name           string        `json:"name"`
+	Role           OrgMemberRole `json:"role"`
+}
+
+// ConfigImportItem is an object created by a config import
+type ConfigImportItem struct {
+	SourceID string `json:"source_id"`
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+}
+
+// ConfigImportIssue reports a reference of the bundle that could not be resolved in this deployment
+type ConfigImportIssue struct {
+	// Kind of the object holding the reference: "knowledge_base", "agent" or "organization"
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// Field holding the reference, e.g. "embedding_model_id"
+	Field     string `json:"field,omitempty"`
+	Reference string `json:"reference,omitempty"`
+	Message   string `json:"message"`
+}
+
+// TenantConfigImportResult is the outcome of a config import
+type TenantConfigImportResult struct {
+	KnowledgeBases []ConfigImportItem  `json:"knowledge_bases"`
+	Agents         []ConfigImportItem  `json:"agents"`
+	Unresolved     []ConfigImportIssue `json:"unresolved"`
+}
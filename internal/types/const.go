@@ -21,6 +21,8 @@ const (
 	SessionTenantIDContextKey ContextKey = "SessionTenantID"
 	// EmbedQueryContextKey is the context key for embedding query text
 	EmbedQueryContextKey ContextKey = "EmbedQuery"
+	// APIKeyContextKey is the context key for the scoped API key that authenticated the request
+	APIKeyContextKey ContextKey = "APIKey"
 )
 
 // String returns the string representation of the context key
@@ -45,3 +45,10 @@ func SessionTenantIDFromContext(ctx context.Context) (uint64, bool) {
 	}
 	return TenantIDFromContext(ctx)
 }
+
+// APIKeyFromContext extracts the scoped *APIKey that authenticated the request from ctx.
+// Returns false for JWT and tenant API key requests, which are not restricted to knowledge bases.
+func APIKeyFromContext(ctx context.Context) (*APIKey, bool) {
+	v, ok := ctx.Value(APIKeyContextKey).(*APIKey)
+	return v, ok && v != nil
+}
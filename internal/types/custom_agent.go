@@ -24,6 +24,98 @@ const (
 	BuiltinDocumentAssistantID = "builtin-document-assistant"
 )
 
+// MaxStopSequences is the maximum number of stop sequences an agent may configure
+const MaxStopSequences = 4
+
+// MaxNoMatchPrefixLength is the maximum length in runes of an agent's no-match prefix
+const MaxNoMatchPrefixLength = 200
+
+// Few-shot example limits for an agent
+const (
+	// MaxFewShotExamples is the maximum number of few-shot examples an agent may configure
+	MaxFewShotExamples = 10
+	// MaxFewShotExampleTokens is the maximum estimated token count of all few-shot examples combined
+	MaxFewShotExampleTokens = 4000
+)
+
+// ChatExample is a few-shot example injected as a prior user/assistant turn
+type ChatExample struct {
+	User      string `yaml:"user"      json:"user"`
+	Assistant string `yaml:"assistant" json:"assistant"`
+}
+
+// EstimateChatExampleTokens roughly estimates the token count of examples (about 4 characters per token)
+func EstimateChatExampleTokens(examples []ChatExample) int {
+	totalChars := 0
+	for _, example := range examples {
+		totalChars += len(example.User) + len(example.Assistant)
+	}
+	return totalChars / 4
+}
+
+// Agent evaluation limits
+const (
+	// MaxEvaluationQuestions is the maximum number of questions accepted by a single evaluation run
+	MaxEvaluationQuestions = 20
+	// EvaluationConcurrency is the number of questions evaluated in parallel
+	EvaluationConcurrency = 4
+)
+
+// AgentEvaluationResult is the outcome of running one evaluation question through an agent
+type AgentEvaluationResult struct {
+	Question   string     `json:"question"`
+	Answer     string     `json:"answer"`
+	References References `json:"references"`
+	Error      string     `json:"error,omitempty"`
+	DurationMs int64      `json:"duration_ms"`
+}
+
+// AgentCloneResult is a newly cloned agent plus warnings about references (knowledge bases, models)
+// that are not accessible in the caller's tenant
+type AgentCloneResult struct {
+	Agent    *CustomAgent `json:"agent"`
+	Warnings []string     `json:"warnings"`
+}
+
+// AgentResolvedKBs lists the knowledge bases an agent searches when the request mentions none
+type AgentResolvedKBs struct {
+	KBSelectionMode string `json:"kb_selection_mode"`
+	// When true, the knowledge bases are only searched if the user @mentions them
+	RetrieveKBOnlyWhenMentioned bool              `json:"retrieve_kb_only_when_mentioned"`
+	KnowledgeBases              []AgentResolvedKB `json:"knowledge_bases"`
+}
+
+// AgentResolvedKB is one knowledge base in an agent's resolved scope
+type AgentResolvedKB struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	TenantID uint64 `json:"tenant_id"`
+	// Missing is true when a configured knowledge base no longer exists
+	Missing bool `json:"missing,omitempty"`
+}
+
+// AgentComparisonRun is one side of an A/B comparison between two agents
+type AgentComparisonRun struct {
+	AgentID   string `json:"agent_id"`
+	AgentName string `json:"agent_name"`
+	*AgentEvaluationResult
+}
+
+// AgentComparisonResult holds the side-by-side outcome of running one query through two agents
+type AgentComparisonResult struct {
+	Query  string              `json:"query"`
+	AgentA *AgentComparisonRun `json:"agent_a"`
+	AgentB *AgentComparisonRun `json:"agent_b"`
+}
+
+// ResponseFormat constants for agent output format
+const (
+	// ResponseFormatText is the default free-form text output
+	ResponseFormatText = "text"
+	// ResponseFormatJSONObject requires the final answer to be a valid JSON object
+	ResponseFormatJSONObject = "json_object"
+)
+
 // AgentMode constants for agent running mode
 const (
 	// AgentModeQuickAnswer is the RAG mode for quick Q&A
@@ -67,8 +159,13 @@ type CustomAgentConfig struct {
 	AgentMode string `yaml:"agent_mode" json:"agent_mode"`
 	// System prompt for the agent (unified prompt, uses web_search_status placeholder for dynamic behavior)
 	SystemPrompt string `yaml:"system_prompt" json:"system_prompt"`
+	// Optional ID of a tenant prompt template; when it resolves, it takes precedence over SystemPrompt
+	SystemPromptRef string `yaml:"system_prompt_ref" json:"system_prompt_ref,omitempty"`
 	// Context template for normal mode (how to format retrieved chunks)
 	ContextTemplate string `yaml:"context_template" json:"context_template"`
+	// Few-shot examples injected as prior turns after the system prompt and before history.
+	// They are rebuilt on every request and never stored in the compressed LLM context.
+	FewShotExamples []ChatExample `yaml:"few_shot_examples" json:"few_shot_examples,omitempty"`
 
 	// ===== Model Settings =====
 	// Model ID to use for conversations
@@ -77,10 +174,16 @@ type CustomAgentConfig struct {
 	RerankModelID string `yaml:"rerank_model_id" json:"rerank_model_id"`
 	// Temperature for LLM (0-1)
 	Temperature float64 `yaml:"temperature" json:"temperature"`
-	// Maximum completion tokens (only for normal mode)
+	// Maximum completion tokens (applies to both normal and agent mode)
 	MaxCompletionTokens int `yaml:"max_completion_tokens" json:"max_completion_tokens"`
+	// Stop sequences that terminate generation (at most MaxStopSequences entries)
+	StopSequences []string `yaml:"stop_sequences" json:"stop_sequences"`
 	// Whether to enable thinking mode (for models that support extended thinking)
 	Thinking *bool `yaml:"thinking" json:"thinking"`
+	// Response format: "text" (default) or "json_object"
+	ResponseFormat string `yaml:"response_format" json:"response_format"`
+	// Optional JSON schema for the answer (only used when ResponseFormat is "json_object")
+	ResponseSchema string `yaml:"response_schema" json:"response_schema"`
 
 	// ===== Agent Mode Settings =====
 	// Maximum iterations for ReAct loop (only for agent type)
@@ -120,8 +223,13 @@ type CustomAgentConfig struct {
 	FAQPriorityEnabled bool `yaml:"faq_priority_enabled" json:"faq_priority_enabled"`
 	// FAQ direct answer threshold - if similarity > this value, use FAQ answer directly
 	FAQDirectAnswerThreshold float64 `yaml:"faq_direct_answer_threshold" json:"faq_direct_answer_threshold"`
-	// FAQ score boost multiplier - FAQ results score multiplied by this factor
+	// FAQ score boost multiplier - FAQ results score multiplied by this factor.
+	// Does not compound with Knowledge.RetrievalBoost: an FAQ chunk gets the larger of the two multipliers.
 	FAQScoreBoost float64 `yaml:"faq_score_boost" json:"faq_score_boost"`
+	// Whether to skip document search when an FAQ hit reaches the direct answer threshold.
+	// FAQ knowledge bases are then searched before document knowledge bases instead of alongside them,
+	// which saves the document retrieval (and its reranking) for questions the FAQ answers.
+	FAQExclusiveAboveThreshold bool `yaml:"faq_exclusive_above_threshold" json:"faq_exclusive_above_threshold"`
 
 	// ===== Web Search Settings =====
 	// Whether web search is enabled
@@ -134,6 +242,8 @@ type CustomAgentConfig struct {
 	MultiTurnEnabled bool `yaml:"multi_turn_enabled" json:"multi_turn_enabled"`
 	// Number of history turns to keep in context
 	HistoryTurns int `yaml:"history_turns" json:"history_turns"`
+	// Context compression overrides for agent mode; zero fields fall back to the tenant config
+	ContextConfig *ContextConfig `yaml:"context_config" json:"context_config,omitempty"`
 
 	// ===== Retrieval Strategy Settings (for both modes) =====
 	// Embedding/Vector retrieval top K
@@ -146,6 +256,8 @@ type CustomAgentConfig struct {
 	RerankTopK int `yaml:"rerank_top_k" json:"rerank_top_k"`
 	// Rerank threshold
 	RerankThreshold float64 `yaml:"rerank_threshold" json:"rerank_threshold"`
+	// Adjacent chunks added before and after each retrieved chunk (normal mode); 0 uses the global setting
+	ChunkContextWindow int `yaml:"chunk_context_window" json:"chunk_context_window"`
 
 	// ===== Advanced Settings (mainly for normal mode) =====
 	// Whether to enable query expansion
@@ -156,12 +268,20 @@ type CustomAgentConfig struct {
 	RewritePromptSystem string `yaml:"rewrite_prompt_system" json:"rewrite_prompt_system"`
 	// Rewrite prompt user message template
 	RewritePromptUser string `yaml:"rewrite_prompt_user" json:"rewrite_prompt_user"`
+	// Rewrite prompts per detected query language (ISO 639-1 code), overriding the prompts above
+	RewritePromptsByLanguage RewritePrompts `yaml:"rewrite_prompts_by_language" json:"rewrite_prompts_by_language,omitempty"`
 	// Fallback strategy: "fixed" for fixed response, "model" for model generation
 	FallbackStrategy string `yaml:"fallback_strategy" json:"fallback_strategy"`
 	// Fixed fallback response (when FallbackStrategy is "fixed")
 	FallbackResponse string `yaml:"fallback_response" json:"fallback_response"`
 	// Fallback prompt (when FallbackStrategy is "model")
 	FallbackPrompt string `yaml:"fallback_prompt" json:"fallback_prompt"`
+	// Prefix the model answers with when nothing relevant is found (normal mode); the answer is then
+	// replaced by the fallback. Overrides conversation.summary.no_match_prefix (at most MaxNoMatchPrefixLength runes)
+	NoMatchPrefix string `yaml:"no_match_prefix" json:"no_match_prefix,omitempty"`
+	// Require answers to cite the retrieved chunks (normal mode); uncited answers get the fallback response.
+	// Enabling it globally applies to every agent.
+	CitationRequired bool `yaml:"citation_required" json:"citation_required"`
 }
 
 // Value implements driver.Valuer interface for CustomAgentConfig
@@ -232,6 +352,22 @@ func (a *CustomAgent) EnsureDefaults() {
 	}
 }
 
+// ResetConfig replaces the agent's configuration with base plus defaults, keeping the agent mode
+// and knowledge base selection
+func (a *CustomAgent) ResetConfig(base CustomAgentConfig) {
+	base.AgentMode = a.Config.AgentMode
+	base.KBSelectionMode = a.Config.KBSelectionMode
+	base.KnowledgeBases = a.Config.KnowledgeBases
+	base.RetrieveKBOnlyWhenMentioned = a.Config.RetrieveKBOnlyWhenMentioned
+	a.Config = base
+	a.EnsureDefaults()
+}
+
+// IsJSONMode returns true if the agent's answers must be valid JSON
+func (c *CustomAgentConfig) IsJSONMode() bool {
+	return c.ResponseFormat == ResponseFormatJSONObject
+}
+
 // IsAgentMode returns true if this agent uses ReAct agent mode
 func (a *CustomAgent) IsAgentMode() bool {
 	return a.Config.AgentMode == AgentModeSmartReasoning
@@ -0,0 +1,23 @@
+package types
+
+// Bounds of the number of chunks sent to the embedding model in one request
+const (
+	DefaultEmbeddingBatchSize = 5
+	MaxEmbeddingBatchSize     = 256
+)
+
+// IsValidEmbeddingBatchSize reports whether size is an accepted embedding batch size; 0 means "not set"
+func IsValidEmbeddingBatchSize(size int) bool {
+	return size >= 0 && size <= MaxEmbeddingBatchSize
+}
+
+// ResolveEmbeddingBatchSize returns the first positive size (e.g. tenant override, then global config),
+// capped at MaxEmbeddingBatchSize, or DefaultEmbeddingBatchSize when none is set
+func ResolveEmbeddingBatchSize(sizes ...int) int {
+	for _, size := range sizes {
+		if size > 0 {
+			return min(size, MaxEmbeddingBatchSize)
+		}
+	}
+	return DefaultEmbeddingBatchSize
+}
@@ -19,6 +19,22 @@ func NewStorageQuotaExceededError() *StorageQuotaExceededError {
 	}
 }
 
+// NewOrgStorageQuotaExceededError creates a storage quota exceeded error for an organization the
+// knowledge base is shared to
+func NewOrgStorageQuotaExceededError(orgName string) *StorageQuotaExceededError {
+	return &StorageQuotaExceededError{
+		Message: fmt.Sprintf("Storage quota of organization %s exceeded", orgName),
+	}
+}
+
+// NewCopyStorageQuotaExceededError creates a storage quota exceeded error for a knowledge base copy
+// that does not fit in the remaining storage quota of the target user
+func NewCopyStorageQuotaExceededError() *StorageQuotaExceededError {
+	return &StorageQuotaExceededError{
+		Message: "Storage quota of the target user is not enough for this knowledge base",
+	}
+}
+
 // DuplicateKnowledgeError duplicate knowledge error, contains the existing knowledge object
 type DuplicateKnowledgeError struct {
 	Message   string
@@ -29,6 +45,14 @@ func (e *DuplicateKnowledgeError) Error() string {
 	return e.Message
 }
 
+// KnowledgeBaseID returns the knowledge base holding the existing knowledge
+func (e *DuplicateKnowledgeError) KnowledgeBaseID() string {
+	if e.Knowledge == nil {
+		return ""
+	}
+	return e.Knowledge.KnowledgeBaseID
+}
+
 // NewDuplicateFileError creates a duplicate file error
 func NewDuplicateFileError(knowledge *Knowledge) *DuplicateKnowledgeError {
 	return &DuplicateKnowledgeError{
@@ -13,6 +13,8 @@ const (
 	TypeKnowledgeMove       = "knowledge:move"        // 知识移动任务
 	TypeDataTableSummary    = "datatable:summary"     // 表格摘要任务
 	TypeImageMultimodal     = "image:multimodal"      // 图片多模态处理任务（OCR + VLM Caption）
+	TypeKBKeywordIndex      = "kb:keyword_index"      // 知识库关键词索引重建任务
+	TypeKBReindex           = "kb:reindex"            // 知识库重建向量索引任务
 )
 
 // ExtractChunkPayload represents the extract chunk task payload
@@ -37,6 +39,8 @@ type DocumentProcessPayload struct {
 	EnableMultimodel         bool     `json:"enable_multimodel"`
 	EnableQuestionGeneration bool     `json:"enable_question_generation"` // 是否启用问题生成
 	QuestionCount            int      `json:"question_count,omitempty"`   // 每个chunk生成的问题数量
+	OCREnabled               *bool    `json:"ocr_enabled,omitempty"`      // 本次上传的 OCR 开关，未设置时使用知识库配置
+	OCRLanguages             []string `json:"ocr_languages,omitempty"`    // 本次上传的 OCR 语言，未设置时使用知识库配置
 }
 
 // FAQImportPayload represents the FAQ import task payload (including dry run mode)
@@ -74,6 +78,11 @@ type KBClonePayload struct {
 	TaskID   string `json:"task_id"`
 	SourceID string `json:"source_id"`
 	TargetID string `json:"target_id"`
+	// SourceTenantID is set when the source belongs to another tenant than TenantID (copy to another user)
+	SourceTenantID uint64 `json:"source_tenant_id,omitempty"`
+	// KnowledgeIDs restricts the clone to these source knowledge (retry of failed items);
+	// when set, knowledge missing from the source is not deleted from the target
+	KnowledgeIDs []string `json:"knowledge_ids,omitempty"`
 }
 
 // IndexDeletePayload represents the index delete task payload
@@ -93,6 +102,37 @@ type KBDeletePayload struct {
 	EffectiveEngines []RetrieverEngineParams `json:"effective_engines"`
 }
 
+// KBKeywordIndexPayload represents the knowledge base keyword index rebuild task payload
+type KBKeywordIndexPayload struct {
+	TenantID         uint64                  `json:"tenant_id"`
+	KnowledgeBaseID  string                  `json:"knowledge_base_id"`
+	EffectiveEngines []RetrieverEngineParams `json:"effective_engines"`
+}
+
+// KBReindexPayload represents the knowledge base reindex task payload
+type KBReindexPayload struct {
+	TenantID        uint64 `json:"tenant_id"`
+	KnowledgeBaseID string `json:"knowledge_base_id"`
+}
+
+// KBKeywordIndexProgress represents the progress of a knowledge base keyword index rebuild
+type KBKeywordIndexProgress struct {
+	KnowledgeBaseID string            `json:"knowledge_base_id"`
+	Status          KBCloneTaskStatus `json:"status"`
+	Progress        int               `json:"progress"`   // 0-100
+	Total           int               `json:"total"`      // 总知识数
+	Processed       int               `json:"processed"`  // 已处理数
+	Message         string            `json:"message"`    // 状态消息
+	Error           string            `json:"error"`      // 错误信息
+	CreatedAt       int64             `json:"created_at"` // 任务创建时间
+	UpdatedAt       int64             `json:"updated_at"` // 最后更新时间
+}
+
+// IsRunning reports whether the rebuild is still queued or in progress
+func (p *KBKeywordIndexProgress) IsRunning() bool {
+	return p.Status == KBCloneStatusPending || p.Status == KBCloneStatusProcessing
+}
+
 // KnowledgeListDeletePayload represents the batch knowledge delete task payload
 type KnowledgeListDeletePayload struct {
 	TenantID     uint64   `json:"tenant_id"`
@@ -135,6 +175,8 @@ type ImageMultimodalPayload struct {
 	ImageLocalPath  string `json:"image_local_path"` // deprecated: kept for backward compat with in-flight tasks
 	EnableOCR       bool   `json:"enable_ocr"`
 	EnableCaption   bool   `json:"enable_caption"`
+	// Languages of the image text, passed to the VLM as a hint
+	OCRLanguages []string `json:"ocr_languages,omitempty"`
 }
 
 // KBCloneTaskStatus represents the status of a knowledge base clone task
@@ -145,8 +187,17 @@ const (
 	KBCloneStatusProcessing KBCloneTaskStatus = "processing"
 	KBCloneStatusCompleted  KBCloneTaskStatus = "completed"
 	KBCloneStatusFailed     KBCloneTaskStatus = "failed"
+	// KBCloneStatusCompletedWithErrors the task finished but some knowledge could not be cloned
+	KBCloneStatusCompletedWithErrors KBCloneTaskStatus = "completed_with_errors"
 )
 
+// KBCloneFailedItem is a source knowledge that could not be cloned
+type KBCloneFailedItem struct {
+	KnowledgeID string `json:"knowledge_id"`
+	Title       string `json:"title"`
+	Error       string `json:"error"`
+}
+
 // KBCloneProgress represents the progress of a knowledge base clone task
 type KBCloneProgress struct {
 	TaskID    string            `json:"task_id"`
@@ -160,6 +211,8 @@ type KBCloneProgress struct {
 	Error     string            `json:"error"`      // 错误信息
 	CreatedAt int64             `json:"created_at"` // 任务创建时间
 	UpdatedAt int64             `json:"updated_at"` // 最后更新时间
+	// FailedItems 复制失败的知识，可通过重试接口仅重新复制这些知识
+	FailedItems []KBCloneFailedItem `json:"failed_items,omitempty"`
 }
 
 // ChunkContext represents chunk content with surrounding context
@@ -0,0 +1,23 @@
+package types
+
+import "time"
+
+// Generation modes reported by ActiveGeneration
+const (
+	GenerationModeKnowledgeQA = "knowledge_qa"
+	GenerationModeAgentQA     = "agent_qa"
+)
+
+// ActiveGeneration describes an answer that is currently being streamed
+type ActiveGeneration struct {
+	// SessionID is the session the answer belongs to
+	SessionID string `json:"session_id"`
+	// MessageID is the assistant message being generated
+	MessageID string `json:"message_id"`
+	// TenantID is the tenant owning the session
+	TenantID uint64 `json:"tenant_id"`
+	// Mode is knowledge_qa or agent_qa
+	Mode string `json:"mode"`
+	// StartedAt is when the generation started
+	StartedAt time.Time `json:"started_at"`
+}
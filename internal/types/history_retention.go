@@ -0,0 +1,47 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+)
+
+// Upper bounds accepted for HistoryRetentionConfig values
+const (
+	MaxHistoryRetentionMessages = 100000
+	MaxHistoryRetentionDays     = 3650
+)
+
+// HistoryRetentionConfig limits how much conversation history is stored per session.
+// Messages beyond the limits are trimmed oldest-first by the background pruner or via
+// POST /sessions/{id}/prune. The context-manager summary is kept, so trimmed turns are
+// still represented in the LLM context.
+//
+// Stored as a JSONB column on the tenants table, managed via /tenants/kv/history-retention-config.
+type HistoryRetentionConfig struct {
+	// MaxMessages is the maximum number of messages kept per session (0 = unlimited)
+	MaxMessages int `json:"max_messages"`
+	// MaxAgeDays is the maximum age of a message in days (0 = unlimited)
+	MaxAgeDays int `json:"max_age_days"`
+}
+
+// IsEnabled reports whether any retention limit is configured
+func (c *HistoryRetentionConfig) IsEnabled() bool {
+	return c != nil && (c.MaxMessages > 0 || c.MaxAgeDays > 0)
+}
+
+// Value implements the driver.Valuer interface for database serialization
+func (c HistoryRetentionConfig) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+// Scan implements the sql.Scanner interface for database deserialization
+func (c *HistoryRetentionConfig) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, c)
+}
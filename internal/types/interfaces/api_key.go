@@ -0,0 +1,40 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// APIKeyService defines the scoped API key service interface
+type APIKeyService interface {
+	// CreateAPIKey creates a key of the tenant in context limited to key.KnowledgeBaseIDs and key.Permission;
+	// the plaintext key is only returned here
+	CreateAPIKey(ctx context.Context, key *types.APIKey) (*types.CreatedAPIKey, error)
+	// ListAPIKeys lists the scoped API keys of the tenant in context
+	ListAPIKeys(ctx context.Context) ([]*types.APIKey, error)
+	// DeleteAPIKey revokes a scoped API key of the tenant in context
+	DeleteAPIKey(ctx context.Context, id string) error
+	// Authenticate returns the scoped API key matching a raw key
+	Authenticate(ctx context.Context, rawKey string) (*types.APIKey, error)
+	// ConsumeUpload counts an upload against the rate limit and daily quota of a key,
+	// returning an error when either is exceeded
+	ConsumeUpload(ctx context.Context, key *types.APIKey) error
+}
+
+// APIKeyRepository defines the scoped API key repository interface
+type APIKeyRepository interface {
+	// Create creates an API key record
+	Create(ctx context.Context, key *types.APIKey) error
+	// GetByID retrieves an API key by ID and tenant
+	GetByID(ctx context.Context, tenantID uint64, id string) (*types.APIKey, error)
+	// GetByHash retrieves an API key by the hash of its key
+	GetByHash(ctx context.Context, keyHash string) (*types.APIKey, error)
+	// List lists all API keys of a tenant
+	List(ctx context.Context, tenantID uint64) ([]*types.APIKey, error)
+	// UpdateLastUsedAt records when an API key was last used
+	UpdateLastUsedAt(ctx context.Context, id string, usedAt time.Time) error
+	// Delete deletes an API key record
+	Delete(ctx context.Context, tenantID uint64, id string) error
+}
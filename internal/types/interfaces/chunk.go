@@ -65,6 +65,13 @@ type ChunkRepository interface {
 	DeleteChunksByTagID(ctx context.Context, tenantID uint64, kbID string, tagID string, excludeIDs []string) ([]string, error)
 	// CountChunksByKnowledgeBaseID counts the number of chunks in a knowledge base.
 	CountChunksByKnowledgeBaseID(ctx context.Context, tenantID uint64, kbID string) (int64, error)
+	// CountChunksByKnowledgeBaseIDs counts the chunks of several knowledge bases in one query,
+	// keyed by knowledge base ID; knowledge bases without chunks are absent from the map.
+	CountChunksByKnowledgeBaseIDs(ctx context.Context, kbIDs []string) (map[string]int64, error)
+	// AggregateChunksByKnowledgeBaseID returns the chunk count, average chunk size and the embedding coverage counts
+	// of a knowledge base. A non-nil knowledgeIDs limits the aggregation to the chunks of those knowledge items.
+	AggregateChunksByKnowledgeBaseID(ctx context.Context,
+		tenantID uint64, kbID string, knowledgeIDs []string) (*types.ChunkAggregates, error)
 	// DeleteUnindexedChunks deletes unindexed chunks by knowledge id and chunk index range
 	DeleteUnindexedChunks(ctx context.Context, tenantID uint64, knowledgeID string) ([]*types.Chunk, error)
 	// ListAllFAQChunksByKnowledgeID lists all FAQ chunks for a knowledge ID
@@ -83,6 +90,9 @@ type ChunkRepository interface {
 	// Supports updating is_enabled, flags, and tag_id fields.
 	// newTagID: if not nil, updates tag_id to this value (empty string means uncategorized)
 	UpdateChunkFieldsByTagID(ctx context.Context, tenantID uint64, kbID string, tagID string, isEnabled *bool, setFlags types.ChunkFlags, clearFlags types.ChunkFlags, newTagID *string, excludeIDs []string) ([]string, error)
+	// ReassignTagID moves all chunks of a knowledge base from one tag to another (empty means uncategorized)
+	// and returns the IDs of the moved chunks.
+	ReassignTagID(ctx context.Context, tenantID uint64, kbID string, fromTagID string, toTagID string) ([]string, error)
 	// FAQChunkDiff compares FAQ chunks between two knowledge bases and returns the differences.
 	// Returns: chunksToAdd (content_hash in src but not in dst), chunksToDelete (content_hash in dst but not in src)
 	FAQChunkDiff(ctx context.Context, srcTenantID uint64, srcKBID string, dstTenantID uint64, dstKBID string) (chunksToAdd []string, chunksToDelete []string, err error)
@@ -105,12 +115,21 @@ type ChunkService interface {
 		page *types.Pagination,
 		chunkType []types.ChunkType,
 	) (*types.PageResult, error)
+	// ListChunksByKnowledge lists the retrievable chunks of a knowledge item with pagination,
+	// for inspecting how a document was chunked and embedded
+	ListChunksByKnowledge(ctx context.Context, knowledgeID string, page *types.Pagination) (*types.PageResult, error)
 	// UpdateChunk updates a chunk
 	UpdateChunk(ctx context.Context, chunk *types.Chunk) error
+	// UpdateChunkText replaces a document chunk's text and re-embeds that chunk with the knowledge base's
+	// embedding model. FAQ chunks are rejected; their question/answer fields are edited via FAQ entries.
+	UpdateChunkText(ctx context.Context, knowledgeID string, chunkID string, text string) (*types.Chunk, error)
 	// UpdateChunks updates chunks in batch
 	UpdateChunks(ctx context.Context, chunks []*types.Chunk) error
 	// DeleteChunk deletes a chunk
 	DeleteChunk(ctx context.Context, id string) error
+	// DeleteKnowledgeChunk deletes a document chunk of a knowledge item together with its embeddings
+	// and image children. The knowledge item stays listed even when its last chunk is removed.
+	DeleteKnowledgeChunk(ctx context.Context, knowledgeID string, chunkID string) error
 	// DeleteChunks deletes chunks by IDs in batch
 	DeleteChunks(ctx context.Context, ids []string) error
 	// DeleteChunksByKnowledgeID deletes chunks by knowledge id
@@ -0,0 +1,18 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// ConfigBundleService exports and imports the configuration of a tenant for migration between deployments
+type ConfigBundleService interface {
+	// ExportConfig returns the knowledge base and agent settings of the tenant in context, and the
+	// organization memberships of the calling user. Documents and credentials are not included.
+	ExportConfig(ctx context.Context) (*types.TenantConfigBundle, error)
+	// ImportConfig creates the knowledge bases and agents of a bundle in the tenant in context.
+	// Model, knowledge base, MCP service and prompt template references are re-resolved; those that
+	// cannot be resolved are dropped (or the object is skipped when the reference is required) and reported.
+	ImportConfig(ctx context.Context, bundle *types.TenantConfigBundle) (*types.TenantConfigImportResult, error)
+}
@@ -64,6 +64,55 @@ type CustomAgentService interface {
 	//   - The newly created agent copy
 	//   - Possible errors such as not existing, insufficient permissions, etc.
 	CopyAgent(ctx context.Context, id string) (*types.CustomAgent, error)
+
+	// CloneAgent creates a new agent in the current tenant from an owned or shared agent
+	// Parameters:
+	//   - ctx: Context information
+	//   - id: Unique identifier of the agent to clone
+	//   - newName: Name of the new agent; empty uses the source name with a copy suffix
+	// Returns:
+	//   - The new agent and warnings about knowledge bases or models it cannot access
+	//   - Possible errors such as not existing, insufficient permissions, etc.
+	CloneAgent(ctx context.Context, id string, newName string) (*types.AgentCloneResult, error)
+
+	// PreviewResolvedKBs returns the knowledge bases the agent would search for a request without @ mentions
+	// Parameters:
+	//   - ctx: Context information
+	//   - id: Unique identifier of the agent
+	// Returns:
+	//   - The selection mode and resolved knowledge bases with their names
+	//   - Possible errors such as not existing, insufficient permissions, etc.
+	PreviewResolvedKBs(ctx context.Context, id string) (*types.AgentResolvedKBs, error)
+
+	// ResetAgentConfig resets an agent's configuration to defaults
+	// Parameters:
+	//   - ctx: Context information
+	//   - id: Unique identifier of the agent
+	// Returns:
+	//   - The agent with its reset configuration; name, avatar, mode and knowledge base selection are kept
+	//   - Possible errors such as not existing, insufficient permissions, etc.
+	ResetAgentConfig(ctx context.Context, id string) (*types.CustomAgent, error)
+
+	// EvaluateAgent runs each question through the agent in temporary sessions that are deleted afterwards
+	// Parameters:
+	//   - ctx: Context information
+	//   - id: Unique identifier of the agent to evaluate
+	//   - questions: Questions to ask, at most types.MaxEvaluationQuestions
+	// Returns:
+	//   - One result per question, in the same order as the input
+	//   - Possible errors such as agent not existing, too many questions, etc.
+	EvaluateAgent(ctx context.Context, id string, questions []string) ([]*types.AgentEvaluationResult, error)
+
+	// CompareAgents runs the same query through two agents side by side
+	// Parameters:
+	//   - ctx: Context information
+	//   - idA: Unique identifier of the first agent
+	//   - idB: Unique identifier of the second agent
+	//   - query: Question asked to both agents
+	// Returns:
+	//   - Both answers, references and timings
+	//   - Possible errors such as either agent not existing, empty query, etc.
+	CompareAgents(ctx context.Context, idA, idB string, query string) (*types.AgentComparisonResult, error)
 }
 
 // CustomAgentRepository defines the custom agent repository interface
@@ -13,6 +13,7 @@ import (
 type KnowledgeService interface {
 	// CreateKnowledgeFromFile creates knowledge from a file.
 	// tagID is optional - when provided, the file will be assigned to the specified tag/category.
+	// ocr optionally overrides the OCR settings of the knowledge base for this file.
 	CreateKnowledgeFromFile(
 		ctx context.Context,
 		kbID string,
@@ -21,7 +22,11 @@ type KnowledgeService interface {
 		enableMultimodel *bool,
 		customFileName string,
 		tagID string,
+		ocr *types.OCRSettings,
 	) (*types.Knowledge, error)
+	// ValidateFileUpload checks a file's name, size and content type against the rules of
+	// CreateKnowledgeFromFile, without uploading it.
+	ValidateFileUpload(ctx context.Context, kbID string, req *types.FileUploadCheckRequest) (*types.FileUploadCheckResult, error)
 	// CreateKnowledgeFromURL creates knowledge from a URL.
 	// When fileName or fileType is provided (or the URL path has a known file extension),
 	// the URL is treated as a direct file download instead of a web page crawl.
@@ -56,10 +61,14 @@ type KnowledgeService interface {
 	GetKnowledgeBatchWithSharedAccess(ctx context.Context, tenantID uint64, ids []string) ([]*types.Knowledge, error)
 	// ListKnowledgeByKnowledgeBaseID lists all knowledge under a knowledge base.
 	ListKnowledgeByKnowledgeBaseID(ctx context.Context, kbID string) ([]*types.Knowledge, error)
+	// GetKnowledgeByFilename returns the knowledge under a knowledge base uploaded with the given
+	// original file name, most recent first. Several documents can share a name.
+	GetKnowledgeByFilename(ctx context.Context, kbID string, filename string) ([]*types.Knowledge, error)
 	// ListPagedKnowledgeByKnowledgeBaseID lists all knowledge under a knowledge base with pagination.
 	// When tagID is non-empty, results are filtered by tag_id.
 	// When keyword is non-empty, results are filtered by file_name.
 	// When fileType is non-empty, results are filtered by file_type or type.
+	// When knowledgeIDs is non-nil (partial shares), results are limited to those IDs.
 	ListPagedKnowledgeByKnowledgeBaseID(
 		ctx context.Context,
 		kbID string,
@@ -67,7 +76,11 @@ type KnowledgeService interface {
 		tagID string,
 		keyword string,
 		fileType string,
+		knowledgeIDs []string,
 	) (*types.PageResult, error)
+	// ListAllKnowledge lists the knowledge in all document knowledge bases of the tenant with pagination,
+	// newest first, each with its knowledge base name.
+	ListAllKnowledge(ctx context.Context, page *types.Pagination, filter *types.KnowledgeListFilter) (*types.PageResult, error)
 	// DeleteKnowledge deletes knowledge by ID.
 	DeleteKnowledge(ctx context.Context, id string) error
 	// DeleteKnowledgeList deletes multiple knowledge entries by IDs.
@@ -84,6 +97,8 @@ type KnowledgeService interface {
 	) (*types.Knowledge, error)
 	// ReparseKnowledge deletes existing document content and re-parses the knowledge asynchronously.
 	ReparseKnowledge(ctx context.Context, knowledgeID string) (*types.Knowledge, error)
+	// RetryProcessing re-runs the processing of a knowledge item in the failed state, reusing its stored original file.
+	RetryProcessing(ctx context.Context, knowledgeID string) (*types.Knowledge, error)
 	// CloneKnowledgeBase clones knowledge to another knowledge base.
 	CloneKnowledgeBase(ctx context.Context, srcID, dstID string) error
 	// UpdateImageInfo updates image information for a knowledge chunk.
@@ -143,6 +158,8 @@ type KnowledgeService interface {
 	ProcessKnowledgeMove(ctx context.Context, t *asynq.Task) error
 	// ProcessKnowledgeListDelete handles Asynq knowledge list delete tasks
 	ProcessKnowledgeListDelete(ctx context.Context, t *asynq.Task) error
+	// ProcessKBReindex handles Asynq knowledge base reindex tasks
+	ProcessKBReindex(ctx context.Context, t *asynq.Task) error
 	// GetKBCloneProgress retrieves the progress of a knowledge base clone task
 	GetKBCloneProgress(ctx context.Context, taskID string) (*types.KBCloneProgress, error)
 	// SaveKBCloneProgress saves the progress of a knowledge base clone task
@@ -160,6 +177,10 @@ type KnowledgeService interface {
 	SearchKnowledge(ctx context.Context, keyword string, offset, limit int, fileTypes []string) ([]*types.Knowledge, bool, error)
 	// SearchKnowledgeForScopes searches knowledge within the given (tenant_id, kb_id) scopes (e.g. for shared agent context).
 	SearchKnowledgeForScopes(ctx context.Context, scopes []types.KnowledgeSearchScope, keyword string, offset, limit int, fileTypes []string) ([]*types.Knowledge, bool, error)
+	// ResolveRetrievalScope returns the IDs of knowledge in a knowledge base matching the retrieval filters.
+	// Metadata filters are ignored when no knowledge in the base carries any of the filter keys;
+	// applied is false when nothing is left to filter on, in which case callers should search unfiltered.
+	ResolveRetrievalScope(ctx context.Context, tenantID uint64, kbID string, filters *types.RetrievalFilters) (ids []string, applied bool, err error)
 }
 
 // KnowledgeRepository defines the interface for knowledge repositories.
@@ -169,12 +190,21 @@ type KnowledgeRepository interface {
 	// GetKnowledgeByIDOnly returns knowledge by ID without tenant filter (for permission resolution).
 	GetKnowledgeByIDOnly(ctx context.Context, id string) (*types.Knowledge, error)
 	ListKnowledgeByKnowledgeBaseID(ctx context.Context, tenantID uint64, kbID string) ([]*types.Knowledge, error)
+	// ListKnowledgeByFileName lists the knowledge in a knowledge base with the given file name, most recent first.
+	ListKnowledgeByFileName(ctx context.Context, tenantID uint64, kbID string, fileName string) ([]*types.Knowledge, error)
 	// ListPagedKnowledgeByKnowledgeBaseID lists all knowledge in a knowledge base with pagination.
 	// When tagID is non-empty, results are filtered by tag_id.
 	// When keyword is non-empty, results are filtered by file_name.
 	// When fileType is non-empty, results are filtered by file_type or type.
+	// When knowledgeIDs is non-nil, results are limited to those IDs.
 	ListPagedKnowledgeByKnowledgeBaseID(ctx context.Context,
 		tenantID uint64, kbID string, page *types.Pagination, tagID string, keyword string, fileType string,
+		knowledgeIDs []string,
+	) ([]*types.Knowledge, int64, error)
+	// ListPagedKnowledgeByTenant lists the knowledge in all document knowledge bases of a tenant with
+	// pagination in a single query, filling KnowledgeBaseName.
+	ListPagedKnowledgeByTenant(ctx context.Context,
+		tenantID uint64, page *types.Pagination, filter *types.KnowledgeListFilter,
 	) ([]*types.Knowledge, int64, error)
 	UpdateKnowledge(ctx context.Context, knowledge *types.Knowledge) error
 	// UpdateKnowledgeBatch updates knowledge items in batch
@@ -195,10 +225,24 @@ type KnowledgeRepository interface {
 	// AminusB returns the difference set of A and B.
 	AminusB(ctx context.Context, Atenant uint64, A string, Btenant uint64, B string) ([]string, error)
 	UpdateKnowledgeColumn(ctx context.Context, id string, column string, value interface{}) error
+	// ReassignTagID moves all knowledge of a knowledge base from one tag to another (empty means untagged)
+	// and returns the number of moved items.
+	ReassignTagID(ctx context.Context, tenantID uint64, kbID string, fromTagID string, toTagID string) (int64, error)
 	// CountKnowledgeByKnowledgeBaseID counts the number of knowledge items in a knowledge base.
 	CountKnowledgeByKnowledgeBaseID(ctx context.Context, tenantID uint64, kbID string) (int64, error)
+	// CountKnowledgeByKnowledgeBaseIDs counts the knowledge items of several knowledge bases in one query,
+	// keyed by knowledge base ID; knowledge bases without items are absent from the map.
+	CountKnowledgeByKnowledgeBaseIDs(ctx context.Context, kbIDs []string) (map[string]int64, error)
+	// SumKnowledgeSize sums the file and index sizes of the knowledge in the knowledge bases plus the
+	// listed knowledge items, in one query.
+	SumKnowledgeSize(ctx context.Context, kbIDs []string, knowledgeIDs []string) (int64, error)
 	// CountKnowledgeByStatus counts the number of knowledge items with the specified parse status.
 	CountKnowledgeByStatus(ctx context.Context, tenantID uint64, kbID string, parseStatuses []string) (int64, error)
+	// AggregateKnowledgeByKnowledgeBaseID returns the count, file type and parse status breakdown, total file
+	// size and latest update of the knowledge items in a knowledge base. A non-nil knowledgeIDs limits the
+	// aggregation to those items.
+	AggregateKnowledgeByKnowledgeBaseID(ctx context.Context,
+		tenantID uint64, kbID string, knowledgeIDs []string) (*types.KnowledgeAggregates, error)
 	// SearchKnowledge searches knowledge items by keyword across the tenant.
 	// fileTypes: optional list of file extensions to filter by (e.g., ["csv", "xlsx"])
 	SearchKnowledge(ctx context.Context, tenantID uint64, keyword string, offset, limit int, fileTypes []string) ([]*types.Knowledge, bool, error)
@@ -206,4 +250,8 @@ type KnowledgeRepository interface {
 	SearchKnowledgeInScopes(ctx context.Context, scopes []types.KnowledgeSearchScope, keyword string, offset, limit int, fileTypes []string) ([]*types.Knowledge, bool, error)
 	// ListIDsByTagID returns all knowledge IDs that have the specified tag ID.
 	ListIDsByTagID(ctx context.Context, tenantID uint64, kbID, tagID string) ([]string, error)
+	// ListIDsByRetrievalFilters returns all knowledge IDs in a knowledge base matching the metadata and creation date filters.
+	ListIDsByRetrievalFilters(ctx context.Context, tenantID uint64, kbID string, filters *types.RetrievalFilters) ([]string, error)
+	// HasMetadataKeys reports whether any knowledge in a knowledge base has metadata for at least one of the keys.
+	HasMetadataKeys(ctx context.Context, tenantID uint64, kbID string, keys []string) (bool, error)
 }
@@ -47,6 +47,9 @@ type KnowledgeBaseService interface {
 
 	// FillKnowledgeBaseCounts fills KnowledgeCount, ChunkCount, IsProcessing, ProcessingCount for the given KB (uses kb.TenantID).
 	FillKnowledgeBaseCounts(ctx context.Context, kb *types.KnowledgeBase) error
+	// GetKnowledgeBaseStats returns aggregate statistics of a knowledge base, computed in the KB owner's tenant.
+	// A non-nil knowledgeIDs limits the statistics to those knowledge items (partial shares).
+	GetKnowledgeBaseStats(ctx context.Context, kbID string, knowledgeIDs []string) (*types.KnowledgeBaseStats, error)
 
 	// ListKnowledgeBases lists all knowledge bases under the current tenant
 	// Parameters:
@@ -83,6 +86,10 @@ type KnowledgeBaseService interface {
 	// TogglePinKnowledgeBase toggles the pin status of a knowledge base
 	TogglePinKnowledgeBase(ctx context.Context, id string) (*types.KnowledgeBase, error)
 
+	// SetShareLabel sets the label matched by dynamic share rules on a knowledge base of the
+	// current tenant; an empty label clears it
+	SetShareLabel(ctx context.Context, id string, label string) (*types.KnowledgeBase, error)
+
 	// HybridSearch performs hybrid search (vector + keywords) in the knowledge base
 	// Parameters:
 	//   - ctx: Context information
@@ -118,6 +125,33 @@ type KnowledgeBaseService interface {
 	// Returns:
 	//   - Possible errors during deletion
 	ProcessKBDelete(ctx context.Context, t *asynq.Task) error
+
+	// RebuildKeywordIndex queues a rebuild of the keyword index of a knowledge base's chunks,
+	// without re-embedding them
+	// Parameters:
+	//   - ctx: Context information
+	//   - id: Knowledge base ID
+	// Returns:
+	//   - Initial progress of the rebuild
+	//   - Possible errors such as not existing or a rebuild already running
+	RebuildKeywordIndex(ctx context.Context, id string) (*types.KBKeywordIndexProgress, error)
+
+	// GetKeywordIndexProgress gets the progress of the latest keyword index rebuild of a knowledge base
+	// Parameters:
+	//   - ctx: Context information
+	//   - id: Knowledge base ID
+	// Returns:
+	//   - Rebuild progress
+	//   - Possible errors such as no rebuild found
+	GetKeywordIndexProgress(ctx context.Context, id string) (*types.KBKeywordIndexProgress, error)
+
+	// ProcessKeywordIndexRebuild handles async keyword index rebuild task
+	// Parameters:
+	//   - ctx: Context information
+	//   - t: Asynq task containing KBKeywordIndexPayload
+	// Returns:
+	//   - Possible errors during the rebuild
+	ProcessKeywordIndexRebuild(ctx context.Context, t *asynq.Task) error
 }
 
 // KnowledgeBaseRepository defines the knowledge base repository interface
@@ -196,4 +230,7 @@ type KnowledgeBaseRepository interface {
 
 	// TogglePinKnowledgeBase toggles the pin status of a knowledge base
 	TogglePinKnowledgeBase(ctx context.Context, id string, tenantID uint64) (*types.KnowledgeBase, error)
+
+	// SetShareLabel sets the share label of a knowledge base of the tenant
+	SetShareLabel(ctx context.Context, id string, tenantID uint64, label string) (*types.KnowledgeBase, error)
 }
@@ -32,6 +32,12 @@ type MessageService interface {
 	// DeleteMessage deletes a message
 	DeleteMessage(ctx context.Context, sessionID string, id string) error
 
+	// SearchSessionMessages finds the messages of a session containing the keyword (case-insensitive),
+	// oldest first, each with a snippet around the first match
+	SearchSessionMessages(
+		ctx context.Context, sessionID string, keyword string, limit int,
+	) ([]*types.SessionMessageMatch, error)
+
 	// SearchMessages searches messages by keyword and/or vector similarity across all sessions of the current tenant.
 	// Uses the chat history knowledge base for vector search instead of in-memory computation.
 	SearchMessages(ctx context.Context, params *types.MessageSearchParams) (*types.MessageSearchResult, error)
@@ -70,6 +76,12 @@ type MessageRepository interface {
 	DeleteMessage(ctx context.Context, sessionID string, id string) error
 	// GetFirstMessageOfUser gets the first message of a user
 	GetFirstMessageOfUser(ctx context.Context, sessionID string) (*types.Message, error)
+	// ListMessageMetaBySession lists all messages of a session (without content), oldest first
+	ListMessageMetaBySession(ctx context.Context, sessionID string) ([]*types.Message, error)
+	// DeleteMessagesByIDs deletes the given messages of a session
+	DeleteMessagesByIDs(ctx context.Context, sessionID string, ids []string) error
+	// SearchMessages lists the messages of a session whose content contains the keyword (case-insensitive), oldest first
+	SearchMessages(ctx context.Context, sessionID string, keyword string, limit int) ([]*types.Message, error)
 	// SearchMessagesByKeyword searches messages by keyword (ILIKE) across sessions for a tenant
 	SearchMessagesByKeyword(ctx context.Context, tenantID uint64, keyword string, sessionIDs []string, limit int) ([]*types.MessageWithSession, error)
 	// GetMessagesByKnowledgeIDs retrieves messages by their associated Knowledge IDs
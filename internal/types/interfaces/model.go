@@ -18,6 +18,8 @@ type ModelService interface {
 	GetModelByID(ctx context.Context, id string) (*types.Model, error)
 	// ListModels lists all models
 	ListModels(ctx context.Context) ([]*types.Model, error)
+	// ListModelsGrouped lists all models grouped into chat, embedding, rerank and VLM buckets
+	ListModelsGrouped(ctx context.Context) (*types.ModelCatalog, error)
 	// UpdateModel updates a model
 	UpdateModel(ctx context.Context, model *types.Model) error
 	// DeleteModel deletes a model
@@ -32,6 +34,10 @@ type ModelService interface {
 	GetChatModel(ctx context.Context, modelId string) (chat.Chat, error)
 	// GetVLMModel gets a vision language model
 	GetVLMModel(ctx context.Context, modelId string) (vlm.VLM, error)
+	// WarmupModel sends a minimal request to a model so its connection and weights are loaded
+	WarmupModel(ctx context.Context, id string) (*types.ModelWarmupResult, error)
+	// WarmupModels warms up all active models of the tenant
+	WarmupModels(ctx context.Context) ([]*types.ModelWarmupResult, error)
 }
 
 // ModelRepository defines the model repository interface
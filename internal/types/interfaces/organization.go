@@ -2,6 +2,7 @@ package interfaces
 
 import (
 	"context"
+	"io"
 	"time"
 
 	"github.com/Tencent/WeKnora/internal/types"
@@ -16,13 +17,41 @@ type OrganizationService interface {
 	ListUserOrganizations(ctx context.Context, userID string) ([]*types.Organization, error)
 	UpdateOrganization(ctx context.Context, id string, userID string, req *types.UpdateOrganizationRequest) (*types.Organization, error)
 	DeleteOrganization(ctx context.Context, id string, userID string) error
+	// CountOwnedOrganizations returns how many organizations the user owns
+	CountOwnedOrganizations(ctx context.Context, userID string) (int64, error)
+	// GetMaxOwnedOrganizations returns how many organizations a user can own, 0 if unlimited
+	GetMaxOwnedOrganizations() int
 
 	// Member Management
 	AddMember(ctx context.Context, orgID string, userID string, tenantID uint64, role types.OrgMemberRole) error
 	RemoveMember(ctx context.Context, orgID string, memberUserID string, operatorUserID string) error
 	UpdateMemberRole(ctx context.Context, orgID string, memberUserID string, role types.OrgMemberRole, operatorUserID string) error
 	ListMembers(ctx context.Context, orgID string) ([]*types.OrganizationMember, error)
+	// SearchMembers finds members whose username or email contains query, case-insensitively
+	SearchMembers(ctx context.Context, orgID string, query string, limit int) ([]*types.OrganizationMember, error)
 	GetMember(ctx context.Context, orgID string, userID string) (*types.OrganizationMember, error)
+	// SuspendMember revokes a member's access while keeping the membership and role (admin only)
+	SuspendMember(ctx context.Context, orgID string, memberUserID string, operatorUserID string) error
+	// UnsuspendMember restores the access of a suspended member (admin only)
+	UnsuspendMember(ctx context.Context, orgID string, memberUserID string, operatorUserID string) error
+	// BulkInviteMembers invites users by email (admin only); emails without an account get a
+	// pending invite that the user who signs up with the email can accept
+	BulkInviteMembers(ctx context.Context, orgID string, operatorUserID string, emails []string, role types.OrgMemberRole) ([]*types.BulkInviteResult, error)
+	// NotifyPendingInvites notifies a newly registered user of the unexpired invites to their email
+	NotifyPendingInvites(ctx context.Context, user *types.User) error
+	// AcceptPendingInvite adds the user to the organization of an invite sent to their email
+	AcceptPendingInvite(ctx context.Context, inviteID string, user *types.User) (*types.OrganizationMember, error)
+	// DeclinePendingInvite deletes an invite sent to the user's email
+	DeclinePendingInvite(ctx context.Context, inviteID string, user *types.User) error
+	// DeleteExpiredPendingInvites deletes the pending invites that can no longer be accepted
+	DeleteExpiredPendingInvites(ctx context.Context) error
+
+	// Avatar
+	// UploadAvatar stores an avatar image for the organization (admin only)
+	UploadAvatar(ctx context.Context, orgID string, userID string, tenantID uint64, data []byte) (*types.Organization, error)
+	// GetAvatar opens the uploaded avatar of the organization and returns its storage path.
+	// Non-members can only read the avatar of a searchable organization.
+	GetAvatar(ctx context.Context, orgID string, userID string) (io.ReadCloser, string, error)
 
 	// Invite Code
 	GenerateInviteCode(ctx context.Context, orgID string, userID string) (string, error)
@@ -34,12 +63,18 @@ type OrganizationService interface {
 	// Join Requests (for organizations that require approval)
 	SubmitJoinRequest(ctx context.Context, orgID string, userID string, tenantID uint64, message string, requestedRole types.OrgMemberRole) (*types.OrganizationJoinRequest, error)
 	ListJoinRequests(ctx context.Context, orgID string) ([]*types.OrganizationJoinRequest, error)
+	// ListUpgradeRequests lists the pending role upgrade requests of an organization
+	ListUpgradeRequests(ctx context.Context, orgID string) ([]*types.OrganizationJoinRequest, error)
 	CountPendingJoinRequests(ctx context.Context, orgID string) (int64, error)
 	ReviewJoinRequest(ctx context.Context, orgID string, requestID string, approved bool, reviewerID string, message string, assignRole *types.OrgMemberRole) error
 
 	// Role Upgrade Requests (for existing members to request higher permissions)
 	RequestRoleUpgrade(ctx context.Context, orgID string, userID string, tenantID uint64, requestedRole types.OrgMemberRole, message string) (*types.OrganizationJoinRequest, error)
 	GetPendingUpgradeRequest(ctx context.Context, orgID string, userID string) (*types.OrganizationJoinRequest, error)
+	// CancelRequest withdraws a pending join or upgrade request submitted by userID
+	CancelRequest(ctx context.Context, requestID string, userID string) error
+	// ExpireStaleJoinRequests marks the pending requests older than their organization's TTL as expired
+	ExpireStaleJoinRequests(ctx context.Context) error
 
 	// Permission Check
 	IsOrgAdmin(ctx context.Context, orgID string, userID string) (bool, error)
@@ -53,6 +88,7 @@ type OrganizationRepository interface {
 	GetByID(ctx context.Context, id string) (*types.Organization, error)
 	GetByInviteCode(ctx context.Context, inviteCode string) (*types.Organization, error)
 	ListByUserID(ctx context.Context, userID string) ([]*types.Organization, error)
+	CountByOwnerID(ctx context.Context, ownerID string) (int64, error)
 	ListSearchable(ctx context.Context, query string, limit int) ([]*types.Organization, error)
 	Update(ctx context.Context, org *types.Organization) error
 	Delete(ctx context.Context, id string) error
@@ -62,12 +98,17 @@ type OrganizationRepository interface {
 	RemoveMember(ctx context.Context, orgID string, userID string) error
 	UpdateMemberRole(ctx context.Context, orgID string, userID string, role types.OrgMemberRole) error
 	ListMembers(ctx context.Context, orgID string) ([]*types.OrganizationMember, error)
+	SearchMembers(ctx context.Context, orgID string, query string, limit int) ([]*types.OrganizationMember, error)
 	GetMember(ctx context.Context, orgID string, userID string) (*types.OrganizationMember, error)
+	GetMemberIncludingSuspended(ctx context.Context, orgID string, userID string) (*types.OrganizationMember, error)
+	SetMemberSuspended(ctx context.Context, orgID string, userID string, suspended bool) error
 	ListMembersByUserForOrgs(ctx context.Context, userID string, orgIDs []string) (map[string]*types.OrganizationMember, error)
 	CountMembers(ctx context.Context, orgID string) (int64, error)
+	CountMembersByOrganizations(ctx context.Context, orgIDs []string) (map[string]int64, error)
 
 	// Invite code
 	UpdateInviteCode(ctx context.Context, orgID string, inviteCode string, expiresAt *time.Time) error
+	InviteCodeExists(ctx context.Context, inviteCode string) (bool, error)
 
 	// Join requests
 	CreateJoinRequest(ctx context.Context, request *types.OrganizationJoinRequest) error
@@ -75,14 +116,28 @@ type OrganizationRepository interface {
 	GetPendingJoinRequest(ctx context.Context, orgID string, userID string) (*types.OrganizationJoinRequest, error)
 	GetPendingRequestByType(ctx context.Context, orgID string, userID string, requestType types.JoinRequestType) (*types.OrganizationJoinRequest, error)
 	ListJoinRequests(ctx context.Context, orgID string, status types.JoinRequestStatus) ([]*types.OrganizationJoinRequest, error)
+	ListPendingJoinRequestsByUser(ctx context.Context, userID string, orgIDs []string) ([]*types.OrganizationJoinRequest, error)
+	ListJoinRequestsByType(ctx context.Context, orgID string, requestType types.JoinRequestType, status types.JoinRequestStatus) ([]*types.OrganizationJoinRequest, error)
 	CountJoinRequests(ctx context.Context, orgID string, status types.JoinRequestStatus) (int64, error)
+	CountPendingJoinRequestsSince(ctx context.Context, orgID string, createdAfter *time.Time) (int64, error)
+	ListOrganizationIDsWithPendingJoinRequests(ctx context.Context) ([]string, error)
+	ExpireJoinRequests(ctx context.Context, orgID string, createdBefore time.Time) (int64, error)
 	UpdateJoinRequestStatus(ctx context.Context, id string, status types.JoinRequestStatus, reviewedBy string, reviewMessage string) error
+	CancelJoinRequest(ctx context.Context, id string) error
+
+	// Pending invites
+	UpsertPendingInvite(ctx context.Context, invite *types.OrganizationPendingInvite) error
+	GetPendingInvite(ctx context.Context, id string) (*types.OrganizationPendingInvite, error)
+	ListPendingInvitesByEmail(ctx context.Context, email string) ([]*types.OrganizationPendingInvite, error)
+	DeletePendingInvite(ctx context.Context, id string) error
+	DeleteExpiredPendingInvites(ctx context.Context, before time.Time) (int64, error)
 }
 
 // KBShareService defines the knowledge base sharing service interface
 type KBShareService interface {
 	// Share Management
-	ShareKnowledgeBase(ctx context.Context, kbID string, orgID string, userID string, tenantID uint64, permission types.OrgMemberRole) (*types.KnowledgeBaseShare, error)
+	// ShareKnowledgeBase shares a KB to an organization; a non-empty knowledgeIDs shares only those documents.
+	ShareKnowledgeBase(ctx context.Context, kbID string, orgID string, userID string, tenantID uint64, permission types.OrgMemberRole, knowledgeIDs []string) (*types.KnowledgeBaseShare, error)
 	UpdateSharePermission(ctx context.Context, shareID string, permission types.OrgMemberRole, userID string) error
 	RemoveShare(ctx context.Context, shareID string, userID string) error
 
@@ -100,6 +155,11 @@ type KBShareService interface {
 	// Permission Check
 	CheckUserKBPermission(ctx context.Context, kbID string, userID string) (types.OrgMemberRole, bool, error)
 	HasKBPermission(ctx context.Context, kbID string, userID string, requiredRole types.OrgMemberRole) (bool, error)
+	// GetSharedKnowledgeIDs returns the documents a user can access in a KB shared with them through partial shares.
+	// restricted is false when the user can access the whole KB (or the KB is not shared with them).
+	GetSharedKnowledgeIDs(ctx context.Context, kbID string, userID string) (knowledgeIDs []string, restricted bool, err error)
+	// HasKnowledgePermission is HasKBPermission for a single document, honoring partial shares.
+	HasKnowledgePermission(ctx context.Context, kbID string, knowledgeID string, userID string, requiredRole types.OrgMemberRole) (bool, error)
 
 	// Get source tenant for cross-tenant embedding
 	GetKBSourceTenant(ctx context.Context, kbID string) (uint64, error)
@@ -108,6 +168,24 @@ type KBShareService interface {
 	CountSharesByKnowledgeBaseIDs(ctx context.Context, kbIDs []string) (map[string]int64, error)
 	// CountByOrganizations returns share counts per organization (for sidebar); excludes deleted KBs
 	CountByOrganizations(ctx context.Context, orgIDs []string) (map[string]int64, error)
+
+	// Storage
+	// GetOrgStorageUsage returns the bytes used by the KBs shared to an organization (cached for a few minutes).
+	GetOrgStorageUsage(ctx context.Context, orgID string) (*types.OrgStorageUsage, error)
+	// CheckOrgStorageQuota fails with a StorageQuotaExceededError if the KB is shared to an organization
+	// whose storage quota is used up.
+	CheckOrgStorageQuota(ctx context.Context, kbID string) error
+
+	// Dynamic share rules (org admin only): share every KB of the caller's tenant whose share label
+	// matches the criteria. Matching KBs are resolved at query time by ListSharesByOrganization and
+	// the permission checks.
+	CreateDynamicShare(ctx context.Context, orgID string, criteria types.KBShareCriteria, userID string, tenantID uint64, permission types.OrgMemberRole) (*types.KnowledgeBaseDynamicShare, error)
+	ListDynamicShares(ctx context.Context, orgID string) ([]*types.KnowledgeBaseDynamicShare, error)
+	RemoveDynamicShare(ctx context.Context, orgID string, ruleID string, userID string) error
+
+	// CopyKBToUser enqueues a clone of the caller's KB into the target user's tenant instead of sharing it.
+	// The target must be a member of an organization the caller belongs to. Returns the initial task progress.
+	CopyKBToUser(ctx context.Context, kbID string, targetUserID string) (*types.KBCloneProgress, error)
 }
 
 // KBShareRepository defines the knowledge base sharing repository interface
@@ -135,6 +213,20 @@ type KBShareRepository interface {
 	// Count shares
 	CountSharesByKnowledgeBaseID(ctx context.Context, kbID string) (int64, error)
 	CountSharesByKnowledgeBaseIDs(ctx context.Context, kbIDs []string) (map[string]int64, error)
+
+	// Dynamic share rules
+	CreateDynamic(ctx context.Context, rule *types.KnowledgeBaseDynamicShare) error
+	GetDynamicByID(ctx context.Context, id string) (*types.KnowledgeBaseDynamicShare, error)
+	DeleteDynamic(ctx context.Context, id string) error
+	// DeleteDynamicByOrganizationID soft-deletes all dynamic rules for an organization (e.g. when the org is deleted)
+	DeleteDynamicByOrganizationID(ctx context.Context, orgID string) error
+	ListDynamicByOrganization(ctx context.Context, orgID string) ([]*types.KnowledgeBaseDynamicShare, error)
+	// ListDynamicForUser lists the dynamic rules of all organizations the user belongs to
+	ListDynamicForUser(ctx context.Context, userID string) ([]*types.KnowledgeBaseDynamicShare, error)
+	// ListDynamicMatchingKnowledgeBase lists the dynamic rules whose criteria currently match the KB
+	ListDynamicMatchingKnowledgeBase(ctx context.Context, kbID string) ([]*types.KnowledgeBaseDynamicShare, error)
+	// ListKnowledgeBasesMatchingDynamic lists the KBs currently matched by a dynamic rule
+	ListKnowledgeBasesMatchingDynamic(ctx context.Context, rule *types.KnowledgeBaseDynamicShare) ([]*types.KnowledgeBase, error)
 }
 
 // AgentShareService defines the agent sharing service interface
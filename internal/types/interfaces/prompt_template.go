@@ -0,0 +1,39 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// PromptTemplateService defines the prompt library service interface
+type PromptTemplateService interface {
+	// CreateTemplate creates a prompt template under the tenant in context
+	CreateTemplate(ctx context.Context, template *types.PromptTemplate) (*types.PromptTemplate, error)
+	// GetTemplate retrieves a prompt template of the tenant in context
+	GetTemplate(ctx context.Context, id string) (*types.PromptTemplate, error)
+	// ListTemplates lists all prompt templates of the tenant in context
+	ListTemplates(ctx context.Context) ([]*types.PromptTemplate, error)
+	// UpdateTemplate updates the name, description and content of a prompt template
+	UpdateTemplate(ctx context.Context, template *types.PromptTemplate) (*types.PromptTemplate, error)
+	// DeleteTemplate deletes a prompt template; agents still referencing it fall back to their inline prompt
+	DeleteTemplate(ctx context.Context, id string) error
+	// ResolveSystemPrompt returns the system prompt an agent should use at request time:
+	// the current text of its referenced template, or its inline SystemPrompt when the
+	// reference is empty or dangling, with request variables such as {{user_name}} substituted
+	ResolveSystemPrompt(ctx context.Context, agent *types.CustomAgent) string
+}
+
+// PromptTemplateRepository defines the prompt template repository interface
+type PromptTemplateRepository interface {
+	// Create creates a prompt template record
+	Create(ctx context.Context, template *types.PromptTemplate) error
+	// GetByID retrieves a prompt template by ID and tenant
+	GetByID(ctx context.Context, tenantID uint64, id string) (*types.PromptTemplate, error)
+	// List lists all prompt templates of a tenant
+	List(ctx context.Context, tenantID uint64) ([]*types.PromptTemplate, error)
+	// Update updates a prompt template record
+	Update(ctx context.Context, template *types.PromptTemplate) error
+	// Delete deletes a prompt template record (soft delete)
+	Delete(ctx context.Context, tenantID uint64, id string) error
+}
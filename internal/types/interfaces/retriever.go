@@ -60,6 +60,10 @@ type RetrieveEngineRepository interface {
 	// chunkTagMap: map of chunk ID to tag ID (empty string means no tag)
 	BatchUpdateChunkTagID(ctx context.Context, chunkTagMap map[string]string) error
 
+	// RebuildKeywordIndex re-analyzes the stored content of the given knowledge so the keyword
+	// index matches it again; embeddings are left untouched
+	RebuildKeywordIndex(ctx context.Context, knowledgeIDList []string) error
+
 	// RetrieveEngine retrieves the engine
 	RetrieveEngine
 }
@@ -127,6 +131,10 @@ type RetrieveEngineService interface {
 	// chunkTagMap: map of chunk ID to tag ID (empty string means no tag)
 	BatchUpdateChunkTagID(ctx context.Context, chunkTagMap map[string]string) error
 
+	// RebuildKeywordIndex re-analyzes the stored content of the given knowledge so the keyword
+	// index matches it again; embeddings are left untouched
+	RebuildKeywordIndex(ctx context.Context, knowledgeIDList []string) error
+
 	// RetrieveEngine retrieves the engine
 	RetrieveEngine
 }
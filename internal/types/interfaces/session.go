@@ -2,6 +2,7 @@ package interfaces
 
 import (
 	"context"
+	"mime/multipart"
 
 	"github.com/Tencent/WeKnora/internal/event"
 	"github.com/Tencent/WeKnora/internal/types"
@@ -17,6 +18,8 @@ type SessionService interface {
 	GetSessionsByTenant(ctx context.Context) ([]*types.Session, error)
 	// GetPagedSessionsByTenant gets paged sessions of a tenant
 	GetPagedSessionsByTenant(ctx context.Context, page *types.Pagination) (*types.PageResult, error)
+	// GetPagedSessionsByAgent gets paged sessions of a tenant that were started with the given agent
+	GetPagedSessionsByAgent(ctx context.Context, agentID string, page *types.Pagination) (*types.PageResult, error)
 	// UpdateSession updates a session
 	UpdateSession(ctx context.Context, session *types.Session) error
 	// DeleteSession deletes a session
@@ -28,46 +31,56 @@ type SessionService interface {
 	// GenerateTitle generates a title for the current conversation
 	// modelID: optional model ID to use for title generation (if empty, uses first available KnowledgeQA model)
 	GenerateTitle(ctx context.Context, session *types.Session, messages []types.Message, modelID string) (string, error)
+	// RegenerateTitle generates a fresh title from the conversation, overwriting any existing one
+	RegenerateTitle(ctx context.Context, sessionID string, modelID string) (string, error)
 	// GenerateTitleAsync generates a title for the session asynchronously
 	// It emits an event when the title is generated
 	// modelID: optional model ID to use for title generation (if empty, uses first available KnowledgeQA model)
 	GenerateTitleAsync(ctx context.Context, session *types.Session, userQuery string, modelID string, eventBus *event.EventBus)
-	// KnowledgeQA performs knowledge-based question answering
-	// knowledgeBaseIDs: list of knowledge base IDs to search (supports multi-KB)
-	// knowledgeIDs: list of specific knowledge (file) IDs to search
-	// summaryModelID: optional summary model ID override (if empty, uses session/KB default)
-	// webSearchEnabled: whether to enable web search to supplement knowledge base results
-	// customAgent: optional custom agent for config override (multiTurnEnabled, historyTurns)
-	// enableMemory: whether to enable memory feature for this request
+	// KnowledgeQA performs knowledge-based question answering for req
 	// Events are emitted through eventBus (references, answer chunks, completion)
-	KnowledgeQA(ctx context.Context,
-		session *types.Session, query string, knowledgeBaseIDs []string, knowledgeIDs []string,
-		assistantMessageID string, summaryModelID string, webSearchEnabled bool, eventBus *event.EventBus,
-		customAgent *types.CustomAgent, enableMemory bool,
-	) error
+	KnowledgeQA(ctx context.Context, req *types.QARequest, eventBus *event.EventBus) error
 	// KnowledgeQAByEvent performs knowledge-based question answering by event
 	KnowledgeQAByEvent(ctx context.Context, chatManage *types.ChatManage, eventList []types.EventType) error
 	// SearchKnowledge performs knowledge-based search, without summarization
 	// knowledgeBaseIDs: list of knowledge base IDs to search (supports multi-KB)
 	// knowledgeIDs: list of specific knowledge (file) IDs to search
-	SearchKnowledge(ctx context.Context, knowledgeBaseIDs []string, knowledgeIDs []string, query string) ([]*types.SearchResult, error)
+	// filters: optional metadata and creation date filters that retrieved documents must match
+	SearchKnowledge(ctx context.Context, knowledgeBaseIDs []string, knowledgeIDs []string, query string,
+		filters *types.RetrievalFilters) ([]*types.SearchResult, error)
 	// AgentQA performs agent-based question answering with conversation history and streaming support
-	// eventBus is optional - if nil, uses service's default EventBus
-	// customAgent is optional - if provided, uses custom agent configuration instead of tenant defaults
-	// summaryModelID is optional - if provided, overrides the model from customAgent config
-	AgentQA(
-		ctx context.Context,
-		session *types.Session,
-		query string,
-		assistantMessageID string,
-		summaryModelID string,
-		eventBus *event.EventBus,
-		customAgent *types.CustomAgent,
-		knowledgeBaseIDs []string,
-		knowledgeIDs []string,
-	) error
+	// req.CustomAgent is required; eventBus is optional - if nil, uses service's default EventBus
+	AgentQA(ctx context.Context, req *types.QARequest, eventBus *event.EventBus) error
+	// ResolveAgentKnowledgeBases returns the knowledge base IDs the agent searches when the request
+	// mentions none, based on its KBSelectionMode (sessionTenantID detects shared agents)
+	ResolveAgentKnowledgeBases(ctx context.Context, customAgent *types.CustomAgent, sessionTenantID uint64) []string
 	// ClearContext clears the LLM context for a session
 	ClearContext(ctx context.Context, sessionID string) error
+	// GetContextSummary returns the compressed conversation summary stored in the LLM context
+	GetContextSummary(ctx context.Context, sessionID string) (string, error)
+	// UpdateContextSummary replaces the compressed conversation summary, an empty text removes it
+	UpdateContextSummary(ctx context.Context, sessionID string, text string) error
+	// PinKnowledgeBases replaces the knowledge bases pinned to a session; pinned knowledge bases are
+	// always searched by KnowledgeQA and AgentQA in addition to the resolved set. An empty list unpins all.
+	PinKnowledgeBases(ctx context.Context, sessionID string, kbIDs []string) (*types.Session, error)
+	// PruneSessionHistory trims the oldest messages of a session according to the tenant's
+	// history retention config and returns the number of deleted messages
+	PruneSessionHistory(ctx context.Context, sessionID string) (int, error)
+	// AttachFileToSession uploads a file into the session's temporary knowledge base so that
+	// KnowledgeQA retrieves from it in subsequent turns; it is removed together with the session
+	AttachFileToSession(
+		ctx context.Context, sessionID string, file *multipart.FileHeader, enableMultimodel *bool,
+	) (*types.Knowledge, error)
+	// StartGeneration registers an in-flight answer generation; stop is invoked when it is cancelled
+	StartGeneration(ctx context.Context, gen *types.ActiveGeneration, stop func())
+	// FinishGeneration removes a generation from the registry
+	FinishGeneration(sessionID, messageID string)
+	// ListActiveGenerations lists the generations in flight on all instances (tenantID 0 = all tenants)
+	ListActiveGenerations(ctx context.Context, tenantID uint64) ([]*types.ActiveGeneration, error)
+	// CancelGeneration stops the in-flight generation of an assistant message on any instance
+	CancelGeneration(ctx context.Context, messageID string) (*types.ActiveGeneration, error)
+	// PruneAllSessionHistory applies history retention to every session of every tenant that configured it
+	PruneAllSessionHistory(ctx context.Context) error
 }
 
 // SessionRepository defines the session repository interface
@@ -80,8 +93,16 @@ type SessionRepository interface {
 	GetByTenantID(ctx context.Context, tenantID uint64) ([]*types.Session, error)
 	// GetPagedByTenantID gets paged sessions of a tenant
 	GetPagedByTenantID(ctx context.Context, tenantID uint64, page *types.Pagination) ([]*types.Session, int64, error)
-	// Update updates a session
+	// GetPagedByTenantAndAgent gets paged sessions of a tenant that were started with the given agent
+	GetPagedByTenantAndAgent(
+		ctx context.Context, tenantID uint64, agentID string, page *types.Pagination,
+	) ([]*types.Session, int64, error)
+	// Update updates a session (pinned knowledge bases are left untouched)
 	Update(ctx context.Context, session *types.Session) error
+	// UpdatePinnedKnowledgeBases replaces the knowledge bases pinned to a session
+	UpdatePinnedKnowledgeBases(ctx context.Context, tenantID uint64, id string, kbIDs []string) error
+	// UpdateTitleIfEmpty sets a generated title unless the session already has one; reports whether it was set
+	UpdateTitleIfEmpty(ctx context.Context, tenantID uint64, id string, title string) (bool, error)
 	// Delete deletes a session
 	Delete(ctx context.Context, tenantID uint64, id string) error
 	// BatchDelete deletes multiple sessions by IDs
@@ -27,6 +27,8 @@ type StreamManager interface {
 
 	// GetEvents gets events starting from offset
 	// Uses Redis LRange for incremental reads
+	// Offsets are stable for the lifetime of the stream, so offset+1 serves as the event's
+	// monotonic sequence number (used as the SSE id for Last-Event-ID based replay)
 	// Returns: events slice, next offset for subsequent reads, error
 	GetEvents(ctx context.Context, sessionID, messageID string, fromOffset int) ([]StreamEvent, int, error)
 }
@@ -15,10 +15,14 @@ type KnowledgeTagService interface {
 	CreateTag(ctx context.Context, kbID string, name string, color string, sortOrder int) (*types.KnowledgeTag, error)
 	// UpdateTag updates tag basic information.
 	UpdateTag(ctx context.Context, id string, name *string, color *string, sortOrder *int) (*types.KnowledgeTag, error)
-	// DeleteTag deletes a tag.
+	// DeleteTag deletes a tag. Knowledge and FAQ entries under the tag become untagged unless force=true,
+	// which deletes them too.
 	// When contentOnly=true, only deletes the content under the tag but keeps the tag itself.
 	// excludeIDs: IDs of chunks to exclude from deletion (only valid when deleting chunks)
 	DeleteTag(ctx context.Context, id string, force bool, contentOnly bool, excludeIDs []string) error
+	// MergeTags moves all knowledge and FAQ entries from the source tag to the target tag, then deletes
+	// the source tag. Both tags must belong to the knowledge base.
+	MergeTags(ctx context.Context, kbID string, sourceID string, targetID string) (*types.KnowledgeTag, error)
 	// FindOrCreateTagByName finds a tag by name or creates it if not exists.
 	FindOrCreateTagByName(ctx context.Context, kbID string, name string) (*types.KnowledgeTag, error)
 	// ProcessIndexDelete handles async index deletion task
@@ -14,6 +14,8 @@ type UserService interface {
 	Login(ctx context.Context, req *types.LoginRequest) (*types.LoginResponse, error)
 	// GetUserByID gets a user by ID
 	GetUserByID(ctx context.Context, id string) (*types.User, error)
+	// GetUsersByIDs gets the users with the given IDs; unknown IDs are skipped
+	GetUsersByIDs(ctx context.Context, ids []string) ([]*types.User, error)
 	// GetUserByEmail gets a user by email
 	GetUserByEmail(ctx context.Context, email string) (*types.User, error)
 	// GetUserByUsername gets a user by username
@@ -40,6 +42,8 @@ type UserService interface {
 	GetCurrentUser(ctx context.Context) (*types.User, error)
 	// SearchUsers searches users by username or email
 	SearchUsers(ctx context.Context, query string, limit int) ([]*types.User, error)
+	// ListNotifications lists a page of the notifications of a user, newest first
+	ListNotifications(ctx context.Context, userID string, page *types.Pagination) (*types.PageResult, error)
 }
 
 // UserRepository defines the user repository interface
@@ -48,6 +52,8 @@ type UserRepository interface {
 	CreateUser(ctx context.Context, user *types.User) error
 	// GetUserByID gets a user by ID
 	GetUserByID(ctx context.Context, id string) (*types.User, error)
+	// GetUsersByIDs gets the users with the given IDs; unknown IDs are skipped
+	GetUsersByIDs(ctx context.Context, ids []string) ([]*types.User, error)
 	// GetUserByEmail gets a user by email
 	GetUserByEmail(ctx context.Context, email string) (*types.User, error)
 	// GetUserByUsername gets a user by username
@@ -81,3 +87,11 @@ type AuthTokenRepository interface {
 	// RevokeTokensByUserID revokes all tokens for a user
 	RevokeTokensByUserID(ctx context.Context, userID string) error
 }
+
+// UserNotificationRepository defines the user notification repository interface
+type UserNotificationRepository interface {
+	// Create stores a notification
+	Create(ctx context.Context, notification *types.UserNotification) error
+	// ListByUserID lists a page of the notifications of a user, newest first, with their total count
+	ListByUserID(ctx context.Context, userID string, page *types.Pagination) ([]*types.UserNotification, int64, error)
+}
@@ -21,6 +21,13 @@ type WebSearchStateService interface {
 		knowledgeIDs []string,
 	)
 
+	// AddSessionAttachment records a file attached to the session; the knowledge is stored in the
+	// session's temporary KB and is deleted together with it
+	AddSessionAttachment(ctx context.Context, sessionID string, tempKBID string, knowledgeID string) error
+
+	// GetSessionAttachments returns the session's temporary KB and the knowledge IDs of its attachments
+	GetSessionAttachments(ctx context.Context, sessionID string) (tempKBID string, attachmentIDs []string)
+
 	// DeleteWebSearchTempKBState deletes the temporary KB state for web search from Redis
 	DeleteWebSearchTempKBState(ctx context.Context, sessionID string) error
 }
@@ -44,6 +44,14 @@ const (
 	SummaryStatusFailed = "failed"
 )
 
+// Retrieval boost limits for a knowledge document
+const (
+	// DefaultRetrievalBoost leaves chunk scores unchanged
+	DefaultRetrievalBoost = 1.0
+	// MaxRetrievalBoost is the largest score multiplier a document may be given
+	MaxRetrievalBoost = 10.0
+)
+
 // ManualKnowledgeFormat represents the format of the manual knowledge
 const (
 	ManualKnowledgeFormatMarkdown = "markdown"
@@ -95,6 +103,8 @@ type Knowledge struct {
 	Metadata JSON `json:"metadata"           gorm:"type:json"`
 	// Last FAQ import result (for FAQ type knowledge only)
 	LastFAQImportResult JSON `json:"last_faq_import_result" gorm:"type:json"`
+	// Score multiplier applied to this document's chunks before final ranking (1.0 = no change)
+	RetrievalBoost float64 `json:"retrieval_boost"    gorm:"default:1"`
 	// Creation time of the knowledge
 	CreatedAt time.Time `json:"created_at"`
 	// Last updated time of the knowledge
@@ -125,6 +135,14 @@ func (k *Knowledge) GetMetadata() map[string]string {
 	return metadata
 }
 
+// EffectiveRetrievalBoost returns the retrieval boost, treating unset values as DefaultRetrievalBoost
+func (k *Knowledge) EffectiveRetrievalBoost() float64 {
+	if k.RetrievalBoost <= 0 {
+		return DefaultRetrievalBoost
+	}
+	return k.RetrievalBoost
+}
+
 // BeforeCreate hook generates a UUID for new Knowledge entities before they are created.
 func (k *Knowledge) BeforeCreate(tx *gorm.DB) (err error) {
 	if k.ID == "" {
@@ -150,10 +168,34 @@ type ManualKnowledgePayload struct {
 	TagID   string `json:"tag_id"`
 }
 
+// FileUploadCheckRequest describes a file to check before uploading it to a knowledge base
+type FileUploadCheckRequest struct {
+	FileName string `json:"file_name" binding:"required"`
+	// Size in bytes; 0 skips the size check
+	FileSize int64  `json:"file_size"`
+	MimeType string `json:"mime_type"`
+	// Optional first bytes of the file (base64 in JSON), used to detect its actual content type
+	Head []byte `json:"head"`
+}
+
+// FileUploadCheckResult reports whether a file would be accepted by CreateKnowledgeFromFile
+type FileUploadCheckResult struct {
+	Supported bool   `json:"supported"`
+	FileType  string `json:"file_type"`
+	// Content type detected from Head, or the given mime type when no head was sent
+	DetectedMimeType string `json:"detected_mime_type,omitempty"`
+	MaxFileSize      int64  `json:"max_file_size"`
+	ExceedsMaxSize   bool   `json:"exceeds_max_size"`
+	// Reasons the upload would be rejected, empty when supported
+	Errors []string `json:"errors"`
+}
+
 // KnowledgeSearchScope defines a (tenant_id, knowledge_base_id) scope for knowledge search (e.g. own KBs + shared KBs).
 type KnowledgeSearchScope struct {
 	TenantID uint64
 	KBID     string
+	// KnowledgeIDs restricts the scope to these documents (partial shares); nil covers the whole KB
+	KnowledgeIDs []string
 }
 
 // NewManualKnowledgeMetadata creates a new ManualKnowledgeMetadata instance.
@@ -290,4 +332,19 @@ type KnowledgeCheckParams struct {
 	Passages []string
 	// Knowledge type
 	Type string
+	// Scope of the check: DuplicateScopeKB (default) or DuplicateScopeTenant
+	Scope string
+}
+
+// KnowledgeListFilter narrows a listing of knowledge across the knowledge bases of a tenant.
+// Empty fields do not filter.
+type KnowledgeListFilter struct {
+	// Only knowledge in this knowledge base
+	KnowledgeBaseID string `form:"knowledge_base_id"`
+	// Only knowledge with this tag
+	TagID string `form:"tag_id"`
+	// File type such as pdf, or "manual" / "url" for manual and web page knowledge
+	FileType string `form:"file_type"`
+	// Matches the file name or title
+	Keyword string `form:"keyword"`
 }
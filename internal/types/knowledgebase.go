@@ -36,6 +36,25 @@ const (
 	FAQQuestionIndexModeSeparate FAQQuestionIndexMode = "separate"
 )
 
+// Duplicate detection scopes of a knowledge base
+const (
+	// DuplicateScopeKB rejects content that already exists in the same knowledge base
+	DuplicateScopeKB = "kb"
+	// DuplicateScopeTenant rejects content that already exists in any knowledge base of the tenant
+	DuplicateScopeTenant = "tenant"
+)
+
+// MaxFallbackResponseLength is the maximum length in characters of a knowledge base fallback response
+const MaxFallbackResponseLength = 2000
+
+// MaxShareLabelLength is the maximum length in characters of a knowledge base share label
+const MaxShareLabelLength = 128
+
+// IsValidDuplicateScope reports whether scope is a supported duplicate detection scope
+func IsValidDuplicateScope(scope string) bool {
+	return scope == DuplicateScopeKB || scope == DuplicateScopeTenant
+}
+
 // KnowledgeBase represents a knowledge base entity
 type KnowledgeBase struct {
 	// Unique identifier of the knowledge base
@@ -70,6 +89,12 @@ type KnowledgeBase struct {
 	FAQConfig *FAQConfig `yaml:"faq_config"              json:"faq_config"              gorm:"column:faq_config;type:json"`
 	// QuestionGenerationConfig stores question generation configuration for document knowledge bases
 	QuestionGenerationConfig *QuestionGenerationConfig `yaml:"question_generation_config" json:"question_generation_config" gorm:"column:question_generation_config;type:json"`
+	// DuplicateScope controls where uploads are checked for duplicates: "kb" (default) or "tenant"
+	DuplicateScope string `yaml:"duplicate_scope"         json:"duplicate_scope"         gorm:"type:varchar(16);default:'kb'"`
+	// FallbackResponse is answered when nothing relevant is found in this knowledge base; empty uses the global one
+	FallbackResponse string `yaml:"fallback_response"       json:"fallback_response"       gorm:"type:text"`
+	// Label matched by the dynamic share rules of organizations; only the owning tenant can set it
+	ShareLabel string `yaml:"share_label"             json:"share_label"             gorm:"type:varchar(128);not null;default:''"`
 	// Whether this knowledge base is pinned to the top of the list
 	IsPinned bool `yaml:"is_pinned"               json:"is_pinned"               gorm:"default:false"`
 	// Time when the knowledge base was pinned (nil if not pinned)
@@ -100,6 +125,15 @@ type KnowledgeBaseConfig struct {
 	ImageProcessingConfig ImageProcessingConfig `yaml:"image_processing_config" json:"image_processing_config"`
 	// FAQ configuration (only for FAQ type knowledge bases)
 	FAQConfig *FAQConfig `yaml:"faq_config"              json:"faq_config"`
+	// Duplicate detection scope: "kb" or "tenant"; empty keeps the current value
+	DuplicateScope string `yaml:"duplicate_scope"         json:"duplicate_scope"`
+	// Fallback response of the knowledge base; nil keeps the current value, empty clears it
+	FallbackResponse *string `yaml:"fallback_response"       json:"fallback_response"`
+	// Embedding model of the knowledge base; empty keeps the current model
+	EmbeddingModelID string `yaml:"embedding_model_id"      json:"embedding_model_id"`
+	// Reindex re-processes all knowledge with the new embedding model. Required when the new model
+	// has a different vector dimension and the knowledge base already has content.
+	Reindex bool `yaml:"reindex"                 json:"reindex"`
 }
 
 // ParserEngineRule maps a set of file types to a specific parser engine.
@@ -131,6 +165,11 @@ type ChunkingConfig struct {
 	// ChildChunkSize is the size of child chunks used for embedding (default: 384).
 	// Only used when EnableParentChild is true.
 	ChildChunkSize int `yaml:"child_chunk_size,omitempty" json:"child_chunk_size,omitempty"`
+	// OCREnabled turns text recognition on scanned pages and images on or off; unset keeps it on.
+	// Disabling it speeds up born-digital documents.
+	OCREnabled *bool `yaml:"ocr_enabled,omitempty" json:"ocr_enabled,omitempty"`
+	// OCRLanguages lists the languages of the document text (e.g. "ja", "en"), primary language first
+	OCRLanguages []string `yaml:"ocr_languages,omitempty" json:"ocr_languages,omitempty"`
 }
 
 // ResolveParserEngine returns the engine name for the given file type
@@ -443,6 +482,9 @@ func (kb *KnowledgeBase) EnsureDefaults() {
 	if kb.Type == "" {
 		kb.Type = KnowledgeBaseTypeDocument
 	}
+	if !IsValidDuplicateScope(kb.DuplicateScope) {
+		kb.DuplicateScope = DuplicateScopeKB
+	}
 	if kb.Type != KnowledgeBaseTypeFAQ {
 		kb.FAQConfig = nil
 		return
@@ -0,0 +1,46 @@
+package types
+
+import "time"
+
+// KnowledgeBaseStats holds aggregate statistics of a knowledge base
+type KnowledgeBaseStats struct {
+	KnowledgeBaseID string `json:"knowledge_base_id"`
+	// Number of knowledge items (documents, URLs, manual entries, FAQ imports)
+	KnowledgeCount int64 `json:"knowledge_count"`
+	// Total size of the uploaded files in bytes
+	TotalFileSize int64 `json:"total_file_size"`
+	// Number of knowledge items per file type; non-file knowledge is counted under its knowledge type
+	FileTypes map[string]int64 `json:"file_types"`
+	// Number of knowledge items per parse status
+	ParseStatuses map[string]int64 `json:"parse_statuses"`
+	// Number of chunks of all types
+	ChunkCount int64 `json:"chunk_count"`
+	// Average chunk content length in characters
+	AvgChunkSize float64 `json:"avg_chunk_size"`
+	// Chunks that take part in vector indexing (parent chunks are context only)
+	IndexableChunkCount int64 `json:"indexable_chunk_count"`
+	// Indexable chunks that have been embedded and indexed
+	IndexedChunkCount int64 `json:"indexed_chunk_count"`
+	// IndexedChunkCount / IndexableChunkCount, between 0 and 1 (1 when there is nothing to index)
+	EmbeddingCoverage float64 `json:"embedding_coverage"`
+	// Latest update of the knowledge base or any of its knowledge items
+	LastUpdatedAt time.Time `json:"last_updated_at"`
+}
+
+// KnowledgeAggregates holds the knowledge-level aggregations of a knowledge base
+type KnowledgeAggregates struct {
+	Count         int64
+	TotalFileSize int64
+	FileTypes     map[string]int64
+	ParseStatuses map[string]int64
+	// LastUpdatedAt is nil when the knowledge base has no knowledge
+	LastUpdatedAt *time.Time
+}
+
+// ChunkAggregates holds the chunk-level aggregations of a knowledge base
+type ChunkAggregates struct {
+	Count          int64   `gorm:"column:count"`
+	AvgSize        float64 `gorm:"column:avg_size"`
+	IndexableCount int64   `gorm:"column:indexable_count"`
+	IndexedCount   int64   `gorm:"column:indexed_count"`
+}
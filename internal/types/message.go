@@ -164,6 +164,20 @@ type MessageSearchParams struct {
 	SessionIDs []string `json:"session_ids"`
 }
 
+// SessionMessageMatch is a message of a session matching a keyword search
+type SessionMessageMatch struct {
+	// ID of the matched message
+	ID string `json:"id"`
+	// Request ID shared by the question and its answer
+	RequestID string `json:"request_id"`
+	// Message role: "user" or "assistant"
+	Role string `json:"role"`
+	// Text around the first occurrence of the keyword
+	Snippet string `json:"snippet"`
+	// Message creation timestamp
+	CreatedAt time.Time `json:"created_at"`
+}
+
 // MessageWithSession extends Message with session title for search results
 type MessageWithSession struct {
 	Message
@@ -49,6 +49,14 @@ const (
 	ModelSourceOpenRouter  ModelSource = "openrouter"  // OpenRouter model
 )
 
+// ModelDeployment tells whether a model runs locally or is called through a remote API
+type ModelDeployment string
+
+const (
+	ModelDeploymentLocal  ModelDeployment = "local"  // Served locally (e.g. Ollama)
+	ModelDeploymentRemote ModelDeployment = "remote" // Called through a provider API
+)
+
 // EmbeddingParameters represents the embedding parameters for a model
 type EmbeddingParameters struct {
 	Dimension            int `yaml:"dimension"              json:"dimension"`
@@ -138,3 +146,83 @@ func (m *Model) BeforeCreate(tx *gorm.DB) (err error) {
 	m.ID = uuid.New().String()
 	return nil
 }
+
+// ModelCatalogEntry summarizes a model for populating model selectors
+type ModelCatalogEntry struct {
+	ID              string          `json:"id"`
+	Name            string          `json:"name"`
+	Type            ModelType       `json:"type"`
+	Source          ModelSource     `json:"source"`
+	Deployment      ModelDeployment `json:"deployment"`
+	Status          ModelStatus     `json:"status"`
+	IsDefault       bool            `json:"is_default"`
+	IsBuiltin       bool            `json:"is_builtin"`
+	Available       bool            `json:"available"`        // Whether the model is active and can be used now
+	TitleGeneration bool            `json:"title_generation"` // Whether the model can generate session titles
+}
+
+// ModelCatalog groups a tenant's models by purpose
+type ModelCatalog struct {
+	Chat      []*ModelCatalogEntry `json:"chat"`
+	Embedding []*ModelCatalogEntry `json:"embedding"`
+	Rerank    []*ModelCatalogEntry `json:"rerank"`
+	VLM       []*ModelCatalogEntry `json:"vlm"`
+}
+
+// Deployment reports whether the model is served locally or remotely
+func (m *Model) Deployment() ModelDeployment {
+	if m.Source == ModelSourceLocal {
+		return ModelDeploymentLocal
+	}
+	return ModelDeploymentRemote
+}
+
+// NewModelCatalog buckets models by type; models of unknown types are skipped
+func NewModelCatalog(models []*Model) *ModelCatalog {
+	catalog := &ModelCatalog{
+		Chat:      []*ModelCatalogEntry{},
+		Embedding: []*ModelCatalogEntry{},
+		Rerank:    []*ModelCatalogEntry{},
+		VLM:       []*ModelCatalogEntry{},
+	}
+	for _, m := range models {
+		if m == nil {
+			continue
+		}
+		available := m.Status == ModelStatusActive
+		entry := &ModelCatalogEntry{
+			ID:         m.ID,
+			Name:       m.Name,
+			Type:       m.Type,
+			Source:     m.Source,
+			Deployment: m.Deployment(),
+			Status:     m.Status,
+			IsDefault:  m.IsDefault,
+			IsBuiltin:  m.IsBuiltin,
+			Available:  available,
+			// Title generation runs on a chat model
+			TitleGeneration: available && m.Type == ModelTypeKnowledgeQA,
+		}
+		switch m.Type {
+		case ModelTypeKnowledgeQA:
+			catalog.Chat = append(catalog.Chat, entry)
+		case ModelTypeEmbedding:
+			catalog.Embedding = append(catalog.Embedding, entry)
+		case ModelTypeRerank:
+			catalog.Rerank = append(catalog.Rerank, entry)
+		case ModelTypeVLLM:
+			catalog.VLM = append(catalog.VLM, entry)
+		}
+	}
+	return catalog
+}
+
+// ModelWarmupResult is the outcome of warming up a model with a minimal request
+type ModelWarmupResult struct {
+	ModelID   string    `json:"model_id"`
+	Name      string    `json:"name"`
+	Type      ModelType `json:"type"`
+	Success   bool      `json:"success"`
+	LatencyMs int64     `json:"latency_ms"` // Time taken to initialize the model and answer the request
+	Error     string    `json:"error,omitempty"`
+}
@@ -0,0 +1,71 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// Defaults and upper bounds for ModelRetryConfig
+const (
+	DefaultModelRetryInitialBackoffMs = 500
+	DefaultModelRetryMaxBackoffMs     = 10000
+	MaxModelRetryAttempts             = 10
+	MaxModelRetryBackoffMs            = 60000
+)
+
+// ModelRetryConfig controls how transient failures of chat, embedding and rerank calls are retried.
+// Retries back off exponentially: InitialBackoffMs, then doubling up to MaxBackoffMs.
+// Streaming chat calls are only retried before the first chunk is received.
+//
+// The global default lives in config.yaml (model_retry); tenants can override it via
+// /tenants/kv/model-retry-config, stored as a JSONB column on the tenants table.
+type ModelRetryConfig struct {
+	// MaxAttempts is the total number of attempts including the first call (0 or 1 = no retry)
+	MaxAttempts int `yaml:"max_attempts"       json:"max_attempts"`
+	// InitialBackoffMs is the wait before the first retry in milliseconds
+	InitialBackoffMs int `yaml:"initial_backoff_ms" json:"initial_backoff_ms"`
+	// MaxBackoffMs caps the wait between two attempts in milliseconds
+	MaxBackoffMs int `yaml:"max_backoff_ms"     json:"max_backoff_ms"`
+}
+
+// GetMaxAttempts returns the total number of attempts, at least 1
+func (c *ModelRetryConfig) GetMaxAttempts() int {
+	if c == nil || c.MaxAttempts < 1 {
+		return 1
+	}
+	return min(c.MaxAttempts, MaxModelRetryAttempts)
+}
+
+// Backoff returns the wait before the given retry (1 = first retry)
+func (c *ModelRetryConfig) Backoff(retry int) time.Duration {
+	initial, maxBackoff := DefaultModelRetryInitialBackoffMs, DefaultModelRetryMaxBackoffMs
+	if c != nil && c.InitialBackoffMs > 0 {
+		initial = c.InitialBackoffMs
+	}
+	if c != nil && c.MaxBackoffMs > 0 {
+		maxBackoff = c.MaxBackoffMs
+	}
+	backoff := time.Duration(initial) * time.Millisecond
+	for i := 1; i < retry && backoff < time.Duration(maxBackoff)*time.Millisecond; i++ {
+		backoff *= 2
+	}
+	return min(backoff, time.Duration(maxBackoff)*time.Millisecond)
+}
+
+// Value implements the driver.Valuer interface for database serialization
+func (c ModelRetryConfig) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+// Scan implements the sql.Scanner interface for database deserialization
+func (c *ModelRetryConfig) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, c)
+}
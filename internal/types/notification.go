@@ -0,0 +1,54 @@
+package types
+
+import "time"
+
+// UserNotificationType identifies what a user notification is about
+type UserNotificationType string
+
+const (
+	// UserNotificationOrgRequestReviewed is sent when an admin reviews the user's join or upgrade request
+	UserNotificationOrgRequestReviewed UserNotificationType = "org_request_reviewed"
+	// UserNotificationOrgInviteReceived is sent when the user signs up with an email that has a pending invite
+	UserNotificationOrgInviteReceived UserNotificationType = "org_invite_received"
+)
+
+// UserNotification is a message stored for a user and shown in their notification list
+type UserNotification struct {
+	// Unique identifier
+	ID string `json:"id" gorm:"type:varchar(36);primaryKey"`
+	// User ID of the recipient
+	UserID string `json:"user_id" gorm:"type:varchar(36);not null;index"`
+	// Type of the notification, which determines the shape of Data
+	Type UserNotificationType `json:"type" gorm:"type:varchar(64);not null"`
+	// Type-specific details, e.g. OrgRequestReviewedData or OrgInviteReceivedData
+	Data JSON `json:"data" gorm:"type:json"`
+	// Creation time
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName returns the table name for GORM
+func (UserNotification) TableName() string {
+	return "user_notifications"
+}
+
+// OrgRequestReviewedData is the data of a UserNotificationOrgRequestReviewed notification
+type OrgRequestReviewedData struct {
+	OrganizationID   string            `json:"organization_id"`
+	OrganizationName string            `json:"organization_name"`
+	RequestID        string            `json:"request_id"`
+	RequestType      JoinRequestType   `json:"request_type"`
+	Status           JoinRequestStatus `json:"status"` // approved or rejected
+	// Role is the role assigned by the review; only set when approved
+	Role          OrgMemberRole `json:"role,omitempty"`
+	ReviewMessage string        `json:"review_message,omitempty"`
+}
+
+// OrgInviteReceivedData is the data of a UserNotificationOrgInviteReceived notification
+type OrgInviteReceivedData struct {
+	OrganizationID   string `json:"organization_id"`
+	OrganizationName string `json:"organization_name"`
+	// InviteID is accepted or declined through /organizations/invites/:invite_id
+	InviteID  string        `json:"invite_id"`
+	Role      OrgMemberRole `json:"role"`
+	ExpiresAt time.Time     `json:"expires_at"`
+}
@@ -0,0 +1,140 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ocrLanguage is a language that can be configured for OCR
+type ocrLanguage struct {
+	// Name is used in VLM OCR prompts
+	Name string
+	// MinerU is the language code of the MinerU parser engines
+	MinerU string
+}
+
+// ocrLanguages maps the accepted OCR language codes (ISO 639-1, plus zh-tw) to their parser settings
+var ocrLanguages = map[string]ocrLanguage{
+	"zh":    {Name: "Simplified Chinese", MinerU: "ch"},
+	"zh-tw": {Name: "Traditional Chinese", MinerU: "chinese_cht"},
+	"en":    {Name: "English", MinerU: "en"},
+	"ja":    {Name: "Japanese", MinerU: "japan"},
+	"ko":    {Name: "Korean", MinerU: "korean"},
+	"fr":    {Name: "French", MinerU: "latin"},
+	"de":    {Name: "German", MinerU: "latin"},
+	"es":    {Name: "Spanish", MinerU: "latin"},
+	"pt":    {Name: "Portuguese", MinerU: "latin"},
+	"it":    {Name: "Italian", MinerU: "latin"},
+	"ru":    {Name: "Russian", MinerU: "east_slavic"},
+	"uk":    {Name: "Ukrainian", MinerU: "east_slavic"},
+	"ar":    {Name: "Arabic", MinerU: "arabic"},
+	"hi":    {Name: "Hindi", MinerU: "devanagari"},
+	"ta":    {Name: "Tamil", MinerU: "ta"},
+	"te":    {Name: "Telugu", MinerU: "te"},
+}
+
+// SupportedOCRLanguages returns the accepted OCR language codes in sorted order
+func SupportedOCRLanguages() []string {
+	codes := make([]string, 0, len(ocrLanguages))
+	for code := range ocrLanguages {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// NormalizeOCRLanguages lower-cases and de-duplicates OCR language codes, keeping their order,
+// and returns an error naming the codes that are not supported
+func NormalizeOCRLanguages(languages []string) ([]string, error) {
+	if len(languages) == 0 {
+		return nil, nil
+	}
+	normalized := make([]string, 0, len(languages))
+	seen := make(map[string]bool, len(languages))
+	var unsupported []string
+	for _, lang := range languages {
+		code := strings.ToLower(strings.TrimSpace(lang))
+		if code == "" || seen[code] {
+			continue
+		}
+		seen[code] = true
+		if _, ok := ocrLanguages[code]; !ok {
+			unsupported = append(unsupported, lang)
+			continue
+		}
+		normalized = append(normalized, code)
+	}
+	if len(unsupported) > 0 {
+		return nil, fmt.Errorf("unsupported OCR languages: %s (supported: %s)",
+			strings.Join(unsupported, ", "), strings.Join(SupportedOCRLanguages(), ", "))
+	}
+	return normalized, nil
+}
+
+// OCRSettings are the effective OCR settings for parsing one document
+type OCRSettings struct {
+	// Enabled is nil when neither the upload nor the knowledge base sets it, leaving the parser default
+	Enabled *bool
+	// Languages of the document text, most significant first; empty leaves the parser default
+	Languages []string
+}
+
+// ResolveOCRSettings combines the OCR overrides of an upload with the knowledge base configuration
+func ResolveOCRSettings(cfg ChunkingConfig, enabled *bool, languages []string) OCRSettings {
+	settings := OCRSettings{Enabled: cfg.OCREnabled, Languages: cfg.OCRLanguages}
+	if enabled != nil {
+		settings.Enabled = enabled
+	}
+	if len(languages) > 0 {
+		settings.Languages = languages
+	}
+	return settings
+}
+
+// IsEnabled reports whether OCR runs; it is on unless explicitly disabled
+func (s OCRSettings) IsEnabled() bool {
+	return s.Enabled == nil || *s.Enabled
+}
+
+// MinerULanguage returns the MinerU language code of the primary language, empty when unset
+func (s OCRSettings) MinerULanguage() string {
+	for _, code := range s.Languages {
+		if lang, ok := ocrLanguages[code]; ok {
+			return lang.MinerU
+		}
+	}
+	return ""
+}
+
+// LanguageNames returns the names of the configured languages, e.g. "Japanese, English"
+func (s OCRSettings) LanguageNames() string {
+	names := make([]string, 0, len(s.Languages))
+	for _, code := range s.Languages {
+		if lang, ok := ocrLanguages[code]; ok {
+			names = append(names, lang.Name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// ApplyToParserOverrides returns a copy of the parser engine overrides with the OCR settings applied
+// to the MinerU engines. Unset settings keep the tenant-level overrides.
+func (s OCRSettings) ApplyToParserOverrides(overrides map[string]string) map[string]string {
+	if s.Enabled == nil && s.MinerULanguage() == "" {
+		return overrides
+	}
+	merged := make(map[string]string, len(overrides)+4)
+	for k, v := range overrides {
+		merged[k] = v
+	}
+	if s.Enabled != nil {
+		merged["mineru_enable_ocr"] = fmt.Sprintf("%v", *s.Enabled)
+		merged["mineru_cloud_enable_ocr"] = fmt.Sprintf("%v", *s.Enabled)
+	}
+	if lang := s.MinerULanguage(); lang != "" {
+		merged["mineru_language"] = lang
+		merged["mineru_cloud_language"] = lang
+	}
+	return merged
+}
@@ -1,6 +1,7 @@
 package types
 
 import (
+	"fmt"
 	"time"
 
 	"gorm.io/gorm"
@@ -48,6 +49,8 @@ type Organization struct {
 	Description string `json:"description" gorm:"type:text"`
 	// Avatar URL for display in list and settings
 	Avatar string `json:"avatar" gorm:"type:varchar(512)"`
+	// Storage path of the avatar uploaded through the avatar endpoint; only set by the server
+	AvatarPath string `json:"-" gorm:"type:varchar(512)"`
 	// User ID of the organization owner
 	OwnerID string `json:"owner_id" gorm:"type:varchar(36);not null;index"`
 	// Unique invitation code for joining the organization
@@ -62,6 +65,12 @@ type Organization struct {
 	Searchable bool `json:"searchable" gorm:"default:false"`
 	// Max members allowed; 0 means no limit
 	MemberLimit int `json:"member_limit" gorm:"default:50"`
+	// Permission given to shares created without an explicit permission (admin/editor/viewer)
+	DefaultSharePermission OrgMemberRole `json:"default_share_permission" gorm:"type:varchar(32);not null;default:'viewer'"`
+	// Days after which pending join requests expire; 0 means the global default
+	JoinRequestTTLDays int `json:"join_request_ttl_days" gorm:"not null;default:0"`
+	// Max bytes the knowledge bases shared to the organization may use; 0 means no limit
+	StorageQuota int64 `json:"storage_quota" gorm:"not null;default:0"`
 	// Creation time
 	CreatedAt time.Time `json:"created_at"`
 	// Last updated time
@@ -80,6 +89,28 @@ func (Organization) TableName() string {
 	return "organizations"
 }
 
+// HasStoredAvatar reports whether the avatar was uploaded to object storage rather than set as a URL
+func (o *Organization) HasStoredAvatar() bool {
+	return o.AvatarPath != ""
+}
+
+// AvatarURL returns the URL to display the avatar with. Uploaded avatars are served through the
+// organization avatar endpoint since storage paths are not directly reachable.
+func (o *Organization) AvatarURL() string {
+	if o.HasStoredAvatar() {
+		return fmt.Sprintf("/api/v1/organizations/%s/avatar", o.ID)
+	}
+	return o.Avatar
+}
+
+// GetDefaultSharePermission returns the permission for shares created without one, viewer if unset
+func (o *Organization) GetDefaultSharePermission() OrgMemberRole {
+	if o.DefaultSharePermission.IsValid() {
+		return o.DefaultSharePermission
+	}
+	return OrgRoleViewer
+}
+
 // OrganizationMember represents a member of an organization
 type OrganizationMember struct {
 	// Unique identifier
@@ -92,6 +123,8 @@ type OrganizationMember struct {
 	TenantID uint64 `json:"tenant_id" gorm:"not null;index"`
 	// Role in the organization (admin/editor/viewer)
 	Role OrgMemberRole `json:"role" gorm:"type:varchar(32);not null;default:'viewer'"`
+	// Whether the member is suspended; suspended members keep their role but have no access
+	Suspended bool `json:"suspended" gorm:"not null;default:false"`
 	// Creation time
 	CreatedAt time.Time `json:"created_at"`
 	// Last updated time
@@ -114,8 +147,22 @@ const (
 	JoinRequestStatusPending  JoinRequestStatus = "pending"
 	JoinRequestStatusApproved JoinRequestStatus = "approved"
 	JoinRequestStatusRejected JoinRequestStatus = "rejected"
+	// JoinRequestStatusCancelled is set when the requester withdraws a pending request
+	JoinRequestStatusCancelled JoinRequestStatus = "cancelled"
+	// JoinRequestStatusExpired is set when a request stays pending longer than the organization's TTL
+	JoinRequestStatusExpired JoinRequestStatus = "expired"
 )
 
+// IsValid checks if the status is a known join request status
+func (s JoinRequestStatus) IsValid() bool {
+	switch s {
+	case JoinRequestStatusPending, JoinRequestStatusApproved, JoinRequestStatusRejected,
+		JoinRequestStatusCancelled, JoinRequestStatusExpired:
+		return true
+	}
+	return false
+}
+
 // JoinRequestType represents the type of a join request
 type JoinRequestType string
 
@@ -168,6 +215,32 @@ func (OrganizationJoinRequest) TableName() string {
 	return "organization_join_requests"
 }
 
+// OrganizationPendingInvite represents an invitation sent to an email without an account. The
+// user who registers with that email is notified and has to accept it before joining.
+type OrganizationPendingInvite struct {
+	// Unique identifier
+	ID string `json:"id" gorm:"type:varchar(36);primaryKey"`
+	// Organization ID
+	OrganizationID string `json:"organization_id" gorm:"type:varchar(36);not null;index"`
+	// Invited email, stored in lower case
+	Email string `json:"email" gorm:"type:varchar(255);not null;index"`
+	// Role assigned when the invite is accepted; never admin
+	Role OrgMemberRole `json:"role" gorm:"type:varchar(32);not null;default:'viewer'"`
+	// User ID of the admin who sent the invite
+	InvitedBy string `json:"invited_by" gorm:"type:varchar(36);not null"`
+	// Time after which the invite can no longer be accepted
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null;index"`
+	// Creation time
+	CreatedAt time.Time `json:"created_at"`
+	// Last updated time
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for GORM
+func (OrganizationPendingInvite) TableName() string {
+	return "organization_pending_invites"
+}
+
 // KnowledgeBaseShare represents a sharing record of a knowledge base to an organization
 type KnowledgeBaseShare struct {
 	// Unique identifier
@@ -182,6 +255,8 @@ type KnowledgeBaseShare struct {
 	SourceTenantID uint64 `json:"source_tenant_id" gorm:"not null;index"`
 	// Permission level (admin/editor/viewer)
 	Permission OrgMemberRole `json:"permission" gorm:"type:varchar(32);not null;default:'viewer'"`
+	// Knowledge IDs exposed by a partial share; empty shares the whole knowledge base
+	KnowledgeIDs StringArray `json:"knowledge_ids,omitempty" gorm:"type:json"`
 	// Creation time
 	CreatedAt time.Time `json:"created_at"`
 	// Last updated time
@@ -192,6 +267,10 @@ type KnowledgeBaseShare struct {
 	// Associations (not stored in database)
 	KnowledgeBase *KnowledgeBase `json:"knowledge_base,omitempty" gorm:"foreignKey:KnowledgeBaseID"`
 	Organization  *Organization  `json:"organization,omitempty" gorm:"foreignKey:OrganizationID"`
+
+	// DynamicShareID is set when the share was resolved from a dynamic share rule rather than
+	// stored in kb_shares (not stored in database)
+	DynamicShareID string `json:"dynamic_share_id,omitempty" gorm:"-"`
 }
 
 // TableName returns the table name for GORM
@@ -199,6 +278,50 @@ func (KnowledgeBaseShare) TableName() string {
 	return "kb_shares"
 }
 
+// IsPartial reports whether the share exposes only specific documents of the knowledge base
+func (s *KnowledgeBaseShare) IsPartial() bool {
+	return len(s.KnowledgeIDs) > 0
+}
+
+// KBShareCriteria selects the knowledge bases covered by a dynamic share rule
+type KBShareCriteria struct {
+	// Label matches knowledge bases whose share label equals it (case-insensitive). Unlike document
+	// tags, the share label can only be set by the tenant owning the knowledge base.
+	Label string `json:"label" gorm:"column:label;type:varchar(128)"`
+}
+
+// KnowledgeBaseDynamicShare is a rule sharing every knowledge base of the source tenant that matches
+// its criteria to an organization. Matching knowledge bases are resolved at query time, so knowledge
+// bases labeled later become visible to the organization automatically.
+type KnowledgeBaseDynamicShare struct {
+	// Unique identifier
+	ID string `json:"id" gorm:"type:varchar(36);primaryKey"`
+	// Organization ID receiving the shares
+	OrganizationID string `json:"organization_id" gorm:"type:varchar(36);not null;index"`
+	// User ID who created the rule
+	CreatedByUserID string `json:"created_by_user_id" gorm:"type:varchar(36);not null"`
+	// Tenant whose knowledge bases are matched
+	SourceTenantID uint64 `json:"source_tenant_id" gorm:"not null;index"`
+	// Criteria selecting the shared knowledge bases
+	Criteria KBShareCriteria `json:"criteria" gorm:"embedded;embeddedPrefix:criteria_"`
+	// Permission level granted on every matching knowledge base (admin/editor/viewer)
+	Permission OrgMemberRole `json:"permission" gorm:"type:varchar(32);not null;default:'viewer'"`
+	// Creation time
+	CreatedAt time.Time `json:"created_at"`
+	// Last updated time
+	UpdatedAt time.Time `json:"updated_at"`
+	// Deletion time (soft delete)
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+
+	// Associations (not stored in database)
+	Organization *Organization `json:"organization,omitempty" gorm:"foreignKey:OrganizationID"`
+}
+
+// TableName returns the table name for GORM
+func (KnowledgeBaseDynamicShare) TableName() string {
+	return "kb_dynamic_shares"
+}
+
 // SharedKnowledgeBaseInfo represents a shared knowledge base with additional sharing info
 type SharedKnowledgeBaseInfo struct {
 	KnowledgeBase  *KnowledgeBase `json:"knowledge_base"`
@@ -232,15 +355,15 @@ func (AgentShare) TableName() string {
 
 // SharedAgentInfo represents a shared agent with additional sharing info
 type SharedAgentInfo struct {
-	Agent             *CustomAgent  `json:"agent"`
-	ShareID           string        `json:"share_id"`
-	OrganizationID    string        `json:"organization_id"`
-	OrgName           string        `json:"org_name"`
-	Permission        OrgMemberRole `json:"permission"`
-	SourceTenantID    uint64        `json:"source_tenant_id"`
-	SharedAt          time.Time     `json:"shared_at"`
-	SharedByUserID    string        `json:"shared_by_user_id,omitempty"`
-	SharedByUsername  string        `json:"shared_by_username,omitempty"`
+	Agent            *CustomAgent  `json:"agent"`
+	ShareID          string        `json:"share_id"`
+	OrganizationID   string        `json:"organization_id"`
+	OrgName          string        `json:"org_name"`
+	Permission       OrgMemberRole `json:"permission"`
+	SourceTenantID   uint64        `json:"source_tenant_id"`
+	SharedAt         time.Time     `json:"shared_at"`
+	SharedByUserID   string        `json:"shared_by_user_id,omitempty"`
+	SharedByUsername string        `json:"shared_by_username,omitempty"`
 	// DisabledByMe: current tenant has hidden this shared agent from their conversation dropdown (per-user preference)
 	DisabledByMe bool `json:"disabled_by_me"`
 }
@@ -256,7 +379,7 @@ type SourceFromAgentInfo struct {
 // When SourceFromAgent is set, the KB is from a shared agent's config (no direct KB share); show as read-only and "来自智能体 XXX".
 type OrganizationSharedKnowledgeBaseItem struct {
 	SharedKnowledgeBaseInfo
-	IsMine          bool                `json:"is_mine"`
+	IsMine          bool                 `json:"is_mine"`
 	SourceFromAgent *SourceFromAgentInfo `json:"source_from_agent,omitempty"`
 }
 
@@ -290,6 +413,10 @@ type CreateOrganizationRequest struct {
 	Avatar                 string `json:"avatar" binding:"omitempty,max=512"` // optional avatar URL
 	InviteCodeValidityDays *int   `json:"invite_code_validity_days"`          // optional: 0=never, 1, 7, 30; default 7
 	MemberLimit            *int   `json:"member_limit"`                       // optional: max members; 0=unlimited; default 50
+	// optional: permission of shares created without one; default viewer
+	DefaultSharePermission *OrgMemberRole `json:"default_share_permission"`
+	// optional: days before pending join requests expire; 0 = global default
+	JoinRequestTTLDays *int `json:"join_request_ttl_days"`
 }
 
 // UpdateOrganizationRequest represents a request to update an organization
@@ -301,6 +428,12 @@ type UpdateOrganizationRequest struct {
 	Searchable             *bool   `json:"searchable"`                // open for search so others can discover and join
 	InviteCodeValidityDays *int    `json:"invite_code_validity_days"` // 0=never, 1, 7, 30
 	MemberLimit            *int    `json:"member_limit"`              // max members; 0=unlimited
+	// permission of shares created without one
+	DefaultSharePermission *OrgMemberRole `json:"default_share_permission"`
+	// days before pending join requests expire; 0 = global default
+	JoinRequestTTLDays *int `json:"join_request_ttl_days"`
+	// max bytes used by shared knowledge bases; 0 = unlimited
+	StorageQuota *int64 `json:"storage_quota"`
 }
 
 // AddMemberRequest represents a request to add a member to an organization
@@ -345,10 +478,75 @@ type InviteMemberRequest struct {
 	Role   OrgMemberRole `json:"role" binding:"required"`    // Role to assign: admin/editor/viewer
 }
 
+// MaxBulkInviteEmails is the maximum number of emails in one bulk invite request
+const MaxBulkInviteEmails = 100
+
+// PendingInviteValidity is how long an invite to an email without an account can be accepted
+const PendingInviteValidity = 14 * 24 * time.Hour
+
+// BulkInviteMembersRequest represents a request to invite several users to organization by email
+type BulkInviteMembersRequest struct {
+	Emails []string      `json:"emails" binding:"required,min=1,max=100"` // Emails to invite
+	Role   OrgMemberRole `json:"role" binding:"required"`                 // Role to assign: admin/editor/viewer
+}
+
+// BulkInviteStatus represents the outcome of inviting one email
+type BulkInviteStatus string
+
+const (
+	// BulkInviteStatusAdded means the user was added to the organization
+	BulkInviteStatusAdded BulkInviteStatus = "added"
+	// BulkInviteStatusPending means no user has the email yet; the user who signs up with it is
+	// notified and can accept the invite until it expires
+	BulkInviteStatusPending BulkInviteStatus = "pending"
+	// BulkInviteStatusRoleNotAllowed means no user has the email yet and the admin role can't be
+	// given through a pending invite
+	BulkInviteStatusRoleNotAllowed BulkInviteStatus = "role_not_allowed"
+	// BulkInviteStatusAlreadyMember means the user is already a member and was skipped
+	BulkInviteStatusAlreadyMember BulkInviteStatus = "already_member"
+	// BulkInviteStatusSuspended means the user is a suspended member and was skipped
+	BulkInviteStatusSuspended BulkInviteStatus = "suspended"
+	// BulkInviteStatusLimitReached means the organization member limit was reached
+	BulkInviteStatusLimitReached BulkInviteStatus = "limit_reached"
+	// BulkInviteStatusInvalid means the email is malformed
+	BulkInviteStatusInvalid BulkInviteStatus = "invalid_email"
+	// BulkInviteStatusFailed means the invite failed for another reason
+	BulkInviteStatusFailed BulkInviteStatus = "failed"
+)
+
+// BulkInviteResult is the outcome of inviting one email
+type BulkInviteResult struct {
+	Email  string           `json:"email"`
+	Status BulkInviteStatus `json:"status"`
+	UserID string           `json:"user_id,omitempty"`
+}
+
 // ShareKnowledgeBaseRequest represents a request to share a knowledge base
 type ShareKnowledgeBaseRequest struct {
-	OrganizationID string        `json:"organization_id" binding:"required"`
-	Permission     OrgMemberRole `json:"permission" binding:"required"`
+	OrganizationID string `json:"organization_id" binding:"required"`
+	// Permission is optional; the organization's default share permission is used when omitted
+	Permission OrgMemberRole `json:"permission"`
+	// KnowledgeIDs limits the share to these documents; omit to share the whole knowledge base
+	KnowledgeIDs []string `json:"knowledge_ids"`
+}
+
+// CreateDynamicShareRequest represents a request to share all knowledge bases matching criteria
+type CreateDynamicShareRequest struct {
+	Criteria KBShareCriteria `json:"criteria" binding:"required"`
+	// Permission is optional; the organization's default share permission is used when omitted
+	Permission OrgMemberRole `json:"permission"`
+}
+
+// CopyKBToUserRequest represents a request to give a user an independent copy of a knowledge base
+type CopyKBToUserRequest struct {
+	TargetUserID string `json:"target_user_id" binding:"required"`
+}
+
+// CopyKBToUserResponse is returned when a copy-to-user clone task has been enqueued
+type CopyKBToUserResponse struct {
+	TaskID   string `json:"task_id"`
+	SourceID string `json:"source_id"`
+	TargetID string `json:"target_id"` // the copy, owned by the target user's tenant
 }
 
 // UpdateSharePermissionRequest represents a request to update share permission
@@ -369,9 +567,12 @@ type OrganizationResponse struct {
 	RequireApproval         bool       `json:"require_approval"`
 	Searchable              bool       `json:"searchable"`
 	MemberLimit             int        `json:"member_limit"` // 0 = unlimited
+	DefaultSharePermission  string     `json:"default_share_permission"`
+	JoinRequestTTLDays      int        `json:"join_request_ttl_days"` // 0 = global default
+	StorageQuota            int64      `json:"storage_quota"`         // bytes, 0 = unlimited
 	MemberCount             int        `json:"member_count"`
 	ShareCount              int        `json:"share_count"`                // 共享到该组织的知识库数量
-	AgentShareCount         int        `json:"agent_share_count"`        // 共享到该组织的智能体数量
+	AgentShareCount         int        `json:"agent_share_count"`          // 共享到该组织的智能体数量
 	PendingJoinRequestCount int        `json:"pending_join_request_count"` // 待审批加入申请数（仅管理员可见）
 	IsOwner                 bool       `json:"is_owner"`
 	MyRole                  string     `json:"my_role,omitempty"`
@@ -382,14 +583,15 @@ type OrganizationResponse struct {
 
 // OrganizationMemberResponse represents a member in API responses
 type OrganizationMemberResponse struct {
-	ID       string    `json:"id"`
-	UserID   string    `json:"user_id"`
-	Username string    `json:"username"`
-	Email    string    `json:"email"`
-	Avatar   string    `json:"avatar"`
-	Role     string    `json:"role"`
-	TenantID uint64    `json:"tenant_id"`
-	JoinedAt time.Time `json:"joined_at"`
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	Avatar    string    `json:"avatar"`
+	Role      string    `json:"role"`
+	Suspended bool      `json:"suspended"`
+	TenantID  uint64    `json:"tenant_id"`
+	JoinedAt  time.Time `json:"joined_at"`
 }
 
 // KnowledgeBaseShareResponse represents a share record in API responses
@@ -410,6 +612,8 @@ type KnowledgeBaseShareResponse struct {
 	MyPermission      string    `json:"my_permission"`  // Effective permission for current user = min(Permission, MyRoleInOrg)
 	CreatedAt         time.Time `json:"created_at"`
 	RequireApproval   bool      `json:"require_approval"`
+	DynamicShareID    string    `json:"dynamic_share_id,omitempty"` // Set when shared by a dynamic share rule
+	KnowledgeIDs      []string  `json:"knowledge_ids,omitempty"`    // Set when only these documents are shared
 }
 
 // AgentShareResponse represents an agent share record in API responses
@@ -427,10 +631,10 @@ type AgentShareResponse struct {
 	MyPermission     string    `json:"my_permission,omitempty"`
 	CreatedAt        time.Time `json:"created_at"`
 	// Agent scope summary for list display (from agent config when available)
-	ScopeKB        string `json:"scope_kb,omitempty"`        // "all" | "selected" | "none"
+	ScopeKB        string `json:"scope_kb,omitempty"`       // "all" | "selected" | "none"
 	ScopeKBCount   int    `json:"scope_kb_count,omitempty"` // when selected
 	ScopeWebSearch bool   `json:"scope_web_search,omitempty"`
-	ScopeMCP       string `json:"scope_mcp,omitempty"`        // "all" | "selected" | "none"
+	ScopeMCP       string `json:"scope_mcp,omitempty"`       // "all" | "selected" | "none"
 	ScopeMCPCount  int    `json:"scope_mcp_count,omitempty"` // when selected
 	// Agent avatar (emoji or icon name) for list display
 	AgentAvatar string `json:"agent_avatar,omitempty"`
@@ -438,8 +642,8 @@ type AgentShareResponse struct {
 
 // ListOrganizationsResponse represents the response for listing organizations
 type ListOrganizationsResponse struct {
-	Organizations  []OrganizationResponse     `json:"organizations"`
-	Total          int64                      `json:"total"`
+	Organizations  []OrganizationResponse       `json:"organizations"`
+	Total          int64                        `json:"total"`
 	ResourceCounts *ResourceCountsByOrgResponse `json:"resource_counts,omitempty"` // 各空间内知识库/智能体数量，供列表侧栏展示
 }
 
@@ -465,6 +669,10 @@ type SearchableOrganizationItem struct {
 	AgentShareCount int    `json:"agent_share_count"` // 共享到该组织的智能体数量
 	IsAlreadyMember bool   `json:"is_already_member"`
 	RequireApproval bool   `json:"require_approval"`
+	// IsFull is set when the member limit is reached, so joining would fail
+	IsFull bool `json:"is_full"`
+	// HasPendingRequest is set when the current user already has a join request awaiting review
+	HasPendingRequest bool `json:"has_pending_request"`
 }
 
 // ListSearchableOrganizationsResponse is the response for searching discoverable organizations
@@ -473,6 +681,19 @@ type ListSearchableOrganizationsResponse struct {
 	Total         int64                        `json:"total"`
 }
 
+// OrgPreviewKnowledgeBase is a knowledge base listed in the detailed preview of an organization.
+// Only its name and type are exposed to prospective members.
+type OrgPreviewKnowledgeBase struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// OrgPreviewAgent is an agent listed in the detailed preview of an organization
+type OrgPreviewAgent struct {
+	Name   string `json:"name"`
+	Avatar string `json:"avatar,omitempty"`
+}
+
 // JoinByOrganizationIDRequest is used to join a searchable organization by ID (no invite code)
 type JoinByOrganizationIDRequest struct {
 	OrganizationID string        `json:"organization_id" binding:"required"`
@@ -509,6 +730,23 @@ type ListMembersResponse struct {
 
 // ListSharesResponse represents the response for listing shares
 type ListSharesResponse struct {
-	Shares []KnowledgeBaseShareResponse `json:"shares"`
-	Total  int64                        `json:"total"`
+	Shares   []KnowledgeBaseShareResponse `json:"shares"`
+	Total    int64                        `json:"total"`
+	Page     int                          `json:"page,omitempty"`      // Set only when the listing is paginated
+	PageSize int                          `json:"page_size,omitempty"` // Set only when the listing is paginated
+}
+
+// OrgStorageUsage is the storage used by the knowledge bases shared to an organization
+type OrgStorageUsage struct {
+	OrganizationID string `json:"organization_id"`
+	// Bytes of the uploaded files and their index data, counting only the shared documents of partial shares
+	UsedBytes int64 `json:"used_bytes"`
+	// Quota in bytes; 0 means no limit
+	QuotaBytes int64 `json:"quota_bytes"`
+	// Whether the usage has reached the quota, which blocks new shares and uploads
+	Exceeded bool `json:"exceeded"`
+	// Number of knowledge bases shared to the organization
+	KnowledgeBaseCount int `json:"knowledge_base_count"`
+	// Time the usage was computed; it is cached for a few minutes
+	ComputedAt time.Time `json:"computed_at"`
 }
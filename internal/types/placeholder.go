@@ -86,26 +86,55 @@ var (
 		Label:       "网络搜索状态",
 		Description: "网络搜索工具是否启用的状态（Enabled 或 Disabled）",
 	}
+
+	// Request variables, substituted when an agent's system prompt is resolved
+	PlaceholderUserName = PromptPlaceholder{
+		Name:        "user_name",
+		Label:       "用户名",
+		Description: "当前登录用户的用户名（无登录用户时保持原样）",
+	}
+
+	PlaceholderDate = PromptPlaceholder{
+		Name:        "date",
+		Label:       "当前日期",
+		Description: "当前日期（格式：2006-01-02）",
+	}
+
+	PlaceholderOrgName = PromptPlaceholder{
+		Name:        "org_name",
+		Label:       "组织名称",
+		Description: "当前请求所属租户（空间）的名称",
+	}
 )
 
+// RequestPromptPlaceholders returns the request variables substituted in agent system prompts.
+// Placeholders outside this set are left untouched for later rendering stages.
+func RequestPromptPlaceholders() []PromptPlaceholder {
+	return []PromptPlaceholder{
+		PlaceholderUserName,
+		PlaceholderDate,
+		PlaceholderOrgName,
+	}
+}
+
 // PlaceholdersByField returns the available placeholders for a specific prompt field type
 func PlaceholdersByField(fieldType PromptFieldType) []PromptPlaceholder {
 	switch fieldType {
 	case PromptFieldSystemPrompt:
 		// Normal mode system prompt
-		return []PromptPlaceholder{
+		return append([]PromptPlaceholder{
 			PlaceholderQuery,
 			PlaceholderContexts,
 			PlaceholderCurrentTime,
 			PlaceholderCurrentWeek,
-		}
+		}, RequestPromptPlaceholders()...)
 	case PromptFieldAgentSystemPrompt:
 		// Agent mode system prompt
-		return []PromptPlaceholder{
+		return append([]PromptPlaceholder{
 			PlaceholderKnowledgeBases,
 			PlaceholderWebSearchStatus,
 			PlaceholderCurrentTime,
-		}
+		}, RequestPromptPlaceholders()...)
 	case PromptFieldContextTemplate:
 		return []PromptPlaceholder{
 			PlaceholderQuery,
@@ -149,6 +178,9 @@ func AllPlaceholders() []PromptPlaceholder {
 		PlaceholderAnswer,
 		PlaceholderKnowledgeBases,
 		PlaceholderWebSearchStatus,
+		PlaceholderUserName,
+		PlaceholderDate,
+		PlaceholderOrgName,
 	}
 }
 
@@ -0,0 +1,32 @@
+package types
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PromptTemplate is a tenant-scoped system prompt that agents can reference by ID
+// through CustomAgentConfig.SystemPromptRef instead of copying the prompt inline
+type PromptTemplate struct {
+	// Unique identifier of the template (UUID)
+	ID string `yaml:"id" json:"id" gorm:"type:varchar(36);primaryKey"`
+	// Tenant that owns the template
+	TenantID uint64 `yaml:"tenant_id" json:"tenant_id" gorm:"index"`
+	// Name of the template
+	Name string `yaml:"name" json:"name" gorm:"type:varchar(255);not null"`
+	// Description of the template
+	Description string `yaml:"description" json:"description" gorm:"type:text"`
+	// Prompt text, supports the same placeholders as an inline system prompt
+	Content string `yaml:"content" json:"content" gorm:"type:text;not null"`
+
+	// Timestamps
+	CreatedAt time.Time      `yaml:"created_at" json:"created_at"`
+	UpdatedAt time.Time      `yaml:"updated_at" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `yaml:"deleted_at" json:"deleted_at" gorm:"index"`
+}
+
+// TableName returns the table name for PromptTemplate
+func (PromptTemplate) TableName() string {
+	return "prompt_templates"
+}
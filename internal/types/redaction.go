@@ -0,0 +1,115 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Defaults and limits for RedactionConfig
+const (
+	DefaultRedactionReplacement = "[REDACTED]"
+	DefaultRedactionWindowSize  = 64
+	MaxRedactionRules           = 50
+	MaxRedactionPatternLength   = 500
+	MaxRedactionWindowSize      = 512
+)
+
+// RedactionConfig lists regular expressions whose matches are replaced in generated answers (KnowledgeQA,
+// AgentQA and fallback answers, whether asked over HTTP, an IM channel or an agent evaluation), e.g. to
+// redact emails or phone numbers.
+// Redaction happens while the answer streams: the last WindowSize characters of each chunk are held back
+// until the next chunk arrives (or longer while a match runs into them), so a match split across chunks is
+// still redacted. This delays streaming by up to WindowSize characters; a match longer than the window may
+// be missed when it spans chunks. Disabled by default.
+//
+// Stored as a JSONB column on the tenants table, managed via /tenants/kv/redaction-config.
+type RedactionConfig struct {
+	// Enabled turns redaction on
+	Enabled bool `json:"enabled"`
+	// Rules are applied in order
+	Rules []RedactionRule `json:"rules"`
+	// WindowSize is the number of trailing characters held back while streaming (default 64)
+	WindowSize int `json:"window_size,omitempty"`
+}
+
+// RedactionRule replaces every match of Pattern with Replacement
+type RedactionRule struct {
+	// Name is an optional label, e.g. "email"
+	Name string `json:"name,omitempty"`
+	// Pattern is a Go (RE2) regular expression
+	Pattern string `json:"pattern"`
+	// Replacement replaces each match (default "[REDACTED]"); $1-style group references are not expanded
+	Replacement string `json:"replacement,omitempty"`
+}
+
+// IsActive reports whether redaction is enabled with at least one rule
+func (c *RedactionConfig) IsActive() bool {
+	return c != nil && c.Enabled && len(c.Rules) > 0
+}
+
+// GetWindowSize returns the streaming window, falling back to the default
+func (c *RedactionConfig) GetWindowSize() int {
+	if c == nil || c.WindowSize <= 0 {
+		return DefaultRedactionWindowSize
+	}
+	return min(c.WindowSize, MaxRedactionWindowSize)
+}
+
+// GetReplacement returns the replacement text, falling back to the default
+func (r RedactionRule) GetReplacement() string {
+	if r.Replacement == "" {
+		return DefaultRedactionReplacement
+	}
+	return r.Replacement
+}
+
+// Validate trims and checks the rules; each pattern must compile and must not match the empty string
+func (c *RedactionConfig) Validate() error {
+	if len(c.Rules) > MaxRedactionRules {
+		return fmt.Errorf("at most %d rules are allowed", MaxRedactionRules)
+	}
+	if c.WindowSize < 0 || c.WindowSize > MaxRedactionWindowSize {
+		return fmt.Errorf("window_size must be between 0 and %d", MaxRedactionWindowSize)
+	}
+	for i := range c.Rules {
+		rule := &c.Rules[i]
+		rule.Name = strings.TrimSpace(rule.Name)
+		if strings.TrimSpace(rule.Pattern) == "" {
+			return fmt.Errorf("rule %d: pattern is required", i+1)
+		}
+		if len(rule.Pattern) > MaxRedactionPatternLength {
+			return fmt.Errorf("rule %d: pattern must be at most %d characters", i+1, MaxRedactionPatternLength)
+		}
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return fmt.Errorf("rule %d: invalid pattern: %v", i+1, err)
+		}
+		if re.MatchString("") {
+			return fmt.Errorf("rule %d: pattern must not match empty text", i+1)
+		}
+	}
+	if c.Enabled && len(c.Rules) == 0 {
+		return fmt.Errorf("rules are required when redaction is enabled")
+	}
+	return nil
+}
+
+// Value implements the driver.Valuer interface for database serialization
+func (c RedactionConfig) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+// Scan implements the sql.Scanner interface for database deserialization
+func (c *RedactionConfig) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, c)
+}
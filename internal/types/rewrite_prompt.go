@@ -0,0 +1,50 @@
+package types
+
+import "strings"
+
+// RewritePrompt is a system/user prompt pair for the query rewrite stage.
+// Empty fields fall back to the default rewrite prompts.
+type RewritePrompt struct {
+	System string `yaml:"system" json:"system"`
+	User   string `yaml:"user"   json:"user"`
+}
+
+// RewritePrompts maps a language code (ISO 639-1, e.g. "zh", "en") to its rewrite prompts
+type RewritePrompts map[string]RewritePrompt
+
+// ForLanguage returns the prompts configured for the language, matching case-insensitively
+// and ignoring region suffixes ("zh-CN" matches "zh")
+func (p RewritePrompts) ForLanguage(lang string) (RewritePrompt, bool) {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if lang == "" || len(p) == 0 {
+		return RewritePrompt{}, false
+	}
+	if prompt, ok := p[lang]; ok {
+		return prompt, true
+	}
+	for key, prompt := range p {
+		k := strings.ToLower(strings.TrimSpace(key))
+		if base, _, ok := strings.Cut(k, "-"); ok {
+			k = base
+		}
+		if k == lang {
+			return prompt, true
+		}
+	}
+	return RewritePrompt{}, false
+}
+
+// Merge returns the prompts of p overlaid with override; override wins per language
+func (p RewritePrompts) Merge(override RewritePrompts) RewritePrompts {
+	if len(override) == 0 {
+		return p
+	}
+	merged := make(RewritePrompts, len(p)+len(override))
+	for k, v := range p {
+		merged[k] = v
+	}
+	for k, v := range override {
+		merged[k] = v
+	}
+	return merged
+}
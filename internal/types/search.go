@@ -3,6 +3,11 @@ package types
 import (
 	"database/sql/driver"
 	"encoding/json"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"time"
 )
 
 // SearchTargetType represents the type of search target
@@ -78,6 +83,20 @@ func (st SearchTargets) ContainsKB(kbID string) bool {
 	return false
 }
 
+// ContainsKnowledge checks if the search targets cover a document: its knowledge base is a whole-KB
+// target, or a knowledge target of that knowledge base lists the document
+func (st SearchTargets) ContainsKnowledge(kbID string, knowledgeID string) bool {
+	for _, t := range st {
+		if t.KnowledgeBaseID != kbID {
+			continue
+		}
+		if t.Type == SearchTargetTypeKnowledgeBase || slices.Contains(t.KnowledgeIDs, knowledgeID) {
+			return true
+		}
+	}
+	return false
+}
+
 // SearchResult represents the search result
 type SearchResult struct {
 	// ID
@@ -129,6 +148,172 @@ type SearchResult struct {
 
 	// KnowledgeBaseID is the ID of the knowledge base this result belongs to
 	KnowledgeBaseID string `json:"knowledge_base_id,omitempty"`
+
+	// RetrievalBoost is the parent document's score multiplier (see Knowledge.RetrievalBoost)
+	RetrievalBoost float64 `json:"retrieval_boost,omitempty"`
+
+	// Score breakdown, informational only: ranking always uses Score.
+	// VectorScore is the raw similarity from vector retrieval (nil when not a vector hit)
+	VectorScore *float64 `json:"vector_score,omitempty"`
+	// KeywordScore is the raw score from keyword retrieval (nil when not a keyword hit)
+	KeywordScore *float64 `json:"keyword_score,omitempty"`
+	// RerankScore is the relevance score returned by the rerank model (nil when not reranked)
+	RerankScore *float64 `json:"rerank_score,omitempty"`
+	// ThresholdApplied is the retrieval threshold the result passed: the vector threshold for
+	// vector hits, otherwise the keyword threshold
+	ThresholdApplied *float64 `json:"threshold_applied,omitempty"`
+
+	// Document holds document-level fields for rendering citations, only set when requested
+	// through include_metadata
+	Document *SearchResultDocument `json:"document,omitempty"`
+}
+
+// SearchResultDocument holds the core fields and selected metadata of the knowledge a search result comes from
+type SearchResultDocument struct {
+	Title     string            `json:"title"`
+	FileName  string            `json:"file_name,omitempty"`
+	FileType  string            `json:"file_type,omitempty"`
+	Source    string            `json:"source,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+}
+
+// IncludeAllMetadata selects every metadata key of the knowledge in include_metadata
+const IncludeAllMetadata = "*"
+
+// MaxIncludeMetadataKeys is the maximum number of metadata keys that can be requested in include_metadata
+const MaxIncludeMetadataKeys = 20
+
+// ValidateIncludeMetadata checks that the requested metadata keys are well-formed
+func ValidateIncludeMetadata(keys []string) error {
+	if len(keys) > MaxIncludeMetadataKeys {
+		return fmt.Errorf("at most %d include_metadata keys are allowed", MaxIncludeMetadataKeys)
+	}
+	for _, key := range keys {
+		if key != IncludeAllMetadata && !metadataFilterKeyPattern.MatchString(key) {
+			return fmt.Errorf("invalid include_metadata key %q: use 1-64 letters, digits, '_', '.' or '-'", key)
+		}
+	}
+	return nil
+}
+
+// NewSearchResultDocument returns the document fields of a knowledge with only the requested metadata keys
+func NewSearchResultDocument(knowledge *Knowledge, includeMetadata []string) *SearchResultDocument {
+	doc := &SearchResultDocument{
+		Title:     knowledge.Title,
+		FileName:  knowledge.FileName,
+		FileType:  knowledge.FileType,
+		Source:    knowledge.Source,
+		CreatedAt: knowledge.CreatedAt,
+		UpdatedAt: knowledge.UpdatedAt,
+	}
+	metadata := knowledge.GetMetadata()
+	if slices.Contains(includeMetadata, IncludeAllMetadata) {
+		if len(metadata) > 0 {
+			doc.Metadata = metadata
+		}
+		return doc
+	}
+	for _, key := range includeMetadata {
+		if value, ok := metadata[key]; ok {
+			if doc.Metadata == nil {
+				doc.Metadata = make(map[string]string, len(includeMetadata))
+			}
+			doc.Metadata[key] = value
+		}
+	}
+	return doc
+}
+
+// AttachSearchResultDocuments sets the document fields of each result whose knowledge is in knowledgeMap
+func AttachSearchResultDocuments(results []*SearchResult,
+	knowledgeMap map[string]*Knowledge, includeMetadata []string,
+) {
+	for _, result := range results {
+		if knowledge, ok := knowledgeMap[result.KnowledgeID]; ok {
+			result.Document = NewSearchResultDocument(knowledge, includeMetadata)
+		}
+	}
+}
+
+// Metadata filter limits for retrieval requests
+const (
+	// MaxMetadataFilters is the maximum number of metadata filters in a single request
+	MaxMetadataFilters = 10
+	// MaxMetadataFilterValueLength is the maximum length of a metadata filter value
+	MaxMetadataFilterValueLength = 256
+)
+
+// metadataFilterKeyPattern restricts filter keys to simple identifiers usable as JSON paths
+var metadataFilterKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// ValidateMetadataFilters checks that metadata filter keys and values are well-formed
+func ValidateMetadataFilters(filters map[string]string) error {
+	if len(filters) > MaxMetadataFilters {
+		return fmt.Errorf("at most %d metadata filters are allowed", MaxMetadataFilters)
+	}
+	for key, value := range filters {
+		if !metadataFilterKeyPattern.MatchString(key) {
+			return fmt.Errorf("invalid metadata filter key %q: use 1-64 letters, digits, '_', '.' or '-'", key)
+		}
+		if value == "" || len(value) > MaxMetadataFilterValueLength {
+			return fmt.Errorf("metadata filter value for %q must be 1-%d characters", key, MaxMetadataFilterValueLength)
+		}
+	}
+	return nil
+}
+
+// RetrievalFilters restricts which documents retrieval may draw candidates from.
+// Filters only narrow the candidate set; similarity thresholds still apply to what remains.
+type RetrievalFilters struct {
+	// MetadataFilters requires document metadata to match every key/value pair
+	MetadataFilters map[string]string `json:"metadata_filters,omitempty"`
+	// CreatedAfter keeps only documents created at or after this time
+	CreatedAfter *time.Time `json:"created_after,omitempty"`
+	// CreatedBefore keeps only documents created before this time
+	CreatedBefore *time.Time `json:"created_before,omitempty"`
+}
+
+// IsEmpty reports whether no filter is set
+func (f *RetrievalFilters) IsEmpty() bool {
+	return f == nil || (len(f.MetadataFilters) == 0 && !f.HasDateRange())
+}
+
+// HasDateRange reports whether a creation date bound is set
+func (f *RetrievalFilters) HasDateRange() bool {
+	return f != nil && (f.CreatedAfter != nil || f.CreatedBefore != nil)
+}
+
+// Validate checks metadata filters and that the date range is not inverted
+func (f *RetrievalFilters) Validate() error {
+	if f == nil {
+		return nil
+	}
+	if err := ValidateMetadataFilters(f.MetadataFilters); err != nil {
+		return err
+	}
+	if f.CreatedAfter != nil && f.CreatedBefore != nil && !f.CreatedAfter.Before(*f.CreatedBefore) {
+		return fmt.Errorf("created_after must be earlier than created_before")
+	}
+	return nil
+}
+
+// Clone returns a copy of the filters that shares no mutable state
+func (f *RetrievalFilters) Clone() RetrievalFilters {
+	if f == nil {
+		return RetrievalFilters{}
+	}
+	clone := RetrievalFilters{MetadataFilters: maps.Clone(f.MetadataFilters)}
+	if f.CreatedAfter != nil {
+		after := *f.CreatedAfter
+		clone.CreatedAfter = &after
+	}
+	if f.CreatedBefore != nil {
+		before := *f.CreatedBefore
+		clone.CreatedBefore = &before
+	}
+	return clone
 }
 
 // SearchParams represents the search parameters
@@ -146,6 +331,9 @@ type SearchParams struct {
 	// in processSearchResults. Used by the chat pipeline where context assembly
 	// is handled separately in the merge stage.
 	SkipContextEnrichment bool `json:"skip_context_enrichment,omitempty"`
+	// IncludeMetadata lists the knowledge metadata keys returned in each result's document ("*" for all).
+	// Document fields are only returned when it is set.
+	IncludeMetadata []string `json:"include_metadata,omitempty"`
 }
 
 // Value implements the driver.Valuer interface, used to convert SearchResult to database value
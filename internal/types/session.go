@@ -3,6 +3,7 @@ package types
 import (
 	"database/sql/driver"
 	"encoding/json"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
@@ -45,6 +46,138 @@ type SummaryConfig struct {
 	MaxCompletionTokens int `json:"max_completion_tokens"`
 	// Thinking - whether to enable thinking mode
 	Thinking *bool `json:"thinking"`
+	// Response format: "text" or "json_object"
+	ResponseFormat string `json:"response_format"`
+	// Optional JSON schema for the answer (only used in JSON mode)
+	ResponseSchema string `json:"response_schema"`
+	// Few-shot examples injected as prior turns after the system prompt
+	FewShotExamples []ChatExample `json:"few_shot_examples,omitempty"`
+	// Verbosity of the answer, appended to the system prompt as an instruction
+	Verbosity AnswerVerbosity `json:"verbosity,omitempty"`
+}
+
+// AnswerVerbosity is a coarse control over the length and detail of an answer
+type AnswerVerbosity string
+
+const (
+	// AnswerVerbosityBrief asks for a short, direct answer and caps the completion tokens
+	AnswerVerbosityBrief AnswerVerbosity = "brief"
+	// AnswerVerbosityNormal leaves the answer length to the prompt and model
+	AnswerVerbosityNormal AnswerVerbosity = "normal"
+	// AnswerVerbosityDetailed asks for a thorough answer
+	AnswerVerbosityDetailed AnswerVerbosity = "detailed"
+)
+
+// BriefAnswerMaxCompletionTokens is the completion token cap for brief answers
+const BriefAnswerMaxCompletionTokens = 512
+
+// IsValid reports whether v is empty or a known verbosity
+func (v AnswerVerbosity) IsValid() bool {
+	switch v {
+	case "", AnswerVerbosityBrief, AnswerVerbosityNormal, AnswerVerbosityDetailed:
+		return true
+	}
+	return false
+}
+
+// Instruction returns the system prompt suffix for the verbosity, empty for normal
+func (v AnswerVerbosity) Instruction() string {
+	switch v {
+	case AnswerVerbosityBrief:
+		return "Answer briefly and directly, in a few sentences at most. " +
+			"Skip background, caveats and restating the question unless they are essential."
+	case AnswerVerbosityDetailed:
+		return "Give a detailed and thorough answer. Explain the reasoning, cover the relevant aspects " +
+			"found in the context and include examples or steps where they help."
+	}
+	return ""
+}
+
+// ApplyVerbosity sets the answer verbosity and caps the completion tokens for brief answers.
+// An existing lower cap is kept.
+func (c *SummaryConfig) ApplyVerbosity(v AnswerVerbosity) {
+	c.Verbosity = v
+	if v == AnswerVerbosityBrief &&
+		(c.MaxCompletionTokens <= 0 || c.MaxCompletionTokens > BriefAnswerMaxCompletionTokens) {
+		c.MaxCompletionTokens = BriefAnswerMaxCompletionTokens
+	}
+}
+
+// QARequest is a question answered by KnowledgeQA or AgentQA together with its per-request options
+type QARequest struct {
+	Session *Session
+	Query   string
+	// AssistantMessageID is the message the answer is written to
+	AssistantMessageID string
+	// KnowledgeBaseIDs and KnowledgeIDs are the knowledge bases and files the request mentions
+	KnowledgeBaseIDs []string
+	KnowledgeIDs     []string
+	// SummaryModelID overrides the session, knowledge base or agent model when set
+	SummaryModelID string
+	// CustomAgent overrides the tenant defaults; optional for KnowledgeQA
+	CustomAgent *CustomAgent
+	// Sampling overrides temperature/top_p for this request only
+	Sampling *SamplingOverride
+
+	// Filters are the metadata and creation date filters retrieved documents must match (KnowledgeQA)
+	Filters *RetrievalFilters
+	// IncludeMetadata lists the knowledge metadata keys returned with each reference, "*" for all (KnowledgeQA)
+	IncludeMetadata []string
+	// WebSearchEnabled supplements the knowledge base results with web search (KnowledgeQA)
+	WebSearchEnabled bool
+	// EnableMemory enables the memory feature for this request (KnowledgeQA)
+	EnableMemory bool
+	// Verbosity of the answer; empty keeps the configured behavior (KnowledgeQA)
+	Verbosity AnswerVerbosity
+
+	// DisabledTools are removed for this request only; web_search also disables web search (AgentQA)
+	DisabledTools []string
+}
+
+// Ranges accepted for per-request sampling overrides
+const (
+	MaxSamplingTemperature = 2.0
+	MaxSamplingTopP        = 1.0
+)
+
+// SamplingOverride holds sampling parameters set on a single chat request. They take precedence over
+// the tenant and agent configuration for that request only; nil fields keep the resolved value.
+type SamplingOverride struct {
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"top_p,omitempty"`
+}
+
+// Validate checks that temperature is in (0, 2] and top_p in (0, 1].
+// Zero is rejected because model clients treat it as "not set".
+func (o *SamplingOverride) Validate() error {
+	if o == nil {
+		return nil
+	}
+	if o.Temperature != nil && (*o.Temperature <= 0 || *o.Temperature > MaxSamplingTemperature) {
+		return errors.New("temperature must be greater than 0 and at most 2")
+	}
+	if o.TopP != nil && (*o.TopP <= 0 || *o.TopP > MaxSamplingTopP) {
+		return errors.New("top_p must be greater than 0 and at most 1")
+	}
+	return nil
+}
+
+// IsEmpty reports whether no sampling parameter is overridden
+func (o *SamplingOverride) IsEmpty() bool {
+	return o == nil || (o.Temperature == nil && o.TopP == nil)
+}
+
+// ApplySampling overrides temperature and top_p with the request values
+func (c *SummaryConfig) ApplySampling(o *SamplingOverride) {
+	if o == nil {
+		return
+	}
+	if o.Temperature != nil {
+		c.Temperature = *o.Temperature
+	}
+	if o.TopP != nil {
+		c.TopP = *o.TopP
+	}
 }
 
 // ContextCompressionStrategy represents the strategy for context compression
@@ -71,6 +204,41 @@ type ContextConfig struct {
 	SummarizeThreshold int `json:"summarize_threshold"`
 }
 
+// Upper bounds accepted for user-supplied ContextConfig values
+const (
+	MaxContextTokens             = 1024 * 1024
+	MaxContextRecentMessageCount = 1000
+	MaxContextSummarizeThreshold = 1000
+)
+
+// Merge returns a copy of c with every non-zero field of override applied on top.
+// A nil receiver or override yields a copy of the other config (nil when both are nil).
+func (c *ContextConfig) Merge(override *ContextConfig) *ContextConfig {
+	if c == nil && override == nil {
+		return nil
+	}
+	merged := &ContextConfig{}
+	if c != nil {
+		*merged = *c
+	}
+	if override == nil {
+		return merged
+	}
+	if override.MaxTokens > 0 {
+		merged.MaxTokens = override.MaxTokens
+	}
+	if override.CompressionStrategy != "" {
+		merged.CompressionStrategy = override.CompressionStrategy
+	}
+	if override.RecentMessageCount > 0 {
+		merged.RecentMessageCount = override.RecentMessageCount
+	}
+	if override.SummarizeThreshold > 0 {
+		merged.SummarizeThreshold = override.SummarizeThreshold
+	}
+	return merged
+}
+
 // Session represents the session
 type Session struct {
 	// ID
@@ -83,6 +251,13 @@ type Session struct {
 	TenantID uint64 `json:"tenant_id"   gorm:"index"`
 	// External user ID
 	ExternalUserId string `json:"external_user_id"`
+	// Custom agent the session was started with, empty for the default agent
+	AgentID string `json:"agent_id"    gorm:"type:varchar(36);index"`
+	// Whether the title is never generated automatically
+	AutoTitleDisabled bool `json:"auto_title_disabled" gorm:"default:false"`
+	// Knowledge bases pinned to this session, always searched in addition to the resolved set.
+	// Only written through the pinned-kbs endpoint.
+	PinnedKnowledgeBaseIDs StringArray `json:"pinned_knowledge_base_ids" gorm:"type:json"`
 
 	// // Strategy configuration
 	// KnowledgeBaseID   string              `json:"knowledge_base_id"`                    // 关联的知识库ID
@@ -109,11 +284,39 @@ type Session struct {
 	Messages []Message `json:"-" gorm:"foreignKey:SessionID"`
 }
 
+// NeedsAutoTitle reports whether a title should be generated for the session after a question
+func (s *Session) NeedsAutoTitle() bool {
+	return s.Title == "" && !s.AutoTitleDisabled
+}
+
 func (s *Session) BeforeCreate(tx *gorm.DB) (err error) {
 	s.ID = uuid.New().String()
 	return nil
 }
 
+// WithPinnedKnowledgeBases returns kbIDs with the session's pinned knowledge bases appended,
+// skipping IDs that are already present.
+func (s *Session) WithPinnedKnowledgeBases(kbIDs []string) []string {
+	if s == nil || len(s.PinnedKnowledgeBaseIDs) == 0 {
+		return kbIDs
+	}
+	seen := make(map[string]bool, len(kbIDs)+len(s.PinnedKnowledgeBaseIDs))
+	merged := make([]string, 0, len(kbIDs)+len(s.PinnedKnowledgeBaseIDs))
+	for _, id := range kbIDs {
+		if !seen[id] {
+			seen[id] = true
+			merged = append(merged, id)
+		}
+	}
+	for _, id := range s.PinnedKnowledgeBaseIDs {
+		if !seen[id] {
+			seen[id] = true
+			merged = append(merged, id)
+		}
+	}
+	return merged
+}
+
 // StringArray represents a list of strings
 type StringArray []string
 
@@ -0,0 +1,51 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+)
+
+// SummaryModelDefaults holds the tenant-level default summary (chat) model per knowledge base type.
+// It is consulted when a knowledge base has no explicit SummaryModelID, so that e.g. FAQ answering
+// can be routed to a cheaper model than document QA.
+//
+// Stored as a JSONB column on the tenants table, managed via /tenants/kv/summary-model-defaults.
+type SummaryModelDefaults struct {
+	// Document is the default summary model ID for document knowledge bases
+	Document string `json:"document"`
+	// FAQ is the default summary model ID for FAQ knowledge bases
+	FAQ string `json:"faq"`
+}
+
+// ModelIDForType returns the default summary model ID configured for the given knowledge base type.
+// An empty type is treated as a document knowledge base.
+func (d *SummaryModelDefaults) ModelIDForType(kbType string) string {
+	if d == nil {
+		return ""
+	}
+	switch kbType {
+	case KnowledgeBaseTypeFAQ:
+		return d.FAQ
+	case KnowledgeBaseTypeDocument, "":
+		return d.Document
+	default:
+		return ""
+	}
+}
+
+// Value implements the driver.Valuer interface for database serialization
+func (d SummaryModelDefaults) Value() (driver.Value, error) {
+	return json.Marshal(d)
+}
+
+// Scan implements the sql.Scanner interface for database deserialization
+func (d *SummaryModelDefaults) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, d)
+}
@@ -1,6 +1,10 @@
 package types
 
-import "time"
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
 
 // KnowledgeTag represents a tag (category) under a specific knowledge base.
 // Tags are scoped by knowledge base (and tenant) and are used to categorize
@@ -16,7 +20,7 @@ type KnowledgeTag struct {
 	KnowledgeBaseID string `json:"knowledge_base_id" gorm:"type:varchar(36);index"`
 	// Tag name, unique within the same knowledge base
 	Name string `json:"name"              gorm:"type:varchar(128);not null"`
-	// Optional display color
+	// Optional display color as a hex code, e.g. #1890ff
 	Color string `json:"color"             gorm:"type:varchar(32)"`
 	// Sort order within the same knowledge base
 	SortOrder int `json:"sort_order"        gorm:"default:0"`
@@ -38,3 +42,14 @@ type TagReferenceCounts struct {
 	KnowledgeCount int64
 	ChunkCount     int64
 }
+
+// tagColorPattern matches #RGB and #RRGGBB hex color codes
+var tagColorPattern = regexp.MustCompile(`^#(?:[0-9A-Fa-f]{3}|[0-9A-Fa-f]{6})$`)
+
+// ValidateTagColor checks that a tag color is empty or a #RGB / #RRGGBB hex code
+func ValidateTagColor(color string) error {
+	if color != "" && !tagColorPattern.MatchString(color) {
+		return fmt.Errorf("invalid tag color %q: use a hex code such as #1890ff", color)
+	}
+	return nil
+}
@@ -107,6 +107,18 @@ type Tenant struct {
 	ChatHistoryConfig *ChatHistoryConfig `yaml:"chat_history_config" json:"chat_history_config" gorm:"type:jsonb"`
 	// Retrieval config: global search/retrieval parameters shared by knowledge search and message search
 	RetrievalConfig *RetrievalConfig `yaml:"retrieval_config" json:"retrieval_config" gorm:"type:jsonb"`
+	// Summary model defaults: default chat model per knowledge base type, used when a KB has no SummaryModelID
+	SummaryModelDefaults *SummaryModelDefaults `yaml:"summary_model_defaults" json:"summary_model_defaults" gorm:"type:jsonb"`
+	// History retention config: per-session message limits enforced by the history pruner
+	HistoryRetentionConfig *HistoryRetentionConfig `yaml:"history_retention_config" json:"history_retention_config" gorm:"type:jsonb"`
+	// Banned words config: phrases masked or halted in streamed answers
+	BannedWordsConfig *BannedWordsConfig `yaml:"banned_words_config" json:"banned_words_config" gorm:"type:jsonb"`
+	// Redaction config: regex matches (e.g. emails, phone numbers) replaced in streamed answers
+	RedactionConfig *RedactionConfig `yaml:"redaction_config" json:"redaction_config" gorm:"type:jsonb"`
+	// Model retry config: overrides the global retry policy for chat, embedding and rerank calls
+	ModelRetryConfig *ModelRetryConfig `yaml:"model_retry_config" json:"model_retry_config" gorm:"type:jsonb"`
+	// Embedding batch size: chunks per embedding request during ingestion, 0 uses the global setting
+	EmbeddingBatchSize int `yaml:"embedding_batch_size" json:"embedding_batch_size" gorm:"default:0"`
 	// Creation time
 	CreatedAt time.Time `yaml:"created_at"          json:"created_at"`
 	// Last updated time
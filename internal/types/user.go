@@ -100,6 +100,9 @@ type UserInfo struct {
 	CanAccessAllTenants bool      `json:"can_access_all_tenants"`
 	CreatedAt           time.Time `json:"created_at"`
 	UpdatedAt           time.Time `json:"updated_at"`
+	// Organizations owned by the user and the cap on them (0 = unlimited); only set on the profile
+	OwnedOrganizationCount *int64 `json:"owned_organization_count,omitempty"`
+	MaxOwnedOrganizations  *int   `json:"max_owned_organizations,omitempty"`
 }
 
 // ToUserInfo converts User to UserInfo (without sensitive data)
@@ -1,11 +1,14 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 
 	pg_query "github.com/pganalyze/pg_query_go/v6"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
 // This file provides comprehensive SQL validation and security features
@@ -72,6 +75,30 @@ Example Usage:
        WithAllowedFunctions("count", "avg", "sum"),
        WithTenantIsolation(tenantID, "sessions"),
    )
+
+8. Bound the number of returned rows:
+   securedSQL, validation, err := ValidateAndSecureSQL(
+       "SELECT id, name FROM knowledge_bases LIMIT 10000",
+       WithSecurityDefaults(tenantID),
+       WithAutoLimit(100),
+   )
+   // the LIMIT is clamped to 100 and validation.LimitClamped is true
+
+9. Restrict selectable/filterable columns per table:
+   parseResult, validation := ValidateSQL(
+       "SELECT u.id, u.name FROM users u WHERE u.age > 18",
+       WithAllowedTables("users"),
+       WithAllowedColumns("users", "id", "name", "age"),
+   )
+   // "SELECT * FROM users" or "SELECT password_hash FROM users" would be rejected
+
+10. Allow a few non-recursive CTEs on top of the security defaults:
+   securedSQL, validation, err := ValidateAndSecureSQL(
+       "WITH kb AS (SELECT id FROM knowledge_bases) SELECT k.id FROM knowledges k JOIN kb ON k.knowledge_base_id = kb.id",
+       WithSecurityDefaults(tenantID),
+       WithAllowedCTEs(2),
+   )
+   // tenant conditions are added inside the CTE body as well as to the main query
 */
 
 // SQLParseResult represents the parsed components of a SELECT SQL statement
@@ -94,8 +121,22 @@ type SQLValidationError struct {
 
 // SQLValidationResult represents the result of SQL validation
 type SQLValidationResult struct {
-	Valid  bool                 `json:"valid"`  // Whether the SQL passed validation
-	Errors []SQLValidationError `json:"errors"` // List of validation errors
+	Valid        bool                 `json:"valid"`                   // Whether the SQL passed validation
+	Errors       []SQLValidationError `json:"errors"`                  // List of validation errors
+	LimitAdded   bool                 `json:"limit_added,omitempty"`   // Whether WithAutoLimit appended a LIMIT clause
+	LimitClamped bool                 `json:"limit_clamped,omitempty"` // Whether WithAutoLimit lowered an existing LIMIT
+	Params       []interface{}        `json:"params,omitempty"`        // Values to bind to placeholders added by WithTenantPlaceholder
+}
+
+// sqlRuleError is returned by statement validation when a rule with its own error type is violated,
+// so ValidateSQL can report that type instead of the generic "statement_validation_error"
+type sqlRuleError struct {
+	errType string
+	message string
+}
+
+func (e *sqlRuleError) Error() string {
+	return e.message
 }
 
 // SQLValidationOption is a function that configures SQL validation
@@ -115,6 +156,13 @@ type sqlValidator struct {
 	// Table validation
 	allowedTables   map[string]bool
 	checkTableNames bool
+	maxTables       int // 0 means unlimited
+	maxJoins        int // 0 means unlimited
+
+	// Column validation
+	allowedColumns   map[string]map[string]bool // table name -> allowed column names
+	checkColumnNames bool
+	columnScopes     []map[string]string // alias -> table name, one scope per nested SELECT
 
 	// Function validation
 	allowedFunctions   map[string]bool
@@ -122,8 +170,10 @@ type sqlValidator struct {
 
 	// Security checks
 	checkInjectionRisk  bool
+	checkComments       bool
 	checkSubqueries     bool
 	checkCTEs           bool
+	maxCTEs             int // > 0 allows up to maxCTEs non-recursive CTEs, overriding checkCTEs
 	checkSystemColumns  bool
 	checkSchemaAccess   bool
 	checkDangerousFuncs bool
@@ -132,10 +182,15 @@ type sqlValidator struct {
 	enableTenantInjection bool
 	tenantID              uint64
 	tablesWithTenantID    map[string]bool
+	useTenantPlaceholder  bool
+	tenantPlaceholder     string // "$1" when set by ValidateAndSecureSQL
 
 	// Soft delete filtering
 	enableSoftDeleteInjection bool
 	tablesWithDeletedAt       map[string]bool
+
+	// Result size bounding
+	autoLimit int // 0 means no automatic LIMIT
 }
 
 // ParseSQL parses a SQL statement using pg_query_go and extracts table names, select fields, and where fields
@@ -227,23 +282,60 @@ func extractTableNamesFromPgQuery(selectStmt *pg_query.SelectStmt) []string {
 	tables := make([]string, 0)
 	tableMap := make(map[string]bool) // Avoid duplicates
 
-	if selectStmt.FromClause == nil {
-		return tables
+	addTables := func(fromItems []*pg_query.Node, cteNames map[string]bool) {
+		for _, fromItem := range fromItems {
+			for _, tableName := range extractTableNamesFromNode(fromItem, cteNames) {
+				if tableName != "" && !tableMap[tableName] {
+					tableMap[tableName] = true
+					tables = append(tables, tableName)
+				}
+			}
+		}
 	}
 
-	for _, fromItem := range selectStmt.FromClause {
-		tableNames := extractTableNamesFromNode(fromItem)
-		for _, tableName := range tableNames {
-			if tableName != "" && !tableMap[tableName] {
-				tableMap[tableName] = true
-				tables = append(tables, tableName)
+	// Tables read by CTE bodies belong to the query, while references to a CTE by name are not tables
+	if selectStmt.WithClause != nil {
+		for i, cte := range selectStmt.WithClause.Ctes {
+			if body := cte.GetCommonTableExpr().GetCtequery().GetSelectStmt(); body != nil {
+				addTables(body.FromClause, cteNamesVisibleTo(selectStmt.WithClause, i))
 			}
 		}
 	}
+	addTables(selectStmt.FromClause, extractCTENames(selectStmt))
 
 	return tables
 }
 
+// cteNamesVisibleTo returns the lowercased names of the CTEs that the i-th CTE body of a WITH
+// clause can reference: those defined before it, or all of them in a WITH RECURSIVE. A
+// non-recursive body never sees itself, so "WITH t AS (SELECT * FROM t)" reads the table t.
+func cteNamesVisibleTo(with *pg_query.WithClause, i int) map[string]bool {
+	names := make(map[string]bool, len(with.Ctes))
+	for j, cte := range with.Ctes {
+		if j >= i && !with.Recursive {
+			break
+		}
+		if expr := cte.GetCommonTableExpr(); expr != nil {
+			names[strings.ToLower(expr.Ctename)] = true
+		}
+	}
+	return names
+}
+
+// extractCTENames returns the lowercased names of the CTEs defined by a SELECT's WITH clause
+func extractCTENames(selectStmt *pg_query.SelectStmt) map[string]bool {
+	if selectStmt.WithClause == nil {
+		return nil
+	}
+	names := make(map[string]bool, len(selectStmt.WithClause.Ctes))
+	for _, cte := range selectStmt.WithClause.Ctes {
+		if expr := cte.GetCommonTableExpr(); expr != nil {
+			names[strings.ToLower(expr.Ctename)] = true
+		}
+	}
+	return names
+}
+
 // extractWhereFromPgQuery extracts WHERE clause fields and text using pg_query parse tree
 func extractWhereFromPgQuery(selectStmt *pg_query.SelectStmt, originalSQL string) ([]string, string) {
 	fields := make([]string, 0)
@@ -269,6 +361,17 @@ func extractWhereFromPgQuery(selectStmt *pg_query.SelectStmt, originalSQL string
 	return fields, whereClause
 }
 
+// ParseSQLToJSON returns the PostgreSQL parse tree of a SQL statement serialized as JSON.
+// It is a debugging aid for seeing how a query was parsed (e.g. which node type caused a
+// rejection); the SQL is only parsed, never executed.
+func ParseSQLToJSON(sql string) (string, error) {
+	tree, err := pg_query.ParseToJSON(sql)
+	if err != nil {
+		return "", fmt.Errorf("SQL parse error: %v", err)
+	}
+	return tree, nil
+}
+
 // extractColumnNamesFromNode recursively extracts column names from a parse tree node
 func extractColumnNamesFromNode(node *pg_query.Node) []string {
 	if node == nil {
@@ -358,8 +461,9 @@ func extractColumnNamesFromNode(node *pg_query.Node) []string {
 	return colNames
 }
 
-// extractTableNamesFromNode recursively extracts table names from a parse tree node
-func extractTableNamesFromNode(node *pg_query.Node) []string {
+// extractTableNamesFromNode recursively extracts table names from a parse tree node.
+// Unqualified references to one of cteNames are skipped.
+func extractTableNamesFromNode(node *pg_query.Node, cteNames map[string]bool) []string {
 	if node == nil {
 		return nil
 	}
@@ -368,7 +472,7 @@ func extractTableNamesFromNode(node *pg_query.Node) []string {
 
 	// Handle RangeVar (table reference)
 	if rangeVar := node.GetRangeVar(); rangeVar != nil {
-		if rangeVar.Relname != "" {
+		if rangeVar.Relname != "" && !isCTEReference(rangeVar, cteNames) {
 			tableNames = append(tableNames, rangeVar.Relname)
 		}
 		return tableNames
@@ -376,8 +480,8 @@ func extractTableNamesFromNode(node *pg_query.Node) []string {
 
 	// Handle JoinExpr (JOIN)
 	if joinExpr := node.GetJoinExpr(); joinExpr != nil {
-		tableNames = append(tableNames, extractTableNamesFromNode(joinExpr.Larg)...)
-		tableNames = append(tableNames, extractTableNamesFromNode(joinExpr.Rarg)...)
+		tableNames = append(tableNames, extractTableNamesFromNode(joinExpr.Larg, cteNames)...)
+		tableNames = append(tableNames, extractTableNamesFromNode(joinExpr.Rarg, cteNames)...)
 		return tableNames
 	}
 
@@ -390,6 +494,12 @@ func extractTableNamesFromNode(node *pg_query.Node) []string {
 	return tableNames
 }
 
+// isCTEReference reports whether a table reference names a CTE rather than a table.
+// A schema-qualified name always refers to a table, even if a CTE has the same name.
+func isCTEReference(rangeVar *pg_query.RangeVar, cteNames map[string]bool) bool {
+	return rangeVar.Schemaname == "" && cteNames[strings.ToLower(rangeVar.Relname)]
+}
+
 // extractWhereClauseText extracts the WHERE clause text from the original SQL
 func extractWhereClauseText(sql string) string {
 	lowerSQL := strings.ToLower(sql)
@@ -425,6 +535,44 @@ func WithAllowedTables(tables ...string) SQLValidationOption {
 	}
 }
 
+// WithMaxTables limits the number of table references in a query. References are counted over the
+// whole statement, CTE bodies and subqueries included, and a table referenced twice (e.g. in a
+// self-join) counts twice.
+func WithMaxTables(n int) SQLValidationOption {
+	return func(v *sqlValidator) {
+		v.maxTables = n
+	}
+}
+
+// WithMaxJoins limits the number of joins in a query, counting both explicit JOINs
+// and implicit joins from comma-separated FROM items over the whole statement, CTE bodies
+// and subqueries included
+func WithMaxJoins(n int) SQLValidationOption {
+	return func(v *sqlValidator) {
+		v.maxJoins = n
+	}
+}
+
+// WithAllowedColumns creates a validation option that restricts the columns referenced on a table.
+// Once a table has a column whitelist, only the listed columns can be selected, filtered,
+// grouped or sorted on, and SELECT * over that table is rejected. Tables without a whitelist
+// are unaffected. It can be applied multiple times to whitelist columns of several tables.
+func WithAllowedColumns(table string, cols ...string) SQLValidationOption {
+	return func(v *sqlValidator) {
+		v.checkColumnNames = true
+		if v.allowedColumns == nil {
+			v.allowedColumns = make(map[string]map[string]bool)
+		}
+		tableName := strings.ToLower(table)
+		if v.allowedColumns[tableName] == nil {
+			v.allowedColumns[tableName] = make(map[string]bool)
+		}
+		for _, col := range cols {
+			v.allowedColumns[tableName][strings.ToLower(col)] = true
+		}
+	}
+}
+
 // WithInjectionRiskCheck creates a validation option that checks for SQL injection risks
 func WithInjectionRiskCheck() SQLValidationOption {
 	return func(v *sqlValidator) {
@@ -432,6 +580,15 @@ func WithInjectionRiskCheck() SQLValidationOption {
 	}
 }
 
+// WithNoComments blocks SQL comments ('--' and '/* */') in the raw input.
+// Comments are a classic way to truncate or split injected payloads, but they are also
+// legitimate in hand-written SQL, so this is opt-in.
+func WithNoComments() SQLValidationOption {
+	return func(v *sqlValidator) {
+		v.checkComments = true
+	}
+}
+
 // WithInputValidation enables basic input validation (length, null bytes, etc.)
 func WithInputValidation(minLen, maxLen int) SQLValidationOption {
 	return func(v *sqlValidator) {
@@ -534,6 +691,16 @@ func WithNoCTEs() SQLValidationOption {
 	}
 }
 
+// WithAllowedCTEs permits up to max non-recursive CTEs per query, even when WithNoCTEs (or
+// WithSecurityDefaults) is also given. Each CTE body must be a plain SELECT and is validated like
+// the main query, and ValidateAndSecureSQL adds the tenant and soft delete conditions to the
+// tables it reads. Recursive CTEs stay blocked, since they can run without bound.
+func WithAllowedCTEs(max int) SQLValidationOption {
+	return func(v *sqlValidator) {
+		v.maxCTEs = max
+	}
+}
+
 // WithNoSystemColumns blocks access to PostgreSQL system columns
 func WithNoSystemColumns() SQLValidationOption {
 	return func(v *sqlValidator) {
@@ -578,6 +745,17 @@ func WithTenantIsolation(tenantID uint64, tables ...string) SQLValidationOption
 	}
 }
 
+// WithTenantPlaceholder makes tenant isolation emit a bind placeholder (tenant_id = $1)
+// instead of the literal tenant ID. The tenant ID is then returned in SQLValidationResult.Params
+// and must be passed as a bound parameter when executing the query, which lets the database
+// reuse the plan across tenants. Queries with bind parameters of their own are rejected with
+// ErrBindParameters. Inline literals remain the default.
+func WithTenantPlaceholder() SQLValidationOption {
+	return func(v *sqlValidator) {
+		v.useTenantPlaceholder = true
+	}
+}
+
 // WithSoftDeleteFilter enables automatic deleted_at IS NULL injection.
 func WithSoftDeleteFilter(tables ...string) SQLValidationOption {
 	return func(v *sqlValidator) {
@@ -598,6 +776,14 @@ func WithSoftDeleteFilter(tables ...string) SQLValidationOption {
 	}
 }
 
+// WithAutoLimit makes ValidateAndSecureSQL bound the number of returned rows to maxRows:
+// a LIMIT is appended to queries without one, and a larger (or non-constant) LIMIT is clamped.
+func WithAutoLimit(maxRows int) SQLValidationOption {
+	return func(v *sqlValidator) {
+		v.autoLimit = maxRows
+	}
+}
+
 // WithSecurityDefaults applies a comprehensive set of security validations
 func WithSecurityDefaults(tenantID uint64) SQLValidationOption {
 	return func(v *sqlValidator) {
@@ -648,15 +834,21 @@ func ValidateSQL(sql string, opts ...SQLValidationOption) (*SQLParseResult, *SQL
 		Errors: make([]SQLValidationError, 0),
 	}
 
-	// Phase 1: Basic input validation
-	if validator.checkInputValidation {
+	// Phase 1: Basic input validation and pre-parse obfuscation scan
+	if validator.checkInputValidation || validator.checkInjectionRisk || validator.checkComments {
 		if err := validator.validateInput(sql); err != nil {
 			validationResult.Valid = false
-			validationResult.Errors = append(validationResult.Errors, SQLValidationError{
+			validationErr := SQLValidationError{
 				Type:    "input_validation_error",
 				Message: "Input validation failed",
 				Details: err.Error(),
-			})
+			}
+			var ruleErr *sqlRuleError
+			if errors.As(err, &ruleErr) {
+				validationErr.Type = ruleErr.errType
+				validationErr.Message = "Potential SQL injection risk detected"
+			}
+			validationResult.Errors = append(validationResult.Errors, validationErr)
 			return nil, validationResult
 		}
 	}
@@ -745,10 +937,26 @@ func ValidateSQL(sql string, opts ...SQLValidationOption) (*SQLParseResult, *SQL
 		// Phase 5: Validate the SELECT statement with deep inspection
 		if err := validator.validateSelectStmt(selectStmt, validationResult); err != nil {
 			validationResult.Valid = false
-			validationResult.Errors = append(validationResult.Errors, SQLValidationError{
+			validationErr := SQLValidationError{
 				Type:    "statement_validation_error",
 				Message: "Statement validation failed",
 				Details: err.Error(),
+			}
+			var ruleErr *sqlRuleError
+			if errors.As(err, &ruleErr) {
+				validationErr.Type = ruleErr.errType
+				validationErr.Message = ruleErr.message
+			}
+			validationResult.Errors = append(validationResult.Errors, validationErr)
+		}
+
+		// Bound the size of the whole statement, CTE bodies and subqueries included
+		if err := validator.validateQuerySize(stmt); err != nil {
+			validationResult.Valid = false
+			validationResult.Errors = append(validationResult.Errors, SQLValidationError{
+				Type:    err.errType,
+				Message: err.message,
+				Details: err.message,
 			})
 		}
 
@@ -779,6 +987,14 @@ func ValidateSQL(sql string, opts ...SQLValidationOption) (*SQLParseResult, *SQL
 	return result, validationResult
 }
 
+// ErrTenantIsolationGap is returned by ValidateAndSecureSQL when tenant isolation is enabled but
+// an allowed or queried table has no registered tenant column, so it would be read unfiltered
+var ErrTenantIsolationGap = errors.New("table has no registered tenant column")
+
+// ErrBindParameters is returned by ValidateAndSecureSQL when WithTenantPlaceholder is used and the
+// query has bind parameters of its own, which would have no values in SQLValidationResult.Params
+var ErrBindParameters = errors.New("query must not contain bind parameters")
+
 // ValidateAndSecureSQL validates SQL and returns a secured version with tenant isolation
 // This is a convenience function that combines validation and SQL rewriting
 func ValidateAndSecureSQL(sql string, opts ...SQLValidationOption) (string, *SQLValidationResult, error) {
@@ -803,8 +1019,21 @@ func ValidateAndSecureSQL(sql string, opts ...SQLValidationOption) (string, *SQL
 		opt(validator)
 	}
 
+	// Refuse to run with a table that tenant conditions would silently skip
+	if validator.enableTenantInjection {
+		if table := validator.tenantIsolationGap(parseResult.TableNames); table != "" {
+			validationResult.Valid = false
+			validationResult.Errors = append(validationResult.Errors, SQLValidationError{
+				Type:    "tenant_isolation_gap",
+				Message: fmt.Sprintf("Table %s is not covered by tenant isolation", table),
+				Details: "Register the table's tenant column with WithTenantIsolation or stop allowing it",
+			})
+			return "", validationResult, fmt.Errorf("%w: %s", ErrTenantIsolationGap, table)
+		}
+	}
+
 	// If no SQL rewriting is enabled, return original SQL
-	if !validator.enableTenantInjection && !validator.enableSoftDeleteInjection {
+	if !validator.enableTenantInjection && !validator.enableSoftDeleteInjection && validator.autoLimit <= 0 {
 		return sql, validationResult, nil
 	}
 
@@ -826,14 +1055,123 @@ func ValidateAndSecureSQL(sql string, opts ...SQLValidationOption) (string, *SQL
 		tablesInQuery[strings.ToLower(tableName)] = strings.ToLower(tableName)
 	}
 
-	// Inject tenant conditions
-	securedSQL := validator.injectTenantConditions(normalizedSQL, tablesInQuery)
-	// Inject deleted_at IS NULL conditions
-	securedSQL = validator.injectSoftDeleteConditions(securedSQL, tablesInQuery)
+	// The tenant ID is the only bound value, so the query can't bring parameters of its own
+	if validator.enableTenantInjection && validator.useTenantPlaceholder {
+		hasParams, err := hasBindParams(normalizedSQL)
+		if err != nil {
+			return "", validationResult, err
+		}
+		if hasParams {
+			validationResult.Valid = false
+			validationResult.Errors = append(validationResult.Errors, SQLValidationError{
+				Type:    "bind_parameter",
+				Message: "Bind parameters such as $1 are not allowed",
+				Details: "Write values into the query as literals",
+			})
+			return "", validationResult, ErrBindParameters
+		}
+		validator.tenantPlaceholder = "$1"
+	}
+
+	var securedSQL string
+	if result.Stmts[0].Stmt.GetSelectStmt().GetWithClause() != nil {
+		// Tables read inside CTE bodies need the conditions as well
+		cteSQL, tenantFiltered, err := validator.secureCTEQuery(normalizedSQL)
+		if err != nil {
+			return "", validationResult, err
+		}
+		securedSQL = cteSQL
+		if validator.tenantPlaceholder != "" && tenantFiltered {
+			validationResult.Params = append(validationResult.Params, validator.tenantID)
+		}
+	} else {
+		// Inject tenant conditions
+		securedSQL = validator.injectTenantConditions(normalizedSQL, tablesInQuery)
+		if validator.tenantPlaceholder != "" && securedSQL != normalizedSQL {
+			validationResult.Params = append(validationResult.Params, validator.tenantID)
+		}
+		// Inject deleted_at IS NULL conditions
+		securedSQL = validator.injectSoftDeleteConditions(securedSQL, tablesInQuery)
+	}
+
+	// Bound the result size last, so the LIMIT is applied to the final statement
+	if validator.autoLimit > 0 {
+		limitedSQL, added, clamped, err := applyAutoLimit(securedSQL, validator.autoLimit)
+		if err != nil {
+			return "", validationResult, err
+		}
+		securedSQL = limitedSQL
+		validationResult.LimitAdded = added
+		validationResult.LimitClamped = clamped
+	}
 
 	return securedSQL, validationResult, nil
 }
 
+// tenantIsolationGap returns the first allowed or queried table without a registered tenant
+// column, or "" if tenant conditions cover them all
+func (v *sqlValidator) tenantIsolationGap(queriedTables []string) string {
+	tables := getMapKeys(v.allowedTables)
+	sort.Strings(tables)
+	for _, table := range queriedTables {
+		tables = append(tables, strings.ToLower(table))
+	}
+	for _, table := range tables {
+		if !v.tablesWithTenantID[table] {
+			return table
+		}
+	}
+	return ""
+}
+
+// applyAutoLimit rewrites a SELECT so it returns at most maxRows rows, by appending a LIMIT
+// or lowering an existing one through the parse tree. It reports whether the limit was
+// added or clamped; the SQL is returned unchanged if neither was needed.
+func applyAutoLimit(sql string, maxRows int) (string, bool, bool, error) {
+	tree, err := pg_query.Parse(sql)
+	if err != nil {
+		return "", false, false, fmt.Errorf("failed to parse SQL: %v", err)
+	}
+	if len(tree.Stmts) == 0 {
+		return sql, false, false, nil
+	}
+	stmt := tree.Stmts[0].Stmt.GetSelectStmt()
+	if stmt == nil {
+		return sql, false, false, nil
+	}
+
+	added, clamped := false, false
+	if stmt.LimitCount == nil {
+		added = true
+		stmt.LimitOption = pg_query.LimitOption_LIMIT_OPTION_COUNT
+	} else if limit, ok := constLimitValue(stmt.LimitCount); !ok || limit > maxRows {
+		// LIMIT ALL, parameters and expressions cannot be checked, so they are clamped too
+		clamped = true
+	} else {
+		return sql, false, false, nil
+	}
+	stmt.LimitCount = pg_query.MakeAConstIntNode(int64(maxRows), -1)
+
+	limitedSQL, err := pg_query.Deparse(tree)
+	if err != nil {
+		return "", false, false, fmt.Errorf("failed to apply LIMIT: %v", err)
+	}
+	return limitedSQL, added, clamped, nil
+}
+
+// constLimitValue returns the value of a constant integer LIMIT
+func constLimitValue(node *pg_query.Node) (int, bool) {
+	ac := node.GetAConst()
+	if ac == nil || ac.Isnull {
+		return 0, false
+	}
+	ival := ac.GetIval()
+	if ival == nil {
+		return 0, false
+	}
+	return int(ival.Ival), true
+}
+
 // InjectAndConditions injects filter conditions into a SQL statement using AND semantics.
 // If WHERE exists, the original WHERE predicates will be wrapped in parentheses.
 func InjectAndConditions(sql, filter string) string {
@@ -846,7 +1184,7 @@ func InjectAndConditions(sql, filter string) string {
 	wherePattern := regexp.MustCompile(`(?i)\bWHERE\b`)
 	if wherePattern.MatchString(sql) {
 		// Add filter and wrap existing conditions in parentheses to prevent OR precedence issues
-		return wherePattern.ReplaceAllString(sql, fmt.Sprintf("WHERE %s AND (", filter)) + ")"
+		return wherePattern.ReplaceAllLiteralString(sql, fmt.Sprintf("WHERE %s AND (", filter)) + ")"
 	}
 
 	// Add new WHERE clause before ORDER BY, GROUP BY, LIMIT, etc.
@@ -869,11 +1207,7 @@ func (v *sqlValidator) injectTenantConditions(sql string, tablesInQuery map[stri
 	var conditions []string
 	for tableName, alias := range tablesInQuery {
 		if v.tablesWithTenantID[tableName] {
-			if tableName == "tenants" {
-				conditions = append(conditions, fmt.Sprintf("%s.id = %d", alias, v.tenantID))
-			} else {
-				conditions = append(conditions, fmt.Sprintf("%s.tenant_id = %d", alias, v.tenantID))
-			}
+			conditions = append(conditions, v.tenantCondition(tableName, alias))
 		}
 	}
 
@@ -885,6 +1219,130 @@ func (v *sqlValidator) injectTenantConditions(sql string, tablesInQuery map[stri
 	return InjectAndConditions(sql, tenantFilter)
 }
 
+// tenantCondition returns the condition restricting a table, referenced by alias, to the tenant
+func (v *sqlValidator) tenantCondition(tableName, alias string) string {
+	tenantValue := fmt.Sprintf("%d", v.tenantID)
+	if v.tenantPlaceholder != "" {
+		tenantValue = v.tenantPlaceholder
+	}
+	if tableName == "tenants" {
+		return fmt.Sprintf("%s.id = %s", alias, tenantValue)
+	}
+	return fmt.Sprintf("%s.tenant_id = %s", alias, tenantValue)
+}
+
+// secureCTEQuery adds the tenant and soft delete conditions to every SELECT of a query with
+// CTEs, i.e. each CTE body and the main query, through the parse tree. Text-based injection
+// can't be used there since the query has several WHERE clauses. It reports whether a tenant
+// condition was added.
+func (v *sqlValidator) secureCTEQuery(sql string) (string, bool, error) {
+	tree, err := pg_query.Parse(sql)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to parse SQL: %v", err)
+	}
+	stmt := tree.Stmts[0].Stmt.GetSelectStmt()
+	// Each SELECT is secured with the CTE names it can see, so a CTE named after the table it
+	// reads still gets the conditions in its body
+	type scopedSelect struct {
+		sel      *pg_query.SelectStmt
+		cteNames map[string]bool
+	}
+	selects := []scopedSelect{{stmt, extractCTENames(stmt)}}
+	for i, cte := range stmt.WithClause.Ctes {
+		if body := cte.GetCommonTableExpr().GetCtequery().GetSelectStmt(); body != nil {
+			selects = append(selects, scopedSelect{body, cteNamesVisibleTo(stmt.WithClause, i)})
+		}
+	}
+
+	tenantFiltered := false
+	for _, scoped := range selects {
+		sel := scoped.sel
+		tables := make(map[string]string) // alias -> table name
+		for _, fromItem := range sel.FromClause {
+			collectTableAliases(fromItem, scoped.cteNames, tables)
+		}
+		aliases := make([]string, 0, len(tables))
+		for alias := range tables {
+			aliases = append(aliases, alias)
+		}
+		sort.Strings(aliases)
+
+		var conditions []string
+		for _, alias := range aliases {
+			tableName := tables[alias]
+			if v.enableTenantInjection && v.tablesWithTenantID[tableName] {
+				conditions = append(conditions, v.tenantCondition(tableName, alias))
+				tenantFiltered = true
+			}
+			if v.enableSoftDeleteInjection && v.tablesWithDeletedAt[tableName] {
+				conditions = append(conditions, fmt.Sprintf("%s.deleted_at IS NULL", alias))
+			}
+		}
+		if err := addWhereConditions(sel, conditions); err != nil {
+			return "", false, err
+		}
+	}
+
+	securedSQL, err := pg_query.Deparse(tree)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to secure SQL: %v", err)
+	}
+	return securedSQL, tenantFiltered, nil
+}
+
+// collectTableAliases maps the alias of each table in a FROM clause item to the table name,
+// skipping references to CTEs
+func collectTableAliases(node *pg_query.Node, cteNames map[string]bool, tables map[string]string) {
+	if rv := node.GetRangeVar(); rv != nil {
+		if isCTEReference(rv, cteNames) {
+			return
+		}
+		tableName := strings.ToLower(rv.Relname)
+		alias := tableName
+		if rv.Alias != nil && rv.Alias.Aliasname != "" {
+			alias = strings.ToLower(rv.Alias.Aliasname)
+		}
+		tables[alias] = tableName
+		return
+	}
+	if je := node.GetJoinExpr(); je != nil {
+		collectTableAliases(je.Larg, cteNames, tables)
+		collectTableAliases(je.Rarg, cteNames, tables)
+	}
+}
+
+// addWhereConditions ANDs conditions into a SELECT's WHERE clause
+func addWhereConditions(stmt *pg_query.SelectStmt, conditions []string) error {
+	if len(conditions) == 0 {
+		return nil
+	}
+	filterTree, err := pg_query.Parse("SELECT 1 WHERE " + strings.Join(conditions, " AND "))
+	if err != nil {
+		return fmt.Errorf("failed to build filter: %v", err)
+	}
+	filter := filterTree.Stmts[0].Stmt.GetSelectStmt().WhereClause
+	if stmt.WhereClause != nil {
+		filter = pg_query.MakeBoolExprNode(pg_query.BoolExprType_AND_EXPR, []*pg_query.Node{filter, stmt.WhereClause}, -1)
+	}
+	stmt.WhereClause = filter
+	return nil
+}
+
+// hasBindParams reports whether the query uses bind parameters ($n).
+// The scanner is used so that "$1" inside string literals is not mistaken for a parameter.
+func hasBindParams(sql string) (bool, error) {
+	scanResult, err := pg_query.Scan(sql)
+	if err != nil {
+		return false, fmt.Errorf("failed to scan SQL: %v", err)
+	}
+	for _, token := range scanResult.Tokens {
+		if token.Token == pg_query.Token_PARAM {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 // injectSoftDeleteConditions adds deleted_at IS NULL filtering to the query.
 func (v *sqlValidator) injectSoftDeleteConditions(sql string, tablesInQuery map[string]string) string {
 	if !v.enableSoftDeleteInjection {
@@ -996,36 +1454,159 @@ func getMapKeys(m map[string]bool) []string {
 	return keys
 }
 
-// validateInput performs basic input validation
+// validateInput performs basic input validation and, when injection or comment checks are
+// enabled, a lexical scan of the raw SQL before it is handed to the parser
 func (v *sqlValidator) validateInput(sql string) error {
-	// Check for null bytes
-	if strings.Contains(sql, "\x00") {
-		return fmt.Errorf("invalid character in SQL query")
-	}
+	if v.checkInputValidation {
+		// Check for null bytes
+		if strings.Contains(sql, "\x00") {
+			return fmt.Errorf("invalid character in SQL query")
+		}
 
-	// Check length limits
-	if len(sql) < v.minLength {
-		return fmt.Errorf("SQL query too short (min %d characters)", v.minLength)
+		// Check length limits
+		if len(sql) < v.minLength {
+			return fmt.Errorf("SQL query too short (min %d characters)", v.minLength)
+		}
+		if len(sql) > v.maxLength {
+			return fmt.Errorf("SQL query too long (max %d characters)", v.maxLength)
+		}
 	}
-	if len(sql) > v.maxLength {
-		return fmt.Errorf("SQL query too long (max %d characters)", v.maxLength)
+
+	if v.checkInjectionRisk || v.checkComments {
+		return v.scanRawSQL(sql)
 	}
+	return nil
+}
 
+// escapeSequencePattern matches hex, unicode and octal escapes inside E'...' strings
+var escapeSequencePattern = regexp.MustCompile(`\\(x[0-9a-f]|u[0-9a-f]|[0-7])`)
+
+// dollarQuotePattern matches the opening tag of a dollar-quoted string ($$ or $tag$)
+var dollarQuotePattern = regexp.MustCompile(`^\$([a-z_][a-z0-9_]*)?\$`)
+
+// scanRawSQL scans the raw SQL text for comments and encoded literals.
+// This runs before pg_query on purpose: the parser discards comments and decodes
+// escape sequences, so by the time we inspect the AST and the deparsed WHERE text,
+// a payload hidden as U&'\0031', E'\x31', X'31', 0x31 or split by /* */ has already
+// been normalized and the pattern-based checks in checkSQLInjectionRisks cannot see
+// how it was written. Rejecting these forms up front is defense in depth; none of them
+// are needed by the queries this validator is meant to accept.
+// String literals and quoted identifiers are skipped, so '--' inside a value is fine.
+func (v *sqlValidator) scanRawSQL(sql string) error {
+	lower := strings.ToLower(sql)
+	n := len(lower)
+	for i := 0; i < n; i++ {
+		c := lower[i]
+		afterIdent := i > 0 && isSQLIdentChar(lower[i-1])
+		next := byte(0)
+		if i+1 < n {
+			next = lower[i+1]
+		}
+
+		switch {
+		case c == '\'':
+			i = skipSQLQuoted(lower, i, '\'', false)
+		case c == '"':
+			i = skipSQLQuoted(lower, i, '"', false)
+		case c == '-' && next == '-':
+			if v.checkComments {
+				return &sqlRuleError{errType: "sql_injection_risk", message: "SQL comments ('--') are not allowed"}
+			}
+			for i < n && lower[i] != '\n' {
+				i++
+			}
+		case c == '/' && next == '*':
+			if v.checkComments {
+				return &sqlRuleError{errType: "sql_injection_risk", message: "SQL comments ('/* */') are not allowed"}
+			}
+			if end := strings.Index(lower[i+2:], "*/"); end >= 0 {
+				i += end + 3
+			} else {
+				i = n
+			}
+		case c == 'e' && next == '\'' && !afterIdent:
+			end := skipSQLQuoted(lower, i+1, '\'', true)
+			if v.checkInjectionRisk && escapeSequencePattern.MatchString(lower[i+1:end]) {
+				return &sqlRuleError{
+					errType: "sql_injection_risk",
+					message: "Hex, unicode or octal escape sequences in E'...' strings are not allowed",
+				}
+			}
+			i = end
+		case !v.checkInjectionRisk || afterIdent:
+			continue
+		case c == 'u' && next == '&' && i+2 < n && (lower[i+2] == '\'' || lower[i+2] == '"'):
+			return &sqlRuleError{errType: "sql_injection_risk", message: "Unicode escape literals (U&'...') are not allowed"}
+		case c == 'x' && next == '\'':
+			return &sqlRuleError{errType: "sql_injection_risk", message: "Hex string literals (X'...') are not allowed"}
+		case c == '0' && next == 'x':
+			return &sqlRuleError{errType: "sql_injection_risk", message: "Hex numeric literals (0x...) are not allowed"}
+		case c == '$' && dollarQuotePattern.MatchString(lower[i:]):
+			return &sqlRuleError{errType: "sql_injection_risk", message: "Dollar-quoted strings are not allowed"}
+		}
+	}
 	return nil
 }
 
+// skipSQLQuoted returns the index of the quote closing the literal that starts at start.
+// A doubled quote is an escaped quote; backslash escapes apply only to E'...' strings.
+func skipSQLQuoted(s string, start int, quote byte, backslashEscapes bool) int {
+	for i := start + 1; i < len(s); i++ {
+		if backslashEscapes && s[i] == '\\' {
+			i++
+			continue
+		}
+		if s[i] == quote {
+			if i+1 < len(s) && s[i+1] == quote {
+				i++
+				continue
+			}
+			return i
+		}
+	}
+	return len(s)
+}
+
+// isSQLIdentChar reports whether c can be part of an unquoted identifier or number
+func isSQLIdentChar(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c >= 0x80
+}
+
 // validateSelectStmt validates a SELECT statement with configured options
 func (v *sqlValidator) validateSelectStmt(stmt *pg_query.SelectStmt, result *SQLValidationResult) error {
 	tablesInQuery := make(map[string]string) // table name -> alias
 
+	// Open a column resolution scope for this SELECT (nested SELECTs push their own)
+	if v.checkColumnNames {
+		v.columnScopes = append(v.columnScopes, make(map[string]string))
+		defer func() {
+			v.columnScopes = v.columnScopes[:len(v.columnScopes)-1]
+		}()
+	}
+
 	// Check for UNION/INTERSECT/EXCEPT (compound queries)
 	if stmt.Op != pg_query.SetOperation_SETOP_NONE {
 		return fmt.Errorf("compound queries (UNION/INTERSECT/EXCEPT) are not allowed")
 	}
 
 	// Check for WITH clause (CTEs)
-	if v.checkCTEs && stmt.WithClause != nil {
-		return fmt.Errorf("WITH clause (CTEs) is not allowed")
+	if stmt.WithClause != nil {
+		switch {
+		case v.maxCTEs > 0:
+			if err := v.validateCTEs(stmt.WithClause, result); err != nil {
+				return err
+			}
+		case v.checkCTEs:
+			return fmt.Errorf("WITH clause (CTEs) is not allowed")
+		case v.checkColumnNames:
+			// With column whitelisting, CTE bodies must be validated too, otherwise
+			// a whitelisted table could be read through the CTE without restriction
+			for _, cte := range stmt.WithClause.Ctes {
+				if err := v.validateNestedSelect(cte.GetCommonTableExpr().GetCtequery(), result); err != nil {
+					return err
+				}
+			}
+		}
 	}
 
 	// Check for INTO clause (SELECT INTO)
@@ -1088,6 +1669,113 @@ func (v *sqlValidator) validateSelectStmt(stmt *pg_query.SelectStmt, result *SQL
 	return nil
 }
 
+// validateCTEs validates the CTEs permitted by WithAllowedCTEs: their number, and each body
+// with the same rules as the main query
+func (v *sqlValidator) validateCTEs(with *pg_query.WithClause, result *SQLValidationResult) error {
+	if with.Recursive {
+		return &sqlRuleError{
+			errType: "recursive_cte",
+			message: "Recursive CTEs are not allowed",
+		}
+	}
+	if len(with.Ctes) > v.maxCTEs {
+		return &sqlRuleError{
+			errType: "too_many_ctes",
+			message: fmt.Sprintf("Query defines %d CTEs, at most %d are allowed", len(with.Ctes), v.maxCTEs),
+		}
+	}
+	for _, node := range with.Ctes {
+		cte := node.GetCommonTableExpr()
+		if cte == nil {
+			return fmt.Errorf("unsupported WITH clause item")
+		}
+		// Data-modifying CTEs (INSERT/UPDATE/DELETE ... RETURNING) are rejected here
+		body := cte.GetCtequery().GetSelectStmt()
+		if body == nil {
+			return fmt.Errorf("CTE '%s' must be a SELECT", cte.Ctename)
+		}
+		if body.WithClause != nil {
+			return fmt.Errorf("nested WITH clause in CTE '%s' is not allowed", cte.Ctename)
+		}
+		if err := v.validateSelectStmt(body, result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateQuerySize checks the WithMaxTables and WithMaxJoins limits against the whole statement.
+// They are checked once here rather than per SELECT, so CTE bodies and subqueries share one budget.
+func (v *sqlValidator) validateQuerySize(stmt *pg_query.Node) *sqlRuleError {
+	if v.maxTables <= 0 && v.maxJoins <= 0 {
+		return nil
+	}
+	size := &querySize{cteNames: make(map[string]bool)}
+	size.walk(stmt.ProtoReflect())
+	tables := 0
+	for _, name := range size.tableRefs {
+		// References to a CTE are not tables; the tables of its body are counted there
+		if !size.cteNames[name] {
+			tables++
+		}
+	}
+	if v.maxTables > 0 && tables > v.maxTables {
+		return &sqlRuleError{
+			errType: "too_many_tables",
+			message: fmt.Sprintf("Query references %d tables, at most %d are allowed", tables, v.maxTables),
+		}
+	}
+	if v.maxJoins > 0 && size.joins > v.maxJoins {
+		return &sqlRuleError{
+			errType: "too_many_joins",
+			message: fmt.Sprintf("Query contains %d joins, at most %d are allowed", size.joins, v.maxJoins),
+		}
+	}
+	return nil
+}
+
+// querySize collects the table references, CTE names and joins of a parse tree
+type querySize struct {
+	tableRefs []string // lower-cased, schema-qualified when written so
+	cteNames  map[string]bool
+	joins     int
+}
+
+// walk visits every node of the parse tree
+func (s *querySize) walk(msg protoreflect.Message) {
+	switch n := msg.Interface().(type) {
+	case *pg_query.RangeVar:
+		name := strings.ToLower(n.Relname)
+		if n.Schemaname != "" {
+			name = strings.ToLower(n.Schemaname) + "." + name
+		}
+		s.tableRefs = append(s.tableRefs, name)
+	case *pg_query.CommonTableExpr:
+		s.cteNames[strings.ToLower(n.Ctename)] = true
+	case *pg_query.JoinExpr:
+		s.joins++
+	case *pg_query.SelectStmt:
+		// Comma-separated FROM items are implicit joins
+		if len(n.FromClause) > 1 {
+			s.joins += len(n.FromClause) - 1
+		}
+	}
+	msg.Range(func(fd protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		if fd.Kind() != protoreflect.MessageKind {
+			return true
+		}
+		if fd.IsList() {
+			list := value.List()
+			for i := 0; i < list.Len(); i++ {
+				s.walk(list.Get(i).Message())
+			}
+		} else if !fd.IsMap() {
+			s.walk(value.Message())
+		}
+		return true
+	})
+}
+
 // validateFromItem validates a FROM clause item
 func (v *sqlValidator) validateFromItem(node *pg_query.Node, tables map[string]string, result *SQLValidationResult) error {
 	if node == nil {
@@ -1112,6 +1800,9 @@ func (v *sqlValidator) validateFromItem(node *pg_query.Node, tables map[string]s
 			alias = strings.ToLower(rv.Alias.Aliasname)
 		}
 		tables[tableName] = alias
+		if n := len(v.columnScopes); n > 0 {
+			v.columnScopes[n-1][alias] = tableName
+		}
 		return nil
 	}
 
@@ -1132,8 +1823,13 @@ func (v *sqlValidator) validateFromItem(node *pg_query.Node, tables map[string]s
 	}
 
 	// Handle RangeSubselect (subquery in FROM)
-	if v.checkSubqueries && node.GetRangeSubselect() != nil {
-		return fmt.Errorf("subqueries in FROM clause are not allowed")
+	if rs := node.GetRangeSubselect(); rs != nil {
+		if v.checkSubqueries {
+			return fmt.Errorf("subqueries in FROM clause are not allowed")
+		}
+		if v.checkColumnNames {
+			return v.validateNestedSelect(rs.Subquery, result)
+		}
 	}
 
 	// Handle RangeFunction (function in FROM)
@@ -1348,6 +2044,11 @@ func (v *sqlValidator) validateNode(node *pg_query.Node, result *SQLValidationRe
 		if err := v.validateNode(sl.Testexpr, result); err != nil {
 			return err
 		}
+		if v.checkColumnNames {
+			if err := v.validateNestedSelect(sl.Subselect, result); err != nil {
+				return err
+			}
+		}
 	}
 
 	// OpExpr (operator expressions)
@@ -1774,30 +2475,127 @@ func (v *sqlValidator) validateFuncCall(fc *pg_query.FuncCall, result *SQLValida
 
 // validateColumnRef validates a column reference
 func (v *sqlValidator) validateColumnRef(cr *pg_query.ColumnRef) error {
-	if !v.checkSystemColumns {
+	if v.checkSystemColumns {
+		// Check for system column access
+		for _, field := range cr.Fields {
+			if s := field.GetString_(); s != nil {
+				colName := strings.ToLower(s.Sval)
+				// Block access to system columns
+				systemColumns := []string{"xmin", "xmax", "cmin", "cmax", "ctid", "tableoid"}
+				for _, sysCol := range systemColumns {
+					if colName == sysCol {
+						return fmt.Errorf("access to system column '%s' is not allowed", colName)
+					}
+				}
+				// Block pg_ prefixed identifiers
+				if strings.HasPrefix(colName, "pg_") {
+					return fmt.Errorf("access to '%s' is not allowed", colName)
+				}
+			}
+		}
+	}
+
+	if v.checkColumnNames {
+		return v.validateAllowedColumn(cr)
+	}
+	return nil
+}
+
+// validateAllowedColumn checks a column reference against the per-table column whitelist.
+// Qualified references (alias.col, table.col) are resolved to their table through the
+// current scopes. Unqualified references cannot be attributed to a table without the
+// schema, so they must be allowed on every whitelisted table visible to the query.
+func (v *sqlValidator) validateAllowedColumn(cr *pg_query.ColumnRef) error {
+	if len(cr.Fields) == 0 {
 		return nil
 	}
 
-	// Check for system column access
-	for _, field := range cr.Fields {
-		if s := field.GetString_(); s != nil {
-			colName := strings.ToLower(s.Sval)
-			// Block access to system columns
-			systemColumns := []string{"xmin", "xmax", "cmin", "cmax", "ctid", "tableoid"}
-			for _, sysCol := range systemColumns {
-				if colName == sysCol {
-					return fmt.Errorf("access to system column '%s' is not allowed", colName)
-				}
+	last := cr.Fields[len(cr.Fields)-1]
+	isStar := last.GetAStar() != nil
+	colName := ""
+	if s := last.GetString_(); s != nil {
+		colName = strings.ToLower(s.Sval)
+	}
+
+	if len(cr.Fields) > 1 {
+		qualifier := ""
+		if s := cr.Fields[len(cr.Fields)-2].GetString_(); s != nil {
+			qualifier = strings.ToLower(s.Sval)
+		}
+		// Search from the innermost scope outwards, like PostgreSQL does for correlated references
+		for i := len(v.columnScopes) - 1; i >= 0; i-- {
+			if tableName, ok := v.columnScopes[i][qualifier]; ok {
+				return v.checkColumnAllowed(tableName, colName, isStar)
 			}
-			// Block pg_ prefixed identifiers
-			if strings.HasPrefix(colName, "pg_") {
-				return fmt.Errorf("access to '%s' is not allowed", colName)
+		}
+		return nil
+	}
+
+	for _, scope := range v.columnScopes {
+		for _, tableName := range scope {
+			if err := v.checkColumnAllowed(tableName, colName, isStar); err != nil {
+				return err
 			}
 		}
 	}
 	return nil
 }
 
+// checkColumnAllowed reports whether a column (or *) may be referenced on a table
+func (v *sqlValidator) checkColumnAllowed(tableName, colName string, isStar bool) error {
+	allowed, restricted := v.allowedColumns[tableName]
+	if !restricted {
+		return nil
+	}
+	if isStar {
+		return &sqlRuleError{
+			errType: "column_not_allowed",
+			message: fmt.Sprintf("SELECT * is not allowed on table '%s', list the columns explicitly", tableName),
+		}
+	}
+	if !allowed[colName] {
+		return &sqlRuleError{
+			errType: "column_not_allowed",
+			message: fmt.Sprintf("Column '%s' is not allowed on table '%s'", colName, tableName),
+		}
+	}
+	return nil
+}
+
+// validateNestedSelect validates a subquery or CTE body in its own column scope
+func (v *sqlValidator) validateNestedSelect(node *pg_query.Node, result *SQLValidationResult) error {
+	if node == nil {
+		return nil
+	}
+	stmt := node.GetSelectStmt()
+	if stmt == nil {
+		return nil
+	}
+	// Compound subqueries (UNION, etc.) keep their branches in Larg/Rarg
+	if stmt.Op != pg_query.SetOperation_SETOP_NONE {
+		for _, branch := range []*pg_query.SelectStmt{stmt.Larg, stmt.Rarg} {
+			if branch == nil {
+				continue
+			}
+			if err := v.validateNestedSelect(&pg_query.Node{Node: &pg_query.Node_SelectStmt{SelectStmt: branch}}, result); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	// SELECT without FROM (e.g. a correlated scalar subquery) has no tables of its own,
+	// but its expressions may still reference columns of the outer query
+	if len(stmt.FromClause) == 0 {
+		for _, target := range stmt.TargetList {
+			if err := v.validateNode(target, result); err != nil {
+				return err
+			}
+		}
+		return v.validateNode(stmt.WhereClause, result)
+	}
+	return v.validateSelectStmt(stmt, result)
+}
+
 // getTypeName extracts the type name from a TypeName node
 func (v *sqlValidator) getTypeName(tn *pg_query.TypeName) string {
 	var parts []string
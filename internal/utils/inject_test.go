@@ -2,7 +2,9 @@ package utils
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -323,6 +325,195 @@ func TestValidateSQL_InjectionRisk(t *testing.T) {
 	}
 }
 
+func TestValidateSQL_ObfuscationRisk(t *testing.T) {
+	tests := []struct {
+		name          string
+		sql           string
+		opts          []SQLValidationOption
+		wantValid     bool
+		wantErrorType string
+	}{
+		{
+			name:      "Comment markers inside string literal",
+			sql:       "SELECT * FROM users WHERE name = 'a--b /* c */'",
+			opts:      []SQLValidationOption{WithInjectionRiskCheck(), WithNoComments()},
+			wantValid: true,
+		},
+		{
+			name:      "Line comment allowed without opt-in",
+			sql:       "SELECT * FROM users WHERE age > 18 -- adults",
+			opts:      []SQLValidationOption{WithInjectionRiskCheck()},
+			wantValid: true,
+		},
+		{
+			name:          "Line comment blocked",
+			sql:           "SELECT * FROM users WHERE name = 'admin' --' AND status = 'active'",
+			opts:          []SQLValidationOption{WithNoComments()},
+			wantValid:     false,
+			wantErrorType: "sql_injection_risk",
+		},
+		{
+			name:          "Block comment blocked",
+			sql:           "SELECT * FROM users WHERE id = 1 /**/OR/**/ status = 'x'",
+			opts:          []SQLValidationOption{WithNoComments()},
+			wantValid:     false,
+			wantErrorType: "sql_injection_risk",
+		},
+		{
+			name:          "Unicode escape literal",
+			sql:           "SELECT * FROM users WHERE name = U&'\\0061dmin'",
+			opts:          []SQLValidationOption{WithInjectionRiskCheck()},
+			wantValid:     false,
+			wantErrorType: "sql_injection_risk",
+		},
+		{
+			name:          "Hex escape in E string",
+			sql:           "SELECT * FROM users WHERE name = E'\\x61dmin'",
+			opts:          []SQLValidationOption{WithInjectionRiskCheck()},
+			wantValid:     false,
+			wantErrorType: "sql_injection_risk",
+		},
+		{
+			name:      "Plain escape in E string",
+			sql:       "SELECT * FROM users WHERE name = E'a\\tb'",
+			opts:      []SQLValidationOption{WithInjectionRiskCheck()},
+			wantValid: true,
+		},
+		{
+			name:          "Hex bit string literal",
+			sql:           "SELECT * FROM users WHERE flags = X'1F'",
+			opts:          []SQLValidationOption{WithInjectionRiskCheck()},
+			wantValid:     false,
+			wantErrorType: "sql_injection_risk",
+		},
+		{
+			name:          "Dollar-quoted string",
+			sql:           "SELECT * FROM users WHERE name = $$admin$$",
+			opts:          []SQLValidationOption{WithInjectionRiskCheck()},
+			wantValid:     false,
+			wantErrorType: "sql_injection_risk",
+		},
+		{
+			name:      "Obfuscation allowed without injection check",
+			sql:       "SELECT * FROM users WHERE name = U&'\\0061dmin'",
+			opts:      []SQLValidationOption{WithAllowedTables("users")},
+			wantValid: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, validation := ValidateSQL(tt.sql, tt.opts...)
+
+			if validation.Valid != tt.wantValid {
+				t.Errorf("Valid = %v, want %v", validation.Valid, tt.wantValid)
+			}
+
+			if !tt.wantValid && len(validation.Errors) > 0 {
+				if validation.Errors[0].Type != tt.wantErrorType {
+					t.Errorf("Error type = %v, want %v", validation.Errors[0].Type, tt.wantErrorType)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateSQL_MaxTablesAndJoins(t *testing.T) {
+	tests := []struct {
+		name          string
+		sql           string
+		opts          []SQLValidationOption
+		wantValid     bool
+		wantErrorType string
+	}{
+		{
+			name:      "Single table within limits",
+			sql:       "SELECT id FROM users",
+			opts:      []SQLValidationOption{WithMaxTables(1), WithMaxJoins(0)},
+			wantValid: true,
+		},
+		{
+			name:      "Join within limits",
+			sql:       "SELECT u.id, o.total FROM users u JOIN orders o ON u.id = o.user_id",
+			opts:      []SQLValidationOption{WithMaxTables(2), WithMaxJoins(1)},
+			wantValid: true,
+		},
+		{
+			name:          "Too many tables",
+			sql:           "SELECT u.id FROM users u JOIN orders o ON u.id = o.user_id JOIN items i ON o.id = i.order_id",
+			opts:          []SQLValidationOption{WithMaxTables(2)},
+			wantValid:     false,
+			wantErrorType: "too_many_tables",
+		},
+		{
+			name:          "Too many explicit joins",
+			sql:           "SELECT u.id FROM users u JOIN orders o ON u.id = o.user_id JOIN items i ON o.id = i.order_id",
+			opts:          []SQLValidationOption{WithMaxJoins(1)},
+			wantValid:     false,
+			wantErrorType: "too_many_joins",
+		},
+		{
+			name:          "Too many implicit joins",
+			sql:           "SELECT u.id FROM users u, orders o, items i WHERE u.id = o.user_id AND o.id = i.order_id",
+			opts:          []SQLValidationOption{WithMaxJoins(1)},
+			wantValid:     false,
+			wantErrorType: "too_many_joins",
+		},
+		{
+			name:          "Self-join counts each reference",
+			sql:           "SELECT a.id FROM users a JOIN users b ON a.manager_id = b.id",
+			opts:          []SQLValidationOption{WithMaxTables(1)},
+			wantValid:     false,
+			wantErrorType: "too_many_tables",
+		},
+		{
+			name: "CTE bodies share the table budget",
+			sql: "WITH a AS (SELECT id FROM users), b AS (SELECT user_id FROM orders) " +
+				"SELECT a.id FROM a JOIN b ON a.id = b.user_id",
+			opts:          []SQLValidationOption{WithAllowedCTEs(2), WithMaxTables(1)},
+			wantValid:     false,
+			wantErrorType: "too_many_tables",
+		},
+		{
+			name: "CTE bodies share the join budget",
+			sql: "WITH a AS (SELECT u.id FROM users u JOIN orders o ON u.id = o.user_id) " +
+				"SELECT a.id FROM a JOIN items i ON a.id = i.order_id",
+			opts:          []SQLValidationOption{WithAllowedCTEs(1), WithMaxJoins(1)},
+			wantValid:     false,
+			wantErrorType: "too_many_joins",
+		},
+		{
+			name: "CTE within limits",
+			sql: "WITH a AS (SELECT id FROM users) " +
+				"SELECT a.id FROM a JOIN orders o ON a.id = o.user_id",
+			opts:      []SQLValidationOption{WithAllowedCTEs(1), WithMaxTables(2), WithMaxJoins(1)},
+			wantValid: true,
+		},
+		{
+			name:      "No limits configured",
+			sql:       "SELECT u.id FROM users u, orders o, items i WHERE u.id = o.user_id AND o.id = i.order_id",
+			opts:      []SQLValidationOption{WithAllowedTables("users", "orders", "items")},
+			wantValid: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, validation := ValidateSQL(tt.sql, tt.opts...)
+
+			if validation.Valid != tt.wantValid {
+				t.Errorf("Valid = %v, want %v", validation.Valid, tt.wantValid)
+			}
+
+			if !tt.wantValid && len(validation.Errors) > 0 {
+				if validation.Errors[0].Type != tt.wantErrorType {
+					t.Errorf("Error type = %v, want %v", validation.Errors[0].Type, tt.wantErrorType)
+				}
+			}
+		})
+	}
+}
+
 func TestValidateSQL_CombinedOptions(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -383,6 +574,422 @@ func TestValidateSQL_CombinedOptions(t *testing.T) {
 	}
 }
 
+func TestValidateSQL_AllowedColumns(t *testing.T) {
+	opts := []SQLValidationOption{
+		WithAllowedTables("users", "orders"),
+		WithAllowedColumns("users", "id", "name", "age"),
+	}
+
+	tests := []struct {
+		name          string
+		sql           string
+		wantValid     bool
+		wantErrorType string
+	}{
+		{
+			name:      "Unqualified allowed columns",
+			sql:       "SELECT id, name FROM users WHERE age > 18 ORDER BY name",
+			wantValid: true,
+		},
+		{
+			name:      "Qualified allowed columns with alias",
+			sql:       "SELECT u.id, u.name FROM users u WHERE u.age > 18",
+			wantValid: true,
+		},
+		{
+			name:      "Qualified allowed columns with table name",
+			sql:       "SELECT users.id FROM users WHERE users.name = 'John'",
+			wantValid: true,
+		},
+		{
+			name:          "Unqualified column not allowed",
+			sql:           "SELECT id, password_hash FROM users",
+			wantValid:     false,
+			wantErrorType: "column_not_allowed",
+		},
+		{
+			name:          "Qualified column not allowed",
+			sql:           "SELECT u.password_hash FROM users u",
+			wantValid:     false,
+			wantErrorType: "column_not_allowed",
+		},
+		{
+			name:          "Column not allowed in WHERE clause",
+			sql:           "SELECT id FROM users WHERE password_hash = 'x'",
+			wantValid:     false,
+			wantErrorType: "column_not_allowed",
+		},
+		{
+			name:          "Column not allowed inside function",
+			sql:           "SELECT count(password_hash) FROM users",
+			wantValid:     false,
+			wantErrorType: "column_not_allowed",
+		},
+		{
+			name:          "SELECT * on whitelisted table",
+			sql:           "SELECT * FROM users",
+			wantValid:     false,
+			wantErrorType: "column_not_allowed",
+		},
+		{
+			name:          "Qualified star on whitelisted table",
+			sql:           "SELECT u.* FROM users u",
+			wantValid:     false,
+			wantErrorType: "column_not_allowed",
+		},
+		{
+			name:      "SELECT * on table without whitelist",
+			sql:       "SELECT * FROM orders",
+			wantValid: true,
+		},
+		{
+			name:      "Join with qualified columns",
+			sql:       "SELECT u.name, o.total FROM users u JOIN orders o ON u.id = o.user_id",
+			wantValid: true,
+		},
+		{
+			name:          "Join with qualified column not allowed",
+			sql:           "SELECT u.password_hash, o.total FROM users u JOIN orders o ON u.id = o.user_id",
+			wantValid:     false,
+			wantErrorType: "column_not_allowed",
+		},
+		{
+			name:          "Join with ambiguous unqualified column",
+			sql:           "SELECT u.name, total FROM users u JOIN orders o ON u.id = o.user_id",
+			wantValid:     false,
+			wantErrorType: "column_not_allowed",
+		},
+		{
+			name:          "Column not allowed in subquery",
+			sql:           "SELECT o.total FROM orders o WHERE o.user_id IN (SELECT id FROM users WHERE password_hash = 'x')",
+			wantValid:     false,
+			wantErrorType: "column_not_allowed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, validation := ValidateSQL(tt.sql, opts...)
+
+			if validation.Valid != tt.wantValid {
+				t.Errorf("Valid = %v, want %v", validation.Valid, tt.wantValid)
+			}
+
+			if !tt.wantValid && len(validation.Errors) > 0 {
+				if validation.Errors[0].Type != tt.wantErrorType {
+					t.Errorf("Error type = %v, want %v", validation.Errors[0].Type, tt.wantErrorType)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateAndSecureSQL_AutoLimit(t *testing.T) {
+	tests := []struct {
+		name        string
+		sql         string
+		wantLimit   string
+		wantAdded   bool
+		wantClamped bool
+	}{
+		{
+			name:      "Missing LIMIT is added",
+			sql:       "SELECT id FROM users",
+			wantLimit: "LIMIT 100",
+			wantAdded: true,
+		},
+		{
+			name:        "Larger LIMIT is clamped",
+			sql:         "SELECT id FROM users LIMIT 5000",
+			wantLimit:   "LIMIT 100",
+			wantClamped: true,
+		},
+		{
+			name:        "LIMIT ALL is clamped",
+			sql:         "SELECT id FROM users LIMIT ALL",
+			wantLimit:   "LIMIT 100",
+			wantClamped: true,
+		},
+		{
+			name:      "Smaller LIMIT is kept",
+			sql:       "SELECT id FROM users LIMIT 10",
+			wantLimit: "LIMIT 10",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			securedSQL, validation, err := ValidateAndSecureSQL(tt.sql, WithAutoLimit(100))
+			if err != nil {
+				t.Fatalf("ValidateAndSecureSQL() error = %v", err)
+			}
+
+			if !strings.Contains(securedSQL, tt.wantLimit) {
+				t.Errorf("secured SQL %q does not contain %q", securedSQL, tt.wantLimit)
+			}
+			if validation.LimitAdded != tt.wantAdded {
+				t.Errorf("LimitAdded = %v, want %v", validation.LimitAdded, tt.wantAdded)
+			}
+			if validation.LimitClamped != tt.wantClamped {
+				t.Errorf("LimitClamped = %v, want %v", validation.LimitClamped, tt.wantClamped)
+			}
+		})
+	}
+}
+
+func TestValidateAndSecureSQL_TenantPlaceholder(t *testing.T) {
+	tests := []struct {
+		name       string
+		sql        string
+		opts       []SQLValidationOption
+		wantClause string
+		wantParams []interface{}
+	}{
+		{
+			name:       "Inline tenant ID by default",
+			sql:        "SELECT id FROM knowledge_bases",
+			opts:       []SQLValidationOption{WithTenantIsolation(42)},
+			wantClause: "knowledge_bases.tenant_id = 42",
+		},
+		{
+			name:       "Placeholder tenant ID",
+			sql:        "SELECT id FROM knowledge_bases",
+			opts:       []SQLValidationOption{WithTenantIsolation(42), WithTenantPlaceholder()},
+			wantClause: "knowledge_bases.tenant_id = $1",
+			wantParams: []interface{}{uint64(42)},
+		},
+		{
+			// Only the scanner's parameter tokens count, not "$1" in a string literal
+			name:       "Placeholder with $1 in a literal",
+			sql:        "SELECT id FROM knowledge_bases WHERE name = '$1'",
+			opts:       []SQLValidationOption{WithTenantIsolation(42), WithTenantPlaceholder()},
+			wantClause: "knowledge_bases.tenant_id = $1",
+			wantParams: []interface{}{uint64(42)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			securedSQL, validation, err := ValidateAndSecureSQL(tt.sql, tt.opts...)
+			if err != nil {
+				t.Fatalf("ValidateAndSecureSQL() error = %v", err)
+			}
+
+			if !strings.Contains(securedSQL, tt.wantClause) {
+				t.Errorf("secured SQL %q does not contain %q", securedSQL, tt.wantClause)
+			}
+			if fmt.Sprint(validation.Params) != fmt.Sprint(tt.wantParams) {
+				t.Errorf("Params = %v, want %v", validation.Params, tt.wantParams)
+			}
+		})
+	}
+}
+
+func TestValidateAndSecureSQL_RejectsBindParameters(t *testing.T) {
+	opts := []SQLValidationOption{WithTenantIsolation(42), WithTenantPlaceholder()}
+	for _, sql := range []string{
+		"SELECT id FROM knowledge_bases WHERE name = $1",
+		"WITH kb AS (SELECT id FROM knowledge_bases WHERE name = $2) SELECT id FROM kb",
+	} {
+		_, validation, err := ValidateAndSecureSQL(sql, opts...)
+		if !errors.Is(err, ErrBindParameters) {
+			t.Errorf("ValidateAndSecureSQL(%q) error = %v, want %v", sql, err, ErrBindParameters)
+		}
+		if validation.Valid {
+			t.Errorf("ValidateAndSecureSQL(%q) reported a valid query", sql)
+		}
+	}
+}
+
+func TestValidateAndSecureSQL_TenantIsolationGap(t *testing.T) {
+	tests := []struct {
+		name      string
+		sql       string
+		opts      []SQLValidationOption
+		wantTable string
+	}{
+		{
+			name:      "Queried table without tenant column",
+			sql:       "SELECT id FROM users",
+			opts:      []SQLValidationOption{WithTenantIsolation(42), WithTenantPlaceholder()},
+			wantTable: "users",
+		},
+		{
+			// A table added to the allowed list but not to the tenant tables is caught
+			// even by queries that don't touch it
+			name: "Allowed table without tenant column",
+			sql:  "SELECT id FROM knowledges",
+			opts: []SQLValidationOption{
+				WithSecurityDefaults(42),
+				WithAllowedTables("knowledge_bases", "knowledges", "chunks", "messages"),
+			},
+			wantTable: "messages",
+		},
+		{
+			name: "Tenant tables registered for every allowed table",
+			sql:  "SELECT id FROM sessions",
+			opts: []SQLValidationOption{
+				WithAllowedTables("sessions"),
+				WithTenantIsolation(42, "sessions"),
+			},
+		},
+		{
+			name: "No tenant isolation",
+			sql:  "SELECT id FROM users",
+			opts: []SQLValidationOption{WithAllowedTables("users")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, validation, err := ValidateAndSecureSQL(tt.sql, tt.opts...)
+			if tt.wantTable == "" {
+				if err != nil {
+					t.Fatalf("ValidateAndSecureSQL() error = %v", err)
+				}
+				return
+			}
+
+			if !errors.Is(err, ErrTenantIsolationGap) || !strings.Contains(err.Error(), tt.wantTable) {
+				t.Fatalf("error = %v, want tenant isolation gap on %s", err, tt.wantTable)
+			}
+			if validation.Valid {
+				t.Error("Valid = true, want false")
+			}
+		})
+	}
+}
+
+func TestValidateSQL_AllowedCTEs(t *testing.T) {
+	tests := []struct {
+		name          string
+		sql           string
+		wantValid     bool
+		wantErrorType string
+	}{
+		{
+			name:      "CTEs within the limit",
+			sql:       "WITH kb AS (SELECT id FROM knowledge_bases), k AS (SELECT id, knowledge_base_id FROM knowledges) SELECT k.id FROM k JOIN kb ON k.knowledge_base_id = kb.id",
+			wantValid: true,
+		},
+		{
+			name:          "Too many CTEs",
+			sql:           "WITH a AS (SELECT id FROM chunks), b AS (SELECT id FROM chunks), c AS (SELECT id FROM chunks) SELECT id FROM a",
+			wantValid:     false,
+			wantErrorType: "too_many_ctes",
+		},
+		{
+			name:          "Recursive CTE",
+			sql:           "WITH RECURSIVE r AS (SELECT id FROM knowledges UNION ALL SELECT id FROM r) SELECT id FROM r",
+			wantValid:     false,
+			wantErrorType: "recursive_cte",
+		},
+		{
+			name:      "Data-modifying CTE",
+			sql:       "WITH d AS (DELETE FROM knowledges RETURNING id) SELECT id FROM d",
+			wantValid: false,
+		},
+		{
+			name:          "CTE body reading a table that is not allowed",
+			sql:           "WITH u AS (SELECT id FROM users) SELECT id FROM u",
+			wantValid:     false,
+			wantErrorType: "table_not_allowed",
+		},
+		{
+			name:      "CTE body with dangerous function",
+			sql:       "WITH k AS (SELECT pg_sleep(10) AS s FROM knowledges) SELECT s FROM k",
+			wantValid: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, validation := ValidateSQL(tt.sql, WithSecurityDefaults(42), WithAllowedCTEs(2))
+			if validation.Valid != tt.wantValid {
+				t.Fatalf("Valid = %v, want %v, errors: %v", validation.Valid, tt.wantValid, validation.Errors)
+			}
+			if tt.wantErrorType == "" {
+				return
+			}
+			for _, err := range validation.Errors {
+				if err.Type == tt.wantErrorType {
+					return
+				}
+			}
+			t.Errorf("errors %v do not contain type %s", validation.Errors, tt.wantErrorType)
+		})
+	}
+}
+
+func TestValidateAndSecureSQL_CTETenantIsolation(t *testing.T) {
+	sql := "WITH recent AS (SELECT k.id, k.knowledge_base_id FROM knowledges k WHERE k.created_at > '2024-01-01') " +
+		"SELECT recent.id, kb.name FROM recent JOIN knowledge_bases kb ON recent.knowledge_base_id = kb.id"
+	securedSQL, _, err := ValidateAndSecureSQL(sql,
+		WithSecurityDefaults(42),
+		WithAllowedCTEs(1),
+		WithSoftDeleteFilter(),
+	)
+	if err != nil {
+		t.Fatalf("ValidateAndSecureSQL() error = %v", err)
+	}
+
+	cteBody, mainQuery, _ := strings.Cut(securedSQL, ") SELECT")
+	for _, want := range []string{"k.tenant_id = 42", "k.deleted_at IS NULL", "created_at > '2024-01-01'"} {
+		if !strings.Contains(cteBody, want) {
+			t.Errorf("CTE body of %q does not contain %q", securedSQL, want)
+		}
+	}
+	for _, want := range []string{"kb.tenant_id = 42", "kb.deleted_at IS NULL"} {
+		if !strings.Contains(mainQuery, want) {
+			t.Errorf("main query of %q does not contain %q", securedSQL, want)
+		}
+	}
+	if strings.Contains(securedSQL, "recent.tenant_id") {
+		t.Errorf("secured SQL %q filters the CTE reference itself", securedSQL)
+	}
+}
+
+func TestValidateAndSecureSQL_CTENamedAfterTable(t *testing.T) {
+	tests := []struct {
+		name        string
+		sql         string
+		wantFilters []string
+		notWant     []string
+	}{
+		{
+			name:        "CTE reads the table it shadows",
+			sql:         "WITH chunks AS (SELECT id, content FROM chunks) SELECT id, content FROM chunks",
+			wantFilters: []string{"chunks.tenant_id = 42"},
+		},
+		{
+			name: "later CTE reads an earlier one",
+			sql: "WITH knowledges AS (SELECT id FROM knowledges), recent AS (SELECT id FROM knowledges) " +
+				"SELECT id FROM recent",
+			wantFilters: []string{"knowledges.tenant_id = 42"},
+			notWant:     []string{"recent.tenant_id"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			securedSQL, _, err := ValidateAndSecureSQL(tt.sql, WithSecurityDefaults(42), WithAllowedCTEs(3))
+			if err != nil {
+				t.Fatalf("ValidateAndSecureSQL() error = %v", err)
+			}
+			cteBody, _, _ := strings.Cut(securedSQL, ")")
+			for _, want := range tt.wantFilters {
+				if !strings.Contains(cteBody, want) {
+					t.Errorf("first CTE body of %q does not contain %q", securedSQL, want)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(securedSQL, notWant) {
+					t.Errorf("secured SQL %q contains %q", securedSQL, notWant)
+				}
+			}
+		})
+	}
+}
+
 func ExampleValidateSQL() {
 	// Example 1: Validate table names
 	sql1 := "SELECT * FROM users WHERE age > 18"
@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Names of the bundled SQL validation profiles
+const (
+	// SQLProfileStrict allows single-table lookups on the tenant's own tables
+	SQLProfileStrict = "strict"
+	// SQLProfileReadonlyJoins allows joining a few of the tenant's tables
+	SQLProfileReadonlyJoins = "readonly-joins"
+	// SQLProfileAnalytics allows longer queries with more joins, CTEs and larger result sets
+	SQLProfileAnalytics = "analytics"
+)
+
+// ErrUnknownSQLProfile is returned when a SQL validation profile name is not registered
+var ErrUnknownSQLProfile = errors.New("unknown SQL validation profile")
+
+// sqlValidationProfiles holds the curated option combinations behind each profile name.
+// Every profile starts from WithSecurityDefaults, so statement type, schema access, dangerous
+// function and tenant isolation checks can't be left out. Subqueries stay blocked in all of
+// them because tenant conditions are only injected for tables of the main query and CTE bodies.
+var sqlValidationProfiles = map[string]func(tenantID uint64) []SQLValidationOption{
+	SQLProfileStrict: func(tenantID uint64) []SQLValidationOption {
+		return []SQLValidationOption{
+			WithSecurityDefaults(tenantID),
+			WithSoftDeleteFilter(),
+			WithInjectionRiskCheck(),
+			WithNoComments(),
+			WithMaxTables(1),
+			WithAutoLimit(100),
+		}
+	},
+	SQLProfileReadonlyJoins: func(tenantID uint64) []SQLValidationOption {
+		return []SQLValidationOption{
+			WithSecurityDefaults(tenantID),
+			WithSoftDeleteFilter(),
+			WithInjectionRiskCheck(),
+			WithNoComments(),
+			WithMaxJoins(2),
+			WithAutoLimit(500),
+		}
+	},
+	SQLProfileAnalytics: func(tenantID uint64) []SQLValidationOption {
+		return []SQLValidationOption{
+			WithSecurityDefaults(tenantID),
+			WithSoftDeleteFilter(),
+			WithInjectionRiskCheck(),
+			WithInputValidation(6, 16384),
+			WithMaxJoins(4),
+			WithAllowedCTEs(3),
+			WithAutoLimit(10000),
+		}
+	},
+}
+
+// SQLValidationProfiles returns the names of the registered SQL validation profiles, sorted
+func SQLValidationProfiles() []string {
+	names := make([]string, 0, len(sqlValidationProfiles))
+	for name := range sqlValidationProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SQLProfileOptions returns the validation options of a named profile, built for the tenant
+func SQLProfileOptions(profileName string, tenantID uint64) ([]SQLValidationOption, error) {
+	profile, ok := sqlValidationProfiles[profileName]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownSQLProfile, profileName)
+	}
+	return profile(tenantID), nil
+}
+
+// ValidateSQLWithProfile validates a SQL statement with the options of a named profile
+func ValidateSQLWithProfile(sql, profileName string, tenantID uint64) (*SQLParseResult, *SQLValidationResult, error) {
+	opts, err := SQLProfileOptions(profileName, tenantID)
+	if err != nil {
+		return nil, nil, err
+	}
+	parseResult, validationResult := ValidateSQL(sql, opts...)
+	return parseResult, validationResult, nil
+}
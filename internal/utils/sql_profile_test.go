@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateSQLWithProfile_BlocksAttacks(t *testing.T) {
+	attacks := []struct {
+		name string
+		sql  string
+	}{
+		{"Data modification", "DELETE FROM knowledges WHERE id = 'k1'"},
+		{"Stacked statements", "SELECT id FROM knowledges; DROP TABLE knowledges"},
+		{"Table of another domain", "SELECT password_hash FROM users"},
+		{"Table without tenant column", "SELECT content FROM messages"},
+		{"System catalog", "SELECT usename FROM pg_catalog.pg_user"},
+		{"Information schema", "SELECT table_name FROM information_schema.tables"},
+		{"System column", "SELECT ctid FROM knowledges"},
+		{"Dangerous function", "SELECT pg_read_file('/etc/passwd') FROM knowledges"},
+		{"Time based probe", "SELECT pg_sleep(10) FROM knowledges"},
+		{"Always-true condition", "SELECT id FROM knowledges WHERE id = 'k1' OR 1=1"},
+		{"Subquery escaping tenant filter", "SELECT id FROM knowledges WHERE knowledge_base_id IN (SELECT id FROM knowledge_bases)"},
+		{"Recursive CTE", "WITH RECURSIVE r AS (SELECT id FROM knowledges UNION ALL SELECT id FROM r) SELECT id FROM r"},
+		{"Data-modifying CTE", "WITH d AS (DELETE FROM knowledges RETURNING id) SELECT id FROM d"},
+	}
+
+	for _, profile := range SQLValidationProfiles() {
+		for _, attack := range attacks {
+			t.Run(profile+"/"+attack.name, func(t *testing.T) {
+				_, validation, err := ValidateSQLWithProfile(attack.sql, profile, 42)
+				if err != nil {
+					t.Fatalf("ValidateSQLWithProfile() error = %v", err)
+				}
+				if validation.Valid {
+					t.Errorf("profile %s accepted %q", profile, attack.sql)
+				}
+			})
+		}
+	}
+}
+
+func TestValidateSQLWithProfile_Differences(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want map[string]bool // profile -> valid
+	}{
+		{
+			name: "Single table lookup",
+			sql:  "SELECT id, title FROM knowledges WHERE knowledge_base_id = 'kb-1'",
+			want: map[string]bool{SQLProfileStrict: true, SQLProfileReadonlyJoins: true, SQLProfileAnalytics: true},
+		},
+		{
+			name: "Comment",
+			sql:  "SELECT id FROM knowledges -- latest",
+			want: map[string]bool{SQLProfileStrict: false, SQLProfileReadonlyJoins: false, SQLProfileAnalytics: true},
+		},
+		{
+			name: "CTE",
+			sql:  "WITH kb AS (SELECT id FROM knowledge_bases) SELECT id FROM kb",
+			want: map[string]bool{SQLProfileStrict: false, SQLProfileReadonlyJoins: false, SQLProfileAnalytics: true},
+		},
+		{
+			name: "One join",
+			sql:  "SELECT k.id FROM knowledges k JOIN knowledge_bases kb ON k.knowledge_base_id = kb.id",
+			want: map[string]bool{SQLProfileStrict: false, SQLProfileReadonlyJoins: true, SQLProfileAnalytics: true},
+		},
+		{
+			name: "Three joins",
+			sql: "SELECT k.id FROM knowledges k JOIN knowledge_bases kb ON k.knowledge_base_id = kb.id " +
+				"JOIN chunks c ON c.knowledge_id = k.id JOIN chunks c2 ON c2.knowledge_id = k.id",
+			want: map[string]bool{SQLProfileStrict: false, SQLProfileReadonlyJoins: false, SQLProfileAnalytics: true},
+		},
+	}
+
+	for _, tt := range tests {
+		for profile, wantValid := range tt.want {
+			t.Run(profile+"/"+tt.name, func(t *testing.T) {
+				_, validation, err := ValidateSQLWithProfile(tt.sql, profile, 42)
+				if err != nil {
+					t.Fatalf("ValidateSQLWithProfile() error = %v", err)
+				}
+				if validation.Valid != wantValid {
+					t.Errorf("Valid = %v, want %v, errors: %v", validation.Valid, wantValid, validation.Errors)
+				}
+			})
+		}
+	}
+}
+
+func TestSQLProfileOptions_SecuresQueries(t *testing.T) {
+	for _, profile := range SQLValidationProfiles() {
+		t.Run(profile, func(t *testing.T) {
+			opts, err := SQLProfileOptions(profile, 42)
+			if err != nil {
+				t.Fatalf("SQLProfileOptions() error = %v", err)
+			}
+			securedSQL, _, err := ValidateAndSecureSQL("SELECT id FROM knowledges", opts...)
+			if err != nil {
+				t.Fatalf("ValidateAndSecureSQL() error = %v", err)
+			}
+			for _, want := range []string{"knowledges.tenant_id = 42", "knowledges.deleted_at IS NULL", "LIMIT"} {
+				if !strings.Contains(securedSQL, want) {
+					t.Errorf("secured SQL %q does not contain %q", securedSQL, want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateSQLWithProfile_UnknownProfile(t *testing.T) {
+	_, _, err := ValidateSQLWithProfile("SELECT id FROM knowledges", "permissive", 42)
+	if !errors.Is(err, ErrUnknownSQLProfile) {
+		t.Errorf("error = %v, want ErrUnknownSQLProfile", err)
+	}
+}
@@ -1,9 +1,14 @@
+DROP TABLE IF EXISTS api_keys;
+DROP TABLE IF EXISTS user_notifications;
+DROP TABLE IF EXISTS organization_pending_invites;
 DROP TABLE IF EXISTS tenant_disabled_shared_agents;
 DROP TABLE IF EXISTS agent_shares;
 DROP TABLE IF EXISTS organization_join_requests;
+DROP TABLE IF EXISTS kb_dynamic_shares;
 DROP TABLE IF EXISTS kb_shares;
 DROP TABLE IF EXISTS organization_members;
 DROP TABLE IF EXISTS organizations;
+DROP TABLE IF EXISTS prompt_templates;
 DROP TABLE IF EXISTS custom_agents;
 DROP TABLE IF EXISTS mcp_services;
 DROP TABLE IF EXISTS knowledge_tags;
@@ -16,6 +16,12 @@ CREATE TABLE IF NOT EXISTS tenants (
     web_search_config TEXT DEFAULT NULL,
     parser_engine_config TEXT DEFAULT NULL,
     storage_engine_config TEXT DEFAULT NULL,
+    summary_model_defaults TEXT DEFAULT NULL,
+    history_retention_config TEXT DEFAULT NULL,
+    banned_words_config TEXT DEFAULT NULL,
+    model_retry_config TEXT DEFAULT NULL,
+    embedding_batch_size INTEGER NOT NULL DEFAULT 0,
+    redaction_config TEXT DEFAULT NULL,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     deleted_at DATETIME
@@ -61,6 +67,9 @@ CREATE TABLE IF NOT EXISTS knowledge_bases (
     faq_config TEXT,
     question_generation_config TEXT NULL,
     is_temporary BOOLEAN NOT NULL DEFAULT 0,
+    duplicate_scope VARCHAR(16) NOT NULL DEFAULT 'kb',
+    fallback_response TEXT,
+    share_label VARCHAR(128) NOT NULL DEFAULT '',
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     deleted_at DATETIME
@@ -89,6 +98,7 @@ CREATE TABLE IF NOT EXISTS knowledges (
     tag_id VARCHAR(36),
     summary_status VARCHAR(32) DEFAULT 'none',
     last_faq_import_result TEXT DEFAULT NULL,
+    retrieval_boost REAL NOT NULL DEFAULT 1.0,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     processed_at DATETIME,
@@ -124,6 +134,8 @@ CREATE TABLE IF NOT EXISTS sessions (
     agent_config TEXT DEFAULT NULL,
     context_config TEXT DEFAULT NULL,
     agent_id VARCHAR(36),
+    auto_title_disabled BOOLEAN NOT NULL DEFAULT 0,
+    pinned_knowledge_base_ids TEXT DEFAULT NULL,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     deleted_at DATETIME
@@ -280,6 +292,20 @@ CREATE INDEX IF NOT EXISTS idx_custom_agents_tenant_id ON custom_agents(tenant_i
 CREATE INDEX IF NOT EXISTS idx_custom_agents_is_builtin ON custom_agents(is_builtin);
 CREATE INDEX IF NOT EXISTS idx_custom_agents_deleted_at ON custom_agents(deleted_at);
 
+CREATE TABLE IF NOT EXISTS prompt_templates (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    content TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    deleted_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_prompt_templates_tenant_id ON prompt_templates(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_prompt_templates_deleted_at ON prompt_templates(deleted_at);
+
 CREATE TABLE IF NOT EXISTS organizations (
     id VARCHAR(36) PRIMARY KEY,
     name VARCHAR(255) NOT NULL,
@@ -290,8 +316,12 @@ CREATE TABLE IF NOT EXISTS organizations (
     invite_code_expires_at DATETIME,
     invite_code_validity_days SMALLINT NOT NULL DEFAULT 7,
     avatar VARCHAR(512) DEFAULT '',
+    avatar_path VARCHAR(512) NOT NULL DEFAULT '',
     searchable BOOLEAN NOT NULL DEFAULT 0,
     member_limit INTEGER NOT NULL DEFAULT 50,
+    default_share_permission VARCHAR(32) NOT NULL DEFAULT 'viewer',
+    join_request_ttl_days INTEGER NOT NULL DEFAULT 0,
+    storage_quota INTEGER NOT NULL DEFAULT 0,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     deleted_at DATETIME
@@ -299,6 +329,7 @@ CREATE TABLE IF NOT EXISTS organizations (
 
 CREATE INDEX IF NOT EXISTS idx_organizations_owner_id ON organizations(owner_id);
 CREATE INDEX IF NOT EXISTS idx_organizations_deleted_at ON organizations(deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_organizations_invite_code ON organizations(invite_code) WHERE invite_code IS NOT NULL AND deleted_at IS NULL;
 
 CREATE TABLE IF NOT EXISTS organization_members (
     id VARCHAR(36) PRIMARY KEY,
@@ -306,6 +337,7 @@ CREATE TABLE IF NOT EXISTS organization_members (
     user_id VARCHAR(36) NOT NULL,
     tenant_id INTEGER NOT NULL,
     role VARCHAR(32) NOT NULL DEFAULT 'viewer',
+    suspended BOOLEAN NOT NULL DEFAULT 0,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 );
@@ -322,6 +354,7 @@ CREATE TABLE IF NOT EXISTS kb_shares (
     shared_by_user_id VARCHAR(36) NOT NULL,
     source_tenant_id INTEGER NOT NULL,
     permission VARCHAR(32) NOT NULL DEFAULT 'viewer',
+    knowledge_ids TEXT,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     deleted_at DATETIME
@@ -332,6 +365,22 @@ CREATE INDEX IF NOT EXISTS idx_kb_shares_org_id ON kb_shares(organization_id);
 CREATE INDEX IF NOT EXISTS idx_kb_shares_source_tenant ON kb_shares(source_tenant_id);
 CREATE INDEX IF NOT EXISTS idx_kb_shares_deleted_at ON kb_shares(deleted_at);
 
+CREATE TABLE IF NOT EXISTS kb_dynamic_shares (
+    id VARCHAR(36) PRIMARY KEY,
+    organization_id VARCHAR(36) NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    created_by_user_id VARCHAR(36) NOT NULL,
+    source_tenant_id INTEGER NOT NULL,
+    criteria_label VARCHAR(128) NOT NULL,
+    permission VARCHAR(32) NOT NULL DEFAULT 'viewer',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    deleted_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_kb_dynamic_shares_org_id ON kb_dynamic_shares(organization_id);
+CREATE INDEX IF NOT EXISTS idx_kb_dynamic_shares_source_tenant ON kb_dynamic_shares(source_tenant_id);
+CREATE INDEX IF NOT EXISTS idx_kb_dynamic_shares_deleted_at ON kb_dynamic_shares(deleted_at);
+
 CREATE TABLE IF NOT EXISTS organization_join_requests (
     id VARCHAR(36) PRIMARY KEY,
     organization_id VARCHAR(36) NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
@@ -353,6 +402,31 @@ CREATE INDEX IF NOT EXISTS idx_org_join_requests_org_id ON organization_join_req
 CREATE INDEX IF NOT EXISTS idx_org_join_requests_user_id ON organization_join_requests(user_id);
 CREATE INDEX IF NOT EXISTS idx_org_join_requests_status ON organization_join_requests(status);
 
+CREATE TABLE IF NOT EXISTS organization_pending_invites (
+    id VARCHAR(36) PRIMARY KEY,
+    organization_id VARCHAR(36) NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL,
+    role VARCHAR(32) NOT NULL DEFAULT 'viewer',
+    invited_by VARCHAR(36) NOT NULL,
+    expires_at DATETIME NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_org_pending_invites_org_email ON organization_pending_invites(organization_id, email);
+CREATE INDEX IF NOT EXISTS idx_org_pending_invites_email ON organization_pending_invites(email);
+CREATE INDEX IF NOT EXISTS idx_org_pending_invites_expires_at ON organization_pending_invites(expires_at);
+
+CREATE TABLE IF NOT EXISTS user_notifications (
+    id VARCHAR(36) PRIMARY KEY,
+    user_id VARCHAR(36) NOT NULL,
+    type VARCHAR(64) NOT NULL,
+    data TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_notifications_user_created ON user_notifications(user_id, created_at DESC);
+
 CREATE TABLE IF NOT EXISTS agent_shares (
     id VARCHAR(36) PRIMARY KEY,
     agent_id VARCHAR(36) NOT NULL,
@@ -380,3 +454,22 @@ CREATE TABLE IF NOT EXISTS tenant_disabled_shared_agents (
 );
 
 CREATE INDEX IF NOT EXISTS idx_tenant_disabled_shared_agents_tenant_id ON tenant_disabled_shared_agents(tenant_id);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    key_hash VARCHAR(64) NOT NULL,
+    key_prefix VARCHAR(16) NOT NULL,
+    knowledge_base_ids TEXT NOT NULL,
+    permission VARCHAR(32) NOT NULL DEFAULT 'viewer',
+    upload_rate_limit INTEGER NOT NULL DEFAULT 0,
+    daily_upload_quota INTEGER NOT NULL DEFAULT 0,
+    created_by VARCHAR(36),
+    last_used_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_keys_key_hash ON api_keys(key_hash);
+CREATE INDEX IF NOT EXISTS idx_api_keys_tenant_id ON api_keys(tenant_id);
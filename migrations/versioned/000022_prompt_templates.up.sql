@@ -0,0 +1,20 @@
+-- Migration: 000022_prompt_templates
+-- Description: Create tenant prompt library referenced by custom agents
+DO $$ BEGIN RAISE NOTICE '[Migration 000022] Creating table: prompt_templates'; END $$;
+
+CREATE TABLE IF NOT EXISTS prompt_templates (
+    id VARCHAR(36) PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id BIGINT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    content TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_prompt_templates_tenant_id ON prompt_templates (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_prompt_templates_deleted_at ON prompt_templates (deleted_at);
+
+COMMENT ON TABLE prompt_templates IS 'Tenant-scoped system prompts referenced by custom agents via config.system_prompt_ref';
+COMMENT ON COLUMN prompt_templates.content IS 'Prompt text resolved at request time by referencing agents';
@@ -0,0 +1,2 @@
+-- Remove retrieval_boost column from knowledges table
+ALTER TABLE knowledges DROP COLUMN IF EXISTS retrieval_boost;
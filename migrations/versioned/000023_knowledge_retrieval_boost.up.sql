@@ -0,0 +1,3 @@
+-- Add retrieval_boost column to knowledges table
+-- Multiplier applied to the scores of this document's chunks before final ranking (1.0 = no change)
+ALTER TABLE knowledges ADD COLUMN IF NOT EXISTS retrieval_boost DOUBLE PRECISION NOT NULL DEFAULT 1.0;
@@ -0,0 +1,2 @@
+-- Remove metadata index from knowledges table
+DROP INDEX IF EXISTS idx_knowledges_metadata;
@@ -0,0 +1,2 @@
+-- Index knowledges.metadata so retrieval can filter documents by metadata key/value pairs
+CREATE INDEX IF NOT EXISTS idx_knowledges_metadata ON knowledges USING GIN (metadata jsonb_path_ops);
@@ -0,0 +1,2 @@
+-- Remove summary_model_defaults column from tenants table
+ALTER TABLE tenants DROP COLUMN IF EXISTS summary_model_defaults;
@@ -0,0 +1,2 @@
+-- Add summary_model_defaults JSONB column to tenants table (default summary model per knowledge base type)
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS summary_model_defaults JSONB;
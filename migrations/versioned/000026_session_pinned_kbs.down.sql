@@ -0,0 +1,2 @@
+-- Remove pinned_knowledge_base_ids column from sessions table
+ALTER TABLE sessions DROP COLUMN IF EXISTS pinned_knowledge_base_ids;
@@ -0,0 +1,2 @@
+-- Add pinned_knowledge_base_ids column to sessions table (knowledge bases always searched in the session)
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS pinned_knowledge_base_ids JSONB;
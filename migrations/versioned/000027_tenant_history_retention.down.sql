@@ -0,0 +1,2 @@
+-- Remove history_retention_config column from tenants table
+ALTER TABLE tenants DROP COLUMN IF EXISTS history_retention_config;
@@ -0,0 +1,2 @@
+-- Add history_retention_config JSONB column to tenants table (per-session message retention limits)
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS history_retention_config JSONB;
@@ -0,0 +1,2 @@
+-- Remove banned_words_config column from tenants table
+ALTER TABLE tenants DROP COLUMN IF EXISTS banned_words_config;
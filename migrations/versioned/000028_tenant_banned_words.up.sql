@@ -0,0 +1,2 @@
+-- Add banned_words_config JSONB column to tenants table (phrases filtered out of streamed answers)
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS banned_words_config JSONB;
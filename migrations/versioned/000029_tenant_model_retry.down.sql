@@ -0,0 +1,2 @@
+-- Remove model_retry_config column from tenants table
+ALTER TABLE tenants DROP COLUMN IF EXISTS model_retry_config;
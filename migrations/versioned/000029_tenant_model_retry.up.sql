@@ -0,0 +1,2 @@
+-- Add model_retry_config JSONB column to tenants table (retry policy override for model calls)
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS model_retry_config JSONB;
@@ -0,0 +1,2 @@
+-- Drop kb_dynamic_shares table
+DROP TABLE IF EXISTS kb_dynamic_shares;
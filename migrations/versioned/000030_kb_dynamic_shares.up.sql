@@ -0,0 +1,19 @@
+-- Create kb_dynamic_shares table (rules sharing every knowledge base matching a tag to an organization)
+CREATE TABLE IF NOT EXISTS kb_dynamic_shares (
+    id VARCHAR(36) PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id VARCHAR(36) NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    created_by_user_id VARCHAR(36) NOT NULL,
+    source_tenant_id INTEGER NOT NULL,
+    criteria_tag VARCHAR(128) NOT NULL,
+    permission VARCHAR(32) NOT NULL DEFAULT 'viewer',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_kb_dynamic_shares_org_id ON kb_dynamic_shares(organization_id);
+CREATE INDEX IF NOT EXISTS idx_kb_dynamic_shares_source_tenant ON kb_dynamic_shares(source_tenant_id);
+CREATE INDEX IF NOT EXISTS idx_kb_dynamic_shares_deleted_at ON kb_dynamic_shares(deleted_at);
+
+COMMENT ON TABLE kb_dynamic_shares IS 'Dynamic knowledge base sharing rules, resolved against knowledge base tags at query time';
+COMMENT ON COLUMN kb_dynamic_shares.criteria_tag IS 'Tag name matched case-insensitively against the tags of the source tenant knowledge bases';
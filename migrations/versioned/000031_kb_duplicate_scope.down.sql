@@ -0,0 +1,2 @@
+-- Remove duplicate_scope column from knowledge_bases table
+ALTER TABLE knowledge_bases DROP COLUMN IF EXISTS duplicate_scope;
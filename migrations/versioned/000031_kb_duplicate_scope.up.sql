@@ -0,0 +1,2 @@
+-- Add duplicate_scope column to knowledge_bases table (kb: per knowledge base, tenant: across the tenant)
+ALTER TABLE knowledge_bases ADD COLUMN IF NOT EXISTS duplicate_scope VARCHAR(16) NOT NULL DEFAULT 'kb';
@@ -0,0 +1,2 @@
+-- Remove embedding_batch_size column from tenants table
+ALTER TABLE tenants DROP COLUMN IF EXISTS embedding_batch_size;
@@ -0,0 +1,2 @@
+-- Add embedding_batch_size column to tenants table (chunks per embedding request, 0 = global setting)
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS embedding_batch_size INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+-- Remove auto_title_disabled column from sessions table
+ALTER TABLE sessions DROP COLUMN IF EXISTS auto_title_disabled;
@@ -0,0 +1,2 @@
+-- Add auto_title_disabled column to sessions table (true keeps the session from being titled automatically)
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS auto_title_disabled BOOLEAN NOT NULL DEFAULT FALSE;
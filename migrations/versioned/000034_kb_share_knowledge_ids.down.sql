@@ -0,0 +1,2 @@
+-- Remove knowledge_ids column from kb_shares table
+ALTER TABLE kb_shares DROP COLUMN IF EXISTS knowledge_ids;
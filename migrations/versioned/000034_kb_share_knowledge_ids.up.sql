@@ -0,0 +1,2 @@
+-- Add knowledge_ids column to kb_shares table (documents exposed by a partial share; NULL shares the whole knowledge base)
+ALTER TABLE kb_shares ADD COLUMN IF NOT EXISTS knowledge_ids JSONB;
@@ -0,0 +1,2 @@
+-- Remove redaction_config column from tenants table
+ALTER TABLE tenants DROP COLUMN IF EXISTS redaction_config;
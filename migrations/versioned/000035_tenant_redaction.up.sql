@@ -0,0 +1,2 @@
+-- Add redaction_config JSONB column to tenants table (regex matches redacted from streamed answers)
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS redaction_config JSONB;
@@ -0,0 +1,2 @@
+-- Remove default_share_permission column from organizations table
+ALTER TABLE organizations DROP COLUMN IF EXISTS default_share_permission;
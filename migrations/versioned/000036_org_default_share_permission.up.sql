@@ -0,0 +1,2 @@
+-- Add default_share_permission column to organizations table (permission of shares created without one)
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS default_share_permission VARCHAR(32) NOT NULL DEFAULT 'viewer';
@@ -0,0 +1,2 @@
+-- Drop user_notifications table
+DROP TABLE IF EXISTS user_notifications;
@@ -0,0 +1,13 @@
+-- Create user_notifications table (messages shown to a user, e.g. review results of their organization requests)
+CREATE TABLE IF NOT EXISTS user_notifications (
+    id VARCHAR(36) PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id VARCHAR(36) NOT NULL,
+    type VARCHAR(64) NOT NULL,
+    data JSONB,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_notifications_user_created ON user_notifications(user_id, created_at DESC);
+
+COMMENT ON TABLE user_notifications IS 'Notifications stored for users and listed by GET /me/notifications';
+COMMENT ON COLUMN user_notifications.data IS 'Type-specific details of the notification';
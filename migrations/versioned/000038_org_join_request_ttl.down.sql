@@ -0,0 +1,2 @@
+-- Remove join_request_ttl_days column from organizations table
+ALTER TABLE organizations DROP COLUMN IF EXISTS join_request_ttl_days;
@@ -0,0 +1,2 @@
+-- Add join_request_ttl_days column to organizations table (days before pending join requests expire, 0 = global default)
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS join_request_ttl_days INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+-- Remove suspended column from organization_members table
+ALTER TABLE organization_members DROP COLUMN IF EXISTS suspended;
@@ -0,0 +1,2 @@
+-- Add suspended column to organization_members table (suspended members keep their role but have no access)
+ALTER TABLE organization_members ADD COLUMN IF NOT EXISTS suspended BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,2 @@
+-- Drop organization_pending_invites table
+DROP TABLE IF EXISTS organization_pending_invites;
@@ -0,0 +1,15 @@
+-- Create organization_pending_invites table (invites to emails without an account, claimed on signup)
+CREATE TABLE IF NOT EXISTS organization_pending_invites (
+    id VARCHAR(36) PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id VARCHAR(36) NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL,
+    role VARCHAR(32) NOT NULL DEFAULT 'viewer',
+    invited_by VARCHAR(36) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_org_pending_invites_org_email ON organization_pending_invites(organization_id, email);
+CREATE INDEX IF NOT EXISTS idx_org_pending_invites_email ON organization_pending_invites(email);
+
+COMMENT ON TABLE organization_pending_invites IS 'Organization invites sent by email to users who have not signed up yet';
@@ -0,0 +1,2 @@
+-- Remove storage_quota column from organizations table
+ALTER TABLE organizations DROP COLUMN IF EXISTS storage_quota;
@@ -0,0 +1,2 @@
+-- Add storage_quota column to organizations table (max bytes used by shared knowledge bases, 0 = unlimited)
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS storage_quota BIGINT NOT NULL DEFAULT 0;
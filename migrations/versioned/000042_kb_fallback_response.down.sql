@@ -0,0 +1,2 @@
+-- Remove fallback_response column from knowledge_bases table
+ALTER TABLE knowledge_bases DROP COLUMN IF EXISTS fallback_response;
@@ -0,0 +1,2 @@
+-- Add fallback_response column to knowledge_bases table (answer used when nothing relevant is found, empty = global default)
+ALTER TABLE knowledge_bases ADD COLUMN IF NOT EXISTS fallback_response TEXT;
@@ -0,0 +1,21 @@
+-- Create api_keys table (tenant API keys restricted to a set of knowledge bases and a permission level)
+CREATE TABLE IF NOT EXISTS api_keys (
+    id VARCHAR(36) PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id BIGINT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    key_hash VARCHAR(64) NOT NULL,
+    key_prefix VARCHAR(16) NOT NULL,
+    knowledge_base_ids JSONB NOT NULL,
+    permission VARCHAR(32) NOT NULL DEFAULT 'viewer',
+    created_by VARCHAR(36),
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_keys_key_hash ON api_keys(key_hash);
+CREATE INDEX IF NOT EXISTS idx_api_keys_tenant_id ON api_keys(tenant_id);
+
+COMMENT ON TABLE api_keys IS 'Scoped API keys managed through /api-keys; only the SHA-256 hash of a key is stored';
+COMMENT ON COLUMN api_keys.knowledge_base_ids IS 'Knowledge bases the key may access';
+COMMENT ON COLUMN api_keys.permission IS 'Highest permission of the key on those knowledge bases: viewer or editor';
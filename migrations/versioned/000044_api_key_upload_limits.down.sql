@@ -0,0 +1,3 @@
+-- Remove per-key upload limits from api_keys
+ALTER TABLE api_keys DROP COLUMN IF EXISTS daily_upload_quota;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS upload_rate_limit;
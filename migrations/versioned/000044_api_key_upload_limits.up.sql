@@ -0,0 +1,6 @@
+-- Add per-key upload limits to api_keys (0 = use knowledge_base.api_key_upload_limits from the config)
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS upload_rate_limit INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS daily_upload_quota INTEGER NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN api_keys.upload_rate_limit IS 'Uploads per minute allowed to the key, 0 uses the configured default';
+COMMENT ON COLUMN api_keys.daily_upload_quota IS 'Uploads per day allowed to the key, 0 uses the configured default';
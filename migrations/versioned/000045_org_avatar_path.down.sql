@@ -0,0 +1,2 @@
+-- Remove avatar_path column from organizations table
+ALTER TABLE organizations DROP COLUMN IF EXISTS avatar_path;
@@ -0,0 +1,7 @@
+-- Keep uploaded organization avatars in their own column so that the avatar URL set by admins
+-- can never point at a storage path
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS avatar_path VARCHAR(512) NOT NULL DEFAULT '';
+
+-- Storage paths in avatar may have been set by any admin and are not trusted to be their uploads
+UPDATE organizations SET avatar = ''
+WHERE avatar LIKE 'local://%' OR avatar LIKE 'minio://%' OR avatar LIKE 'cos://%' OR avatar LIKE 'tos://%';
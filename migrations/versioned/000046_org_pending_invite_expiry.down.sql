@@ -0,0 +1,3 @@
+-- Remove expires_at column from organization_pending_invites table
+DROP INDEX IF EXISTS idx_org_pending_invites_expires_at;
+ALTER TABLE organization_pending_invites DROP COLUMN IF EXISTS expires_at;
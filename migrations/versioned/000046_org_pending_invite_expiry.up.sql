@@ -0,0 +1,9 @@
+-- Pending invites now expire and have to be accepted by the user who signs up with the email
+ALTER TABLE organization_pending_invites ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP WITH TIME ZONE;
+UPDATE organization_pending_invites SET expires_at = created_at + INTERVAL '14 days' WHERE expires_at IS NULL;
+ALTER TABLE organization_pending_invites ALTER COLUMN expires_at SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_org_pending_invites_expires_at ON organization_pending_invites(expires_at);
+
+-- Admin can no longer be granted through an invite to an unverified email
+DELETE FROM organization_pending_invites WHERE role = 'admin';
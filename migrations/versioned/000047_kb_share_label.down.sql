@@ -0,0 +1,3 @@
+-- Match dynamic share rules against knowledge base tags again
+ALTER TABLE kb_dynamic_shares RENAME COLUMN criteria_label TO criteria_tag;
+ALTER TABLE knowledge_bases DROP COLUMN IF EXISTS share_label;
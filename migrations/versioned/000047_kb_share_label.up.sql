@@ -0,0 +1,7 @@
+-- Dynamic share rules match a label only the owning tenant can set, instead of document tags that
+-- members of organizations a knowledge base is shared with can edit
+ALTER TABLE knowledge_bases ADD COLUMN IF NOT EXISTS share_label VARCHAR(128) NOT NULL DEFAULT '';
+ALTER TABLE kb_dynamic_shares RENAME COLUMN criteria_tag TO criteria_label;
+
+COMMENT ON COLUMN knowledge_bases.share_label IS 'Label matched by organization dynamic share rules, set by the owning tenant only';
+COMMENT ON COLUMN kb_dynamic_shares.criteria_label IS 'Share label matched case-insensitively against the knowledge bases of the source tenant';